@@ -8,19 +8,19 @@ require (
 	github.com/happy-sdk/happy/pkg/branding v0.1.1
 	github.com/happy-sdk/happy/pkg/cli/ansicolor v0.2.1
 	github.com/happy-sdk/happy/pkg/devel/testutils v0.7.0
+	github.com/happy-sdk/happy/pkg/diff v0.1.0
 	github.com/happy-sdk/happy/pkg/options v0.2.1
 	github.com/happy-sdk/happy/pkg/scheduling/cron v0.4.1
 	github.com/happy-sdk/happy/pkg/settings v0.3.3
 	github.com/happy-sdk/happy/pkg/strings/humanize v0.2.0
 	github.com/happy-sdk/happy/pkg/strings/slug v0.1.0
 	github.com/happy-sdk/happy/pkg/strings/textfmt v0.3.2
+	github.com/happy-sdk/happy/pkg/validate v0.1.0
 	github.com/happy-sdk/happy/pkg/vars v0.13.0
 	github.com/happy-sdk/happy/pkg/version v0.1.4
+	golang.org/x/mod v0.22.0
 	golang.org/x/sys v0.27.0
 	golang.org/x/text v0.20.0
 )
 
-require (
-	github.com/happy-sdk/happy/pkg/strings/bexp v1.4.0 // indirect
-	golang.org/x/mod v0.22.0 // indirect
-)
+require github.com/happy-sdk/happy/pkg/strings/bexp v1.4.0 // indirect
@@ -23,15 +23,22 @@ import (
 	"github.com/happy-sdk/happy/sdk/app"
 	"github.com/happy-sdk/happy/sdk/app/engine"
 	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/artifacts"
+	"github.com/happy-sdk/happy/sdk/build"
 	"github.com/happy-sdk/happy/sdk/cli"
 	"github.com/happy-sdk/happy/sdk/config"
 	"github.com/happy-sdk/happy/sdk/custom"
 	"github.com/happy-sdk/happy/sdk/datetime"
 	"github.com/happy-sdk/happy/sdk/devel"
+	"github.com/happy-sdk/happy/sdk/diagnostics"
 	"github.com/happy-sdk/happy/sdk/instance"
 	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/notify"
+	"github.com/happy-sdk/happy/sdk/paths"
 	"github.com/happy-sdk/happy/sdk/services"
 	"github.com/happy-sdk/happy/sdk/stats"
+	"github.com/happy-sdk/happy/sdk/telemetry"
+	"github.com/happy-sdk/happy/sdk/update"
 	"golang.org/x/text/language"
 )
 
@@ -50,14 +57,21 @@ type Settings struct {
 	License        settings.String `key:"app.license" default:"NOASSERTION" desc:"Application license"`
 
 	// Application settings
-	Engine   engine.Settings   `key:"app.engine"`
-	CLI      cli.Settings      `key:"app.cli"`
-	Config   config.Settings   `key:"app.config"`
-	DateTime datetime.Settings `key:"app.datetime"`
-	Instance instance.Settings `key:"app.instance"`
-	Logging  logging.Settings  `key:"app.logging"`
-	Services services.Settings `key:"app.services"`
-	Stats    stats.Settings    `key:"app.stats"`
+	Engine      engine.Settings      `key:"app.engine"`
+	CLI         cli.Settings         `key:"app.cli"`
+	Config      config.Settings      `key:"app.config"`
+	DateTime    datetime.Settings    `key:"app.datetime"`
+	Instance    instance.Settings    `key:"app.instance"`
+	Logging     logging.Settings     `key:"app.logging"`
+	Services    services.Settings    `key:"app.services"`
+	Stats       stats.Settings       `key:"app.stats"`
+	Diagnostics diagnostics.Settings `key:"app.diagnostics"`
+	Update      update.Settings      `key:"app.update"`
+	Artifacts   artifacts.Settings   `key:"app.artifacts"`
+	Build       build.Settings       `key:"app.build"`
+	Telemetry   telemetry.Settings   `key:"app.telemetry"`
+	Notify      notify.Settings      `key:"app.notify"`
+	FS          paths.Settings       `key:"app.fs"`
 
 	Devel devel.Settings `key:"app.devel"`
 
@@ -85,3 +85,35 @@ func (c Color) Err() error {
 func (c Color) Valid() bool {
 	return c.valid
 }
+
+func TestHyperlink(t *testing.T) {
+	want := "\033]8;;https://example.com\033\\example\033]8;;\033\\"
+	if got := Hyperlink("https://example.com", "example"); got != want {
+		t.Errorf("Hyperlink() = %q, want %q", got, want)
+	}
+}
+
+func TestHyperlinksEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{"Unknown", map[string]string{"TERM": "xterm-256color"}, false},
+		{"Dumb", map[string]string{"TERM": "dumb", "WT_SESSION": "1"}, false},
+		{"CI", map[string]string{"CI": "true", "TERM_PROGRAM": "vscode"}, false},
+		{"Windows Terminal", map[string]string{"WT_SESSION": "1"}, true},
+		{"VTE", map[string]string{"VTE_VERSION": "6003"}, true},
+		{"Old VTE", map[string]string{"VTE_VERSION": "4600"}, false},
+		{"iTerm", map[string]string{"TERM_PROGRAM": "iTerm.app"}, true},
+		{"Apple Terminal", map[string]string{"TERM_PROGRAM": "Apple_Terminal"}, false},
+		{"Kitty", map[string]string{"TERM": "xterm-kitty"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hyperlinksEnv(func(k string) string { return tt.env[k] }); got != tt.want {
+				t.Errorf("hyperlinksEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
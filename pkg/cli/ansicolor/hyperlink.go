@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package ansicolor
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Hyperlink returns text wrapped in OSC 8 escape sequence linking to url.
+// Terminals without OSC 8 support print text only, but some print
+// escape sequence as is, use HyperlinksSupported before using it.
+func Hyperlink(url, text string) string {
+	return "\033]8;;" + url + "\033\\" + text + "\033]8;;\033\\"
+}
+
+// HyperlinksSupported reports whether terminal f is attached to supports
+// OSC 8 hyperlinks. Support is detected from environment of known
+// terminals, FORCE_HYPERLINK environment variable overrides detection,
+// "0" disables and any other value enables hyperlinks.
+func HyperlinksSupported(f *os.File) bool {
+	if force, ok := os.LookupEnv("FORCE_HYPERLINK"); ok {
+		return force != "0"
+	}
+	if f == nil {
+		return false
+	}
+	if info, err := f.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	return hyperlinksEnv(os.Getenv)
+}
+
+func hyperlinksEnv(getenv func(string) string) bool {
+	term := getenv("TERM")
+	if term == "dumb" || getenv("CI") != "" {
+		return false
+	}
+	if getenv("WT_SESSION") != "" || getenv("DOMTERM") != "" || getenv("KONSOLE_VERSION") != "" {
+		return true
+	}
+	if vte, err := strconv.Atoi(getenv("VTE_VERSION")); err == nil && vte >= 5000 {
+		return true
+	}
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "Hyper", "ghostty", "Tabby", "rio":
+		return true
+	}
+	for _, t := range []string{"xterm-kitty", "alacritty", "foot", "wezterm", "xterm-ghostty"} {
+		if strings.HasPrefix(term, t) {
+			return true
+		}
+	}
+	return false
+}
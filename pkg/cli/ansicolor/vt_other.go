@@ -0,0 +1,13 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+//go:build !windows
+
+package ansicolor
+
+// EnableVirtualTerminal enables ANSI escape sequence processing
+// on Windows consoles, on other platforms it is no-op.
+func EnableVirtualTerminal() error {
+	return nil
+}
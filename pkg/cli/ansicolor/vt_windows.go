@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package ansicolor
+
+import "syscall"
+
+const enableVirtualTerminalProcessing = 0x0004
+
+var (
+	kernel32           = syscall.NewLazyDLL("kernel32.dll")
+	procSetConsoleMode = kernel32.NewProc("SetConsoleMode")
+)
+
+func init() {
+	// Windows 10+ consoles support ANSI sequences once VT processing is enabled.
+	_ = EnableVirtualTerminal()
+}
+
+// EnableVirtualTerminal enables ANSI escape sequence processing
+// for stdout and stderr console handles.
+func EnableVirtualTerminal() error {
+	var firstErr error
+	for _, h := range []syscall.Handle{syscall.Stdout, syscall.Stderr} {
+		var mode uint32
+		if err := syscall.GetConsoleMode(h, &mode); err != nil {
+			// not a console e.g. redirected to file
+			continue
+		}
+		if mode&enableVirtualTerminalProcessing != 0 {
+			continue
+		}
+		r, _, err := procSetConsoleMode.Call(uintptr(h), uintptr(mode|enableVirtualTerminalProcessing))
+		if r == 0 && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package diff
+
+import (
+	"os"
+
+	"github.com/happy-sdk/happy/pkg/cli/ansicolor"
+)
+
+var (
+	styleHeader   = ansicolor.Style{Format: ansicolor.Bold}
+	styleHunk     = ansicolor.Style{FG: ansicolor.RGB(0, 175, 215)}
+	styleInsert   = ansicolor.Style{FG: ansicolor.RGB(76, 175, 80)}
+	styleDelete   = ansicolor.Style{FG: ansicolor.RGB(213, 0, 0)}
+	styleModified = ansicolor.Style{FG: ansicolor.RGB(255, 152, 0)}
+)
+
+func plain(_, text string) string {
+	return text
+}
+
+func colorize(kind, text string) string {
+	switch kind {
+	case "header":
+		return styleHeader.String(text)
+	case "hunk":
+		return styleHunk.String(text)
+	case "+":
+		return styleInsert.String(text)
+	case "-":
+		return styleDelete.String(text)
+	case "~":
+		return styleModified.String(text)
+	}
+	return text
+}
+
+// ColorEnabled reports whether output to f should be colorized, f must
+// be terminal and NO_COLOR environment variable unset.
+func ColorEnabled(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
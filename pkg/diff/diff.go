@@ -0,0 +1,307 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package diff computes line diffs of text rendered as unified diffs and
+// structured diffs of key value sets such as settings and vars.Map.
+// Both can be rendered colorized for terminals or encoded as JSON.
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var Error = errors.New("diff")
+
+// DefaultContext is number of unchanged lines shown around changes.
+const DefaultContext = 3
+
+// Op is edit operation.
+type Op uint8
+
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+func (op Op) String() string {
+	switch op {
+	case Insert:
+		return "+"
+	case Delete:
+		return "-"
+	}
+	return " "
+}
+
+// MarshalText encodes op as "equal", "insert" or "delete".
+func (op Op) MarshalText() ([]byte, error) {
+	switch op {
+	case Equal:
+		return []byte("equal"), nil
+	case Insert:
+		return []byte("insert"), nil
+	case Delete:
+		return []byte("delete"), nil
+	}
+	return nil, fmt.Errorf("%w: invalid op %d", Error, op)
+}
+
+// Line is line of diff, Text does not include line terminator.
+type Line struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Hunk is group of changes with surrounding context lines. Line
+// numbers are 1 based, start is line before hunk when hunk is empty.
+type Hunk struct {
+	OldStart int    `json:"old_start"`
+	OldLines int    `json:"old_lines"`
+	NewStart int    `json:"new_start"`
+	NewLines int    `json:"new_lines"`
+	Lines    []Line `json:"lines"`
+}
+
+// Header returns @@ -l,s +l,s @@ range header of hunk.
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+}
+
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// File is line diff of two versions of file.
+type File struct {
+	OldName string `json:"old_name"`
+	NewName string `json:"new_name"`
+	Hunks   []Hunk `json:"hunks"`
+}
+
+// Changed reports whether versions differ.
+func (f *File) Changed() bool {
+	return len(f.Hunks) > 0
+}
+
+// String returns unified diff, empty when versions are equal.
+func (f *File) String() string {
+	return f.render(plain)
+}
+
+// Colorized returns unified diff with ANSI colors.
+func (f *File) Colorized() string {
+	return f.render(colorize)
+}
+
+func (f *File) render(style func(kind, text string) string) string {
+	if !f.Changed() {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(style("header", "--- "+f.OldName) + "\n")
+	b.WriteString(style("header", "+++ "+f.NewName) + "\n")
+	for _, h := range f.Hunks {
+		b.WriteString(style("hunk", h.Header()) + "\n")
+		for _, l := range h.Lines {
+			b.WriteString(style(l.Op.String(), l.Op.String()+l.Text) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// Text returns line diff of old and new text with DefaultContext
+// lines of context.
+func Text(oldName, newName, old, new string) *File {
+	return TextContext(oldName, newName, old, new, DefaultContext)
+}
+
+// TextContext is like Text with n lines of context.
+func TextContext(oldName, newName, old, new string, n int) *File {
+	return &File{
+		OldName: oldName,
+		NewName: newName,
+		Hunks:   hunks(Lines(splitLines(old), splitLines(new)), n),
+	}
+}
+
+// Lines returns shortest edit script turning a into b.
+func Lines(a, b []string) []Line {
+	var lines []Line
+	for _, e := range myers(a, b) {
+		switch e.op {
+		case Equal:
+			lines = append(lines, Line{Op: Equal, Text: a[e.a]})
+		case Delete:
+			lines = append(lines, Line{Op: Delete, Text: a[e.a]})
+		case Insert:
+			lines = append(lines, Line{Op: Insert, Text: b[e.b]})
+		}
+	}
+	return lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+type edit struct {
+	op   Op
+	a, b int
+}
+
+// myers implements Myers O(ND) difference algorithm.
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// backtrack, trace[d] holds furthest reaching paths before round d
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{op: Equal, a: x, b: y})
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{op: Insert, a: prevX, b: prevY})
+			} else {
+				edits = append(edits, edit{op: Delete, a: prevX, b: prevY})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// hunks groups lines into hunks with n lines of context.
+func hunks(lines []Line, n int) []Hunk {
+	if n < 0 {
+		n = 0
+	}
+	var (
+		out      []Hunk
+		cur      *Hunk
+		oldLine  = 1
+		newLine  = 1
+		trailing int
+	)
+	for i, l := range lines {
+		if l.Op == Equal {
+			if cur != nil {
+				// close hunk when no change follows within context
+				if trailing >= n && !changeWithin(lines[i:], n+1) {
+					out = append(out, *cur)
+					cur = nil
+				} else {
+					cur.Lines = append(cur.Lines, l)
+					cur.OldLines++
+					cur.NewLines++
+					trailing++
+				}
+			}
+			oldLine++
+			newLine++
+			continue
+		}
+		if cur == nil {
+			start := i - n
+			if start < 0 {
+				start = 0
+			}
+			for start < i && lines[start].Op != Equal {
+				start++
+			}
+			ctx := i - start
+			cur = &Hunk{OldStart: oldLine - ctx, NewStart: newLine - ctx}
+			for _, c := range lines[start:i] {
+				cur.Lines = append(cur.Lines, c)
+				cur.OldLines++
+				cur.NewLines++
+			}
+		}
+		trailing = 0
+		cur.Lines = append(cur.Lines, l)
+		if l.Op == Delete {
+			cur.OldLines++
+			oldLine++
+		} else {
+			cur.NewLines++
+			newLine++
+		}
+	}
+	if cur != nil {
+		out = append(out, *cur)
+	}
+	// empty ranges start at line before change
+	for i := range out {
+		if out[i].OldLines == 0 {
+			out[i].OldStart--
+		}
+		if out[i].NewLines == 0 {
+			out[i].NewStart--
+		}
+	}
+	return out
+}
+
+func changeWithin(lines []Line, n int) bool {
+	for i := 0; i < n && i < len(lines); i++ {
+		if lines[i].Op != Equal {
+			return true
+		}
+	}
+	return false
+}
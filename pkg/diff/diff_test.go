@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package diff
+
+import (
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/vars"
+)
+
+func TestText(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	want := `--- old
++++ new
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`
+	if got := Text("old", "new", old, new).String(); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+	if f := Text("old", "new", old, old); f.Changed() {
+		t.Errorf("equal text reported as changed:\n%s", f)
+	}
+}
+
+func TestTextEmpty(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := Text("a", "b", "", "x\ny\n").String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	want = "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-x\n-y\n"
+	if got := Text("a", "b", "x\ny\n", "").String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMaps(t *testing.T) {
+	var old, new vars.Map
+	_ = old.Store("app.name", "one")
+	_ = old.Store("app.debug", false)
+	_ = new.Store("app.name", "two")
+	_ = new.Store("app.port", 8080)
+
+	want := `- app.debug = "false"
+~ app.name = "one" -> "two"
++ app.port = "8080"
+`
+	changes := Maps(&old, &new)
+	if got := changes.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	data, err := changes.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `[{"kind":"removed","key":"app.debug","old":"false"},{"kind":"modified","key":"app.name","old":"one","new":"two"},{"kind":"added","key":"app.port","new":"8080"}]`
+	if string(data) != wantJSON {
+		t.Errorf("got %s, want %s", data, wantJSON)
+	}
+}
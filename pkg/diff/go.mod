@@ -0,0 +1,13 @@
+module github.com/happy-sdk/happy/pkg/diff
+
+go 1.22
+
+require (
+	github.com/happy-sdk/happy/pkg/cli/ansicolor v0.2.1
+	github.com/happy-sdk/happy/pkg/vars v0.13.0
+)
+
+require (
+	github.com/happy-sdk/happy/pkg/strings/bexp v1.4.0 // indirect
+	golang.org/x/text v0.20.0 // indirect
+)
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/vars"
+)
+
+// Kind is kind of value change.
+type Kind uint8
+
+const (
+	Added Kind = iota + 1
+	Removed
+	Modified
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return "unknown"
+}
+
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Change is change of single value.
+type Change struct {
+	Kind Kind   `json:"kind"`
+	Key  string `json:"key"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// Changes is structured diff of key value sets ordered by key.
+type Changes []Change
+
+// Values returns changes turning old into new.
+func Values(old, new map[string]string) Changes {
+	var changes Changes
+	for key, o := range old {
+		n, ok := new[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: Removed, Key: key, Old: o})
+		case n != o:
+			changes = append(changes, Change{Kind: Modified, Key: key, Old: o, New: n})
+		}
+	}
+	for key, n := range new {
+		if _, ok := old[key]; !ok {
+			changes = append(changes, Change{Kind: Added, Key: key, New: n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// Maps returns changes turning old into new, values are compared by
+// their string representation. Nil maps are treated as empty.
+func Maps(old, new *vars.Map) Changes {
+	return Values(mapValues(old), mapValues(new))
+}
+
+func mapValues(m *vars.Map) map[string]string {
+	values := make(map[string]string)
+	if m == nil {
+		return values
+	}
+	m.Range(func(v vars.Variable) bool {
+		values[v.Name()] = v.String()
+		return true
+	})
+	return values
+}
+
+// String returns changes one per line prefixed with +, - or ~.
+func (c Changes) String() string {
+	return c.render(plain)
+}
+
+// Colorized returns changes with ANSI colors.
+func (c Changes) Colorized() string {
+	return c.render(colorize)
+}
+
+// JSON returns changes encoded as JSON array.
+func (c Changes) JSON() ([]byte, error) {
+	if c == nil {
+		c = Changes{}
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return data, nil
+}
+
+func (c Changes) render(style func(kind, text string) string) string {
+	var b strings.Builder
+	for _, ch := range c {
+		switch ch.Kind {
+		case Added:
+			b.WriteString(style("+", fmt.Sprintf("+ %s = %q", ch.Key, ch.New)) + "\n")
+		case Removed:
+			b.WriteString(style("-", fmt.Sprintf("- %s = %q", ch.Key, ch.Old)) + "\n")
+		case Modified:
+			b.WriteString(style("~", fmt.Sprintf("~ %s = %q -> %q", ch.Key, ch.Old, ch.New)) + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/vars"
+)
+
+var (
+	ErrOptionNotFound = fmt.Errorf("%w: not found", ErrOption)
+	ErrOptionType     = fmt.Errorf("%w: invalid type", ErrOption)
+)
+
+// Source is option source such as *Options or application session.
+type Source interface {
+	Has(key string) bool
+	Get(key string) vars.Variable
+}
+
+// Value is type GetAs can convert option values to.
+type Value interface {
+	string | bool | int | int64 | uint | uint64 | float64 | time.Duration
+}
+
+// GetOr returns option key or variable holding def when option does
+// not exist.
+func (opts *Options) GetOr(key string, def any) vars.Variable {
+	if v, ok := opts.Load(key); ok {
+		return v
+	}
+	v, err := vars.New(key, def, true)
+	if err != nil {
+		return emptyStringVariable
+	}
+	return v
+}
+
+// MustGet returns option key and panics when option does not exist,
+// use it only for options guaranteed by application setup.
+func (opts *Options) MustGet(key string) vars.Variable {
+	v, ok := opts.Load(key)
+	if !ok {
+		panic(fmt.Errorf("%w: %s", ErrOptionNotFound, key))
+	}
+	return v
+}
+
+// GetAs returns value of key converted to T, it returns
+// ErrOptionNotFound when key does not exist and ErrOptionType when
+// value can not be converted to T.
+func GetAs[T Value](src Source, key string) (T, error) {
+	var zero T
+	if !src.Has(key) {
+		return zero, fmt.Errorf("%w: %s", ErrOptionNotFound, key)
+	}
+	val := src.Get(key).Value()
+
+	var (
+		res any
+		err error
+	)
+	switch any(zero).(type) {
+	case string:
+		res = val.String()
+	case bool:
+		res, err = val.Bool()
+	case int:
+		res, err = val.Int()
+	case int64:
+		res, err = val.Int64()
+	case uint:
+		res, err = val.Uint()
+	case uint64:
+		res, err = val.Uint64()
+	case float64:
+		res, err = val.Float64()
+	case time.Duration:
+		res, err = val.Duration()
+	}
+	if err != nil {
+		return zero, fmt.Errorf("%w: %s: %s", ErrOptionType, key, err.Error())
+	}
+	return res.(T), nil
+}
+
+// GetAsOr returns value of key converted to T or def when key does not
+// exist or can not be converted.
+func GetAsOr[T Value](src Source, key string, def T) T {
+	v, err := GetAs[T](src, key)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// MustGetAs returns value of key converted to T and panics on error,
+// use it only for options guaranteed by application setup.
+func MustGetAs[T Value](src Source, key string) T {
+	v, err := GetAs[T](src, key)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
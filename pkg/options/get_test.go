@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package options
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newGetTestOptions(t *testing.T) *Options {
+	t.Helper()
+	opts, err := New("get", []Spec{
+		NewOption("name", "happy", "name", KindConfig, NoopValueValidator),
+		NewOption("enabled", true, "enabled", KindConfig, NoopValueValidator),
+		NewOption("count", 42, "count", KindConfig, NoopValueValidator),
+		NewOption("ratio", 0.5, "ratio", KindConfig, NoopValueValidator),
+		NewOption("timeout", "1m30s", "timeout", KindConfig, NoopValueValidator),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return opts
+}
+
+func testGetAs[T Value](t *testing.T, src Source, key string, want T, wantErr error) {
+	t.Helper()
+	got, err := GetAs[T](src, key)
+	if wantErr != nil {
+		if !errors.Is(err, wantErr) {
+			t.Errorf("%s: expected error %v, got %v", key, wantErr, err)
+		}
+		if got != want {
+			t.Errorf("%s: expected zero value %v on error, got %v", key, want, got)
+		}
+		return
+	}
+	if err != nil {
+		t.Errorf("%s: unexpected error: %v", key, err)
+		return
+	}
+	if got != want {
+		t.Errorf("%s: expected %v, got %v", key, want, got)
+	}
+}
+
+func TestGetAs(t *testing.T) {
+	opts := newGetTestOptions(t)
+	tests := []struct {
+		name string
+		fn   func(t *testing.T)
+	}{
+		{"string", func(t *testing.T) { testGetAs(t, opts, "name", "happy", nil) }},
+		{"string from int", func(t *testing.T) { testGetAs(t, opts, "count", "42", nil) }},
+		{"bool", func(t *testing.T) { testGetAs(t, opts, "enabled", true, nil) }},
+		{"int", func(t *testing.T) { testGetAs(t, opts, "count", 42, nil) }},
+		{"int64", func(t *testing.T) { testGetAs(t, opts, "count", int64(42), nil) }},
+		{"uint", func(t *testing.T) { testGetAs(t, opts, "count", uint(42), nil) }},
+		{"uint64", func(t *testing.T) { testGetAs(t, opts, "count", uint64(42), nil) }},
+		{"float64", func(t *testing.T) { testGetAs(t, opts, "ratio", 0.5, nil) }},
+		{"duration", func(t *testing.T) { testGetAs(t, opts, "timeout", 90*time.Second, nil) }},
+		{"missing", func(t *testing.T) { testGetAs(t, opts, "missing", "", ErrOptionNotFound) }},
+		{"bool from string", func(t *testing.T) { testGetAs(t, opts, "name", false, ErrOptionType) }},
+		{"int from string", func(t *testing.T) { testGetAs(t, opts, "name", 0, ErrOptionType) }},
+		{"duration from string", func(t *testing.T) { testGetAs(t, opts, "name", time.Duration(0), ErrOptionType) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, tt.fn)
+	}
+}
+
+func TestGetAsOr(t *testing.T) {
+	opts := newGetTestOptions(t)
+	tests := []struct {
+		key  string
+		def  int
+		want int
+	}{
+		{"count", 1, 42},
+		{"missing", 1, 1},
+		{"name", 2, 2},
+	}
+	for _, tt := range tests {
+		if got := GetAsOr(opts, tt.key, tt.def); got != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.key, tt.want, got)
+		}
+	}
+}
+
+func TestGetOr(t *testing.T) {
+	opts := newGetTestOptions(t)
+	tests := []struct {
+		key  string
+		def  any
+		want string
+	}{
+		{"name", "default", "happy"},
+		{"missing", "default", "default"},
+		{"missing", 7, "7"},
+	}
+	for _, tt := range tests {
+		if got := opts.GetOr(tt.key, tt.def).String(); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.key, tt.want, got)
+		}
+	}
+}
+
+func TestMustGet(t *testing.T) {
+	opts := newGetTestOptions(t)
+	tests := []struct {
+		name      string
+		fn        func()
+		wantPanic bool
+	}{
+		{"MustGet", func() { opts.MustGet("name") }, false},
+		{"MustGet missing", func() { opts.MustGet("missing") }, true},
+		{"MustGetAs", func() { MustGetAs[int](opts, "count") }, false},
+		{"MustGetAs missing", func() { MustGetAs[int](opts, "missing") }, true},
+		{"MustGetAs invalid type", func() { MustGetAs[bool](opts, "name") }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if tt.wantPanic {
+					err, ok := r.(error)
+					if !ok || !errors.Is(err, ErrOption) {
+						t.Errorf("expected panic with option error, got %v", r)
+					}
+					return
+				}
+				if r != nil {
+					t.Errorf("unexpected panic: %v", r)
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
@@ -8,6 +8,7 @@ package options
 import (
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/happy-sdk/happy/pkg/vars"
 )
@@ -17,10 +18,21 @@ type (
 	// Options is general collection of options
 	// attached to specific application component.
 	Options struct {
+		*optionSet
+		// owner is name writes are attributed to, see Owned.
+		owner string
+	}
+
+	// optionSet is state shared by Options and its owned views.
+	optionSet struct {
+		mu     sync.RWMutex
 		name   string
 		db     vars.Map
 		config map[string]Spec
 		sealed bool
+		guards []WriteGuard
+		// aliases maps deprecated keys to current keys.
+		aliases map[string]string
 	}
 
 	// Spec holds specification for given option.
@@ -42,6 +54,13 @@ type (
 	// as radonly if validation succeeds.
 	ValueValidator func(key string, val vars.Value) error
 
+	// WriteGuard is called before option is set, it returns error
+	// when owner is not allowed to set option key. Owner is name given
+	// to Owned view options are set through, it is empty when options
+	// are set directly. Guard is called while options are locked
+	// within Update, so it must not call methods of the options.
+	WriteGuard func(owner, key string) error
+
 	Arg struct {
 		key   string
 		value any
@@ -82,7 +101,7 @@ func NewOption(key string, dval any, desc string, kind Kind, vfunc ValueValidato
 // New returns new named options set.
 func New(name string, specs []Spec) (*Options, error) {
 	opts := &Options{
-		name: name,
+		optionSet: &optionSet{name: name},
 	}
 	for _, spec := range specs {
 		if err := opts.Add(spec); err != nil {
@@ -94,13 +113,59 @@ func New(name string, specs []Spec) (*Options, error) {
 
 // Accepts reports whether given option key is accepted by Options.
 func (opts *Options) Accepts(key string) bool {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	return opts.accepts(key)
+}
+
+// Owned returns view of options which writes are attributed to owner,
+// write guards receive owner of options option is set through. View
+// shares values, specifications and guards with opts.
+func (opts *Options) Owned(owner string) *Options {
+	return &Options{optionSet: opts.optionSet, owner: owner}
+}
+
+// Owner returns name writes through opts are attributed to, empty
+// unless opts were returned by Owned.
+func (opts *Options) Owner() string {
+	return opts.owner
+}
+
+// Alias makes deprecated key refer to option key, e.g. to keep old key
+// working after option was renamed. Options are read and set through
+// alias as through key.
+func (opts *Options) Alias(alias, key string) error {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	if _, ok := opts.config[key]; !ok {
+		return fmt.Errorf("%w(%s): alias %s of unknown key %s", ErrOption, opts.name, alias, key)
+	}
+	if _, ok := opts.config[alias]; ok {
+		return fmt.Errorf("%w(%s): alias %s is option key", ErrOption, opts.name, alias)
+	}
+	if opts.aliases == nil {
+		opts.aliases = make(map[string]string)
+	}
+	opts.aliases[alias] = key
+	return nil
+}
+
+// resolve returns key alias refers to or key itself, opts.mu must be held.
+func (opts *Options) resolve(key string) string {
+	if k, ok := opts.aliases[key]; ok {
+		return k
+	}
+	return key
+}
+
+func (opts *Options) accepts(key string) bool {
 	if opts.config == nil {
 		return false
 	}
 	if _, ok := opts.config["*"]; ok {
 		return true
 	}
-	_, ok := opts.config[key]
+	_, ok := opts.config[opts.resolve(key)]
 	return ok
 }
 
@@ -110,7 +175,9 @@ func (opts *Options) Name() string {
 }
 
 func (opts *Options) Describe(key string) string {
-	c, ok := opts.config[key]
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	c, ok := opts.config[opts.resolve(key)]
 	if !ok {
 		return ""
 	}
@@ -120,6 +187,9 @@ func (opts *Options) Describe(key string) string {
 var emptyStringVariable, _ = vars.New("empty", "", true)
 
 func (opts *Options) Get(key string) vars.Variable {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	key = opts.resolve(key)
 	if opts.db.Has(key) {
 		return opts.db.Get(key)
 	}
@@ -127,21 +197,30 @@ func (opts *Options) Get(key string) vars.Variable {
 }
 
 func (opts *Options) Load(key string) (vars.Variable, bool) {
-	return opts.db.Load(key)
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	return opts.db.Load(opts.resolve(key))
 }
 
+// Range calls fn for each option, fn may set options.
 func (opts *Options) Range(fn func(opt Option) bool) {
-	opts.db.Range(func(v vars.Variable) bool {
-		return fn(Option{val: v})
-	})
+	opts.mu.RLock()
+	all := opts.db.All()
+	opts.mu.RUnlock()
+	for _, v := range all {
+		if !fn(Option{val: v}) {
+			return
+		}
+	}
 }
 
 func (opts *Options) set(key string, value any, override bool) error {
 	if key == "*" {
 		return nil
 	}
+	key = opts.resolve(key)
 
-	if !opts.Accepts(key) {
+	if !opts.accepts(key) {
 		return fmt.Errorf(
 			"%w: %s does not accept option %s",
 			ErrOption,
@@ -198,20 +277,68 @@ func (opts *Options) set(key string, value any, override bool) error {
 	return opts.db.StoreReadOnly(key, val, cnf.kind&KindReadOnly != 0)
 }
 
+// Set sets option key, write guards are called before options are
+// locked so that they can read options.
 func (opts *Options) Set(key string, value any) error {
+	opts.mu.RLock()
+	key = opts.resolve(key)
+	guards := opts.guards
+	opts.mu.RUnlock()
+	if err := callGuards(guards, opts.owner, key); err != nil {
+		return err
+	}
+
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
 	return opts.set(key, value, !opts.sealed)
 }
 
+// SetWriteGuard sets guard which is consulted before option is set
+// with Set or within Update, it replaces all previously added guards.
+// Default values set by Add and Seal are not guarded.
+func (opts *Options) SetWriteGuard(guard WriteGuard) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.guards = []WriteGuard{guard}
+}
+
+// AddWriteGuard adds guard which is consulted before option is set
+// with Set or within Update after previously added guards.
+func (opts *Options) AddWriteGuard(guard WriteGuard) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.guards = append(opts.guards, guard)
+}
+
+// callGuards returns first error reported by guards.
+func callGuards(guards []WriteGuard, owner, key string) error {
+	for _, guard := range guards {
+		if guard == nil {
+			continue
+		}
+		if err := guard(owner, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Has reports whether options has given key
 func (opts *Options) Has(key string) bool {
-	return opts.db.Has(key)
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	return opts.db.Has(opts.resolve(key))
 }
 
 func (opts *Options) Len() int {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
 	return opts.db.Len()
 }
 
 func (opts *Options) Add(spec Spec) error {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
 	if opts.sealed {
 		return fmt.Errorf("%w: can not add %s to already sealed %s options", ErrOption, spec.key, opts.name)
 	}
@@ -226,10 +353,12 @@ func (opts *Options) Add(spec Spec) error {
 		return fmt.Errorf("%w(%s): duplicated key %s", ErrOption, opts.name, key)
 	}
 	opts.config[key] = spec
-	return opts.Set(spec.key, spec.value)
+	return opts.set(spec.key, spec.value, true)
 }
 
 func (opts *Options) WithPrefix(prefix string) *vars.Map {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
 	return opts.db.ExtractWithPrefix(prefix)
 }
 
@@ -237,6 +366,8 @@ func (opts *Options) WithPrefix(prefix string) *vars.Map {
 // It will set default values for options that are not set.
 // It will return error if options are already sealed.
 func (opts *Options) Seal() error {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
 	if opts.sealed {
 		return fmt.Errorf("%w: %s already sealed", ErrOption, opts.name)
 	}
@@ -245,7 +376,7 @@ func (opts *Options) Seal() error {
 			continue
 		}
 		if !opts.db.Has(key) {
-			if err := opts.Set(key, cnf.value); err != nil {
+			if err := opts.set(key, cnf.value, true); err != nil {
 				return err
 			}
 		}
@@ -299,10 +430,19 @@ func (o Option) Kind() vars.Kind {
 }
 
 func MergeOptions(dest, src *Options) error {
-	if dest.sealed {
+	dest.mu.RLock()
+	sealed := dest.sealed
+	dest.mu.RUnlock()
+	if sealed {
 		return fmt.Errorf("%w: can not add %s options to sealed destination %s", ErrOption, src.name, dest.name)
 	}
+	src.mu.RLock()
+	specs := make([]Spec, 0, len(src.config))
 	for _, spec := range src.config {
+		specs = append(specs, spec)
+	}
+	src.mu.RUnlock()
+	for _, spec := range specs {
 		spec.key = src.name + "." + spec.key
 		if err := dest.Add(spec); err != nil {
 			return err
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentAddSeal(t *testing.T) {
+	opts, err := New("concurrent", []Spec{
+		NewOption("a", 1, "a", KindConfig, NoopValueValidator),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if err := opts.Add(NewOption(fmt.Sprintf("k%d", i), i, "k", KindConfig, nil)); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		if err := opts.Seal(); err != nil {
+			t.Error(err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = opts.Set("a", i)
+			_ = opts.Get("a")
+			_ = opts.Accepts(fmt.Sprintf("k%d", i))
+			_ = opts.Describe("a")
+		}
+	}()
+	wg.Wait()
+
+	if err := opts.Add(NewOption("late", 1, "late", KindConfig, nil)); err == nil {
+		t.Fatal("adding option to sealed options must fail")
+	}
+	if got := opts.Get("k99").Int(); got != 99 {
+		t.Fatalf("k99 = %d, want 99", got)
+	}
+}
+
+func TestWriteGuardReadsOptions(t *testing.T) {
+	opts, err := New("guard", []Spec{
+		NewOption("lock", false, "lock", KindConfig, NoopValueValidator),
+		NewOption("a", 1, "a", KindConfig, NoopValueValidator),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := opts.Seal(); err != nil {
+		t.Fatal(err)
+	}
+	errLocked := errors.New("locked")
+	opts.AddWriteGuard(func(owner, key string) error {
+		if key == "a" && opts.Get("lock").Bool() {
+			return errLocked
+		}
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		if err := opts.Set("a", 2); err != nil {
+			done <- err
+			return
+		}
+		if err := opts.Set("lock", true); err != nil {
+			done <- err
+			return
+		}
+		done <- opts.Set("a", 3)
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, errLocked) {
+			t.Fatalf("want %v got %v", errLocked, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("guard reading options deadlocked Set")
+	}
+	if got := opts.Get("a").Int(); got != 2 {
+		t.Fatalf("want a=2 got %d", got)
+	}
+}
+
+func TestOwned(t *testing.T) {
+	opts, err := New("owned", []Spec{
+		NewOption("a.x", 1, "x", KindConfig, NoopValueValidator),
+		NewOption("b.x", 1, "x", KindConfig, NoopValueValidator),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := opts.Seal(); err != nil {
+		t.Fatal(err)
+	}
+	var owners []string
+	opts.AddWriteGuard(func(owner, key string) error {
+		owners = append(owners, owner)
+		if owner != "" && !strings.HasPrefix(key, owner+".") {
+			return fmt.Errorf("%s can not set %s", owner, key)
+		}
+		return nil
+	})
+
+	a := opts.Owned("a")
+	if a.Owner() != "a" || opts.Owner() != "" {
+		t.Fatalf("unexpected owners %q and %q", a.Owner(), opts.Owner())
+	}
+	if err := a.Set("a.x", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Set("b.x", 2); err == nil {
+		t.Fatal("owner a must not set b.x")
+	}
+	if err := a.Update(func(tx OptionsTx) error { return tx.Set("b.x", 3) }); err == nil {
+		t.Fatal("owner a must not set b.x within Update")
+	}
+	if err := opts.Set("b.x", 4); err != nil {
+		t.Fatal(err)
+	}
+	if got := opts.Get("a.x").Int(); got != 2 {
+		t.Fatalf("owned view must share values, want 2 got %d", got)
+	}
+	if got := a.Get("b.x").Int(); got != 4 {
+		t.Fatalf("owned view must share values, want 4 got %d", got)
+	}
+	if want, got := "a,a,a,", strings.Join(owners, ","); want != got {
+		t.Fatalf("want owners %q got %q", want, got)
+	}
+}
+
+func TestAlias(t *testing.T) {
+	opts, err := New("alias", []Spec{
+		NewOption("addon.a.x", 1, "x", KindConfig, NoopValueValidator),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := opts.Alias("a.x", "addon.a.x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := opts.Alias("b.x", "addon.b.x"); err == nil {
+		t.Fatal("alias of unknown key must fail")
+	}
+	if err := opts.Alias("addon.a.x", "addon.a.x"); err == nil {
+		t.Fatal("alias must not shadow option key")
+	}
+	if err := opts.Seal(); err != nil {
+		t.Fatal(err)
+	}
+
+	var guarded []string
+	opts.AddWriteGuard(func(owner, key string) error {
+		guarded = append(guarded, key)
+		return nil
+	})
+	if !opts.Has("a.x") || !opts.Accepts("a.x") || opts.Describe("a.x") != "x" {
+		t.Fatal("alias must refer to option")
+	}
+	if err := opts.Set("a.x", 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := opts.Get("addon.a.x").Int(); got != 2 {
+		t.Fatalf("set through alias, want 2 got %d", got)
+	}
+	if err := opts.Update(func(tx OptionsTx) error { return tx.Set("a.x", 3) }); err != nil {
+		t.Fatal(err)
+	}
+	if got := opts.Get("a.x").Int(); got != 3 {
+		t.Fatalf("get through alias, want 3 got %d", got)
+	}
+	if want, got := "addon.a.x,addon.a.x", strings.Join(guarded, ","); want != got {
+		t.Fatalf("guards must receive option key, want %q got %q", want, got)
+	}
+}
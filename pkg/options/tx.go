@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package options
+
+import (
+	"errors"
+
+	"github.com/happy-sdk/happy/pkg/vars"
+)
+
+// OptionsTx is transaction of option changes applied with Options.Update.
+type OptionsTx interface {
+	// Get returns option value, including changes made in transaction.
+	Get(key string) vars.Variable
+	// Set sets option value, error is returned for unknown,
+	// read-only or invalid options.
+	Set(key string, value any) error
+}
+
+type tx struct {
+	opts *Options
+	prev map[string]prevValue
+	keys []string
+}
+
+type prevValue struct {
+	val vars.Variable
+	ok  bool
+}
+
+func (t *tx) Get(key string) vars.Variable {
+	key = t.opts.resolve(key)
+	if t.opts.db.Has(key) {
+		return t.opts.db.Get(key)
+	}
+	return emptyStringVariable
+}
+
+func (t *tx) Set(key string, value any) error {
+	key = t.opts.resolve(key)
+	if err := callGuards(t.opts.guards, t.opts.owner, key); err != nil {
+		return err
+	}
+	if _, ok := t.prev[key]; !ok {
+		val, ok := t.opts.db.Load(key)
+		t.prev[key] = prevValue{val: val, ok: ok}
+		t.keys = append(t.keys, key)
+	}
+	return t.opts.set(key, value, !t.opts.sealed)
+}
+
+func (t *tx) rollback() error {
+	var errs []error
+	for i := len(t.keys) - 1; i >= 0; i-- {
+		key := t.keys[i]
+		t.opts.db.Delete(key)
+		if prev := t.prev[key]; prev.ok {
+			errs = append(errs, t.opts.db.Store(key, prev.val))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Update applies all option changes made by fn atomically. Concurrent
+// readers observe either none or all of the changes. When fn or any
+// Set within it returns error, changes are rolled back and error is
+// returned. Options are locked while fn runs, write guards called by
+// Set within fn must not call methods of the options.
+func (opts *Options) Update(fn func(tx OptionsTx) error) error {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+
+	t := &tx{
+		opts: opts,
+		prev: make(map[string]prevValue),
+	}
+	if err := fn(t); err != nil {
+		return errors.Join(err, t.rollback())
+	}
+	return nil
+}
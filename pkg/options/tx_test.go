@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/vars"
+)
+
+var errTxTest = errors.New("tx test")
+
+func newTxTestOptions(t *testing.T) *Options {
+	t.Helper()
+	positive := func(key string, val vars.Value) error {
+		n, err := val.Int()
+		if err != nil || n < 0 {
+			return fmt.Errorf("%w: %s must be positive", ErrOptionValidation, key)
+		}
+		return nil
+	}
+	opts, err := New("tx", []Spec{
+		NewOption("a", 1, "a", KindConfig, positive),
+		NewOption("b", 2, "b", KindConfig, positive),
+		NewOption("version", "1.0.0", "version", KindReadOnly, NoopValueValidator),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := opts.Seal(); err != nil {
+		t.Fatal(err)
+	}
+	opts.SetWriteGuard(func(owner, key string) error {
+		if key == "b" && opts.db.Get("a").Int() == 13 {
+			return fmt.Errorf("%w: b is locked", errTxTest)
+		}
+		return nil
+	})
+	return opts
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(tx OptionsTx) error
+		wantErr error
+		a, b    int
+	}{
+		{
+			name: "commit",
+			fn: func(tx OptionsTx) error {
+				if err := tx.Set("a", 10); err != nil {
+					return err
+				}
+				if got := tx.Get("a").Int(); got != 10 {
+					return fmt.Errorf("tx must see own change, got %d", got)
+				}
+				return tx.Set("b", 20)
+			},
+			a: 10,
+			b: 20,
+		},
+		{
+			name: "same key set twice",
+			fn: func(tx OptionsTx) error {
+				if err := tx.Set("a", 10); err != nil {
+					return err
+				}
+				return tx.Set("a", 11)
+			},
+			a: 11,
+			b: 2,
+		},
+		{
+			name: "rollback on fn error",
+			fn: func(tx OptionsTx) error {
+				if err := tx.Set("a", 10); err != nil {
+					return err
+				}
+				if err := tx.Set("b", 20); err != nil {
+					return err
+				}
+				return errTxTest
+			},
+			wantErr: errTxTest,
+			a:       1,
+			b:       2,
+		},
+		{
+			name: "rollback of key set twice",
+			fn: func(tx OptionsTx) error {
+				if err := tx.Set("a", 10); err != nil {
+					return err
+				}
+				if err := tx.Set("a", 11); err != nil {
+					return err
+				}
+				return errTxTest
+			},
+			wantErr: errTxTest,
+			a:       1,
+			b:       2,
+		},
+		{
+			name: "rollback on validation error",
+			fn: func(tx OptionsTx) error {
+				if err := tx.Set("a", 10); err != nil {
+					return err
+				}
+				return tx.Set("b", -1)
+			},
+			wantErr: ErrOptionValidation,
+			a:       1,
+			b:       2,
+		},
+		{
+			name: "rollback on unknown option",
+			fn: func(tx OptionsTx) error {
+				if err := tx.Set("a", 10); err != nil {
+					return err
+				}
+				return tx.Set("unknown", 1)
+			},
+			wantErr: ErrOption,
+			a:       1,
+			b:       2,
+		},
+		{
+			name: "rollback on read-only option",
+			fn: func(tx OptionsTx) error {
+				if err := tx.Set("a", 10); err != nil {
+					return err
+				}
+				return tx.Set("version", "2.0.0")
+			},
+			wantErr: ErrOptionReadOnly,
+			a:       1,
+			b:       2,
+		},
+		{
+			name: "rollback on write guard",
+			fn: func(tx OptionsTx) error {
+				if err := tx.Set("a", 13); err != nil {
+					return err
+				}
+				return tx.Set("b", 20)
+			},
+			wantErr: errTxTest,
+			a:       1,
+			b:       2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := newTxTestOptions(t)
+			err := opts.Update(tt.fn)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got := opts.Get("a").Int(); got != tt.a {
+				t.Errorf("a: expected %d, got %d", tt.a, got)
+			}
+			if got := opts.Get("b").Int(); got != tt.b {
+				t.Errorf("b: expected %d, got %d", tt.b, got)
+			}
+			if got := opts.Get("version").String(); got != "1.0.0" {
+				t.Errorf("version: expected 1.0.0, got %s", got)
+			}
+			if !opts.Get("version").ReadOnly() {
+				t.Error("version must stay read-only")
+			}
+		})
+	}
+}
+
+func TestUpdateAtomic(t *testing.T) {
+	opts := newTxTestOptions(t)
+
+	var (
+		wg   sync.WaitGroup
+		done = make(chan struct{})
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 3; i < 200; i++ {
+			_ = opts.Update(func(tx OptionsTx) error {
+				if err := tx.Set("a", i); err != nil {
+					return err
+				}
+				if err := tx.Set("b", i+1); err != nil {
+					return err
+				}
+				if i%2 == 0 {
+					return errTxTest
+				}
+				return nil
+			})
+		}
+		close(done)
+	}()
+
+	for {
+		select {
+		case <-done:
+			wg.Wait()
+			return
+		default:
+		}
+		opts.mu.RLock()
+		a, b := opts.db.Get("a").Int(), opts.db.Get("b").Int()
+		opts.mu.RUnlock()
+		if b != a+1 {
+			t.Fatalf("observed partial update a=%d b=%d", a, b)
+		}
+	}
+}
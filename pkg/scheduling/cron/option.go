@@ -46,3 +46,11 @@ func WithLogger(logger Logger) Option {
 		c.logger = logger
 	}
 }
+
+// WithClock overrides the clock used to schedule jobs,
+// it allows to drive the scheduler with fake time in tests.
+func WithClock(clock Clock) Option {
+	return func(c *Cron) {
+		c.clock = clock
+	}
+}
@@ -7,9 +7,11 @@ package settings
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 
 	"golang.org/x/text/language"
 )
@@ -79,7 +81,74 @@ func (b *Blueprint) Migrate(keyfrom, keyto string) error {
 	return nil
 }
 
-func (b *Blueprint) settingSpecFromField(field reflect.StructField, value reflect.Value) (SettingSpec, error) {
+// fieldKey identifies exported field of settings struct.
+type fieldKey struct {
+	owner reflect.Type
+	index int
+}
+
+// fieldSpecs caches value independent part of SettingSpec parsed from
+// struct field type and tags, so repeated blueprint compilation of same
+// settings struct e.g. on every application start does not reflect over
+// tags and interfaces again. SettingKind of a type is expected to be
+// constant.
+var fieldSpecs sync.Map // map[fieldKey]SettingSpec
+
+func (b *Blueprint) settingSpecFromField(owner reflect.Type, field reflect.StructField, value reflect.Value) (SettingSpec, error) {
+	key := fieldKey{owner: owner, index: field.Index[0]}
+	var spec SettingSpec
+	if cached, ok := fieldSpecs.Load(key); ok {
+		spec = cached.(SettingSpec)
+	} else {
+		var err error
+		if spec, err = b.fieldSpec(field, value); err != nil {
+			return spec, err
+		}
+		fieldSpecs.Store(key, spec)
+	}
+
+	if spec.Kind == KindSettings {
+		// Handle nested settings
+		var err error
+		if value.Kind() == reflect.Ptr {
+			// If the value is a nil pointer, initialize it
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			spec.Settings, err = New(value.Interface().(Settings))
+		} else {
+			spec.Settings, err = New(value.Addr().Interface().(Settings))
+		}
+		return spec, err
+	}
+
+	if isFieldSet(value) {
+		// if the field is set by the developer, then set it as the default value
+		val := getStringValue(value)
+		spec.Value = val
+		spec.Default = val
+	} else {
+		spec.Value = spec.Default
+	}
+
+	// Check if value implements Marshaller and Unmarshaller
+	if value.CanAddr() {
+		ptr := value.Addr().Interface()
+		if unmarshaller, ok := ptr.(Unmarshaller); ok {
+			spec.Unmarchaler = unmarshaller
+		}
+		if marshaller, ok := ptr.(Marshaller); ok {
+			spec.Marchaler = marshaller
+		}
+	}
+	if spec.Unmarchaler == nil || spec.Marchaler == nil {
+		return spec, fmt.Errorf("%w: %q field %q must implement SettingField interface (both UnmarshalSetting and MarshalSetting methods required)", ErrBlueprint, b.pkg, spec.Key)
+	}
+	return spec, nil
+}
+
+// fieldSpec parses value independent part of SettingSpec from field.
+func (b *Blueprint) fieldSpec(field reflect.StructField, value reflect.Value) (SettingSpec, error) {
 	spec := SettingSpec{}
 
 	var persistent string
@@ -101,85 +170,44 @@ func (b *Blueprint) settingSpecFromField(field reflect.StructField, value reflec
 		spec.Mutability = SettingImmutable
 		spec.IsSet = true
 		spec.Kind = KindSettings
+		return spec, nil
+	}
+	if !fieldImplementsSetting(field) {
+		return spec, fmt.Errorf("%w: %q field %q must implement either Settings or SettingField interface", ErrBlueprint, b.pkg, spec.Key)
+	}
 
-		// Handle nested settings
-		var err error
-		if value.Kind() == reflect.Ptr {
-			// If the value is a nil pointer, initialize it
-			if value.IsNil() {
-				value.Set(reflect.New(value.Type().Elem()))
-			}
-			spec.Settings, err = New(value.Interface().(Settings))
-		} else {
-			spec.Settings, err = New(value.Addr().Interface().(Settings))
-		}
-
-		if err != nil {
-			return spec, err
-		}
-	} else if fieldImplementsSetting(field) {
-		spec.Required = field.Tag.Get("required") == "" || field.Tag.Get("required") == "true"
-
-		mutation := field.Tag.Get("mutation")
-		switch mutation {
-		case "once":
-			spec.Mutability = SettingOnce
-		case "mutable":
-			spec.Mutability = SettingMutable
-		default:
-			spec.Mutability = SettingImmutable
-			spec.IsSet = true
-		}
-
-		kindGetterMethod := value.MethodByName("SettingKind")
-		if kindGetterMethod.IsValid() {
-			results := kindGetterMethod.Call(nil)
-			if len(results) != 1 {
-				return spec, fmt.Errorf("%w: %q field %q must implement either Setting or Settings interface", ErrBlueprint, b.pkg, spec.Key)
-			}
-			spec.Kind = results[0].Interface().(Kind)
-		} else {
-			spec.Kind = KindCustom
-		}
-
-		desc := field.Tag.Get("desc")
-		if desc != "" {
-			if spec.i18n == nil {
-				spec.i18n = make(map[language.Tag]string)
-			}
-			spec.i18n[language.English] = desc
-		}
-		spec.Default = field.Tag.Get("default")
-		if spec.Kind == KindBool && (spec.Default != "" && spec.Default != "false") {
-			return spec, fmt.Errorf("%w: %q boolean field %q can have default value only false", ErrBlueprint, b.pkg, spec.Key)
-		}
+	spec.Required = field.Tag.Get("required") == "" || field.Tag.Get("required") == "true"
 
-		if isFieldSet(value) {
-			// if the field is set by the developer, then set it as the default value
-			val := getStringValue(value)
-			spec.Value = val
-			spec.Default = val
-		} else {
-			spec.Value = spec.Default
-		}
+	mutation := field.Tag.Get("mutation")
+	switch mutation {
+	case "once":
+		spec.Mutability = SettingOnce
+	case "mutable":
+		spec.Mutability = SettingMutable
+	default:
+		spec.Mutability = SettingImmutable
+		spec.IsSet = true
+	}
 
-		// Check if value implements Marshaller and Unmarshaller
-		if value.CanAddr() {
-			ptr := value.Addr().Interface()
-			if unmarshaller, ok := ptr.(Unmarshaller); ok {
-				spec.Unmarchaler = unmarshaller
-			}
-			if marshaller, ok := ptr.(Marshaller); ok {
-				spec.Marchaler = marshaller
-			}
-		}
-		if spec.Unmarchaler == nil || spec.Marchaler == nil {
-			return spec, fmt.Errorf("%w: %q field %q must implement SettingField interface (both UnmarshalSetting and MarshalSetting methods required)", ErrBlueprint, b.pkg, spec.Key)
+	kindGetterMethod := value.MethodByName("SettingKind")
+	if kindGetterMethod.IsValid() {
+		results := kindGetterMethod.Call(nil)
+		if len(results) != 1 {
+			return spec, fmt.Errorf("%w: %q field %q must implement either Setting or Settings interface", ErrBlueprint, b.pkg, spec.Key)
 		}
+		spec.Kind = results[0].Interface().(Kind)
 	} else {
-		return spec, fmt.Errorf("%w: %q field %q must implement either Settings or SettingField interface", ErrBlueprint, b.pkg, spec.Key)
+		spec.Kind = KindCustom
 	}
 
+	desc := field.Tag.Get("desc")
+	if desc != "" {
+		spec.i18n = map[language.Tag]string{language.English: desc}
+	}
+	spec.Default = field.Tag.Get("default")
+	if spec.Kind == KindBool && (spec.Default != "" && spec.Default != "false") {
+		return spec, fmt.Errorf("%w: %q boolean field %q can have default value only false", ErrBlueprint, b.pkg, spec.Key)
+	}
 	return spec, nil
 }
 
@@ -211,17 +239,17 @@ func (b *Blueprint) Describe(key string, lang language.Tag, description string)
 		return
 	}
 
-	if spec.i18n == nil {
-		spec.i18n = make(map[language.Tag]string)
-	}
-
 	// check does language exist already
 	if v, ok := spec.i18n[lang]; ok {
 		b.errs = append(b.errs, fmt.Errorf("%w: %s already described in %s: %s", ErrBlueprint, key, lang, v))
 		return
 	}
 
-	spec.i18n[lang] = description
+	// i18n may be shared with cached field spec, copy it before modifying.
+	i18n := make(map[language.Tag]string, len(spec.i18n)+1)
+	maps.Copy(i18n, spec.i18n)
+	i18n[lang] = description
+	spec.i18n = i18n
 	b.specs[key] = spec
 }
 
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package settings
+
+import (
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+type cacheTestSettings struct {
+	Name  String `key:"name" default:"anonymous" desc:"name"`
+	Debug Bool   `key:"debug"`
+}
+
+func (s cacheTestSettings) Blueprint() (*Blueprint, error) {
+	return New(s)
+}
+
+func TestBlueprintFieldSpecCache(t *testing.T) {
+	b1, err := New(cacheTestSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b1.Describe("name", language.Make("et"), "Name")
+	if len(b1.errs) > 0 {
+		t.Fatal(b1.errs)
+	}
+
+	b2, err := New(cacheTestSettings{Name: "happy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2.Describe("name", language.Make("et"), "Name")
+	if len(b2.errs) > 0 {
+		t.Fatalf("description shared between blueprints: %v", b2.errs)
+	}
+
+	spec1, _ := b1.GetSpec("name")
+	spec2, _ := b2.GetSpec("name")
+	if spec1.Value != "anonymous" || spec2.Value != "happy" {
+		t.Errorf("values = %q, %q, want %q, %q", spec1.Value, spec2.Value, "anonymous", "happy")
+	}
+	if spec2.Default != "happy" {
+		t.Errorf("default = %q, want value set by developer", spec2.Default)
+	}
+	if spec, _ := b2.GetSpec("debug"); spec.Kind != KindBool {
+		t.Errorf("kind = %s, want %s", spec.Kind, KindBool)
+	}
+}
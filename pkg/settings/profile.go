@@ -20,6 +20,11 @@ type Profile struct {
 	schema   Schema
 	loaded   bool
 	settings map[string]Setting
+
+	// dirty are persistent settings changed since last flush.
+	dirty   map[string]bool
+	flushmu sync.Mutex
+	flush   func(changes map[string]string) error
 }
 
 func (p *Profile) Name() string {
@@ -121,9 +126,67 @@ func (p *Profile) Set(key string, val any) (err error) {
 		}
 	}
 
+	prev := p.settings[key]
 	setting.isSet = true
 
 	p.settings[key] = setting
+	if setting.persistent && (!prev.isSet || prev.vv.String() != setting.vv.String()) {
+		if p.dirty == nil {
+			p.dirty = make(map[string]bool)
+		}
+		p.dirty[key] = true
+	}
+	return nil
+}
+
+// Dirty reports whether persistent settings have changed since profile
+// was loaded or last flushed.
+func (p *Profile) Dirty() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.dirty) > 0
+}
+
+// OnFlush sets function which saves changed persistent settings, changes
+// are values of changed settings by key.
+func (p *Profile) OnFlush(fn func(changes map[string]string) error) {
+	p.flushmu.Lock()
+	defer p.flushmu.Unlock()
+	p.flush = fn
+}
+
+// Flush saves persistent settings changed since last flush with function
+// set by OnFlush. Nothing is saved when no setting has changed, so it is
+// cheap to call periodically e.g. from long running daemons. Settings
+// changed again while saving stay dirty for next flush.
+func (p *Profile) Flush() error {
+	p.flushmu.Lock()
+	defer p.flushmu.Unlock()
+	if p.flush == nil {
+		return nil
+	}
+
+	p.mu.RLock()
+	changes := make(map[string]string, len(p.dirty))
+	for key := range p.dirty {
+		changes[key] = p.settings[key].vv.String()
+	}
+	p.mu.RUnlock()
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if err := p.flush(changes); err != nil {
+		return fmt.Errorf("%w: flush: %w", ErrProfile, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, val := range changes {
+		if p.settings[key].vv.String() == val {
+			delete(p.dirty, key)
+		}
+	}
 	return nil
 }
 
@@ -149,7 +212,7 @@ func (p *Profile) Validate(key string, val any) (err error) {
 	return nil
 }
 
-func (p *Profile) load(prefs *Preferences) (err error) {
+func (p *Profile) load(prefs *Preferences, validate bool) (err error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	if p.loaded {
@@ -182,9 +245,11 @@ func (p *Profile) load(prefs *Preferences) (err error) {
 				}
 				s.isSet = true
 
-				for _, v := range p.schema.settings[lkey].validators {
-					if err := v.fn(s); err != nil {
-						return err
+				if validate {
+					for _, v := range p.schema.settings[lkey].validators {
+						if err := v.fn(s); err != nil {
+							return err
+						}
 					}
 				}
 				p.settings[lkey] = s
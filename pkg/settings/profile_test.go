@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package settings
+
+import (
+	"errors"
+	"testing"
+)
+
+type flushTestSettings struct {
+	Theme String `key:"theme,save" default:"light" mutation:"mutable"`
+	Size  Int    `key:"size,save" default:"10" mutation:"mutable"`
+	Debug Bool   `key:"debug" mutation:"mutable"`
+}
+
+func (s flushTestSettings) Blueprint() (*Blueprint, error) {
+	return New(s)
+}
+
+func TestProfileFlush(t *testing.T) {
+	errSave := errors.New("save")
+
+	tests := []struct {
+		name    string
+		set     map[string]any
+		saveErr error
+		want    map[string]string
+		dirty   bool
+	}{
+		{name: "unchanged"},
+		{name: "not persistent", set: map[string]any{"debug": true}},
+		{
+			name: "changed",
+			set:  map[string]any{"theme": "dark", "size": 12, "debug": true},
+			want: map[string]string{"theme": "dark", "size": "12"},
+		},
+		{
+			name: "explicitly set to default",
+			set:  map[string]any{"theme": "light"},
+			want: map[string]string{"theme": "light"},
+		},
+		{
+			name:    "save failed",
+			set:     map[string]any{"theme": "dark"},
+			saveErr: errSave,
+			want:    map[string]string{"theme": "dark"},
+			dirty:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := New(flushTestSettings{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			schema, err := b.Schema("example.com/app", "v1.0.0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			profile, err := schema.Profile("default", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var saved []map[string]string
+			profile.OnFlush(func(changes map[string]string) error {
+				saved = append(saved, changes)
+				return tt.saveErr
+			})
+			for key, val := range tt.set {
+				if err := profile.Set(key, val); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err = profile.Flush()
+			if !errors.Is(err, tt.saveErr) {
+				t.Fatalf("Flush() = %v, want %v", err, tt.saveErr)
+			}
+			if tt.want == nil {
+				if len(saved) > 0 {
+					t.Fatalf("saved %v, want nothing", saved)
+				}
+				return
+			}
+			if len(saved) != 1 || len(saved[0]) != len(tt.want) {
+				t.Fatalf("saved %v, want %v", saved, tt.want)
+			}
+			for key, val := range tt.want {
+				if saved[0][key] != val {
+					t.Errorf("saved %s = %q, want %q", key, saved[0][key], val)
+				}
+			}
+			if profile.Dirty() != tt.dirty {
+				t.Errorf("Dirty() = %t after flush, want %t", profile.Dirty(), tt.dirty)
+			}
+
+			// flushing again saves only what is still dirty
+			saved = nil
+			_ = profile.Flush()
+			if tt.dirty != (len(saved) == 1) {
+				t.Errorf("second flush saved %v", saved)
+			}
+		})
+	}
+}
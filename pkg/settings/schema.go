@@ -8,6 +8,7 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"sort"
 
 	"golang.org/x/text/language"
 )
@@ -43,13 +44,38 @@ func (s *Schema) Profile(name string, pref *Preferences) (*Profile, error) {
 		schema: *s,
 		lang:   language.English,
 	}
-	if err := profile.load(pref); err != nil {
+	if err := profile.load(pref, true); err != nil {
 		return nil, err
 	}
 
 	return profile, nil
 }
 
+// TrustedProfile returns profile like Profile but setting validators are
+// not called for preferences. Use it only for preferences which were
+// already validated with same schema e.g. loaded from compiled cache.
+func (s *Schema) TrustedProfile(name string, pref *Preferences) (*Profile, error) {
+	profile := &Profile{
+		name:   name,
+		schema: *s,
+		lang:   language.English,
+	}
+	if err := profile.load(pref, false); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Keys returns sorted keys of all settings in schema.
+func (s *Schema) Keys() []string {
+	keys := make([]string, 0, len(s.settings))
+	for key := range s.settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (s *Schema) setID() {
 	// Generate the ID using SHA-256 on the combined package path and execution mode.
 	data := s.pkg + "-" + s.module + "-" + s.mode.String()
@@ -100,7 +100,7 @@ func New[S Settings](s S) (*Blueprint, error) {
 			continue
 		}
 		value := val.Field(i)
-		spec, err := b.settingSpecFromField(field, value)
+		spec, err := b.settingSpecFromField(typ, field, value)
 		if err != nil {
 			return nil, err
 		}
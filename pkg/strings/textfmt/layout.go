@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package textfmt
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Align is horizontal alignment used by Pad.
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignRight
+	AlignCenter
+)
+
+// Pad pads s with spaces to width columns, s wider than width is
+// returned as is.
+func Pad(s string, width int, align Align) string {
+	gap := width - Width(s)
+	if gap <= 0 {
+		return s
+	}
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", gap) + s
+	case AlignCenter:
+		return strings.Repeat(" ", gap/2) + s + strings.Repeat(" ", gap-gap/2)
+	}
+	return s + strings.Repeat(" ", gap)
+}
+
+// Truncate shortens s to at most width columns replacing removed part
+// with tail e.g. "…". Escape sequences of removed part are kept so
+// that colors are reset and hyperlinks closed.
+func Truncate(s string, width int, tail string) string {
+	if Width(s) <= width {
+		return s
+	}
+	limit := width - Width(tail)
+	if limit < 0 {
+		limit, tail = width, ""
+	}
+	var (
+		b   strings.Builder
+		w   int
+		cut bool
+	)
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		if cut {
+			continue
+		}
+		if rw := RuneWidth(r); w+rw <= limit {
+			b.WriteRune(r)
+			w += rw
+			continue
+		}
+		b.WriteString(tail)
+		cut = true
+	}
+	return b.String()
+}
+
+// Wrap wraps s to lines of at most width columns breaking at spaces,
+// words wider than width are broken. Existing line breaks are kept.
+func Wrap(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = wrapLine(line, width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func wrapLine(line string, width int) string {
+	var (
+		b  strings.Builder
+		lw int
+	)
+	for _, word := range strings.Fields(line) {
+		for _, part := range split(word, width) {
+			pw := Width(part)
+			switch {
+			case lw == 0:
+			case lw+1+pw <= width:
+				b.WriteByte(' ')
+				lw++
+			default:
+				b.WriteByte('\n')
+				lw = 0
+			}
+			b.WriteString(part)
+			lw += pw
+		}
+	}
+	return b.String()
+}
+
+// split breaks word into parts of at most width columns.
+func split(word string, width int) []string {
+	if Width(word) <= width {
+		return []string{word}
+	}
+	var (
+		parts []string
+		b     strings.Builder
+		w     int
+	)
+	for i := 0; i < len(word); {
+		if n := escapeLen(word[i:]); n > 0 {
+			b.WriteString(word[i : i+n])
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(word[i:])
+		i += size
+		rw := RuneWidth(r)
+		if w+rw > width && w > 0 {
+			parts = append(parts, b.String())
+			b.Reset()
+			w = 0
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	if b.Len() > 0 {
+		parts = append(parts, b.String())
+	}
+	return parts
+}
+
+// Indent prefixes every non empty line of s with prefix.
+func Indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
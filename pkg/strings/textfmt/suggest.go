@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package textfmt
+
+// Distance returns Levenshtein edit distance between a and b counted
+// in runes.
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// Suggest returns candidate closest to s by edit distance or empty
+// string when no candidate is close enough to be likely typo. Allowed
+// distance grows with length of s up to 3 edits, ties are resolved
+// alphabetically.
+func Suggest(s string, candidates []string) string {
+	var (
+		best     string
+		bestDist = min(len(s)/3+1, 3)
+	)
+	for _, c := range candidates {
+		if c == s {
+			continue
+		}
+		d := Distance(s, c)
+		if d > bestDist || (d == bestDist && best != "" && c > best) {
+			continue
+		}
+		best, bestDist = c, d
+	}
+	return best
+}
@@ -2,13 +2,14 @@
 //
 // Copyright © 2024 The Happy Authors
 
-// Package textfmt offers a collection of utils for plain text formatting.
+// Package textfmt offers a collection of utils for text formatting, widths
+// are measured in terminal columns so that wide characters and ANSI escape
+// sequences do not break layouts.
 package textfmt
 
 import (
 	"fmt"
 	"strings"
-	"unicode/utf8"
 )
 
 type Table struct {
@@ -49,7 +50,7 @@ func (t *Table) String() string {
 	if t.Title != "" {
 		title := fmt.Sprint(t.Title)
 		b.WriteString(t.buildBorder('┌', '─', '┐', maxColWidth))
-		suffixlen := tableWidth - utf8.RuneCountInString(title) - 4
+		suffixlen := tableWidth - Width(title) - 4
 
 		suffix := ""
 		if suffixlen > 0 {
@@ -85,7 +86,7 @@ func (t *Table) calculateMaxColumnWidths() (cw []int, total int) {
 
 	for _, row := range t.rows {
 		for i, col := range row {
-			colLen := utf8.RuneCountInString(col) + 2
+			colLen := Width(col) + 2
 			if colLen > maxColWidth[i] {
 				maxColWidth[i] = colLen
 			}
@@ -122,7 +123,7 @@ func (t *Table) formatRow(row []string, colWidths []int) string {
 		if i < len(row) {
 			col = row[i]
 		}
-		colDisplayWidth := utf8.RuneCountInString(col)
+		colDisplayWidth := Width(col)
 		padding := colWidths[i] - colDisplayWidth - 1 // -1 for space before the text
 		if padding < 0 {
 			padding = 0
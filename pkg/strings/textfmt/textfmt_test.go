@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package textfmt
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	red   = "\033[31m"
+	reset = "\033[0m"
+	link  = "\033]8;;https://example.com\033\\"
+	end   = "\033]8;;\033\\"
+)
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want int
+	}{
+		{"ASCII", "hello", 5},
+		{"Empty", "", 0},
+		{"Accented", "héllo", 5},
+		{"Combining", "héllo", 5},
+		{"CJK", "日本語", 6},
+		{"Hangul", "한국어", 6},
+		{"Fullwidth", "ＡＢ", 4},
+		{"Emoji", "ok 🚀", 5},
+		{"Color", red + "hello" + reset, 5},
+		{"Hyperlink", link + "example" + end, 7},
+		{"Color CJK", red + "日本" + reset, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Width(tt.in); got != tt.want {
+				t.Errorf("Width(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrip(t *testing.T) {
+	if got := Strip(red + "a" + reset + link + "b" + end); got != "ab" {
+		t.Errorf("Strip() = %q, want %q", got, "ab")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		width int
+		want  string
+	}{
+		{"Fits", "hello", 5, "hello"},
+		{"ASCII", "hello world", 8, "hello w…"},
+		{"CJK", "日本語テキスト", 7, "日本語…"},
+		{"CJK odd", "日本語", 4, "日…"},
+		{"Color", red + "hello world" + reset, 6, red + "hello…" + reset},
+		{"Hyperlink", link + "example" + end, 4, link + "exa…" + end},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.in, tt.width, "…")
+			if got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+			}
+			if w := Width(got); w > tt.width {
+				t.Errorf("Truncate(%q, %d) width = %d", tt.in, tt.width, w)
+			}
+		})
+	}
+}
+
+func TestPad(t *testing.T) {
+	tests := []struct {
+		in    string
+		align Align
+		want  string
+	}{
+		{"ab", AlignLeft, "ab   "},
+		{"ab", AlignRight, "   ab"},
+		{"ab", AlignCenter, " ab  "},
+		{"日本", AlignLeft, "日本 "},
+		{red + "ab" + reset, AlignRight, "   " + red + "ab" + reset},
+		{"abcdefg", AlignLeft, "abcdefg"},
+	}
+	for _, tt := range tests {
+		if got := Pad(tt.in, 5, tt.align); got != tt.want {
+			t.Errorf("Pad(%q, 5, %d) = %q, want %q", tt.in, tt.align, got, tt.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		width int
+		want  string
+	}{
+		{"Words", "the quick brown fox jumps", 10, "the quick\nbrown fox\njumps"},
+		{"Long word", "abcdefghijkl xy", 5, "abcde\nfghij\nkl xy"},
+		{"Newlines", "one two\nthree", 20, "one two\nthree"},
+		{"CJK", "日本語のテキスト", 6, "日本語\nのテキ\nスト"},
+		{"Color", red + "quick" + reset + " brown fox", 9, red + "quick" + reset + "\nbrown fox"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Wrap(tt.in, tt.width)
+			if got != tt.want {
+				t.Errorf("Wrap(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+			}
+			for _, line := range strings.Split(got, "\n") {
+				if w := Width(line); w > tt.width {
+					t.Errorf("line %q width %d > %d", line, w, tt.width)
+				}
+			}
+		})
+	}
+}
+
+func TestIndent(t *testing.T) {
+	if got := Indent("a\n\nb", "  "); got != "  a\n\n  b" {
+		t.Errorf("Indent() = %q", got)
+	}
+}
+
+func TestTableWidth(t *testing.T) {
+	table := Table{}
+	table.AddRow("name", "日本")
+	table.AddRow(red+"colored"+reset, "x")
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	want := Width(lines[0])
+	for _, line := range lines {
+		if w := Width(line); w != want {
+			t.Errorf("line %q width %d, want %d", line, w, want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"greet", "config", "doctor", "info"}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"gret", "greet"},
+		{"confg", "config"},
+		{"doktor", "doctor"},
+		{"inf", "info"},
+		{"xyz", ""},
+		{"greet", ""},
+	}
+	for _, tt := range tests {
+		if got := Suggest(tt.in, candidates); got != tt.want {
+			t.Errorf("Suggest(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if d := Distance("kitten", "sitting"); d != 3 {
+		t.Errorf("Distance() = %d, want 3", d)
+	}
+}
@@ -0,0 +1,226 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package tmpl
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/happy-sdk/happy/pkg/strings/slug"
+	"github.com/happy-sdk/happy/pkg/version"
+)
+
+// Funcs returns default helper functions.
+//
+//	lower upper title          strings.ToLower, ToUpper, first letter of words upper
+//	camel pascal snake kebab   myName MyName my_name my-name
+//	screaming                  MY_NAME
+//	slug                       URL and file name safe slug
+//	trim trimPrefix trimSuffix replace contains hasPrefix hasSuffix split join
+//	quote indent default
+//	semver                     canonical version with v prefix or empty when invalid
+//	semverMajor semverMinor semverPatch semverPrerelease
+//	semverCompare              -1, 0 or 1
+//	semverBump                 next version for "major", "minor" or "patch"
+//	pathJoin pathBase pathDir pathExt filepathJoin
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"title":     Title,
+		"camel":     Camel,
+		"pascal":    Pascal,
+		"snake":     Snake,
+		"kebab":     Kebab,
+		"screaming": func(s string) string { return strings.ToUpper(Snake(s)) },
+		"slug":      slug.Create,
+
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":       func(sep string, elems []string) string { return strings.Join(elems, sep) },
+		"quote":      strconv.Quote,
+		"indent":     indent,
+		"default":    defaultValue,
+
+		"semver":           semverCanonical,
+		"semverMajor":      func(v string) uint64 { return parseVersion(v).Major() },
+		"semverMinor":      func(v string) uint64 { return parseVersion(v).Minor() },
+		"semverPatch":      func(v string) uint64 { return parseVersion(v).Patch() },
+		"semverPrerelease": func(v string) string { return parseVersion(v).Prerelease() },
+		"semverCompare":    version.Compare,
+		"semverBump":       semverBump,
+
+		"pathJoin":     path.Join,
+		"pathBase":     path.Base,
+		"pathDir":      path.Dir,
+		"pathExt":      path.Ext,
+		"filepathJoin": filepath.Join,
+	}
+}
+
+// Words splits s into words on separators, case changes and
+// boundaries between letters and digits, "HTTPServer2Go" is split
+// into "HTTP", "Server", "2" and "Go".
+func Words(s string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if len(word) > 0 {
+			prev := word[len(word)-1]
+			switch {
+			case unicode.IsDigit(r) != unicode.IsDigit(prev):
+				flush()
+			case unicode.IsUpper(r) && unicode.IsLower(prev):
+				flush()
+			// last upper case letter of acronym starts new word: HTTPServer
+			case unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// Title returns s with first letter of every word upper cased.
+func Title(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = upperFirst(w)
+	}
+	return strings.Join(words, " ")
+}
+
+// Camel returns s in camelCase.
+func Camel(s string) string {
+	words := Words(s)
+	for i, w := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(w)
+			continue
+		}
+		words[i] = upperFirst(strings.ToLower(w))
+	}
+	return strings.Join(words, "")
+}
+
+// Pascal returns s in PascalCase.
+func Pascal(s string) string {
+	words := Words(s)
+	for i, w := range words {
+		words[i] = upperFirst(strings.ToLower(w))
+	}
+	return strings.Join(words, "")
+}
+
+// Snake returns s in snake_case.
+func Snake(s string) string {
+	return strings.ToLower(strings.Join(Words(s), "_"))
+}
+
+// Kebab returns s in kebab-case.
+func Kebab(s string) string {
+	return strings.ToLower(strings.Join(Words(s), "-"))
+}
+
+func upperFirst(s string) string {
+	for i, r := range s {
+		return string(unicode.ToUpper(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// defaultValue returns value unless it is empty, {{ .Name | default "app" }}.
+func defaultValue(def any, value any) any {
+	switch v := value.(type) {
+	case nil:
+		return def
+	case string:
+		if v == "" {
+			return def
+		}
+	case bool:
+		if !v {
+			return def
+		}
+	case int:
+		if v == 0 {
+			return def
+		}
+	}
+	return value
+}
+
+func semverCanonical(v string) string {
+	ver, err := version.Parse(v)
+	if err != nil {
+		return ""
+	}
+	return ver.String()
+}
+
+func parseVersion(v string) version.Version {
+	ver, _ := version.Parse(v)
+	return ver
+}
+
+func semverBump(bump, v string) (string, error) {
+	ver, err := version.Parse(v)
+	if err != nil {
+		return "", err
+	}
+	var b version.Bump
+	switch bump {
+	case "major":
+		b = version.BumpMajor
+	case "minor":
+		b = version.BumpMinor
+	case "patch":
+		b = version.BumpPatch
+	default:
+		return "", fmt.Errorf("%w: unknown version bump %q", Error, bump)
+	}
+	next, err := ver.Next(b)
+	if err != nil {
+		return "", err
+	}
+	return next.String(), nil
+}
@@ -0,0 +1,14 @@
+module github.com/happy-sdk/happy/pkg/tmpl
+
+go 1.22.0
+
+require (
+	github.com/happy-sdk/happy/pkg/strings/slug v0.1.0
+	github.com/happy-sdk/happy/pkg/version v0.1.4
+)
+
+require golang.org/x/mod v0.22.0 // indirect
+
+// version.Compare and version.Bump* are not released yet, replace is
+// dropped by releaser when pkg/version is released.
+replace github.com/happy-sdk/happy/pkg/version => ../version
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package tmpl renders text/template templates and directory trees of
+// templates for code generators. Templates have access to helper
+// functions for case conversion, slugs, semantic versions and paths,
+// rendered files can be post-processed with hooks such as Gofmt.
+package tmpl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"path"
+	"text/template"
+)
+
+var Error = errors.New("tmpl")
+
+// Hook post-processes rendered file, name is slash separated output path.
+type Hook func(name string, data []byte) ([]byte, error)
+
+type hook struct {
+	pattern string
+	fn      Hook
+}
+
+// Engine renders templates with helper functions and hooks.
+type Engine struct {
+	funcs      template.FuncMap
+	hooks      []hook
+	left       string
+	right      string
+	missingKey string
+}
+
+// New returns engine with default helper functions and without hooks.
+func New() *Engine {
+	return &Engine{
+		funcs:      Funcs(),
+		missingKey: "error",
+	}
+}
+
+// Funcs adds functions available to templates, existing functions with
+// same name are replaced.
+func (e *Engine) Funcs(funcs template.FuncMap) *Engine {
+	for name, fn := range funcs {
+		e.funcs[name] = fn
+	}
+	return e
+}
+
+// Delims sets action delimiters, empty delimiters reset to {{ and }}.
+func (e *Engine) Delims(left, right string) *Engine {
+	e.left, e.right = left, right
+	return e
+}
+
+// AllowMissingKeys renders missing map keys as zero values instead
+// of failing.
+func (e *Engine) AllowMissingKeys() *Engine {
+	e.missingKey = "zero"
+	return e
+}
+
+// Hook adds hook applied to rendered files which base name matches
+// pattern e.g. "*.go", hooks are applied in order they were added.
+func (e *Engine) Hook(pattern string, fn Hook) *Engine {
+	e.hooks = append(e.hooks, hook{pattern: pattern, fn: fn})
+	return e
+}
+
+// Render renders template text, name is used in error messages and
+// to match hooks.
+func (e *Engine) Render(name, text string, data any) ([]byte, error) {
+	t, err := e.parse(name, text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return e.applyHooks(name, buf.Bytes())
+}
+
+// RenderString renders template text without applying hooks.
+func (e *Engine) RenderString(text string, data any) (string, error) {
+	t, err := e.parse("string", text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return buf.String(), nil
+}
+
+func (e *Engine) parse(name, text string) (*template.Template, error) {
+	t, err := template.New(name).
+		Delims(e.left, e.right).
+		Option("missingkey=" + e.missingKey).
+		Funcs(e.funcs).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return t, nil
+}
+
+func (e *Engine) applyHooks(name string, data []byte) ([]byte, error) {
+	for _, h := range e.hooks {
+		if ok, _ := path.Match(h.pattern, path.Base(name)); !ok {
+			continue
+		}
+		var err error
+		if data, err = h.fn(name, data); err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", Error, name, err.Error())
+		}
+	}
+	return data, nil
+}
+
+// Gofmt is hook formatting Go source with gofmt rules.
+func Gofmt(name string, data []byte) ([]byte, error) {
+	return format.Source(data)
+}
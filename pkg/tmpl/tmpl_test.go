@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package tmpl
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestCase(t *testing.T) {
+	tests := []struct {
+		in, camel, pascal, snake, kebab string
+	}{
+		{"hello world", "helloWorld", "HelloWorld", "hello_world", "hello-world"},
+		{"HTTPServer2Go", "httpServer2Go", "HttpServer2Go", "http_server_2_go", "http-server-2-go"},
+		{"my-app_name", "myAppName", "MyAppName", "my_app_name", "my-app-name"},
+		{"", "", "", "", ""},
+	}
+	for _, tt := range tests {
+		if got := Camel(tt.in); got != tt.camel {
+			t.Errorf("Camel(%q) = %q, want %q", tt.in, got, tt.camel)
+		}
+		if got := Pascal(tt.in); got != tt.pascal {
+			t.Errorf("Pascal(%q) = %q, want %q", tt.in, got, tt.pascal)
+		}
+		if got := Snake(tt.in); got != tt.snake {
+			t.Errorf("Snake(%q) = %q, want %q", tt.in, got, tt.snake)
+		}
+		if got := Kebab(tt.in); got != tt.kebab {
+			t.Errorf("Kebab(%q) = %q, want %q", tt.in, got, tt.kebab)
+		}
+	}
+}
+
+func TestRenderTree(t *testing.T) {
+	fsys := fstest.MapFS{
+		"tpl/{{ .Name | kebab }}/main.go.tmpl":      {Data: []byte("package main\nfunc main(){println({{ quote .Name }})}\n")},
+		"tpl/{{ .Name | kebab }}/static.txt":        {Data: []byte("{{ .Name }}")},
+		"tpl/{{ if .Docs }}docs{{ end }}/README.md": {Data: []byte("docs")},
+	}
+	files, err := New().Hook("*.go", Gofmt).RenderTree(fsys, "tpl", map[string]any{"Name": "My App", "Docs": false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"my-app/main.go":    "package main\n\nfunc main() { println(\"My App\") }\n",
+		"my-app/static.txt": "{{ .Name }}",
+	}
+	if len(files) != len(want) {
+		t.Fatalf("got %d files, want %d: %v", len(files), len(want), files)
+	}
+	for _, f := range files {
+		if w, ok := want[f.Path]; !ok || string(f.Data) != w {
+			t.Errorf("%s = %q, want %q", f.Path, f.Data, w)
+		}
+	}
+}
+
+func TestSemver(t *testing.T) {
+	out, err := New().RenderString(`{{ semver "1.2.3" }} {{ semverBump "minor" "v1.2.3" }} {{ semverMajor "v1.2.3" }}`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "v1.2.3 v1.3.0 1"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
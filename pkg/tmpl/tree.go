@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package tmpl
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Ext is file name suffix of files rendered by RenderTree, files
+// without it are copied as is.
+const Ext = ".tmpl"
+
+// File is rendered file of tree.
+type File struct {
+	// Path is slash separated path relative to tree root.
+	Path string
+	Data []byte
+	Mode fs.FileMode
+}
+
+// RenderTree renders directory tree root of fsys. Every path element
+// is rendered as template so {{ .Name | kebab }}/main.go.tmpl is valid
+// path, files and directories which name renders empty are skipped.
+// Content of files with Ext suffix is rendered and suffix removed,
+// hooks are applied to rendered files only.
+func (e *Engine) RenderTree(fsys fs.FS, root string, data any) ([]File, error) {
+	var files []File
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel := strings.TrimPrefix(p, root+"/")
+		if root == "." {
+			rel = p
+		}
+		name, err := e.renderPath(rel, data)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(name, Ext) {
+			name = strings.TrimSuffix(name, Ext)
+			if content, err = e.Render(name, string(content), data); err != nil {
+				return err
+			}
+		}
+		files = append(files, File{Path: name, Data: content, Mode: info.Mode().Perm()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// renderPath renders every element of p and returns empty string when
+// any element renders empty.
+func (e *Engine) renderPath(p string, data any) (string, error) {
+	elems := strings.Split(p, "/")
+	for i, elem := range elems {
+		if !strings.Contains(elem, e.leftDelim()) {
+			continue
+		}
+		name, err := e.RenderString(elem, data)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s: %s", Error, p, err.Error())
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return "", nil
+		}
+		if name == "." || name == ".." || strings.Contains(name, "/") {
+			return "", fmt.Errorf("%w: %s: invalid path element %q", Error, p, name)
+		}
+		elems[i] = name
+	}
+	return path.Join(elems...), nil
+}
+
+func (e *Engine) leftDelim() string {
+	if e.left == "" {
+		return "{{"
+	}
+	return e.left
+}
+
+// WriteTree writes files under dir creating directories as needed,
+// existing files are overwritten.
+func WriteTree(dir string, files []File) error {
+	for _, f := range files {
+		if !filepath.IsLocal(filepath.FromSlash(f.Path)) {
+			return fmt.Errorf("%w: path %q is outside of %s", Error, f.Path, dir)
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+			return fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		mode := f.Mode
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := os.WriteFile(dest, f.Data, mode); err != nil {
+			return fmt.Errorf("%w: %s", Error, err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+module github.com/happy-sdk/happy/pkg/validate
+
+go 1.22
+
+require github.com/happy-sdk/happy/pkg/version v0.1.4
+
+require golang.org/x/mod v0.22.0 // indirect
@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/version"
+)
+
+// NonEmpty rejects empty and whitespace only values.
+func NonEmpty() Validator {
+	return func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return newError("non_empty", value, "is empty", "")
+		}
+		return nil
+	}
+}
+
+// MaxLen rejects values longer than n runes.
+func MaxLen(n int) Validator {
+	return func(value string) error {
+		if l := len([]rune(value)); l > n {
+			return newError("max_len", value, fmt.Sprintf("is %d characters long", l), fmt.Sprintf("at most %d characters", n))
+		}
+		return nil
+	}
+}
+
+// Regexp rejects values not matching pattern, desc describes pattern
+// to the user e.g. "lower case letters". It panics when pattern is
+// invalid.
+func Regexp(pattern, desc string) Validator {
+	re := regexp.MustCompile(pattern)
+	return func(value string) error {
+		if !re.MatchString(value) {
+			return newError("regexp", value, "does not match "+re.String(), desc)
+		}
+		return nil
+	}
+}
+
+// OneOf rejects values other than values.
+func OneOf(values ...string) Validator {
+	return func(value string) error {
+		if !slices.Contains(values, value) {
+			return newError("one_of", value, "is not allowed", "one of "+strings.Join(values, ", "))
+		}
+		return nil
+	}
+}
+
+// Int rejects values which are not integers in range min..max inclusive.
+func Int(min, max int) Validator {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return newError("int", value, "is not integer", "")
+		}
+		if n < min || n > max {
+			return newError("int", value, "is out of range", fmt.Sprintf("%d-%d", min, max))
+		}
+		return nil
+	}
+}
+
+var hostnameLabel = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// Hostname rejects values which are not RFC 1123 host names.
+func Hostname() Validator {
+	return func(value string) error {
+		name := strings.TrimSuffix(value, ".")
+		if name == "" || len(name) > 253 {
+			return newError("hostname", value, "is not valid host name", "")
+		}
+		for _, label := range strings.Split(name, ".") {
+			if !hostnameLabel.MatchString(label) {
+				return newError("hostname", value, "is not valid host name",
+					"labels of letters, digits and hyphens separated by dots")
+			}
+		}
+		return nil
+	}
+}
+
+// Host rejects values which are neither host names nor IP addresses.
+func Host() Validator {
+	return func(value string) error {
+		if net.ParseIP(value) != nil {
+			return nil
+		}
+		if err := Hostname()(value); err != nil {
+			return newError("host", value, "is not valid host name or IP address", "")
+		}
+		return nil
+	}
+}
+
+// Port rejects values which are not tcp or udp port numbers, 0 is allowed
+// only when allowZero is true.
+func Port(allowZero bool) Validator {
+	min := 1
+	if allowZero {
+		min = 0
+	}
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < min || n > 65535 {
+			return newError("port", value, "is not valid port", fmt.Sprintf("%d-65535", min))
+		}
+		return nil
+	}
+}
+
+// HostPort rejects values which are not host:port addresses, empty host
+// is allowed and means all interfaces.
+func HostPort() Validator {
+	return func(value string) error {
+		host, port, err := net.SplitHostPort(value)
+		if err != nil {
+			return newError("host_port", value, "is not valid address", "host:port")
+		}
+		if host != "" {
+			if err := Host()(host); err != nil {
+				return newError("host_port", value, "has invalid host", "host:port")
+			}
+		}
+		if err := Port(true)(port); err != nil {
+			return newError("host_port", value, "has invalid port", "port 0-65535")
+		}
+		return nil
+	}
+}
+
+// URL rejects values which are not absolute URLs with host, allowed
+// schemes can be restricted with schemes.
+func URL(schemes ...string) Validator {
+	return func(value string) error {
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return newError("url", value, "is not absolute URL", "scheme://host/path")
+		}
+		if len(schemes) > 0 && !slices.Contains(schemes, u.Scheme) {
+			return newError("url", value, "has unsupported scheme "+u.Scheme, "scheme "+strings.Join(schemes, ", "))
+		}
+		return nil
+	}
+}
+
+// Semver rejects values which are not semantic versions, v prefix is
+// optional.
+func Semver() Validator {
+	return func(value string) error {
+		if _, err := version.Parse(value); err != nil {
+			return newError("semver", value, "is not semantic version", "e.g. v1.2.3")
+		}
+		return nil
+	}
+}
+
+// PathExists rejects paths which do not exist.
+func PathExists() Validator {
+	return func(value string) error {
+		if _, err := os.Stat(value); err != nil {
+			return newError("path_exists", value, pathReason(err), "")
+		}
+		return nil
+	}
+}
+
+// DirExists rejects paths which are not existing directories.
+func DirExists() Validator {
+	return func(value string) error {
+		info, err := os.Stat(value)
+		if err != nil {
+			return newError("dir_exists", value, pathReason(err), "")
+		}
+		if !info.IsDir() {
+			return newError("dir_exists", value, "is not a directory", "")
+		}
+		return nil
+	}
+}
+
+// Writable rejects paths which are not existing writable directories,
+// it creates and removes temporary file to check it.
+func Writable() Validator {
+	return func(value string) error {
+		if err := DirExists()(value); err != nil {
+			return err
+		}
+		f, err := os.CreateTemp(value, ".validate-*")
+		if err != nil {
+			return newError("writable", value, "is not writable", "check owner and permissions")
+		}
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil
+	}
+}
+
+// PathCreatable rejects paths which neither exist nor can be created,
+// path can be created when its nearest existing parent is writable
+// directory.
+func PathCreatable() Validator {
+	return func(value string) error {
+		if value == "" {
+			return newError("path_creatable", value, "is empty", "")
+		}
+		if _, err := os.Stat(value); err == nil {
+			return nil
+		}
+		dir := filepath.Dir(filepath.Clean(value))
+		for {
+			info, err := os.Stat(dir)
+			if err == nil {
+				if !info.IsDir() {
+					return newError("path_creatable", value, "can not be created, "+dir+" is not a directory", "")
+				}
+				if err := Writable()(dir); err != nil {
+					return newError("path_creatable", value, "can not be created, "+dir+" is not writable", "")
+				}
+				return nil
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return newError("path_creatable", value, "can not be created", "")
+			}
+			dir = parent
+		}
+	}
+}
+
+func pathReason(err error) string {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return "does not exist"
+	case errors.Is(err, os.ErrPermission):
+		return "is not accessible"
+	}
+	return "can not be read"
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package validate provides composable validators of user input such
+// as setting values, flag values and prompt answers. Validators return
+// *Error describing which rule failed and how to fix the value.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalid is wrapped by all errors returned by validators.
+var ErrInvalid = errors.New("invalid value")
+
+// Error is validation error.
+type Error struct {
+	// Rule is name of failed validator e.g. "port".
+	Rule string
+	// Value is validated value.
+	Value string
+	// Reason describes why value is invalid.
+	Reason string
+	// Hint describes valid values, optional.
+	Hint string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s: %q %s", ErrInvalid, e.Value, e.Reason)
+	if e.Hint != "" {
+		msg += " (" + e.Hint + ")"
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return ErrInvalid
+}
+
+func newError(rule, value, reason, hint string) *Error {
+	return &Error{Rule: rule, Value: value, Reason: reason, Hint: hint}
+}
+
+// Validator validates value, it returns nil when value is valid.
+type Validator func(value string) error
+
+// Check validates value with all validators and returns first error.
+func Check(value string, validators ...Validator) error {
+	return All(validators...)(value)
+}
+
+// All returns validator which requires all validators to pass, first
+// error is returned.
+func All(validators ...Validator) Validator {
+	return func(value string) error {
+		for _, v := range validators {
+			if err := v(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Any returns validator which requires at least one of validators to
+// pass, error of last validator is returned when none passes.
+func Any(validators ...Validator) Validator {
+	return func(value string) error {
+		var err error
+		for _, v := range validators {
+			if err = v(value); err == nil {
+				return nil
+			}
+		}
+		return err
+	}
+}
+
+// Optional returns validator which accepts empty value and validates
+// other values with v.
+func Optional(v Validator) Validator {
+	return func(value string) error {
+		if value == "" {
+			return nil
+		}
+		return v(value)
+	}
+}
+
+// Each returns validator which splits value by sep and validates every
+// non empty element with v.
+func Each(sep string, v Validator) Validator {
+	return func(value string) error {
+		for _, elem := range strings.Split(value, sep) {
+			if elem == "" {
+				continue
+			}
+			if err := v(elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Message returns validator which replaces hint of errors returned
+// by v with hint.
+func Message(v Validator, hint string) Validator {
+	return func(value string) error {
+		err := v(value)
+		var verr *Error
+		if errors.As(err, &verr) {
+			e := *verr
+			e.Hint = hint
+			return &e
+		}
+		return err
+	}
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package validate
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidators(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		v       Validator
+		valid   []string
+		invalid []string
+	}{
+		{"non_empty", NonEmpty(), []string{"a"}, []string{"", "  "}},
+		{"one_of", OneOf("js", "wasi"), []string{"js", "wasi"}, []string{"", "wasm"}},
+		{"hostname", Hostname(), []string{"localhost", "example.com", "a-b.example.com."}, []string{"", "-a.com", "a..b", "a_b.com"}},
+		{"port", Port(false), []string{"1", "8080", "65535"}, []string{"0", "65536", "http"}},
+		{"host_port", HostPort(), []string{"127.0.0.1:6061", ":8080", "[::1]:80", "localhost:0"}, []string{"localhost", "bad_host:80", "localhost:99999"}},
+		{"url", URL("https"), []string{"https://example.com/x"}, []string{"example.com", "http://example.com", "/path"}},
+		{"semver", Semver(), []string{"1.2.3", "v1.2.3-rc.1"}, []string{"1.2.x", "latest"}},
+		{"each", Each("|", OneOf("a", "b")), []string{"a|b", "a||b", ""}, []string{"a|c"}},
+		{"optional", Optional(Port(false)), []string{"", "80"}, []string{"x"}},
+		{"dir_exists", DirExists(), []string{dir}, []string{filepath.Join(dir, "missing")}},
+		{"path_creatable", PathCreatable(), []string{dir, filepath.Join(dir, "a", "b")}, []string{""}},
+	}
+	for _, tt := range tests {
+		for _, value := range tt.valid {
+			if err := tt.v(value); err != nil {
+				t.Errorf("%s: %q: unexpected error %v", tt.name, value, err)
+			}
+		}
+		for _, value := range tt.invalid {
+			err := tt.v(value)
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("%s: %q: expected ErrInvalid, got %v", tt.name, value, err)
+			}
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	err := Check("99999", NonEmpty(), Port(false))
+	var verr *Error
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *Error, got %T", err)
+	}
+	if verr.Rule != "port" {
+		t.Errorf("rule = %q, want port", verr.Rule)
+	}
+	if want := `invalid value: "99999" is not valid port (1-65535)`; err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package vars
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// Format is format of input read by Decoder.
+type Format uint8
+
+const (
+	// FormatEnv is key=value per line as returned by os.Environ,
+	// empty lines are skipped.
+	FormatEnv Format = iota
+	// FormatDotenv is FormatEnv which also skips comment lines
+	// starting with # and strips optional export prefix of a line.
+	FormatDotenv
+	// FormatJSON is JSON object, its members are decoded one at a time.
+	FormatJSON
+)
+
+// maxLineSize is maximum size of a line decoded from line based formats.
+const maxLineSize = 1 << 20
+
+// Decoder reads variables one at a time from input, so that large inputs
+// e.g. environment dumps can be filtered or transformed without loading
+// all variables into a Map.
+//
+//	dec := vars.NewDecoder(r, vars.FormatDotenv)
+//	for dec.Next() {
+//		v := dec.Variable()
+//		// ...
+//	}
+//	if err := dec.Err(); err != nil {
+//		// ...
+//	}
+type Decoder struct {
+	format  Format
+	scanner *bufio.Scanner
+	json    *json.Decoder
+	started bool
+	line    int
+	v       Variable
+	err     error
+}
+
+// NewDecoder returns decoder reading variables in format from r.
+func NewDecoder(r io.Reader, format Format) *Decoder {
+	d := &Decoder{format: format}
+	switch format {
+	case FormatEnv, FormatDotenv:
+		d.scanner = bufio.NewScanner(r)
+		d.scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	case FormatJSON:
+		d.json = json.NewDecoder(r)
+	default:
+		d.err = errorf("%w: unknown format %d", ErrDecode, format)
+	}
+	return d
+}
+
+// Next decodes next variable, which is then available through Variable.
+// It returns false when input is consumed or decoding fails, Err reports
+// which of them happened.
+func (d *Decoder) Next() bool {
+	if d.err != nil {
+		return false
+	}
+	d.v = EmptyVariable
+	if d.json != nil {
+		return d.nextJSON()
+	}
+	return d.nextLine()
+}
+
+// Variable returns variable decoded by last call to Next.
+func (d *Decoder) Variable() Variable {
+	return d.v
+}
+
+// Err returns first error encountered by decoder.
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+// Line returns line number of variable decoded by last call to Next for
+// line based formats.
+func (d *Decoder) Line() int {
+	return d.line
+}
+
+func (d *Decoder) nextLine() bool {
+	for d.scanner.Scan() {
+		d.line++
+		line := d.scanner.Bytes()
+		if d.format == FormatDotenv {
+			line = bytes.TrimSpace(line)
+			if len(line) > 0 && line[0] == '#' {
+				continue
+			}
+			line = bytes.TrimPrefix(line, []byte("export "))
+		}
+		if len(line) == 0 {
+			continue
+		}
+		// scanner reuses its buffer, so line is copied once and
+		// variable key and value share that copy.
+		v, err := ParseVariableFromString(string(line))
+		if err != nil {
+			d.err = errorf("%w: line %d: %w", ErrDecode, d.line, err)
+			return false
+		}
+		d.v = v
+		return true
+	}
+	if err := d.scanner.Err(); err != nil {
+		d.err = errorf("%w: line %d: %w", ErrDecode, d.line+1, err)
+	}
+	return false
+}
+
+func (d *Decoder) nextJSON() bool {
+	if !d.started {
+		d.started = true
+		tok, err := d.json.Token()
+		if err == io.EOF {
+			return false
+		}
+		if err != nil {
+			d.err = errorf("%w: %w", ErrDecode, err)
+			return false
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+			d.err = errorf("%w: expected JSON object", ErrDecode)
+			return false
+		}
+	}
+	if !d.json.More() {
+		// consume closing delimiter
+		if _, err := d.json.Token(); err != nil {
+			d.err = errorf("%w: %w", ErrDecode, err)
+		}
+		return false
+	}
+
+	tok, err := d.json.Token()
+	if err != nil {
+		d.err = errorf("%w: %w", ErrDecode, err)
+		return false
+	}
+	key, _ := tok.(string)
+	var val any
+	if err := d.json.Decode(&val); err != nil {
+		d.err = errorf("%w: %s: %w", ErrDecode, key, err)
+		return false
+	}
+	v, err := New(key, val, false)
+	if err != nil {
+		d.err = errorf("%w: %s: %w", ErrDecode, key, err)
+		return false
+	}
+	d.v = v
+	return true
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package vars_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/vars"
+)
+
+func decodeAll(t *testing.T, dec *vars.Decoder) []string {
+	t.Helper()
+	var got []string
+	for dec.Next() {
+		got = append(got, dec.Variable().Name()+"="+dec.Variable().String())
+	}
+	return got
+}
+
+func TestDecoder(t *testing.T) {
+	tests := []struct {
+		name   string
+		format vars.Format
+		input  string
+		want   []string
+	}{
+		{"env", vars.FormatEnv, "A=1\n\nB=two\nC=\"quoted\"\n", []string{"A=1", "B=two", "C=quoted"}},
+		{"dotenv", vars.FormatDotenv, "# comment\nexport A=1\n  B = two \n", []string{"A=1", "B=two"}},
+		{"json", vars.FormatJSON, `{"A": 1, "B": "two", "C": true}`, []string{"A=1", "B=two", "C=true"}},
+		{"empty json", vars.FormatJSON, ``, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := vars.NewDecoder(strings.NewReader(tt.input), tt.format)
+			got := decodeAll(t, dec)
+			testutils.NoError(t, dec.Err())
+			testutils.Equal(t, strings.Join(tt.want, ","), strings.Join(got, ","))
+		})
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	dec := vars.NewDecoder(strings.NewReader("A=1\n=2\nC=3\n"), vars.FormatEnv)
+	got := decodeAll(t, dec)
+	testutils.Equal(t, "A=1", strings.Join(got, ","))
+	testutils.Equal(t, 2, dec.Line())
+	testutils.True(t, errors.Is(dec.Err(), vars.ErrDecode))
+	testutils.True(t, errors.Is(dec.Err(), vars.ErrKey))
+
+	dec = vars.NewDecoder(strings.NewReader(`["A"]`), vars.FormatJSON)
+	testutils.False(t, dec.Next())
+	testutils.True(t, errors.Is(dec.Err(), vars.ErrDecode))
+}
+
+func TestDecoderDotenvFile(t *testing.T) {
+	f, err := os.Open("testdata/dot_env")
+	testutils.NoError(t, err)
+	defer f.Close()
+
+	collection, err := vars.ParseMapFromSlice(nil)
+	testutils.NoError(t, err)
+	dec := vars.NewDecoder(f, vars.FormatDotenv)
+	for dec.Next() {
+		if v := dec.Variable(); strings.HasPrefix(v.Name(), "GOARCH") {
+			testutils.NoError(t, collection.Store(v.Name(), v))
+		}
+	}
+	testutils.NoError(t, dec.Err())
+	testutils.Equal(t, 1, collection.Len())
+	testutils.Equal(t, "amd64", collection.Get("GOARCH").String())
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package vars
+
+// interned are string values of common small values: empty string,
+// booleans and integers below nSmalls. They are shared, so that parsing
+// large variable sets e.g. environment or dotenv files does not allocate
+// for every such value.
+var interned = func() map[string]Value {
+	m := make(map[string]Value, nSmalls+3)
+	add := func(s string) {
+		m[s] = Value{raw: s, kind: KindString, str: s}
+	}
+	add("")
+	add("true")
+	add("false")
+	for i := range nSmalls {
+		add(small(i))
+	}
+	return m
+}()
+
+// stringValue returns Value of string s, interned value is used when
+// available.
+func stringValue(s string) Value {
+	if v, ok := interned[s]; ok {
+		return v
+	}
+	return Value{raw: s, kind: KindString, str: s}
+}
+
+// internString returns b as string without allocating when b is one of
+// interned values. Map lookup with converted key does not allocate.
+func internString(b []byte) string {
+	if v, ok := interned[string(b)]; ok {
+		return v.str
+	}
+	return string(b)
+}
+
+// string returns formatted value from parser buffer.
+func (p *parser) string() string {
+	return internString(p.buf)
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package vars_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/vars"
+)
+
+func TestInternedValues(t *testing.T) {
+	for _, in := range []any{"true", "false", "", "7", "42", true, 42, uint8(9)} {
+		allocs := testing.AllocsPerRun(100, func() {
+			if _, err := vars.NewValue(in); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs > 0 {
+			t.Errorf("NewValue(%#v) allocated %.0f times", in, allocs)
+		}
+	}
+
+	v, err := vars.ParseVariableFromString("KEY=\"true\"")
+	testutils.NoError(t, err)
+	testutils.Equal(t, vars.KindString, v.Kind())
+	testutils.Equal(t, "true", v.String())
+	testutils.True(t, v.Bool())
+
+	v, err = vars.ParseVariableFromString("KEY= 042 ")
+	testutils.NoError(t, err)
+	testutils.Equal(t, "042", v.String())
+}
+
+func BenchmarkParseMapFromSlice(b *testing.B) {
+	var lines []string
+	for i := range 200 {
+		switch i % 4 {
+		case 0:
+			lines = append(lines, fmt.Sprintf("KEY_%d=true", i))
+		case 1:
+			lines = append(lines, fmt.Sprintf("KEY_%d=%d", i, i%50))
+		case 2:
+			lines = append(lines, fmt.Sprintf("KEY_%d=some value %d", i, i))
+		default:
+			lines = append(lines, fmt.Sprintf("KEY_%d=\"quoted\"", i))
+		}
+	}
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := vars.ParseMapFromSlice(lines); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -22,6 +22,18 @@ type Map struct {
 // Error is returned when key or value parsing fails
 // or variable is already set and is readonly.
 func (m *Map) Store(key string, value any) error {
+	if v, ok := value.(Variable); ok && v.Name() == key {
+		return m.storeVariable(key, v)
+	}
+	v, err := New(key, value, false)
+	if err != nil {
+		return err
+	}
+	return m.storeVariable(key, v)
+}
+
+// storeVariable stores already parsed variable v for key.
+func (m *Map) storeVariable(key string, v Variable) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -33,24 +45,11 @@ func (m *Map) Store(key string, value any) error {
 	if has && curr.ReadOnly() {
 		return errorf("%w: can not set value for %s", ErrReadOnly, key)
 	}
-
-	if v, ok := value.(Variable); ok && v.Name() == key {
-		m.db[key] = v
-		if !has {
-			atomic.AddInt64(&m.len, 1)
-		}
-		return nil
-	}
-
-	v, err := New(key, value, false)
-	if err != nil {
-		return err
-	}
 	m.db[key] = v
 	if !has {
 		atomic.AddInt64(&m.len, 1)
 	}
-	return err
+	return nil
 }
 
 func (m *Map) StoreReadOnly(key string, value any, ro bool) error {
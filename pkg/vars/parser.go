@@ -161,7 +161,7 @@ func parseFloat(str string, bitSize int) (r float64, s string, err error) {
 	if e != nil {
 		err = errors.Join(ErrValueConv, e)
 	} else {
-		s = string(fastFtoa(make([]byte, 0, 24), r, 'g', -1, bitSize))
+		s = internString(fastFtoa(make([]byte, 0, 24), r, 'g', -1, bitSize))
 	}
 	return r, s, err
 }
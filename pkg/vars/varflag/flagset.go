@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 
 	"github.com/happy-sdk/happy/pkg/vars"
@@ -280,8 +281,10 @@ func (s *FlagSet) Parse(args []string) error {
 		}
 	}
 
-	// parse flags for sets
-	for _, set := range s.sets {
+	// parse flags for sets, set named first in args takes precedence
+	// so that arguments of set equal to name of another set are not
+	// mistaken as that set.
+	for _, set := range setsByPosition(s.sets, currargs) {
 		err := set.Parse(currargs)
 		if err != nil {
 			return err
@@ -303,6 +306,22 @@ func (s *FlagSet) Parse(args []string) error {
 	return s.extractArgs(currargs)
 }
 
+// setsByPosition returns sets ordered by position of their name in
+// args, sets not named in args keep their order after named sets.
+func setsByPosition(sets []Flags, args []string) []Flags {
+	pos := func(set Flags) int {
+		if i := slices.Index(args[min(1, len(args)):], set.Name()); i >= 0 {
+			return i
+		}
+		return len(args)
+	}
+	sorted := slices.Clone(sets)
+	slices.SortStableFunc(sorted, func(a, b Flags) int {
+		return pos(a) - pos(b)
+	})
+	return sorted
+}
+
 func (s *FlagSet) extractArgs(args []string) error {
 	if len(args) == 0 {
 		return nil
@@ -335,7 +354,7 @@ includessubset:
 	sargs := slicediff(args, used)
 
 	if s.argn == 0 && len(sargs) > 0 {
-		return fmt.Errorf("%w: %s does not accept arg %s", ErrInvalidArguments, s.name, sargs[0])
+		return &ArgError{Set: s.name, Arg: sargs[0]}
 	}
 
 	for _, arg := range sargs {
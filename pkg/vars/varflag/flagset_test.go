@@ -190,3 +190,20 @@ func TestShaddowFlags(t *testing.T) {
 		t.Error("f2 value should val2 got", f2.Value())
 	}
 }
+
+func TestFlagSetArgNamedAsSet(t *testing.T) {
+	global, err := NewFlagSet("app", 0)
+	testutils.NoError(t, err)
+	search, _ := New("search", "", "search term")
+	help, err := NewFlagSet("help", 1)
+	testutils.NoError(t, err)
+	testutils.NoError(t, help.Add(search))
+	deploy, err := NewFlagSet("deploy", 0)
+	testutils.NoError(t, err)
+	testutils.NoError(t, global.AddSet(deploy, help))
+
+	testutils.NoError(t, global.Parse([]string{"app", "help", "--search", "deploy"}))
+	testutils.True(t, help.Present(), "expected help to be present")
+	testutils.False(t, deploy.Present(), "expected deploy not to be present")
+	testutils.Equal(t, "deploy", search.String())
+}
@@ -118,6 +118,18 @@ func (f *OptionFlag) Value() []string {
 	return f.val
 }
 
+// Options returns sorted options flag accepts.
+func (f *OptionFlag) Options() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	opts := make([]string, 0, len(f.opts))
+	for opt := range f.opts {
+		opts = append(opts, opt)
+	}
+	sort.Strings(opts)
+	return opts
+}
+
 // Usage returns a usage description for that flag.
 func (f *OptionFlag) Usage() string {
 	f.mu.RLock()
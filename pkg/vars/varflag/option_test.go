@@ -6,6 +6,7 @@ package varflag
 
 import (
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -20,6 +21,13 @@ func TestOptionFlag(t *testing.T) {
 	}
 }
 
+func TestOptionFlagOptions(t *testing.T) {
+	flag, _ := Option("some-flag", []string{}, []string{"c", "a", "b"}, "")
+	if got := strings.Join(flag.Options(), ","); got != "a,b,c" {
+		t.Errorf("expected options a,b,c got %s", got)
+	}
+}
+
 func TestOptionFlagFalse(t *testing.T) {
 	flag, _ := Option("some-flag", []string{}, []string{"a", "b", "c"}, "", "s")
 	if present, err := flag.Parse([]string{"--some-flag=d"}); !errors.Is(err, ErrInvalidValue) {
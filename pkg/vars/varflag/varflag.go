@@ -48,6 +48,18 @@ var (
 	ErrInvalidArguments = errors.New("invalid arguments")
 )
 
+// ArgError records argument not accepted by flag set.
+type ArgError struct {
+	Set string // name of the flag set
+	Arg string // the argument
+}
+
+func (e *ArgError) Error() string {
+	return fmt.Sprintf("%s: %s does not accept arg %s", ErrInvalidArguments, e.Set, e.Arg)
+}
+
+func (e *ArgError) Unwrap() error { return ErrInvalidArguments }
+
 type (
 	FlagCreateFunc func() (Flag, error)
 
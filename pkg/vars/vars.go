@@ -45,6 +45,9 @@ var (
 	ErrRange = fmt.Errorf("%w: value out of range", ErrValue)
 	// ErrSyntax indicates that a value does not have the right syntax for the target type.
 	ErrSyntax = fmt.Errorf("%w: invalid syntax", ErrValue)
+
+	// ErrDecode is returned by Decoder when input can not be decoded.
+	ErrDecode = errors.New("decode error")
 )
 
 // KindOf returns kind for provided  value.
@@ -116,7 +119,10 @@ func ParseVariableFromString(kv string) (Variable, error) {
 		return EmptyVariable, fmt.Errorf("%w: failed to parse variable key", err)
 	}
 
-	return New(key, normalizeValue(v), false)
+	return Variable{
+		name: key,
+		val:  stringValue(normalizeValue(v)),
+	}, nil
 }
 
 // NewValue parses provided val into Value
@@ -136,6 +142,13 @@ func NewValue(val any) (Value, error) {
 		}
 		return vv.val, nil
 	}
+	// strings are not copied through parser buffer
+	if str, ok := val.(string); ok {
+		if v, ok := interned[str]; ok {
+			return v, nil
+		}
+		return Value{raw: val, kind: KindString, str: str}, nil
+	}
 	p := getParser()
 	defer p.free()
 
@@ -143,7 +156,7 @@ func NewValue(val any) (Value, error) {
 	v := Value{
 		raw:      p.val,
 		kind:     kind,
-		str:      string(p.buf),
+		str:      p.string(),
 		isCustom: p.isCustom,
 	}
 	return v, err
@@ -161,12 +174,12 @@ func NewValueAs(val any, kind Kind) (Value, error) {
 		return Value{
 			raw:      p.val,
 			kind:     kind,
-			str:      string(p.buf),
+			str:      p.string(),
 			isCustom: p.isCustom,
 		}, nil
 	}
 
-	str := string(p.buf)
+	str := p.string()
 	p.free()
 
 	if v, err := convert(val, akind, kind); err == nil {
@@ -331,7 +344,7 @@ func convert(raw any, from, to Kind) (Value, error) {
 				if _, err := p.parseValue(v); err != nil {
 					return EmptyValue, err
 				}
-				v.str = string(p.buf)
+				v.str = p.string()
 				return v, nil
 			}
 		}
@@ -342,7 +355,7 @@ func convert(raw any, from, to Kind) (Value, error) {
 				if _, err := p.parseValue(v); err != nil {
 					return EmptyValue, err
 				}
-				v.str = string(p.buf)
+				v.str = p.string()
 				return v, nil
 			}
 		}
@@ -353,7 +366,7 @@ func convert(raw any, from, to Kind) (Value, error) {
 				if _, err := p.parseValue(v); err != nil {
 					return EmptyValue, err
 				}
-				v.str = string(p.buf)
+				v.str = p.string()
 				return v, nil
 			}
 		}
@@ -474,7 +487,7 @@ func ParseMapFromSlice(kv []string) (*Map, error) {
 		if err != nil {
 			return nil, err
 		}
-		if err := vars.Store(vv.Name(), vv.Value()); err != nil {
+		if err := vars.storeVariable(vv.Name(), vv); err != nil {
 			return nil, err
 		}
 	}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint is set of version ranges, e.g. ">=1.2.0 <2.0.0 || ^3.1".
+// Comparators separated by space or comma must all match and ranges
+// separated by "||" are alternatives. Supported operators are =, !=,
+// >, >=, <, <=, ~ (same minor) and ^ (same major, same minor for v0).
+// Pre-release versions only match when range has comparator with
+// pre-release of same major, minor and patch.
+type Constraint struct {
+	raw    string
+	ranges [][]comparator
+}
+
+type comparator struct {
+	op string
+	v  Version
+}
+
+// ParseConstraint parses version constraint.
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{raw: strings.TrimSpace(s)}
+	for _, rng := range strings.Split(s, "||") {
+		fields := strings.FieldsFunc(rng, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%w: empty range in constraint %q", Error, s)
+		}
+		var comps []comparator
+		for _, field := range fields {
+			cc, err := parseComparator(field)
+			if err != nil {
+				return nil, err
+			}
+			comps = append(comps, cc...)
+		}
+		c.ranges = append(c.ranges, comps)
+	}
+	return c, nil
+}
+
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Check reports whether version v satisfies the constraint.
+func (c *Constraint) Check(v Version) bool {
+	if !IsValid(v.String()) {
+		return false
+	}
+	for _, rng := range c.ranges {
+		if matchRange(rng, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRange(rng []comparator, v Version) bool {
+	for _, cc := range rng {
+		if !cc.match(v) {
+			return false
+		}
+	}
+	if v.Prerelease() == "" {
+		return true
+	}
+	for _, cc := range rng {
+		if cc.v.Prerelease() != "" && cc.v.Major() == v.Major() &&
+			cc.v.Minor() == v.Minor() && cc.v.Patch() == v.Patch() {
+			return true
+		}
+	}
+	return false
+}
+
+func (cc comparator) match(v Version) bool {
+	cmp := v.Compare(cc.v)
+	switch cc.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+func parseComparator(s string) ([]comparator, error) {
+	op := ""
+	for _, o := range []string{">=", "<=", "!=", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(s, o) {
+			op = o
+			break
+		}
+	}
+	raw := strings.TrimPrefix(s, op)
+	v, err := Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid constraint %q", Error, s)
+	}
+	// shorthand v1 and v1.2 are upper bound exclusive ranges
+	short := strings.Count(strings.TrimPrefix(raw, "v"), ".") < 2
+
+	switch op {
+	case "", "=":
+		if !short {
+			return []comparator{{"=", v}}, nil
+		}
+		next, _ := v.Next(BumpMinor)
+		if strings.Count(raw, ".") == 0 {
+			next, _ = v.Next(BumpMajor)
+		}
+		return []comparator{{">=", v}, {"<", next}}, nil
+	case "~":
+		next, _ := v.Next(BumpMinor)
+		return []comparator{{">=", v}, {"<", next}}, nil
+	case "^":
+		var next Version
+		switch {
+		case v.Major() > 0:
+			next, _ = v.Next(BumpMajor)
+		case v.Minor() > 0:
+			next, _ = v.Next(BumpMinor)
+		default:
+			next, _ = v.Next(BumpPatch)
+		}
+		return []comparator{{">=", v}, {"<", next}}, nil
+	}
+	return []comparator{{op, v}}, nil
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Build metadata injected with linker flags, injected values take
+// precedence over values read from build info e.g.
+//
+//	go build -ldflags "-X github.com/happy-sdk/happy/pkg/version.buildVersion=v1.2.3 \
+//	  -X github.com/happy-sdk/happy/pkg/version.buildTime=2024-06-01T10:00:00Z"
+var (
+	buildVersion  string
+	buildRevision string
+	buildTime     string
+)
+
+// Names of injectable build metadata variables for -X linker flag.
+const (
+	LdflagsVersion  = "github.com/happy-sdk/happy/pkg/version.buildVersion"
+	LdflagsRevision = "github.com/happy-sdk/happy/pkg/version.buildRevision"
+	LdflagsTime     = "github.com/happy-sdk/happy/pkg/version.buildTime"
+)
+
+// Info is build information of running binary.
+type Info struct {
+	Version  string `json:"version"`
+	Module   string `json:"module"`
+	Revision string `json:"revision,omitempty"`
+	// Time is RFC 3339 build time or commit time when build time
+	// was not injected.
+	Time string `json:"time,omitempty"`
+	// Modified reports whether binary was built from modified
+	// working tree.
+	Modified  bool   `json:"modified"`
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	Compiler  string `json:"compiler"`
+}
+
+var (
+	infoOnce sync.Once
+	info     Info
+)
+
+// ReadInfo returns build information of running binary, it is read
+// once from debug.ReadBuildInfo and values injected with linker flags.
+func ReadInfo() Info {
+	infoOnce.Do(func() {
+		info = Info{
+			Version:   Current().String(),
+			GoVersion: runtime.Version(),
+			OS:        runtime.GOOS,
+			Arch:      runtime.GOARCH,
+			Compiler:  runtime.Compiler,
+		}
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			info.Module = bi.Main.Path
+			info.GoVersion = bi.GoVersion
+			for _, s := range bi.Settings {
+				switch s.Key {
+				case "vcs.revision":
+					info.Revision = s.Value
+				case "vcs.time":
+					info.Time = s.Value
+				case "vcs.modified":
+					info.Modified = s.Value == "true"
+				}
+			}
+		}
+		if buildRevision != "" {
+			info.Revision = buildRevision
+		}
+		if buildTime != "" {
+			info.Time = buildTime
+		}
+	})
+	return info
+}
+
+// BuildTime returns parsed Time, zero time when unknown.
+func (i Info) BuildTime() time.Time {
+	t, _ := time.Parse(time.RFC3339, i.Time)
+	return t
+}
+
+// String returns info as key value lines.
+func (i Info) String() string {
+	var b strings.Builder
+	row := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%-10s %s\n", key, value)
+		}
+	}
+	row("version", i.Version)
+	row("module", i.Module)
+	row("revision", i.Revision)
+	row("time", i.Time)
+	if i.Modified {
+		row("modified", "true")
+	}
+	row("go", i.GoVersion)
+	row("platform", i.OS+"/"+i.Arch)
+	row("compiler", i.Compiler)
+	return b.String()
+}
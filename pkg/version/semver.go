@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// Bump is kind of version increment.
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	}
+	return "none"
+}
+
+// Canonical returns v with "v" prefix, it does not validate v.
+func Canonical(v string) string {
+	if v != "" && !strings.HasPrefix(v, "v") {
+		return "v" + v
+	}
+	return v
+}
+
+// IsValid reports whether v is valid semantic version with or without
+// "v" prefix.
+func IsValid(v string) bool {
+	return semver.IsValid(Canonical(v))
+}
+
+// Compare returns an integer comparing two versions according to
+// semantic version precedence, build metadata is ignored. Invalid
+// version is considered less than valid one.
+func Compare(a, b string) int {
+	return semver.Compare(Canonical(a), Canonical(b))
+}
+
+// Compare compares v to other, see Compare.
+func (v Version) Compare(other Version) int {
+	return Compare(v.String(), other.String())
+}
+
+// Major returns major version number of v.
+func (v Version) Major() uint64 {
+	major, _, _ := v.numbers()
+	return major
+}
+
+// Minor returns minor version number of v.
+func (v Version) Minor() uint64 {
+	_, minor, _ := v.numbers()
+	return minor
+}
+
+// Patch returns patch version number of v.
+func (v Version) Patch() uint64 {
+	_, _, patch := v.numbers()
+	return patch
+}
+
+// Prerelease returns pre-release identifiers of v without leading "-".
+func (v Version) Prerelease() string {
+	return Prerelease(Canonical(v.String()))
+}
+
+// WithPrerelease returns v with pre-release identifiers pre, empty pre
+// removes pre-release. Build metadata is preserved.
+func (v Version) WithPrerelease(pre string) (Version, error) {
+	return v.with(strings.TrimPrefix(pre, "-"), v.Build())
+}
+
+// WithBuild returns v with build metadata build, empty build removes
+// build metadata.
+func (v Version) WithBuild(build string) (Version, error) {
+	return v.with(v.Prerelease(), strings.TrimPrefix(build, "+"))
+}
+
+// Next returns v incremented by bump. Pre-release and build metadata
+// are dropped, next version of pre-release is its release when bump
+// does not go past it e.g. v1.1.0-rc.1 bumped by minor is v1.1.0.
+func (v Version) Next(bump Bump) (Version, error) {
+	if !IsValid(v.String()) {
+		return "", fmt.Errorf("%w: invalid version %q", Error, v)
+	}
+	major, minor, patch := v.numbers()
+	pre := v.Prerelease() != ""
+	switch bump {
+	case BumpNone:
+		return v, nil
+	case BumpMajor:
+		if !pre || minor != 0 || patch != 0 {
+			major, minor, patch = major+1, 0, 0
+		}
+	case BumpMinor:
+		if !pre || patch != 0 {
+			minor, patch = minor+1, 0
+		}
+	case BumpPatch:
+		if !pre {
+			patch++
+		}
+	}
+	return Version(fmt.Sprintf("v%d.%d.%d", major, minor, patch)), nil
+}
+
+func (v Version) with(pre, build string) (Version, error) {
+	major, minor, patch := v.numbers()
+	s := fmt.Sprintf("v%d.%d.%d", major, minor, patch)
+	if pre != "" {
+		s += "-" + pre
+	}
+	if build != "" {
+		s += "+" + build
+	}
+	if !semver.IsValid(s) {
+		return "", fmt.Errorf("%w: invalid version %q", Error, s)
+	}
+	return Version(s), nil
+}
+
+func (v Version) numbers() (major, minor, patch uint64) {
+	c := semver.Canonical(Canonical(v.String()))
+	if c == "" {
+		return 0, 0, 0
+	}
+	c, _, _ = strings.Cut(strings.TrimPrefix(c, "v"), "-")
+	c, _, _ = strings.Cut(c, "+")
+	parts := strings.SplitN(c, ".", 3)
+	major, _ = strconv.ParseUint(parts[0], 10, 64)
+	minor, _ = strconv.ParseUint(parts[1], 10, 64)
+	patch, _ = strconv.ParseUint(parts[2], 10, 64)
+	return
+}
+
+// BumpFromCommits returns version increment required by commit messages
+// following conventional commits: breaking change bumps major, feat
+// bumps minor and fix, perf and others bump patch. Merge commits and
+// commits of types chore, docs, style, test, ci and build are ignored.
+func BumpFromCommits(commits []string) Bump {
+	bump := BumpNone
+	for _, msg := range commits {
+		if b := commitBump(msg); b > bump {
+			bump = b
+		}
+	}
+	return bump
+}
+
+// NextFromCommits returns next version of v for commit messages, see
+// BumpFromCommits. While major version is 0 breaking changes bump minor.
+func (v Version) NextFromCommits(commits []string) (Version, error) {
+	bump := BumpFromCommits(commits)
+	if bump == BumpMajor && v.Major() == 0 {
+		bump = BumpMinor
+	}
+	return v.Next(bump)
+}
+
+func commitBump(msg string) Bump {
+	subject, body, _ := strings.Cut(msg, "\n")
+	if strings.Contains(body, "BREAKING CHANGE:") || strings.Contains(body, "BREAKING-CHANGE:") {
+		return BumpMajor
+	}
+	head, _, ok := strings.Cut(subject, ":")
+	if !ok {
+		return BumpNone
+	}
+	if strings.HasSuffix(head, "!") {
+		return BumpMajor
+	}
+	typ, _, _ := strings.Cut(head, "(")
+	switch strings.ToLower(strings.TrimSpace(typ)) {
+	case "feat":
+		return BumpMinor
+	case "chore", "docs", "style", "test", "ci", "build", "merge":
+		return BumpNone
+	}
+	return BumpPatch
+}
@@ -30,8 +30,14 @@ func (v Version) Build() string {
 	return strings.Trim(semver.Build(v.String()), "+")
 }
 
-// Current tryes to read version info from go module being built.
+// Current tryes to read version info from go module being built,
+// version injected with linker flags takes precedence.
 func Current() Version {
+	if buildVersion != "" {
+		if v, err := Parse(buildVersion); err == nil {
+			return v
+		}
+	}
 	bi, ok := debug.ReadBuildInfo()
 	if !ok {
 		return Version(fmt.Sprintf("v0.0.1-devel+%d", time.Now().UnixMilli()))
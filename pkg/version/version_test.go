@@ -3,3 +3,120 @@
 // Copyright © 2022 The Happy Authors
 
 package version
+
+import "testing"
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		v    Version
+		bump Bump
+		want Version
+	}{
+		{"v1.2.3", BumpPatch, "v1.2.4"},
+		{"v1.2.3", BumpMinor, "v1.3.0"},
+		{"v1.2.3", BumpMajor, "v2.0.0"},
+		{"v1.2.3+build", BumpPatch, "v1.2.4"},
+		{"v1.3.0-rc.1", BumpMinor, "v1.3.0"},
+		{"v1.3.1-rc.1", BumpMinor, "v1.4.0"},
+		{"v2.0.0-alpha", BumpMajor, "v2.0.0"},
+		{"1.0.0", BumpNone, "1.0.0"},
+	}
+	for _, tt := range tests {
+		got, err := tt.v.Next(tt.bump)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s.Next(%s) = %s, want %s", tt.v, tt.bump, got, tt.want)
+		}
+	}
+}
+
+func TestNextFromCommits(t *testing.T) {
+	tests := []struct {
+		v       Version
+		commits []string
+		want    Version
+	}{
+		{"v1.2.3", []string{"docs: readme"}, "v1.2.3"},
+		{"v1.2.3", []string{"fix(cli): flags", "docs: readme"}, "v1.2.4"},
+		{"v1.2.3", []string{"fix: a", "feat: b"}, "v1.3.0"},
+		{"v1.2.3", []string{"feat!: drop api"}, "v2.0.0"},
+		{"v1.2.3", []string{"fix: a\n\nBREAKING CHANGE: removed"}, "v2.0.0"},
+		{"v0.4.1", []string{"feat(api)!: drop api"}, "v0.5.0"},
+	}
+	for _, tt := range tests {
+		got, err := tt.v.NextFromCommits(tt.commits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s.NextFromCommits(%q) = %s, want %s", tt.v, tt.commits, got, tt.want)
+		}
+	}
+}
+
+func TestWithPrereleaseAndBuild(t *testing.T) {
+	v, err := Version("v1.2.3+abc").WithPrerelease("rc.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "v1.2.3-rc.1+abc" {
+		t.Errorf("WithPrerelease = %s", v)
+	}
+	if v, err = v.WithBuild(""); err != nil || v != "v1.2.3-rc.1" {
+		t.Errorf("WithBuild = %s, %v", v, err)
+	}
+	if _, err := v.WithPrerelease("bad..pre"); err == nil {
+		t.Error("expected error for invalid pre-release")
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		v          Version
+		want       bool
+	}{
+		{">=1.2.0 <2.0.0", "v1.5.0", true},
+		{">=1.2.0, <2.0.0", "v2.0.0", false},
+		{"^1.2.0", "v1.9.9", true},
+		{"^1.2.0", "v2.0.0", false},
+		{"^0.2.3", "v0.2.9", true},
+		{"^0.2.3", "v0.3.0", false},
+		{"~1.2.3", "v1.2.9", true},
+		{"~1.2.3", "v1.3.0", false},
+		{"1.2", "v1.2.7", true},
+		{"1", "v1.9.0", true},
+		{"1", "v2.0.0", false},
+		{"!=1.0.0", "v1.0.0", false},
+		{"<1.0.0 || >=3.0.0", "v3.1.0", true},
+		{"<1.0.0 || >=3.0.0", "v2.1.0", false},
+		{">=1.0.0", "v1.1.0-rc.1", false},
+		{">=1.1.0-rc.0", "v1.1.0-rc.1", true},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Check(tt.v); got != tt.want {
+			t.Errorf("%q.Check(%s) = %t, want %t", tt.constraint, tt.v, got, tt.want)
+		}
+	}
+	if _, err := ParseConstraint(">=x"); err == nil {
+		t.Error("expected error for invalid constraint")
+	}
+}
+
+func TestCurrentInjected(t *testing.T) {
+	defer func(v string) { buildVersion = v }(buildVersion)
+	buildVersion = "1.2.3"
+	if got := Current(); got != "v1.2.3" {
+		t.Errorf("Current() = %s, want v1.2.3", got)
+	}
+	buildVersion = "invalid"
+	if got := Current(); got == "invalid" {
+		t.Errorf("Current() returned invalid injected version")
+	}
+}
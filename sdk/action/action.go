@@ -23,26 +23,34 @@ type Tock func(sess *session.Context, delta time.Duration, tps int) error
 type WithPrevErr func(sess *session.Context, err error) error
 type WithOptions func(sess *session.Context, opts *options.Options) error
 
+// Exit is called with exit code when application exits.
+type Exit func(sess *session.Context, code int) error
+
 type Args interface {
 	Arg(i uint) vars.Value
 	ArgDefault(i uint, value any) (vars.Value, error)
 	Args() []vars.Value
 	Argn() uint
 	Flag(name string) varflag.Flag
+	// Passthrough returns arguments which were not parsed, arguments
+	// after "--" and with passthrough flag parsing also unknown flags.
+	Passthrough() []string
 }
 
 type args struct {
-	args  []vars.Value
-	argn  uint
-	flags varflag.Flags
+	args        []vars.Value
+	argn        uint
+	flags       varflag.Flags
+	passthrough []string
 }
 
-func NewArgs(flags varflag.Flags) Args {
+func NewArgs(flags varflag.Flags, passthrough ...string) Args {
 	fargs := flags.Args()
 	return &args{
-		args:  fargs,
-		argn:  uint(len(fargs)),
-		flags: flags,
+		args:        fargs,
+		argn:        uint(len(fargs)),
+		flags:       flags,
+		passthrough: passthrough,
 	}
 }
 
@@ -82,3 +90,7 @@ func (a *args) Flag(name string) varflag.Flag {
 	}
 	return f
 }
+
+func (a *args) Passthrough() []string {
+	return a.passthrough
+}
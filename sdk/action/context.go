@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package action
+
+import (
+	"context"
+	"time"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+// TickContext is Tick receiving context which is cancelled when
+// application or service stops and when invocation exceeds its
+// deadline, handlers pass it to network calls instead of polling
+// sess.Done.
+type TickContext func(ctx context.Context, sess *session.Context, ts time.Time, delta time.Duration) error
+
+// TockContext is Tock receiving context, see TickContext.
+type TockContext func(ctx context.Context, sess *session.Context, delta time.Duration, tps int) error
+
+// WithContext is Action receiving context, e.g. cron job which context
+// is cancelled when service stops and when job is due again.
+type WithContext func(ctx context.Context, sess *session.Context) error
+
+// ContextTick returns a as TickContext ignoring context.
+func ContextTick(a Tick) TickContext {
+	if a == nil {
+		return nil
+	}
+	return func(_ context.Context, sess *session.Context, ts time.Time, delta time.Duration) error {
+		return a(sess, ts, delta)
+	}
+}
+
+// ContextTock returns a as TockContext ignoring context.
+func ContextTock(a Tock) TockContext {
+	if a == nil {
+		return nil
+	}
+	return func(_ context.Context, sess *session.Context, delta time.Duration, tps int) error {
+		return a(sess, delta, tps)
+	}
+}
+
+// ContextAction returns a as WithContext ignoring context.
+func ContextAction(a Action) WithContext {
+	if a == nil {
+		return nil
+	}
+	return func(_ context.Context, sess *session.Context) error {
+		return a(sess)
+	}
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package action
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Group runs functions concurrently with shared context, first error
+// cancels context of the group so that remaining functions can stop
+// early. Zero Group is not usable, use NewGroup.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	results []GroupResult
+	failed  bool
+}
+
+// GroupResult is result of function run by Group.
+type GroupResult struct {
+	Name string
+	// Err is error returned by function, nil when it succeeded.
+	Err error
+	// Canceled is set when function failed after group was canceled
+	// by error of other function.
+	Canceled bool
+}
+
+// GroupError is returned by Group.Wait when any function failed,
+// it holds results of all functions in order they were added.
+type GroupError struct {
+	Results []GroupResult
+}
+
+// NewGroup returns Group which context is derived from ctx,
+// e.g. session.
+func NewGroup(ctx context.Context) *Group {
+	g := &Group{}
+	g.ctx, g.cancel = context.WithCancelCause(ctx)
+	return g
+}
+
+// Go calls fn in new goroutine, panic is returned as *PanicError.
+func (g *Group) Go(name string, fn func(ctx context.Context) error) {
+	g.mu.Lock()
+	i := len(g.results)
+	g.results = append(g.results, GroupResult{Name: name})
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		err := Try(func() error { return fn(g.ctx) })
+		if err == nil {
+			return
+		}
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.results[i].Err = err
+		if g.failed {
+			g.results[i].Canceled = true
+			return
+		}
+		g.failed = true
+		g.cancel(err)
+	}()
+}
+
+// Wait blocks until all functions returned, it returns *GroupError
+// when any of them failed.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.failed {
+		return nil
+	}
+	return &GroupError{Results: append([]GroupResult(nil), g.results...)}
+}
+
+// Failed returns results of functions which failed.
+func (e *GroupError) Failed() []GroupResult {
+	var failed []GroupResult
+	for _, res := range e.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+func (e *GroupError) Error() string {
+	var msgs []string
+	for _, res := range e.Failed() {
+		if res.Canceled {
+			msgs = append(msgs, res.Name+": canceled: "+res.Err.Error())
+			continue
+		}
+		msgs = append(msgs, res.Name+": "+res.Err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *GroupError) Unwrap() []error {
+	var errs []error
+	for _, res := range e.Failed() {
+		errs = append(errs, res.Err)
+	}
+	return errs
+}
+
+// Cause returns error of function which failed first.
+func (e *GroupError) Cause() error {
+	for _, res := range e.Failed() {
+		if !res.Canceled {
+			return res.Err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+)
+
+func TestGroup(t *testing.T) {
+	errFail := errors.New("fail")
+
+	g := NewGroup(context.Background())
+	g.Go("ok", func(ctx context.Context) error { return nil })
+	g.Go("fail", func(ctx context.Context) error { return errFail })
+	g.Go("wait", func(ctx context.Context) error {
+		<-ctx.Done()
+		return context.Cause(ctx)
+	})
+	g.Go("panic", func(ctx context.Context) error {
+		<-ctx.Done()
+		panic("boom")
+	})
+
+	err := g.Wait()
+	var gerr *GroupError
+	if !testutils.True(t, errors.As(err, &gerr), "error must be *GroupError") {
+		return
+	}
+	testutils.ErrorIs(t, err, errFail)
+	testutils.ErrorIs(t, err, ErrPanic)
+	testutils.Equal(t, errFail, gerr.Cause())
+
+	want := []struct {
+		name     string
+		failed   bool
+		canceled bool
+	}{
+		{name: "ok"},
+		{name: "fail", failed: true},
+		{name: "wait", failed: true, canceled: true},
+		{name: "panic", failed: true, canceled: true},
+	}
+	testutils.Equal(t, len(want), len(gerr.Results))
+	for i, w := range want {
+		res := gerr.Results[i]
+		testutils.Equal(t, w.name, res.Name)
+		testutils.Equal(t, w.failed, res.Err != nil, w.name+" failed")
+		testutils.Equal(t, w.canceled, res.Canceled, w.name+" canceled")
+	}
+}
+
+func TestGroupSuccess(t *testing.T) {
+	g := NewGroup(context.Background())
+	for _, name := range []string{"a", "b"} {
+		g.Go(name, func(ctx context.Context) error { return nil })
+	}
+	testutils.NoError(t, g.Wait())
+}
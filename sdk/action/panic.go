@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package action
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrPanic is the error matched by errors.Is for recovered panics.
+var ErrPanic = errors.New("panic")
+
+// PanicError is returned in place of panic recovered from user provided
+// action, service hook or cron job.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+// NewPanicError returns PanicError for recovered value r with current stack.
+func NewPanicError(r any) *PanicError {
+	return &PanicError{
+		Value: r,
+		Stack: debug.Stack(),
+	}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s: %v (recovered)", ErrPanic.Error(), e.Value)
+}
+
+func (e *PanicError) Unwrap() []error {
+	if err, ok := e.Value.(error); ok {
+		return []error{ErrPanic, err}
+	}
+	return []error{ErrPanic}
+}
+
+// Recover converts panic into *PanicError assigned to err.
+// It must be deferred directly:
+//
+//	defer action.Recover(&err)
+func Recover(err *error) {
+	if r := recover(); r != nil {
+		*err = NewPanicError(r)
+	}
+}
+
+// Try calls fn and returns its error or *PanicError when fn panics.
+func Try(fn func() error) (err error) {
+	defer Recover(&err)
+	return fn()
+}
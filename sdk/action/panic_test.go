@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package action
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+)
+
+func TestTry(t *testing.T) {
+	errFail := errors.New("fail")
+
+	tests := []struct {
+		name    string
+		fn      func() error
+		wantErr error
+		panics  bool
+	}{
+		{name: "nil", fn: func() error { return nil }},
+		{name: "error", fn: func() error { return errFail }, wantErr: errFail},
+		{name: "panic", fn: func() error { panic("boom") }, wantErr: ErrPanic, panics: true},
+		{name: "panic error", fn: func() error { panic(errFail) }, wantErr: errFail, panics: true},
+		{name: "nil map", fn: func() error {
+			var m map[string]int
+			m["a"] = 1
+			return nil
+		}, wantErr: ErrPanic, panics: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Try(tt.fn)
+			if tt.wantErr == nil {
+				testutils.NoError(t, err)
+				return
+			}
+			testutils.ErrorIs(t, err, tt.wantErr)
+			var perr *PanicError
+			if !tt.panics {
+				testutils.False(t, errors.As(err, &perr), "error must not be *PanicError")
+				return
+			}
+			if !testutils.True(t, errors.As(err, &perr), "want *PanicError") {
+				return
+			}
+			testutils.ErrorIs(t, err, ErrPanic)
+			testutils.HasPrefix(t, err.Error(), "panic: ")
+			testutils.True(t, strings.Contains(string(perr.Stack), "panic_test.go"), "stack must include panicking function")
+		})
+	}
+}
+
+func TestRecover(t *testing.T) {
+	errFail := errors.New("fail")
+	call := func(panics bool) (err error) {
+		defer Recover(&err)
+		if panics {
+			panic("boom")
+		}
+		return errFail
+	}
+
+	testutils.ErrorIs(t, call(false), errFail)
+
+	err := call(true)
+	var perr *PanicError
+	if testutils.True(t, errors.As(err, &perr), "want *PanicError") {
+		testutils.EqualAny(t, "boom", perr.Value)
+		testutils.True(t, len(perr.Stack) > 0, "stack must be recorded")
+	}
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package action
+
+import "github.com/happy-sdk/happy/sdk/app/session"
+
+// WithArgsResult is Do action which returns Result in addition to error.
+type WithArgsResult func(sess *session.Context, args Args) (*Result, error)
+
+// WithResult is AfterSuccess action which receives Result of Do action,
+// result is nil when Do action did not return any.
+type WithResult func(sess *session.Context, res *Result) error
+
+// Result is typed outcome of command Do action. It is passed to
+// AfterSuccess action and serialized by runtime when --output=json is set.
+type Result struct {
+	Value     any        `json:"value,omitempty"`
+	Warnings  []string   `json:"warnings,omitempty"`
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+}
+
+// Artifact is file or resource produced by command.
+type Artifact struct {
+	Name string `json:"name"`
+	Path string `json:"path,omitempty"`
+	Kind string `json:"kind,omitempty"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// NewResult returns Result with value.
+func NewResult(value any) *Result {
+	return &Result{Value: value}
+}
+
+// Warn adds warning to result.
+func (r *Result) Warn(msg string) *Result {
+	r.Warnings = append(r.Warnings, msg)
+	return r
+}
+
+// AddArtifact adds artifact to result.
+func (r *Result) AddArtifact(a Artifact) *Result {
+	r.Artifacts = append(r.Artifacts, a)
+	return r
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package addon
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+// OptionsPrefix is namespace of addon runtime options,
+// options of addon are keyed addon.<slug>.<key>. Former <slug>.<key>
+// keys are deprecated aliases, see Manager.ExtendOptions.
+const OptionsPrefix = "addon."
+
+// sdkPrefix is package path prefix of SDK packages, frames from SDK
+// packages are skipped when looking up which addon sets option.
+const sdkPrefix = "github.com/happy-sdk/happy/"
+
+// AccessError is returned when addon sets option outside of its namespace.
+type AccessError struct {
+	// Addon is slug of addon which attempted to set the option.
+	Addon string
+	Key   string
+	// Caller is function and location which attempted to set the option,
+	// empty when option was set through session owned by addon.
+	Caller string
+}
+
+func (e *AccessError) Error() string {
+	msg := fmt.Sprintf("%s: addon %s is not allowed to set option %s", ErrPermission.Error(), e.Addon, e.Key)
+	if e.Caller != "" {
+		msg += " (" + e.Caller + ")"
+	}
+	return msg
+}
+
+func (e *AccessError) Unwrap() error {
+	return ErrPermission
+}
+
+// GuardOptions protects runtime options of the session so that addons
+// can only set options within own addon.<slug>.* namespace. Addon hooks
+// are called with session owned by addon, so writes through it are
+// attributed to addon explicitly. Other writes e.g. from addon commands
+// and services are attributed by inspecting callers. Application code
+// is allowed to set any option. Violations are logged at BUG level.
+func (m *Manager) GuardOptions(sess *session.Context) {
+	sessions := make(map[string]*session.Context, len(m.addons))
+	owners := make(map[string]string, len(m.addons))
+	ambiguous := make(map[string]bool)
+	for _, addon := range m.addons {
+		sessions[addon.info.Slug] = session.Owned(sess, addon.info.Slug)
+		// addons declared in package main can not be told apart from application.
+		if addon.info.Module != "" && addon.info.Module != "main" {
+			if _, ok := owners[addon.info.Module]; ok {
+				// addons declared in same package can not be told apart.
+				ambiguous[addon.info.Module] = true
+			}
+			owners[addon.info.Module] = addon.info.Slug
+		}
+	}
+	for module := range ambiguous {
+		delete(owners, module)
+	}
+	m.mu.Lock()
+	m.sessions = sessions
+	m.mu.Unlock()
+
+	sess.Opts().AddWriteGuard(func(owner, key string) error {
+		slug, caller := owner, ""
+		if _, ok := sessions[slug]; !ok {
+			var found bool
+			if len(owners) == 0 {
+				return nil
+			}
+			if slug, caller, found = callingAddon(owners); !found {
+				return nil
+			}
+		}
+		if strings.HasPrefix(key, OptionsPrefix+slug+".") {
+			return nil
+		}
+		err := &AccessError{Addon: slug, Key: key, Caller: caller}
+		sess.Log().BUG(
+			"addon attempted to set option outside of its namespace",
+			slog.String("addon", slug),
+			slog.String("option", key),
+			slog.String("caller", caller),
+		)
+		return err
+	})
+}
+
+// session returns session owned by addon with slug, or sess when
+// options are not guarded.
+func (m *Manager) session(sess *session.Context, slug string) *session.Context {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if owned, ok := m.sessions[slug]; ok {
+		return owned
+	}
+	return sess
+}
+
+// callingAddon returns slug of addon which is nearest non SDK caller.
+func callingAddon(owners map[string]string) (slug, caller string, ok bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		pkg := funcPackage(frame.Function)
+		for module, s := range owners {
+			if pkg == module || strings.HasPrefix(pkg, module+"/") {
+				return s, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line), true
+			}
+		}
+		if isThirdParty(pkg) && !strings.HasPrefix(pkg, sdkPrefix) {
+			// application code
+			return "", "", false
+		}
+		if !more {
+			return "", "", false
+		}
+	}
+}
+
+// isThirdParty reports whether pkg is not standard library package.
+func isThirdParty(pkg string) bool {
+	elem, _, _ := strings.Cut(pkg, "/")
+	return strings.Contains(elem, ".")
+}
+
+// funcPackage returns package path of fully qualified function name
+// e.g. github.com/org/repo/pkg.(*T).Method.
+func funcPackage(fn string) string {
+	slash := strings.LastIndex(fn, "/")
+	if dot := strings.Index(fn[slash+1:], "."); dot >= 0 {
+		return fn[:slash+1+dot]
+	}
+	return fn
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package addon_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/happy-sdk/happy"
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/options"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/addon"
+	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/logging"
+)
+
+func TestGuardOptions(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		errs = make(map[string]error)
+		// both hooks run concurrently before any of them returns.
+		started sync.WaitGroup
+	)
+	started.Add(2)
+	record := func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs[name] = err
+	}
+	configure := func(slug, other string) action.Action {
+		return func(sess *session.Context) error {
+			started.Done()
+			started.Wait()
+			record(slug+" own", sess.Opts().Set("addon."+slug+".x", slug))
+			record(slug+" other", sess.Opts().Set("addon."+other+".x", slug))
+			record(slug+" legacy", sess.Opts().Set(slug+".y", slug))
+			return nil
+		}
+	}
+
+	a := addon.New(addon.Config{Name: "a"},
+		options.NewOption("x", "", "x", options.KindConfig, nil),
+		options.NewOption("y", "", "y", options.KindConfig, nil),
+	)
+	a.OnConfigure(configure("a", "b"))
+	b := addon.New(addon.Config{Name: "b"},
+		options.NewOption("x", "", "x", options.KindConfig, nil),
+		options.NewOption("y", "", "y", options.KindConfig, nil),
+	)
+	b.OnConfigure(configure("b", "a"))
+
+	main := app.New(happy.Settings{Slug: "happy-guard-options-test"})
+	main.WithLogger(logging.NewTestLogger(logging.LevelQuiet))
+	main.WithAddon(a)
+	main.WithAddon(b)
+	var values []string
+	main.Do(func(sess *session.Context, args action.Args) error {
+		values = []string{
+			sess.Get("addon.a.x").String(),
+			sess.Get("addon.b.x").String(),
+			sess.Get("a.y").String(),
+			sess.Get("b.y").String(),
+		}
+		return nil
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+
+	for _, slug := range []string{"a", "b"} {
+		testutils.NoError(t, errs[slug+" own"])
+		testutils.NoError(t, errs[slug+" legacy"])
+
+		err := errs[slug+" other"]
+		testutils.ErrorIs(t, err, addon.ErrPermission)
+		var aerr *addon.AccessError
+		if testutils.True(t, errors.As(err, &aerr), "want *addon.AccessError") {
+			testutils.Equal(t, slug, aerr.Addon)
+		}
+	}
+	testutils.Equal(t, "a,b,a,b", strings.Join(values, ","))
+}
@@ -14,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -23,8 +24,11 @@ import (
 	"github.com/happy-sdk/happy/pkg/strings/slug"
 	"github.com/happy-sdk/happy/pkg/version"
 	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
 	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/cli/help"
 	"github.com/happy-sdk/happy/sdk/custom"
+	"github.com/happy-sdk/happy/sdk/doctor"
 	"github.com/happy-sdk/happy/sdk/events"
 	"github.com/happy-sdk/happy/sdk/services"
 )
@@ -40,6 +44,16 @@ type Config struct {
 	WithoutCommands bool
 	WithoutServices bool
 	Settings        settings.Settings
+	// Permissions declares resources addon is allowed to use.
+	Permissions Permissions
+	// DependsOn are slugs of addons whose register and lifecycle hooks
+	// complete before hooks of this addon are called, shutdown hooks are
+	// called in reverse order. Hooks of addons which do not depend on
+	// each other are called concurrently.
+	DependsOn []string
+	// Timeout limits duration of each register and lifecycle hook of
+	// addon, zero means no limit.
+	Timeout time.Duration
 }
 
 type Info struct {
@@ -48,6 +62,7 @@ type Info struct {
 	Description string
 	Version     version.Version
 	Module      string
+	Permissions Permissions
 }
 
 func Option(key string, dval any, desc string, ro bool, vfunc options.ValueValidator) options.Spec {
@@ -65,9 +80,17 @@ type Addon struct {
 	api            custom.API
 	registerAction action.Register
 
+	configureAction action.Action
+	servicesAction  action.Action
+	readyAction     action.Action
+	shutdownAction  action.Action
+	exitFuncs       []action.Exit
+
 	events []events.Event
 	cmds   []*command.Command
 	svcs   []*services.Service
+	checks []doctor.Check
+	docs   []*help.Topic
 	opts   *options.Options
 
 	errs []error
@@ -77,7 +100,8 @@ func New(c Config, opts ...options.Spec) *Addon {
 	addon := &Addon{
 		config: c,
 		info: Info{
-			Name: c.Name,
+			Name:        c.Name,
+			Permissions: c.Permissions,
 		},
 	}
 
@@ -87,7 +111,7 @@ func New(c Config, opts ...options.Spec) *Addon {
 	addon.loadPackageInfo()
 
 	var err error
-	addon.opts, err = options.New(addon.info.Slug, opts)
+	addon.opts, err = options.New(OptionsPrefix+addon.info.Slug, opts)
 	addon.perr(err)
 	return addon
 }
@@ -98,6 +122,51 @@ func (addon *Addon) OnRegister(action action.Register) {
 	addon.registerAction = action
 }
 
+// OnConfigure is called when application is configured and
+// settings are available, before services are registered.
+func (addon *Addon) OnConfigure(action action.Action) {
+	addon.mu.Lock()
+	defer addon.mu.Unlock()
+	addon.configureAction = action
+}
+
+// OnServicesRegistered is called after all application
+// and addon services are registered, before engine starts.
+func (addon *Addon) OnServicesRegistered(action action.Action) {
+	addon.mu.Lock()
+	defer addon.mu.Unlock()
+	addon.servicesAction = action
+}
+
+// OnReady is called when session is ready, before command Do action.
+func (addon *Addon) OnReady(action action.Action) {
+	addon.mu.Lock()
+	defer addon.mu.Unlock()
+	addon.readyAction = action
+}
+
+// OnShutdown is called when application shuts down, before session is destroyed.
+func (addon *Addon) OnShutdown(action action.Action) {
+	addon.mu.Lock()
+	defer addon.mu.Unlock()
+	addon.shutdownAction = action
+}
+
+// OnExit adds function called with exit code when application exits,
+// before OnShutdown action. Exit functions are called in reverse order
+// of registration and exit functions of addon are called before exit
+// functions of addons it depends on.
+func (addon *Addon) OnExit(fn action.Exit) {
+	addon.mu.Lock()
+	defer addon.mu.Unlock()
+	addon.exitFuncs = append(addon.exitFuncs, fn)
+}
+
+func (addon *Addon) register(sess session.Register) (err error) {
+	defer action.Recover(&err)
+	return addon.registerAction(sess)
+}
+
 func (addon *Addon) Emits(evs ...events.Event) {
 	addon.mu.Lock()
 	defer addon.mu.Unlock()
@@ -134,6 +203,35 @@ func (addon *Addon) ProvideServices(svcs ...*services.Service) {
 	}
 }
 
+// RegisterDoctorCheck registers checks which are run by the doctor command.
+// Check names are prefixed with the addon slug.
+func (addon *Addon) RegisterDoctorCheck(checks ...doctor.Check) {
+	addon.mu.Lock()
+	defer addon.mu.Unlock()
+	for _, check := range checks {
+		if check.Run == nil {
+			addon.perr(fmt.Errorf("%w: %s provided doctor check %q without run function", Error, addon.info.Name, check.Name))
+			return
+		}
+		check.Name = addon.info.Slug + "." + check.Name
+		addon.checks = append(addon.checks, check)
+	}
+}
+
+// ProvideDocs adds Markdown documentation topics rendered by help
+// command and served by documentation server.
+func (addon *Addon) ProvideDocs(topics ...*help.Topic) {
+	addon.mu.Lock()
+	defer addon.mu.Unlock()
+	for _, topic := range topics {
+		if topic == nil {
+			addon.perr(fmt.Errorf("%w: %s provided <nil> docs topic", Error, addon.info.Name))
+			return
+		}
+		addon.docs = append(addon.docs, topic)
+	}
+}
+
 func (addon *Addon) ProvideAPI(api custom.API) {
 	addon.mu.Lock()
 	defer addon.mu.Unlock()
@@ -7,13 +7,20 @@ package addon
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/happy-sdk/happy/pkg/options"
 	"github.com/happy-sdk/happy/pkg/settings"
 	"github.com/happy-sdk/happy/pkg/strings/slug"
+	"github.com/happy-sdk/happy/sdk/action"
 	"github.com/happy-sdk/happy/sdk/app/session"
 	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/cli/help"
 	"github.com/happy-sdk/happy/sdk/custom"
+	"github.com/happy-sdk/happy/sdk/doctor"
 	"github.com/happy-sdk/happy/sdk/events"
 	"github.com/happy-sdk/happy/sdk/services"
 )
@@ -25,14 +32,35 @@ var (
 type Manager struct {
 	// Addons is a map of all registered addons.
 	addons map[string]*Addon
+	mu     sync.Mutex
+	// took is time spent initializing each addon.
+	took map[string]time.Duration
+	// sessions are sessions owned by addons, see GuardOptions.
+	sessions map[string]*session.Context
 }
 
 func NewManager() *Manager {
 	return &Manager{
 		addons: make(map[string]*Addon),
+		took:   make(map[string]time.Duration),
 	}
 }
 
+// Took returns time spent extending settings, options and calling
+// register and lifecycle hooks of addon so far.
+func (m *Manager) Took(slug string) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.took[slug]
+}
+
+// timed adds time spent since start to init time of addon.
+func (m *Manager) timed(slug string, start time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.took[slug] += time.Since(start)
+}
+
 func (m *Manager) Add(addon *Addon) error {
 	if !slug.IsValid(addon.info.Slug) {
 		return fmt.Errorf("%w: %q", ErrInvalidAddonName, addon.info.Slug)
@@ -44,10 +72,23 @@ func (m *Manager) Add(addon *Addon) error {
 	return nil
 }
 
+// Remove removes addon with slug before it is configured, e.g. addon
+// disabled by system policy. It reports false when there is no such addon.
+func (m *Manager) Remove(slug string) bool {
+	if _, ok := m.addons[slug]; !ok {
+		return false
+	}
+	delete(m.addons, slug)
+	return true
+}
+
 func (m *Manager) ExtendSettings(sb *settings.Blueprint) error {
 	for _, addon := range m.addons {
 		if addon.config.Settings != nil {
-			if err := sb.Extend(addon.info.Slug, addon.config.Settings); err != nil {
+			start := time.Now()
+			err := sb.Extend(addon.info.Slug, addon.config.Settings)
+			m.timed(addon.info.Slug, start)
+			if err != nil {
 				return fmt.Errorf("%w: %s", Error, err)
 			}
 		}
@@ -55,10 +96,19 @@ func (m *Manager) ExtendSettings(sb *settings.Blueprint) error {
 	return nil
 }
 
+// ExtendOptions adds options of addons to opts. Options keyed
+// <slug>.<key> before addon options were namespaced remain available
+// as deprecated aliases of addon.<slug>.<key>.
 func (m *Manager) ExtendOptions(opts *options.Options) error {
 	for _, addon := range m.addons {
 		if addon.opts != nil {
-			if err := options.MergeOptions(opts, addon.opts); err != nil {
+			start := time.Now()
+			err := options.MergeOptions(opts, addon.opts)
+			if err == nil {
+				err = aliasLegacyOptions(opts, addon)
+			}
+			m.timed(addon.info.Slug, start)
+			if err != nil {
 				return fmt.Errorf("%w: %s", Error, err)
 			}
 		}
@@ -66,6 +116,21 @@ func (m *Manager) ExtendOptions(opts *options.Options) error {
 	return nil
 }
 
+// aliasLegacyOptions makes <slug>.<key> refer to addon.<slug>.<key>.
+func aliasLegacyOptions(opts *options.Options, addon *Addon) error {
+	var err error
+	addon.opts.Range(func(opt options.Option) bool {
+		key := opt.Name()
+		if opts.Has(addon.info.Slug + "." + key) {
+			// legacy key is taken by other option.
+			return true
+		}
+		err = opts.Alias(addon.info.Slug+"."+key, addon.opts.Name()+"."+key)
+		return err == nil
+	})
+	return err
+}
+
 func (m *Manager) Commands() []*command.Command {
 	var cmds []*command.Command
 	for _, addon := range m.addons {
@@ -100,21 +165,259 @@ func (m *Manager) Events() []events.Event {
 }
 
 func (m *Manager) Register(sess session.Register) error {
-	for _, addon := range m.addons {
-		err := errors.Join(addon.errs...)
-		if err != nil {
-			return fmt.Errorf("%w(%s): %s", Error, addon.info.Slug, err.Error())
+	for _, info := range m.Info() {
+		if err := errors.Join(m.addons[info.Slug].errs...); err != nil {
+			return fmt.Errorf("%w(%s): %s", Error, info.Slug, err.Error())
 		}
+	}
+	return m.run("register", false, func(addon *Addon) func() error {
 		if addon.registerAction == nil {
-			continue
+			return nil
+		}
+		var reg session.Register = sess
+		if owned := m.session(nil, addon.info.Slug); owned != nil {
+			reg = owned
+		}
+		return func() error { return addon.register(reg) }
+	})
+}
+
+// Configure calls OnConfigure actions of addons.
+func (m *Manager) Configure(sess *session.Context) error {
+	return m.hook(sess, "configure", func(addon *Addon) action.Action { return addon.configureAction })
+}
+
+// ServicesRegistered calls OnServicesRegistered actions of addons.
+func (m *Manager) ServicesRegistered(sess *session.Context) error {
+	return m.hook(sess, "services registered", func(addon *Addon) action.Action { return addon.servicesAction })
+}
+
+// Ready calls OnReady actions of addons.
+func (m *Manager) Ready(sess *session.Context) error {
+	return m.hook(sess, "ready", func(addon *Addon) action.Action { return addon.readyAction })
+}
+
+// Shutdown calls OnShutdown actions of addons in reverse dependency
+// order, action of addon is called after actions of addons depending on
+// it returned. All actions are called, errors are joined and returned
+// after all actions are called.
+func (m *Manager) Shutdown(sess *session.Context) error {
+	return m.run("shutdown", true, func(addon *Addon) func() error {
+		if addon.shutdownAction == nil {
+			return nil
+		}
+		sess := m.session(sess, addon.info.Slug)
+		return func() error { return action.Try(func() error { return addon.shutdownAction(sess) }) }
+	})
+}
+
+// ExitFuncs returns exit functions of addons in order of registration,
+// exit functions of addon follow exit functions of its dependencies.
+// Exit functions are meant to be called in reverse order.
+func (m *Manager) ExitFuncs() []action.Exit {
+	var (
+		fns     []action.Exit
+		visited = make(map[string]bool)
+		visit   func(slug string)
+	)
+	visit = func(slug string) {
+		addon, ok := m.addons[slug]
+		if !ok || visited[slug] {
+			return
+		}
+		visited[slug] = true
+		for _, dep := range addon.config.DependsOn {
+			visit(dep)
+		}
+		addon.mu.Lock()
+		fns = append(fns, addon.exitFuncs...)
+		addon.mu.Unlock()
+	}
+	for _, info := range m.Info() {
+		visit(info.Slug)
+	}
+	return fns
+}
+
+// hook calls addon actions, see Manager.run.
+func (m *Manager) hook(sess *session.Context, phase string, get func(addon *Addon) action.Action) error {
+	return m.run(phase, false, func(addon *Addon) func() error {
+		a := get(addon)
+		if a == nil {
+			return nil
+		}
+		sess := m.session(sess, addon.info.Slug)
+		return func() error { return action.Try(func() error { return a(sess) }) }
+	})
+}
+
+// run calls phase functions returned by get concurrently. Function of
+// addon is called after functions of addons it depends on returned, it
+// is skipped and reported as failed when any of them failed. When
+// reverse is set function of addon is called after functions of addons
+// depending on it returned and it is called even when they failed.
+// Function running longer than addon Timeout is abandoned and reported
+// as failed. Errors are returned in order of addon slugs.
+func (m *Manager) run(phase string, reverse bool, get func(addon *Addon) func() error) error {
+	infos := m.Info()
+	if err := m.verifyDependencies(); err != nil {
+		return err
+	}
+
+	done := make(map[string]chan struct{}, len(infos))
+	waits := make(map[string][]string, len(infos))
+	for _, info := range infos {
+		done[info.Slug] = make(chan struct{})
+		for _, dep := range m.addons[info.Slug].config.DependsOn {
+			if reverse {
+				waits[dep] = append(waits[dep], info.Slug)
+			} else {
+				waits[info.Slug] = append(waits[info.Slug], dep)
+			}
 		}
-		if err := addon.registerAction(sess); err != nil {
-			return fmt.Errorf("%w: %s", Error, err)
+	}
+	var (
+		mu     sync.Mutex
+		failed = make(map[string]bool)
+		errs   = make(map[string]error)
+		wg     sync.WaitGroup
+	)
+	fail := func(slug string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed[slug] = true
+		if err != nil {
+			errs[slug] = err
+		}
+	}
+	for _, info := range infos {
+		wg.Add(1)
+		go func(slug string, addon *Addon) {
+			defer wg.Done()
+			defer close(done[slug])
+
+			fn := get(addon)
+			for _, dep := range waits[slug] {
+				<-done[dep]
+				mu.Lock()
+				depFailed := failed[dep]
+				mu.Unlock()
+				if depFailed && !reverse {
+					var err error
+					if fn != nil {
+						err = fmt.Errorf("%w(%s): %s: skipped, dependency %s failed", Error, slug, phase, dep)
+					}
+					fail(slug, err)
+					return
+				}
+			}
+
+			if fn == nil {
+				return
+			}
+			start := time.Now()
+			err := callWithTimeout(fn, addon.config.Timeout)
+			m.timed(slug, start)
+			if err != nil {
+				fail(slug, fmt.Errorf("%w(%s): %s: %s", Error, slug, phase, err.Error()))
+			}
+		}(info.Slug, m.addons[info.Slug])
+	}
+	wg.Wait()
+
+	var res []error
+	for _, info := range infos {
+		if err, ok := errs[info.Slug]; ok {
+			res = append(res, err)
+		}
+	}
+	return errors.Join(res...)
+}
+
+// verifyDependencies checks that addons depend only on attached addons
+// and that there are no dependency cycles.
+func (m *Manager) verifyDependencies() error {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int)
+	var visit func(slug string, path []string) error
+	visit = func(slug string, path []string) error {
+		switch state[slug] {
+		case visiting:
+			return fmt.Errorf("%w: dependency cycle %s", Error, strings.Join(append(path, slug), " -> "))
+		case visited:
+			return nil
+		}
+		state[slug] = visiting
+		for _, dep := range m.addons[slug].config.DependsOn {
+			if _, ok := m.addons[dep]; !ok {
+				return fmt.Errorf("%w(%s): depends on addon %q which is not attached", Error, slug, dep)
+			}
+			if err := visit(dep, append(path, slug)); err != nil {
+				return err
+			}
+		}
+		state[slug] = visited
+		return nil
+	}
+	for _, info := range m.Info() {
+		if err := visit(info.Slug, nil); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// callWithTimeout calls fn and returns its error, or error when fn does
+// not return within timeout. Zero timeout waits until fn returns.
+func callWithTimeout(fn func() error, timeout time.Duration) error {
+	if timeout <= 0 {
+		return fn()
+	}
+	res := make(chan error, 1)
+	go func() { res <- fn() }()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-res:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+}
+
+// DoctorChecks returns doctor checks registered by addons.
+func (m *Manager) DoctorChecks() []doctor.Check {
+	var checks []doctor.Check
+	for _, info := range m.Info() {
+		checks = append(checks, m.addons[info.Slug].checks...)
+	}
+	return checks
+}
+
+// Docs returns documentation topics provided by addons.
+func (m *Manager) Docs() []*help.Topic {
+	var topics []*help.Topic
+	for _, info := range m.Info() {
+		topics = append(topics, m.addons[info.Slug].docs...)
+	}
+	return topics
+}
+
+// Info returns info of attached addons sorted by slug.
+func (m *Manager) Info() []Info {
+	var infos []Info
+	for _, addon := range m.addons {
+		infos = append(infos, addon.info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Slug < infos[j].Slug
+	})
+	return infos
+}
+
 func (m *Manager) GetAPIs() map[string]custom.API {
 	apis := make(map[string]custom.API)
 	for _, addon := range m.addons {
@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package addon
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+func TestManagerRun(t *testing.T) {
+	errFail := errors.New("fail")
+
+	tests := []struct {
+		name   string
+		addons []Config
+		fail   []string
+		block  []string
+		// reverse runs in reverse dependency order as on shutdown.
+		reverse bool
+		// unordered is set when want lists addons called in any order.
+		unordered bool
+		want      string
+		wantErr   string
+	}{
+		{
+			name: "dependencies first",
+			addons: []Config{
+				{Name: "a", DependsOn: []string{"b"}},
+				{Name: "b", DependsOn: []string{"c"}},
+				{Name: "c"},
+			},
+			want: "c,b,a",
+		},
+		{
+			name: "failed dependency",
+			addons: []Config{
+				{Name: "a", DependsOn: []string{"b"}},
+				{Name: "b"},
+				{Name: "c"},
+			},
+			fail:      []string{"b"},
+			unordered: true,
+			want:      "b,c",
+			wantErr:   "addon(a): test: skipped, dependency b failed\naddon(b): test: fail",
+		},
+		{
+			name: "errors in slug order",
+			addons: []Config{
+				{Name: "a"},
+				{Name: "b"},
+			},
+			fail:      []string{"b", "a"},
+			unordered: true,
+			want:      "a,b",
+			wantErr:   "addon(a): test: fail\naddon(b): test: fail",
+		},
+		{
+			name: "timeout",
+			addons: []Config{
+				{Name: "a", Timeout: 10 * time.Millisecond},
+				{Name: "b", DependsOn: []string{"a"}},
+			},
+			block:   []string{"a"},
+			wantErr: "addon(a): test: timed out after 10ms\naddon(b): test: skipped, dependency a failed",
+		},
+		{
+			name: "reverse dependencies",
+			addons: []Config{
+				{Name: "a", DependsOn: []string{"b"}},
+				{Name: "b", DependsOn: []string{"c"}},
+				{Name: "c"},
+			},
+			reverse: true,
+			want:    "a,b,c",
+		},
+		{
+			// dependencies are shut down even when dependents failed.
+			name: "reverse failed dependent",
+			addons: []Config{
+				{Name: "a", DependsOn: []string{"b"}},
+				{Name: "b"},
+			},
+			fail:    []string{"a"},
+			reverse: true,
+			want:    "a,b",
+			wantErr: "addon(a): test: fail",
+		},
+		{
+			name: "reverse timeout",
+			addons: []Config{
+				{Name: "a", DependsOn: []string{"b"}, Timeout: 10 * time.Millisecond},
+				{Name: "b"},
+			},
+			block:   []string{"a"},
+			reverse: true,
+			want:    "b",
+			wantErr: "addon(a): test: timed out after 10ms",
+		},
+		{
+			name: "unknown dependency",
+			addons: []Config{
+				{Name: "a", DependsOn: []string{"missing"}},
+			},
+			wantErr: `addon(a): depends on addon "missing" which is not attached`,
+		},
+		{
+			name: "cycle",
+			addons: []Config{
+				{Name: "a", DependsOn: []string{"b"}},
+				{Name: "b", DependsOn: []string{"a"}},
+			},
+			wantErr: "addon: dependency cycle a -> b -> a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager()
+			for _, c := range tt.addons {
+				testutils.NoError(t, m.Add(New(c)))
+			}
+			var (
+				mu      sync.Mutex
+				called  []string
+				release = make(chan struct{})
+			)
+			defer close(release)
+			err := m.run("test", tt.reverse, func(addon *Addon) func() error {
+				slug := addon.info.Slug
+				return func() error {
+					for _, s := range tt.block {
+						if s == slug {
+							<-release
+						}
+					}
+					mu.Lock()
+					called = append(called, slug)
+					mu.Unlock()
+					for _, s := range tt.fail {
+						if s == slug {
+							return errFail
+						}
+					}
+					return nil
+				}
+			})
+			if tt.wantErr != "" {
+				testutils.Error(t, err)
+				testutils.Equal(t, tt.wantErr, err.Error())
+			} else {
+				testutils.NoError(t, err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if tt.unordered {
+				sort.Strings(called)
+			}
+			testutils.Equal(t, tt.want, strings.Join(called, ","))
+		})
+	}
+}
+
+func TestManagerRunConcurrent(t *testing.T) {
+	m := NewManager()
+	for _, name := range []string{"a", "b", "c"} {
+		testutils.NoError(t, m.Add(New(Config{Name: name, Timeout: time.Second})))
+	}
+	// each addon waits until all addons are running
+	var started sync.WaitGroup
+	started.Add(3)
+	err := m.run("test", false, func(addon *Addon) func() error {
+		return func() error {
+			started.Done()
+			started.Wait()
+			return nil
+		}
+	})
+	testutils.NoError(t, err)
+	for _, name := range []string{"a", "b", "c"} {
+		testutils.True(t, m.Took(name) > 0, name+" time must be recorded")
+	}
+}
+
+func TestManagerShutdown(t *testing.T) {
+	m := NewManager()
+	var (
+		mu     sync.Mutex
+		called []string
+	)
+	for _, c := range []Config{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b"},
+		{Name: "c", DependsOn: []string{"a"}},
+	} {
+		addon := New(c)
+		addon.OnShutdown(func(sess *session.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			called = append(called, c.Name)
+			if c.Name == "c" {
+				panic("shutdown")
+			}
+			return nil
+		})
+		testutils.NoError(t, m.Add(addon))
+	}
+	err := m.Shutdown(nil)
+	testutils.Error(t, err)
+	testutils.HasPrefix(t, err.Error(), "addon(c): shutdown:")
+	testutils.Equal(t, "c,a,b", strings.Join(called, ","))
+}
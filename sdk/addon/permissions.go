@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package addon
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+var ErrPermission = fmt.Errorf("%w: permission denied", Error)
+
+// Permissions declares resources addon uses. They are enforced for
+// subprocess plugins started with Addon.Command, for host-compiled addons
+// they serve as documentation and helpers such as Addon.Getenv log
+// warning when used outside of declared scope.
+type Permissions struct {
+	// Paths are filesystem paths or glob patterns addon accesses,
+	// path grants access to all files below it. Leading ~ is expanded
+	// to user home directory.
+	Paths []string
+	// Hosts are network hosts addon connects to, with optional port
+	// e.g. api.example.com:443, leading *. matches any subdomain.
+	Hosts []string
+	// Env are environment variable names addon reads,
+	// trailing * matches any suffix e.g. AWS_*.
+	Env []string
+	// Exec are names or paths of executables addon runs.
+	Exec []string
+}
+
+// Empty reports whether no permissions are declared.
+func (p Permissions) Empty() bool {
+	return len(p.Paths) == 0 && len(p.Hosts) == 0 && len(p.Env) == 0 && len(p.Exec) == 0
+}
+
+// String returns permissions summary e.g. "fs:/tmp net:example.com".
+func (p Permissions) String() string {
+	var parts []string
+	for _, group := range []struct {
+		prefix string
+		vals   []string
+	}{{"fs", p.Paths}, {"net", p.Hosts}, {"env", p.Env}, {"exec", p.Exec}} {
+		for _, v := range group.vals {
+			parts = append(parts, group.prefix+":"+v)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// AllowsPath reports whether p is within declared paths.
+func (p Permissions) AllowsPath(name string) bool {
+	name, err := filepath.Abs(expandHome(name))
+	if err != nil {
+		return false
+	}
+	for _, allowed := range p.Paths {
+		allowed = filepath.Clean(expandHome(allowed))
+		if ok, _ := filepath.Match(allowed, name); ok {
+			return true
+		}
+		if name == allowed || strings.HasPrefix(name, allowed+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsHost reports whether address host[:port] is within declared hosts.
+func (p Permissions) AllowsHost(address string) bool {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, ""
+	}
+	for _, allowed := range p.Hosts {
+		ahost, aport, err := net.SplitHostPort(allowed)
+		if err != nil {
+			ahost, aport = allowed, ""
+		}
+		if aport != "" && aport != port {
+			continue
+		}
+		if ahost == host || ahost == "*" {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(ahost, "*."); ok && strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsEnv reports whether environment variable key is declared.
+func (p Permissions) AllowsEnv(key string) bool {
+	for _, allowed := range p.Env {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok && strings.HasPrefix(key, prefix) {
+			return true
+		}
+		if allowed == key {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsExec reports whether executable name is declared.
+func (p Permissions) AllowsExec(name string) bool {
+	for _, allowed := range p.Exec {
+		if allowed == name || (!strings.ContainsAny(allowed, `/\`) && allowed == path.Base(filepath.ToSlash(name))) {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions returns permissions declared by addon.
+func (addon *Addon) Permissions() Permissions {
+	return addon.config.Permissions
+}
+
+// Getenv returns value of environment variable and warns when variable
+// is not declared in addon permissions.
+func (addon *Addon) Getenv(sess *session.Context, key string) string {
+	if !addon.config.Permissions.AllowsEnv(key) {
+		addon.warnScope(sess, "env", key)
+	}
+	return os.Getenv(key)
+}
+
+// Open opens file for reading and warns when it is not within declared paths.
+func (addon *Addon) Open(sess *session.Context, name string) (*os.File, error) {
+	if !addon.config.Permissions.AllowsPath(name) {
+		addon.warnScope(sess, "fs", name)
+	}
+	return os.Open(name)
+}
+
+// Dial connects to address and warns when host is not declared.
+func (addon *Addon) Dial(sess *session.Context, network, address string) (net.Conn, error) {
+	if !addon.config.Permissions.AllowsHost(address) {
+		addon.warnScope(sess, "net", address)
+	}
+	return net.Dial(network, address)
+}
+
+// Command returns command for running subprocess plugin. Permissions
+// are enforced, executable must be declared and only declared
+// environment variables are passed to the subprocess.
+func (addon *Addon) Command(sess *session.Context, name string, args ...string) (*exec.Cmd, error) {
+	perms := addon.config.Permissions
+	if !perms.AllowsExec(name) {
+		return nil, fmt.Errorf("%w: %s is not allowed to execute %s", ErrPermission, addon.info.Slug, name)
+	}
+	cmd := exec.CommandContext(sess, name, args...)
+	cmd.Env = []string{}
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if perms.AllowsEnv(key) {
+			cmd.Env = append(cmd.Env, kv)
+		}
+	}
+	return cmd, nil
+}
+
+func (addon *Addon) warnScope(sess *session.Context, kind, target string) {
+	sess.Log().Warn(
+		"addon used undeclared permission",
+		slog.String("addon", addon.info.Slug),
+		slog.String("permission", kind+":"+target),
+	)
+}
+
+func expandHome(p string) string {
+	if rest, ok := strings.CutPrefix(p, "~"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return p
+}
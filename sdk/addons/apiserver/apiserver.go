@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package apiserver provides addon which exposes application commands
+// over HTTP, so that any happy CLI can be controlled as an agent
+// without writing a server by hand. Commands are run with
+// POST /cmd/<path> and logs they write are returned with the result
+// or streamed as server-sent events. Requests must carry bearer token
+// configured with api.token setting.
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/validate"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/addon"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/networking/listener"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+var Error = errors.New("apiserver")
+
+const ServiceName = "api"
+
+type Settings struct {
+	Address  settings.String      `key:"address,save" default:"127.0.0.1:6062" mutation:"once" desc:"Address API server listens on"`
+	Token    settings.String      `key:"token" default:"" mutation:"once" desc:"Bearer token required by API requests"`
+	Commands settings.StringSlice `key:"commands,save" default:"" desc:"Commands exposed over HTTP, all commands when empty"`
+}
+
+func (s Settings) Blueprint() (*settings.Blueprint, error) {
+	b, err := settings.New(s)
+	if err != nil {
+		return nil, err
+	}
+	b.AddValidator("address", "", func(s settings.Setting) error {
+		if err := validate.HostPort()(s.Value().String()); err != nil {
+			return fmt.Errorf("%w: address %w", settings.ErrSetting, err)
+		}
+		return nil
+	})
+	return b, nil
+}
+
+// Addon returns API server addon providing api service and api command
+// which runs the service in foreground. Settings are available under
+// api.* keys.
+func Addon() *addon.Addon {
+	a := addon.New(addon.Config{
+		Name:     "API",
+		Settings: Settings{},
+	})
+	a.ProvideServices(AsService())
+	a.ProvideCommands(Command())
+	return a
+}
+
+// AsService returns service serving commands on api.address. Service
+// fails to start when api.token is not set.
+func AsService() *services.Service {
+	svc := services.New(service.Config{
+		Name:        ServiceName,
+		Description: "Exposes application commands over HTTP",
+	})
+
+	var (
+		mu  sync.Mutex
+		srv *http.Server
+	)
+
+	svc.OnStart(func(sess *session.Context) error {
+		token := sess.Get("api.token").String()
+		if token == "" {
+			return fmt.Errorf("%w: api.token is not set", Error)
+		}
+		ln, err := listener.Listen("tcp", sess.Get("api.address").String())
+		if err != nil {
+			return fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		server := NewServer(sess, token)
+		server.Commands = splitList(sess.Get("api.commands").String())
+
+		mu.Lock()
+		srv = &http.Server{
+			Handler:           server,
+			ReadHeaderTimeout: 10 * time.Second,
+			BaseContext:       func(net.Listener) context.Context { return sess },
+		}
+		mu.Unlock()
+
+		go func() {
+			if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				sess.Log().Error("api service failed", slog.String("err", err.Error()))
+			}
+		}()
+		internal.Log(sess.Log(), "api service listening", slog.String("addr", ln.Addr().String()))
+		return nil
+	})
+
+	svc.OnStop(func(sess *session.Context, e error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if srv == nil {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(ctx)
+	})
+	return svc
+}
+
+// Command returns api command which starts api service and serves
+// commands until interrupted.
+func Command() *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "api",
+		Category:    "Automation",
+		Description: "Serve application commands over HTTP",
+	})
+
+	cmd.AddInfo("Requests must set Authorization: Bearer <api.token>. GET /cmd lists commands, POST /cmd/<path> runs command, e.g. POST /cmd/db/migrate with body {\"args\": [], \"flags\": {\"dry-run\": true}}. Send Accept: text/event-stream to stream logs as server-sent events.")
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		loader := services.NewLoader(sess, ServiceName)
+		<-loader.Load()
+		if err := loader.Err(); err != nil {
+			return err
+		}
+		sess.Log().Notice("serving commands", slog.String("url", "http://"+sess.Get("api.address").String()))
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigs)
+		select {
+		case <-sigs:
+		case <-sess.Done():
+		}
+		return nil
+	})
+	return cmd
+}
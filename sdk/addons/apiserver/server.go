@@ -0,0 +1,331 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/help"
+	"github.com/happy-sdk/happy/sdk/logging"
+)
+
+// maxBodySize limits size of request body.
+const maxBodySize = 1 << 20
+
+// Request is body of POST /cmd/<path> request.
+type Request struct {
+	// Args are positional arguments of the command.
+	Args []string `json:"args,omitempty"`
+	// Flags are flags of the command by name, values are strings,
+	// numbers, booleans or lists of those for repeated flags.
+	Flags map[string]any `json:"flags,omitempty"`
+}
+
+// Response is result of command, logs are included when they are not
+// streamed.
+type Response struct {
+	Command string            `json:"command"`
+	OK      bool              `json:"ok"`
+	Error   string            `json:"error,omitempty"`
+	Logs    []json.RawMessage `json:"logs,omitempty"`
+}
+
+// CommandInfo is command listed by GET /cmd.
+type CommandInfo struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server runs commands of session on HTTP requests. Commands are run
+// one at a time in scoped session which logger writes to response.
+type Server struct {
+	// Commands limits exposed commands to these command paths, all
+	// commands are exposed when empty.
+	Commands []string
+
+	sess  *session.Context
+	token string
+	mux   *http.ServeMux
+	// sem serializes command invocations.
+	sem chan struct{}
+}
+
+// NewServer returns server running commands of sess, requests must
+// carry bearer token.
+func NewServer(sess *session.Context, token string) *Server {
+	s := &Server{
+		sess:  sess,
+		token: token,
+		mux:   http.NewServeMux(),
+		sem:   make(chan struct{}, 1),
+	}
+	s.mux.HandleFunc("GET /cmd", s.serveList)
+	s.mux.HandleFunc("POST /cmd/{path...}", s.serveCommand)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(auth), []byte(s.token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+		writeError(w, http.StatusUnauthorized, "invalid or missing token")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.commands())
+}
+
+func (s *Server) serveCommand(w http.ResponseWriter, r *http.Request) {
+	path := strings.Join(strings.FieldsFunc(r.PathValue("path"), func(r rune) bool { return r == '/' }), " ")
+	if !slices.ContainsFunc(s.commands(), func(c CommandInfo) bool { return c.Path == path }) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown command %q", path))
+		return
+	}
+
+	var req Request
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+			return
+		}
+	}
+	args, err := req.CommandArgs()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	select {
+	case s.sem <- struct{}{}:
+		defer func() { <-s.sem }()
+	case <-r.Context().Done():
+		return
+	}
+
+	var out output
+	if r.Header.Get("Accept") == "text/event-stream" {
+		out = newEventStream(w)
+	} else {
+		out = &collector{}
+	}
+
+	resp := Response{Command: path}
+	if err := s.invoke(r.Context(), out, path, args); err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.OK = true
+	}
+	out.done(w, resp)
+}
+
+// invoke runs command in scoped session writing logs to out, session
+// is destroyed when client goes away.
+func (s *Server) invoke(ctx context.Context, out output, path string, args []string) error {
+	logger := logging.NewFromHandler(slog.NewJSONHandler(out, &slog.HandlerOptions{
+		ReplaceAttr: replaceLevel,
+	}), s.sess.Log().Level())
+	sess, err := session.Scoped(s.sess, "api", logger)
+	if err != nil {
+		return err
+	}
+	defer sess.Destroy(nil)
+	stop := context.AfterFunc(ctx, func() { sess.Destroy(ctx.Err()) })
+	defer stop()
+	return sess.InvokeCommand(path, args...)
+}
+
+// commands returns commands exposed by server.
+func (s *Server) commands() []CommandInfo {
+	var list []CommandInfo
+	for _, e := range s.sess.HelpIndex() {
+		if e.Kind != help.KindCommand {
+			continue
+		}
+		// index paths start with name of root command.
+		_, path, ok := strings.Cut(e.Command, " ")
+		// api command would serve until application exits.
+		if !ok || path == ServiceName {
+			continue
+		}
+		if len(s.Commands) > 0 && !slices.Contains(s.Commands, path) {
+			continue
+		}
+		list = append(list, CommandInfo{
+			Name:        e.Name,
+			Path:        path,
+			Description: e.Description,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list
+}
+
+// CommandArgs returns command line arguments of request, flags are
+// sorted by name and precede positional arguments.
+func (req Request) CommandArgs() ([]string, error) {
+	names := make([]string, 0, len(req.Flags))
+	for name := range req.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var args []string
+	for _, name := range names {
+		if name == "" || strings.HasPrefix(name, "-") {
+			return nil, fmt.Errorf("%w: invalid flag name %q", Error, name)
+		}
+		values, ok := req.Flags[name].([]any)
+		if !ok {
+			values = []any{req.Flags[name]}
+		}
+		for _, v := range values {
+			switch v := v.(type) {
+			case bool:
+				if v {
+					args = append(args, "--"+name)
+				} else {
+					args = append(args, "--"+name+"=false")
+				}
+			case string:
+				args = append(args, "--"+name+"="+v)
+			case json.Number:
+				args = append(args, "--"+name+"="+v.String())
+			case float64:
+				args = append(args, fmt.Sprintf("--%s=%v", name, v))
+			default:
+				return nil, fmt.Errorf("%w: unsupported value of flag %q", Error, name)
+			}
+		}
+	}
+	return append(args, req.Args...), nil
+}
+
+// output receives JSON log records of command and writes response.
+type output interface {
+	io.Writer
+	done(w http.ResponseWriter, resp Response)
+}
+
+// collector collects logs returned with response.
+type collector struct {
+	mu   sync.Mutex
+	logs []json.RawMessage
+}
+
+func (c *collector) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logs = append(c.logs, json.RawMessage(bytes.TrimSpace(bytes.Clone(p))))
+	return len(p), nil
+}
+
+func (c *collector) done(w http.ResponseWriter, resp Response) {
+	c.mu.Lock()
+	resp.Logs = c.logs
+	c.mu.Unlock()
+	status := http.StatusOK
+	if !resp.OK {
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, resp)
+}
+
+// eventStream streams logs as log events followed by result event.
+type eventStream struct {
+	mu     sync.Mutex
+	w      http.ResponseWriter
+	closed bool
+}
+
+func newEventStream(w http.ResponseWriter) *eventStream {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	return &eventStream{w: w}
+}
+
+func (es *eventStream) Write(p []byte) (int, error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.closed {
+		// goroutines of command may log after it returned.
+		return len(p), nil
+	}
+	if err := es.event("log", bytes.TrimSpace(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (es *eventStream) done(_ http.ResponseWriter, resp Response) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	data, _ := json.Marshal(resp)
+	_ = es.event("result", data)
+	es.closed = true
+}
+
+func (es *eventStream) event(name string, data []byte) error {
+	if _, err := fmt.Fprintf(es.w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+		return err
+	}
+	if err := http.NewResponseController(es.w).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, Response{Error: msg})
+}
+
+func replaceLevel(_ []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey {
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			a.Value = slog.StringValue(logging.Level(level).String())
+		}
+	}
+	return a
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, "|") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
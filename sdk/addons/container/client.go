@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/internal"
+)
+
+// Client runs container runtime CLI.
+type Client struct {
+	Runtime string
+	// Root is directory build context is relative to.
+	Root       string
+	Dockerfile string
+	Context    string
+	Image      string
+	BuildArgs  []string
+}
+
+// ClientFromSettings returns client configured with container.*
+// settings, Root is project root when application runs within project.
+func ClientFromSettings(sess *session.Context) (*Client, error) {
+	c := &Client{
+		Runtime:    sess.Get("addon.container.runtime").String(),
+		Dockerfile: sess.Get("container.dockerfile").String(),
+		Context:    sess.Get("container.context").String(),
+		Image:      sess.Get("container.image").String(),
+		BuildArgs:  splitList(sess.Get("container.build_args").String()),
+	}
+	if c.Runtime == "" {
+		c.Runtime = "docker"
+	}
+	if c.Image == "" {
+		c.Image = sess.Get("app.slug").String()
+	}
+	if proj := sess.Project(); proj != nil {
+		c.Root = proj.Root
+	} else {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		c.Root = wd
+	}
+	return c, nil
+}
+
+// ContextDir returns absolute build context directory.
+func (c *Client) ContextDir() string {
+	if filepath.IsAbs(c.Context) {
+		return c.Context
+	}
+	return filepath.Join(c.Root, c.Context)
+}
+
+// Build builds Image from Dockerfile in build context.
+func (c *Client) Build(ctx context.Context, sess *session.Context) error {
+	if c.Image == "" {
+		return fmt.Errorf("%w: image is required", Error)
+	}
+	dir := c.ContextDir()
+	dockerfile := c.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(dir, dockerfile)
+	}
+	args := []string{"build", "-f", dockerfile, "-t", c.Image}
+	for _, arg := range c.BuildArgs {
+		args = append(args, "--build-arg", arg)
+	}
+	args = append(args, dir)
+
+	internal.Log(sess.Log(), "container build", slog.String("image", c.Image), slog.String("context", dir))
+	if _, err := c.exec(ctx, args...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Run runs ephemeral container and returns its combined output when
+// it exits. Container is removed afterwards, Name and DependsOn
+// are ignored.
+func (c *Client) Run(ctx context.Context, sess *session.Context, spec Container, network string) ([]byte, error) {
+	if spec.Image == "" {
+		return nil, fmt.Errorf("%w: image is required", Error)
+	}
+	internal.Log(sess.Log(), "container run", slog.String("image", spec.Image))
+	return c.exec(ctx, runArgs(spec, network, true)...)
+}
+
+// EnsureNetwork creates network when it does not exist.
+func (c *Client) EnsureNetwork(ctx context.Context, name string) error {
+	if _, err := c.exec(ctx, "network", "inspect", name); err == nil {
+		return nil
+	}
+	_, err := c.exec(ctx, "network", "create", name)
+	return err
+}
+
+func (c *Client) exec(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, c.Runtime, args...)
+	cmd.Dir = c.Root
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return out, fmt.Errorf("%w: %s %s: %s", Error, c.Runtime, args[0], msg)
+	}
+	return out, nil
+}
@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/services"
+)
+
+// Command returns container command with build, run and up subcommands.
+func Command(cnf Config) *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "container",
+		Category:    "Development",
+		Description: "Build images and run containers",
+	})
+	cmd.WithSubCommands(buildCommand(), runCommand(cnf), upCommand(cnf))
+	return cmd
+}
+
+func buildCommand() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "build",
+		Description:      "Build image from Dockerfile",
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.AddInfo("Dockerfile, build context, image tag and build arguments are configured with container.* settings.")
+
+	cmd.WithFlags(
+		varflag.StringFunc("tag", "", "image tag overriding container.image", "t"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		client, err := ClientFromSettings(sess)
+		if err != nil {
+			return err
+		}
+		if tag := args.Flag("tag").String(); tag != "" {
+			client.Image = tag
+		}
+		started := sess.Time().Now()
+		if err := client.Build(sess, sess); err != nil {
+			return err
+		}
+		sess.Log().Ok("image built",
+			slog.String("image", client.Image),
+			slog.String("took", sess.Time().Since(started).String()))
+		return nil
+	})
+	return cmd
+}
+
+func runCommand(cnf Config) *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "run",
+		Description:      "Run ephemeral container and print its output",
+		MinArgs:          1,
+		MaxArgs:          1,
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.Usage("[flags] <image> [-- container args...]")
+
+	cmd.WithFlags(
+		varflag.StringFunc("env", "", "comma separated KEY=VALUE environment variables", "e"),
+		varflag.StringFunc("volume", "", "comma separated volumes to mount", "v"),
+		varflag.StringFunc("network", cnf.Network, "network container is attached to"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		client, err := ClientFromSettings(sess)
+		if err != nil {
+			return err
+		}
+		spec := Container{
+			Image:   args.Arg(0).String(),
+			Args:    args.Passthrough(),
+			Env:     splitComma(args.Flag("env").String()),
+			Volumes: splitComma(args.Flag("volume").String()),
+		}
+		out, err := client.Run(sess, sess, spec, args.Flag("network").String())
+		if len(out) > 0 {
+			fmt.Print(string(out))
+		}
+		return err
+	})
+	return cmd
+}
+
+func upCommand(cnf Config) *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "up",
+		Description: "Start declared containers until interrupted",
+		MaxArgs:     100,
+	})
+
+	cmd.Usage("[container...]")
+	cmd.AddInfo("Containers are started in dependency order, given containers are started along with their dependencies. Containers are stopped in reverse order when command is interrupted.")
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		ordered, err := order(cnf.Containers)
+		if err != nil {
+			return err
+		}
+		if len(ordered) == 0 {
+			return fmt.Errorf("%w: no containers declared", Error)
+		}
+		if args.Argn() > 0 {
+			var names []string
+			for _, arg := range args.Args() {
+				names = append(names, arg.String())
+			}
+			if ordered, err = selectContainers(ordered, names); err != nil {
+				return err
+			}
+		}
+
+		if cnf.Network != "" {
+			client, err := ClientFromSettings(sess)
+			if err != nil {
+				return err
+			}
+			if err := client.EnsureNetwork(sess, cnf.Network); err != nil {
+				return err
+			}
+		}
+
+		var loaders []*services.ServiceLoader
+		stop := func() error {
+			var errs []error
+			for i := len(loaders) - 1; i >= 0; i-- {
+				errs = append(errs, loaders[i].Stop())
+			}
+			return errors.Join(errs...)
+		}
+		for _, c := range ordered {
+			loader, err := services.Require(sess, ServicePrefix+c.Name)
+			if err != nil {
+				return errors.Join(err, stop())
+			}
+			loaders = append(loaders, loader)
+			sess.Log().Notice("container started", slog.String("container", c.Name), slog.String("image", c.Image))
+		}
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigs)
+		select {
+		case <-sigs:
+		case <-sess.Done():
+		}
+		return stop()
+	})
+	return cmd
+}
+
+// selectContainers returns containers named in names and their
+// dependencies keeping order of ordered.
+func selectContainers(ordered []Container, names []string) ([]Container, error) {
+	byName := make(map[string]Container, len(ordered))
+	for _, c := range ordered {
+		byName[c.Name] = c
+	}
+	selected := make(map[string]bool)
+	var mark func(name string) error
+	mark = func(name string) error {
+		c, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("%w: unknown container %s", Error, name)
+		}
+		if selected[name] {
+			return nil
+		}
+		selected[name] = true
+		for _, dep := range c.DependsOn {
+			if err := mark(dep); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, name := range names {
+		if err := mark(name); err != nil {
+			return nil, err
+		}
+	}
+	var list []Container
+	for _, c := range ordered {
+		if selected[c.Name] {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+func splitComma(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
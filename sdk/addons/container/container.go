@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package container provides addon which builds images and runs
+// containers with docker or podman CLI. Containers declared with the
+// addon run as exec services supervised by the application and can be
+// brought up together for development with container up command.
+package container
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/addon"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+var Error = errors.New("container")
+
+// ServicePrefix prefixes names of services running declared containers.
+const ServicePrefix = "container-"
+
+type Settings struct {
+	Dockerfile settings.String      `key:"dockerfile,save" default:"Dockerfile" desc:"Dockerfile path relative to build context"`
+	Context    settings.String      `key:"context,save" default:"." desc:"Build context relative to project root"`
+	Image      settings.String      `key:"image,save" default:"" desc:"Tag of built image, defaults to application slug"`
+	BuildArgs  settings.StringSlice `key:"build_args,save" default:"" desc:"Build arguments in KEY=VALUE form"`
+}
+
+func (s Settings) Blueprint() (*settings.Blueprint, error) {
+	b, err := settings.New(s)
+	if err != nil {
+		return nil, err
+	}
+	b.AddValidator("build_args", "", func(s settings.Setting) error {
+		for _, arg := range splitList(s.Value().String()) {
+			if name, _, ok := strings.Cut(arg, "="); !ok || name == "" {
+				return fmt.Errorf("%w: build argument %q must be KEY=VALUE", settings.ErrSetting, arg)
+			}
+		}
+		return nil
+	})
+	return b, nil
+}
+
+// Config configures container addon.
+type Config struct {
+	// Runtime is container runtime CLI, docker when empty.
+	Runtime string
+	// Network is network declared containers are attached to, it is
+	// created by container up command when it does not exist.
+	Network string
+	// Containers run as services named ServicePrefix+Name.
+	Containers []Container
+}
+
+// Container declares container run by the application.
+type Container struct {
+	// Name is name of the container and its service.
+	Name  string
+	Image string
+	// Args are passed to container entrypoint.
+	Args []string
+	// Env are environment variables in KEY=VALUE form.
+	Env []string
+	// Ports are published ports e.g. 5432:5432.
+	Ports []string
+	// Volumes are mounted volumes e.g. data:/var/lib/data.
+	Volumes []string
+	// DependsOn are names of containers started before this one.
+	DependsOn []string
+	// Restart is restart policy of the service, service.RestartOnFailure
+	// when empty.
+	Restart string
+}
+
+// Addon returns container addon providing container command and
+// services of declared containers. Settings are available under
+// container.* keys and runtime as read-only addon.container.runtime
+// option.
+func Addon(cnf Config) *addon.Addon {
+	if cnf.Runtime == "" {
+		cnf.Runtime = "docker"
+	}
+	a := addon.New(addon.Config{
+		Name:     "Container",
+		Settings: Settings{},
+		Permissions: addon.Permissions{
+			Exec: []string{cnf.Runtime},
+		},
+	}, addon.Option("runtime", cnf.Runtime, "Container runtime CLI", true, nil))
+
+	for _, c := range cnf.Containers {
+		a.ProvideServices(AsService(cnf.Runtime, cnf.Network, c))
+	}
+	a.ProvideCommands(Command(cnf))
+	return a
+}
+
+// AsService returns exec service running container c with runtime in
+// foreground. Container is removed when it exits and it is stopped
+// with the service since runtime forwards signals to the container.
+func AsService(runtime, network string, c Container) *services.Service {
+	restart := c.Restart
+	if restart == "" {
+		restart = service.RestartOnFailure
+	}
+	return services.Exec(service.Config{
+		Name:        settings.String(ServicePrefix + c.Name),
+		Description: settings.String("Runs container " + c.Name),
+		Process: service.Process{
+			Restart:   settings.String(restart),
+			LogPrefix: settings.String(c.Name),
+		},
+	}, append([]string{runtime}, runArgs(c, network, false)...)...)
+}
+
+// runArgs returns run command arguments of c, ephemeral containers
+// are not named so that they can run concurrently.
+func runArgs(c Container, network string, ephemeral bool) []string {
+	args := []string{"run", "--rm"}
+	if !ephemeral && c.Name != "" {
+		args = append(args, "--name", c.Name)
+	}
+	if network != "" {
+		args = append(args, "--network", network)
+	}
+	for _, env := range c.Env {
+		args = append(args, "-e", env)
+	}
+	for _, port := range c.Ports {
+		args = append(args, "-p", port)
+	}
+	for _, vol := range c.Volumes {
+		args = append(args, "-v", vol)
+	}
+	args = append(args, c.Image)
+	return append(args, c.Args...)
+}
+
+// order returns containers ordered so that dependencies come before
+// containers depending on them.
+func order(containers []Container) ([]Container, error) {
+	byName := make(map[string]Container, len(containers))
+	for _, c := range containers {
+		if c.Name == "" || c.Image == "" {
+			return nil, fmt.Errorf("%w: container name and image are required", Error)
+		}
+		if _, ok := byName[c.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicated container %s", Error, c.Name)
+		}
+		byName[c.Name] = c
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	var (
+		ordered []Container
+		state   = make(map[string]int, len(containers))
+		visit   func(name, from string) error
+	)
+	visit = func(name, from string) error {
+		c, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("%w: %s depends on unknown container %s", Error, from, name)
+		}
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: dependency cycle at %s", Error, name)
+		}
+		state[name] = visiting
+		for _, dep := range c.DependsOn {
+			if err := visit(dep, name); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		ordered = append(ordered, c)
+		return nil
+	}
+	for _, c := range containers {
+		if err := visit(c.Name, c.Name); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, "|") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
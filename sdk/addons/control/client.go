@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+// dialTimeout limits time spent connecting to control socket.
+const dialTimeout = time.Second
+
+// ErrNoInstance is returned by Dial when no running instance serves
+// control socket.
+var ErrNoInstance = fmt.Errorf("%w: no running instance with control service", Error)
+
+// Client calls control methods of running instance.
+type Client struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+	id      int
+	// Timeout limits time of single call, starting services may take
+	// up to app.services.loader_timeout.
+	Timeout time.Duration
+}
+
+// Dial connects to control socket of running instance with instance
+// id, when instance is empty and only one instance is running it
+// connects to that one. Sockets left behind by crashed instances are
+// removed.
+func Dial(sess *session.Context, instance string) (*Client, error) {
+	if instance != "" {
+		path, err := socketPath(sess, instance)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := net.DialTimeout("unix", path, dialTimeout)
+		if err != nil {
+			if stale(err) {
+				return nil, fmt.Errorf("%w: %s", ErrNoInstance, instance)
+			}
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		return newClient(sess, conn), nil
+	}
+
+	instances, err := Instances(sess)
+	if err != nil {
+		return nil, err
+	}
+	switch len(instances) {
+	case 0:
+		return nil, ErrNoInstance
+	case 1:
+		return Dial(sess, instances[0])
+	default:
+		return nil, fmt.Errorf("%w: %d instances are running, select one with --instance: %s",
+			Error, len(instances), strings.Join(instances, ", "))
+	}
+}
+
+// Instances returns ids of instances listening on control socket.
+func Instances(sess *session.Context) ([]string, error) {
+	dir := sess.Get("app.fs.path.pids").String()
+	if dir == "" {
+		return nil, fmt.Errorf("%w: pids directory is not set", Error)
+	}
+	sockets, err := filepath.Glob(filepath.Join(dir, "control-*.sock"))
+	if err != nil {
+		return nil, err
+	}
+	var instances []string
+	for _, socket := range sockets {
+		conn, err := net.DialTimeout("unix", socket, dialTimeout)
+		if err != nil {
+			if stale(err) {
+				_ = os.Remove(socket)
+			}
+			continue
+		}
+		_ = conn.Close()
+		id := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(socket), "control-"), ".sock")
+		instances = append(instances, id)
+	}
+	return instances, nil
+}
+
+func newClient(sess *session.Context, conn net.Conn) *Client {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxMessageSize)
+	return &Client{
+		conn:    conn,
+		scanner: scanner,
+		Timeout: sess.Get("app.services.loader_timeout").Duration() + 5*time.Second,
+	}
+}
+
+// Call calls method with params and decodes its result into result
+// unless it is nil. Method errors are returned as *RPCError.
+func (c *Client) Call(method string, params, result any) error {
+	c.id++
+	req := RPCRequest{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage(fmt.Sprint(c.id)),
+		Method:  method,
+	}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		req.Params = data
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if c.Timeout > 0 {
+		_ = c.conn.SetDeadline(time.Now().Add(c.Timeout))
+	}
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if !c.scanner.Scan() {
+		err := c.scanner.Err()
+		if err == nil {
+			err = errors.New("connection closed")
+		}
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	var resp RPCResponse
+	if err := json.Unmarshal(c.scanner.Bytes(), &resp); err != nil {
+		return fmt.Errorf("%w: invalid response: %s", Error, err.Error())
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("%w: invalid result: %s", Error, err.Error())
+	}
+	return nil
+}
+
+// Close closes connection to control socket.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// stale reports whether dial error means nobody listens on socket.
+func stale(err error) bool {
+	return errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package control provides addon which lets local processes control
+// running application over JSON-RPC 2.0. Control service listens on
+// unix socket next to pid files of the application and ctl command
+// group is its client, e.g. ctl status or ctl stop <service>. Sockets
+// are used on Windows as well, AF_UNIX is supported since Windows 10.
+// Socket is accessible only by the user running the application.
+package control
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/addon"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/events"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+var Error = errors.New("control")
+
+// ServiceName is name of the service serving control socket.
+const ServiceName = "control"
+
+// ReloadEvent is dispatched by config.reload method after remote
+// configuration is refreshed, payload value changed reports whether
+// it was updated. Services which can apply configuration while running
+// listen for it.
+var ReloadEvent = events.New("config", "reload")
+
+// Addon returns control addon providing control service and ctl
+// command. Application must load ServiceName service e.g. with
+// services.Require in its long running command.
+func Addon() *addon.Addon {
+	a := addon.New(addon.Config{
+		Name: "Control",
+	})
+	a.Emits(ReloadEvent)
+	a.ProvideServices(AsService())
+	a.ProvideCommands(Command())
+	return a
+}
+
+// AsService returns service serving control methods on socket of
+// current instance.
+func AsService() *services.Service {
+	svc := services.New(service.Config{
+		Name:        settings.String(ServiceName),
+		Description: settings.String("Serves control methods on local socket"),
+	})
+
+	var (
+		mu     sync.Mutex
+		ln     net.Listener
+		socket string
+	)
+
+	svc.OnStart(func(sess *session.Context) error {
+		path, err := SocketPath(sess)
+		if err != nil {
+			return err
+		}
+		_ = os.Remove(path)
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			return fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			_ = l.Close()
+			return fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		mu.Lock()
+		ln, socket = l, path
+		mu.Unlock()
+
+		go NewServer(sess).Serve(l)
+		internal.Log(sess.Log(), "control socket listening", slog.String("socket", path))
+		return nil
+	})
+
+	svc.OnStop(func(sess *session.Context, e error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if ln == nil {
+			return nil
+		}
+		err := ln.Close()
+		ln = nil
+		if rerr := os.Remove(socket); rerr != nil && !errors.Is(rerr, os.ErrNotExist) {
+			err = errors.Join(err, rerr)
+		}
+		return err
+	})
+	return svc
+}
+
+// SocketPath returns path of control socket of current instance.
+func SocketPath(sess *session.Context) (string, error) {
+	return socketPath(sess, sess.Get("app.instance.id").String())
+}
+
+func socketPath(sess *session.Context, instance string) (string, error) {
+	dir := sess.Get("app.fs.path.pids").String()
+	if dir == "" || instance == "" {
+		return "", fmt.Errorf("%w: pids directory or instance id is not set", Error)
+	}
+	return filepath.Join(dir, "control-"+instance+".sock"), nil
+}
@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package control
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+)
+
+// Command returns ctl command controlling running instance over
+// control socket.
+func Command() *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "ctl",
+		Category:    "Automation",
+		Description: "Control running application instance",
+	})
+	cmd.AddInfo("Running instance must load " + ServiceName + " service. When several instances are running select one with --instance.")
+	cmd.WithFlags(
+		varflag.StringFunc("instance", "", "id of instance to control"),
+	)
+	cmd.WithSubCommands(
+		ctlStatus(),
+		ctlServices(),
+		ctlStart(),
+		ctlStop(),
+		ctlLogLevel(),
+		ctlReload(),
+	)
+	return cmd
+}
+
+func ctlStatus() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "status",
+		Description:      "Show status of running instance",
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		var st Status
+		if err := call(sess, args, MethodStatus, nil, &st); err != nil {
+			return err
+		}
+		table := textfmt.Table{
+			Title: "Status",
+		}
+		table.AddRow("App", st.App)
+		table.AddRow("Version", st.Version)
+		table.AddRow("Instance", st.Instance)
+		table.AddRow("PID", fmt.Sprint(st.PID))
+		table.AddRow("Go", st.Go)
+		table.AddRow("Log level", st.LogLevel)
+		table.AddRow("Uptime", st.Uptime.Round(time.Second).String())
+		table.AddRow("Services", fmt.Sprintf("%d running, %d failed, %d total", st.ServicesRunning, st.ServicesFailed, st.Services))
+		sess.Log().Println(table.String())
+		return nil
+	})
+	return cmd
+}
+
+func ctlServices() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "services",
+		Description:      "List services of running instance",
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		var list []ServiceStatus
+		if err := call(sess, args, MethodServicesList, nil, &list); err != nil {
+			return err
+		}
+		printServices(sess, list)
+		return nil
+	})
+	return cmd
+}
+
+func ctlStart() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "start",
+		Description:      "Start services of running instance",
+		MinArgs:          1,
+		MaxArgs:          32,
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+	cmd.Usage("[flags] <service...>")
+	cmd.AddInfo("Services are selected by name, slug or selector e.g. db-*.")
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		var list []ServiceStatus
+		if err := call(sess, args, MethodServicesStart, ServicesParams{Names: argNames(args)}, &list); err != nil {
+			return err
+		}
+		printServices(sess, list)
+		return nil
+	})
+	return cmd
+}
+
+func ctlStop() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "stop",
+		Description:      "Stop services of running instance",
+		MinArgs:          1,
+		MaxArgs:          32,
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+	cmd.Usage("[flags] <service...>")
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		var list []ServiceStatus
+		if err := call(sess, args, MethodServicesStop, ServicesParams{Names: argNames(args)}, &list); err != nil {
+			return err
+		}
+		printServices(sess, list)
+		return nil
+	})
+	return cmd
+}
+
+func ctlLogLevel() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "log-level",
+		Description:      "Show or change log level of running instance",
+		MaxArgs:          1,
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+	cmd.Usage("[flags] [level]")
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		var params, res LogLevelParams
+		if args.Argn() > 0 {
+			params.Level = args.Arg(0).String()
+		}
+		if err := call(sess, args, MethodLogLevel, params, &res); err != nil {
+			return err
+		}
+		sess.Log().Ok("log level", slog.String("level", res.Level))
+		return nil
+	})
+	return cmd
+}
+
+func ctlReload() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "reload",
+		Description:      "Reload configuration of running instance",
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+	cmd.AddInfo("Remote configuration is refreshed when app.config.remote is set and " + ReloadEvent.Scope() + "." + ReloadEvent.Key() + " event is dispatched to services of the instance.")
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		var res ReloadResult
+		if err := call(sess, args, MethodConfigReload, nil, &res); err != nil {
+			return err
+		}
+		sess.Log().Ok("configuration reloaded",
+			slog.Bool("remote", res.Remote),
+			slog.Bool("changed", res.Changed))
+		return nil
+	})
+	return cmd
+}
+
+// call calls method of instance selected with --instance flag.
+func call(sess *session.Context, args action.Args, method string, params, result any) error {
+	client, err := Dial(sess, args.Flag("instance").String())
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.Call(method, params, result)
+}
+
+func argNames(args action.Args) []string {
+	var names []string
+	for _, arg := range args.Args() {
+		names = append(names, arg.String())
+	}
+	return names
+}
+
+func printServices(sess *session.Context, list []ServiceStatus) {
+	if len(list) == 0 {
+		sess.Log().Info("no services")
+		return
+	}
+	table := textfmt.Table{
+		Title:      "Services",
+		WithHeader: true,
+	}
+	table.AddRow("NAME", "ADDRESS", "STATE", "SINCE", "LAST ERROR")
+	for _, svc := range list {
+		state, since := "stopped", svc.StoppedAt
+		switch {
+		case svc.Running:
+			state, since = "running", svc.StartedAt
+		case svc.Failed:
+			state = "failed"
+		}
+		var sinceStr, lastErr string
+		if !since.IsZero() {
+			sinceStr = sess.Time().In(since).Format(time.DateTime)
+		}
+		if n := len(svc.Errors); n > 0 {
+			lastErr = strings.TrimSpace(svc.Errors[n-1])
+		}
+		table.AddRow(svc.Name, svc.Addr, state, sinceStr, lastErr)
+	}
+	sess.Log().Println(table.String())
+}
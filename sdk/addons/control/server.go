@@ -0,0 +1,402 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package control
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/config"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+const (
+	// maxMessageSize limits size of single request line.
+	maxMessageSize = 1 << 20
+	// idleTimeout closes connections which send no requests.
+	idleTimeout = time.Minute
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	// CodeMethodFailed is returned when method was called with valid
+	// params but failed.
+	CodeMethodFailed = -32000
+)
+
+// Methods served by control service.
+const (
+	MethodStatus        = "status"
+	MethodLogLevel      = "log.level"
+	MethodServicesList  = "services.list"
+	MethodServicesStart = "services.start"
+	MethodServicesStop  = "services.stop"
+	MethodConfigReload  = "config.reload"
+)
+
+// RPCRequest is JSON-RPC request, one per line. Requests without ID
+// are notifications which get no response.
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// RPCResponse is JSON-RPC response, one per line.
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is error of JSON-RPC call.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s: %s (%d)", Error.Error(), e.Message, e.Code)
+}
+
+// Status is result of status method.
+type Status struct {
+	App      string `json:"app"`
+	Version  string `json:"version"`
+	Instance string `json:"instance"`
+	PID      int    `json:"pid"`
+	Go       string `json:"go"`
+	LogLevel string `json:"log_level"`
+	// Uptime is time since control service started.
+	Uptime          time.Duration `json:"uptime"`
+	Services        int           `json:"services"`
+	ServicesRunning int           `json:"services_running"`
+	ServicesFailed  int           `json:"services_failed"`
+}
+
+// LogLevelParams are params of log.level method, current level is
+// returned when Level is empty.
+type LogLevelParams struct {
+	Level string `json:"level,omitempty"`
+}
+
+// ServicesParams are params of services.start and services.stop
+// methods, names are service names, slugs or addresses.
+type ServicesParams struct {
+	Names []string `json:"names"`
+}
+
+// ServiceStatus describes service in results of services methods.
+type ServiceStatus struct {
+	Name      string    `json:"name"`
+	Addr      string    `json:"addr"`
+	Running   bool      `json:"running"`
+	Failed    bool      `json:"failed"`
+	StartedAt time.Time `json:"started_at,omitempty"`
+	StoppedAt time.Time `json:"stopped_at,omitempty"`
+	Errors    []string  `json:"errors,omitempty"`
+}
+
+// ReloadResult is result of config.reload method.
+type ReloadResult struct {
+	// Remote reports whether remote configuration is configured.
+	Remote bool `json:"remote"`
+	// Changed reports whether remote configuration was updated.
+	Changed bool `json:"changed"`
+}
+
+type method func(params json.RawMessage) (any, error)
+
+// Server serves control methods of session on connections accepted
+// from listener, connections are served concurrently and requests of
+// single connection in order.
+type Server struct {
+	sess      *session.Context
+	startedAt time.Time
+	methods   map[string]method
+}
+
+// NewServer returns server controlling sess.
+func NewServer(sess *session.Context) *Server {
+	s := &Server{
+		sess:      sess,
+		startedAt: sess.Time().Now(),
+	}
+	s.methods = map[string]method{
+		MethodStatus:        s.status,
+		MethodLogLevel:      s.logLevel,
+		MethodServicesList:  s.servicesList,
+		MethodServicesStart: s.servicesStart,
+		MethodServicesStop:  s.servicesStop,
+		MethodConfigReload:  s.configReload,
+	}
+	return s
+}
+
+// Serve accepts connections until ln is closed.
+func (s *Server) Serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.sess.Log().Warn("control listener failed", slog.String("err", err.Error()))
+			}
+			return
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxMessageSize)
+	enc := json.NewEncoder(conn)
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		if !scanner.Scan() {
+			return
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		resp, ok := s.handle(line)
+		if !ok {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// handle handles single request, ok is false for notifications.
+func (s *Server) handle(line []byte) (resp RPCResponse, ok bool) {
+	resp.JSONRPC = "2.0"
+	var req RPCRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		resp.ID = json.RawMessage("null")
+		resp.Error = &RPCError{Code: CodeParseError, Message: err.Error()}
+		return resp, true
+	}
+	resp.ID = req.ID
+	if len(resp.ID) == 0 {
+		resp.ID = json.RawMessage("null")
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &RPCError{Code: CodeInvalidRequest, Message: "invalid request"}
+		return resp, true
+	}
+
+	fn, found := s.methods[req.Method]
+	if !found {
+		resp.Error = &RPCError{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+		return resp, len(req.ID) > 0
+	}
+	internal.Log(s.sess.Log(), "control method called", slog.String("method", req.Method))
+	result, err := fn(req.Params)
+	if len(req.ID) == 0 {
+		return resp, false
+	}
+	if err != nil {
+		var rerr *RPCError
+		if !errors.As(err, &rerr) {
+			rerr = &RPCError{Code: CodeMethodFailed, Message: err.Error()}
+		}
+		resp.Error = rerr
+		return resp, true
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &RPCError{Code: CodeMethodFailed, Message: err.Error()}
+		return resp, true
+	}
+	resp.Result = data
+	return resp, true
+}
+
+func (s *Server) status(json.RawMessage) (any, error) {
+	sess := s.sess
+	st := Status{
+		App:      sess.Get("app.slug").String(),
+		Version:  sess.Get("app.version").String(),
+		Instance: sess.Get("app.instance.id").String(),
+		PID:      sess.Get("app.pid").Int(),
+		Go:       runtime.Version(),
+		LogLevel: sess.Log().Level().String(),
+		Uptime:   sess.Time().Since(s.startedAt),
+	}
+	for _, info := range sess.Services() {
+		st.Services++
+		if info.Running() {
+			st.ServicesRunning++
+		}
+		if info.Failed() {
+			st.ServicesFailed++
+		}
+	}
+	return st, nil
+}
+
+func (s *Server) logLevel(raw json.RawMessage) (any, error) {
+	var params LogLevelParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.Level != "" {
+		lvl, err := logging.LevelFromString(params.Level)
+		if err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		prev := s.sess.Log().Level()
+		s.sess.Log().SetLevel(lvl)
+		s.sess.Log().Notice("log level changed over control socket",
+			slog.String("from", prev.String()),
+			slog.String("to", lvl.String()))
+	}
+	return LogLevelParams{Level: s.sess.Log().Level().String()}, nil
+}
+
+func (s *Server) servicesList(json.RawMessage) (any, error) {
+	list := make([]ServiceStatus, 0)
+	for _, info := range s.sess.Services() {
+		list = append(list, serviceStatus(info))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+func (s *Server) servicesStart(raw json.RawMessage) (any, error) {
+	names, err := serviceNames(raw)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := services.Require(s.sess, names...); err != nil {
+		return nil, err
+	}
+	return s.lookup(names)
+}
+
+func (s *Server) servicesStop(raw json.RawMessage) (any, error) {
+	names, err := serviceNames(raw)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if name == ServiceName {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "control service can not be stopped over control socket"}
+		}
+	}
+	if err := services.Stop(s.sess, names...); err != nil {
+		return nil, err
+	}
+	return s.lookup(names)
+}
+
+func (s *Server) configReload(json.RawMessage) (any, error) {
+	sess := s.sess
+	var res ReloadResult
+	if rawurl := sess.Get("app.config.remote").String(); rawurl != "" {
+		res.Remote = true
+		remote, err := config.NewRemote(
+			rawurl,
+			sess.Get("app.config.remote_key").String(),
+			sess.Get("app.config.remote_interval").Duration(),
+			filepath.Join(sess.Get("app.fs.path.profile").String(), config.RemoteFilename),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if res.Changed, err = remote.Refresh(sess); err != nil {
+			return nil, err
+		}
+	}
+	sess.Dispatch(ReloadEvent.Create(res.Changed, nil))
+	return res, nil
+}
+
+// lookup returns status of services by names, slugs or addresses.
+func (s *Server) lookup(names []string) ([]ServiceStatus, error) {
+	var list []ServiceStatus
+	for _, name := range names {
+		info, err := s.sess.ServiceInfo(name)
+		if err != nil {
+			for _, i := range s.sess.Services() {
+				if i.Name() == name {
+					info, err = i, nil
+					break
+				}
+			}
+		}
+		if err != nil {
+			// selectors may match several services.
+			continue
+		}
+		list = append(list, serviceStatus(info))
+	}
+	return list, nil
+}
+
+func serviceStatus(info *service.Info) ServiceStatus {
+	st := ServiceStatus{
+		Name:      info.Name(),
+		Addr:      info.Addr().String(),
+		Running:   info.Running(),
+		Failed:    info.Failed(),
+		StartedAt: info.StartedAt(),
+		StoppedAt: info.StoppedAt(),
+	}
+	errs := info.Errs()
+	times := make([]time.Time, 0, len(errs))
+	for t := range errs {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	for _, t := range times {
+		st.Errors = append(st.Errors, errs[t].Error())
+	}
+	return st
+}
+
+func serviceNames(raw json.RawMessage) ([]string, error) {
+	var params ServicesParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if len(params.Names) == 0 {
+		return nil, &RPCError{Code: CodeInvalidParams, Message: "names are required"}
+	}
+	return params.Names, nil
+}
+
+func decodeParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
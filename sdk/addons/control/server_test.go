@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package control_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy"
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/addons/control"
+	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+// runControl runs application with control and worker services and
+// calls fn with client connected to control socket.
+func runControl(t *testing.T, fn func(t *testing.T, sess *session.Context, client *control.Client)) {
+	t.Helper()
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"happy-control-test"}
+
+	main := app.New(happy.Settings{Slug: "happy-control-test"})
+	main.WithLogger(logging.NewTestLogger(logging.LevelError))
+	main.WithServices(control.AsService(), services.New(service.Config{Name: "worker"}))
+
+	var socket string
+	main.Do(func(sess *session.Context, args action.Args) error {
+		if _, err := services.Require(sess, control.ServiceName); err != nil {
+			return err
+		}
+		var err error
+		if socket, err = control.SocketPath(sess); err != nil {
+			return err
+		}
+		client, err := control.Dial(sess, "")
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+		fn(t, sess, client)
+		return nil
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+	_, err := os.Stat(socket)
+	testutils.ErrorIs(t, err, os.ErrNotExist, "socket must be removed when control service stops")
+}
+
+func TestServerMethods(t *testing.T) {
+	runControl(t, func(t *testing.T, sess *session.Context, client *control.Client) {
+		t.Run("status", func(t *testing.T) {
+			var st control.Status
+			testutils.NoError(t, client.Call(control.MethodStatus, nil, &st))
+			testutils.Equal(t, "happy-control-test", st.App)
+			testutils.Equal(t, os.Getpid(), st.PID)
+			testutils.Equal(t, 2, st.Services)
+			testutils.Equal(t, 1, st.ServicesRunning)
+		})
+
+		t.Run("log level", func(t *testing.T) {
+			var res control.LogLevelParams
+			testutils.NoError(t, client.Call(control.MethodLogLevel, control.LogLevelParams{Level: "debug"}, &res))
+			testutils.Equal(t, "debug", res.Level)
+			testutils.Equal(t, logging.LevelDebug, sess.Log().Level())
+			testutils.NoError(t, client.Call(control.MethodLogLevel, nil, &res))
+			testutils.Equal(t, "debug", res.Level)
+			sess.Log().SetLevel(logging.LevelError)
+		})
+
+		t.Run("services", func(t *testing.T) {
+			var list []control.ServiceStatus
+			testutils.NoError(t, client.Call(control.MethodServicesList, nil, &list))
+			if testutils.Equal(t, 2, len(list)) {
+				testutils.Equal(t, "control", list[0].Name)
+				testutils.True(t, list[0].Running, "control service must be running")
+				testutils.Equal(t, "worker", list[1].Name)
+				testutils.False(t, list[1].Running, "worker must not be running")
+			}
+
+			names := control.ServicesParams{Names: []string{"worker"}}
+			testutils.NoError(t, client.Call(control.MethodServicesStart, names, &list))
+			if testutils.Equal(t, 1, len(list)) {
+				testutils.True(t, list[0].Running, "worker must be started")
+			}
+			testutils.NoError(t, client.Call(control.MethodServicesStop, names, &list))
+			if testutils.Equal(t, 1, len(list)) {
+				testutils.False(t, list[0].Running, "worker must be stopped")
+			}
+		})
+
+		tests := []struct {
+			name   string
+			method string
+			params any
+			code   int
+		}{
+			{name: "unknown method", method: "missing", code: control.CodeMethodNotFound},
+			{name: "invalid level", method: control.MethodLogLevel, params: control.LogLevelParams{Level: "loud"}, code: control.CodeInvalidParams},
+			{name: "invalid params", method: control.MethodServicesStart, params: map[string]string{"names": "worker"}, code: control.CodeInvalidParams},
+			{name: "missing names", method: control.MethodServicesStart, params: control.ServicesParams{}, code: control.CodeInvalidParams},
+			{name: "stop control", method: control.MethodServicesStop, params: control.ServicesParams{Names: []string{control.ServiceName}}, code: control.CodeInvalidParams},
+			{name: "unknown service", method: control.MethodServicesStop, params: control.ServicesParams{Names: []string{"missing"}}, code: control.CodeMethodFailed},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := client.Call(tt.method, tt.params, nil)
+				var rerr *control.RPCError
+				if testutils.True(t, errors.As(err, &rerr), "want *control.RPCError got %v", err) {
+					testutils.Equal(t, tt.code, rerr.Code)
+				}
+			})
+		}
+	})
+}
+
+func TestServerRequests(t *testing.T) {
+	runControl(t, func(t *testing.T, sess *session.Context, client *control.Client) {
+		socket, err := control.SocketPath(sess)
+		testutils.NoError(t, err)
+
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(socket)
+			if testutils.NoError(t, err) {
+				testutils.Equal(t, os.FileMode(0600), info.Mode().Perm(), "socket must be accessible only by owner")
+			}
+		}
+
+		conn, err := net.Dial("unix", socket)
+		if !testutils.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		scanner := bufio.NewScanner(conn)
+
+		tests := []struct {
+			name string
+			req  string
+			id   string
+			code int
+		}{
+			{name: "parse error", req: `{"jsonrpc":`, id: "null", code: control.CodeParseError},
+			{name: "invalid version", req: `{"jsonrpc":"1.0","id":1,"method":"status"}`, id: "1", code: control.CodeInvalidRequest},
+			{name: "missing method", req: `{"jsonrpc":"2.0","id":2}`, id: "2", code: control.CodeInvalidRequest},
+			// notifications get no response, next response is for request 3.
+			{name: "notification", req: `{"jsonrpc":"2.0","method":"status"}` + "\n" + `{"jsonrpc":"2.0","id":3,"method":"status"}`, id: "3"},
+			{name: "unknown method notification", req: `{"jsonrpc":"2.0","method":"missing"}` + "\n" + `{"jsonrpc":"2.0","id":"4","method":"status"}`, id: `"4"`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := conn.Write([]byte(tt.req + "\n"))
+				testutils.NoError(t, err)
+				if !testutils.True(t, scanner.Scan(), "response expected") {
+					return
+				}
+				var resp control.RPCResponse
+				testutils.NoError(t, json.Unmarshal(scanner.Bytes(), &resp))
+				testutils.Equal(t, "2.0", resp.JSONRPC)
+				testutils.Equal(t, tt.id, string(resp.ID))
+				if tt.code == 0 {
+					testutils.True(t, resp.Error == nil, "unexpected error %v", resp.Error)
+					testutils.True(t, len(resp.Result) > 0, "result expected")
+					return
+				}
+				if testutils.True(t, resp.Error != nil, "error expected") {
+					testutils.Equal(t, tt.code, resp.Error.Code)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package docs provides addon which serves Go documentation of the
+// application module and its dependencies locally, along with
+// documentation topics provided by the application and its addons.
+package docs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/validate"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/addon"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/networking/listener"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+var Error = errors.New("docs")
+
+const ServiceName = "docs"
+
+type Settings struct {
+	Address  settings.String `key:"address,save" default:"127.0.0.1:6061" mutation:"once" desc:"Address documentation server listens on"`
+	Standard settings.Bool   `key:"standard,save" default:"false" desc:"List standard library packages imported by the module"`
+}
+
+func (s Settings) Blueprint() (*settings.Blueprint, error) {
+	b, err := settings.New(s)
+	if err != nil {
+		return nil, err
+	}
+	b.AddValidator("address", "", func(s settings.Setting) error {
+		if err := validate.HostPort()(s.Value().String()); err != nil {
+			return fmt.Errorf("%w: address %w", settings.ErrSetting, err)
+		}
+		return nil
+	})
+	return b, nil
+}
+
+// Addon returns docs addon providing docs service and docs command
+// which runs the service in foreground. Settings are available under
+// docs.* keys.
+func Addon() *addon.Addon {
+	a := addon.New(addon.Config{
+		Name:     "Docs",
+		Settings: Settings{},
+		Permissions: addon.Permissions{
+			Exec: []string{"go"},
+		},
+	})
+	a.ProvideServices(AsService())
+	a.ProvideCommands(Command())
+	return a
+}
+
+// AsService returns service serving documentation on docs.address.
+// Module is documented from project root or working directory.
+func AsService() *services.Service {
+	svc := services.New(service.Config{
+		Name:        ServiceName,
+		Description: "Serves Go documentation and documentation topics",
+	})
+
+	var (
+		mu  sync.Mutex
+		srv *http.Server
+	)
+
+	svc.OnStart(func(sess *session.Context) error {
+		root := sess.Get("app.fs.path.wd").String()
+		if proj := sess.Project(); proj != nil {
+			root = proj.Root
+		}
+		ln, err := listener.Listen("tcp", sess.Get("docs.address").String())
+		if err != nil {
+			return fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		server := NewServer(root, sess.Docs())
+		server.Standard = sess.Get("docs.standard").Bool()
+
+		mu.Lock()
+		srv = &http.Server{
+			Handler:           server,
+			ReadHeaderTimeout: 10 * time.Second,
+			BaseContext:       func(net.Listener) context.Context { return sess },
+		}
+		mu.Unlock()
+
+		go func() {
+			if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				sess.Log().Error("docs service failed", slog.String("err", err.Error()))
+			}
+		}()
+		internal.Log(sess.Log(), "docs service listening", slog.String("addr", ln.Addr().String()))
+		return nil
+	})
+
+	svc.OnStop(func(sess *session.Context, e error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if srv == nil {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(ctx)
+	})
+	return svc
+}
+
+// Command returns docs command which starts docs service and serves
+// documentation until interrupted.
+func Command() *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "docs",
+		Category:    "Development",
+		Description: "Serve Go documentation of the module and its dependencies",
+	})
+
+	cmd.AddInfo("Documentation topics provided by the application and its addons are served under /topics. Use --set docs.address=<host:port> to listen on another address.")
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		loader := services.NewLoader(sess, ServiceName)
+		<-loader.Load()
+		if err := loader.Err(); err != nil {
+			return err
+		}
+		sess.Log().Notice("serving documentation", slog.String("url", "http://"+sess.Get("docs.address").String()))
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigs)
+		select {
+		case <-sigs:
+		case <-sess.Done():
+		}
+		return nil
+	})
+	return cmd
+}
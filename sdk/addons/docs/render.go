@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package docs
+
+import (
+	"bytes"
+	"fmt"
+	"go/doc"
+	"go/printer"
+	"go/token"
+	"html"
+	"html/template"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+type packagePage struct {
+	Title       string
+	Package     *Package
+	Doc         *doc.Package
+	Subpackages []*Package
+	fset        *token.FileSet
+}
+
+// Decl returns source of declaration node.
+func (p *packagePage) Decl(node any) string {
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := cfg.Fprint(&buf, p.fset, node); err != nil {
+		return err.Error()
+	}
+	return buf.String()
+}
+
+// DocHTML returns doc comment text formatted as HTML.
+func (p *packagePage) DocHTML(text string) template.HTML {
+	return template.HTML(p.Doc.HTML(text))
+}
+
+const layout = `{{define "layout"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body{font-family:system-ui,sans-serif;max-width:60rem;margin:0 auto;padding:1rem 2rem;line-height:1.5;color:#202224}
+a{color:#007d9c;text-decoration:none}a:hover{text-decoration:underline}
+pre{background:#f6f8fa;padding:.75rem;overflow-x:auto;border-radius:4px}
+code{font-family:ui-monospace,monospace;font-size:.9em}
+table{border-collapse:collapse}td{padding:.15rem 1rem .15rem 0;vertical-align:top}
+nav{margin-bottom:1rem}h2{border-bottom:1px solid #ddd;padding-bottom:.25rem}
+.muted{color:#6e7781}
+</style>
+</head>
+<body>
+<nav><a href="/">Index</a></nav>
+{{template "content" .}}
+</body>
+</html>{{end}}`
+
+var indexTemplate = template.Must(template.Must(template.New("index").Parse(layout)).Parse(`{{define "content"}}
+<h1>{{.Title}}</h1>
+{{with .Topics}}<h2>Topics</h2>
+<table>{{range .}}<tr><td><a href="/topics/{{.Name}}">{{.Name}}</a></td><td>{{.Title}}</td></tr>{{end}}</table>{{end}}
+<h2>Packages</h2>
+<table>{{range .Packages}}<tr><td><a href="/pkg/{{.ImportPath}}">{{.ImportPath}}</a></td><td class="muted">{{.Synopsis}}</td></tr>{{end}}</table>
+{{end}}`))
+
+var topicTemplate = template.Must(template.Must(template.New("topic").Parse(layout)).Parse(`{{define "content"}}{{.Content}}{{end}}`))
+
+var packageTemplate = template.Must(template.Must(template.New("package").Parse(layout)).Parse(`{{define "content"}}
+{{$p := .}}
+<h1>package {{.Doc.Name}}</h1>
+<p><code>import "{{.Package.ImportPath}}"</code>{{with .Package.Module}} <span class="muted">module {{.}}</span>{{end}}</p>
+{{$p.DocHTML .Doc.Doc}}
+{{with .Doc.Consts}}<h2 id="pkg-constants">Constants</h2>{{range .}}<pre>{{$p.Decl .Decl}}</pre>{{$p.DocHTML .Doc}}{{end}}{{end}}
+{{with .Doc.Vars}}<h2 id="pkg-variables">Variables</h2>{{range .}}<pre>{{$p.Decl .Decl}}</pre>{{$p.DocHTML .Doc}}{{end}}{{end}}
+{{with .Doc.Funcs}}<h2 id="pkg-functions">Functions</h2>{{range .}}<h3 id="{{.Name}}">func {{.Name}}</h3><pre>{{$p.Decl .Decl}}</pre>{{$p.DocHTML .Doc}}{{end}}{{end}}
+{{with .Doc.Types}}<h2 id="pkg-types">Types</h2>{{range .}}
+<h3 id="{{.Name}}">type {{.Name}}</h3><pre>{{$p.Decl .Decl}}</pre>{{$p.DocHTML .Doc}}
+{{range .Consts}}<pre>{{$p.Decl .Decl}}</pre>{{$p.DocHTML .Doc}}{{end}}
+{{range .Vars}}<pre>{{$p.Decl .Decl}}</pre>{{$p.DocHTML .Doc}}{{end}}
+{{range .Funcs}}<h4 id="{{.Name}}">func {{.Name}}</h4><pre>{{$p.Decl .Decl}}</pre>{{$p.DocHTML .Doc}}{{end}}
+{{range .Methods}}<h4 id="{{.Orig}}.{{.Name}}">func ({{.Recv}}) {{.Name}}</h4><pre>{{$p.Decl .Decl}}</pre>{{$p.DocHTML .Doc}}{{end}}
+{{end}}{{end}}
+{{with .Subpackages}}<h2 id="pkg-subdirectories">Subpackages</h2>
+<table>{{range .}}<tr><td><a href="/pkg/{{.ImportPath}}">{{.ImportPath}}</a></td><td class="muted">{{.Synopsis}}</td></tr>{{end}}</table>{{end}}
+{{end}}`))
+
+func render(w http.ResponseWriter, tmpl *template.Template, data any) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+var (
+	mdBold = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	mdCode = regexp.MustCompile("`([^`]+)`")
+	mdLink = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+)
+
+// markdownHTML renders same Markdown subset as help topics in terminal,
+// headings, lists, quotes, code blocks, links and inline emphasis.
+func markdownHTML(md string) template.HTML {
+	var (
+		b     strings.Builder
+		fence bool
+		list  bool
+		para  []string
+	)
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + inlineHTML(strings.Join(para, " ")) + "</p>\n")
+			para = nil
+		}
+		if list {
+			b.WriteString("</ul>\n")
+			list = false
+		}
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			if fence {
+				b.WriteString("</code></pre>\n")
+			} else {
+				flush()
+				b.WriteString("<pre><code>")
+			}
+			fence = !fence
+			continue
+		}
+		if fence {
+			b.WriteString(html.EscapeString(line) + "\n")
+			continue
+		}
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			flush()
+			level := min(len(trimmed)-len(strings.TrimLeft(trimmed, "#")), 6)
+			title := inlineHTML(strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", level, title, level)
+		case strings.HasPrefix(trimmed, "- "), strings.HasPrefix(trimmed, "* "):
+			if len(para) > 0 {
+				flush()
+			}
+			if !list {
+				b.WriteString("<ul>\n")
+				list = true
+			}
+			b.WriteString("<li>" + inlineHTML(trimmed[2:]) + "</li>\n")
+		case strings.HasPrefix(trimmed, "> "):
+			flush()
+			b.WriteString("<blockquote>" + inlineHTML(trimmed[2:]) + "</blockquote>\n")
+		case trimmed == "":
+			flush()
+		default:
+			if list {
+				flush()
+			}
+			para = append(para, trimmed)
+		}
+	}
+	if fence {
+		b.WriteString("</code></pre>\n")
+	}
+	flush()
+	return template.HTML(b.String())
+}
+
+func inlineHTML(s string) string {
+	s = html.EscapeString(s)
+	s = mdCode.ReplaceAllString(s, "<code>$1</code>")
+	s = mdBold.ReplaceAllString(s, "<strong>$1</strong>")
+	s = mdLink.ReplaceAllStringFunc(s, func(m string) string {
+		sub := mdLink.FindStringSubmatch(m)
+		href := sub[2]
+		if !strings.HasPrefix(href, "http://") && !strings.HasPrefix(href, "https://") && !strings.HasPrefix(href, "/") {
+			href = "#"
+		}
+		return `<a href="` + href + `">` + sub[1] + `</a>`
+	})
+	return s
+}
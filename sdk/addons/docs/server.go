@@ -0,0 +1,228 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package docs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+
+	"github.com/happy-sdk/happy/sdk/cli/help"
+)
+
+// Package is package listed by documentation server.
+type Package struct {
+	ImportPath string
+	Dir        string
+	Module     string
+	// Main is true for packages of documented module.
+	Main     bool
+	Standard bool
+	Synopsis string
+}
+
+// Server serves documentation of module in Root and its dependencies.
+// Packages are listed with go list on first request, package
+// documentation is rendered from source when requested.
+type Server struct {
+	Root string
+	// Standard lists standard library packages imported by the module.
+	Standard bool
+
+	topics []*help.Topic
+	mux    *http.ServeMux
+
+	once     sync.Once
+	pkgs     map[string]*Package
+	listErr  error
+	mu       sync.Mutex
+	rendered map[string][]byte
+}
+
+// NewServer returns documentation server for module in root serving
+// topics under /topics/<name>.
+func NewServer(root string, topics []*help.Topic) *Server {
+	s := &Server{
+		Root:     root,
+		topics:   topics,
+		rendered: make(map[string][]byte),
+	}
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /{$}", s.serveIndex)
+	s.mux.HandleFunc("GET /pkg/{path...}", s.servePackage)
+	s.mux.HandleFunc("GET /topics/{name}", s.serveTopic)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Packages returns listed packages sorted by import path.
+func (s *Server) Packages() ([]*Package, error) {
+	s.once.Do(s.list)
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+	pkgs := make([]*Package, 0, len(s.pkgs))
+	for _, pkg := range s.pkgs {
+		if pkg.Standard && !s.Standard {
+			continue
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		if pkgs[i].Main != pkgs[j].Main {
+			return pkgs[i].Main
+		}
+		return pkgs[i].ImportPath < pkgs[j].ImportPath
+	})
+	return pkgs, nil
+}
+
+const listFormat = `{{.ImportPath}}{{"\t"}}{{.Dir}}{{"\t"}}{{with .Module}}{{.Path}}{{"\t"}}{{.Main}}{{else}}{{"\t"}}false{{end}}{{"\t"}}{{.Standard}}{{"\t"}}{{.Doc}}`
+
+func (s *Server) list() {
+	cmd := exec.Command("go", "list", "-deps", "-f", listFormat, "./...")
+	cmd.Dir = s.Root
+	out, err := cmd.Output()
+	if err != nil {
+		if exit, ok := err.(*exec.ExitError); ok {
+			err = fmt.Errorf("%s", strings.TrimSpace(string(exit.Stderr)))
+		}
+		s.listErr = fmt.Errorf("%w: go list: %s", Error, err.Error())
+		return
+	}
+	s.pkgs = make(map[string]*Package)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 6)
+		if len(fields) != 6 {
+			continue
+		}
+		s.pkgs[fields[0]] = &Package{
+			ImportPath: fields[0],
+			Dir:        fields[1],
+			Module:     fields[2],
+			Main:       fields[3] == "true",
+			Standard:   fields[4] == "true",
+			Synopsis:   fields[5],
+		}
+	}
+}
+
+func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	pkgs, err := s.Packages()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	render(w, indexTemplate, map[string]any{
+		"Title":    "Documentation",
+		"Packages": pkgs,
+		"Topics":   s.topics,
+	})
+}
+
+func (s *Server) serveTopic(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	for _, topic := range s.topics {
+		if topic.Name() != name {
+			continue
+		}
+		render(w, topicTemplate, map[string]any{
+			"Title":   topic.Title(),
+			"Content": markdownHTML(topic.Content(preferredLanguage(r))),
+		})
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (s *Server) servePackage(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.PathValue("path"), "/")
+	if _, err := s.Packages(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pkg, ok := s.pkgs[path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	page, ok := s.rendered[path]
+	s.mu.Unlock()
+	if !ok {
+		var buf bytes.Buffer
+		if err := s.renderPackage(&buf, pkg); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		page = buf.Bytes()
+		s.mu.Lock()
+		s.rendered[path] = page
+		s.mu.Unlock()
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(page)
+}
+
+func (s *Server) renderPackage(buf *bytes.Buffer, pkg *Package) error {
+	bpkg, err := build.ImportDir(pkg.Dir, 0)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bpkg.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		files = append(files, f)
+	}
+	dpkg, err := doc.NewFromFiles(fset, files, pkg.ImportPath)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+
+	var subpkgs []*Package
+	for _, p := range s.pkgs {
+		if strings.HasPrefix(p.ImportPath, pkg.ImportPath+"/") && (!p.Standard || s.Standard) {
+			subpkgs = append(subpkgs, p)
+		}
+	}
+	sort.Slice(subpkgs, func(i, j int) bool { return subpkgs[i].ImportPath < subpkgs[j].ImportPath })
+
+	return packageTemplate.ExecuteTemplate(buf, "layout", &packagePage{
+		Title:       dpkg.Name,
+		Package:     pkg,
+		Doc:         dpkg,
+		fset:        fset,
+		Subpackages: subpkgs,
+	})
+}
+
+// preferredLanguage returns first language of Accept-Language header.
+func preferredLanguage(r *http.Request) language.Tag {
+	tag, _, _ := strings.Cut(r.Header.Get("Accept-Language"), ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	return language.Make(strings.TrimSpace(tag))
+}
@@ -0,0 +1,489 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package grpccli
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Messages are encoded from and decoded to values of JSON mapping of
+// protocol buffers: objects keyed by field or JSON name, 64-bit
+// integers as strings, enums by name, bytes as base64 and well-known
+// Timestamp, Duration and wrapper types as their JSON values.
+
+// Marshal encodes JSON value v as message m.
+func (m *Message) Marshal(v map[string]any) ([]byte, error) {
+	switch m.Name {
+	case "google.protobuf.Empty":
+		return nil, nil
+	}
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b []byte
+	for _, k := range keys {
+		f := m.field(k)
+		if f == nil {
+			return nil, fmt.Errorf("%w: %s has no field %q", Error, m.Name, k)
+		}
+		val := v[k]
+		if val == nil {
+			continue
+		}
+		var err error
+		switch {
+		case f.IsMap():
+			b, err = f.appendMap(b, val)
+		case f.Repeated:
+			b, err = f.appendList(b, val)
+		default:
+			b, err = f.appendValue(b, val)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s.%s: %s", Error, m.Name, f.Name, err.Error())
+		}
+	}
+	return b, nil
+}
+
+// Unmarshal decodes message m to JSON value.
+func (m *Message) Unmarshal(b []byte) (map[string]any, error) {
+	fields, err := parseFields(b)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]any)
+	for _, wf := range fields {
+		f := m.fieldByNumber(wf.Num)
+		if f == nil {
+			continue
+		}
+		switch {
+		case f.IsMap():
+			entry, err := f.Message.Unmarshal(wf.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			mv, _ := out[f.JSONName].(map[string]any)
+			if mv == nil {
+				mv = make(map[string]any)
+				out[f.JSONName] = mv
+			}
+			key := f.Message.fieldByNumber(1)
+			k := entry[key.JSONName]
+			if k == nil {
+				k = ""
+			}
+			mv[fmt.Sprint(k)] = entry[f.Message.fieldByNumber(2).JSONName]
+		case f.Repeated:
+			list, _ := out[f.JSONName].([]any)
+			if wf.Type == wireBytes && f.packable() {
+				values, err := packed(wf.Bytes, f.wireType())
+				if err != nil {
+					return nil, err
+				}
+				for _, v := range values {
+					list = append(list, f.scalar(v))
+				}
+			} else {
+				v, err := f.decode(wf)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			out[f.JSONName] = list
+		default:
+			v, err := f.decode(wf)
+			if err != nil {
+				return nil, err
+			}
+			out[f.JSONName] = v
+		}
+	}
+	return out, nil
+}
+
+// unmarshalValue decodes message to JSON value, well-known types are
+// decoded to their JSON values.
+func (m *Message) unmarshalValue(b []byte) (any, error) {
+	v, err := m.Unmarshal(b)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case m.Name == "google.protobuf.Timestamp":
+		secs, nanos := wktParts(v)
+		return time.Unix(secs, nanos).UTC().Format(time.RFC3339Nano), nil
+	case m.Name == "google.protobuf.Duration":
+		secs, nanos := wktParts(v)
+		return strconv.FormatFloat(float64(secs)+float64(nanos)/1e9, 'f', -1, 64) + "s", nil
+	case isWrapper(m.Name):
+		return v["value"], nil
+	}
+	return v, nil
+}
+
+func (m *Message) field(name string) *Field {
+	for _, f := range m.Fields {
+		if f.Name == name || f.JSONName == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (m *Message) fieldByNumber(num int32) *Field {
+	for _, f := range m.Fields {
+		if f.Number == num {
+			return f
+		}
+	}
+	return nil
+}
+
+func (f *Field) appendMap(b []byte, v any) ([]byte, error) {
+	mv, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected object, got %T", v)
+	}
+	keys := make([]string, 0, len(mv))
+	for k := range mv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	key, value := f.Message.fieldByNumber(1), f.Message.fieldByNumber(2)
+	for _, k := range keys {
+		entry, err := key.appendValue(nil, k)
+		if err != nil {
+			return nil, err
+		}
+		if mv[k] != nil {
+			if entry, err = value.appendValue(entry, mv[k]); err != nil {
+				return nil, err
+			}
+		}
+		b = appendBytes(b, f.Number, entry)
+	}
+	return b, nil
+}
+
+func (f *Field) appendList(b []byte, v any) ([]byte, error) {
+	list, ok := v.([]any)
+	if !ok {
+		list = []any{v}
+	}
+	if !f.packable() {
+		for _, item := range list {
+			var err error
+			if b, err = f.appendValue(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	var raw []byte
+	for _, item := range list {
+		_, r, err := f.raw(item)
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, r...)
+	}
+	return appendBytes(b, f.Number, raw), nil
+}
+
+func (f *Field) appendValue(b []byte, v any) ([]byte, error) {
+	wt, raw, err := f.raw(v)
+	if err != nil {
+		return nil, err
+	}
+	if wt == wireBytes {
+		return appendBytes(b, f.Number, raw), nil
+	}
+	return append(appendTag(b, f.Number, wt), raw...), nil
+}
+
+// raw encodes value of field without tag, length delimited values are
+// returned without length.
+func (f *Field) raw(v any) (int, []byte, error) {
+	switch f.Type {
+	case typeDouble:
+		n, err := toFloat(v)
+		return wireFixed64, fixed64(math.Float64bits(n)), err
+	case typeFloat:
+		n, err := toFloat(v)
+		return wireFixed32, fixed32(float32bits(n)), err
+	case typeInt64, typeInt32:
+		n, err := toInt(v)
+		return wireVarint, varint(uint64(n)), err
+	case typeUint64, typeUint32:
+		n, err := toUint(v)
+		return wireVarint, varint(n), err
+	case typeSint32, typeSint64:
+		n, err := toInt(v)
+		return wireVarint, varint(zigzag(n)), err
+	case typeFixed64:
+		n, err := toUint(v)
+		return wireFixed64, fixed64(n), err
+	case typeSfixed64:
+		n, err := toInt(v)
+		return wireFixed64, fixed64(uint64(n)), err
+	case typeFixed32:
+		n, err := toUint(v)
+		return wireFixed32, fixed32(uint32(n)), err
+	case typeSfixed32:
+		n, err := toInt(v)
+		return wireFixed32, fixed32(uint32(int32(n))), err
+	case typeBool:
+		bv, err := toBool(v)
+		if bv {
+			return wireVarint, varint(1), err
+		}
+		return wireVarint, varint(0), err
+	case typeString:
+		s, ok := v.(string)
+		if !ok {
+			return 0, nil, fmt.Errorf("expected string, got %T", v)
+		}
+		return wireBytes, []byte(s), nil
+	case typeBytes:
+		s, ok := v.(string)
+		if !ok {
+			return 0, nil, fmt.Errorf("expected base64 string, got %T", v)
+		}
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			data, err = base64.URLEncoding.DecodeString(s)
+		}
+		return wireBytes, data, err
+	case typeEnum:
+		if s, ok := v.(string); ok {
+			if num, ok := f.Enum.number(s); ok {
+				return wireVarint, varint(uint64(int64(num))), nil
+			}
+			if _, err := strconv.ParseInt(s, 10, 32); err != nil {
+				return 0, nil, fmt.Errorf("unknown value %q of %s", s, f.Enum.Name)
+			}
+		}
+		n, err := toInt(v)
+		return wireVarint, varint(uint64(n)), err
+	case typeMessage:
+		data, err := f.Message.marshalValue(v)
+		return wireBytes, data, err
+	default:
+		return 0, nil, fmt.Errorf("unsupported field type %d", f.Type)
+	}
+}
+
+// marshalValue encodes JSON value of message, well-known types accept
+// their JSON values.
+func (m *Message) marshalValue(v any) ([]byte, error) {
+	switch {
+	case m.Name == "google.protobuf.Timestamp":
+		s, ok := v.(string)
+		if !ok {
+			break
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, err
+		}
+		return m.Marshal(map[string]any{"seconds": t.Unix(), "nanos": t.Nanosecond()})
+	case m.Name == "google.protobuf.Duration":
+		s, ok := v.(string)
+		if !ok {
+			break
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		return m.Marshal(map[string]any{"seconds": int64(d / time.Second), "nanos": int64(d % time.Second)})
+	case isWrapper(m.Name):
+		if _, ok := v.(map[string]any); !ok {
+			return m.Marshal(map[string]any{"value": v})
+		}
+	}
+	mv, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected object, got %T", v)
+	}
+	return m.Marshal(mv)
+}
+
+func (f *Field) decode(wf field) (any, error) {
+	switch f.Type {
+	case typeString:
+		return string(wf.Bytes), nil
+	case typeBytes:
+		return base64.StdEncoding.EncodeToString(wf.Bytes), nil
+	case typeMessage:
+		return f.Message.unmarshalValue(wf.Bytes)
+	case typeGroup:
+		return nil, nil
+	}
+	if wf.Type != f.wireType() {
+		return nil, fmt.Errorf("%w: %s has wire type %d", Error, f.Name, wf.Type)
+	}
+	return f.scalar(wf.Value), nil
+}
+
+// scalar returns JSON value of numeric field value v.
+func (f *Field) scalar(v uint64) any {
+	switch f.Type {
+	case typeDouble:
+		return math.Float64frombits(v)
+	case typeFloat:
+		return float64(math.Float32frombits(uint32(v)))
+	case typeInt64, typeSfixed64:
+		return strconv.FormatInt(int64(v), 10)
+	case typeUint64, typeFixed64:
+		return strconv.FormatUint(v, 10)
+	case typeSint64:
+		return strconv.FormatInt(unzigzag(v), 10)
+	case typeInt32:
+		return int32(v)
+	case typeSfixed32:
+		return int32(uint32(v))
+	case typeSint32:
+		return int32(unzigzag(v))
+	case typeUint32, typeFixed32:
+		return uint32(v)
+	case typeBool:
+		return v != 0
+	case typeEnum:
+		return f.Enum.name(int32(v))
+	}
+	return v
+}
+
+func (f *Field) wireType() int {
+	switch f.Type {
+	case typeDouble, typeFixed64, typeSfixed64:
+		return wireFixed64
+	case typeFloat, typeFixed32, typeSfixed32:
+		return wireFixed32
+	case typeString, typeBytes, typeMessage:
+		return wireBytes
+	case typeGroup:
+		return wireStart
+	}
+	return wireVarint
+}
+
+// packable reports whether repeated field may be packed.
+func (f *Field) packable() bool {
+	wt := f.wireType()
+	return wt == wireVarint || wt == wireFixed32 || wt == wireFixed64
+}
+
+// jsonScalar reports whether JSON value of message is not object.
+func (m *Message) jsonScalar() bool {
+	return m.Name == "google.protobuf.Timestamp" || m.Name == "google.protobuf.Duration" || isWrapper(m.Name)
+}
+
+func isWrapper(name string) bool {
+	switch strings.TrimPrefix(name, "google.protobuf.") {
+	case "DoubleValue", "FloatValue", "Int64Value", "UInt64Value", "Int32Value",
+		"UInt32Value", "BoolValue", "StringValue", "BytesValue":
+		return strings.HasPrefix(name, "google.protobuf.")
+	}
+	return false
+}
+
+func wktParts(v map[string]any) (secs, nanos int64) {
+	if s, ok := v["seconds"].(string); ok {
+		secs, _ = strconv.ParseInt(s, 10, 64)
+	}
+	if n, ok := v["nanos"].(int32); ok {
+		nanos = int64(n)
+	}
+	return secs, nanos
+}
+
+func varint(v uint64) []byte { return binary.AppendUvarint(nil, v) }
+
+func fixed32(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+
+func fixed64(v uint64) []byte { return binary.LittleEndian.AppendUint64(nil, v) }
+
+func toFloat(v any) (float64, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return v.Float64()
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("expected number, got %T", v)
+}
+
+func toInt(v any) (int64, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return strconv.ParseInt(v.String(), 10, 64)
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("expected integer, got %v", v)
+		}
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return 0, fmt.Errorf("expected integer, got %T", v)
+}
+
+func toUint(v any) (uint64, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return strconv.ParseUint(v.String(), 10, 64)
+	case string:
+		return strconv.ParseUint(v, 10, 64)
+	}
+	n, err := toInt(v)
+	if err == nil && n < 0 {
+		return 0, fmt.Errorf("expected unsigned integer, got %d", n)
+	}
+	return uint64(n), err
+}
+
+func toBool(v any) (bool, error) {
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(v)
+	}
+	return false, fmt.Errorf("expected boolean, got %T", v)
+}
@@ -0,0 +1,497 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package grpccli
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Field types of FieldDescriptorProto.
+const (
+	typeDouble   = 1
+	typeFloat    = 2
+	typeInt64    = 3
+	typeUint64   = 4
+	typeInt32    = 5
+	typeFixed64  = 6
+	typeFixed32  = 7
+	typeBool     = 8
+	typeString   = 9
+	typeGroup    = 10
+	typeMessage  = 11
+	typeBytes    = 12
+	typeUint32   = 13
+	typeEnum     = 14
+	typeSfixed32 = 15
+	typeSfixed64 = 16
+	typeSint32   = 17
+	typeSint64   = 18
+)
+
+const labelRepeated = 3
+
+var typeNames = map[int]string{
+	typeDouble: "double", typeFloat: "float", typeInt64: "int64",
+	typeUint64: "uint64", typeInt32: "int32", typeFixed64: "fixed64",
+	typeFixed32: "fixed32", typeBool: "bool", typeString: "string",
+	typeGroup: "group", typeMessage: "message", typeBytes: "bytes",
+	typeUint32: "uint32", typeEnum: "enum", typeSfixed32: "sfixed32",
+	typeSfixed64: "sfixed64", typeSint32: "sint32", typeSint64: "sint64",
+}
+
+// Descriptors are services and types of compiled protocol buffers
+// files, e.g. descriptor set written by protoc --descriptor_set_out
+// or fetched with server reflection.
+type Descriptors struct {
+	// files are encoded FileDescriptorProto by file name.
+	files    map[string][]byte
+	order    []string
+	Services []*Service
+	messages map[string]*Message
+	enums    map[string]*Enum
+}
+
+// Service is gRPC service.
+type Service struct {
+	// Name is fully qualified name of the service e.g. acme.v1.Users.
+	Name    string
+	Methods []*Method
+}
+
+// Method is method of gRPC service.
+type Method struct {
+	Name            string
+	Service         *Service
+	Input           *Message
+	Output          *Message
+	ClientStreaming bool
+	ServerStreaming bool
+
+	input, output string
+}
+
+// Path returns HTTP path of the method.
+func (m *Method) Path() string {
+	return "/" + m.Service.Name + "/" + m.Name
+}
+
+// Message is protocol buffers message type.
+type Message struct {
+	// Name is fully qualified name of the message.
+	Name     string
+	Fields   []*Field
+	MapEntry bool
+}
+
+// Field is field of message.
+type Field struct {
+	Name     string
+	JSONName string
+	Number   int32
+	Type     int
+	Repeated bool
+	// Oneof is name of oneof field belongs to.
+	Oneof string
+	// Message and Enum are set for message and enum fields.
+	Message *Message
+	Enum    *Enum
+
+	typeName string
+}
+
+// IsMap reports whether field is map field.
+func (f *Field) IsMap() bool {
+	return f.Repeated && f.Message != nil && f.Message.MapEntry
+}
+
+// kind returns type of field as written in .proto file.
+func (f *Field) kind() string {
+	switch {
+	case f.IsMap():
+		key, value := f.Message.fieldByNumber(1), f.Message.fieldByNumber(2)
+		if key != nil && value != nil {
+			return "map<" + key.kind() + ", " + value.kind() + ">"
+		}
+	case f.Message != nil:
+		return f.Message.Name
+	case f.Enum != nil:
+		return f.Enum.Name
+	}
+	return typeNames[f.Type]
+}
+
+// Enum is protocol buffers enum type.
+type Enum struct {
+	Name   string
+	Values []EnumValue
+}
+
+// EnumValue is value of enum.
+type EnumValue struct {
+	Name   string
+	Number int32
+}
+
+// name returns name of enum value number, number itself when it is
+// not known.
+func (e *Enum) name(num int32) any {
+	for _, v := range e.Values {
+		if v.Number == num {
+			return v.Name
+		}
+	}
+	return num
+}
+
+func (e *Enum) number(name string) (int32, bool) {
+	for _, v := range e.Values {
+		if v.Name == name {
+			return v.Number, true
+		}
+	}
+	return 0, false
+}
+
+// LoadDescriptors reads descriptor set file.
+func LoadDescriptors(path string) (*Descriptors, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return ParseDescriptors(data)
+}
+
+// ParseDescriptors parses encoded FileDescriptorSet.
+func ParseDescriptors(data []byte) (*Descriptors, error) {
+	fields, err := parseFields(data)
+	if err != nil {
+		return nil, err
+	}
+	d := newDescriptors()
+	for _, f := range fields {
+		if f.Num == 1 && f.Type == wireBytes {
+			if err := d.add(f.Bytes); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := d.resolve(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func newDescriptors() *Descriptors {
+	return &Descriptors{
+		files:    make(map[string][]byte),
+		messages: make(map[string]*Message),
+		enums:    make(map[string]*Enum),
+	}
+}
+
+// Marshal returns descriptors encoded as FileDescriptorSet.
+func (d *Descriptors) Marshal() []byte {
+	var b []byte
+	for _, name := range d.order {
+		b = appendBytes(b, 1, d.files[name])
+	}
+	return b
+}
+
+// Service returns service by fully qualified name.
+func (d *Descriptors) Service(name string) (*Service, bool) {
+	for _, svc := range d.Services {
+		if svc.Name == name {
+			return svc, true
+		}
+	}
+	return nil, false
+}
+
+// add adds encoded FileDescriptorProto, files added before are
+// ignored. Types are resolved by resolve.
+func (d *Descriptors) add(file []byte) error {
+	fields, err := parseFields(file)
+	if err != nil {
+		return err
+	}
+	var name, pkg string
+	for _, f := range fields {
+		switch f.Num {
+		case 1:
+			name = string(f.Bytes)
+		case 2:
+			pkg = string(f.Bytes)
+		}
+	}
+	if _, ok := d.files[name]; ok {
+		return nil
+	}
+	d.files[name] = file
+	d.order = append(d.order, name)
+
+	scope := ""
+	if pkg != "" {
+		scope = pkg + "."
+	}
+	for _, f := range fields {
+		switch f.Num {
+		case 4:
+			if err := d.addMessage(scope, f.Bytes); err != nil {
+				return err
+			}
+		case 5:
+			if err := d.addEnum(scope, f.Bytes); err != nil {
+				return err
+			}
+		case 6:
+			if err := d.addService(scope, f.Bytes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dependencies returns names of files imported by added files which
+// were not added.
+func (d *Descriptors) dependencies() []string {
+	var missing []string
+	for _, name := range d.order {
+		fields, _ := parseFields(d.files[name])
+		for _, f := range fields {
+			if f.Num != 3 {
+				continue
+			}
+			dep := string(f.Bytes)
+			if _, ok := d.files[dep]; !ok && !slices.Contains(missing, dep) {
+				missing = append(missing, dep)
+			}
+		}
+	}
+	return missing
+}
+
+func (d *Descriptors) addMessage(scope string, b []byte) error {
+	fields, err := parseFields(b)
+	if err != nil {
+		return err
+	}
+	msg := &Message{}
+	var oneofs []string
+	for _, f := range fields {
+		switch f.Num {
+		case 1:
+			msg.Name = scope + string(f.Bytes)
+		case 7:
+			opts, err := parseFields(f.Bytes)
+			if err != nil {
+				return err
+			}
+			for _, o := range opts {
+				if o.Num == 7 && o.Value == 1 {
+					msg.MapEntry = true
+				}
+			}
+		case 8:
+			oneof, err := parseFields(f.Bytes)
+			if err != nil {
+				return err
+			}
+			name := ""
+			for _, o := range oneof {
+				if o.Num == 1 {
+					name = string(o.Bytes)
+				}
+			}
+			oneofs = append(oneofs, name)
+		}
+	}
+	d.messages[msg.Name] = msg
+
+	for _, f := range fields {
+		switch f.Num {
+		case 2:
+			field, oneof, err := parseField(f.Bytes)
+			if err != nil {
+				return err
+			}
+			if oneof >= 0 && oneof < len(oneofs) {
+				field.Oneof = oneofs[oneof]
+			}
+			msg.Fields = append(msg.Fields, field)
+		case 3:
+			if err := d.addMessage(msg.Name+".", f.Bytes); err != nil {
+				return err
+			}
+		case 4:
+			if err := d.addEnum(msg.Name+".", f.Bytes); err != nil {
+				return err
+			}
+		}
+	}
+	sort.Slice(msg.Fields, func(i, j int) bool { return msg.Fields[i].Number < msg.Fields[j].Number })
+	return nil
+}
+
+// parseField parses FieldDescriptorProto and returns index of its
+// oneof or -1.
+func parseField(b []byte) (*Field, int, error) {
+	fields, err := parseFields(b)
+	if err != nil {
+		return nil, -1, err
+	}
+	field := &Field{}
+	oneof := -1
+	synthetic := false
+	for _, f := range fields {
+		switch f.Num {
+		case 1:
+			field.Name = string(f.Bytes)
+		case 3:
+			field.Number = int32(f.Value)
+		case 4:
+			field.Repeated = f.Value == labelRepeated
+		case 5:
+			field.Type = int(f.Value)
+		case 6:
+			field.typeName = strings.TrimPrefix(string(f.Bytes), ".")
+		case 9:
+			oneof = int(f.Value)
+		case 10:
+			field.JSONName = string(f.Bytes)
+		case 17:
+			// proto3 optional fields are in synthetic oneof.
+			synthetic = f.Value == 1
+		}
+	}
+	if field.JSONName == "" {
+		field.JSONName = jsonName(field.Name)
+	}
+	if synthetic {
+		oneof = -1
+	}
+	return field, oneof, nil
+}
+
+func (d *Descriptors) addEnum(scope string, b []byte) error {
+	fields, err := parseFields(b)
+	if err != nil {
+		return err
+	}
+	enum := &Enum{}
+	for _, f := range fields {
+		switch f.Num {
+		case 1:
+			enum.Name = scope + string(f.Bytes)
+		case 2:
+			vfields, err := parseFields(f.Bytes)
+			if err != nil {
+				return err
+			}
+			var v EnumValue
+			for _, vf := range vfields {
+				switch vf.Num {
+				case 1:
+					v.Name = string(vf.Bytes)
+				case 2:
+					v.Number = int32(vf.Value)
+				}
+			}
+			enum.Values = append(enum.Values, v)
+		}
+	}
+	d.enums[enum.Name] = enum
+	return nil
+}
+
+func (d *Descriptors) addService(scope string, b []byte) error {
+	fields, err := parseFields(b)
+	if err != nil {
+		return err
+	}
+	svc := &Service{}
+	for _, f := range fields {
+		switch f.Num {
+		case 1:
+			svc.Name = scope + string(f.Bytes)
+		case 2:
+			mfields, err := parseFields(f.Bytes)
+			if err != nil {
+				return err
+			}
+			m := &Method{Service: svc}
+			for _, mf := range mfields {
+				switch mf.Num {
+				case 1:
+					m.Name = string(mf.Bytes)
+				case 2:
+					m.input = strings.TrimPrefix(string(mf.Bytes), ".")
+				case 3:
+					m.output = strings.TrimPrefix(string(mf.Bytes), ".")
+				case 5:
+					m.ClientStreaming = mf.Value == 1
+				case 6:
+					m.ServerStreaming = mf.Value == 1
+				}
+			}
+			svc.Methods = append(svc.Methods, m)
+		}
+	}
+	d.Services = append(d.Services, svc)
+	return nil
+}
+
+// resolve links fields and methods to their types.
+func (d *Descriptors) resolve() error {
+	for _, msg := range d.messages {
+		for _, f := range msg.Fields {
+			switch f.Type {
+			case typeMessage, typeGroup:
+				if f.Message = d.messages[f.typeName]; f.Message == nil {
+					return fmt.Errorf("%w: %s.%s: unknown type %s", Error, msg.Name, f.Name, f.typeName)
+				}
+			case typeEnum:
+				if f.Enum = d.enums[f.typeName]; f.Enum == nil {
+					return fmt.Errorf("%w: %s.%s: unknown type %s", Error, msg.Name, f.Name, f.typeName)
+				}
+			}
+		}
+	}
+	for _, svc := range d.Services {
+		for _, m := range svc.Methods {
+			if m.Input = d.messages[m.input]; m.Input == nil {
+				return fmt.Errorf("%w: %s: unknown type %s", Error, m.Path(), m.input)
+			}
+			if m.Output = d.messages[m.output]; m.Output == nil {
+				return fmt.Errorf("%w: %s: unknown type %s", Error, m.Path(), m.output)
+			}
+		}
+	}
+	sort.Slice(d.Services, func(i, j int) bool { return d.Services[i].Name < d.Services[j].Name })
+	return nil
+}
+
+// jsonName returns lowerCamelCase JSON name of field name.
+func jsonName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper && r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
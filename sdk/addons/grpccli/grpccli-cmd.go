@@ -0,0 +1,494 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package grpccli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+)
+
+// maxFieldDepth limits nesting of message fields exposed as flags.
+const maxFieldDepth = 3
+
+// Command returns grpc command with subcommand per service of
+// descriptors configured by cnf, which has subcommand per method, and
+// reflect subcommand fetching descriptors from server.
+func Command(cnf Config) *command.Command {
+	name := cnf.Name
+	if name == "" {
+		name = "grpc"
+	}
+	desc := cnf.Description
+	if desc == "" {
+		desc = "Call methods of gRPC services"
+	}
+	cmd := command.New(command.Config{
+		Name:        settings.String(name),
+		Category:    settings.String(cnf.Category),
+		Description: settings.String(desc),
+	})
+
+	cmd.AddInfo("Server is configured with grpc.* settings which can be overridden with flags. Each method is command taking fields of request as flags, fields of nested messages are joined with dash e.g. --address-city. Flags of repeated fields and of map fields in KEY=VALUE form can be repeated. Whole request can be given as JSON with --data, flags override its fields.")
+	if cnf.DescriptorSet != "" {
+		cmd.AddInfo("Commands are generated from descriptor set " + cnf.DescriptorSet + ", run " + name + " reflect to fetch it from server with server reflection.")
+	}
+
+	cmd.WithFlags(
+		varflag.StringFunc("target", "", "address of server as host:port overriding grpc.target"),
+		varflag.BoolFunc("plaintext", false, "connect without TLS"),
+		varflag.BoolFunc("insecure", false, "skip verification of server certificate"),
+		varflag.StringFunc("header", "", "metadata sent with call as KEY=VALUE, can be repeated"),
+	)
+	cmd.WithSubCommands(reflectCommand(cnf))
+
+	d, err := loadDescriptors(cnf)
+	var services [][2]string
+	if d != nil {
+		used := map[string]bool{"reflect": true}
+		for _, svc := range d.Services {
+			if len(svc.Methods) == 0 || (len(cnf.Services) > 0 && !slices.Contains(cnf.Services, svc.Name)) {
+				continue
+			}
+			sname := commandName(svc.Name[strings.LastIndex(svc.Name, ".")+1:])
+			if used[sname] {
+				sname = commandName(svc.Name)
+			}
+			if used[sname] || !varflag.ValidFlagName(sname) {
+				continue
+			}
+			used[sname] = true
+			services = append(services, [2]string{sname, svc.Name})
+			cmd.WithSubCommandFunc(sname, func() *command.Command {
+				return serviceCommand(sname, svc, cnf.Target)
+			})
+		}
+	}
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return fmt.Errorf("%w: no descriptors, run %s reflect to fetch them from server", Error, name)
+		}
+		table := textfmt.Table{
+			Title:      "Services",
+			WithHeader: true,
+		}
+		table.AddRow("Command", "Service", "Methods")
+		table.AddDivider()
+		for _, svc := range services {
+			s, _ := d.Service(svc[1])
+			table.AddRow(svc[0], svc[1], fmt.Sprint(len(s.Methods)))
+		}
+		sess.Log().Println(table.String())
+		return nil
+	})
+	return cmd
+}
+
+func reflectCommand(cnf Config) *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "reflect",
+		Description:      "Fetch descriptors from server with server reflection",
+		MaxArgs:          1,
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.Usage("[file]")
+	cmd.AddInfo("Descriptors of all services of the server are written as descriptor set to given file, by default to file commands are generated from. Server must have server reflection enabled.")
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		path := cnf.DescriptorSet
+		if args.Argn() > 0 {
+			path = args.Arg(0).String()
+		}
+		if path == "" {
+			return fmt.Errorf("%w: descriptor set file is not configured", Error)
+		}
+		dial, err := dialFromArgs(sess, args, cnf.Target)
+		if err != nil {
+			return err
+		}
+		client, err := NewClient(dial)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(sess, timeout(sess))
+		defer cancel()
+		d, err := Reflect(ctx, client)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, d.Marshal(), 0o644); err != nil {
+			return fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		var names []string
+		for _, svc := range d.Services {
+			names = append(names, svc.Name)
+		}
+		sess.Log().Ok("descriptors written",
+			slog.String("file", path),
+			slog.String("services", strings.Join(names, ", ")))
+		return nil
+	})
+	return cmd
+}
+
+func serviceCommand(name string, svc *Service, target string) *command.Command {
+	cmd := command.New(command.Config{
+		Name:        settings.String(name),
+		Description: settings.String("Call methods of " + svc.Name),
+	})
+	used := make(map[string]bool)
+	for _, m := range svc.Methods {
+		mname := commandName(m.Name)
+		if used[mname] || !varflag.ValidFlagName(mname) {
+			continue
+		}
+		used[mname] = true
+		cmd.WithSubCommands(methodCommand(mname, m, target))
+	}
+	return cmd
+}
+
+func methodCommand(name string, m *Method, target string) *command.Command {
+	cmd := command.New(command.Config{
+		Name:             settings.String(name),
+		Description:      settings.String("Call " + m.Path()),
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.AddInfo(fmt.Sprintf("Request is %s and response is %s.", m.Input.Name, m.Output.Name))
+	if m.ClientStreaming {
+		cmd.AddInfo("Method is client streaming, when --data is JSON array each element is sent as request.")
+	}
+	if m.ServerStreaming {
+		cmd.AddInfo("Method is server streaming, responses are printed as JSON array once the call completes.")
+	}
+
+	flags := requestFlags(m.Input, "", nil, 0)
+	for _, f := range flags {
+		cmd.WithFlags(f.create())
+	}
+	cmd.WithFlags(
+		varflag.StringFunc("data", "", "request as JSON, @file reads it from file and - from stdin"),
+	)
+
+	cmd.DoWithResult(func(sess *session.Context, args action.Args) (*action.Result, error) {
+		reqs, err := requests(m, flags, args)
+		if err != nil {
+			return nil, err
+		}
+		dial, err := dialFromArgs(sess, args, target)
+		if err != nil {
+			return nil, err
+		}
+		client, err := NewClient(dial)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(sess, timeout(sess))
+		defer cancel()
+
+		msgs, ierr := client.Invoke(ctx, m.Path(), reqs...)
+		resps := make([]any, 0, len(msgs))
+		for _, msg := range msgs {
+			resp, err := m.Output.unmarshalValue(msg)
+			if err != nil {
+				return nil, err
+			}
+			resps = append(resps, resp)
+		}
+		var value any = resps
+		if !m.ServerStreaming && len(resps) == 1 {
+			value = resps[0]
+		}
+		if ierr != nil && len(resps) == 0 {
+			return nil, ierr
+		}
+		if args.Flag("output").String() != "json" {
+			out, err := json.MarshalIndent(value, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", Error, err.Error())
+			}
+			fmt.Println(string(out))
+		}
+		return action.NewResult(value), ierr
+	})
+	return cmd
+}
+
+// requestFlag is flag setting field of request message.
+type requestFlag struct {
+	name  string
+	path  []string
+	field *Field
+}
+
+func (rf requestFlag) create() varflag.FlagCreateFunc {
+	f := rf.field
+	usage := fmt.Sprintf("%s (%s)", f.Name, f.kind())
+	if f.Repeated {
+		usage += ", can be repeated"
+	}
+	switch {
+	case f.Type == typeBool && !f.Repeated:
+		return varflag.BoolFunc(rf.name, false, usage)
+	case f.Type == typeEnum && len(f.Enum.Values) > 0:
+		opts := make([]string, 0, len(f.Enum.Values))
+		for _, v := range f.Enum.Values {
+			opts = append(opts, v.Name)
+		}
+		return varflag.OptionFunc(rf.name, nil, opts, usage)
+	}
+	return varflag.StringFunc(rf.name, "", usage)
+}
+
+// value returns JSON value of flag f.
+func (rf requestFlag) value(f varflag.Flag) (any, error) {
+	if rf.field.Type == typeBool && !rf.field.Repeated {
+		return f.Var().Bool(), nil
+	}
+	if !rf.field.Repeated {
+		return f.String(), nil
+	}
+	// flag input contains flag names along with values
+	var values []string
+	for _, in := range f.Input() {
+		if !strings.HasPrefix(in, "-") {
+			values = append(values, in)
+		}
+	}
+	if !rf.field.IsMap() {
+		list := make([]any, 0, len(values))
+		for _, v := range values {
+			list = append(list, v)
+		}
+		return list, nil
+	}
+	entries := make(map[string]any, len(values))
+	for _, v := range values {
+		key, val, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: --%s value %q must be KEY=VALUE", Error, rf.name, v)
+		}
+		entries[key] = val
+	}
+	return entries, nil
+}
+
+// reservedFlags are names of flags which fields can not use.
+var reservedFlags = func() map[string]bool {
+	names := map[string]bool{
+		"target": true, "plaintext": true, "insecure": true, "header": true, "data": true,
+	}
+	for _, fn := range []varflag.FlagCreateFunc{
+		cli.FlagVersion, cli.FlagHelp, cli.FlagX, cli.FlagSystemDebug,
+		cli.FlagDebug, cli.FlagVerbose, cli.FlagPrintStartup, cli.FlagOutput,
+		cli.FlagWaitLock, cli.FlagSet, cli.FlagNotify,
+	} {
+		if f, err := fn(); err == nil {
+			names[f.Name()] = true
+		}
+	}
+	return names
+}()
+
+// requestFlags returns flags of fields of msg. Fields of nested
+// messages are prefixed with name of their field, fields which can
+// not be expressed as flag are set with --data only.
+func requestFlags(msg *Message, prefix string, path []string, depth int) []requestFlag {
+	var flags []requestFlag
+	for _, f := range msg.Fields {
+		name := prefix + commandName(f.Name)
+		fpath := append(slices.Clone(path), f.JSONName)
+		switch {
+		case f.IsMap():
+			value := f.Message.fieldByNumber(2)
+			if value == nil || value.Type == typeMessage {
+				continue
+			}
+		case f.Type == typeGroup:
+			continue
+		case f.Type == typeMessage && !f.Message.jsonScalar():
+			if !f.Repeated && depth < maxFieldDepth {
+				flags = append(flags, requestFlags(f.Message, name+"-", fpath, depth+1)...)
+			}
+			continue
+		}
+		if reservedFlags[name] || !varflag.ValidFlagName(name) {
+			continue
+		}
+		flags = append(flags, requestFlag{name: name, path: fpath, field: f})
+	}
+	return flags
+}
+
+// requests returns encoded requests from --data and field flags.
+func requests(m *Method, flags []requestFlag, args action.Args) ([][]byte, error) {
+	var values []map[string]any
+	if data := args.Flag("data"); data.Present() {
+		raw, err := readData(data.String())
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("%w: invalid --data: %s", Error, err.Error())
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			values = append(values, v)
+		case []any:
+			if !m.ClientStreaming {
+				return nil, fmt.Errorf("%w: --data must be JSON object", Error)
+			}
+			for _, item := range v {
+				obj, ok := item.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("%w: --data must be array of JSON objects", Error)
+				}
+				values = append(values, obj)
+			}
+		default:
+			return nil, fmt.Errorf("%w: --data must be JSON object", Error)
+		}
+	}
+	if len(values) == 0 {
+		values = append(values, make(map[string]any))
+	}
+
+	for _, rf := range flags {
+		f := args.Flag(rf.name)
+		if !f.Present() {
+			continue
+		}
+		v, err := rf.value(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range values {
+			setPath(obj, rf.path, v)
+		}
+	}
+
+	reqs := make([][]byte, 0, len(values))
+	for _, obj := range values {
+		req, err := m.Input.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// readData returns value of --data flag, @file reads file and - stdin.
+func readData(data string) ([]byte, error) {
+	var (
+		raw []byte
+		err error
+	)
+	switch {
+	case data == "-":
+		raw, err = io.ReadAll(os.Stdin)
+	case strings.HasPrefix(data, "@"):
+		raw, err = os.ReadFile(data[1:])
+	default:
+		return []byte(data), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return raw, nil
+}
+
+// setPath sets value at path of nested objects of obj.
+func setPath(obj map[string]any, path []string, v any) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := obj[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			obj[key] = next
+		}
+		obj = next
+	}
+	obj[path[len(path)-1]] = v
+}
+
+// dialFromArgs returns dial configuration from settings overridden
+// with flags.
+func dialFromArgs(sess *session.Context, args action.Args, target string) (Dial, error) {
+	dial := DialFromSettings(sess, target)
+	if t := args.Flag("target").String(); t != "" {
+		dial.Target = t
+	}
+	if args.Flag("plaintext").Present() {
+		dial.Plaintext = args.Flag("plaintext").Var().Bool()
+	}
+	if args.Flag("insecure").Present() {
+		dial.Insecure = args.Flag("insecure").Var().Bool()
+	}
+	for _, in := range args.Flag("header").Input() {
+		if strings.HasPrefix(in, "-") {
+			continue
+		}
+		key, val, ok := strings.Cut(in, "=")
+		if !ok || key == "" {
+			return dial, fmt.Errorf("%w: header %q must be KEY=VALUE", Error, in)
+		}
+		if dial.Metadata == nil {
+			dial.Metadata = make(map[string]string)
+		}
+		dial.Metadata[strings.ToLower(key)] = val
+	}
+	return dial, nil
+}
+
+// commandName returns kebab-case command or flag name of protocol
+// buffers name e.g. GetUser, get_user and acme.v1.Users.
+func commandName(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '.':
+			b.WriteByte('-')
+		case r >= 'A' && r <= 'Z':
+			if i > 0 {
+				prev := runes[i-1]
+				lower := prev >= 'a' && prev <= 'z' || prev >= '0' && prev <= '9'
+				next := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+				if lower || (prev >= 'A' && prev <= 'Z' && next) {
+					b.WriteByte('-')
+				}
+			}
+			b.WriteRune(r + 'a' - 'A')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
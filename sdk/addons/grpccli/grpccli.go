@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package grpccli provides addon which generates commands calling
+// methods of gRPC services, so that internal services get CLI frontend
+// without writing a command per method. Commands are generated from
+// descriptor set, either compiled with protoc --descriptor_set_out
+// --include_imports or fetched from the server with server reflection
+// by grpc reflect command. Each method is command with flags derived
+// from fields of its request message, response is printed as JSON.
+//
+// Client is implemented with standard library, it connects over TLS
+// and, when application is built with Go 1.24 or newer, over plaintext
+// HTTP/2. Compressed messages are not supported.
+package grpccli
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/addon"
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+var Error = errors.New("grpc")
+
+type Settings struct {
+	Target    settings.String   `key:"target,save" default:"" desc:"Address of gRPC server as host:port"`
+	Plaintext settings.Bool     `key:"plaintext,save" default:"false" desc:"Connect without TLS"`
+	Insecure  settings.Bool     `key:"insecure,save" default:"false" desc:"Skip verification of server certificate"`
+	Timeout   settings.Duration `key:"timeout,save" default:"30s" desc:"Timeout of single call"`
+}
+
+func (s Settings) Blueprint() (*settings.Blueprint, error) {
+	return settings.New(s)
+}
+
+// Config configures grpc addon.
+type Config struct {
+	// Name is name of the command, grpc when empty.
+	Name string
+	// Category of the command in help.
+	Category string
+	// Description of the command in help.
+	Description string
+	// DescriptorSet is path of descriptor set file commands are
+	// generated from, reflect command writes it.
+	DescriptorSet string
+	// Descriptors is encoded descriptor set e.g. embedded with
+	// go:embed, it is used instead of DescriptorSet file when set.
+	Descriptors []byte
+	// Target is address of the server used when grpc.target setting
+	// is empty.
+	Target string
+	// Services limits commands to services with given fully qualified
+	// names, commands of all services are generated when empty.
+	Services []string
+}
+
+// Addon returns grpc addon providing command which calls methods of
+// services described by descriptor set. Settings are available under
+// grpc.* keys.
+func Addon(cnf Config) *addon.Addon {
+	var perms addon.Permissions
+	if cnf.Target != "" {
+		perms.Hosts = append(perms.Hosts, cnf.Target)
+	}
+	if cnf.DescriptorSet != "" {
+		perms.Paths = append(perms.Paths, cnf.DescriptorSet)
+	}
+	a := addon.New(addon.Config{
+		Name:        "gRPC",
+		Settings:    Settings{},
+		Permissions: perms,
+	})
+	a.ProvideCommands(Command(cnf))
+	return a
+}
+
+// DialFromSettings returns dial configuration from grpc.* settings,
+// target falls back to target.
+func DialFromSettings(sess *session.Context, target string) Dial {
+	dial := Dial{
+		Target:    sess.Get("grpc.target").String(),
+		Plaintext: sess.Get("grpc.plaintext").Bool(),
+		Insecure:  sess.Get("grpc.insecure").Bool(),
+	}
+	if dial.Target == "" {
+		dial.Target = target
+	}
+	return dial
+}
+
+// timeout returns grpc.timeout setting, 30 seconds when it is not set.
+func timeout(sess *session.Context) time.Duration {
+	if d := sess.Get("grpc.timeout").Duration(); d > 0 {
+		return d
+	}
+	return 30 * time.Second
+}
+
+// loadDescriptors returns descriptors configured by cnf, nil when
+// descriptor set file does not exist yet.
+func loadDescriptors(cnf Config) (*Descriptors, error) {
+	if len(cnf.Descriptors) > 0 {
+		return ParseDescriptors(cnf.Descriptors)
+	}
+	if cnf.DescriptorSet == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(cnf.DescriptorSet); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return LoadDescriptors(cnf.DescriptorSet)
+}
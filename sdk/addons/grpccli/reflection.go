@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package grpccli
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// reflectionPaths are paths of server reflection method, v1alpha is
+// used by servers which do not serve v1.
+var reflectionPaths = []string{
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+}
+
+// codeUnimplemented is status returned for unknown methods.
+const codeUnimplemented = 12
+
+// Reflect fetches descriptors of all services of server with server
+// reflection.
+func Reflect(ctx context.Context, c *Client) (*Descriptors, error) {
+	var (
+		resps [][]byte
+		err   error
+		path  string
+	)
+	// list_services
+	for _, path = range reflectionPaths {
+		resps, err = c.Invoke(ctx, path, appendBytes(nil, 7, []byte("*")))
+		var serr *StatusError
+		if !errors.As(err, &serr) || serr.Code != codeUnimplemented {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	var symbols []string
+	for _, resp := range resps {
+		list, err := reflectionResponse(resp, 6)
+		if err != nil {
+			return nil, err
+		}
+		for _, lsr := range list {
+			services, err := parseFields(lsr)
+			if err != nil {
+				return nil, err
+			}
+			for _, svc := range services {
+				fields, err := parseFields(svc.Bytes)
+				if err != nil {
+					return nil, err
+				}
+				for _, f := range fields {
+					if f.Num == 1 && !strings.HasPrefix(string(f.Bytes), "grpc.reflection.") {
+						symbols = append(symbols, string(f.Bytes))
+					}
+				}
+			}
+		}
+	}
+
+	d := newDescriptors()
+	// file_containing_symbol
+	if err := d.reflect(ctx, c, path, 4, symbols); err != nil {
+		return nil, err
+	}
+	// file_by_filename of imports not sent with files
+	for i := 0; i < 16; i++ {
+		deps := d.dependencies()
+		if len(deps) == 0 {
+			break
+		}
+		if err := d.reflect(ctx, c, path, 3, deps); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.resolve(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// reflect sends reflection request num for each of names and adds
+// returned files.
+func (d *Descriptors) reflect(ctx context.Context, c *Client, path string, num int32, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	reqs := make([][]byte, 0, len(names))
+	for _, name := range names {
+		reqs = append(reqs, appendBytes(nil, num, []byte(name)))
+	}
+	resps, err := c.Invoke(ctx, path, reqs...)
+	if err != nil {
+		return err
+	}
+	for _, resp := range resps {
+		files, err := reflectionResponse(resp, 4)
+		if err != nil {
+			return err
+		}
+		for _, fdr := range files {
+			fields, err := parseFields(fdr)
+			if err != nil {
+				return err
+			}
+			for _, f := range fields {
+				if f.Num != 1 {
+					continue
+				}
+				if err := d.add(f.Bytes); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// reflectionResponse returns field num of ServerReflectionResponse
+// message, error response is returned as error.
+func reflectionResponse(b []byte, num int32) ([][]byte, error) {
+	fields, err := parseFields(b)
+	if err != nil {
+		return nil, err
+	}
+	var values [][]byte
+	for _, f := range fields {
+		switch f.Num {
+		case num:
+			values = append(values, f.Bytes)
+		case 7:
+			serr := &StatusError{}
+			efields, err := parseFields(f.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			for _, ef := range efields {
+				switch ef.Num {
+				case 1:
+					serr.Code = int(int32(ef.Value))
+				case 2:
+					serr.Message = string(ef.Bytes)
+				}
+			}
+			return nil, serr
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package grpccli
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxMessageSize limits size of received message.
+const maxMessageSize = 16 << 20
+
+// codes are names of gRPC status codes.
+var codes = []string{
+	"OK", "CANCELED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED",
+	"NOT_FOUND", "ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION", "ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED",
+	"INTERNAL", "UNAVAILABLE", "DATA_LOSS", "UNAUTHENTICATED",
+}
+
+// StatusError is error status returned by gRPC server.
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	code := strconv.Itoa(e.Code)
+	if e.Code >= 0 && e.Code < len(codes) {
+		code = codes[e.Code]
+	}
+	if e.Message == "" {
+		return fmt.Sprintf("%s: %s", Error.Error(), code)
+	}
+	return fmt.Sprintf("%s: %s: %s", Error.Error(), code, e.Message)
+}
+
+// Dial configures connection to gRPC server.
+type Dial struct {
+	// Target is host:port of the server.
+	Target string
+	// Plaintext connects without TLS, it requires Go 1.24 or newer.
+	Plaintext bool
+	// Insecure skips verification of server certificate.
+	Insecure bool
+	// Metadata is sent with every call.
+	Metadata map[string]string
+}
+
+// Client calls methods of gRPC server over HTTP/2.
+type Client struct {
+	base     string
+	http     *http.Client
+	metadata map[string]string
+}
+
+// NewClient returns client of server configured by dial.
+func NewClient(dial Dial) (*Client, error) {
+	if dial.Target == "" {
+		return nil, fmt.Errorf("%w: target is not set", Error)
+	}
+	c := &Client{metadata: dial.Metadata}
+	var rt http.RoundTripper
+	if dial.Plaintext {
+		t, err := plaintextTransport()
+		if err != nil {
+			return nil, err
+		}
+		rt, c.base = t, "http://"+dial.Target
+	} else {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.ForceAttemptHTTP2 = true
+		t.TLSClientConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: dial.Insecure,
+		}
+		rt, c.base = t, "https://"+dial.Target
+	}
+	if _, err := url.Parse(c.base); err != nil {
+		return nil, fmt.Errorf("%w: invalid target %q", Error, dial.Target)
+	}
+	c.http = &http.Client{Transport: rt}
+	return c, nil
+}
+
+// Invoke calls method at path e.g. /acme.v1.Users/Get with encoded
+// request messages and returns encoded response messages. Unary and
+// server streaming methods receive single request, client streaming
+// methods receive all requests before server responds.
+func (c *Client) Invoke(ctx context.Context, path string, reqs ...[]byte) ([][]byte, error) {
+	var body bytes.Buffer
+	for _, req := range reqs {
+		var prefix [5]byte
+		binary.BigEndian.PutUint32(prefix[1:], uint32(len(req)))
+		body.Write(prefix[:])
+		body.Write(req)
+	}
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base+path, &body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	hreq.Header.Set("Content-Type", "application/grpc")
+	hreq.Header.Set("TE", "trailers")
+	hreq.Header.Set("User-Agent", "happy-grpccli")
+	if deadline, ok := ctx.Deadline(); ok {
+		if ms := time.Until(deadline).Milliseconds(); ms > 0 {
+			hreq.Header.Set("Grpc-Timeout", strconv.FormatInt(ms, 10)+"m")
+		}
+	}
+	for k, v := range c.metadata {
+		hreq.Header.Set(k, v)
+	}
+
+	resp, err := c.http.Do(hreq)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		return nil, fmt.Errorf("%w: server at %s does not speak HTTP/2", Error, c.base)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: unexpected HTTP status %s", Error, resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/grpc") {
+		return nil, fmt.Errorf("%w: unexpected content type %q", Error, ct)
+	}
+
+	var msgs [][]byte
+	for {
+		var prefix [5]byte
+		if _, err := io.ReadFull(resp.Body, prefix[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		if prefix[0] != 0 {
+			return nil, fmt.Errorf("%w: compressed messages are not supported", Error)
+		}
+		size := binary.BigEndian.Uint32(prefix[1:])
+		if size > maxMessageSize {
+			return nil, fmt.Errorf("%w: message of %d bytes exceeds limit", Error, size)
+		}
+		msg := make([]byte, size)
+		if _, err := io.ReadFull(resp.Body, msg); err != nil {
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		msgs = append(msgs, msg)
+	}
+
+	// trailers-only responses carry status in headers.
+	status := resp.Trailer.Get("Grpc-Status")
+	message := resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status, message = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing grpc-status", Error)
+	}
+	if code != 0 {
+		if msg, err := url.PathUnescape(message); err == nil {
+			message = msg
+		}
+		return msgs, &StatusError{Code: code, Message: message}
+	}
+	return msgs, nil
+}
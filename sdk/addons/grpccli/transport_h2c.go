@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+//go:build go1.24
+
+package grpccli
+
+import "net/http"
+
+// plaintextTransport returns transport speaking HTTP/2 without TLS.
+func plaintextTransport() (http.RoundTripper, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Protocols = new(http.Protocols)
+	t.Protocols.SetUnencryptedHTTP2(true)
+	return t, nil
+}
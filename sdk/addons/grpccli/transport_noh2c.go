@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+//go:build !go1.24
+
+package grpccli
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// plaintextTransport fails, net/http speaks HTTP/2 without TLS since
+// Go 1.24.
+func plaintextTransport() (http.RoundTripper, error) {
+	return nil, fmt.Errorf("%w: plaintext connections require application built with Go 1.24 or newer", Error)
+}
@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package grpccli
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Protocol buffers wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireStart   = 3
+	wireEnd     = 4
+	wireFixed32 = 5
+)
+
+var errTruncated = fmt.Errorf("%w: truncated message", Error)
+
+// field is single field of encoded message. Value holds varint and
+// fixed values, Bytes holds length delimited value.
+type field struct {
+	Num   int32
+	Type  int
+	Value uint64
+	Bytes []byte
+}
+
+// parseFields splits encoded message into fields, groups are skipped.
+func parseFields(b []byte) ([]field, error) {
+	var fields []field
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		b = b[n:]
+		f := field{Num: int32(tag >> 3), Type: int(tag & 7)}
+		if f.Num <= 0 {
+			return nil, fmt.Errorf("%w: invalid field number", Error)
+		}
+		switch f.Type {
+		case wireVarint:
+			if f.Value, n = binary.Uvarint(b); n <= 0 {
+				return nil, errTruncated
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return nil, errTruncated
+			}
+			f.Value, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return nil, errTruncated
+			}
+			f.Value, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return nil, errTruncated
+			}
+			f.Bytes, b = b[n:n+int(l)], b[n+int(l):]
+		case wireStart:
+			rest, err := skipGroup(b, f.Num)
+			if err != nil {
+				return nil, err
+			}
+			b = rest
+			continue
+		default:
+			return nil, fmt.Errorf("%w: invalid wire type %d", Error, f.Type)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// skipGroup skips fields of deprecated group num.
+func skipGroup(b []byte, num int32) ([]byte, error) {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		b = b[n:]
+		switch int(tag & 7) {
+		case wireVarint:
+			if _, n = binary.Uvarint(b); n <= 0 {
+				return nil, errTruncated
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return nil, errTruncated
+			}
+			b = b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return nil, errTruncated
+			}
+			b = b[4:]
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return nil, errTruncated
+			}
+			b = b[n+int(l):]
+		case wireStart:
+			rest, err := skipGroup(b, int32(tag>>3))
+			if err != nil {
+				return nil, err
+			}
+			b = rest
+		case wireEnd:
+			if int32(tag>>3) != num {
+				return nil, fmt.Errorf("%w: mismatched group end", Error)
+			}
+			return b, nil
+		}
+	}
+	return nil, errTruncated
+}
+
+// packed returns values of packed repeated scalar field.
+func packed(b []byte, wireType int) ([]uint64, error) {
+	var values []uint64
+	for len(b) > 0 {
+		switch wireType {
+		case wireVarint:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return nil, errTruncated
+			}
+			values, b = append(values, v), b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return nil, errTruncated
+			}
+			values, b = append(values, binary.LittleEndian.Uint64(b)), b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return nil, errTruncated
+			}
+			values, b = append(values, uint64(binary.LittleEndian.Uint32(b))), b[4:]
+		}
+	}
+	return values, nil
+}
+
+func appendTag(b []byte, num int32, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(num)<<3|uint64(wireType))
+}
+
+func appendBytes(b []byte, num int32, v []byte) []byte {
+	b = binary.AppendUvarint(appendTag(b, num, wireBytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+func zigzag(v int64) uint64   { return uint64(v<<1) ^ uint64(v>>63) }
+func unzigzag(v uint64) int64 { return int64(v>>1) ^ -int64(v&1) }
+
+func float32bits(f float64) uint32 { return math.Float32bits(float32(f)) }
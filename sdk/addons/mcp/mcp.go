@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package mcp provides addon which exposes application commands as
+// Model Context Protocol tools over stdio, so that AI assistants can
+// drive the application. Only commands configured with
+// command.Config.ExposeMCP are exposed, input schema of the tool is
+// generated from argument limits and flags of the command. Help topics
+// are exposed as resources.
+package mcp
+
+import (
+	"errors"
+	"os"
+
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/addon"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+)
+
+var Error = errors.New("mcp")
+
+// CommandName is name of the command serving MCP over stdio.
+const CommandName = "mcp"
+
+// Addon returns MCP addon providing mcp command.
+func Addon() *addon.Addon {
+	a := addon.New(addon.Config{
+		Name: "MCP",
+	})
+	a.ProvideCommands(Command())
+	return a
+}
+
+// Command returns mcp command which serves MCP on stdin and stdout
+// until stdin is closed.
+func Command() *command.Command {
+	cmd := command.New(command.Config{
+		Name:        CommandName,
+		Category:    "Automation",
+		Description: "Serve commands as MCP tools over stdio",
+	})
+
+	cmd.AddInfo("Configure the application as stdio MCP server of your AI assistant with this command. Only commands with ExposeMCP enabled are exposed as tools. Stdout carries protocol messages only, logs are written to stderr.")
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		// output printed by commands would corrupt protocol messages.
+		out := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = out }()
+
+		return NewServer(sess).Serve(sess, os.Stdin, out)
+	})
+	return cmd
+}
@@ -0,0 +1,435 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/logging"
+	"golang.org/x/text/language"
+)
+
+// maxMessageSize limits size of single message.
+const maxMessageSize = 4 << 20
+
+// ProtocolVersions are MCP protocol versions supported by server,
+// latest first.
+var ProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC 2.0 error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// Content is content of tool result or resource.
+type Content struct {
+	Type     string `json:"type,omitempty"`
+	URI      string `json:"uri,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// Resource is help topic exposed as resource.
+type Resource struct {
+	URI      string `json:"uri"`
+	Name     string `json:"name"`
+	Title    string `json:"title,omitempty"`
+	MimeType string `json:"mimeType"`
+}
+
+// ToolResult is result of tools/call, command output is returned as
+// text content and failed commands set IsError.
+type ToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError"`
+}
+
+// Server serves MCP over newline delimited JSON-RPC. Requests are
+// handled concurrently and tool calls run one at a time in scoped
+// session which logger writes to tool result.
+type Server struct {
+	sess *session.Context
+	// sem serializes tool calls.
+	sem chan struct{}
+
+	wmu sync.Mutex
+	enc *json.Encoder
+
+	mu      sync.Mutex
+	pending map[string]context.CancelFunc
+}
+
+// NewServer returns server exposing commands of sess.
+func NewServer(sess *session.Context) *Server {
+	return &Server{
+		sess:    sess,
+		sem:     make(chan struct{}, 1),
+		pending: make(map[string]context.CancelFunc),
+	}
+}
+
+// Serve reads requests from r and writes responses to w until r is
+// closed or ctx is done. Pending tool calls are cancelled on return.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.enc = json.NewEncoder(w)
+
+	lines := make(chan []byte)
+	errc := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+		for scanner.Scan() {
+			select {
+			case lines <- bytes.Clone(scanner.Bytes()):
+			case <-ctx.Done():
+				return
+			}
+		}
+		errc <- scanner.Err()
+	}()
+
+	internal.Log(s.sess.Log(), "serving mcp over stdio")
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errc:
+			if err != nil {
+				return fmt.Errorf("%w: %s", Error, err.Error())
+			}
+			return nil
+		case line := <-lines:
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.handle(ctx, line)
+			}()
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, line []byte) {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		s.write(response{ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: err.Error()}})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if len(req.ID) > 0 {
+			s.write(response{ID: req.ID, Error: &rpcError{Code: codeInvalidRequest, Message: "invalid request"}})
+		}
+		return
+	}
+	// notifications get no response
+	if len(req.ID) == 0 {
+		if req.Method == "notifications/cancelled" {
+			s.cancelled(req.Params)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.mu.Lock()
+	s.pending[string(req.ID)] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, string(req.ID))
+		s.mu.Unlock()
+	}()
+
+	result, err := s.call(ctx, req)
+	if ctx.Err() != nil {
+		// cancelled requests get no response.
+		return
+	}
+	resp := response{ID: req.ID, Result: result}
+	if err != nil {
+		rerr, ok := err.(*rpcError)
+		if !ok {
+			rerr = &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		resp.Result, resp.Error = nil, rerr
+	}
+	s.write(resp)
+}
+
+func (s *Server) call(ctx context.Context, req request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		tools := Tools(s.sess)
+		if tools == nil {
+			tools = []Tool{}
+		}
+		return map[string]any{"tools": tools}, nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params)
+	case "resources/list":
+		return map[string]any{"resources": s.resources()}, nil
+	case "resources/read":
+		return s.readResource(req.Params)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
+
+func (s *Server) initialize(raw json.RawMessage) (any, error) {
+	var params struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	version := ProtocolVersions[0]
+	if slices.Contains(ProtocolVersions, params.ProtocolVersion) {
+		version = params.ProtocolVersion
+	}
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities": map[string]any{
+			"tools":     map[string]any{},
+			"resources": map[string]any{},
+		},
+		"serverInfo": map[string]any{
+			"name":    s.sess.Get("app.slug").String(),
+			"title":   s.sess.Get("app.name").String(),
+			"version": s.sess.Get("app.version").String(),
+		},
+		"instructions": fmt.Sprintf("Tools run commands of %s, output of the command is returned as text. Help topics are available as resources.", s.sess.Get("app.name").String()),
+	}, nil
+}
+
+func (s *Server) callTool(ctx context.Context, raw json.RawMessage) (any, error) {
+	var params struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(Tools(s.sess), func(t Tool) bool { return t.Name == params.Name })
+	if i < 0 {
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", params.Name)}
+	}
+	tool := Tools(s.sess)[i]
+	args, err := tool.commandArgs(params.Arguments)
+	if err != nil {
+		return ToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+
+	select {
+	case s.sem <- struct{}{}:
+		defer func() { <-s.sem }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	out := &textOutput{}
+	res := ToolResult{}
+	if err := s.invoke(ctx, out, tool.Command, args); err != nil {
+		res.IsError = true
+		out.line("error: " + err.Error())
+	}
+	res.Content = []Content{{Type: "text", Text: out.String()}}
+	return res, nil
+}
+
+// invoke runs command in scoped session writing output to out, session
+// is destroyed when call is cancelled.
+func (s *Server) invoke(ctx context.Context, out *textOutput, path string, args []string) error {
+	internal.Log(s.sess.Log(), "mcp tool call", slog.String("command", path))
+	logger := logging.NewFromHandler(&textHandler{out: out}, s.sess.Log().Level())
+	sess, err := session.Scoped(s.sess, "mcp", logger)
+	if err != nil {
+		return err
+	}
+	defer sess.Destroy(nil)
+	stop := context.AfterFunc(ctx, func() { sess.Destroy(ctx.Err()) })
+	defer stop()
+	return sess.InvokeCommand(path, args...)
+}
+
+func (s *Server) resources() []Resource {
+	list := make([]Resource, 0)
+	for _, topic := range s.sess.Docs() {
+		list = append(list, Resource{
+			URI:      "help://" + topic.Name(),
+			Name:     topic.Name(),
+			Title:    topic.Title(),
+			MimeType: "text/markdown",
+		})
+	}
+	return list
+}
+
+func (s *Server) readResource(raw json.RawMessage) (any, error) {
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	name, ok := strings.CutPrefix(params.URI, "help://")
+	if ok {
+		for _, topic := range s.sess.Docs() {
+			if topic.Name() == name {
+				return map[string]any{"contents": []Content{{
+					URI:      params.URI,
+					MimeType: "text/markdown",
+					Text:     topic.Content(language.English),
+				}}}, nil
+			}
+		}
+	}
+	return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown resource %q", params.URI)}
+}
+
+// cancelled cancels request named by notifications/cancelled.
+func (s *Server) cancelled(raw json.RawMessage) {
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return
+	}
+	s.mu.Lock()
+	cancel, ok := s.pending[string(params.RequestID)]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func (s *Server) write(resp response) {
+	resp.JSONRPC = "2.0"
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	if err := s.enc.Encode(resp); err != nil {
+		s.sess.Log().Error("mcp write failed", slog.String("err", err.Error()))
+	}
+}
+
+func decodeParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// textOutput collects output of tool call.
+type textOutput struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (o *textOutput) line(s string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.buf.WriteString(strings.TrimRight(s, "\n"))
+	o.buf.WriteByte('\n')
+}
+
+func (o *textOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+// textHandler writes log records as plain text lines, records printed
+// with Println are written as is.
+type textHandler struct {
+	out   *textOutput
+	attrs []slog.Attr
+	group string
+}
+
+func (h *textHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	if logging.Level(r.Level) == logging.LevelAlways {
+		h.out.line(r.Message)
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString(logging.Level(r.Level).String())
+	b.WriteByte(' ')
+	b.WriteString(r.Message)
+	write := func(a slog.Attr) bool {
+		if a.Equal(slog.Attr{}) {
+			return true
+		}
+		fmt.Fprintf(&b, " %s%s=%s", h.group, a.Key, a.Value.String())
+		return true
+	}
+	for _, a := range h.attrs {
+		write(a)
+	}
+	r.Attrs(write)
+	h.out.line(b.String())
+	return nil
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &textHandler{out: h.out, attrs: append(slices.Clip(h.attrs), attrs...), group: h.group}
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	return &textHandler{out: h.out, attrs: h.attrs, group: h.group + name + "."}
+}
@@ -0,0 +1,245 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/help"
+)
+
+// argsProperty is input property holding positional arguments.
+const argsProperty = "args"
+
+var invalidToolChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// Tool is command exposed as MCP tool.
+type Tool struct {
+	Name        string  `json:"name"`
+	Title       string  `json:"title,omitempty"`
+	Description string  `json:"description,omitempty"`
+	InputSchema *Schema `json:"inputSchema"`
+
+	// Command is path of the command without name of root command.
+	Command string `json:"-"`
+	// flags are types of flags command accepts by name.
+	flags   map[string]string
+	minArgs uint
+	maxArgs uint
+}
+
+// Schema is JSON Schema of tool input.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	MinItems             *uint              `json:"minItems,omitempty"`
+	MaxItems             *uint              `json:"maxItems,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+}
+
+// Tools returns tools of commands exposed to MCP clients sorted by
+// name. Global flags of root command are not exposed.
+func Tools(sess *session.Context) []Tool {
+	index := sess.HelpIndex()
+	// flags by command path
+	flags := make(map[string][]help.Entry)
+	for _, e := range index {
+		if e.Kind == help.KindFlag {
+			flags[e.Command] = append(flags[e.Command], e)
+		}
+	}
+
+	var tools []Tool
+	for _, e := range index {
+		if e.Kind != help.KindCommand || !e.ExposeMCP {
+			continue
+		}
+		// index paths start with name of root command.
+		root, path, ok := strings.Cut(e.Command, " ")
+		if !ok || path == CommandName {
+			continue
+		}
+		tool := newTool(e, path)
+		// flags of parent commands are shared with subcommands.
+		words := strings.Fields(path)
+		for i := range words {
+			for _, f := range flags[root+" "+strings.Join(words[:i+1], " ")] {
+				tool.addFlag(f)
+			}
+		}
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
+
+func newTool(e help.Entry, path string) Tool {
+	closed := false
+	tool := Tool{
+		Name:    invalidToolChars.ReplaceAllString(strings.ReplaceAll(path, " ", "_"), "_"),
+		Title:   path,
+		Command: path,
+		flags:   make(map[string]string),
+		minArgs: e.MinArgs,
+		maxArgs: e.MaxArgs,
+		InputSchema: &Schema{
+			Type:                 "object",
+			Properties:           make(map[string]*Schema),
+			AdditionalProperties: &closed,
+		},
+	}
+
+	desc := []string{e.Description}
+	if len(e.Usage) > 0 {
+		desc = append(desc, "Usage: "+strings.Join(e.Usage, "\n"))
+	}
+	if e.Text != "" {
+		desc = append(desc, e.Text)
+	}
+	tool.Description = strings.TrimSpace(strings.Join(desc, "\n\n"))
+
+	if e.MaxArgs > 0 {
+		args := &Schema{
+			Type:        "array",
+			Description: "Positional arguments of the command",
+			Items:       &Schema{Type: "string"},
+			MaxItems:    &tool.maxArgs,
+		}
+		if e.MinArgs > 0 {
+			args.MinItems = &tool.minArgs
+			tool.InputSchema.Required = append(tool.InputSchema.Required, argsProperty)
+		}
+		tool.InputSchema.Properties[argsProperty] = args
+	}
+	return tool
+}
+
+func (t *Tool) addFlag(e help.Entry) {
+	name := strings.TrimLeft(e.Name, "-")
+	if name == argsProperty {
+		return
+	}
+	prop := &Schema{Description: e.Description}
+	switch e.FlagType {
+	case "bool":
+		prop.Type = "boolean"
+		if v, err := strconv.ParseBool(e.Default); err == nil && v {
+			prop.Default = v
+		}
+	case "int", "uint":
+		prop.Type = "integer"
+		if v, err := strconv.ParseInt(e.Default, 10, 64); err == nil && v != 0 {
+			prop.Default = v
+		}
+	case "float":
+		prop.Type = "number"
+		if v, err := strconv.ParseFloat(e.Default, 64); err == nil && v != 0 {
+			prop.Default = v
+		}
+	case "duration":
+		prop.Type = "string"
+		prop.Description += " (duration e.g. 1m30s)"
+		if e.Default != "" && e.Default != "0s" {
+			prop.Default = e.Default
+		}
+	case "option":
+		prop.Type = "string"
+		prop.Enum = e.Options
+		if e.Default != "" {
+			prop.Default = e.Default
+		}
+	default:
+		prop.Type = "string"
+		if e.Default != "" {
+			prop.Default = e.Default
+		}
+	}
+	t.flags[name] = e.FlagType
+	t.InputSchema.Properties[name] = prop
+	if e.Required {
+		t.InputSchema.Required = append(t.InputSchema.Required, name)
+	}
+}
+
+// commandArgs returns command line arguments of tool call, flags are
+// sorted by name and precede positional arguments. Only flags of the
+// command are accepted, so that global flags e.g. --set can not be
+// passed by client.
+func (t Tool) commandArgs(input map[string]any) ([]string, error) {
+	names := make([]string, 0, len(input))
+	for name := range input {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var args, positional []string
+	for _, name := range names {
+		values, ok := input[name].([]any)
+		if !ok {
+			values = []any{input[name]}
+		}
+		if name == argsProperty && t.maxArgs > 0 {
+			for _, v := range values {
+				s, err := argString(v)
+				if err != nil {
+					return nil, fmt.Errorf("%w: argument %s", Error, err.Error())
+				}
+				if strings.HasPrefix(s, "-") {
+					return nil, fmt.Errorf("%w: argument %q must not start with -", Error, s)
+				}
+				positional = append(positional, s)
+			}
+			continue
+		}
+		if _, ok := t.flags[name]; !ok {
+			return nil, fmt.Errorf("%w: unknown argument %q", Error, name)
+		}
+		for _, v := range values {
+			if b, ok := v.(bool); ok {
+				if b {
+					args = append(args, "--"+name)
+				} else {
+					args = append(args, "--"+name+"=false")
+				}
+				continue
+			}
+			s, err := argString(v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: flag %q %s", Error, name, err.Error())
+			}
+			args = append(args, "--"+name+"="+s)
+		}
+	}
+	if n := uint(len(positional)); n < t.minArgs || n > t.maxArgs {
+		return nil, fmt.Errorf("%w: %s expects %d to %d arguments, got %d", Error, t.Command, t.minArgs, t.maxArgs, n)
+	}
+	return append(args, positional...), nil
+}
+
+func argString(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("has unsupported value %v", v)
+	}
+}
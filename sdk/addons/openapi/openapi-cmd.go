@@ -0,0 +1,730 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package openapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/auth"
+	"github.com/happy-sdk/happy/sdk/cli"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+)
+
+const (
+	formType = "application/x-www-form-urlencoded"
+	// maxPropertyDepth limits nesting of body properties exposed as flags.
+	maxPropertyDepth = 2
+	// maxResponseSize limits size of read response body.
+	maxResponseSize = 32 << 20
+)
+
+// Command returns command with subcommand per tag of operations of
+// document configured by cnf, which has subcommand per operation.
+// Operations without tags are subcommands of the command itself.
+func Command(cnf Config) *command.Command {
+	name := cnf.name()
+	spec, err := loadSpec(cnf)
+	desc := cnf.Description
+	if desc == "" && spec != nil && spec.Title != "" {
+		desc = "Call operations of " + spec.Title
+	}
+	if desc == "" {
+		desc = "Call operations of HTTP API"
+	}
+	cmd := command.New(command.Config{
+		Name:        settings.String(name),
+		Category:    settings.String(cnf.Category),
+		Description: settings.String(desc),
+	})
+
+	cmd.AddInfo("Each operation is command taking its parameters and properties of request body as flags, properties of nested objects are joined with dash e.g. --address-city. Flags of array values can be repeated. Whole request body can be given with --data, flags override its properties. Base URL is configured with openapi.base_url setting and defaults to first server of the document.")
+
+	cmd.WithFlags(
+		varflag.StringFunc("base-url", "", "base URL of API overriding openapi.base_url"),
+		varflag.StringFunc("header", "", "header sent with request as KEY=VALUE, can be repeated"),
+	)
+
+	used := map[string]bool{}
+	if cnf.Auth != nil {
+		cmd.WithSubCommands(auth.LoginCommand(cnf.Auth), auth.LogoutCommand(cnf.Auth))
+		used["login"], used["logout"] = true, true
+	} else {
+		cmd.WithSubCommands(tokenCommand(cnf))
+		used["token"] = true
+	}
+
+	type entry struct {
+		path string
+		op   *Operation
+	}
+	var entries []entry
+	if spec != nil {
+		groups := make(map[string][]*Operation)
+		var tags []string
+		for _, op := range spec.Operations {
+			if len(op.Tags) == 0 {
+				opname := operationName(op)
+				if used[opname] || !varflag.ValidFlagName(opname) {
+					continue
+				}
+				used[opname] = true
+				entries = append(entries, entry{opname, op})
+				cmd.WithSubCommandFunc(opname, func() *command.Command {
+					return operationCommand(cnf, spec, opname, op)
+				})
+				continue
+			}
+			if _, ok := groups[op.Tags[0]]; !ok {
+				tags = append(tags, op.Tags[0])
+			}
+			groups[op.Tags[0]] = append(groups[op.Tags[0]], op)
+		}
+		for _, tag := range tags {
+			tname := kebab(tag)
+			if used[tname] || !varflag.ValidFlagName(tname) {
+				continue
+			}
+			used[tname] = true
+			names := make(map[string]*Operation)
+			var order []string
+			for _, op := range groups[tag] {
+				opname := operationName(op)
+				if _, ok := names[opname]; ok || !varflag.ValidFlagName(opname) {
+					continue
+				}
+				names[opname] = op
+				order = append(order, opname)
+				entries = append(entries, entry{tname + " " + opname, op})
+			}
+			if len(order) == 0 {
+				continue
+			}
+			cmd.WithSubCommandFunc(tname, func() *command.Command {
+				tcmd := command.New(command.Config{
+					Name:        settings.String(tname),
+					Description: settings.String("Operations tagged " + tag),
+				})
+				for _, opname := range order {
+					tcmd.WithSubCommands(operationCommand(cnf, spec, opname, names[opname]))
+				}
+				return tcmd
+			})
+		}
+	}
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		if err != nil {
+			return err
+		}
+		if spec == nil {
+			return fmt.Errorf("%w: OpenAPI document is not configured or does not exist", Error)
+		}
+		table := textfmt.Table{
+			Title:      "Operations",
+			WithHeader: true,
+		}
+		table.AddRow("Command", "Method", "Path", "Summary")
+		table.AddDivider()
+		for _, e := range entries {
+			table.AddRow(e.path, e.op.Method, e.op.Path, e.op.Summary)
+		}
+		sess.Log().Println(table.String())
+		return nil
+	})
+	return cmd
+}
+
+func tokenCommand(cnf Config) *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "token",
+		Description:      "Store token authorizing requests",
+		MaxArgs:          1,
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.Usage("[token]")
+	cmd.AddInfo("Token is read from standard input when it is not given as argument, so that it does not end up in shell history. Token of http basic scheme is USER:PASSWORD. Token is kept in credentials store as " + cnf.provider() + ".")
+
+	cmd.WithFlags(
+		varflag.BoolFunc("delete", false, "delete stored token"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		store := cnf.store(sess)
+		if args.Flag("delete").Var().Bool() {
+			if err := store.Delete(cnf.provider()); err != nil {
+				return err
+			}
+			sess.Log().Ok("token deleted", slog.String("provider", cnf.provider()))
+			return nil
+		}
+		token := args.Arg(0).String()
+		if args.Argn() == 0 {
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && err != io.EOF {
+				return fmt.Errorf("%w: %s", Error, err.Error())
+			}
+			token = line
+		}
+		token = strings.TrimSpace(token)
+		if token == "" {
+			return fmt.Errorf("%w: token is empty", Error)
+		}
+		if err := store.Save(cnf.provider(), &auth.Token{AccessToken: token}); err != nil {
+			return err
+		}
+		sess.Log().Ok("token stored", slog.String("provider", cnf.provider()))
+		return nil
+	})
+	return cmd
+}
+
+func operationCommand(cnf Config, spec *Spec, name string, op *Operation) *command.Command {
+	desc := op.Summary
+	if desc == "" {
+		desc = op.Method + " " + op.Path
+	}
+	cmd := command.New(command.Config{
+		Name:             settings.String(name),
+		Description:      settings.String(desc),
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.AddInfo(op.Method + " " + op.Path)
+	if op.Description != "" && op.Description != op.Summary {
+		cmd.AddInfo(op.Description)
+	}
+	if op.Deprecated {
+		cmd.AddInfo("Operation is deprecated.")
+	}
+
+	flags := operationFlags(op)
+	for _, f := range flags {
+		cmd.WithFlags(f.create())
+	}
+	if op.Body != nil {
+		cmd.WithFlags(
+			varflag.StringFunc("data", "", "request body, @file reads it from file and - from stdin"),
+		)
+	}
+
+	cmd.DoWithResult(func(sess *session.Context, args action.Args) (*action.Result, error) {
+		base := cnf.baseURL(sess, spec)
+		if u := args.Flag("base-url").String(); u != "" {
+			base = u
+		}
+		if base == "" {
+			return nil, fmt.Errorf("%w: base URL is not set, set openapi.base_url", Error)
+		}
+		ctx, cancel := context.WithTimeout(sess, timeout(sess))
+		defer cancel()
+
+		req, err := newRequest(ctx, base, op, flags, args)
+		if err != nil {
+			return nil, err
+		}
+		for _, in := range args.Flag("header").Input() {
+			if strings.HasPrefix(in, "-") {
+				continue
+			}
+			key, val, ok := strings.Cut(in, "=")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("%w: header %q must be KEY=VALUE", Error, in)
+			}
+			req.Header.Add(key, val)
+		}
+		if err := authorize(sess, cnf, spec, op, req); err != nil {
+			return nil, err
+		}
+
+		resp, err := (&http.Client{Transport: cnf.Transport}).Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+
+		var value any
+		if len(body) > 0 {
+			value = string(body)
+			if isJSON(resp.Header.Get("Content-Type")) {
+				dec := json.NewDecoder(bytes.NewReader(body))
+				dec.UseNumber()
+				var v any
+				if err := dec.Decode(&v); err == nil {
+					value = v
+				}
+			}
+		}
+		if value != nil && args.Flag("output").String() != "json" {
+			if s, ok := value.(string); ok {
+				fmt.Println(s)
+			} else {
+				out, err := json.MarshalIndent(value, "", "  ")
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s", Error, err.Error())
+				}
+				fmt.Println(string(out))
+			}
+		}
+		if resp.StatusCode >= 400 {
+			return action.NewResult(value), fmt.Errorf("%w: %s %s: %s", Error, op.Method, req.URL.Path, resp.Status)
+		}
+		return action.NewResult(value), nil
+	})
+	return cmd
+}
+
+// operationFlag is flag of parameter or of property of request body.
+type operationFlag struct {
+	name string
+	// param is nil for body properties.
+	param  *Parameter
+	path   []string
+	schema *Schema
+}
+
+func (of operationFlag) create() varflag.FlagCreateFunc {
+	sch := of.schema
+	if sch == nil {
+		sch = &Schema{}
+	}
+	usage := sch.Description
+	if of.param != nil && of.param.Description != "" {
+		usage = of.param.Description
+	}
+	usage, _, _ = strings.Cut(usage, "\n")
+	if usage == "" {
+		usage = of.name
+	}
+	if sch.Type != "" {
+		usage += " (" + sch.Type + ")"
+	}
+	if of.param != nil && of.param.Required {
+		usage += ", required"
+	}
+	switch {
+	case sch.Type == "array":
+		return varflag.StringFunc(of.name, "", usage+", can be repeated")
+	case sch.Type == "boolean":
+		return varflag.BoolFunc(of.name, false, usage)
+	case len(sch.Enum) > 0:
+		return varflag.OptionFunc(of.name, nil, sch.Enum, usage)
+	}
+	return varflag.StringFunc(of.name, "", usage)
+}
+
+// values returns values of flag f.
+func (of operationFlag) values(f varflag.Flag) []string {
+	if of.schema == nil || of.schema.Type != "array" {
+		return []string{f.String()}
+	}
+	// flag input contains flag names along with values
+	var values []string
+	for _, in := range f.Input() {
+		if !strings.HasPrefix(in, "-") {
+			values = append(values, in)
+		}
+	}
+	return values
+}
+
+// value returns JSON value of body property from flag f.
+func (of operationFlag) value(f varflag.Flag) (any, error) {
+	values := of.values(f)
+	if of.schema == nil || of.schema.Type != "array" {
+		return convert(of.name, of.schema, values[0])
+	}
+	list := make([]any, 0, len(values))
+	for _, raw := range values {
+		v, err := convert(of.name, of.schema.Items, raw)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// reservedFlags are names of flags which parameters can not use.
+var reservedFlags = func() map[string]bool {
+	names := map[string]bool{"base-url": true, "header": true, "data": true}
+	for _, fn := range []varflag.FlagCreateFunc{
+		cli.FlagVersion, cli.FlagHelp, cli.FlagX, cli.FlagSystemDebug,
+		cli.FlagDebug, cli.FlagVerbose, cli.FlagPrintStartup, cli.FlagOutput,
+		cli.FlagWaitLock, cli.FlagSet, cli.FlagNotify,
+	} {
+		if f, err := fn(); err == nil {
+			names[f.Name()] = true
+		}
+	}
+	return names
+}()
+
+// operationFlags returns flags of parameters and body properties of
+// op, parameters take precedence over properties with same name.
+func operationFlags(op *Operation) []operationFlag {
+	var flags []operationFlag
+	used := make(map[string]bool)
+	add := func(f operationFlag) {
+		if used[f.name] || reservedFlags[f.name] || !varflag.ValidFlagName(f.name) {
+			return
+		}
+		used[f.name] = true
+		flags = append(flags, f)
+	}
+	for _, p := range op.Parameters {
+		add(operationFlag{name: kebab(p.Name), param: p, schema: p.Schema})
+	}
+	if op.Body == nil || op.Body.Schema == nil {
+		return flags
+	}
+	depth := maxPropertyDepth
+	switch {
+	case op.Body.ContentType == formType:
+		depth = 0
+	case !isJSON(op.Body.ContentType):
+		return flags
+	}
+	// refs are references of walked schemas, recursive schemas are
+	// not walked again.
+	var refs []string
+	var walk func(sch *Schema, prefix string, path []string, d int)
+	walk = func(sch *Schema, prefix string, path []string, d int) {
+		refs = append(refs, sch.ref)
+		defer func() { refs = refs[:len(refs)-1] }()
+		names := make([]string, 0, len(sch.Properties))
+		for name := range sch.Properties {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			prop := sch.Properties[name]
+			fname := prefix + kebab(name)
+			ppath := append(slices.Clone(path), name)
+			if prop.Type == "object" && len(prop.Properties) > 0 {
+				if d < depth && (prop.ref == "" || !slices.Contains(refs, prop.ref)) {
+					walk(prop, fname+"-", ppath, d+1)
+				}
+				continue
+			}
+			add(operationFlag{name: fname, path: ppath, schema: prop})
+		}
+	}
+	walk(op.Body.Schema, "", nil, 0)
+	return flags
+}
+
+// newRequest returns request of op with parameters and body from
+// --data and flags.
+func newRequest(ctx context.Context, base string, op *Operation, flags []operationFlag, args action.Args) (*http.Request, error) {
+	var (
+		path   = op.Path
+		query  = url.Values{}
+		header = http.Header{}
+		raw    []byte
+		obj    map[string]any
+		form   url.Values
+	)
+	if data := args.Flag("data"); op.Body != nil && data.Present() {
+		var err error
+		if raw, err = readData(data.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, of := range flags {
+		f := args.Flag(of.name)
+		if !f.Present() {
+			if of.param != nil && of.param.Required {
+				return nil, fmt.Errorf("%w: --%s is required", Error, of.name)
+			}
+			continue
+		}
+		if of.param != nil {
+			values := of.values(f)
+			switch of.param.In {
+			case "path":
+				path = strings.ReplaceAll(path, "{"+of.param.Name+"}", url.PathEscape(strings.Join(values, ",")))
+			case "query":
+				query[of.param.Name] = append(query[of.param.Name], values...)
+			case "header":
+				header.Set(of.param.Name, strings.Join(values, ","))
+			case "cookie":
+				header.Add("Cookie", (&http.Cookie{Name: of.param.Name, Value: strings.Join(values, ",")}).String())
+			}
+			continue
+		}
+
+		if op.Body.ContentType == formType {
+			if form == nil {
+				var err error
+				if form, err = url.ParseQuery(string(raw)); err != nil {
+					return nil, fmt.Errorf("%w: invalid --data: %s", Error, err.Error())
+				}
+			}
+			form[of.path[0]] = of.values(f)
+			continue
+		}
+		if obj == nil {
+			obj = make(map[string]any)
+			if len(bytes.TrimSpace(raw)) > 0 {
+				dec := json.NewDecoder(bytes.NewReader(raw))
+				dec.UseNumber()
+				if err := dec.Decode(&obj); err != nil {
+					return nil, fmt.Errorf("%w: --data must be JSON object: %s", Error, err.Error())
+				}
+			}
+		}
+		v, err := of.value(f)
+		if err != nil {
+			return nil, err
+		}
+		setPath(obj, of.path, v)
+	}
+	if strings.Contains(path, "{") {
+		return nil, fmt.Errorf("%w: path parameters of %s are not set", Error, path)
+	}
+
+	switch {
+	case form != nil:
+		raw = []byte(form.Encode())
+	case obj != nil:
+		var err error
+		if raw, err = json.Marshal(obj); err != nil {
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+	}
+	if raw == nil && op.Body != nil && op.Body.Required {
+		return nil, fmt.Errorf("%w: request body is required, set it with --data or flags", Error)
+	}
+
+	u := strings.TrimSuffix(base, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var body io.Reader
+	if raw != nil {
+		body = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, op.Method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if raw != nil && op.Body != nil && op.Body.ContentType != "" {
+		req.Header.Set("Content-Type", op.Body.ContentType)
+	}
+	return req, nil
+}
+
+// authorize authorizes req according to security requirements of op
+// with token of cnf.
+func authorize(sess *session.Context, cnf Config, spec *Spec, op *Operation, req *http.Request) error {
+	if len(op.Security) == 0 {
+		return nil
+	}
+	optional := slices.ContainsFunc(op.Security, func(r []string) bool { return len(r) == 0 })
+	tok, err := cnf.token(sess)
+	if err != nil {
+		if optional {
+			return nil
+		}
+		hint := "token"
+		if cnf.Auth != nil {
+			hint = "login"
+		}
+		return fmt.Errorf("%w: %s %s requires authorization, run %s %s: %s", Error, op.Method, op.Path, cnf.name(), hint, err.Error())
+	}
+	for _, r := range op.Security {
+		if len(r) == 0 {
+			continue
+		}
+		supported := true
+		for _, name := range r {
+			if !spec.Schemes[name].supported() {
+				supported = false
+			}
+		}
+		if !supported {
+			continue
+		}
+		for _, name := range r {
+			spec.Schemes[name].apply(req, tok)
+		}
+		return nil
+	}
+	if optional {
+		return nil
+	}
+	return fmt.Errorf("%w: %s %s has no supported security scheme", Error, op.Method, op.Path)
+}
+
+func (s SecurityScheme) supported() bool {
+	switch s.Type {
+	case "http":
+		return s.Scheme == "bearer" || s.Scheme == "basic"
+	case "apiKey":
+		return s.Name != "" && (s.In == "header" || s.In == "query" || s.In == "cookie")
+	case "oauth2", "openIdConnect":
+		return true
+	}
+	return false
+}
+
+func (s SecurityScheme) apply(req *http.Request, tok *auth.Token) {
+	switch {
+	case s.Type == "http" && s.Scheme == "basic":
+		user, pass, _ := strings.Cut(tok.AccessToken, ":")
+		req.SetBasicAuth(user, pass)
+	case s.Type == "apiKey" && s.In == "header":
+		req.Header.Set(s.Name, tok.AccessToken)
+	case s.Type == "apiKey" && s.In == "query":
+		q := req.URL.Query()
+		q.Set(s.Name, tok.AccessToken)
+		req.URL.RawQuery = q.Encode()
+	case s.Type == "apiKey" && s.In == "cookie":
+		req.AddCookie(&http.Cookie{Name: s.Name, Value: tok.AccessToken})
+	default:
+		typ := tok.TokenType
+		if typ == "" || strings.EqualFold(typ, "bearer") {
+			typ = "Bearer"
+		}
+		req.Header.Set("Authorization", typ+" "+tok.AccessToken)
+	}
+}
+
+// convert returns JSON value of raw flag value of property with
+// schema sch.
+func convert(name string, sch *Schema, raw string) (any, error) {
+	if sch == nil {
+		return raw, nil
+	}
+	switch sch.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: --%s must be integer", Error, name)
+		}
+		return json.Number(raw), nil
+	case "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("%w: --%s must be number", Error, name)
+		}
+		return json.Number(raw), nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: --%s must be boolean", Error, name)
+		}
+		return b, nil
+	case "object", "array":
+		var v any
+		dec := json.NewDecoder(strings.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("%w: --%s must be JSON %s", Error, name, sch.Type)
+		}
+		return v, nil
+	}
+	return raw, nil
+}
+
+// readData returns value of --data flag, @file reads file and - stdin.
+func readData(data string) ([]byte, error) {
+	var (
+		raw []byte
+		err error
+	)
+	switch {
+	case data == "-":
+		raw, err = io.ReadAll(os.Stdin)
+	case strings.HasPrefix(data, "@"):
+		raw, err = os.ReadFile(data[1:])
+	default:
+		return []byte(data), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return raw, nil
+}
+
+// setPath sets value at path of nested objects of obj.
+func setPath(obj map[string]any, path []string, v any) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := obj[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			obj[key] = next
+		}
+		obj = next
+	}
+	obj[path[len(path)-1]] = v
+}
+
+// operationName returns command name of op, derived from method and
+// path when operation has no id.
+func operationName(op *Operation) string {
+	if op.ID != "" {
+		return kebab(op.ID)
+	}
+	return kebab(strings.ToLower(op.Method) + " " + strings.NewReplacer("{", "", "}", "").Replace(op.Path))
+}
+
+// kebab returns kebab-case name of camelCase, snake_case and other
+// names, other characters than letters and digits separate words.
+func kebab(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	sep := false
+	for i, r := range runes {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			if i > 0 && b.Len() > 0 && !sep {
+				prev := runes[i-1]
+				next := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+				if prev >= 'a' && prev <= 'z' || prev >= '0' && prev <= '9' || (prev >= 'A' && prev <= 'Z' && next) {
+					b.WriteByte('-')
+				}
+			}
+			b.WriteRune(r + 'a' - 'A')
+			sep = false
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+			sep = false
+		default:
+			if b.Len() > 0 && !sep {
+				b.WriteByte('-')
+				sep = true
+			}
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
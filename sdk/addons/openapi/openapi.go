@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package openapi provides addon which generates commands calling
+// operations of HTTP API described by OpenAPI 3 document, so that CLI
+// frontend of an API does not need command per endpoint. Each operation
+// is command grouped under its first tag, with flags mapped from its
+// parameters and from properties of JSON or form request body. Requests
+// are authorized with token from credentials store according to
+// security schemes of the document, responses are printed as JSON and
+// returned as command result for --output json.
+//
+// Documents are read in JSON, YAML documents have to be converted
+// first.
+package openapi
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/addon"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/auth"
+)
+
+var Error = errors.New("openapi")
+
+type Settings struct {
+	BaseURL settings.String   `key:"base_url,save" default:"" desc:"Base URL of API overriding servers of OpenAPI document"`
+	Timeout settings.Duration `key:"timeout,save" default:"30s" desc:"Timeout of single request"`
+}
+
+func (s Settings) Blueprint() (*settings.Blueprint, error) {
+	return settings.New(s)
+}
+
+// Config configures openapi addon.
+type Config struct {
+	// Name is name of the command, api when empty.
+	Name string
+	// Category of the command in help.
+	Category string
+	// Description of the command in help, title of the document when
+	// empty.
+	Description string
+	// SpecFile is path of OpenAPI document commands are generated from.
+	SpecFile string
+	// Spec is OpenAPI document e.g. embedded with go:embed, it is used
+	// instead of SpecFile when set.
+	Spec []byte
+	// BaseURL is base URL of API used when openapi.base_url setting is
+	// empty, first server of the document is used when it is not set.
+	BaseURL string
+	// Auth is OAuth2 client which provides tokens, login and logout
+	// subcommands are added when it is set. Otherwise token is stored
+	// with token subcommand.
+	Auth *auth.Client
+	// Provider is name token is stored under in credentials store,
+	// name of the command when empty. It is ignored when Auth is set.
+	Provider string
+	// Store is credentials store of tokens, credentials directory of
+	// the current profile when nil. It is ignored when Auth is set.
+	Store auth.Store
+	// Transport sends requests of operations, http.DefaultTransport
+	// when nil. Tests can set it to vcr.Transport to replay recorded
+	// responses offline.
+	Transport http.RoundTripper
+}
+
+// Addon returns openapi addon providing command which calls
+// operations of API described by OpenAPI document. Settings are
+// available under openapi.* keys.
+func Addon(cnf Config) *addon.Addon {
+	var perms addon.Permissions
+	if cnf.SpecFile != "" {
+		perms.Paths = append(perms.Paths, cnf.SpecFile)
+	}
+	a := addon.New(addon.Config{
+		Name:        "OpenAPI",
+		Settings:    Settings{},
+		Permissions: perms,
+	})
+	a.ProvideCommands(Command(cnf))
+	return a
+}
+
+func (cnf Config) name() string {
+	if cnf.Name == "" {
+		return "api"
+	}
+	return cnf.Name
+}
+
+func (cnf Config) provider() string {
+	if cnf.Provider == "" {
+		return cnf.name()
+	}
+	return cnf.Provider
+}
+
+func (cnf Config) store(sess *session.Context) auth.Store {
+	if cnf.Store == nil {
+		return auth.ProfileStore(sess)
+	}
+	return cnf.Store
+}
+
+// token returns access token of Auth client or token stored in
+// credentials store.
+func (cnf Config) token(sess *session.Context) (*auth.Token, error) {
+	if cnf.Auth != nil {
+		return cnf.Auth.Token(sess)
+	}
+	return cnf.store(sess).Load(cnf.provider())
+}
+
+// baseURL returns base URL from openapi.base_url setting, cnf or
+// servers of spec.
+func (cnf Config) baseURL(sess *session.Context, spec *Spec) string {
+	if u := sess.Get("openapi.base_url").String(); u != "" {
+		return u
+	}
+	if cnf.BaseURL != "" {
+		return cnf.BaseURL
+	}
+	if len(spec.Servers) > 0 {
+		return spec.Servers[0]
+	}
+	return ""
+}
+
+// timeout returns openapi.timeout setting, 30 seconds when it is not
+// set.
+func timeout(sess *session.Context) time.Duration {
+	if d := sess.Get("openapi.timeout").Duration(); d > 0 {
+		return d
+	}
+	return 30 * time.Second
+}
+
+// loadSpec returns document configured by cnf, nil when none is
+// configured or the file does not exist.
+func loadSpec(cnf Config) (*Spec, error) {
+	if len(cnf.Spec) > 0 {
+		return ParseSpec(cnf.Spec)
+	}
+	if cnf.SpecFile == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(cnf.SpecFile); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return LoadSpec(cnf.SpecFile)
+}
@@ -0,0 +1,421 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// maxRefDepth limits chains of references.
+const maxRefDepth = 8
+
+// Spec is parsed OpenAPI 3 document.
+type Spec struct {
+	Title   string
+	Version string
+	// Servers are URLs of servers in order of the document.
+	Servers    []string
+	Operations []*Operation
+	// Schemes are security schemes by name.
+	Schemes map[string]SecurityScheme
+
+	doc map[string]any
+	// schemas are resolved schemas by reference, recursive schemas
+	// refer to themselves.
+	schemas map[string]*Schema
+}
+
+// Operation is API operation, that is HTTP method of path.
+type Operation struct {
+	ID          string
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	Parameters  []*Parameter
+	// Body is nil when operation does not accept request body.
+	Body *Body
+	// Security are alternative security requirements, each of them
+	// lists names of schemes which are all required. Empty requirement
+	// means that authorization is optional.
+	Security [][]string
+}
+
+// Parameter is path, query, header or cookie parameter of operation.
+type Parameter struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Schema      *Schema
+}
+
+// Body is request body of operation.
+type Body struct {
+	ContentType string
+	Description string
+	Required    bool
+	Schema      *Schema
+}
+
+// Schema is subset of JSON schema used to derive flags, schemas
+// composed with oneOf or anyOf have no type and are set as JSON.
+type Schema struct {
+	Type        string
+	Format      string
+	Description string
+	Enum        []string
+	Items       *Schema
+	Properties  map[string]*Schema
+	Required    []string
+
+	// ref is reference schema was resolved from.
+	ref string
+}
+
+// SecurityScheme is security scheme of components.securitySchemes.
+type SecurityScheme struct {
+	// Type is apiKey, http, oauth2 or openIdConnect.
+	Type string
+	// Scheme is scheme of http type e.g. bearer or basic.
+	Scheme string
+	// In and Name locate apiKey, In is header, query or cookie.
+	In   string
+	Name string
+}
+
+// LoadSpec reads OpenAPI document in JSON from file.
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return ParseSpec(data)
+}
+
+// ParseSpec parses OpenAPI 3 document in JSON. References to other
+// documents are not supported.
+func ParseSpec(data []byte) (*Spec, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	s := &Spec{
+		Schemes: make(map[string]SecurityScheme),
+		schemas: make(map[string]*Schema),
+	}
+	if err := dec.Decode(&s.doc); err != nil {
+		return nil, fmt.Errorf("%w: invalid document: %s", Error, err.Error())
+	}
+	if v := str(s.doc, "openapi"); !strings.HasPrefix(v, "3.") {
+		return nil, fmt.Errorf("%w: unsupported OpenAPI version %q, 3.x is required", Error, v)
+	}
+	info := obj(s.doc, "info")
+	s.Title, s.Version = str(info, "title"), str(info, "version")
+	for _, srv := range list(s.doc, "servers") {
+		if u := str(asObj(srv), "url"); u != "" {
+			s.Servers = append(s.Servers, u)
+		}
+	}
+
+	for name, v := range obj(obj(s.doc, "components"), "securitySchemes") {
+		scheme, err := s.deref(v)
+		if err != nil {
+			return nil, err
+		}
+		s.Schemes[name] = SecurityScheme{
+			Type:   str(scheme, "type"),
+			Scheme: strings.ToLower(str(scheme, "scheme")),
+			In:     str(scheme, "in"),
+			Name:   str(scheme, "name"),
+		}
+	}
+	security := requirements(list(s.doc, "security"))
+
+	paths := obj(s.doc, "paths")
+	names := make([]string, 0, len(paths))
+	for path := range paths {
+		names = append(names, path)
+	}
+	sort.Strings(names)
+	methods := []string{
+		http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+		http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace,
+	}
+	for _, path := range names {
+		item, err := s.deref(paths[path])
+		if err != nil {
+			return nil, err
+		}
+		shared := list(item, "parameters")
+		for _, method := range methods {
+			op := asObj(item[strings.ToLower(method)])
+			if op == nil {
+				continue
+			}
+			o, err := s.operation(method, path, op, shared)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s %s: %w", Error, method, path, err)
+			}
+			if o.Security == nil {
+				o.Security = security
+			}
+			s.Operations = append(s.Operations, o)
+		}
+	}
+	return s, nil
+}
+
+func (s *Spec) operation(method, path string, op map[string]any, shared []any) (*Operation, error) {
+	o := &Operation{
+		ID:          str(op, "operationId"),
+		Method:      method,
+		Path:        path,
+		Summary:     str(op, "summary"),
+		Description: str(op, "description"),
+		Deprecated:  op["deprecated"] == true,
+	}
+	for _, tag := range list(op, "tags") {
+		if t, ok := tag.(string); ok {
+			o.Tags = append(o.Tags, t)
+		}
+	}
+	if _, ok := op["security"]; ok {
+		o.Security = requirements(list(op, "security"))
+		if o.Security == nil {
+			o.Security = [][]string{}
+		}
+	}
+
+	// operation parameters override path item parameters
+	for _, v := range append(shared, list(op, "parameters")...) {
+		p, err := s.deref(v)
+		if err != nil {
+			return nil, err
+		}
+		param := &Parameter{
+			Name:        str(p, "name"),
+			In:          str(p, "in"),
+			Description: str(p, "description"),
+			Required:    p["required"] == true,
+		}
+		if param.Schema, err = s.schema(p["schema"]); err != nil {
+			return nil, err
+		}
+		replaced := false
+		for i, existing := range o.Parameters {
+			if existing.Name == param.Name && existing.In == param.In {
+				o.Parameters[i], replaced = param, true
+			}
+		}
+		if !replaced {
+			o.Parameters = append(o.Parameters, param)
+		}
+	}
+
+	if v, ok := op["requestBody"]; ok {
+		rb, err := s.deref(v)
+		if err != nil {
+			return nil, err
+		}
+		content := obj(rb, "content")
+		ct := mediaType(content)
+		o.Body = &Body{
+			ContentType: ct,
+			Description: str(rb, "description"),
+			Required:    rb["required"] == true,
+		}
+		if ct != "" {
+			if o.Body.Schema, err = s.schema(obj(content, ct)["schema"]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return o, nil
+}
+
+// schema returns schema of v, composed schemas of allOf are merged.
+func (s *Spec) schema(v any) (*Schema, error) {
+	if v == nil {
+		return nil, nil
+	}
+	ref, _ := asObj(v)["$ref"].(string)
+	if sch, ok := s.schemas[ref]; ok {
+		return sch, nil
+	}
+	m, err := s.deref(v)
+	if err != nil {
+		return nil, err
+	}
+	sch := &Schema{
+		ref:         ref,
+		Type:        schemaType(m["type"]),
+		Format:      str(m, "format"),
+		Description: str(m, "description"),
+	}
+	if ref != "" {
+		s.schemas[ref] = sch
+	}
+	for _, e := range list(m, "enum") {
+		if v, ok := e.(string); ok {
+			sch.Enum = append(sch.Enum, v)
+		}
+	}
+	for _, r := range list(m, "required") {
+		if v, ok := r.(string); ok {
+			sch.Required = append(sch.Required, v)
+		}
+	}
+	if sch.Items, err = s.schema(m["items"]); err != nil {
+		return nil, err
+	}
+	for name, p := range obj(m, "properties") {
+		prop, err := s.schema(p)
+		if err != nil {
+			return nil, err
+		}
+		if prop == nil {
+			continue
+		}
+		if sch.Properties == nil {
+			sch.Properties = make(map[string]*Schema)
+		}
+		sch.Properties[name] = prop
+	}
+	for _, part := range list(m, "allOf") {
+		ps, err := s.schema(part)
+		if err != nil {
+			return nil, err
+		}
+		if ps == nil {
+			continue
+		}
+		if sch.Type == "" {
+			sch.Type = ps.Type
+		}
+		for name, prop := range ps.Properties {
+			if sch.Properties == nil {
+				sch.Properties = make(map[string]*Schema)
+			}
+			sch.Properties[name] = prop
+		}
+		sch.Required = append(sch.Required, ps.Required...)
+	}
+	if sch.Type == "" && sch.Properties != nil {
+		sch.Type = "object"
+	}
+	return sch, nil
+}
+
+// deref returns object v, following local references.
+func (s *Spec) deref(v any) (map[string]any, error) {
+	m := asObj(v)
+	for i := 0; m != nil; i++ {
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return m, nil
+		}
+		if i == maxRefDepth {
+			return nil, fmt.Errorf("%w: reference %s is too deep", Error, ref)
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			return nil, fmt.Errorf("%w: external reference %s is not supported", Error, ref)
+		}
+		var target any = s.doc
+		for _, tok := range strings.Split(ref[2:], "/") {
+			tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+			if target = asObj(target)[tok]; target == nil {
+				return nil, fmt.Errorf("%w: unresolved reference %s", Error, ref)
+			}
+		}
+		m = asObj(target)
+	}
+	return m, nil
+}
+
+// requirements returns security requirements as lists of scheme names.
+func requirements(reqs []any) [][]string {
+	var out [][]string
+	for _, r := range reqs {
+		names := []string{}
+		for name := range asObj(r) {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		out = append(out, names)
+	}
+	return out
+}
+
+// mediaType returns preferred media type of content, JSON is preferred
+// over form and other types.
+func mediaType(content map[string]any) string {
+	types := make([]string, 0, len(content))
+	for ct := range content {
+		types = append(types, ct)
+	}
+	sort.Strings(types)
+	for _, ct := range types {
+		if isJSON(ct) {
+			return ct
+		}
+	}
+	for _, ct := range types {
+		if ct == formType {
+			return ct
+		}
+	}
+	if len(types) > 0 {
+		return types[0]
+	}
+	return ""
+}
+
+// schemaType returns type of schema, first non-null type of OpenAPI
+// 3.1 type lists.
+func schemaType(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []any:
+		for _, tt := range t {
+			if s, ok := tt.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func isJSON(contentType string) bool {
+	ct, _, _ := strings.Cut(contentType, ";")
+	ct = strings.TrimSpace(strings.ToLower(ct))
+	return ct == "application/json" || strings.HasSuffix(ct, "+json")
+}
+
+func asObj(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func obj(m map[string]any, key string) map[string]any {
+	return asObj(m[key])
+}
+
+func list(m map[string]any, key string) []any {
+	l, _ := m[key].([]any)
+	return l
+}
+
+func str(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+)
+
+// Command returns schedule command with add, list and remove
+// subcommands.
+func Command(cnf Config) *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "schedule",
+		Category:    "Automation",
+		Description: "Run commands on cron schedules",
+	})
+	if cnf.Daemon {
+		cmd.AddInfo("Schedules run in-process while " + ServiceName + " service is running, changes are picked up within " + reloadInterval.String() + ".")
+	} else {
+		cmd.AddInfo("Schedules are run by service manager, add emits systemd timer on Linux and launchd agent on macOS.")
+	}
+	cmd.WithSubCommands(addCommand(cnf), listCommand(), removeCommand(cnf))
+	return cmd
+}
+
+func addCommand(cnf Config) *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "add",
+		Description:      "Add schedule running command",
+		MinArgs:          2,
+		MaxArgs:          16,
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.Usage(`[flags] "<cron expression>" <command...> [-- command args...]`)
+	cmd.AddInfo(`Expression has five fields (minute hour day-of-month month day-of-week) or it is descriptor e.g. @daily or "@every 15m". Arguments after -- are passed to the command.`)
+
+	cmd.WithFlags(
+		varflag.StringFunc("id", "", "schedule id, random when empty"),
+		varflag.StringFunc("format", DefaultFormat(), "unit format emitted when not in daemon mode: systemd or launchd"),
+		varflag.StringFunc("output", "", "directory unit files are written to instead of printing them", "o"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		var path []string
+		for _, arg := range args.Args()[1:] {
+			path = append(path, arg.String())
+		}
+		s := Schedule{
+			ID:      args.Flag("id").String(),
+			Expr:    args.Arg(0).String(),
+			Command: strings.Join(path, " "),
+			Args:    args.Passthrough(),
+		}
+		if s.ID == "" {
+			s.ID = newID()
+		}
+
+		var units []Unit
+		if !cnf.Daemon {
+			format := args.Flag("format").String()
+			if format == "" {
+				return fmt.Errorf("%w: no supported service manager on this system, run application in daemon mode", Error)
+			}
+			exe, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("%w: %s", Error, err.Error())
+			}
+			if units, err = Units(format, sess.Get("app.slug").String(), exe, s); err != nil {
+				return err
+			}
+		}
+
+		s, err := Add(sess, s)
+		if err != nil {
+			return err
+		}
+		sess.Log().Ok("schedule added",
+			slog.String("id", s.ID),
+			slog.String("expr", s.Expr),
+			slog.String("command", s.Command))
+		if cnf.Daemon {
+			return nil
+		}
+		return emitUnits(sess, args.Flag("format").String(), args.Flag("output").String(), units)
+	})
+	return cmd
+}
+
+func listCommand() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "list",
+		Description:      "List schedules",
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		list, err := List(sess)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			sess.Log().Info("no schedules")
+			return nil
+		}
+		now := sess.Time().Now()
+		table := textfmt.Table{
+			Title:      "Schedules",
+			WithHeader: true,
+		}
+		table.AddRow("ID", "EXPRESSION", "COMMAND", "NEXT RUN")
+		for _, s := range list {
+			var next string
+			if sched, err := Parse(s.Expr); err == nil {
+				next = sess.Time().In(sched.Next(now)).Format("2006-01-02 15:04")
+			}
+			command := s.Command
+			if len(s.Args) > 0 {
+				command += " -- " + strings.Join(s.Args, " ")
+			}
+			table.AddRow(s.ID, s.Expr, command, next)
+		}
+		sess.Log().Println(table.String())
+		return nil
+	})
+	return cmd
+}
+
+func removeCommand(cnf Config) *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "remove",
+		Description:      "Remove schedule",
+		MinArgs:          1,
+		MaxArgs:          1,
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.Usage("[flags] <id>")
+
+	cmd.WithFlags(
+		varflag.StringFunc("format", DefaultFormat(), "format of emitted units: systemd or launchd"),
+		varflag.StringFunc("output", "", "directory emitted unit files are removed from", "o"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		s, err := Remove(sess, args.Arg(0).String())
+		if err != nil {
+			return err
+		}
+		sess.Log().Ok("schedule removed", slog.String("id", s.ID))
+		if cnf.Daemon {
+			return nil
+		}
+
+		names := UnitNames(args.Flag("format").String(), sess.Get("app.slug").String(), s.ID)
+		dir := args.Flag("output").String()
+		if dir == "" {
+			if len(names) > 0 {
+				sess.Log().Notice("disable and remove emitted units", slog.String("units", strings.Join(names, ", ")))
+			}
+			return nil
+		}
+		for _, name := range names {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("%w: %s", Error, err.Error())
+			}
+		}
+		return nil
+	})
+	return cmd
+}
+
+// emitUnits writes units to dir or prints them when dir is empty.
+func emitUnits(sess *session.Context, format, dir string, units []Unit) error {
+	if dir == "" {
+		for _, u := range units {
+			fmt.Printf("# %s\n%s\n", u.Name, u.Content)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	for _, u := range units {
+		path := filepath.Join(dir, u.Name)
+		if err := os.WriteFile(path, []byte(u.Content), 0644); err != nil {
+			return fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		sess.Log().Info("unit written", slog.String("path", path))
+	}
+	switch format {
+	case FormatSystemd:
+		sess.Log().Notice("enable timer with: systemctl --user daemon-reload && systemctl --user enable --now " + units[len(units)-1].Name)
+	case FormatLaunchd:
+		sess.Log().Notice("load agent with: launchctl load " + filepath.Join(dir, units[0].Name))
+	}
+	return nil
+}
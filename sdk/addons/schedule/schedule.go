@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package schedule provides addon which runs application commands on
+// cron schedules. Schedules are managed with schedule command and kept
+// in application state store. Daemon applications run them in-process
+// with scheduler service, for other applications schedule add emits
+// systemd timer or launchd agent running the command.
+package schedule
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/scheduling/cron"
+	"github.com/happy-sdk/happy/sdk/addon"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/store"
+)
+
+var Error = errors.New("schedule")
+
+// ErrNotFound is returned when schedule does not exist.
+var ErrNotFound = fmt.Errorf("%w: not found", Error)
+
+// Bucket is state store bucket holding schedules by ID.
+var Bucket = store.NewBucket[Schedule]("schedules")
+
+// parser accepts standard five field cron expressions and descriptors
+// e.g. @daily or @every 10m.
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Schedule runs command with args on cron expression.
+type Schedule struct {
+	// ID identifies schedule, it names emitted unit files.
+	ID string `json:"id"`
+	// Expr is cron expression.
+	Expr string `json:"expr"`
+	// Command is space separated path of the command e.g. "db migrate".
+	Command   string    `json:"command"`
+	Args      []string  `json:"args,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Config configures schedule addon.
+type Config struct {
+	// Daemon runs schedules in-process with scheduler service, the
+	// application must be long running and load ServiceName service
+	// e.g. with services.Require. When false schedule add emits systemd
+	// timer or launchd agent running the command.
+	Daemon bool
+}
+
+// Addon returns schedule addon providing schedule command and in
+// daemon mode scheduler service.
+func Addon(cnf Config) *addon.Addon {
+	a := addon.New(addon.Config{
+		Name: "Schedule",
+	})
+	if cnf.Daemon {
+		a.ProvideServices(AsService())
+	}
+	a.ProvideCommands(Command(cnf))
+	return a
+}
+
+// Parse parses cron expression of schedule.
+func Parse(expr string) (cron.Schedule, error) {
+	sched, err := parser.Parse(strings.TrimSpace(expr))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid expression %q: %s", Error, expr, err.Error())
+	}
+	return sched, nil
+}
+
+// Add stores schedule s, random ID is assigned when s has none.
+func Add(sess *session.Context, s Schedule) (Schedule, error) {
+	if s.ID == "" {
+		s.ID = newID()
+	}
+	if !validID.MatchString(s.ID) {
+		return s, fmt.Errorf("%w: invalid id %q, use lowercase letters, digits and dashes", Error, s.ID)
+	}
+	if _, err := Parse(s.Expr); err != nil {
+		return s, err
+	}
+	s.Command = strings.Join(strings.Fields(s.Command), " ")
+	if s.Command == "" {
+		return s, fmt.Errorf("%w: command is required", Error)
+	}
+	s.CreatedAt = sess.Time().Now()
+
+	st, err := sess.Store()
+	if err != nil {
+		return s, err
+	}
+	return s, st.Update(func(tx *store.Tx) error {
+		if Bucket.Has(tx, s.ID) {
+			return fmt.Errorf("%w: schedule %s already exists", Error, s.ID)
+		}
+		return Bucket.Put(tx, s.ID, s)
+	})
+}
+
+// List returns stored schedules sorted by ID.
+func List(sess *session.Context) ([]Schedule, error) {
+	st, err := sess.Store()
+	if err != nil {
+		return nil, err
+	}
+	var list []Schedule
+	err = st.View(func(tx *store.Tx) error {
+		return Bucket.Range(tx, func(_ string, s Schedule) bool {
+			list = append(list, s)
+			return true
+		})
+	})
+	return list, err
+}
+
+// Remove deletes schedule with id and returns it.
+func Remove(sess *session.Context, id string) (Schedule, error) {
+	var s Schedule
+	st, err := sess.Store()
+	if err != nil {
+		return s, err
+	}
+	err = st.Update(func(tx *store.Tx) error {
+		var err error
+		if s, err = Bucket.Get(tx, id); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return fmt.Errorf("%w: %s", ErrNotFound, id)
+			}
+			return err
+		}
+		return Bucket.Delete(tx, id)
+	})
+	return s, err
+}
+
+func newID() string {
+	raw := make([]byte, 4)
+	_, _ = rand.Read(raw)
+	return hex.EncodeToString(raw)
+}
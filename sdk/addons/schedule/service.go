@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package schedule
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/scheduling/cron"
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/datetime"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+// ServiceName is name of the service running schedules in daemon mode.
+const ServiceName = "scheduler"
+
+// reloadInterval is how often scheduler picks up schedules added or
+// removed by other invocations of the application.
+const reloadInterval = 30 * time.Second
+
+// AsService returns scheduler service which invokes commands of stored
+// schedules in-process while it is running.
+func AsService() *services.Service {
+	svc := services.New(service.Config{
+		Name:        settings.String(ServiceName),
+		Description: settings.String("Runs scheduled commands"),
+	})
+	r := &runner{}
+	svc.OnStart(r.start)
+	svc.OnStop(r.stop)
+	return svc
+}
+
+type entry struct {
+	id       cron.EntryID
+	schedule Schedule
+}
+
+type runner struct {
+	mu      sync.Mutex
+	cron    *cron.Cron
+	entries map[string]entry
+}
+
+func (r *runner) start(sess *session.Context) error {
+	r.mu.Lock()
+	r.cron = cron.New(
+		cron.WithParser(parser),
+		cron.WithClock(cronClock{sess.Time().Clock()}),
+		cron.WithLocation(sess.Time().Location()),
+	)
+	r.entries = make(map[string]entry)
+	r.mu.Unlock()
+
+	if err := r.reload(sess); err != nil {
+		return err
+	}
+	r.cron.Schedule(cron.Every(reloadInterval), cron.FuncJob(func() {
+		if err := r.reload(sess); err != nil {
+			sess.Log().Error("reloading schedules failed", slog.String("err", err.Error()))
+		}
+	}))
+	r.cron.Start()
+	return nil
+}
+
+func (r *runner) stop(sess *session.Context, err error) error {
+	r.mu.Lock()
+	c := r.cron
+	r.mu.Unlock()
+	if c != nil {
+		// wait for running commands to complete.
+		<-c.Stop().Done()
+	}
+	return err
+}
+
+// reload syncs cron entries with stored schedules.
+func (r *runner) reload(sess *session.Context) error {
+	list, err := List(sess)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := make(map[string]Schedule, len(list))
+	for _, s := range list {
+		current[s.ID] = s
+	}
+	for id, e := range r.entries {
+		if s, ok := current[id]; ok && same(s, e.schedule) {
+			continue
+		}
+		r.cron.Remove(e.id)
+		delete(r.entries, id)
+		sess.Log().Debug("schedule removed", slog.String("id", id))
+	}
+	for _, s := range list {
+		if _, ok := r.entries[s.ID]; ok {
+			continue
+		}
+		id, err := r.cron.AddFunc(s.Expr, r.job(sess, s))
+		if err != nil {
+			sess.Log().Warn("invalid schedule", slog.String("id", s.ID), slog.String("err", err.Error()))
+			continue
+		}
+		r.entries[s.ID] = entry{id: id, schedule: s}
+		sess.Log().Debug("schedule added",
+			slog.String("id", s.ID),
+			slog.String("expr", s.Expr),
+			slog.String("command", s.Command))
+	}
+	return nil
+}
+
+// job returns function invoking command of schedule s.
+func (r *runner) job(sess *session.Context, s Schedule) func() {
+	return func() {
+		started := sess.Time().Now()
+		err := action.Try(func() error {
+			return sess.InvokeCommand(s.Command, s.Args...)
+		})
+		if err != nil {
+			sess.Log().Error("scheduled command failed",
+				slog.String("id", s.ID),
+				slog.String("command", s.Command),
+				slog.String("err", err.Error()))
+			return
+		}
+		sess.Log().Info("scheduled command completed",
+			slog.String("id", s.ID),
+			slog.String("command", s.Command),
+			slog.String("took", sess.Time().Since(started).String()))
+	}
+}
+
+func same(a, b Schedule) bool {
+	return a.Expr == b.Expr && a.Command == b.Command && slices.Equal(a.Args, b.Args)
+}
+
+// cronClock adapts datetime.Clock to cron.Clock.
+type cronClock struct {
+	datetime.Clock
+}
+
+func (c cronClock) NewTimer(d time.Duration) cron.Timer {
+	return c.Clock.NewTimer(d)
+}
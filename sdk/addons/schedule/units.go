@@ -0,0 +1,280 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package schedule
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/scheduling/cron"
+)
+
+const (
+	// FormatSystemd emits systemd service and timer units.
+	FormatSystemd = "systemd"
+	// FormatLaunchd emits launchd agent property list.
+	FormatLaunchd = "launchd"
+)
+
+// starBit marks cron field given as * which matters for day of month
+// and day of week, cron runs on either of them when both are restricted.
+const starBit = 1 << 63
+
+// maxCalendarIntervals limits number of launchd calendar intervals
+// one expression may expand to.
+const maxCalendarIntervals = 256
+
+var weekdays = [...]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// Unit is service manager file emitted for schedule.
+type Unit struct {
+	// Name is file name of the unit.
+	Name    string
+	Content string
+}
+
+// DefaultFormat returns unit format of service manager of current OS,
+// empty when OS has none supported.
+func DefaultFormat() string {
+	switch runtime.GOOS {
+	case "linux":
+		return FormatSystemd
+	case "darwin":
+		return FormatLaunchd
+	}
+	return ""
+}
+
+// Units returns unit files running schedule s of application slug with
+// executable exe in given format.
+func Units(format, slug, exe string, s Schedule) ([]Unit, error) {
+	sched, err := Parse(s.Expr)
+	if err != nil {
+		return nil, err
+	}
+	argv := append([]string{exe}, strings.Fields(s.Command)...)
+	argv = append(argv, s.Args...)
+	switch format {
+	case FormatSystemd:
+		return systemdUnits(slug, s, sched, argv)
+	case FormatLaunchd:
+		return launchdUnits(slug, s, sched, argv)
+	}
+	return nil, fmt.Errorf("%w: unsupported unit format %q", Error, format)
+}
+
+// UnitNames returns file names of units emitted for schedule with id.
+func UnitNames(format, slug, id string) []string {
+	switch format {
+	case FormatSystemd:
+		return []string{slug + "-" + id + ".service", slug + "-" + id + ".timer"}
+	case FormatLaunchd:
+		return []string{slug + "." + id + ".plist"}
+	}
+	return nil
+}
+
+func systemdUnits(slug string, s Schedule, sched cron.Schedule, argv []string) ([]Unit, error) {
+	var trigger string
+	switch sc := sched.(type) {
+	case cron.ConstantDelaySchedule:
+		trigger = fmt.Sprintf("OnBootSec=%s\nOnUnitActiveSec=%s\n", systemdSpan(sc.Delay), systemdSpan(sc.Delay))
+	case *cron.SpecSchedule:
+		cal, err := onCalendar(sc)
+		if err != nil {
+			return nil, err
+		}
+		trigger = "OnCalendar=" + cal + "\nPersistent=true\n"
+	default:
+		return nil, fmt.Errorf("%w: %q can not be converted to systemd timer", Error, s.Expr)
+	}
+
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		quoted[i] = systemdQuote(arg)
+	}
+	names := UnitNames(FormatSystemd, slug, s.ID)
+	svc := fmt.Sprintf("[Unit]\nDescription=%s scheduled command %s\n\n[Service]\nType=oneshot\nExecStart=%s\n",
+		slug, s.Command, strings.Join(quoted, " "))
+	timer := fmt.Sprintf("[Unit]\nDescription=%s schedule %s (%s)\n\n[Timer]\n%s\n[Install]\nWantedBy=timers.target\n",
+		slug, s.ID, s.Expr, trigger)
+	return []Unit{{Name: names[0], Content: svc}, {Name: names[1], Content: timer}}, nil
+}
+
+// onCalendar returns systemd calendar event of cron schedule.
+func onCalendar(sc *cron.SpecSchedule) (string, error) {
+	if err := checkDays(sc); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if sc.Dow&starBit == 0 {
+		days := fieldValues(sc.Dow, 0, 6)
+		names := make([]string, len(days))
+		for i, d := range days {
+			names[i] = weekdays[d]
+		}
+		b.WriteString(strings.Join(names, ",") + " ")
+	}
+	fmt.Fprintf(&b, "*-%s-%s %s:%s:00",
+		calendarField(sc.Month, 1, 12),
+		calendarField(sc.Dom, 1, 31),
+		calendarField(sc.Hour, 0, 23),
+		calendarField(sc.Minute, 0, 59))
+	if sc.Location != nil && sc.Location != time.Local {
+		b.WriteString(" " + sc.Location.String())
+	}
+	return b.String(), nil
+}
+
+func calendarField(field uint64, min, max int) string {
+	if field&starBit != 0 || len(fieldValues(field, min, max)) == max-min+1 {
+		return "*"
+	}
+	vals := fieldValues(field, min, max)
+	out := make([]string, len(vals))
+	for i, v := range vals {
+		out[i] = fmt.Sprintf("%02d", v)
+	}
+	return strings.Join(out, ",")
+}
+
+func systemdSpan(d time.Duration) string {
+	if d < time.Second {
+		d = time.Second
+	}
+	return strconv.Itoa(int(d.Round(time.Second)/time.Second)) + "s"
+}
+
+// systemdQuote quotes argument of ExecStart when needed, percent signs
+// are escaped since systemd expands specifiers in command lines.
+func systemdQuote(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;$") {
+		return arg
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`)
+	return `"` + r.Replace(arg) + `"`
+}
+
+func launchdUnits(slug string, s Schedule, sched cron.Schedule, argv []string) ([]Unit, error) {
+	var trigger string
+	switch sc := sched.(type) {
+	case cron.ConstantDelaySchedule:
+		trigger = fmt.Sprintf("\t<key>StartInterval</key>\n\t<integer>%s</integer>\n", strings.TrimSuffix(systemdSpan(sc.Delay), "s"))
+	case *cron.SpecSchedule:
+		intervals, err := calendarIntervals(sc)
+		if err != nil {
+			return nil, err
+		}
+		var b strings.Builder
+		b.WriteString("\t<key>StartCalendarInterval</key>\n\t<array>\n")
+		for _, iv := range intervals {
+			b.WriteString("\t\t<dict>\n")
+			for _, kv := range iv {
+				fmt.Fprintf(&b, "\t\t\t<key>%s</key>\n\t\t\t<integer>%d</integer>\n", kv.key, kv.val)
+			}
+			b.WriteString("\t\t</dict>\n")
+		}
+		b.WriteString("\t</array>\n")
+		trigger = b.String()
+	default:
+		return nil, fmt.Errorf("%w: %q can not be converted to launchd agent", Error, s.Expr)
+	}
+
+	label := slug + "." + s.ID
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", escapeXML(label))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range argv {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", escapeXML(arg))
+	}
+	b.WriteString("\t</array>\n")
+	b.WriteString(trigger)
+	b.WriteString("</dict>\n</plist>\n")
+	return []Unit{{Name: UnitNames(FormatLaunchd, slug, s.ID)[0], Content: b.String()}}, nil
+}
+
+type calendarKey struct {
+	key string
+	val int
+}
+
+// calendarIntervals expands cron schedule to launchd calendar
+// intervals, fields matching any value are omitted.
+func calendarIntervals(sc *cron.SpecSchedule) ([][]calendarKey, error) {
+	if err := checkDays(sc); err != nil {
+		return nil, err
+	}
+	if sc.Location != nil && sc.Location != time.Local {
+		return nil, fmt.Errorf("%w: launchd does not support time zone %s", Error, sc.Location)
+	}
+	fields := []struct {
+		key      string
+		bits     uint64
+		min, max int
+	}{
+		{"Month", sc.Month, 1, 12},
+		{"Day", sc.Dom, 1, 31},
+		{"Weekday", sc.Dow, 0, 6},
+		{"Hour", sc.Hour, 0, 23},
+		{"Minute", sc.Minute, 0, 59},
+	}
+	intervals := [][]calendarKey{nil}
+	for _, f := range fields {
+		if calendarField(f.bits, f.min, f.max) == "*" {
+			continue
+		}
+		vals := fieldValues(f.bits, f.min, f.max)
+		if len(intervals)*len(vals) > maxCalendarIntervals {
+			return nil, fmt.Errorf("%w: expression expands to more than %d launchd calendar intervals", Error, maxCalendarIntervals)
+		}
+		next := make([][]calendarKey, 0, len(intervals)*len(vals))
+		for _, iv := range intervals {
+			for _, v := range vals {
+				next = append(next, append(append([]calendarKey(nil), iv...), calendarKey{f.key, v}))
+			}
+		}
+		intervals = next
+	}
+	return intervals, nil
+}
+
+// checkDays rejects schedules restricting both day of month and day of
+// week, cron runs them on either day while service managers require
+// both to match.
+func checkDays(sc *cron.SpecSchedule) error {
+	if sc.Dom&starBit == 0 && sc.Dow&starBit == 0 {
+		return fmt.Errorf("%w: schedule restricting both day of month and day of week can not be converted, use separate schedules", Error)
+	}
+	return nil
+}
+
+func fieldValues(field uint64, min, max int) []int {
+	var vals []int
+	field &^= starBit
+	for field != 0 {
+		v := bits.TrailingZeros64(field)
+		field &^= 1 << v
+		if v >= min && v <= max {
+			vals = append(vals, v)
+		}
+	}
+	return vals
+}
+
+func escapeXML(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
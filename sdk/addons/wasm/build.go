@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/internal"
+)
+
+// WasmExecJS is name of JavaScript support file copied next to js builds.
+const WasmExecJS = "wasm_exec.js"
+
+// Builder compiles package to WebAssembly.
+type Builder struct {
+	// Root is directory builds are run in.
+	Root    string
+	Package string
+	// Output is output directory, relative paths are relative to Root.
+	Output  string
+	Name    string
+	Targets []string
+	Tags    []string
+	// EmbedPackage makes output directory Go package embedding built
+	// assets as FS when not empty.
+	EmbedPackage string
+}
+
+// BuilderFromSettings returns builder configured with wasm.* settings,
+// Root is project root when application runs within project.
+func BuilderFromSettings(sess *session.Context) (*Builder, error) {
+	b := &Builder{
+		Package:      sess.Get("wasm.package").String(),
+		Output:       sess.Get("wasm.output").String(),
+		Name:         sess.Get("wasm.name").String(),
+		Targets:      splitList(sess.Get("wasm.targets").String()),
+		Tags:         splitList(sess.Get("wasm.tags").String()),
+		EmbedPackage: sess.Get("wasm.embed_package").String(),
+	}
+	if proj := sess.Project(); proj != nil {
+		b.Root = proj.Root
+	} else {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		b.Root = wd
+	}
+	return b, nil
+}
+
+// OutputDir returns absolute output directory.
+func (b *Builder) OutputDir() string {
+	if filepath.IsAbs(b.Output) {
+		return b.Output
+	}
+	return filepath.Join(b.Root, b.Output)
+}
+
+// Build builds all targets and returns paths of written files.
+func (b *Builder) Build(ctx context.Context, sess *session.Context) ([]string, error) {
+	out := b.OutputDir()
+	if err := os.MkdirAll(out, 0750); err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	targets := b.Targets
+	if len(targets) == 0 {
+		targets = []string{TargetJS}
+	}
+
+	var files []string
+	for _, target := range targets {
+		bin, err := b.build(ctx, sess, target)
+		if err != nil {
+			return files, err
+		}
+		files = append(files, bin)
+		if target != TargetJS {
+			continue
+		}
+		js, err := b.copyWasmExec(ctx)
+		if err != nil {
+			return files, err
+		}
+		files = append(files, js)
+	}
+
+	if b.EmbedPackage != "" {
+		file, err := b.writeEmbed()
+		if err != nil {
+			return files, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Binary returns path of wasm binary built for target, js build is
+// <name>.wasm and wasip1 build <name>.wasip1.wasm.
+func (b *Builder) Binary(target string) string {
+	name := b.Name
+	if name == "" {
+		name = "main"
+	}
+	if target != TargetJS {
+		name += "." + target
+	}
+	return filepath.Join(b.OutputDir(), name+".wasm")
+}
+
+func (b *Builder) build(ctx context.Context, sess *session.Context, target string) (string, error) {
+	if target != TargetJS && target != TargetWASI {
+		return "", fmt.Errorf("%w: unknown target %q", Error, target)
+	}
+	bin := b.Binary(target)
+	args := []string{"build", "-trimpath", "-o", bin}
+	if len(b.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(b.Tags, ","))
+	}
+	pkg := b.Package
+	if pkg == "" {
+		pkg = "."
+	}
+	args = append(args, pkg)
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = b.Root
+	cmd.Env = append(os.Environ(), "GOOS="+target, "GOARCH=wasm")
+	internal.Log(sess.Log(), "wasm build", slog.String("target", target), slog.String("output", bin))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("%w: %s: %s", Error, target, strings.TrimSpace(string(out)))
+	}
+	return bin, nil
+}
+
+// copyWasmExec copies wasm_exec.js of Go toolchain used for builds into
+// output directory.
+func (b *Builder) copyWasmExec(ctx context.Context) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "env", "GOROOT")
+	cmd.Dir = b.Root
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: go env GOROOT: %s", Error, err.Error())
+	}
+	goroot := strings.TrimSpace(string(out))
+
+	var data []byte
+	// location changed from misc/wasm to lib/wasm in Go 1.24
+	for _, dir := range []string{"lib/wasm", "misc/wasm"} {
+		if data, err = os.ReadFile(filepath.Join(goroot, dir, WasmExecJS)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %s not found in %s", Error, WasmExecJS, goroot)
+	}
+	dest := filepath.Join(b.OutputDir(), WasmExecJS)
+	if current, err := os.ReadFile(dest); err == nil && bytes.Equal(current, data) {
+		return dest, nil
+	}
+	if err := os.WriteFile(dest, data, 0644); err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return dest, nil
+}
+
+// writeEmbed writes embed.go which embeds built assets of output
+// directory as FS of package EmbedPackage.
+func (b *Builder) writeEmbed() (string, error) {
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by wasm addon. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", b.EmbedPackage)
+	fmt.Fprintf(&src, "import \"embed\"\n\n")
+	fmt.Fprintf(&src, "// FS holds WebAssembly binaries and %s.\n", WasmExecJS)
+	fmt.Fprintf(&src, "//\n//go:embed *.wasm")
+	if _, err := os.Stat(filepath.Join(b.OutputDir(), WasmExecJS)); err == nil {
+		fmt.Fprintf(&src, " %s", WasmExecJS)
+	}
+	fmt.Fprintf(&src, "\nvar FS embed.FS\n")
+
+	path := filepath.Join(b.OutputDir(), "embed.go")
+	if err := os.WriteFile(path, src.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return path, nil
+}
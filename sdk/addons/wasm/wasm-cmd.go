@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package wasm
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/options"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/services/watcher"
+)
+
+// Command returns wasm command with build subcommand.
+func Command() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "wasm",
+		Category:         "Development",
+		Description:      "Build WebAssembly modules",
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+	cmd.WithSubCommands(buildCommand())
+	return cmd
+}
+
+func buildCommand() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "build",
+		Description:      "Compile package to WebAssembly",
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.AddInfo("Package, targets and output directory are configured with wasm.* settings. For js target wasm_exec.js of the Go toolchain is copied to output directory and with wasm.embed_package set output directory becomes Go package embedding built assets.")
+
+	cmd.WithFlags(
+		varflag.StringFunc("target", "", "comma separated targets overriding wasm.targets: js, wasip1"),
+		varflag.StringFunc("output", "", "output directory overriding wasm.output"),
+		varflag.BoolFunc("watch", false, "rebuild on source changes"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		b, err := BuilderFromSettings(sess)
+		if err != nil {
+			return err
+		}
+		if targets := args.Flag("target").String(); targets != "" {
+			b.Targets = nil
+			for _, t := range strings.Split(targets, ",") {
+				b.Targets = append(b.Targets, strings.TrimSpace(t))
+			}
+		}
+		if output := args.Flag("output").String(); output != "" {
+			b.Output = output
+		}
+
+		if err := buildAndReport(sess, b); err != nil && !args.Flag("watch").Var().Bool() {
+			return err
+		}
+		if !args.Flag("watch").Var().Bool() {
+			return nil
+		}
+		return watch(sess, b)
+	})
+	return cmd
+}
+
+func buildAndReport(sess *session.Context, b *Builder) error {
+	started := sess.Time().Now()
+	files, err := b.Build(sess, sess)
+	if err != nil {
+		sess.Log().Error("wasm build failed", slog.String("err", err.Error()))
+		return err
+	}
+	for _, file := range files {
+		if rel, err := filepath.Rel(b.Root, file); err == nil {
+			file = rel
+		}
+		internal.Log(sess.Log(), "wrote", slog.String("file", file))
+	}
+	sess.Log().Ok("wasm build succeeded", slog.String("took", sess.Time().Since(started).String()))
+	return nil
+}
+
+// watch rebuilds b when watched files change until interrupted.
+func watch(sess *session.Context, b *Builder) error {
+	ignore := splitList(sess.Get("wasm.ignore").String())
+	if rel, err := filepath.Rel(b.Root, b.OutputDir()); err == nil && !strings.HasPrefix(rel, "..") {
+		ignore = append(ignore, filepath.ToSlash(rel))
+	}
+	w := watcher.New(watcher.Config{
+		Name:     "wasm",
+		Root:     b.Root,
+		Patterns: splitList(sess.Get("wasm.watch").String()),
+		Ignore:   ignore,
+		Debounce: options.GetAsOr(sess, "app.devel.dev.debounce", 300*time.Millisecond),
+	})
+	if err := w.Reset(); err != nil {
+		return fmt.Errorf("%w: %w", Error, err)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	sess.Log().Notice("watching for changes", slog.String("root", b.Root))
+	interval, err := options.GetAs[time.Duration](sess, "app.devel.dev.interval")
+	if err != nil || interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+	ticker := sess.Time().NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sigs:
+			return nil
+		case <-sess.Done():
+			return nil
+		case now := <-ticker.C():
+			if err := w.Poll(now); err != nil {
+				internal.Log(sess.Log(), "wasm poll failed", slog.String("err", err.Error()))
+				continue
+			}
+			changes := w.Debounced(now)
+			if len(changes) == 0 {
+				continue
+			}
+			sess.Log().Notice("source changed, rebuilding", slog.Int("changes", len(changes)))
+			_ = buildAndReport(sess, b)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package wasm provides addon which compiles Go package to WebAssembly
+// for js/wasm and wasip1/wasm, copies wasm_exec.js next to js builds
+// and optionally makes output directory embeddable Go package.
+package wasm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/validate"
+	"github.com/happy-sdk/happy/sdk/addon"
+)
+
+var Error = errors.New("wasm")
+
+const (
+	// TargetJS builds for browsers and Node.js, GOOS=js.
+	TargetJS = "js"
+	// TargetWASI builds for WASI runtimes, GOOS=wasip1.
+	TargetWASI = "wasip1"
+)
+
+type Settings struct {
+	Package      settings.String      `key:"package,save" default:"./wasm" desc:"Package compiled to WebAssembly, relative to project root"`
+	Targets      settings.StringSlice `key:"targets,save" default:"js" desc:"Targets to build: js, wasip1 or both"`
+	Output       settings.String      `key:"output,save" default:"dist/wasm" desc:"Output directory relative to project root"`
+	Name         settings.String      `key:"name,save" default:"main" desc:"Base name of wasm binaries"`
+	Tags         settings.StringSlice `key:"tags,save" default:"" desc:"Build tags"`
+	EmbedPackage settings.String      `key:"embed_package,save" default:"" desc:"When set output directory is made Go package with this name embedding built assets"`
+	Watch        settings.StringSlice `key:"watch,save" default:"**/*.go|go.mod" desc:"Patterns of files which trigger rebuild in watch mode"`
+	Ignore       settings.StringSlice `key:"ignore,save" default:".git|**/node_modules" desc:"Patterns ignored in watch mode, output directory is always ignored"`
+}
+
+func (s Settings) Blueprint() (*settings.Blueprint, error) {
+	b, err := settings.New(s)
+	if err != nil {
+		return nil, err
+	}
+	b.AddValidator("targets", "", func(s settings.Setting) error {
+		if err := validate.Each("|", validate.OneOf(TargetJS, TargetWASI))(s.Value().String()); err != nil {
+			return fmt.Errorf("%w: target %w", settings.ErrSetting, err)
+		}
+		return nil
+	})
+	b.AddValidator("embed_package", "", func(s settings.Setting) error {
+		if name := s.Value().String(); name != "" && !isIdentifier(name) {
+			return fmt.Errorf("%w: %q is not valid package name", settings.ErrSetting, name)
+		}
+		return nil
+	})
+	return b, nil
+}
+
+// Addon returns wasm addon providing wasm command. Settings are
+// available under wasm.* keys.
+func Addon() *addon.Addon {
+	a := addon.New(addon.Config{
+		Name:     "Wasm",
+		Settings: Settings{},
+		Permissions: addon.Permissions{
+			Exec: []string{"go"},
+		},
+	})
+	a.ProvideCommands(Command())
+	return a
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, "|") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
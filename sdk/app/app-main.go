@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"runtime/debug"
 	"sync"
@@ -21,7 +22,11 @@ import (
 	"github.com/happy-sdk/happy/sdk/addon"
 	"github.com/happy-sdk/happy/sdk/app/internal/application"
 	"github.com/happy-sdk/happy/sdk/app/internal/initializer"
+	"github.com/happy-sdk/happy/sdk/app/session"
 	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/cli/help"
+	"github.com/happy-sdk/happy/sdk/datetime"
+	"github.com/happy-sdk/happy/sdk/doctor"
 	"github.com/happy-sdk/happy/sdk/logging"
 	"github.com/happy-sdk/happy/sdk/migration"
 	"github.com/happy-sdk/happy/sdk/services"
@@ -103,18 +108,90 @@ func (m *Main) BeforeAlways(a action.WithArgs) *Main {
 	return m
 }
 
-// Run starts the Application.
+// Run starts the Application. Process exits with application exit
+// code when application is done, unless exit func is set with WithExitFunc.
 func (m *Main) Run() {
+	exitCh, ok := m.start()
+	if !ok {
+		return
+	}
+	osmain(exitCh)
+}
+
+// RunCtx starts the Application and blocks until it exits or ctx is
+// done. Unlike Run it never calls os.Exit, so it is safe to embed happy
+// application inside other programs such as tests, servers or plugins.
+// When ctx is done application session is destroyed and RunCtx waits
+// for shutdown to complete. It returns nil when application exits with
+// code 0, ctx error when ctx was done or *ExitError otherwise.
+func (m *Main) RunCtx(ctx context.Context) error {
+	m.mu.Lock()
+	if m.booted {
+		m.mu.Unlock()
+		return fmt.Errorf("%w: application already booted", Error)
+	}
+	m.rt.Embed()
+	m.mu.Unlock()
+
+	exitCh, ok := m.start()
+	if ok {
+		select {
+		case <-exitCh:
+		case <-ctx.Done():
+			m.rt.Cancel(ctx.Err())
+			<-exitCh
+		}
+	}
+
+	code := m.rt.ExitCode()
+	if code == 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return &ExitError{Code: code}
+}
+
+// OnExit adds function called with exit code when application exits,
+// after After actions of command and before addons are shut down.
+// Exit functions are called in reverse order of registration, exit
+// functions of addons are called before exit functions added by OnExit.
+// Error returned by exit function sets exit code to 1.
+func (m *Main) OnExit(fn action.Exit) *Main {
+	if !m.canConfigure("adding exit func") {
+		return m
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rt.OnExit(fn)
+	return m
+}
+
+// WithExitFunc sets function called with exit code instead of os.Exit
+// when application exits.
+func (m *Main) WithExitFunc(exit func(code int)) *Main {
+	if !m.canConfigure("setting exit func") {
+		return m
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rt.SetExitTrap(exit)
+	return m
+}
+
+// start configures and starts the runtime, it returns channel
+// receiving when runtime exits and false when start failed.
+func (m *Main) start() (exitCh <-chan application.ShutDown, ok bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if m.booted {
-		m.log.LogDepth(1, logging.LevelWarn, "application already booted")
-		m.mu.Unlock()
-		return
+		m.log.LogDepth(2, logging.LevelWarn, "application already booted")
+		return nil, false
 	}
 	m.booted = true
-	m.log.LogDepth(1, logging.LevelDebug, "preparing runtime")
+	m.log.LogDepth(2, logging.LevelDebug, "preparing runtime")
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -131,20 +208,25 @@ func (m *Main) Run() {
 			m.log.LogDepth(1, logging.LevelBUG, "panic (recovered)", slog.String("msg", errMessage))
 			fmt.Println(stackTrace)
 			m.rt.Exit(1)
+			exitCh, ok = nil, false
 		}
 	}()
 
 	if m.init == nil {
 		m.log.BUG("initializer is nil, not set correctly")
-		return
+		return nil, false
 	}
 
 	if err := m.init.Configure(); err != nil {
 		if errors.Is(err, initializer.ErrExitWithSuccess) {
 			m.rt.Exit(0)
-			return
+			return nil, false
+		}
+		if m.init.ShowUsage(err) {
+			m.log.Error(err.Error())
+		} else {
+			m.log.Error("app configuration failed", slog.String("error", err.Error()))
 		}
-		m.log.Error("app configuration failed", slog.String("error", err.Error()))
 		{
 			// rare case where logger is not available, then use slog
 			// to consume the log queue if it is not already consumed.
@@ -157,7 +239,7 @@ func (m *Main) Run() {
 		}
 
 		m.rt.Exit(1)
-		return
+		return nil, false
 	}
 
 	// dispose the initializer
@@ -165,17 +247,25 @@ func (m *Main) Run() {
 		if err := m.init.Finalize(); err != nil {
 			m.log.Error("disposing initializer failed", slog.String("error", err.Error()))
 			m.rt.Exit(1)
-			return
+			return nil, false
 		}
 		m.init = nil
 	}
 
-	exitCh := m.rt.ExitCh()
+	exitCh = m.rt.ExitCh()
 
 	go func() {
 		m.rt.Start()
 	}()
-	osmain(exitCh)
+	return exitCh, true
+}
+
+// Restart performs clean shutdown of the application and execs the
+// binary again, e.g. after self-update. Listeners opened with
+// listener.Listen are handed over to the new process so that daemon
+// apps can be upgraded without downtime.
+func (m *Main) Restart(sess *session.Context) error {
+	return m.rt.Restart(sess)
 }
 
 func (m *Main) Do(a action.WithArgs) *Main {
@@ -197,6 +287,16 @@ func (m *Main) SetOptions(a ...options.Arg) *Main {
 }
 
 func (m *Main) Tick(a action.Tick) *Main {
+	return m.TickContext(action.ContextTick(a))
+}
+
+func (m *Main) Tock(a action.Tock) *Main {
+	return m.TockContext(action.ContextTock(a))
+}
+
+// TickContext sets Tick action receiving context which is cancelled
+// when engine stops and when tick exceeds app.engine.throttle_ticks.
+func (m *Main) TickContext(a action.TickContext) *Main {
 	if m.canConfigure("setting Tick action") {
 		m.mu.Lock()
 		defer m.mu.Unlock()
@@ -205,8 +305,9 @@ func (m *Main) Tick(a action.Tick) *Main {
 	return m
 }
 
-func (m *Main) Tock(a action.Tock) *Main {
-	if m.canConfigure("setting Tick action") {
+// TockContext sets Tock action receiving context, see TickContext.
+func (m *Main) TockContext(a action.TockContext) *Main {
+	if m.canConfigure("setting Tock action") {
 		m.mu.Lock()
 		defer m.mu.Unlock()
 		m.init.MainTock(a)
@@ -238,6 +339,58 @@ func (m *Main) WithCommands(cmd ...*command.Command) *Main {
 	return m
 }
 
+// WithCommandFunc adds command which is created by fn only when it is
+// needed, e.g. when it is named in command line arguments or listed in
+// help. Use it for CLIs with large number of (generated) commands.
+// Command returned by fn must have given name.
+func (m *Main) WithCommandFunc(name string, fn func() *command.Command) *Main {
+	if m.canConfigure("add subcommands") {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.init.MainAddCommandFunc(name, fn)
+	}
+	return m
+}
+
+// WithDoctorChecks adds application checks to the doctor command.
+func (m *Main) WithDoctorChecks(checks ...doctor.Check) *Main {
+	if m.canConfigure("adding doctor checks") {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.init.WithDoctorChecks(checks)
+	}
+	return m
+}
+
+// WithHelpTopic adds Markdown help topic rendered with "help <name>"
+// command, use WithHelpTopics to provide translated topics.
+func (m *Main) WithHelpTopic(name, content string) *Main {
+	return m.WithHelpTopics(help.NewTopic(name, content))
+}
+
+// WithHelpTopics adds help topics rendered with "help <name>" command.
+func (m *Main) WithHelpTopics(topics ...*help.Topic) *Main {
+	if m.canConfigure("adding help topics") {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.init.WithHelpTopics(topics)
+	}
+	return m
+}
+
+// WithEnv declares environment variables which are set for the duration
+// of command execution and restored afterwards. Entry "KEY=value" sets
+// and "KEY" clears variable. Command specific variables can be declared
+// with command.Config.Env and are applied after these.
+func (m *Main) WithEnv(env ...string) *Main {
+	if m.canConfigure("setting environment") {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.init.WithEnv(env)
+	}
+	return m
+}
+
 func (m *Main) WithFlags(ffns ...varflag.FlagCreateFunc) *Main {
 	if m.canConfigure("adding flags") {
 		m.mu.Lock()
@@ -247,6 +400,30 @@ func (m *Main) WithFlags(ffns ...varflag.FlagCreateFunc) *Main {
 	return m
 }
 
+// WithClock sets clock used by session, engine, cron jobs and logger.
+// It is mainly useful in tests together with datetime.FakeClock.
+func (m *Main) WithClock(clock datetime.Clock) *Main {
+	if m.canConfigure("setting clock") {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.init.SetClock(clock)
+	}
+	return m
+}
+
+// WithDefaultProfile sets default profile compiled into binary e.g.
+// with embed.FS. Profile is TOML document, on first run it seeds
+// preferences of default profile and it is copied as is with comments
+// to config directory as documented reference of default preferences.
+func (m *Main) WithDefaultProfile(f fs.File) *Main {
+	if m.canConfigure("setting default profile") {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.init.MainWithDefaultProfile(f)
+	}
+	return m
+}
+
 func (m *Main) WithLogger(logger logging.Logger) *Main {
 	if m.canConfigure("setting logger") {
 		m.mu.Lock()
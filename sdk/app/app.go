@@ -6,8 +6,23 @@ package app
 
 import (
 	"errors"
+	"fmt"
 )
 
 var (
 	Error = errors.New("app")
 )
+
+// ExitError is returned by Main.RunCtx when application
+// exits with non zero exit code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("%s: exit code %d", Error.Error(), e.Code)
+}
+
+func (e *ExitError) Unwrap() error {
+	return Error
+}
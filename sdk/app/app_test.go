@@ -5,12 +5,25 @@
 package app_test
 
 import (
+	"context"
+	"errors"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/happy-sdk/happy"
 	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/addon"
 	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/engine"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
 	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
 )
 
 func TestNew(t *testing.T) {
@@ -19,3 +32,358 @@ func TestNew(t *testing.T) {
 	app.WithLogger(log)
 	testutils.NotNil(t, app, "app must never be nil")
 }
+
+func TestRunCtx(t *testing.T) {
+	log := logging.NewTestLogger(logging.LevelError)
+	main := app.New(happy.Settings{Slug: "happy-run-ctx-test"})
+	main.WithLogger(log)
+	called := false
+	main.Do(func(sess *session.Context, args action.Args) error {
+		called = true
+		return nil
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+	testutils.True(t, called, "Do action must be called")
+}
+
+func TestDeterministicTick(t *testing.T) {
+	log := logging.NewTestLogger(logging.LevelError)
+	main := app.New(happy.Settings{
+		Slug: "happy-deterministic-tick-test",
+		Engine: engine.Settings{
+			Deterministic: true,
+		},
+	})
+	main.WithLogger(log)
+
+	ticks := make(chan time.Time, 1)
+	main.Tick(func(sess *session.Context, ts time.Time, delta time.Duration) error {
+		select {
+		case ticks <- ts:
+		default:
+		}
+		return nil
+	})
+
+	var (
+		start   time.Time
+		tickAt  time.Time
+		sessNow time.Time
+	)
+	main.Do(func(sess *session.Context, args action.Args) error {
+		fake := sess.Time().Fake()
+		if fake == nil {
+			return errors.New("deterministic session must use fake clock")
+		}
+		start = fake.Now()
+		// engine loop ticker is the only timer of the fake clock, wait
+		// until it is created so that advancing fires it.
+		fake.BlockUntil(1)
+		fake.Advance(time.Second)
+		tickAt = <-ticks
+		sessNow = sess.Time().Now()
+		return nil
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+	testutils.True(t, tickAt.After(start), "tick time must be driven by fake clock")
+	testutils.True(t, tickAt.Equal(sessNow), "session time must follow engine clock")
+}
+
+func TestManualEngine(t *testing.T) {
+	log := logging.NewTestLogger(logging.LevelError)
+	main := app.New(happy.Settings{
+		Slug: "happy-manual-engine-test",
+		Engine: engine.Settings{
+			Mode: engine.ModeManual,
+		},
+	})
+	main.WithLogger(log)
+
+	var (
+		ticks  int
+		tocks  int
+		deltas []time.Duration
+	)
+	main.Tick(func(sess *session.Context, ts time.Time, delta time.Duration) error {
+		ticks++
+		deltas = append(deltas, delta)
+		return nil
+	})
+	main.Tock(func(sess *session.Context, delta time.Duration, tps int) error {
+		tocks++
+		return nil
+	})
+
+	main.Do(func(sess *session.Context, args action.Args) error {
+		driver, ok := sess.Engine()
+		if !ok {
+			return errors.New("manual engine must provide driver")
+		}
+		if driver.Due() != nil {
+			return errors.New("manual engine must not signal due frames")
+		}
+		if err := driver.Step(); err != nil {
+			return err
+		}
+		start := sess.Time().Now()
+		if err := driver.StepAt(start.Add(time.Second)); err != nil {
+			return err
+		}
+		return driver.StepAt(start.Add(3 * time.Second))
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+	testutils.Equal(t, 3, ticks)
+	testutils.Equal(t, 3, tocks)
+	testutils.Equal(t, 2*time.Second, deltas[2])
+}
+
+func TestExitOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		failSuccess bool
+		want        string
+		wantErr     bool
+	}{
+		{
+			name: "success",
+			want: "parent.before,child.before,child.do," +
+				"child.success,parent.success,child.always(<nil>),parent.always(<nil>)," +
+				"a.exit,b.exit,main.exit2,main.exit1",
+		},
+		{
+			name:        "after success failed",
+			failSuccess: true,
+			want: "parent.before,child.before,child.do," +
+				"child.success,parent.failure(child failed),child.always(child failed),parent.always(child failed)," +
+				"a.exit,b.exit,main.exit2,main.exit1",
+			wantErr: true,
+		},
+	}
+	defer func(args []string) { os.Args = args }(os.Args)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called []string
+			record := func(name string) { called = append(called, name) }
+			exit := func(name string) action.Exit {
+				return func(sess *session.Context, code int) error {
+					record(name)
+					return nil
+				}
+			}
+			always := func(name string) action.WithPrevErr {
+				return func(sess *session.Context, err error) error {
+					record(name + ".always(" + errString(err) + ")")
+					return nil
+				}
+			}
+
+			child := command.New(command.Config{Name: "child"}).
+				Before(func(sess *session.Context, args action.Args) error {
+					record("child.before")
+					return nil
+				}).
+				Do(func(sess *session.Context, args action.Args) error {
+					record("child.do")
+					return nil
+				}).
+				AfterSuccess(func(sess *session.Context) error {
+					record("child.success")
+					if tt.failSuccess {
+						return errors.New("child failed")
+					}
+					return nil
+				}).
+				AfterAlways(always("child"))
+			parent := command.New(command.Config{Name: "parent", SharedBeforeAction: true}).
+				Before(func(sess *session.Context, args action.Args) error {
+					record("parent.before")
+					return nil
+				}).
+				AfterSuccess(func(sess *session.Context) error {
+					record("parent.success")
+					return nil
+				}).
+				AfterFailure(func(sess *session.Context, err error) error {
+					record("parent.failure(" + errString(err) + ")")
+					return nil
+				}).
+				AfterAlways(always("parent")).
+				WithSubCommands(child)
+
+			a := addon.New(addon.Config{Name: "a", DependsOn: []string{"b"}})
+			a.OnExit(exit("a.exit"))
+			b := addon.New(addon.Config{Name: "b"})
+			b.OnExit(exit("b.exit"))
+
+			main := app.New(happy.Settings{Slug: "happy-exit-order-test"})
+			main.WithLogger(logging.NewTestLogger(logging.LevelQuiet))
+			main.WithAddon(b)
+			main.WithAddon(a)
+			main.WithCommands(parent)
+			main.OnExit(exit("main.exit1"))
+			main.OnExit(exit("main.exit2"))
+
+			os.Args = []string{"happy-exit-order-test", "parent", "child"}
+			err := main.RunCtx(context.Background())
+			if tt.wantErr {
+				testutils.Error(t, err)
+			} else {
+				testutils.NoError(t, err)
+			}
+			testutils.Equal(t, tt.want, strings.Join(called, ","))
+		})
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	return err.Error()
+}
+
+func TestBeforeFailure(t *testing.T) {
+	errBefore := errors.New("before failed")
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"happy-before-failure-test"}
+
+	var (
+		failure  error
+		doCalled bool
+	)
+	main := app.New(happy.Settings{Slug: "happy-before-failure-test"})
+	main.WithLogger(logging.NewTestLogger(logging.LevelQuiet))
+	main.Before(func(sess *session.Context, args action.Args) error {
+		return errBefore
+	})
+	main.Do(func(sess *session.Context, args action.Args) error {
+		doCalled = true
+		return nil
+	})
+	main.AfterFailure(func(sess *session.Context, err error) error {
+		failure = err
+		return nil
+	})
+	testutils.Error(t, main.RunCtx(context.Background()))
+	testutils.False(t, doCalled, "Do must not be called")
+
+	var gerr *action.GroupError
+	if !testutils.True(t, errors.As(failure, &gerr), "AfterFailure must receive *action.GroupError") {
+		return
+	}
+	testutils.ErrorIs(t, gerr.Cause(), errBefore)
+	testutils.Equal(t, 1, len(gerr.Failed()), "failed steps")
+	testutils.Equal(t, "before", gerr.Failed()[0].Name)
+}
+
+func TestPanicBoundaries(t *testing.T) {
+	tests := []struct {
+		name   string
+		engine engine.Settings
+		before action.WithArgs
+		do     action.WithArgs
+		tick   action.Tick
+	}{
+		{
+			name: "before",
+			before: func(sess *session.Context, args action.Args) error {
+				panic("before")
+			},
+			do: func(sess *session.Context, args action.Args) error {
+				return nil
+			},
+		},
+		{
+			name: "do",
+			do: func(sess *session.Context, args action.Args) error {
+				panic("do")
+			},
+		},
+		{
+			name:   "tick",
+			engine: engine.Settings{Mode: engine.ModeManual},
+			tick: func(sess *session.Context, ts time.Time, delta time.Duration) error {
+				panic("tick")
+			},
+			do: func(sess *session.Context, args action.Args) error {
+				driver, ok := sess.Engine()
+				if !ok {
+					return errors.New("manual engine must provide driver")
+				}
+				return driver.Step()
+			},
+		},
+	}
+	defer func(args []string) { os.Args = args }(os.Args)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Args = []string{"happy-panic-test"}
+			main := app.New(happy.Settings{Slug: "happy-panic-test", Engine: tt.engine})
+			main.WithLogger(logging.NewTestLogger(logging.LevelQuiet))
+			if tt.before != nil {
+				main.Before(tt.before)
+			}
+			if tt.tick != nil {
+				main.Tick(tt.tick)
+			}
+			if tt.do != nil {
+				main.Do(tt.do)
+			}
+			var failure error
+			main.AfterFailure(func(sess *session.Context, err error) error {
+				failure = err
+				return nil
+			})
+			testutils.Error(t, main.RunCtx(context.Background()))
+
+			var perr *action.PanicError
+			if !testutils.True(t, errors.As(failure, &perr), "AfterFailure must receive *action.PanicError") {
+				return
+			}
+			testutils.ErrorIs(t, failure, action.ErrPanic)
+			testutils.EqualAny(t, tt.name, perr.Value)
+			testutils.True(t, strings.Contains(string(perr.Stack), "app_test.go"), "stack must include panicking action")
+		})
+	}
+}
+
+func TestBeforeCanceledByServices(t *testing.T) {
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"happy-before-canceled-test", "run"}
+
+	errStart := errors.New("start failed")
+	svc := services.New(service.Config{Name: "failing"})
+	svc.OnStart(func(sess *session.Context) error {
+		return errStart
+	})
+
+	var (
+		beforeErr error
+		doCalled  bool
+	)
+	run := command.New(command.Config{
+		Name:             "run",
+		RequiresServices: settings.StringSlice{"failing"},
+	}).
+		Before(func(sess *session.Context, args action.Args) error {
+			select {
+			case <-sess.Done():
+				beforeErr = sess.Err()
+			case <-time.After(10 * time.Second):
+				beforeErr = errors.New("before was not canceled")
+			}
+			return beforeErr
+		}).
+		Do(func(sess *session.Context, args action.Args) error {
+			doCalled = true
+			return nil
+		})
+
+	main := app.New(happy.Settings{Slug: "happy-before-canceled-test"})
+	main.WithLogger(logging.NewTestLogger(logging.LevelQuiet))
+	main.WithServices(svc)
+	main.WithCommands(run)
+	testutils.Error(t, main.RunCtx(context.Background()))
+	testutils.False(t, doCalled, "Do must not be called")
+	testutils.ErrorIs(t, beforeErr, errStart, "before session must be destroyed with service error")
+}
@@ -0,0 +1,242 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/datetime"
+	"github.com/happy-sdk/happy/sdk/events"
+	"github.com/happy-sdk/happy/sdk/stats"
+)
+
+const (
+	// ModeTicker runs engine loop with internal ticker.
+	ModeTicker = "ticker"
+	// ModeManual does not tick, application drives engine loop by
+	// calling Step of session engine driver.
+	ModeManual = "manual"
+	// ModeExternal integrates engine loop with external loop e.g. of UI
+	// framework which must run frames on its own goroutine. Internal
+	// ticker only signals Due channel of session engine driver and the
+	// external loop calls Step.
+	ModeExternal = "external"
+)
+
+// ErrNotRunning is returned by Driver when engine loop is not running.
+var ErrNotRunning = fmt.Errorf("%w: engine loop is not running", Error)
+
+// Driver drives engine loop in manual and external mode, it is
+// provided to application by sess.Engine.
+type Driver struct {
+	mu   sync.RWMutex
+	loop *loop
+	due  chan time.Time
+	// ready is session ready channel, attached is closed once engine
+	// loop attached to driver or exited before it was started.
+	ready    <-chan struct{}
+	attached chan struct{}
+	once     sync.Once
+}
+
+func newDriver(sess *session.Context, mode string) *Driver {
+	d := &Driver{
+		ready:    sess.Ready(),
+		attached: make(chan struct{}),
+	}
+	if mode == ModeExternal {
+		d.due = make(chan time.Time, 1)
+	}
+	return d
+}
+
+// Step runs single frame, tick and tock actions, at current session
+// time. It must not be called from tick or tock action. When action
+// fails engine loop is stopped and error is returned by all following
+// calls.
+func (d *Driver) Step() error {
+	l, err := d.running()
+	if err != nil {
+		return err
+	}
+	return l.step(l.clock.Now())
+}
+
+// StepAt runs single frame at now, delta passed to tick action is
+// duration since previous frame.
+func (d *Driver) StepAt(now time.Time) error {
+	l, err := d.running()
+	if err != nil {
+		return err
+	}
+	return l.step(now)
+}
+
+// Due returns channel receiving time when frame is due in external
+// mode, in manual mode it returns nil channel.
+func (d *Driver) Due() <-chan time.Time {
+	return d.due
+}
+
+func (d *Driver) running() (*loop, error) {
+	select {
+	case <-d.attached:
+	case <-d.ready:
+		// loop is attached as soon as session is ready.
+		<-d.attached
+	default:
+		return nil, ErrNotRunning
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.loop == nil {
+		return nil, ErrNotRunning
+	}
+	return d.loop, nil
+}
+
+// attach attaches running loop to driver, nil detaches it.
+func (d *Driver) attach(l *loop) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.loop = l
+	d.once.Do(func() { close(d.attached) })
+}
+
+// signal notifies external loop that frame is due, frame time is
+// dropped when previous one was not consumed yet.
+func (d *Driver) signal(now time.Time) {
+	select {
+	case d.due <- now:
+	default:
+	}
+}
+
+// tpsSize is size of the tick delta window used to compute tps.
+const tpsSize = 120
+
+// loop runs engine frames, it is shared by internal ticker and Driver.
+type loop struct {
+	mu sync.Mutex
+	// ctx is parent of contexts passed to tick and tock, it is
+	// cancelled when engine stops.
+	ctx    context.Context
+	budget time.Duration
+	e      *Engine
+	sess   *session.Context
+	clock  datetime.Clock
+
+	lastTick time.Time
+	err      error
+
+	tps          int
+	tpsEnabled   bool
+	tpsSeries    *stats.Series
+	tpsSampledAt time.Time
+	tickDeltas   [tpsSize]time.Duration
+	tdi          int           // tick delta index
+	tds          time.Duration // tick delta sum
+
+	meter *frameMeter
+}
+
+func newLoop(ctx context.Context, e *Engine, sess *session.Context, clock datetime.Clock, throttle time.Duration) *loop {
+	l := &loop{
+		ctx:        ctx,
+		budget:     throttle,
+		e:          e,
+		sess:       sess,
+		clock:      clock,
+		lastTick:   clock.Now(),
+		tpsEnabled: throttle < time.Second,
+	}
+	if l.tpsEnabled {
+		l.tpsSeries = e.stats.Series(TPSSeries, 0)
+		for i := 0; i < tpsSize; i++ {
+			l.tickDeltas[i] = throttle
+			l.tds += throttle
+		}
+	}
+	l.meter = newFrameMeter(throttle, l.lastTick)
+	return l
+}
+
+// step runs tick and tock of frame starting at now.
+func (l *loop) step(now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return l.err
+	}
+	sess := l.sess
+	started := l.clock.Now()
+	delta := now.Sub(l.lastTick)
+	l.lastTick = now
+	if err := l.try(func(ctx context.Context) error { return l.e.tick(ctx, sess, now, delta) }); err != nil {
+		sess.Log().Error("engine tick error", slog.String("err", err.Error()))
+		sess.Dispatch(events.New("engine", "tick.error").Create(err, nil))
+		l.err = fmt.Errorf("%w: tick: %w", ErrNotRunning, err)
+		return l.err
+	}
+
+	if l.tpsEnabled {
+		// Update the sliding window of frame times
+		otd := l.tickDeltas[l.tdi] // oldest tick delta
+		l.tickDeltas[l.tdi] = delta
+		l.tds += delta - otd
+		l.tdi = (l.tdi + 1) % tpsSize
+		if atd := l.tds / tpsSize; atd > 0 { // average tick delta
+			l.tps = int(math.Round(float64(time.Second) / float64(atd)))
+		}
+		// sample tps once per second so that series covers minutes
+		if now.Sub(l.tpsSampledAt) >= time.Second {
+			l.tpsSeries.Push(now, float64(l.tps))
+			l.tpsSampledAt = now
+		}
+	}
+
+	tickDelta := l.clock.Since(started)
+	if err := l.try(func(ctx context.Context) error { return l.e.tock(ctx, sess, tickDelta, l.tps) }); err != nil {
+		sess.Log().Error("tock error", slog.String("err", err.Error()))
+		sess.Dispatch(events.New("engine", "tock.error").Create(err, nil))
+		l.err = fmt.Errorf("%w: tock: %w", ErrNotRunning, err)
+		return l.err
+	}
+	l.meter.record(delta, l.clock.Since(started))
+	l.e.reportFrames(sess, l.meter, now)
+	return nil
+}
+
+// try runs fn with context which deadline is frame budget.
+func (l *loop) try(fn func(ctx context.Context) error) error {
+	ctx, cancel := l.frameContext()
+	defer cancel()
+	return action.Try(func() error { return fn(ctx) })
+}
+
+// frameContext returns context of tick or tock, it is cancelled when
+// engine loop stops or invocation exceeds frame budget.
+func (l *loop) frameContext() (context.Context, context.CancelFunc) {
+	if l.budget > 0 {
+		return context.WithTimeout(l.ctx, l.budget)
+	}
+	return context.WithCancel(l.ctx)
+}
+
+// stop makes following steps fail with ErrNotRunning.
+func (l *loop) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err == nil {
+		l.err = ErrNotRunning
+	}
+}
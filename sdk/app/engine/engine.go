@@ -18,6 +18,7 @@ import (
 	"github.com/happy-sdk/happy/pkg/vars"
 	"github.com/happy-sdk/happy/sdk/action"
 	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/datetime"
 	"github.com/happy-sdk/happy/sdk/events"
 	"github.com/happy-sdk/happy/sdk/internal"
 	"github.com/happy-sdk/happy/sdk/logging"
@@ -29,8 +30,23 @@ import (
 
 var Error = fmt.Errorf("engine error")
 
+// TPSSeries is name of stats series of engine ticks per second, it is
+// sampled once per second when engine ticks faster than once a second.
+const TPSSeries = "engine.tps"
+
 type Settings struct {
 	ThrottleTicks settings.Duration `key:"throttle_ticks,save" default:"1s" mutation:"once" desc:"Throttle engine ticks duration"`
+	// Mode selects what drives engine loop, see ModeTicker, ModeManual
+	// and ModeExternal. In manual and external mode application drives
+	// frames with sess.Engine and throttle_ticks is frame budget.
+	Mode settings.String `key:"mode" default:"ticker" mutation:"once" desc:"Engine loop mode: ticker, manual or external"`
+	// Deterministic drives session clock and so ticks, tocks and cron
+	// jobs with fake clock which only moves when sess.Time().Fake().Advance
+	// is called, intended for tests.
+	Deterministic settings.Bool `key:"deterministic" default:"false" mutation:"once" desc:"Drive session and engine with fake clock advanced by sess.Time().Fake().Advance"`
+	// BlockedStartup is policy applied when Before action or service
+	// start exceeds app.services.loader_timeout, see Watch.
+	BlockedStartup settings.String `key:"blocked_startup,save" default:"continue" mutation:"once" desc:"Policy when startup is blocked longer than loader timeout: continue or fail"`
 }
 
 func (s Settings) Blueprint() (*settings.Blueprint, error) {
@@ -38,9 +54,32 @@ func (s Settings) Blueprint() (*settings.Blueprint, error) {
 	if err != nil {
 		return nil, err
 	}
+	b.AddValidator("blocked_startup", "", func(s settings.Setting) error {
+		switch s.Value().String() {
+		case BlockedStartupContinue, BlockedStartupFail:
+			return nil
+		}
+		return fmt.Errorf("%w: blocked_startup must be %s or %s", settings.ErrSetting, BlockedStartupContinue, BlockedStartupFail)
+	})
+	b.AddValidator("mode", "", func(s settings.Setting) error {
+		switch s.Value().String() {
+		case ModeTicker, ModeManual, ModeExternal:
+			return nil
+		}
+		return fmt.Errorf("%w: mode must be %s, %s or %s", settings.ErrSetting, ModeTicker, ModeManual, ModeExternal)
+	})
 	return b, nil
 }
 
+// Builtin is optional service provided by the SDK. Engine registers it
+// on start when Enabled reports true and waits until it is loaded.
+type Builtin struct {
+	// Name is name of the service as created by Service.
+	Name    string
+	Enabled func(sess *session.Context) bool
+	Service func(sess *session.Context, prof *stats.Profiler) *services.Service
+}
+
 type engineState int
 
 const (
@@ -76,8 +115,8 @@ type Engine struct {
 	state         engineState
 	engineOK      bool
 
-	tick action.Tick
-	tock action.Tock
+	tick action.TickContext
+	tock action.TockContext
 
 	engineLoopCancel context.CancelFunc
 	engineLoopCtx    context.Context
@@ -90,12 +129,18 @@ type Engine struct {
 	gsd                  *gracefulShutdown
 
 	registry map[string]*services.Container
+	builtins []Builtin
+
+	clock datetime.Clock
 
 	stats *stats.Profiler
 	errs  []error
+
+	childrenActive int
+	childrenTotal  int
 }
 
-func New(evch <-chan events.Event, tick action.Tick, tock action.Tock) *Engine {
+func New(evch <-chan events.Event, tick action.TickContext, tock action.TockContext) *Engine {
 	e := &Engine{
 		tick:     tick,
 		tock:     tock,
@@ -103,6 +148,7 @@ func New(evch <-chan events.Event, tick action.Tick, tock action.Tock) *Engine {
 		events:   make(map[string]bool),
 		registry: make(map[string]*services.Container),
 		gsd:      newGracefulShutdown(),
+		clock:    datetime.SystemClock(),
 		stats:    stats.New("app-stats"),
 	}
 
@@ -110,6 +156,7 @@ func New(evch <-chan events.Event, tick action.Tick, tock action.Tock) *Engine {
 
 	var sysevs = []events.Event{
 		services.StartEvent,
+		services.StopEvent,
 		service.StartedEvent,
 		service.StoppedEvent,
 	}
@@ -123,6 +170,23 @@ func New(evch <-chan events.Event, tick action.Tick, tock action.Tock) *Engine {
 	return e
 }
 
+// AddBuiltin adds optional services provided by the SDK, engine
+// registers and loads enabled ones when it starts.
+func (e *Engine) AddBuiltin(builtins ...Builtin) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state != engineInit {
+		return fmt.Errorf("%w: can not add builtin services when engine is %s", Error, e.state.String())
+	}
+	for _, b := range builtins {
+		if b.Name == "" || b.Enabled == nil || b.Service == nil {
+			return fmt.Errorf("%w: invalid builtin service %q", Error, b.Name)
+		}
+	}
+	e.builtins = append(e.builtins, builtins...)
+	return nil
+}
+
 func (e *Engine) Start(sess *session.Context) error {
 	e.mu.RLock()
 	state := e.state
@@ -137,16 +201,29 @@ func (e *Engine) Start(sess *session.Context) error {
 	e.state = engineStarting
 	tick := e.tick
 	tock := e.tock
+	// engine shares session clock so that services reading sess.Time()
+	// observe same time as ticks and cron jobs.
+	e.clock = sess.Time()
+	e.stats.SetClock(e.clock)
 	e.mu.Unlock()
 
+	session.AttachChildObserver(sess, e.trackChild)
+
 	if tick == nil && tock != nil {
 		return fmt.Errorf("%w: register tick action or move tock logic into tick action", Error)
 	}
-	if sess.Get("app.stats.enabled").Bool() {
-		e.mu.Lock()
-		statsSvc := stats.AsService(e.stats)
-		e.mu.Unlock()
-		if err := e.RegisterService(sess, statsSvc); err != nil {
+
+	e.mu.Lock()
+	var builtins []Builtin
+	for _, b := range e.builtins {
+		if b.Enabled(sess) {
+			builtins = append(builtins, b)
+		}
+	}
+	prof := e.stats
+	e.mu.Unlock()
+	for _, b := range builtins {
+		if err := e.RegisterService(sess, b.Service(sess, prof)); err != nil {
 			return err
 		}
 	}
@@ -183,8 +260,8 @@ func (e *Engine) Start(sess *session.Context) error {
 		sess.Destroy(fmt.Errorf("%w: starting engine failed: state %s", Error, state.String()))
 	}
 
-	if sess.Get("app.stats.enabled").Bool() {
-		loader := services.NewLoader(sess, "app-runtime-stats")
+	for _, b := range builtins {
+		loader := services.NewLoader(sess, b.Name)
 		<-loader.Load()
 		if err := loader.Err(); err != nil {
 			return err
@@ -211,6 +288,11 @@ func (e *Engine) Stop(sess *session.Context) error {
 
 	internal.Log(sess.Log(), "stopping engine ...")
 
+	// child sessions depend on services, so they are destroyed first.
+	for _, child := range sess.Children() {
+		child.Destroy(nil)
+	}
+
 	e.engineLoopCancel()
 
 	for u, rsvc := range e.registry {
@@ -258,10 +340,25 @@ func (e *Engine) Stats() *stats.Profiler {
 	return stats
 }
 
+// trackChild records child session counts into engine stats.
+func (e *Engine) trackChild(child *session.Context, active bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if active {
+		e.childrenActive++
+		e.childrenTotal++
+	} else {
+		e.childrenActive--
+	}
+	_ = e.stats.Set("app.sessions.active", e.childrenActive)
+	_ = e.stats.Set("app.sessions.total", e.childrenTotal)
+}
+
 func (e *Engine) loopStart(sess *session.Context, init *sync.WaitGroup) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	e.engineLoopCtx, e.engineLoopCancel = context.WithCancel(sess)
+	clock := e.clock
 
 	if e.tick == nil && e.tock == nil {
 		internal.Log(sess.Log(), "engine loop skipped")
@@ -271,6 +368,13 @@ func (e *Engine) loopStart(sess *session.Context, init *sync.WaitGroup) {
 		e.tock = nooptock
 	}
 
+	mode := sess.Get("app.engine.mode").String()
+	var driver *Driver
+	if mode == ModeManual || mode == ModeExternal {
+		driver = newDriver(sess, mode)
+		session.AttachEngineDriver(sess, driver)
+	}
+
 	init.Add(2)
 	defer init.Done()
 
@@ -278,6 +382,9 @@ func (e *Engine) loopStart(sess *session.Context, init *sync.WaitGroup) {
 	go func() {
 
 		defer func() {
+			if driver != nil {
+				driver.attach(nil)
+			}
 			e.gsd.Done()
 
 			if r := recover(); r != nil {
@@ -323,55 +430,33 @@ func (e *Engine) loopStart(sess *session.Context, init *sync.WaitGroup) {
 		}
 
 		throttle := time.Duration(sess.Get("app.engine.throttle_ticks").Int64())
-		lastTick := sess.Time(time.Now())
-		ttick := time.NewTicker(throttle)
-		defer ttick.Stop()
+		l := newLoop(e.engineLoopCtx, e, sess, clock, throttle)
+		defer l.stop()
+		if driver != nil {
+			driver.attach(l)
+		}
+		internal.Log(sess.Log(), "engine loop started", slog.String("mode", mode))
 
-		tps := 0
-		tpsEnabled := throttle < time.Second
-		const tpsSize = 120 // size of the tick delta array
-		var tickDeltas [tpsSize]time.Duration
-		var tdi int           // tick delta index
-		var tds time.Duration // tick delta sum
-		if tpsEnabled {
-			initialDelta := throttle
-			for i := 0; i < tpsSize; i++ {
-				tickDeltas[i] = initialDelta
-				tds += initialDelta
-			}
+		if mode == ModeManual {
+			<-e.engineLoopCtx.Done()
+			internal.Log(sess.Log(), "engine loop stopped")
+			return
 		}
 
-		internal.Log(sess.Log(), "engine loop started")
+		ttick := clock.NewTicker(throttle)
+		defer ttick.Stop()
 
 	engineLoop:
 		for {
 			select {
 			case <-e.engineLoopCtx.Done():
 				break engineLoop
-			case now := <-ttick.C:
-				now = sess.Time(now)
-				delta := now.Sub(lastTick)
-				lastTick = now
-				if err := e.tick(sess, lastTick, delta); err != nil {
-					sess.Log().Error("engine tick error", slog.String("err", err.Error()))
-					sess.Dispatch(events.New("engine", "tick.error").Create(err, nil))
-					break engineLoop
-				}
-
-				if tpsEnabled {
-					// Update the sliding window of frame times
-					otd := tickDeltas[tdi] // oldest tick delta
-					tickDeltas[tdi] = delta
-					tds += delta - otd
-					tdi = (tdi + 1) % tpsSize
-					atd := tds / tpsSize // average tick delta
-					tps = int(math.Round(float64(time.Second) / float64(atd)))
+			case now := <-ttick.C():
+				if driver != nil {
+					driver.signal(now)
+					continue
 				}
-
-				tickDelta := time.Since(lastTick)
-				if err := e.tock(sess, tickDelta, tps); err != nil {
-					sess.Log().Error("tock error", slog.String("err", err.Error()))
-					sess.Dispatch(events.New("engine", "tock.error").Create(err, nil))
+				if err := l.step(now); err != nil {
 					break engineLoop
 				}
 			}
@@ -383,6 +468,7 @@ func (e *Engine) loopStart(sess *session.Context, init *sync.WaitGroup) {
 func (e *Engine) servicesInit(sess *session.Context, init *sync.WaitGroup) {
 	e.mu.Lock()
 	svccount := len(e.registry)
+	clock := e.clock
 	e.mu.Unlock()
 	if svccount == 0 {
 		internal.Log(sess.Log(), "no services to initialize ...")
@@ -395,6 +481,7 @@ func (e *Engine) servicesInit(sess *session.Context, init *sync.WaitGroup) {
 	for svcaddrstr, svcc := range e.registry {
 		go func(addr string, c *services.Container) {
 			defer init.Done()
+			c.SetClock(clock)
 			if err := c.Register(sess); err != nil {
 				sess.Log().Error(
 					"failed to initialize service",
@@ -462,6 +549,7 @@ func (e *Engine) handleEvent(sess *session.Context, ev events.Event) {
 	e.mu.RLock()
 	_, ok := e.events[skey]
 	registry := e.registry
+	state := e.state
 	e.mu.RUnlock()
 
 	if len(skey) == 1 || !ok {
@@ -479,7 +567,7 @@ func (e *Engine) handleEvent(sess *session.Context, ev events.Event) {
 	case "services":
 		switch ev.Key() {
 		case services.StartEvent.Key():
-			if e.state != engineRunning {
+			if state != engineRunning {
 				sess.Log().Warn("engine is not running, ignoring start.services event")
 				return
 			}
@@ -581,19 +669,28 @@ func (e *Engine) serviceStart(sess *session.Context, svcurl string) {
 		return
 	}
 
-	if err := svcc.Start(e.engineLoopCtx, sess); err != nil {
+	if err := Watch(sess, "service start "+svcurl, func() error {
+		return svcc.Start(e.engineLoopCtx, sess)
+	}); err != nil {
 		sess.Log().Error(
 			"failed to start service",
 			slog.String("err", err.Error()),
 			sarg,
 		)
-		if e.state == engineRunning && svcc.CanRetry() {
+		e.mu.RLock()
+		running := e.state == engineRunning
+		e.mu.RUnlock()
+		if running && svcc.CanRetry() {
 			sess.Log().Notice("retrying to start the service", sarg, slog.Int("retry", svcc.Retries()))
 			e.serviceStart(sess, svcurl)
 		}
 		return
 	}
 
+	e.mu.RLock()
+	clock := e.clock
+	e.mu.RUnlock()
+
 	go func(svcc *services.Container, svcurl string, sarg slog.Attr) {
 
 		if !svcc.HasTick() {
@@ -603,8 +700,8 @@ func (e *Engine) serviceStart(sess *session.Context, svcurl string) {
 		}
 
 		throttle := time.Duration(sess.Get("app.engine.throttle_ticks").Int64())
-		lastTick := sess.Time(time.Now())
-		ttick := time.NewTicker(throttle)
+		lastTick := clock.Now()
+		ttick := clock.NewTicker(throttle)
 		defer ttick.Stop()
 
 		tps := 0
@@ -627,8 +724,7 @@ func (e *Engine) serviceStart(sess *session.Context, svcurl string) {
 			case <-svcc.Done():
 				svcc.Cancel(nil)
 				break ticker
-			case now := <-ttick.C:
-				now = sess.Time(now)
+			case now := <-ttick.C():
 				delta := now.Sub(lastTick)
 				lastTick = now
 
@@ -647,7 +743,7 @@ func (e *Engine) serviceStart(sess *session.Context, svcurl string) {
 					tps = int(math.Round(float64(time.Second) / float64(atd)))
 				}
 
-				tickDelta := time.Since(lastTick)
+				tickDelta := clock.Since(lastTick)
 				if err := svcc.Tock(sess, tickDelta, tps); err != nil {
 					e.serviceStop(sess, svcurl, err)
 					break ticker
@@ -670,20 +766,23 @@ func (e *Engine) serviceStop(sess *session.Context, svcurl string, err error) {
 	internal.Log(sess.Log(), "stopping service", sarg)
 	if stoperr := svcc.Stop(sess, err); stoperr != nil {
 		sess.Log().Error("failed to stop service", slog.String("err", stoperr.Error()), sarg)
-	} else {
-		if e.state == engineRunning && svcc.CanRetry() {
-			if stoperr != nil {
-				sess.Log().Warn("retrying to skipped due service stop error", sarg)
-				return
-			}
-			sess.Log().Notice("retrying to start the service", sarg, slog.Int("retry", svcc.Retries()))
-			go e.serviceStart(sess, svcurl)
-		}
+		return
+	}
+	// only services stopped by error are retried, requested stop is final.
+	if err == nil {
+		return
+	}
+	e.mu.RLock()
+	running := e.state == engineRunning
+	e.mu.RUnlock()
+	if running && svcc.CanRetry() {
+		sess.Log().Notice("retrying to start the service", sarg, slog.Int("retry", svcc.Retries()))
+		go e.serviceStart(sess, svcurl)
 	}
 
 }
 
-var nooptock = func(*session.Context, time.Duration, int) error { return nil }
+var nooptock = func(context.Context, *session.Context, time.Duration, int) error { return nil }
 
 type gracefulShutdown struct {
 	wg sync.WaitGroup
@@ -3,3 +3,107 @@
 // Copyright © 2024 The Happy Authors
 
 package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/datetime"
+)
+
+func TestFrameMeter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := newFrameMeter(100*time.Millisecond, start)
+
+	m.record(110*time.Millisecond, 20*time.Millisecond)
+	m.record(90*time.Millisecond, 40*time.Millisecond)
+	_, ok := m.report(start.Add(500 * time.Millisecond))
+	testutils.False(t, ok, "report before interval")
+	_, _, _, ok = m.warning(start)
+	testutils.False(t, ok, "no frames over budget")
+
+	// frame took 250ms so ticker dropped two ticks
+	m.record(300*time.Millisecond, 250*time.Millisecond)
+	rep, ok := m.report(start.Add(time.Second))
+	testutils.True(t, ok)
+	testutils.Equal(t, frameReport{
+		Frames:          3,
+		AvgDuration:     310 * time.Millisecond / 3,
+		MaxDuration:     250 * time.Millisecond,
+		AvgJitter:       220 * time.Millisecond / 3,
+		MaxJitter:       200 * time.Millisecond,
+		TotalFrames:     3,
+		TotalOverBudget: 1,
+		TotalMissed:     2,
+	}, rep)
+
+	over, missed, longest, ok := m.warning(start.Add(time.Second))
+	testutils.True(t, ok)
+	testutils.Equal(t, uint64(1), over)
+	testutils.Equal(t, uint64(2), missed)
+	testutils.Equal(t, 250*time.Millisecond, longest)
+
+	// warnings are rate limited
+	m.record(100*time.Millisecond, 150*time.Millisecond)
+	_, _, _, ok = m.warning(start.Add(2 * time.Second))
+	testutils.False(t, ok)
+	over, _, _, ok = m.warning(start.Add(12 * time.Second))
+	testutils.True(t, ok)
+	testutils.Equal(t, uint64(1), over)
+}
+
+// trackingContext counts contexts derived from it which are not
+// cancelled yet, it has own Done channel so that derived contexts are
+// registered with AfterFunc.
+type trackingContext struct {
+	context.Context
+	done   chan struct{}
+	mu     sync.Mutex
+	active int
+}
+
+func (c *trackingContext) Done() <-chan struct{} { return c.done }
+
+func (c *trackingContext) AfterFunc(f func()) func() bool {
+	c.mu.Lock()
+	c.active++
+	c.mu.Unlock()
+	var once sync.Once
+	return func() bool {
+		once.Do(func() {
+			c.mu.Lock()
+			c.active--
+			c.mu.Unlock()
+		})
+		return true
+	}
+}
+
+func TestLoopReleasesFrameContexts(t *testing.T) {
+	for _, budget := range []time.Duration{0, time.Second} {
+		ctx := &trackingContext{Context: context.Background(), done: make(chan struct{})}
+		frames := 0
+		e := New(nil,
+			func(ctx context.Context, _ *session.Context, _ time.Time, _ time.Duration) error {
+				frames++
+				return nil
+			},
+			func(ctx context.Context, _ *session.Context, _ time.Duration, _ int) error {
+				return nil
+			})
+		clock := datetime.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		l := newLoop(ctx, e, nil, clock, budget)
+		for i := 0; i < 1000; i++ {
+			clock.Advance(time.Second)
+			testutils.NoError(t, l.step(clock.Now()))
+		}
+		testutils.Equal(t, 1000, frames)
+		ctx.mu.Lock()
+		testutils.Equal(t, 0, ctx.active, "frame contexts retained with budget %s", budget)
+		ctx.mu.Unlock()
+	}
+}
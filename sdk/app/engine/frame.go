@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package engine
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+const (
+	// FrameDurationSeries is stats series of average frame duration in
+	// milliseconds, frame is time spent in tick and tock actions.
+	FrameDurationSeries = "engine.frame.duration"
+	// FrameJitterSeries is stats series of average deviation of tick
+	// interval from app.engine.throttle_ticks in milliseconds.
+	FrameJitterSeries = "engine.frame.jitter"
+)
+
+const (
+	// frameReportInterval is how often frame measurements are
+	// published to stats.
+	frameReportInterval = time.Second
+	// frameWarnInterval limits how often over budget frames are logged.
+	frameWarnInterval = 10 * time.Second
+)
+
+// frameMeter measures engine frames against budget of throttle_ticks.
+// Frames taking longer than budget are over budget and ticks dropped by
+// ticker because previous frame was late are missed deadlines.
+type frameMeter struct {
+	budget time.Duration
+
+	frames     uint64
+	overBudget uint64
+	missed     uint64
+
+	window     frameWindow
+	reportedAt time.Time
+
+	// counts since last warning
+	warnOver   uint64
+	warnMissed uint64
+	warnMax    time.Duration
+	warnedAt   time.Time
+}
+
+type frameWindow struct {
+	frames               int
+	durSum, durMax       time.Duration
+	jitterSum, jitterMax time.Duration
+}
+
+// frameReport summarizes frames since previous report.
+type frameReport struct {
+	Frames      int
+	AvgDuration time.Duration
+	MaxDuration time.Duration
+	AvgJitter   time.Duration
+	MaxJitter   time.Duration
+	// totals since engine loop started
+	TotalFrames     uint64
+	TotalOverBudget uint64
+	TotalMissed     uint64
+}
+
+func newFrameMeter(budget time.Duration, now time.Time) *frameMeter {
+	return &frameMeter{budget: budget, reportedAt: now}
+}
+
+// record records frame which started delta after previous one and
+// took dur.
+func (m *frameMeter) record(delta, dur time.Duration) {
+	m.frames++
+	if dur > m.budget {
+		m.overBudget++
+		m.warnOver++
+	}
+	if dur > m.warnMax {
+		m.warnMax = dur
+	}
+	if m.budget > 0 && delta > m.budget {
+		// ticker drops ticks while frame is running late.
+		if dropped := uint64((delta+m.budget/2)/m.budget) - 1; dropped > 0 {
+			m.missed += dropped
+			m.warnMissed += dropped
+		}
+	}
+
+	jitter := delta - m.budget
+	if jitter < 0 {
+		jitter = -jitter
+	}
+	w := &m.window
+	w.frames++
+	w.durSum += dur
+	w.durMax = max(w.durMax, dur)
+	w.jitterSum += jitter
+	w.jitterMax = max(w.jitterMax, jitter)
+}
+
+// report returns summary of frames since previous report once report
+// interval has elapsed.
+func (m *frameMeter) report(now time.Time) (frameReport, bool) {
+	if now.Sub(m.reportedAt) < frameReportInterval || m.window.frames == 0 {
+		return frameReport{}, false
+	}
+	w := m.window
+	n := time.Duration(w.frames)
+	rep := frameReport{
+		Frames:          w.frames,
+		AvgDuration:     w.durSum / n,
+		MaxDuration:     w.durMax,
+		AvgJitter:       w.jitterSum / n,
+		MaxJitter:       w.jitterMax,
+		TotalFrames:     m.frames,
+		TotalOverBudget: m.overBudget,
+		TotalMissed:     m.missed,
+	}
+	m.window = frameWindow{}
+	m.reportedAt = now
+	return rep, true
+}
+
+// warning returns number of over budget frames, missed deadlines and
+// longest frame since previous warning when they should be logged.
+func (m *frameMeter) warning(now time.Time) (over, missed uint64, longest time.Duration, ok bool) {
+	if m.warnOver == 0 && m.warnMissed == 0 {
+		return 0, 0, 0, false
+	}
+	if !m.warnedAt.IsZero() && now.Sub(m.warnedAt) < frameWarnInterval {
+		return 0, 0, 0, false
+	}
+	over, missed, longest = m.warnOver, m.warnMissed, m.warnMax
+	m.warnOver, m.warnMissed, m.warnMax = 0, 0, 0
+	m.warnedAt = now
+	return over, missed, longest, true
+}
+
+// reportFrames publishes frame measurements to stats and logs warning
+// when frames were over budget.
+func (e *Engine) reportFrames(sess *session.Context, m *frameMeter, now time.Time) {
+	rep, ok := m.report(now)
+	if !ok {
+		return
+	}
+	prof := e.stats
+	_ = prof.Set("engine.frame.budget", m.budget.String())
+	_ = prof.Set("engine.frame.duration.avg", rep.AvgDuration.String())
+	_ = prof.Set("engine.frame.duration.max", rep.MaxDuration.String())
+	_ = prof.Set("engine.frame.jitter.avg", rep.AvgJitter.String())
+	_ = prof.Set("engine.frame.jitter.max", rep.MaxJitter.String())
+	_ = prof.Set("engine.frame.total", rep.TotalFrames)
+	_ = prof.Set("engine.frame.over_budget", rep.TotalOverBudget)
+	_ = prof.Set("engine.frame.missed", rep.TotalMissed)
+	prof.Series(FrameDurationSeries, 0).Push(now, milliseconds(rep.AvgDuration))
+	prof.Series(FrameJitterSeries, 0).Push(now, milliseconds(rep.AvgJitter))
+
+	if over, missed, longest, ok := m.warning(now); ok {
+		sess.Log().Warn("engine frames over budget",
+			slog.Duration("budget", m.budget),
+			slog.Uint64("over_budget", over),
+			slog.Uint64("missed", missed),
+			slog.Duration("longest", longest))
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+const (
+	BlockedStartupContinue = "continue"
+	BlockedStartupFail     = "fail"
+)
+
+var ErrBlocked = fmt.Errorf("%w: startup blocked", Error)
+
+// Watch runs fn and acts as watchdog for it. When fn does not return
+// within app.services.loader_timeout stacks of all goroutines are
+// logged at BUG level. With app.engine.blocked_startup policy fail
+// ErrBlocked is returned without waiting fn to return, with policy
+// continue Watch waits until fn returns.
+func Watch(sess *session.Context, what string, fn func() error) error {
+	timeout := sess.Get("app.services.loader_timeout").Duration()
+	if timeout <= 0 {
+		return action.Try(fn)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- action.Try(fn)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+	}
+
+	sess.Log().BUG(
+		"startup blocked, "+what+" did not return within loader timeout",
+		slog.Duration("timeout", timeout),
+		slog.String("stacks", stacks()),
+	)
+	if sess.Get("app.engine.blocked_startup").String() == BlockedStartupFail {
+		return fmt.Errorf("%w: %s did not return within %s", ErrBlocked, what, timeout)
+	}
+	return <-done
+}
+
+func stacks() string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package application
+
+import (
+	"github.com/happy-sdk/happy/sdk/app/engine"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/diagnostics"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/stats"
+	"github.com/happy-sdk/happy/sdk/telemetry"
+	"github.com/happy-sdk/happy/sdk/update"
+)
+
+// builtinServices are optional SDK services registered and loaded by
+// engine when they are enabled for session.
+var builtinServices = []engine.Builtin{
+	{
+		Name: stats.ServiceName,
+		Enabled: func(sess *session.Context) bool {
+			return sess.Get("app.stats.enabled").Bool()
+		},
+		Service: func(sess *session.Context, prof *stats.Profiler) *services.Service {
+			return stats.AsService(prof, sess.Get("app.stats.history_interval").Duration())
+		},
+	},
+	{
+		Name:    update.ServiceName,
+		Enabled: update.Enabled,
+		Service: func(*session.Context, *stats.Profiler) *services.Service {
+			return update.AsService()
+		},
+	},
+	{
+		Name:    diagnostics.ServiceName,
+		Enabled: diagnostics.Enabled,
+		Service: func(_ *session.Context, prof *stats.Profiler) *services.Service {
+			return diagnostics.AsService(prof)
+		},
+	},
+	{
+		Name:    telemetry.ServiceName,
+		Enabled: telemetry.Enabled,
+		Service: func(sess *session.Context, _ *stats.Profiler) *services.Service {
+			return telemetry.AsService(sess.Get("app.telemetry.flush_interval").Duration())
+		},
+	},
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+//go:build !windows
+
+package application
+
+import (
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/happy-sdk/happy/sdk/networking/listener"
+)
+
+func execRestart(files map[string]*os.File) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	fds := make(map[string]uintptr, len(files))
+	for key, f := range files {
+		fd := f.Fd()
+		// Duplicated descriptors are close-on-exec, clear the flag
+		// so that they survive exec.
+		if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, fd, syscall.F_SETFD, 0); errno != 0 {
+			return errno
+		}
+		fds[key] = fd
+	}
+	return syscall.Exec(exe, os.Args, restartEnv(fds))
+}
+
+func restartEnv(fds map[string]uintptr) []string {
+	var env []string
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, listener.EnvKey+"=") {
+			env = append(env, e)
+		}
+	}
+	if len(fds) > 0 {
+		env = append(env, listener.Env(fds))
+	}
+	return env
+}
@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package application
+
+import (
+	"os"
+)
+
+// execRestart starts new process since Windows has no exec, listener
+// sockets can not be inherited so the new process binds them again.
+func execRestart(files map[string]*os.File) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	proc, err := os.StartProcess(exe, os.Args, &os.ProcAttr{
+		Env:   os.Environ(),
+		Files: []*os.File{os.Stdin, os.Stdout, os.Stderr},
+	})
+	if err != nil {
+		return err
+	}
+	return proc.Release()
+}
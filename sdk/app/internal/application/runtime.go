@@ -6,11 +6,14 @@ package application
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"runtime"
 	"runtime/debug"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -24,12 +27,15 @@ import (
 	"github.com/happy-sdk/happy/sdk/addon"
 	"github.com/happy-sdk/happy/sdk/app/engine"
 	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli"
 	"github.com/happy-sdk/happy/sdk/cli/command"
 	"github.com/happy-sdk/happy/sdk/cli/help"
 	"github.com/happy-sdk/happy/sdk/events"
 	"github.com/happy-sdk/happy/sdk/instance"
 	"github.com/happy-sdk/happy/sdk/internal"
 	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/networking/listener"
+	"github.com/happy-sdk/happy/sdk/notify"
 	"github.com/happy-sdk/happy/sdk/services"
 )
 
@@ -45,13 +51,17 @@ type Runtime struct {
 	inst      *instance.Instance
 	brand     *branding.Brand
 
-	exitFuncs []func(sess *session.Context, code int) error
+	// exitFuncs are SDK cleanup functions, onExit are registered by
+	// application, both are called in reverse order of registration.
+	exitFuncs []action.Exit
+	onExit    []action.Exit
+	env       []string
 	exitCh    chan ShutDown
 
 	setupAction  action.Action
 	beforeAlways action.WithArgs
-	tickAction   action.Tick
-	tockAction   action.Tock
+	tickAction   action.TickContext
+	tockAction   action.TockContext
 
 	sessionReadyEvent events.Event
 	evch              chan events.Event
@@ -64,8 +74,21 @@ type Runtime struct {
 	initTook      time.Duration
 
 	svcs []*services.Service
+	// loader holds services required by the command.
+	loader *services.ServiceLoader
 
 	addonm *addon.Manager
+
+	restart      bool
+	restartFiles map[string]*os.File
+
+	// unlock releases command locks.
+	unlock func() error
+
+	// exitTrap replaces os.Exit when set.
+	exitTrap func(code int)
+	exitCode int
+	embedded bool
 }
 
 func (rt *Runtime) WidthBeforeAlways(a action.WithArgs) error {
@@ -80,6 +103,44 @@ func (rt *Runtime) WithExitFunc(exitFunc func(sess *session.Context, code int) e
 	rt.exitFuncs = append(rt.exitFuncs, exitFunc)
 }
 
+// OnExit adds function called when application exits, see Main.OnExit.
+func (rt *Runtime) OnExit(fn action.Exit) {
+	rt.onExit = append(rt.onExit, fn)
+}
+
+// SetExitTrap sets function called with exit code instead of os.Exit.
+func (rt *Runtime) SetExitTrap(trap func(code int)) {
+	rt.exitTrap = trap
+}
+
+// Embed configures runtime to be embedded into other program,
+// runtime never calls os.Exit and exit is signaled on ExitCh.
+func (rt *Runtime) Embed() {
+	rt.embedded = true
+	if rt.exitCh == nil {
+		rt.exitCh = make(chan ShutDown, 1)
+	}
+}
+
+// ExitCode returns exit code runtime exited with.
+func (rt *Runtime) ExitCode() int {
+	return rt.exitCode
+}
+
+// Cancel destroys application session with err,
+// which shuts down the runtime.
+func (rt *Runtime) Cancel(err error) {
+	if rt.sess != nil {
+		rt.sess.Destroy(err)
+	}
+}
+
+// WithEnv adds environment variables applied for the duration
+// of command execution.
+func (rt *Runtime) WithEnv(env []string) {
+	rt.env = append(rt.env, env...)
+}
+
 func (rt *Runtime) SetLogger(l logging.Logger) {
 	rt.tmplogger = l
 }
@@ -106,11 +167,11 @@ func (rt *Runtime) SetSessionReady(ch chan events.Event, e events.Event) {
 	rt.evch = ch
 }
 
-func (rt *Runtime) SetMainTick(a action.Tick) {
+func (rt *Runtime) SetMainTick(a action.TickContext) {
 	rt.tickAction = a
 }
 
-func (rt *Runtime) SetMainTock(a action.Tock) {
+func (rt *Runtime) SetMainTock(a action.TockContext) {
 	rt.tockAction = a
 }
 
@@ -133,14 +194,34 @@ func (rt *Runtime) boot() (err error) {
 			rt.recover(r, "panic at application boot")
 		}
 	}()
+	if err := session.SetPhase(rt.sess, session.PhaseStarting); err != nil {
+		return err
+	}
+	if err := rt.applyEnv(); err != nil {
+		return err
+	}
+	session.AttachCommandInvoker(rt.sess, func(sess *session.Context, name string, args ...string) error {
+		_, err := rt.cmd.Invoke(sess, name, args...)
+		return err
+	})
+	session.AttachHelpIndex(rt.sess, rt.cmd.HelpIndex)
+
 	// Run setup action?
 	if rt.sess.Get("app.dosetup").Bool() && rt.setupAction != nil {
-		if err := rt.setupAction(rt.sess); err != nil {
+		if err := action.Try(func() error { return rt.setupAction(rt.sess) }); err != nil {
+			rt.logPanicStack(err)
 			return fmt.Errorf("failed to setup application: %w", err)
 		}
 		rt.setupAction = nil
 	}
 
+	// addons may set only options within own namespace from here on.
+	rt.addonm.GuardOptions(rt.sess)
+
+	if err := rt.addonm.Configure(rt.sess); err != nil {
+		return err
+	}
+
 	// Run immediate command?
 	if rt.cmd.IsImmediate() {
 		internal.Log(rt.sess.Log(), "skip application boot for immediate command")
@@ -148,6 +229,7 @@ func (rt *Runtime) boot() (err error) {
 			return err
 		}
 		rt.sess.Dispatch(rt.sessionReadyEvent)
+		rt.reportStartup()
 		return nil
 	}
 
@@ -162,12 +244,17 @@ func (rt *Runtime) boot() (err error) {
 	rt.exitFuncs = append(rt.exitFuncs, func(sess *session.Context, code int) error {
 		return rt.inst.Dispose()
 	})
+	if rt.sess.Get("app.instance.broadcast").Bool() {
+		if err := rt.inst.EnableBroadcast(); err != nil {
+			rt.sess.Log().Warn("instance broadcasting disabled", slog.String("err", err.Error()))
+		}
+	}
 
 	// Create and start app engine
 	{
 		var (
-			tickAction action.Tick
-			tockAction action.Tock
+			tickAction action.TickContext
+			tockAction action.TockContext
 		)
 		if rt.cmd.IsRoot() {
 			tickAction = rt.tickAction
@@ -175,6 +262,12 @@ func (rt *Runtime) boot() (err error) {
 		}
 
 		rt.engine = engine.New(rt.evch, tickAction, tockAction)
+		if err := rt.engine.AddBuiltin(builtinServices...); err != nil {
+			return err
+		}
+		if err := rt.engine.RegisterEvent(session.PhaseEvent); err != nil {
+			return fmt.Errorf("failed to register event: %w", err)
+		}
 
 		// register services
 		for _, ev := range rt.addonm.Events() {
@@ -192,7 +285,15 @@ func (rt *Runtime) boot() (err error) {
 		if err := rt.addonm.Register(rt.sess); err != nil {
 			return fmt.Errorf("failed to register addons: %w", err)
 		}
+		if err := rt.addonm.ServicesRegistered(rt.sess); err != nil {
+			return err
+		}
 
+		if report := rt.sess.StartupReport(); report != nil {
+			for _, svc := range rt.svcs {
+				report.Services = append(report.Services, svc.Name())
+			}
+		}
 		rt.svcs = nil
 		if err := rt.engine.Start(rt.sess); err != nil {
 			return fmt.Errorf("%w: failed to start engine: %w", Error, err)
@@ -202,14 +303,14 @@ func (rt *Runtime) boot() (err error) {
 	if err := rt.executeBeforeActions(); err != nil {
 		return err
 	}
-	if err := rt.engine.Stats().Set("init.at", rt.sess.Time(rt.initStartedAt).Format(time.RFC3339Nano)); err != nil {
+	if err := rt.engine.Stats().Set("init.at", rt.sess.Time().In(rt.initStartedAt).Format(time.RFC3339Nano)); err != nil {
 		return fmt.Errorf("failed to set app initialized at: %w", err)
 	}
 	if err := rt.engine.Stats().Set("init.took", rt.initTook.String()); err != nil {
 		return fmt.Errorf("failed to set app initialization took: %w", err)
 	}
 
-	if err := rt.engine.Stats().Set("boot.at", rt.sess.Time(bootedAt).Format(time.RFC3339Nano)); err != nil {
+	if err := rt.engine.Stats().Set("boot.at", rt.sess.Time().In(bootedAt).Format(time.RFC3339Nano)); err != nil {
 		return fmt.Errorf("failed to set app started at: %w", err)
 	}
 	bootTook := time.Since(bootedAt).String()
@@ -222,6 +323,71 @@ func (rt *Runtime) boot() (err error) {
 	if rt.execlvl == logging.LevelQuiet || rt.execlvl < logging.LevelDebug {
 		rt.sess.Log().LogDepth(1, logging.LevelDebug, "application booted", slog.String("took", bootTook))
 	}
+	rt.reportStartup()
+	return nil
+}
+
+// loadRequiredServices starts services required by the command,
+// loading is canceled when ctx is done.
+func (rt *Runtime) loadRequiredServices(ctx context.Context) (*services.ServiceLoader, error) {
+	required := rt.cmd.RequiresServices()
+	if len(required) == 0 {
+		return nil, nil
+	}
+	if rt.engine == nil {
+		return nil, fmt.Errorf("%w: command %s requires services, but engine is not running", Error, rt.cmd.Name())
+	}
+	loader, err := services.RequireContext(ctx, rt.sess, required...)
+	if err != nil {
+		return nil, err
+	}
+	report := loader.Report()
+	rt.sess.Log().Debug("required services loaded", slog.Duration("took", report.Took), slog.Int("services", len(report.Results)))
+	for _, res := range report.Failed() {
+		rt.sess.Log().Warn("optional service not loaded", slog.String("service", res.Service), slog.String("status", string(res.Status)), slog.String("err", res.Err))
+	}
+	return loader, nil
+}
+
+// reportStartup logs startup report at system debug level
+// and prints it when --print-startup flag is set.
+func (rt *Runtime) reportStartup() {
+	report := rt.sess.StartupReport()
+	if report == nil {
+		return
+	}
+	for i, a := range report.Addons {
+		report.Addons[i].Took = rt.addonm.Took(a.Slug)
+	}
+	phases := make([]any, 0, len(report.Phases))
+	for _, p := range report.Phases {
+		phases = append(phases, slog.String(p.Name, p.Took.String()))
+	}
+	internal.Log(rt.sess.Log(), "startup report",
+		slog.String("profile", report.Profile),
+		slog.String("profile.mode", report.ProfileMode),
+		slog.Int("settings", len(report.Settings)),
+		slog.Int("addons", len(report.Addons)),
+		slog.Int("services", len(report.Services)),
+		slog.Group("phases", phases...),
+		slog.String("took", report.Took.String()),
+	)
+	if rt.cmd.Flag("print-startup").Present() {
+		rt.sess.Log().Println(report.String())
+	}
+}
+
+// applyEnv applies environment variables declared by application
+// and command, they are restored on exit.
+func (rt *Runtime) applyEnv() error {
+	env := append(slices.Clone(rt.env), rt.cmd.Env()...)
+	if len(env) == 0 {
+		return nil
+	}
+	if err := rt.sess.Env().Apply(env...); err != nil {
+		return fmt.Errorf("%w: %w", Error, err)
+	}
+	internal.Log(rt.sess.Log(), "applied environment", slog.Any("env", rt.sess.Env().Overrides()))
 	return nil
 }
 
@@ -231,12 +397,24 @@ func (rt *Runtime) Start() {
 			rt.Exit(0)
 			return
 		}
+		if rt.usageError(err) {
+			rt.Exit(1)
+			return
+		}
+		// Before actions failed, command After actions receive
+		// *action.GroupError with result of each step.
+		var gerr *action.GroupError
+		if errors.As(err, &gerr) {
+			rt.sess.Log().Error("failed to start command", slog.String("err", err.Error()))
+			rt.complete(err, 0)
+			return
+		}
 		rt.sess.Log().Error("failed to boot application", slog.String("err", err.Error()))
 		rt.Exit(1)
 		return
 	}
 
-	rt.startedAt = rt.sess.Time(time.Now())
+	rt.startedAt = rt.sess.Time().Now()
 	if rt.execlvl == logging.LevelQuiet || rt.execlvl < logging.LevelDebug {
 		rt.sess.Log().LogDepth(1, logging.LevelDebug, "starting application", slog.Time("started.at", rt.startedAt))
 	}
@@ -255,13 +433,38 @@ func (rt *Runtime) Start() {
 		return
 	}
 
+	if err := rt.addonm.Ready(rt.sess); err != nil {
+		rt.sess.Log().Error("addon ready failed", slog.String("err", err.Error()))
+		rt.Exit(1)
+		return
+	}
+
+	doStartedAt := rt.sess.Time().Now()
 	err := rt.executeDoAction()
+	took := rt.sess.Time().Since(doStartedAt)
+
+	if rt.loader != nil && !rt.sess.Get("app.services.keep_required").Bool() {
+		if serr := rt.loader.Stop(); serr != nil {
+			rt.sess.Log().Warn("failed to stop required services", slog.String("err", serr.Error()))
+		}
+	}
+	rt.complete(err, took)
+}
+
+// complete calls After actions of the command with err returned by
+// Do action or by Before actions and exits.
+func (rt *Runtime) complete(err error, took time.Duration) {
+	rt.telemetryEvents(err, took)
+	rt.notifyCompleted(err, took)
 	defer func() {
 		if r := recover(); r != nil {
 			rt.recover(r, "shutdown failed")
 		}
 	}()
 
+	if perr := session.SetPhase(rt.sess, session.PhaseDraining); perr != nil {
+		internal.Log(rt.sess.Log(), "session is not drained", slog.String("err", perr.Error()))
+	}
 	if rt.engine != nil {
 		if engErr := rt.engine.Stop(rt.sess); engErr != nil {
 			rt.sess.Log().Error("failed to stop engine", slog.String("err", engErr.Error()))
@@ -271,41 +474,75 @@ func (rt *Runtime) Start() {
 	if rt.evch != nil {
 		close(rt.evch)
 	}
-	canRecover := rt.sess.CanRecover(err)
-
-	if !canRecover {
-		if e := rt.cmd.ExecAfterFailure(rt.sess, err); e != nil {
-			rt.sess.Log().Error(e.Error(), slog.String("action", "AfterFailure"))
-			rt.Exit(1)
-			return
-		}
-	} else {
+	// AfterAlways is called even when AfterFailure or AfterSuccess
+	// fails, it receives error of the action which failed last.
+	if rt.sess.CanRecover(err) {
+		err = nil
 		if e := rt.cmd.ExecAfterSuccess(rt.sess); e != nil {
 			rt.sess.Log().Error(e.Error(), slog.String("action", "AfterSuccess"))
-			rt.Exit(1)
-			return
+			err = e
 		}
-	}
-
-	if canRecover {
-		err = nil
+	} else if e := rt.cmd.ExecAfterFailure(rt.sess, err); e != nil {
+		rt.sess.Log().Error(e.Error(), slog.String("action", "AfterFailure"))
+		err = e
 	}
 	if e := rt.cmd.ExecAfterAlways(rt.sess, err); e != nil {
 		rt.sess.Log().Error(e.Error(), slog.String("action", "AfterAlways"))
-		rt.Exit(1)
-		return
+		err = e
 	}
 	if rt.execlvl < logging.LevelQuiet {
 		rt.sess.Log().SetLevel(rt.execlvl)
 	}
 
 	if err != nil {
+		rt.writeResult(1, err, took)
 		rt.Exit(1)
 		return
 	}
+	rt.writeResult(0, nil, took)
 	rt.Exit(0)
 }
 
+// commandReport is exit metadata written to stdout with --output=json.
+type commandReport struct {
+	Command   string            `json:"command"`
+	Success   bool              `json:"success"`
+	ExitCode  int               `json:"exit_code"`
+	Error     string            `json:"error,omitempty"`
+	TookMs    int64             `json:"took_ms"`
+	Value     any               `json:"value,omitempty"`
+	Warnings  []string          `json:"warnings,omitempty"`
+	Artifacts []action.Artifact `json:"artifacts,omitempty"`
+}
+
+// writeResult writes command result and exit metadata as JSON to stdout
+// when --output=json flag is set.
+func (rt *Runtime) writeResult(code int, err error, took time.Duration) {
+	if rt.cmd == nil || rt.cmd.Flag("output").String() != "json" {
+		return
+	}
+	report := commandReport{
+		Command:  rt.cmd.Name(),
+		Success:  code == 0,
+		ExitCode: code,
+		TookMs:   took.Milliseconds(),
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	if res := rt.cmd.Result(); res != nil {
+		report.Value = res.Value
+		report.Warnings = res.Warnings
+		report.Artifacts = res.Artifacts
+	}
+	data, merr := json.Marshal(report)
+	if merr != nil {
+		rt.sess.Log().Error("failed to encode command result", slog.String("err", merr.Error()))
+		return
+	}
+	fmt.Fprintln(os.Stdout, string(data))
+}
+
 func (rt *Runtime) recover(r any, msg string) {
 	// Log the panic message
 	var errMessage string
@@ -327,6 +564,48 @@ func (rt *Runtime) recover(r any, msg string) {
 	rt.Exit(1)
 }
 
+// telemetryEvents records automatic command telemetry events,
+// only type of the error is recorded to keep events anonymous.
+func (rt *Runtime) telemetryEvents(err error, took time.Duration) {
+	t := rt.sess.Telemetry()
+	if !t.Enabled() {
+		return
+	}
+	t.Event("cmd.run",
+		slog.String("cmd", rt.cmd.Name()),
+		slog.Bool("success", err == nil),
+		slog.Int64("took_ms", took.Milliseconds()),
+	)
+	if err != nil {
+		t.Event("cmd.error",
+			slog.String("cmd", rt.cmd.Name()),
+			slog.String("error", fmt.Sprintf("%T", err)),
+			slog.Bool("panic", errors.Is(err, action.ErrPanic)),
+		)
+	}
+}
+
+// notifyCompleted sends desktop notification about completed command when
+// --notify flag is set or command took longer than app.notify.after.
+func (rt *Runtime) notifyCompleted(err error, took time.Duration) {
+	after := rt.sess.Get("app.notify.after").Duration()
+	if !rt.cmd.Flag("notify").Present() && (after <= 0 || took < after) {
+		return
+	}
+	if nerr := notify.Completed(rt.sess, rt.cmd.Name(), took, err); nerr != nil {
+		rt.sess.Log().Warn("failed to send notification", slog.String("err", nerr.Error()))
+	}
+}
+
+// logPanicStack logs stack trace of panic recovered from user action.
+func (rt *Runtime) logPanicStack(err error) {
+	var perr *action.PanicError
+	if errors.As(err, &perr) {
+		rt.log(2, logging.LevelBUG, perr.Error())
+		rt.log(2, logging.LevelAlways, string(perr.Stack))
+	}
+}
+
 func (rt *Runtime) executeBeforeActions() error {
 	defer func() {
 		if r := recover(); r != nil {
@@ -373,24 +652,66 @@ func (rt *Runtime) executeBeforeActions() error {
 		return ErrExitSuccess
 	}
 
+	if locks := rt.cmd.Locks(); len(locks) > 0 {
+		unlock, err := rt.sess.Lock(rt.cmd.Flag("wait-lock").Var().Duration(), locks...)
+		if err != nil {
+			return err
+		}
+		rt.unlock = unlock
+		internal.Log(rt.sess.Log(), "acquired command locks", slog.String("locks", strings.Join(locks, ",")))
+	}
+
+	// Before actions and loading of required services run concurrently,
+	// first error cancels loading and started services are stopped so
+	// that Do action is never called with partially started services.
+	group := action.NewGroup(rt.sess)
+	group.Go("before", func(ctx context.Context) error {
+		// Before actions get session destroyed when group is canceled,
+		// so that they stop when required services fail to load.
+		sess, cancel := rt.sess.WithCancel()
+		defer cancel()
+		stop := context.AfterFunc(ctx, func() { sess.Destroy(context.Cause(ctx)) })
+		defer stop()
+		return rt.executeBefore(sess)
+	})
+	group.Go("services", func(ctx context.Context) (err error) {
+		rt.loader, err = rt.loadRequiredServices(ctx)
+		return err
+	})
+	if err := group.Wait(); err != nil {
+		if rt.loader != nil {
+			if serr := rt.loader.Stop(); serr != nil {
+				rt.sess.Log().Warn("failed to stop required services", slog.String("err", serr.Error()))
+			}
+			rt.loader = nil
+		}
+		return err
+	}
+	return nil
+}
+
+// executeBefore calls BeforeAlways and Before action of the command
+// with sess.
+func (rt *Runtime) executeBefore(sess *session.Context) error {
 	if rt.beforeAlways != nil && !rt.cmd.SkipSharedBeforeAction() {
 		timer := time.Now()
-		internal.Log(rt.sess.Log(), "executing before always")
+		internal.Log(sess.Log(), "executing before always")
 		args := action.NewArgs(rt.cmd.GetFlagSet())
-		if err := rt.beforeAlways(rt.sess, args); err != nil {
+		if err := engine.Watch(sess, "before always action", func() error { return rt.beforeAlways(sess, args) }); err != nil {
+			rt.logPanicStack(err)
 			return fmt.Errorf("failed to execute before always action: %w", err)
 		}
-		internal.Log(rt.sess.Log(), "before always action took", slog.String("took", time.Since(timer).String()))
+		internal.Log(sess.Log(), "before always action took", slog.String("took", time.Since(timer).String()))
 	}
 
 	if rt.cmd.HasBefore() {
 		timer := time.Now()
-		if err := rt.cmd.ExecBefore(rt.sess); err != nil {
+		if err := engine.Watch(sess, "before action", func() error { return rt.cmd.ExecBefore(sess) }); err != nil {
+			rt.logPanicStack(err)
 			return fmt.Errorf("failed to execute before action: %w", err)
 		}
-		internal.Log(rt.sess.Log(), "before action took", slog.String("took", time.Since(timer).String()))
+		internal.Log(sess.Log(), "before action took", slog.String("took", time.Since(timer).String()))
 	}
-
 	return nil
 }
 
@@ -403,14 +724,41 @@ func (rt *Runtime) executeDoAction() error {
 	doTimer := time.Now()
 	internal.Log(rt.sess.Log(), "executing command", slog.String("args", strings.Join(os.Args, " ")))
 	err := rt.cmd.ExecDo(rt.sess)
-	if err != nil {
+	if err != nil && !rt.usageError(err) {
 		rt.sess.Log().Error(err.Error())
+		rt.logPanicStack(err)
 	}
 	// fmt.Println("") // to separate the command output from the prompt
 	internal.Log(rt.sess.Log(), "command took", slog.String("took", time.Since(doTimer).String()))
 	return err
 }
 
+// Restart shuts down the application cleanly and execs the binary again
+// with same arguments. Listeners opened with listener.Listen are passed
+// to the new process. Restart returns after the shutdown is initiated.
+func (rt *Runtime) Restart(sess *session.Context) error {
+	if rt.sess == nil || sess != rt.sess {
+		return fmt.Errorf("%w: restart requires application session", Error)
+	}
+	if rt.restart {
+		return fmt.Errorf("%w: restart already in progress", Error)
+	}
+	if rt.embedded {
+		return fmt.Errorf("%w: embedded application can not restart", Error)
+	}
+	// Windows can not pass sockets to exec'd process.
+	if runtime.GOOS != "windows" {
+		files, err := listener.Files()
+		if err != nil {
+			return fmt.Errorf("%w: restart: %s", Error, err)
+		}
+		rt.restartFiles = files
+	}
+	rt.restart = true
+	sess.Destroy(nil)
+	return nil
+}
+
 type ShutDown struct{}
 
 // ExitCh return blocking channel that will reveive a signal when the runtime exits
@@ -427,10 +775,30 @@ func (rt *Runtime) SetExecLogLevel(lvl logging.Level) {
 
 func (rt *Runtime) Exit(code int) {
 	rt.log(0, internal.LogLevelHappy, "shutting down", slog.Int("exit.code", code))
+	if rt.sess != nil {
+		// session may be already destroyed e.g. by restart or signal.
+		_ = session.SetPhase(rt.sess, session.PhaseDraining)
+	}
 
-	for _, fn := range rt.exitFuncs {
-		if err := fn(rt.sess, code); err != nil {
+	// addons register exit funcs after application,
+	// so they are called before exit funcs of application.
+	exitFuncs := append([]action.Exit{}, rt.exitFuncs...)
+	exitFuncs = append(exitFuncs, rt.onExit...)
+	if rt.addonm != nil {
+		exitFuncs = append(exitFuncs, rt.addonm.ExitFuncs()...)
+	}
+	for i := len(exitFuncs) - 1; i >= 0; i-- {
+		fn := exitFuncs[i]
+		if err := action.Try(func() error { return fn(rt.sess, code) }); err != nil {
 			rt.log(0, logging.LevelError, "exit func", slog.String("err", err.Error()))
+			rt.logPanicStack(err)
+			code = 1
+		}
+	}
+
+	if rt.addonm != nil && rt.sess != nil {
+		if err := rt.addonm.Shutdown(rt.sess); err != nil {
+			rt.log(0, logging.LevelError, "addon shutdown", slog.String("err", err.Error()))
 			code = 1
 		}
 	}
@@ -442,6 +810,13 @@ func (rt *Runtime) Exit(code int) {
 	}
 
 	if rt.sess != nil {
+		if profile := rt.sess.Settings(); profile != nil {
+			if err := profile.Flush(); err != nil {
+				rt.log(0, logging.LevelError, "failed to save settings", slog.String("err", err.Error()))
+				code = 1
+			}
+		}
+		rt.sess.Env().Restore()
 		if rt.sess.Get("app.stats.enabled").Bool() && rt.sess.Log().Level() <= logging.LevelDebug {
 			if rt.engine != nil {
 				rt.sess.Log().Println(rt.engine.Stats().State().String())
@@ -454,18 +829,44 @@ func (rt *Runtime) Exit(code int) {
 		}
 	}
 
+	if rt.unlock != nil {
+		if err := rt.unlock(); err != nil {
+			rt.log(0, logging.LevelWarn, "failed to release command locks", slog.String("err", err.Error()))
+		}
+		rt.unlock = nil
+	}
+
+	if rt.restart {
+		if code == 0 {
+			// on success exec does not return
+			rt.log(0, logging.LevelInfo, "restarting", slog.Int("listeners", len(rt.restartFiles)))
+			if err := execRestart(rt.restartFiles); err != nil {
+				rt.log(0, logging.LevelError, "restart failed", slog.String("err", err.Error()))
+				code = 1
+			}
+		}
+		for _, f := range rt.restartFiles {
+			_ = f.Close()
+		}
+	}
+
+	rt.exitCode = code
 	if rt.exitCh != nil {
 		rt.exitCh <- struct{}{}
 	}
 
 	if !rt.startedAt.IsZero() {
-		rt.log(1, logging.LevelDebug, "shutdown complete", slog.String("uptime", time.Since(rt.startedAt).String()), slog.Int("exit.code", code))
+		rt.log(1, logging.LevelDebug, "shutdown complete", slog.String("uptime", rt.sess.Time().Since(rt.startedAt).String()), slog.Int("exit.code", code))
 	} else {
 		rt.log(1, logging.LevelDebug, "shutdown complete", slog.Int("exit.code", code))
 	}
 
-	// If we are not testing, exit the main process
-	if !testing.Testing() {
+	if rt.exitTrap != nil {
+		rt.exitTrap(code)
+		return
+	}
+	// If we are not testing or embedded, exit the main process
+	if !testing.Testing() && !rt.embedded {
 		os.Exit(code)
 	}
 }
@@ -486,6 +887,10 @@ func (rt *Runtime) log(depth int, lvl logging.Level, msg string, attrs ...slog.A
 }
 
 func (rt *Runtime) showHelp() error {
+	return rt.help(rt.cmd).Print()
+}
+
+func (rt *Runtime) help(cmd *command.Cmd) *help.Help {
 	theme := rt.brand.ANSI()
 
 	h := help.New(
@@ -497,8 +902,8 @@ func (rt *Runtime) showHelp() error {
 			CopyrightSince: rt.sess.Get("app.copyright_since").Int(),
 			License:        rt.sess.Get("app.license").String(),
 			Address:        rt.sess.Get("app.address").String(),
-			Usage:          rt.cmd.Usage(),
-			Info:           rt.cmd.Info(),
+			Usage:          cmd.Usage(),
+			Info:           cmd.Info(),
 		},
 		help.Style{
 			Primary:     ansicolor.Style{FG: theme.Primary, Format: ansicolor.Bold},
@@ -511,17 +916,48 @@ func (rt *Runtime) showHelp() error {
 		},
 	)
 
-	for _, scmd := range rt.cmd.SubCommands() {
+	for _, scmd := range cmd.SubCommands() {
 		h.AddCommand(scmd.Category, scmd.Name, scmd.Description)
 	}
 
-	h.AddCategoryDescriptions(rt.cmd.Categories())
+	h.AddCategoryDescriptions(cmd.Categories())
+	h.AddCategoryWeights(cmd.CategoryWeights())
 
-	if !rt.cmd.IsRoot() {
-		h.AddCommandFlags(rt.cmd.Flags())
-		h.AddSharedFlags(rt.cmd.SharedFlags())
+	if !cmd.IsRoot() {
+		h.AddCommandFlags(cmd.Flags())
+		h.AddSharedFlags(cmd.SharedFlags())
 	}
 
-	h.AddGlobalFlags(rt.cmd.GlobalFlags())
-	return h.Print()
+	h.AddGlobalFlags(cmd.GlobalFlags())
+	return h
+}
+
+// usageError prints help or usage lines of the command err relates to
+// followed by err. Actions returning cli.ErrCommandArgs or
+// cli.ErrCommandFlags are treated as usage errors of active command.
+// It reports false when err is not usage error.
+func (rt *Runtime) usageError(err error) bool {
+	uerr, ok := command.AsUsageError(err)
+	if !ok {
+		if !errors.Is(err, cli.ErrCommandArgs) && !errors.Is(err, cli.ErrCommandFlags) {
+			return false
+		}
+		uerr = &command.UsageError{Usage: rt.cmd.Usage(), Cmd: rt.cmd, Err: err}
+	}
+	cmd := uerr.Cmd
+	if cmd == nil {
+		cmd = rt.cmd
+	}
+	h := rt.help(cmd)
+	var perr error
+	if rt.sess.Get("app.cli.usage_help").Bool() {
+		perr = h.Print()
+	} else {
+		perr = h.PrintUsage(uerr.Command)
+	}
+	if perr != nil {
+		rt.sess.Log().Error("failed to print help", slog.String("err", perr.Error()))
+	}
+	rt.sess.Log().Error(uerr.Error())
+	return true
 }
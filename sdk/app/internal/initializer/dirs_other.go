@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+//go:build !windows
+
+package initializer
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+func osUserConfigDir() (string, error) {
+	return os.UserConfigDir()
+}
+
+func osUserCacheDir() (string, error) {
+	return os.UserCacheDir()
+}
+
+// osUserDir returns base directory for "data" or "state" following
+// XDG Base Directory specification, on macOS Application Support is used.
+func osUserDir(dir, home string) (string, error) {
+	if runtime.GOOS == "darwin" {
+		base := filepath.Join(home, "Library", "Application Support")
+		if dir == "state" {
+			return filepath.Join(base, "State"), nil
+		}
+		return base, nil
+	}
+	env, def := "XDG_DATA_HOME", filepath.Join(home, ".local", "share")
+	if dir == "state" {
+		env, def = "XDG_STATE_HOME", filepath.Join(home, ".local", "state")
+	}
+	if p := os.Getenv(env); filepath.IsAbs(p) {
+		return p, nil
+	}
+	return def, nil
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package initializer
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	shell32                  = syscall.NewLazyDLL("shell32.dll")
+	ole32                    = syscall.NewLazyDLL("ole32.dll")
+	procSHGetKnownFolderPath = shell32.NewProc("SHGetKnownFolderPath")
+	procCoTaskMemFree        = ole32.NewProc("CoTaskMemFree")
+
+	folderIDRoamingAppData = syscall.GUID{Data1: 0x3EB685DB, Data2: 0x65F9, Data3: 0x4CF6, Data4: [8]byte{0xA0, 0x3A, 0xE3, 0xEF, 0x65, 0x72, 0x9F, 0x3D}}
+	folderIDLocalAppData   = syscall.GUID{Data1: 0xF1B32785, Data2: 0x6FBA, Data3: 0x4FCF, Data4: [8]byte{0x9D, 0x55, 0x7B, 0x8E, 0x7F, 0x15, 0x70, 0x91}}
+)
+
+// osUserConfigDir returns RoamingAppData Known Folder, it does not depend
+// on %AppData% which may be missing e.g. in services.
+func osUserConfigDir() (string, error) {
+	if dir, err := knownFolderPath(&folderIDRoamingAppData); err == nil {
+		return dir, nil
+	}
+	return os.UserConfigDir()
+}
+
+// osUserCacheDir returns LocalAppData Known Folder.
+func osUserCacheDir() (string, error) {
+	if dir, err := knownFolderPath(&folderIDLocalAppData); err == nil {
+		return dir, nil
+	}
+	return os.UserCacheDir()
+}
+
+// osUserDir returns base directory for "data" or "state" under
+// LocalAppData Known Folder.
+func osUserDir(dir, home string) (string, error) {
+	base, err := osUserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	if dir == "state" {
+		return filepath.Join(base, "State"), nil
+	}
+	return base, nil
+}
+
+func knownFolderPath(id *syscall.GUID) (string, error) {
+	var p *uint16
+	r, _, _ := procSHGetKnownFolderPath.Call(uintptr(unsafe.Pointer(id)), 0, 0, uintptr(unsafe.Pointer(&p)))
+	if p != nil {
+		defer procCoTaskMemFree.Call(uintptr(unsafe.Pointer(p)))
+	}
+	if r != 0 || p == nil {
+		return "", syscall.Errno(r)
+	}
+	return syscall.UTF16ToString(unsafe.Slice(p, 32767)), nil
+}
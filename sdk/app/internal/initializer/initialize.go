@@ -23,11 +23,14 @@ import (
 	"github.com/happy-sdk/happy/sdk/app/session"
 	"github.com/happy-sdk/happy/sdk/cli"
 	"github.com/happy-sdk/happy/sdk/cli/command"
+	clicommands "github.com/happy-sdk/happy/sdk/cli/commands"
 	"github.com/happy-sdk/happy/sdk/config"
 	"github.com/happy-sdk/happy/sdk/devel"
 	"github.com/happy-sdk/happy/sdk/instance"
 	"github.com/happy-sdk/happy/sdk/internal"
 	"github.com/happy-sdk/happy/sdk/networking/address"
+	"github.com/happy-sdk/happy/sdk/paths"
+	"github.com/happy-sdk/happy/sdk/stats"
 )
 
 // defaults holds the default values for the application.
@@ -40,11 +43,23 @@ type defaults struct {
 	configAdditionalProfiles  []string
 	configAllowCustomProfiles bool
 	configEnableProfileDevel  bool
+	configStrict              bool
+	configBackups             int
+	configEncrypt             bool
+	configRemote              string
+	configRemoteKey           string
+	configRemoteInterval      time.Duration
 	cliMainMinArgs            uint
 	cliMainMaxArgs            uint
 	cliWithoutConfigCmd       bool
+	cliWithoutDoctorCmd       bool
+	cliWithoutInfoCmd         bool
 	cliWithoutGlobalFlags     bool
 	develAllowProd            bool
+	develWithDevCmd           bool
+	statsEnabled              bool
+	// fsOverrides holds directory overrides keyed by paths.Dirs
+	fsOverrides map[string]string
 }
 
 // initialize sets up the application logger, options, settings, and root command.
@@ -66,17 +81,21 @@ func (init *Initializer) initialize() {
 		return
 	}
 
+	init.phase("options")
+
 	// Set the application paths
 	if err := init.initBasePaths(); err != nil {
 		init.error(err)
 		return
 	}
+	init.phase("paths")
 
 	// Setup root command
 	if err := init.initRootCommand(); err != nil {
 		init.error(err)
 		return
 	}
+	init.phase("command")
 }
 
 func (init *Initializer) initSettingsAndOpts() (err error) {
@@ -87,6 +106,7 @@ func (init *Initializer) initSettingsAndOpts() (err error) {
 		init.error(err)
 		return
 	}
+	init.phase("blueprint")
 
 	// Load defaults before profile is loaded
 	configDisabledSpec, err := init.settingsb.GetSpec("app.config.disabled")
@@ -117,6 +137,38 @@ func (init *Initializer) initSettingsAndOpts() (err error) {
 	if err != nil {
 		return err
 	}
+	configStrictSpec, err := init.settingsb.GetSpec("app.config.strict")
+	if err != nil {
+		return err
+	}
+	configBackupsSpec, err := init.settingsb.GetSpec("app.config.backups")
+	if err != nil {
+		return err
+	}
+	configBackups, err := strconv.Atoi(configBackupsSpec.Value)
+	if err != nil {
+		return err
+	}
+	configEncryptSpec, err := init.settingsb.GetSpec("app.config.encrypt")
+	if err != nil {
+		return err
+	}
+	configRemoteSpec, err := init.settingsb.GetSpec("app.config.remote")
+	if err != nil {
+		return err
+	}
+	configRemoteKeySpec, err := init.settingsb.GetSpec("app.config.remote_key")
+	if err != nil {
+		return err
+	}
+	configRemoteIntervalSpec, err := init.settingsb.GetSpec("app.config.remote_interval")
+	if err != nil {
+		return err
+	}
+	configRemoteInterval, err := time.ParseDuration(configRemoteIntervalSpec.Value)
+	if err != nil {
+		return err
+	}
 	cliMainMinArgsSpec, err := init.settingsb.GetSpec("app.cli.main_min_args")
 	if err != nil {
 		return err
@@ -137,6 +189,14 @@ func (init *Initializer) initSettingsAndOpts() (err error) {
 	if err != nil {
 		return err
 	}
+	cliWithoutDoctorCmdSpec, err := init.settingsb.GetSpec("app.cli.without_doctor_cmd")
+	if err != nil {
+		return err
+	}
+	cliWithoutInfoCmdSpec, err := init.settingsb.GetSpec("app.cli.without_info_cmd")
+	if err != nil {
+		return err
+	}
 	cliWithoutGlobalFlagsSpec, err := init.settingsb.GetSpec("app.cli.without_global_flags")
 	if err != nil {
 		return err
@@ -145,15 +205,42 @@ func (init *Initializer) initSettingsAndOpts() (err error) {
 	if err != nil {
 		return err
 	}
+	develWithDevCmdSpec, err := init.settingsb.GetSpec("app.devel.with_dev_cmd")
+	if err != nil {
+		return err
+	}
+	statsEnabledSpec, err := init.settingsb.GetSpec("app.stats.enabled")
+	if err != nil {
+		return err
+	}
 
 	init.defaults.configDisabled = configDisabledSpec.Value == "true"
+	init.defaults.configStrict = configStrictSpec.Value == "true"
+	init.defaults.configBackups = configBackups
+	init.defaults.configEncrypt = configEncryptSpec.Value == "true"
+	init.defaults.configRemote = configRemoteSpec.Value
+	init.defaults.configRemoteKey = configRemoteKeySpec.Value
+	init.defaults.configRemoteInterval = configRemoteInterval
 	init.defaults.slug = slugSpec.Value
 	init.defaults.identifier = identifierSpec.Value
 	init.defaults.cliMainMinArgs = uint(cliMainMinArgs)
 	init.defaults.cliMainMaxArgs = uint(cliMainMaxArgs)
 	init.defaults.cliWithoutConfigCmd = cliWithoutConfigCmdSpec.Value == "true"
+	init.defaults.cliWithoutDoctorCmd = cliWithoutDoctorCmdSpec.Value == "true"
+	init.defaults.cliWithoutInfoCmd = cliWithoutInfoCmdSpec.Value == "true"
 	init.defaults.cliWithoutGlobalFlags = cliWithoutGlobalFlagsSpec.Value == "true"
 	init.defaults.develAllowProd = develAllowProdSpec.Value == "true"
+	init.defaults.develWithDevCmd = develWithDevCmdSpec.Value == "true"
+	init.defaults.statsEnabled = statsEnabledSpec.Value == "true"
+
+	init.defaults.fsOverrides = make(map[string]string)
+	for _, dir := range paths.Dirs {
+		spec, err := init.settingsb.GetSpec("app.fs." + dir + "_dir")
+		if err != nil {
+			return err
+		}
+		init.defaults.fsOverrides[dir] = spec.Value
+	}
 
 	if init.defaults.configDisabled {
 		init.defaults.configDefaultProfile = configDefaultProfileSpec.Default
@@ -262,6 +349,13 @@ func (init *Initializer) initSettingsAndOpts() (err error) {
 			options.KindConfig|options.KindReadOnly,
 			options.NoopValueValidator,
 		),
+		options.NewOption(
+			"app.fs.path.cache_root",
+			"",
+			"Application owned cache root containing caches of all profiles",
+			options.KindConfig|options.KindReadOnly,
+			options.NoopValueValidator,
+		),
 		options.NewOption(
 			"app.fs.path.config",
 			"",
@@ -269,6 +363,20 @@ func (init *Initializer) initSettingsAndOpts() (err error) {
 			options.KindConfig|options.KindReadOnly,
 			options.NoopValueValidator,
 		),
+		options.NewOption(
+			"app.fs.path.data",
+			"",
+			"Application data directory, created on demand",
+			options.KindConfig|options.KindReadOnly,
+			options.NoopValueValidator,
+		),
+		options.NewOption(
+			"app.fs.path.state",
+			"",
+			"Application state directory, created on demand",
+			options.KindConfig|options.KindReadOnly,
+			options.NoopValueValidator,
+		),
 		options.NewOption(
 			"app.fs.path.pids",
 			"",
@@ -332,6 +440,27 @@ func (init *Initializer) initSettingsAndOpts() (err error) {
 			options.KindConfig|options.KindReadOnly,
 			options.NoopValueValidator,
 		),
+		options.NewOption(
+			"app.addons",
+			"",
+			"Attached addons as slug@version separated by comma",
+			options.KindConfig|options.KindReadOnly,
+			options.NoopValueValidator,
+		),
+		options.NewOption(
+			"app.update.available",
+			false,
+			"Newer application version is available",
+			options.KindRuntime,
+			options.NoopValueValidator,
+		),
+		options.NewOption(
+			"app.update.latest",
+			"",
+			"Latest application version reported by update check",
+			options.KindRuntime,
+			options.NoopValueValidator,
+		),
 	}
 
 	init.opts, err = options.New("app", optSpecs)
@@ -367,7 +496,24 @@ func (init *Initializer) initBasePaths() error {
 		return err
 	}
 
-	tempDir := filepath.Join(os.TempDir(), init.defaults.slug, fmt.Sprintf("instance-%s", instanceID))
+	overrides := make(map[string]string, len(paths.Dirs))
+	for _, dir := range paths.Dirs {
+		p, err := paths.Override(init.defaults.slug, dir, init.defaults.fsOverrides[dir])
+		if err != nil {
+			return err
+		}
+		if p != "" {
+			internal.LogInit(init.log, "directory override", slog.String("dir", dir), slog.String("path", p))
+		}
+		overrides[dir] = p
+	}
+	init.defaults.fsOverrides = overrides
+
+	tempBaseDir := filepath.Join(os.TempDir(), init.defaults.slug)
+	if overrides["tmp"] != "" {
+		tempBaseDir = overrides["tmp"]
+	}
+	tempDir := filepath.Join(tempBaseDir, fmt.Sprintf("instance-%s", instanceID))
 	if err := init.utilMkdir("create tmp directory", tempDir, 0700); err != nil {
 		return err
 	}
@@ -376,15 +522,18 @@ func (init *Initializer) initBasePaths() error {
 	}
 
 	// config dir
-	userConfigDir, err := os.UserConfigDir()
+	userConfigDir, err := osUserConfigDir()
 	if err != nil {
 		return err
 	}
 
 	var appConfigDir string
-	if testing.Testing() {
+	switch {
+	case overrides["config"] != "":
+		appConfigDir = overrides["config"]
+	case testing.Testing():
 		appConfigDir = filepath.Join(init.opts.Get("app.fs.path.tmp").String(), "config")
-	} else {
+	default:
 		appConfigDir = filepath.Join(userConfigDir, init.defaults.slug)
 	}
 
@@ -402,6 +551,25 @@ func (init *Initializer) initBasePaths() error {
 		return err
 	}
 
+	// data and state dirs are created on demand with paths.Ensure
+	for _, dir := range []string{"data", "state"} {
+		p := overrides[dir]
+		switch {
+		case p != "":
+		case testing.Testing():
+			p = filepath.Join(init.opts.Get("app.fs.path.tmp").String(), dir)
+		default:
+			base, err := osUserDir(dir, userHomeDir)
+			if err != nil {
+				return err
+			}
+			p = filepath.Join(base, init.defaults.slug)
+		}
+		if err := init.opts.Set("app.fs.path."+dir, p); err != nil {
+			return err
+		}
+	}
+
 	pidsDir := filepath.Join(appConfigDir, "pids")
 	_, err = os.Stat(pidsDir)
 	if errors.Is(err, fs.ErrNotExist) {
@@ -481,6 +649,11 @@ func (init *Initializer) initRootCommand() error {
 			cli.FlagSystemDebug,
 			cli.FlagDebug,
 			cli.FlagVerbose,
+			cli.FlagOutput,
+			cli.FlagPrintStartup,
+			cli.FlagWaitLock,
+			cli.FlagSet,
+			cli.FlagNotify,
 		)
 
 		if !init.defaults.configDisabled {
@@ -496,7 +669,19 @@ func (init *Initializer) initRootCommand() error {
 	}
 
 	if !init.defaults.cliWithoutConfigCmd {
-		root.WithSubCommands(config.Command())
+		root.WithSubCommands(config.Command(init.addonm.Info()...))
+	}
+
+	if !init.defaults.cliWithoutInfoCmd {
+		root.WithSubCommands(clicommands.Info())
+	}
+
+	if init.defaults.statsEnabled {
+		root.WithSubCommands(stats.Command())
+	}
+
+	if init.defaults.develWithDevCmd && init.opts.Get("app.is_devel").Bool() {
+		root.WithSubCommands(devel.Command())
 	}
 
 	init.main = root
@@ -5,7 +5,9 @@
 package initializer
 
 import (
-	"encoding/gob"
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -14,6 +16,8 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -22,18 +26,25 @@ import (
 	"github.com/happy-sdk/happy/pkg/cli/ansicolor"
 	"github.com/happy-sdk/happy/pkg/options"
 	"github.com/happy-sdk/happy/pkg/settings"
-	"github.com/happy-sdk/happy/pkg/vars"
 	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/pkg/version"
 	"github.com/happy-sdk/happy/sdk/action"
 	"github.com/happy-sdk/happy/sdk/addon"
 	"github.com/happy-sdk/happy/sdk/app/internal/application"
 	"github.com/happy-sdk/happy/sdk/app/session"
 	"github.com/happy-sdk/happy/sdk/cli/command"
+	clicommands "github.com/happy-sdk/happy/sdk/cli/commands"
 	"github.com/happy-sdk/happy/sdk/cli/help"
+	"github.com/happy-sdk/happy/sdk/cli/render"
+	"github.com/happy-sdk/happy/sdk/config"
+	"github.com/happy-sdk/happy/sdk/datetime"
 	"github.com/happy-sdk/happy/sdk/devel"
+	"github.com/happy-sdk/happy/sdk/doctor"
 	"github.com/happy-sdk/happy/sdk/events"
 	"github.com/happy-sdk/happy/sdk/internal"
 	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/paths"
+	"github.com/happy-sdk/happy/sdk/project"
 )
 
 var Error = errors.New("initialization error")
@@ -46,6 +57,9 @@ type Initializer struct {
 	logger  logging.Logger
 	execlvl logging.Level
 
+	// user defined clock, resolved when profile is loaded
+	clock datetime.Clock
+
 	opts      *options.Options
 	settings  settings.Settings
 	settingsb *settings.Blueprint
@@ -53,6 +67,9 @@ type Initializer struct {
 	session   *session.Context
 	addonm    *addon.Manager
 
+	doctorChecks []doctor.Check
+	helpTopics   []*help.Topic
+
 	errs []error
 
 	// root command configurator
@@ -77,9 +94,26 @@ type Initializer struct {
 	pid       int
 	createdAt time.Time
 
+	startup    session.StartupReport
+	phaseStart time.Time
+
 	rt *application.Runtime
 
 	defaults *defaults
+
+	// embedded default profile and preferences parsed from it
+	defaultProfile      []byte
+	defaultProfilePrefs map[string]string
+
+	// layers settings values were resolved from
+	layers *config.Resolver
+
+	// project working directory belongs to and its config file layer
+	project      *project.Project
+	projectLayer config.Layer
+
+	// policy is system policy set by administrator
+	policy *config.Policy
 }
 
 func New(s settings.Settings, rt *application.Runtime, log *logging.QueueLogger) *Initializer {
@@ -92,13 +126,25 @@ func New(s settings.Settings, rt *application.Runtime, log *logging.QueueLogger)
 		rt:        rt,
 		defaults:  &defaults{},
 		execlvl:   logging.LevelQuiet,
+		policy:    &config.Policy{},
 	}
 
 	init.log.LogDepth(3, logging.LevelDebug, "initializing", slog.String("pid", fmt.Sprint(init.pid)))
+	init.phaseStart = init.createdAt
 	init.initialize()
 	return init
 }
 
+// phase records time spent since previous phase for startup report.
+func (init *Initializer) phase(name string) {
+	now := time.Now()
+	init.startup.Phases = append(init.startup.Phases, session.StartupPhase{
+		Name: name,
+		Took: now.Sub(init.phaseStart),
+	})
+	init.phaseStart = now
+}
+
 func (init *Initializer) HasFailed() bool {
 	return len(init.errs) > 0
 }
@@ -232,13 +278,13 @@ func (init *Initializer) SetOptions(a ...options.Arg) {
 	init.pendingOpts = append(init.pendingOpts, a...)
 }
 
-func (init *Initializer) MainTick(a action.Tick) {
+func (init *Initializer) MainTick(a action.TickContext) {
 	init.mu.Lock()
 	defer init.mu.Unlock()
 	init.rt.SetMainTick(a)
 }
 
-func (init *Initializer) MainTock(a action.Tock) {
+func (init *Initializer) MainTock(a action.TockContext) {
 	init.mu.Lock()
 	defer init.mu.Unlock()
 	init.rt.SetMainTock(a)
@@ -250,6 +296,12 @@ func (init *Initializer) MainAddCommands(cmds []*command.Command) {
 	init.main.WithSubCommands(cmds...)
 }
 
+func (init *Initializer) MainAddCommandFunc(name string, fn func() *command.Command) {
+	init.mu.RLock()
+	defer init.mu.RUnlock()
+	init.main.WithSubCommandFunc(name, fn)
+}
+
 func (init *Initializer) MainAddFlags(ffns []varflag.FlagCreateFunc) {
 	init.mu.RLock()
 	defer init.mu.RUnlock()
@@ -274,12 +326,55 @@ func (init *Initializer) SetLogger(logger logging.Logger) {
 	init.logger = logger
 }
 
+// MainWithDefaultProfile reads embedded default profile from f.
+func (init *Initializer) MainWithDefaultProfile(f fs.File) {
+	init.mu.Lock()
+	defer init.mu.Unlock()
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		init.error(fmt.Errorf("%w: failed to read default profile: %s", Error, err.Error()))
+		return
+	}
+	prefs, err := config.ParseProfile(data)
+	if err != nil {
+		init.error(fmt.Errorf("%w: default profile: %w", Error, err))
+		return
+	}
+	init.defaultProfile = data
+	init.defaultProfilePrefs = prefs
+}
+
+func (init *Initializer) SetClock(clock datetime.Clock) {
+	init.mu.Lock()
+	defer init.mu.Unlock()
+	init.clock = clock
+}
+
 func (init *Initializer) WithOptions(opts []options.Spec) {
 	init.mu.Lock()
 	defer init.mu.Unlock()
 	init.mainOptSpecs = append(init.mainOptSpecs, opts...)
 }
 
+func (init *Initializer) WithDoctorChecks(checks []doctor.Check) {
+	init.mu.Lock()
+	defer init.mu.Unlock()
+	init.doctorChecks = append(init.doctorChecks, checks...)
+}
+
+func (init *Initializer) WithHelpTopics(topics []*help.Topic) {
+	init.mu.Lock()
+	defer init.mu.Unlock()
+	init.helpTopics = append(init.helpTopics, topics...)
+}
+
+func (init *Initializer) WithEnv(env []string) {
+	init.mu.Lock()
+	defer init.mu.Unlock()
+	init.rt.WithEnv(env)
+}
+
 func (init *Initializer) WithSetup(action action.Action) {
 	init.mu.Lock()
 	defer init.mu.Unlock()
@@ -297,10 +392,15 @@ func (init *Initializer) Configure() (err error) {
 		return errs
 	}
 
+	if err := init.configurePolicy(); err != nil {
+		return err
+	}
+
 	// Setup addons
 	if err := init.configureAddons(); err != nil {
 		return err
 	}
+	init.phase("addons")
 
 	// Add custom global options
 	for _, opt := range init.mainOptSpecs {
@@ -312,10 +412,15 @@ func (init *Initializer) Configure() (err error) {
 
 	// parse commandline arguments and get active command
 	clierr := init.configureCli()
+	init.phase("cli")
 
+	if err := init.configureProject(); err != nil {
+		return err
+	}
 	if err := init.configureProfile(); err != nil {
 		return err
 	}
+	init.phase("profile")
 	// Setup brand
 	if err := init.configureBrand(); err != nil {
 		return err
@@ -324,6 +429,7 @@ func (init *Initializer) Configure() (err error) {
 	if err := init.configureLogger(); err != nil {
 		return err
 	}
+	init.phase("logger")
 	if clierr != nil {
 		return clierr
 	}
@@ -351,6 +457,7 @@ func (init *Initializer) Configure() (err error) {
 	if err := init.configureSession(); err != nil {
 		return err
 	}
+	init.phase("session")
 	internal.LogInit(init.session.Log(), "configuration completed")
 	return
 }
@@ -368,6 +475,11 @@ func (init *Initializer) Finalize() (err error) {
 	}
 	init.pendingOpts = nil
 
+	init.phase("finalize")
+	session.AttachProject(init.session, init.project)
+	session.AttachDocs(init.session, init.helpTopics)
+	init.startupReport()
+
 	init.rt.SetMain(init.cmd)
 	init.cmd = nil
 
@@ -387,6 +499,7 @@ func (init *Initializer) Finalize() (err error) {
 
 	took := time.Since(init.createdAt)
 	init.rt.InitStats(init.createdAt, took)
+	init.startup.Took = took
 
 	session.Log().LogDepth(1, logging.LevelDebug, "initialization completed", slog.String("took", took.String()))
 
@@ -394,9 +507,79 @@ func (init *Initializer) Finalize() (err error) {
 	return nil
 }
 
+// startupReport completes startup report with settings and addons
+// and attaches it to the session.
+func (init *Initializer) startupReport() {
+	for _, s := range init.session.Settings().All() {
+		if init.layers != nil {
+			if v, ok := init.layers.Explain(s.Key()); ok && v.Value == s.Value().String() {
+				init.startup.Settings = append(init.startup.Settings, startupSetting(v))
+				continue
+			}
+		}
+		var source string
+		switch {
+		case s.IsSet():
+			source = "profile"
+		case s.Value().String() != s.Default().String():
+			source = "app"
+		default:
+			continue
+		}
+		init.startup.Settings = append(init.startup.Settings, session.StartupSetting{
+			Key:    s.Key(),
+			Source: source,
+			Value:  s.Value().String(),
+		})
+	}
+	for _, dir := range paths.Dirs {
+		if v := os.Getenv(paths.EnvKey(init.defaults.slug, dir)); v != "" {
+			init.startup.Settings = append(init.startup.Settings, session.StartupSetting{
+				Key:    "app.fs." + dir + "_dir",
+				Source: "env",
+				Value:  v,
+			})
+		}
+	}
+	for _, info := range init.addonm.Info() {
+		init.startup.Addons = append(init.startup.Addons, session.StartupAddon{
+			Slug:    info.Slug,
+			Version: info.Version.String(),
+		})
+	}
+	session.AttachStartupReport(init.session, &init.startup)
+}
+
+func startupSetting(v config.Value) session.StartupSetting {
+	s := session.StartupSetting{
+		Key:    v.Key,
+		Source: string(v.Source),
+		Path:   v.Path,
+		Value:  v.Value,
+	}
+	for _, o := range v.Overridden {
+		s.Overridden = append(s.Overridden, startupSetting(o))
+	}
+	return s
+}
+
 // ////////////////////////////////////////////////////////////////////////////
 // Configuration stage
 
+// configurePolicy reads system policy and removes addons it disables.
+func (init *Initializer) configurePolicy() (err error) {
+	internal.LogInitDepth(init.log, 1, "configuring policy")
+	if init.policy, err = config.ReadPolicy(config.PolicyFile(init.defaults.slug)); err != nil {
+		return fmt.Errorf("%w: %w", Error, err)
+	}
+	for _, slug := range init.policy.Addons {
+		if init.addonm.Remove(slug) {
+			internal.LogInit(init.log, "addon disabled by policy", slog.String("addon", slug))
+		}
+	}
+	return nil
+}
+
 func (init *Initializer) configureAddons() error {
 	internal.LogInitDepth(init.log, 1, "configuring addons")
 	if err := init.addonm.ExtendSettings(init.settingsb); err != nil {
@@ -405,7 +588,21 @@ func (init *Initializer) configureAddons() error {
 	if err := init.addonm.ExtendOptions(init.opts); err != nil {
 		return err
 	}
+	var addons []string
+	for _, info := range init.addonm.Info() {
+		addons = append(addons, info.Slug+"@"+info.Version.String())
+	}
+	if err := init.opts.Set("app.addons", strings.Join(addons, ",")); err != nil {
+		return err
+	}
+
 	commands := init.addonm.Commands()
+	if !init.defaults.cliWithoutDoctorCmd {
+		checks := slices.Concat(init.doctorChecks, init.addonm.DoctorChecks())
+		commands = append(commands, doctor.Command(checks...))
+	}
+	init.helpTopics = append(init.helpTopics, init.addonm.Docs()...)
+	commands = append(commands, clicommands.Help(init.helpTopics...))
 	init.main.WithSubCommands(commands...)
 
 	init.rt.AddServices(init.addonm.Services())
@@ -421,6 +618,12 @@ var ErrExitWithSuccess = errors.New("exit with success")
 func (init *Initializer) configureCli() error {
 	internal.LogInitDepth(init.log, 1, "configuring command line interface")
 
+	for _, path := range init.policy.Commands {
+		if !init.main.Disable(path, "disabled by system policy") {
+			internal.LogInit(init.log, "policy disables unknown command", slog.String("command", path))
+		}
+	}
+
 	cmd, cmdlog, err := command.Compile(init.main)
 	logerr := init.log.ConsumeQueue(cmdlog)
 	if logerr != nil {
@@ -444,13 +647,52 @@ func (init *Initializer) configureCli() error {
 	init.main = nil
 
 	if cmd.Flag("version").Present() {
-		fmt.Println(init.opts.Get("app.version").String())
+		if cmd.Flag("output").String() != "json" {
+			fmt.Println(init.opts.Get("app.version").String())
+			return ErrExitWithSuccess
+		}
+		info := version.ReadInfo()
+		info.Version = init.opts.Get("app.version").String()
+		if module := init.opts.Get("app.module").String(); module != "" {
+			info.Module = module
+		}
+		data, err := json.Marshal(info)
+		if err != nil {
+			return fmt.Errorf("%w: failed to encode version info: %s", Error, err.Error())
+		}
+		fmt.Println(string(data))
 		return ErrExitWithSuccess
 	}
 
 	return nil
 }
 
+// configureProject detects project working directory belongs to
+// and reads project local config file.
+func (init *Initializer) configureProject() error {
+	internal.LogInitDepth(init.log, 1, "configuring project")
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	init.projectLayer, err = config.ReadLayer(config.SourceProject, config.FindProjectFile(init.defaults.slug, wd))
+	if err != nil {
+		return fmt.Errorf("%w: %w", Error, err)
+	}
+
+	proj, err := project.Detect(wd)
+	if err != nil {
+		if errors.Is(err, project.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("%w: %w", Error, err)
+	}
+	proj.Load(init.projectLayer.Path, init.projectLayer.Values)
+	init.project = proj
+	internal.LogInit(init.log, "project detected", slog.String("root", proj.Root))
+	return nil
+}
+
 func (init *Initializer) configureProfile() (err error) {
 	internal.LogInitDepth(init.log, 1, "configuring profile")
 	const prefFilename = "profile.preferences"
@@ -462,7 +704,8 @@ func (init *Initializer) configureProfile() (err error) {
 		defaultProfileName = init.defaults.configDefaultProfile
 		loadSlug           = init.defaults.configDefaultProfile
 
-		pref *settings.Preferences
+		pref         *settings.Preferences
+		profileLayer = config.Layer{Source: config.SourceProfile}
 	)
 
 	var profileExists = func(slug string) bool {
@@ -512,6 +755,8 @@ func (init *Initializer) configureProfile() (err error) {
 				mode = "devel"
 			}
 			attrs = append(attrs, slog.String("mode", mode))
+			init.startup.Profile = currentProfileName
+			init.startup.ProfileMode = mode
 			if currentProfileName != defaultProfileName {
 				attrs = append(attrs, slog.String("default", defaultProfileName))
 			}
@@ -538,7 +783,11 @@ func (init *Initializer) configureProfile() (err error) {
 					if err := init.utilMkdir("create default profile directory", filepath.Join(profilesDir, dp), 0700); err != nil {
 						return fmt.Errorf("%w: failed to create default profile directory %s", Error, err)
 					}
-					if err := init.utilWriteFile("write default profile preferences", filepath.Join(profilesDir, dp, prefFilename), []byte{}, 0600); err != nil {
+					seed, err := init.defaultPreferences()
+					if err != nil {
+						return err
+					}
+					if err := init.utilWriteFile("write default profile preferences", filepath.Join(profilesDir, dp, prefFilename), seed, 0600); err != nil {
 						return fmt.Errorf("%w: failed to write default profile preferences %s", Error, err)
 					}
 					internal.LogInit(init.log, "created default profile", slog.String("profile", dp))
@@ -555,7 +804,13 @@ func (init *Initializer) configureProfile() (err error) {
 			if err := init.utilMkdir("create default profile directory", filepath.Join(profilesDir, loadSlug), 0700); err != nil {
 				return fmt.Errorf("%w: failed to create development profile directory for %s profile: %s", Error, currentProfileName, err)
 			}
-			if err := init.utilWriteFile("write default profile preferences", filepath.Join(profilesDir, loadSlug, prefFilename), []byte{}, 0600); err != nil {
+			seed := []byte{}
+			if currentProfileName == defaultProfileName {
+				if seed, err = init.defaultPreferences(); err != nil {
+					return err
+				}
+			}
+			if err := init.utilWriteFile("write default profile preferences", filepath.Join(profilesDir, loadSlug, prefFilename), seed, 0600); err != nil {
 				return fmt.Errorf("%w: failed to write development profile preferences for %s profile:  %s", Error, currentProfileName, err)
 			}
 			goto LoadPreferences
@@ -564,6 +819,9 @@ func (init *Initializer) configureProfile() (err error) {
 
 LoadPreferences:
 	{
+		if err := init.writeDefaultProfileReference(); err != nil {
+			return err
+		}
 		loadProfileConfigDir := filepath.Join(profilesDir, loadSlug)
 		if err := init.opts.Set("app.fs.path.profile", loadProfileConfigDir); err != nil {
 			return err
@@ -577,40 +835,41 @@ LoadPreferences:
 			return fmt.Errorf("%w: profile %q loading error: %s", Error, currentProfileName, err.Error())
 		} else {
 			internal.LogInit(init.log, "loading preferences from", slog.String("path", loadPrefFilePath))
-			prefFile, err := os.Open(loadPrefFilePath)
-			if err != nil {
-				return err
-			}
-			defer prefFile.Close()
-			var (
-				data []string
-			)
-			dataDecoder := gob.NewDecoder(prefFile)
-			if err = dataDecoder.Decode(&data); err != nil && !errors.Is(err, io.EOF) {
-				return fmt.Errorf("%w: failed to decode preferences %s", Error, err.Error())
-			}
-			prefsMap, err := vars.ParseMapFromSlice(data)
-			if err != nil {
-				return err
-			}
-			pref = settings.NewPreferences()
-
-			for _, d := range prefsMap.All() {
-				pref.Set(d.Name(), d.Value().String())
-			}
+			init.startup.Preferences = loadPrefFilePath
+			// preferences are read by loadProfile unless compiled
+			// profile cache is up to date
+			profileLayer.Path = loadPrefFilePath
+		}
+		if err := init.unlockProfile(loadPrefFilePath, loadSlug); err != nil {
+			return err
 		}
 	}
 
 LoadProfile:
+	if init.startup.Profile == "" {
+		init.startup.Profile = currentProfileName
+		init.startup.ProfileMode = "production"
+	}
 	schema, err := init.settingsb.Schema(init.opts.Get("app.module").String(), init.opts.Get("app.version").String())
 	if err != nil {
 		return err
 	}
 
-	init.profile, err = schema.Profile(currentProfileName, pref)
+	if init.defaults.configDisabled {
+		init.profile, err = schema.Profile(currentProfileName, pref)
+	} else {
+		init.profile, err = init.loadProfile(&schema, currentProfileName, profileLayer)
+	}
 	if err != nil {
 		return err
 	}
+	if profileLayer.Path != "" {
+		// changed persistent settings are saved on exit or sess.Settings().Flush
+		prefPath := profileLayer.Path
+		init.profile.OnFlush(func(changes map[string]string) error {
+			return config.UpdatePreferences(prefPath, init.defaults.configBackups, changes)
+		})
+	}
 	defer func() {
 		// dereference the settings bluepirnt
 		init.settings = nil
@@ -619,10 +878,12 @@ LoadProfile:
 
 	// Get user cache directory
 	var userCacheDir string
-	if testing.Testing() {
+	if override := init.defaults.fsOverrides["cache"]; override != "" {
+		userCacheDir = override
+	} else if testing.Testing() {
 		userCacheDir = filepath.Join(init.opts.Get("app.fs.path.tmp").String(), "cache")
 	} else {
-		userCacheDir, err = os.UserCacheDir()
+		userCacheDir, err = osUserCacheDir()
 		if err != nil {
 			return fmt.Errorf("%w: failed to get user cache dir %s", Error, err)
 		}
@@ -640,6 +901,9 @@ LoadProfile:
 	if err := init.opts.Set("app.fs.path.cache", profileCacheDir); err != nil {
 		return err
 	}
+	if err := init.opts.Set("app.fs.path.cache_root", userCacheDir); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -670,6 +934,7 @@ func (init *Initializer) configureLogger() (err error) {
 		tslocStr        string
 		timestampFormat string
 		noTimestamp     bool
+		format          = "auto"
 	)
 	if init.profile != nil {
 		lvl, err = logging.LevelFromString(init.profile.Get("app.logging.level").Value().String())
@@ -681,6 +946,7 @@ func (init *Initializer) configureLogger() (err error) {
 		tslocStr = init.profile.Get("app.datetime.location").Value().String()
 		timestampFormat = init.profile.Get("app.logging.timeestamp_format").Value().String()
 		noTimestamp = init.profile.Get("app.logging.no_timestamp").Value().Bool()
+		format = init.profile.Get("app.logging.format").Value().String()
 	} else {
 		lvl = logging.LevelDebug
 		noSource = true
@@ -717,6 +983,14 @@ func (init *Initializer) configureLogger() (err error) {
 
 	}
 
+	if init.clock == nil {
+		if init.profile != nil && init.profile.Get("app.engine.deterministic").Value().Bool() {
+			init.clock = datetime.NewFakeClock(time.Now())
+		} else {
+			init.clock = datetime.SystemClock()
+		}
+	}
+
 	slog.SetLogLoggerLevel(slog.Level(lvl))
 	if init.logger != nil {
 		init.logger.SetLevel(lvl)
@@ -740,7 +1014,14 @@ func (init *Initializer) configureLogger() (err error) {
 		return err
 	}
 	logopts.TimeLocation = tsloc
+	logopts.Clock = init.clock
 	logopts.TimestampFormat = timestampFormat
+	switch format {
+	case "json":
+		logopts.JSON = true
+	case "auto":
+		logopts.JSON = session.DetectRuntime().Kubernetes
+	}
 
 	if init.brand != nil {
 		logopts.Theme = init.brand.ANSI()
@@ -785,6 +1066,7 @@ func (init *Initializer) configureSession() error {
 		Profile:    init.profile,
 		Logger:     init.logger,
 		Opts:       init.opts,
+		Clock:      init.clock,
 		ReadyEvent: init.sessionReadyEvent,
 		EventCh:    init.evch,
 		APIs:       init.addonm.GetAPIs(),
@@ -829,8 +1111,17 @@ func (init *Initializer) utilWriteFile(msg, name string, data []byte, perm fs.Fi
 	return nil
 }
 
+// utilShowHelp prints help of active command. Rendered help is cached
+// per command, terminal width and language, and reused until application
+// version or anything help is rendered from changes.
 func (init *Initializer) utilShowHelp() error {
-	theme := init.brand.ANSI()
+	cacheFile, sum := init.utilHelpCache()
+	if cacheFile != "" {
+		if data, ok := help.ReadCache(cacheFile, sum); ok {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+	}
 
 	h := help.New(
 		help.Info{
@@ -844,15 +1135,7 @@ func (init *Initializer) utilShowHelp() error {
 			Usage:          init.cmd.Usage(),
 			Info:           init.cmd.Info(),
 		},
-		help.Style{
-			Primary:     ansicolor.Style{FG: theme.Primary, Format: ansicolor.Bold},
-			Info:        ansicolor.Style{FG: theme.Info},
-			Version:     ansicolor.Style{FG: theme.Accent, Format: ansicolor.Faint},
-			Credits:     ansicolor.Style{FG: theme.Secondary},
-			License:     ansicolor.Style{FG: theme.Accent, Format: ansicolor.Faint},
-			Description: ansicolor.Style{FG: theme.Secondary},
-			Category:    ansicolor.Style{FG: theme.Accent, Format: ansicolor.Bold},
-		},
+		init.utilHelpStyle(),
 	)
 
 	for _, scmd := range init.cmd.SubCommands() {
@@ -860,6 +1143,7 @@ func (init *Initializer) utilShowHelp() error {
 	}
 
 	h.AddCategoryDescriptions(init.cmd.Categories())
+	h.AddCategoryWeights(init.cmd.CategoryWeights())
 
 	if !init.cmd.IsRoot() {
 		h.AddCommandFlags(init.cmd.Flags())
@@ -867,7 +1151,314 @@ func (init *Initializer) utilShowHelp() error {
 	}
 
 	h.AddGlobalFlags(init.cmd.GlobalFlags())
-	return h.Print()
+	if cacheFile == "" {
+		return h.Print()
+	}
+	var buf bytes.Buffer
+	if err := h.Fprint(&buf); err != nil {
+		return err
+	}
+	if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if err := help.WriteCache(cacheFile, sum, buf.Bytes()); err != nil {
+		internal.Log(init.log, "failed to cache help", slog.String("err", err.Error()))
+	}
+	return nil
+}
+
+// utilHelpCache returns path of help cache file of active command and
+// checksum of sources help is rendered from. Path is empty when cache
+// directory is not known yet.
+func (init *Initializer) utilHelpCache() (path, sum string) {
+	cacheRoot := init.opts.Get("app.fs.path.cache_root").String()
+	if cacheRoot == "" || init.cmd == nil || init.profile == nil {
+		return "", ""
+	}
+	var lang string
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang = os.Getenv(key); lang != "" {
+			break
+		}
+	}
+	name := help.Sum(init.cmd.Path(), os.Getenv("COLUMNS"), lang)
+	path = filepath.Join(cacheRoot, "help", name[:16]+".txt")
+	sum = help.Sum(
+		init.opts.Get("app.version").String(),
+		init.cmd.Fingerprint(),
+		init.profile.Get("app.name").String(),
+		init.profile.Get("app.description").String(),
+		init.profile.Get("app.copyright_by").String(),
+		init.profile.Get("app.copyright_since").String(),
+		init.profile.Get("app.license").String(),
+		init.profile.Get("app.address").String(),
+		strconv.Itoa(time.Now().Year()),
+		strconv.FormatBool(render.Hyperlinks()),
+		fmt.Sprint(init.utilHelpStyle()),
+	)
+	return path, sum
+}
+
+func (init *Initializer) utilHelpStyle() help.Style {
+	theme := init.brand.ANSI()
+	return help.Style{
+		Primary:     ansicolor.Style{FG: theme.Primary, Format: ansicolor.Bold},
+		Info:        ansicolor.Style{FG: theme.Info},
+		Version:     ansicolor.Style{FG: theme.Accent, Format: ansicolor.Faint},
+		Credits:     ansicolor.Style{FG: theme.Secondary},
+		License:     ansicolor.Style{FG: theme.Accent, Format: ansicolor.Faint},
+		Description: ansicolor.Style{FG: theme.Secondary},
+		Category:    ansicolor.Style{FG: theme.Accent, Format: ansicolor.Bold},
+	}
+}
+
+// ShowUsage prints help or usage lines of the command err relates to
+// when err is command.UsageError, it reports whether anything was printed.
+// Errors returned while compiling the command line have no compiled
+// command and only usage lines are printed.
+func (init *Initializer) ShowUsage(err error) bool {
+	uerr, ok := command.AsUsageError(err)
+	if !ok || init.brand == nil {
+		return false
+	}
+	if uerr.Cmd != nil && init.profile != nil && init.profile.Get("app.cli.usage_help").Value().Bool() {
+		init.cmd = uerr.Cmd
+		return init.utilShowHelp() == nil
+	}
+	h := help.New(help.Info{Usage: uerr.Usage}, init.utilHelpStyle())
+	return h.PrintUsage(uerr.Command) == nil
+}
+
+// loadProfile loads profile from configuration layers. Layers resolved
+// and validated on previous start are loaded from compiled profile cache
+// stored next to profile preferences when none of their sources changed,
+// which skips parsing and validation of preferences. Cache which is
+// missing, stale or corrupted is ignored and rewritten.
+func (init *Initializer) loadProfile(schema *settings.Schema, name string, profile config.Layer) (*settings.Profile, error) {
+	var (
+		keys       = schema.Keys()
+		systemFile = config.SystemFile(init.defaults.slug)
+		env        = config.EnvLayer(init.defaults.slug, keys)
+		policy     = init.policy.Layer(keys)
+		remote     = init.remoteLayer(filepath.Dir(profile.Path))
+		cachePath  = filepath.Join(filepath.Dir(profile.Path), config.CacheFilename)
+	)
+	flags, err := init.flagLayer(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := append([]string{
+		init.opts.Get("app.module").String(),
+		init.opts.Get("app.version").String(),
+		strconv.FormatBool(init.defaults.configStrict),
+	}, keys...)
+	sum := config.SourceSum(identity, []string{systemFile, profile.Path}, init.projectLayer, remote, env, flags, policy)
+
+	if cache, ok := config.ReadCache(cachePath, sum); ok {
+		r := cache.Resolver()
+		pref := settings.NewPreferences()
+		for key, val := range r.Values() {
+			pref.Set(key, val)
+		}
+		if p, err := schema.TrustedProfile(name, pref); err == nil {
+			init.layers = r
+			internal.LogInit(init.log, "loaded profile from cache", slog.String("path", cachePath))
+			return p, nil
+		}
+	}
+
+	if profile.Values, err = config.ReadPreferences(profile.Path); err != nil {
+		if !errors.Is(err, config.ErrCorrupted) {
+			return nil, err
+		}
+		if profile.Values, err = init.recoverPreferences(profile.Path, err); err != nil {
+			return nil, err
+		}
+	}
+	system, err := config.ReadLayer(config.SourceSystem, systemFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", Error, err)
+	}
+	pref, err := init.layerPreferences(keys, system, profile, remote, env, flags, policy)
+	if err != nil {
+		return nil, err
+	}
+	p, err := schema.Profile(name, pref)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.WriteCache(cachePath, &config.Cache{Sum: sum, Layers: init.layers.Layers()}); err != nil {
+		internal.LogInit(init.log, "failed to write profile cache", slog.String("err", err.Error()))
+	}
+	return p, nil
+}
+
+// unlockProfile resolves key of profile preferences at path when they
+// are encrypted or profile encryption is enabled, and encrypts or
+// decrypts existing files when setting has changed since last start.
+func (init *Initializer) unlockProfile(path, profile string) error {
+	encrypted, err := config.Encrypted(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", Error, err)
+	}
+	if !encrypted && !init.defaults.configEncrypt {
+		return nil
+	}
+	key, err := config.ProfileKey(init.defaults.slug, profile, !encrypted)
+	if err != nil {
+		return fmt.Errorf("%w: %w", Error, err)
+	}
+	config.Unlock(filepath.Dir(path), key, init.defaults.configEncrypt)
+	if encrypted == init.defaults.configEncrypt {
+		return nil
+	}
+	if err := config.ConvertPreferences(path, init.defaults.configBackups); err != nil {
+		return fmt.Errorf("%w: %w", Error, err)
+	}
+	if init.defaults.configEncrypt {
+		internal.LogInit(init.log, "profile encrypted", slog.String("path", path))
+	} else {
+		internal.LogInit(init.log, "profile decrypted", slog.String("path", path))
+	}
+	return nil
+}
+
+// remoteLayer returns remote configuration layer cached in profile
+// directory dir. Remote configuration which can not be fetched does not
+// prevent application from starting, cached copy is used instead.
+func (init *Initializer) remoteLayer(dir string) config.Layer {
+	if init.defaults.configRemote == "" {
+		return config.Layer{Source: config.SourceRemote}
+	}
+	remote, err := config.NewRemote(init.defaults.configRemote, init.defaults.configRemoteKey,
+		init.defaults.configRemoteInterval, filepath.Join(dir, config.RemoteFilename))
+	if err != nil {
+		init.log.Warn("remote configuration disabled", slog.String("err", err.Error()))
+		return config.Layer{Source: config.SourceRemote}
+	}
+	layer, err := remote.Layer(context.Background())
+	if err != nil {
+		init.log.Warn("remote configuration not refreshed", slog.String("err", err.Error()))
+	}
+	return layer
+}
+
+// recoverPreferences recovers corrupted preferences file at path from
+// its most recent valid backup or resets it to defaults, cause is error
+// reading the file.
+func (init *Initializer) recoverPreferences(path string, cause error) (map[string]string, error) {
+	prefs, rec, err := config.RecoverPreferences(path, init.defaults.configBackups)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to recover %s: %w", Error, cause.Error(), err)
+	}
+	attrs := []slog.Attr{
+		slog.String("err", cause.Error()),
+		slog.String("corrupted", rec.Corrupted),
+	}
+	if rec.Backup != "" {
+		init.log.Warn("profile preferences were corrupted and restored from backup, settings changed after the backup was made are lost",
+			append(attrs, slog.String("backup", rec.Backup))...)
+	} else {
+		init.log.Warn("profile preferences were corrupted and no valid backup was found, using default settings; reapply your settings with config set",
+			attrs...)
+	}
+	return prefs, nil
+}
+
+// flagLayer returns layer of settings given with --set flags.
+func (init *Initializer) flagLayer(keys []string) (config.Layer, error) {
+	if init.cmd == nil {
+		return config.Layer{Source: config.SourceFlag}, nil
+	}
+	// flag input contains flag names along with values
+	var pairs []string
+	for _, in := range init.cmd.Flag("set").Input() {
+		if !strings.HasPrefix(in, "-") {
+			pairs = append(pairs, in)
+		}
+	}
+	flags, err := config.FlagLayer(pairs)
+	if err != nil {
+		return flags, fmt.Errorf("%w: %w", Error, err)
+	}
+	for _, u := range config.UnknownKeys(flags, keys) {
+		if u.Suggestion != "" {
+			return flags, fmt.Errorf("%w: --set setting %q does not exist, did you mean %q?", Error, u.Key, u.Suggestion)
+		}
+		return flags, fmt.Errorf("%w: --set setting %q does not exist", Error, u.Key)
+	}
+	return flags, nil
+}
+
+// layerPreferences resolves preferences from configuration layers:
+// system config file, profile preferences, project local config file
+// found upward from working directory, remote configuration,
+// environment, --set flags and system policy which overrides all other
+// layers.
+func (init *Initializer) layerPreferences(keys []string, system, profile, remote, env, flags, policy config.Layer) (*settings.Preferences, error) {
+	slug := init.defaults.slug
+	r := &config.Resolver{}
+	r.Add(system)
+	r.Add(profile)
+	r.Add(init.projectLayer)
+	r.Add(remote)
+	r.Add(env)
+	r.Add(flags)
+	r.Add(policy)
+
+	if init.defaults.configStrict {
+		var errs []error
+		for _, layer := range []config.Layer{system, profile, init.projectLayer, remote, policy} {
+			for _, u := range config.UnknownKeys(layer, keys) {
+				errs = append(errs, u)
+			}
+		}
+		for _, u := range config.UnknownEnv(slug, keys) {
+			errs = append(errs, u)
+		}
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("%w: strict mode: %w", Error, errors.Join(errs...))
+		}
+	}
+
+	// unknown keys are left for profile to ignore or migrate
+	pref := settings.NewPreferences()
+	for key, val := range r.Values() {
+		pref.Set(key, val)
+	}
+	init.layers = r
+	return pref, nil
+}
+
+// defaultPreferences returns profile.preferences content seeded from
+// embedded default profile.
+func (init *Initializer) defaultPreferences() ([]byte, error) {
+	if len(init.defaultProfilePrefs) == 0 {
+		return []byte{}, nil
+	}
+	for key := range init.defaultProfilePrefs {
+		if _, err := init.settingsb.GetSpec(key); err != nil {
+			return nil, fmt.Errorf("%w: default profile: %s", Error, err.Error())
+		}
+	}
+	return config.EncodePreferences(init.defaultProfilePrefs)
+}
+
+// writeDefaultProfileReference copies embedded default profile as is
+// to config directory so that it can be used as documented reference.
+func (init *Initializer) writeDefaultProfileReference() error {
+	if init.defaultProfile == nil {
+		return nil
+	}
+	dest := filepath.Join(init.opts.Get("app.fs.path.config").String(), config.DefaultProfileFilename)
+	if current, err := os.ReadFile(dest); err == nil && bytes.Equal(current, init.defaultProfile) {
+		return nil
+	}
+	if err := init.utilWriteFile("write default profile reference", dest, init.defaultProfile, 0600); err != nil {
+		return fmt.Errorf("%w: failed to write default profile reference %s", Error, err)
+	}
+	return nil
 }
 
 func (init *Initializer) error(err error) {
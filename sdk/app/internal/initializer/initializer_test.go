@@ -80,7 +80,7 @@ func TestDefaultOptions(t *testing.T) {
 		doCalled           bool
 	)
 	app.BeforeAlways(func(sess *session.Context, args action.Args) error {
-		testutils.Equal(t, 16, sess.Opts().Len(), "invalid default runtime options count")
+		testutils.Equal(t, 22, sess.Opts().Len(), "invalid default runtime options count")
 
 		// app.address
 		host, err := os.Hostname()
@@ -94,8 +94,12 @@ func TestDefaultOptions(t *testing.T) {
 		tmpdir := filepath.Join(os.TempDir(), sess.Get("app.slug").String(), fmt.Sprintf("instance-%s", sess.Get("app.instance.id").String()))
 		// app.fs.path.cache
 		testutils.Equal(t, filepath.Join(tmpdir, "cache", "profiles", "default"), sess.Get("app.fs.path.cache").String(), "app.fs.path.cache")
+		// app.fs.path.cache_root
+		testutils.Equal(t, filepath.Join(tmpdir, "cache"), sess.Get("app.fs.path.cache_root").String(), "app.fs.path.cache_root")
 		// app.fs.path.config
 		testutils.Equal(t, filepath.Join(tmpdir, "config"), sess.Get("app.fs.path.config").String(), "app.fs.path.config")
+		// app.fs.path.data
+		testutils.Equal(t, filepath.Join(tmpdir, "data"), sess.Get("app.fs.path.data").String(), "app.fs.path.data")
 		// app.fs.path.home
 		home, err := os.UserHomeDir()
 		if err != nil {
@@ -112,6 +116,8 @@ func TestDefaultOptions(t *testing.T) {
 			return err
 		}
 		testutils.Equal(t, wd, sess.Get("app.fs.path.wd").String(), "app.fs.path.wd")
+		// app.fs.path.state
+		testutils.Equal(t, filepath.Join(tmpdir, "state"), sess.Get("app.fs.path.state").String(), "app.fs.path.state")
 		// app.fs.path.tmp
 		testutils.Equal(t, tmpdir, sess.Get("app.fs.path.tmp").String(), "app.fs.path.tmp")
 
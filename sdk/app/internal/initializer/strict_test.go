@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package initializer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/sdk/config"
+)
+
+func TestStrictUnknownKeys(t *testing.T) {
+	const slug = "happy-strict-test"
+	keys := []string{"app.name", "app.stats.enabled"}
+
+	tests := []struct {
+		name string
+		// layer is source unknown key is provided by.
+		layer config.Source
+		key   string
+		want  string
+	}{
+		{
+			name:  "profile",
+			layer: config.SourceProfile,
+			key:   "app.stats.enabeld",
+			want:  `unknown setting "app.stats.enabeld" in profile (profile.preferences), did you mean "app.stats.enabled"?`,
+		},
+		{
+			name:  "project",
+			layer: config.SourceProject,
+			key:   "app.nmae",
+			want:  `unknown setting "app.nmae" in project (happy.yaml), did you mean "app.name"?`,
+		},
+		{
+			name:  "env",
+			layer: config.SourceEnv,
+			key:   "HAPPY_STRICT_TEST_APP_STATS_ENABLD",
+			want:  `unknown setting "HAPPY_STRICT_TEST_APP_STATS_ENABLD" in env, did you mean "HAPPY_STRICT_TEST_APP_STATS_ENABLED"?`,
+		},
+	}
+	for _, tt := range tests {
+		for _, strict := range []bool{true, false} {
+			name := tt.name + "/strict"
+			if !strict {
+				name = tt.name + "/non-strict"
+			}
+			t.Run(name, func(t *testing.T) {
+				var (
+					profile = config.Layer{Source: config.SourceProfile, Path: "profile.preferences", Values: map[string]string{"app.name": "profile"}}
+					project = config.Layer{Source: config.SourceProject, Path: "happy.yaml", Values: map[string]string{}}
+					env     = config.Layer{Source: config.SourceEnv, Values: map[string]string{}}
+				)
+				switch tt.layer {
+				case config.SourceProfile:
+					profile.Values[tt.key] = "true"
+				case config.SourceProject:
+					project.Values[tt.key] = "project"
+				case config.SourceEnv:
+					t.Setenv(tt.key, "true")
+				}
+
+				init := &Initializer{
+					projectLayer: project,
+					defaults:     &defaults{slug: slug, configStrict: strict},
+				}
+				pref, err := init.layerPreferences(keys,
+					config.Layer{Source: config.SourceSystem}, profile,
+					config.Layer{Source: config.SourceRemote}, env,
+					config.Layer{Source: config.SourceFlag},
+					config.Layer{Source: config.SourcePolicy},
+				)
+				if !strict {
+					testutils.NoError(t, err, "unknown keys must be ignored without strict mode")
+					testutils.NotNil(t, pref)
+					return
+				}
+				testutils.ErrorIs(t, err, config.ErrUnknownSetting)
+				var u config.Unknown
+				if testutils.True(t, errors.As(err, &u), "want config.Unknown") {
+					testutils.Equal(t, tt.layer, u.Source)
+				}
+				testutils.True(t, strings.Contains(err.Error(), tt.want), "error %q must contain %q", err.Error(), tt.want)
+			})
+		}
+	}
+}
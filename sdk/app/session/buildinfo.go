@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import "github.com/happy-sdk/happy/pkg/version"
+
+// BuildInfo returns build information of running binary, version and
+// module are reported as resolved by the application.
+func (c *Context) BuildInfo() version.Info {
+	info := version.ReadInfo()
+	if v := c.Get("app.version").String(); v != "" {
+		info.Version = v
+	}
+	if m := c.Get("app.module").String(); m != "" {
+		info.Module = m
+	}
+	return info
+}
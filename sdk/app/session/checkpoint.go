@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/options"
+	"github.com/happy-sdk/happy/pkg/vars"
+)
+
+var ErrCheckpoint = fmt.Errorf("%w: checkpoint", Error)
+
+var checkpointName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+type checkpoint struct {
+	Name      string             `json:"name"`
+	CreatedAt time.Time          `json:"created_at"`
+	Options   []checkpointOption `json:"options"`
+}
+
+type checkpointOption struct {
+	Key   string    `json:"key"`
+	Kind  vars.Kind `json:"kind"`
+	Value string    `json:"value"`
+}
+
+// Checkpoint saves current values of mutable runtime options under
+// given name to application state directory, so that long-lived
+// interactive tools can later resume with Restore.
+func (c *Context) Checkpoint(name string) error {
+	p, err := c.checkpointPath(name)
+	if err != nil {
+		return err
+	}
+	cp := checkpoint{
+		Name:      name,
+		CreatedAt: c.Time().Now(),
+	}
+	c.Opts().Range(func(opt options.Option) bool {
+		if !opt.ReadOnly() {
+			cp.Options = append(cp.Options, checkpointOption{
+				Key:   opt.Name(),
+				Kind:  opt.Kind(),
+				Value: opt.Value().String(),
+			})
+		}
+		return true
+	})
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrCheckpoint, err.Error())
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return fmt.Errorf("%w: %s", ErrCheckpoint, err.Error())
+	}
+	if err := os.WriteFile(p, data, 0600); err != nil {
+		return fmt.Errorf("%w: %s", ErrCheckpoint, err.Error())
+	}
+	return nil
+}
+
+// Restore applies runtime option values saved by Checkpoint. Options
+// which are no longer defined or have become read-only are skipped.
+func (c *Context) Restore(name string) error {
+	p, err := c.checkpointPath(name)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %s not found", ErrCheckpoint, name)
+		}
+		return fmt.Errorf("%w: %s", ErrCheckpoint, err.Error())
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return fmt.Errorf("%w: %s: %s", ErrCheckpoint, name, err.Error())
+	}
+
+	opts := c.Opts()
+	for _, opt := range cp.Options {
+		if !opts.Accepts(opt.Key) || opts.Get(opt.Key).ReadOnly() {
+			continue
+		}
+		val, err := vars.ParseValueAs(opt.Value, opt.Kind)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %s", ErrCheckpoint, opt.Key, err.Error())
+		}
+		if err := opts.Set(opt.Key, val); err != nil {
+			return fmt.Errorf("%w: %s", ErrCheckpoint, err.Error())
+		}
+	}
+	return nil
+}
+
+func (c *Context) checkpointPath(name string) (string, error) {
+	if !checkpointName.MatchString(name) {
+		return "", fmt.Errorf("%w: invalid name %q", ErrCheckpoint, name)
+	}
+	dir := c.Get("app.fs.path.state").String()
+	if dir == "" {
+		return "", fmt.Errorf("%w: state directory not available", ErrCheckpoint)
+	}
+	return filepath.Join(dir, "checkpoints", name+".json"), nil
+}
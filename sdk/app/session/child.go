@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/happy-sdk/happy/pkg/options"
+	"github.com/happy-sdk/happy/sdk/logging"
+)
+
+// ChildObserver is notified when child session is created
+// and when it is destroyed.
+type ChildObserver func(child *Context, active bool)
+
+// AttachChildObserver is used internally by the SDK to track child sessions.
+func AttachChildObserver(c *Context, observer ChildObserver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.childObserver = observer
+}
+
+// Child returns scoped sub-session e.g. for handling single connection.
+// Options given in args overlay options of parent session, records
+// logged with child logger have session attribute set to name. Child
+// is destroyed when parent is destroyed, destroying child does not
+// affect parent.
+func (c *Context) Child(name string, args ...options.Arg) (*Context, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: child session name is empty", Error)
+	}
+	if c.Err() != nil {
+		return nil, fmt.Errorf("%w: can not create child %s", ErrDestroyed, name)
+	}
+
+	specs := make([]options.Spec, 0, len(args))
+	for _, arg := range args {
+		specs = append(specs, options.NewOption(arg.Key(), arg.Value(), c.Describe(arg.Key()), options.KindRuntime, nil))
+	}
+	opts, err := options.New("session."+name, specs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: child %s: %s", Error, name, err.Error())
+	}
+	if err := opts.Seal(); err != nil {
+		return nil, fmt.Errorf("%w: child %s: %s", Error, name, err.Error())
+	}
+
+	return c.newChild(name, opts, logging.WithAttrs(c.Log(), slog.String("session", name)), true), nil
+}
+
+// Scoped is used internally by the SDK to create session with own logger
+// e.g. for services with logging overrides. Scoped session is destroyed
+// with parent, but it is not listed in Children.
+func Scoped(c *Context, name string, logger logging.Logger) (*Context, error) {
+	opts, err := options.New("session."+name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: scoped %s: %s", Error, name, err.Error())
+	}
+	if err := opts.Seal(); err != nil {
+		return nil, fmt.Errorf("%w: scoped %s: %s", Error, name, err.Error())
+	}
+	return c.newChild(name, opts, logger, false), nil
+}
+
+// Owned is used internally by the SDK to create session which option
+// writes are attributed to owner e.g. session passed to addon hooks, see
+// options.Options.Owned. Owned session shares options with c, waiting
+// on it or destroying it waits on or destroys c.
+func Owned(c *Context, owner string) *Context {
+	c.mu.RLock()
+	name, opts, logger := c.name, c.opts, c.logger
+	c.mu.RUnlock()
+	owned := c.newChild(name, opts.Owned(owner), logger, false)
+	owned.mu.Lock()
+	owned.owned = true
+	owned.mu.Unlock()
+	return owned
+}
+
+// ownedParent returns parent of session created with Owned.
+func (c *Context) ownedParent() *Context {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.owned {
+		return nil
+	}
+	return c.parent
+}
+
+func (c *Context) newChild(name string, opts *options.Options, logger logging.Logger, tracked bool) *Context {
+	c.mu.Lock()
+	child := &Context{
+		parent:        c,
+		name:          name,
+		logger:        logger,
+		profile:       c.profile,
+		opts:          opts,
+		clock:         c.clock,
+		env:           c.env,
+		evch:          c.evch,
+		ready:         c.ready,
+		readyEvent:    c.readyEvent,
+		apis:          c.apis,
+		invoker:       c.invoker,
+		childObserver: c.childObserver,
+	}
+	var observer ChildObserver
+	if tracked {
+		if c.children == nil {
+			c.children = make(map[*Context]struct{})
+		}
+		c.children[child] = struct{}{}
+		observer = c.childObserver
+	}
+	c.mu.Unlock()
+
+	if observer != nil {
+		observer(child, true)
+	}
+
+	parentDone, childDone := c.Done(), child.Done()
+	go func() {
+		select {
+		case <-parentDone:
+			child.Destroy(c.Err())
+		case <-childDone:
+		}
+	}()
+	return child
+}
+
+// Name returns name of child session, empty for application session.
+func (c *Context) Name() string {
+	return c.name
+}
+
+// Children returns active child sessions created with Child.
+func (c *Context) Children() []*Context {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	children := make([]*Context, 0, len(c.children))
+	for child := range c.children {
+		children = append(children, child)
+	}
+	return children
+}
+
+func (c *Context) removeChild(child *Context) {
+	c.mu.Lock()
+	_, ok := c.children[child]
+	delete(c.children, child)
+	observer := c.childObserver
+	c.mu.Unlock()
+	if ok && observer != nil {
+		observer(child, false)
+	}
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import (
+	"context"
+	"time"
+)
+
+type contextKey struct{}
+
+// NewContext returns copy of parent context carrying session sess,
+// it can be retrieved with FromContext.
+func NewContext(parent context.Context, sess *Context) context.Context {
+	return context.WithValue(parent, contextKey{}, sess)
+}
+
+// FromContext returns session carried by ctx. Session is found when ctx
+// is session itself, derived from session e.g. with context.WithTimeout
+// or created with NewContext, so that session can be retrieved in http
+// handlers and gRPC interceptors.
+func FromContext(ctx context.Context) (*Context, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	if sess, ok := ctx.(*Context); ok {
+		return sess, true
+	}
+	sess, ok := ctx.Value(contextKey{}).(*Context)
+	return sess, ok && sess != nil
+}
+
+// WithCancel returns derived session which is destroyed when cancel is
+// called or when c is destroyed. Derived session shares logger, options
+// and settings with c.
+func (c *Context) WithCancel() (*Context, context.CancelFunc) {
+	derived := c.derive(time.Time{})
+	return derived, func() { derived.Destroy(context.Canceled) }
+}
+
+// WithTimeout returns derived session which is destroyed after timeout,
+// see WithDeadline.
+func (c *Context) WithTimeout(timeout time.Duration) (*Context, context.CancelFunc) {
+	return c.WithDeadline(time.Now().Add(timeout))
+}
+
+// WithDeadline returns derived session which is destroyed with
+// context.DeadlineExceeded error when deadline passes, when cancel is
+// called or when c is destroyed. Derived session shares logger, options
+// and settings with c.
+func (c *Context) WithDeadline(deadline time.Time) (*Context, context.CancelFunc) {
+	if cur, ok := c.Deadline(); ok && cur.Before(deadline) {
+		// current deadline is already sooner than the new one.
+		return c.WithCancel()
+	}
+	derived := c.derive(deadline)
+	timer := time.AfterFunc(time.Until(deadline), func() {
+		derived.Destroy(context.DeadlineExceeded)
+	})
+	return derived, func() {
+		timer.Stop()
+		derived.Destroy(context.Canceled)
+	}
+}
+
+func (c *Context) derive(deadline time.Time) *Context {
+	c.mu.RLock()
+	name, opts, logger := c.name, c.opts, c.logger
+	c.mu.RUnlock()
+
+	derived := c.newChild(name, opts, logger, false)
+	derived.mu.Lock()
+	derived.deadline = deadline
+	derived.mu.Unlock()
+	if c.Err() != nil {
+		derived.Destroy(c.Err())
+	}
+	return derived
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy"
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/logging"
+)
+
+// runSession runs application and calls fn with its session.
+func runSession(t *testing.T, fn func(t *testing.T, sess *session.Context)) {
+	t.Helper()
+	main := app.New(happy.Settings{Slug: "happy-session-context-test"})
+	main.WithLogger(logging.NewTestLogger(logging.LevelError))
+	main.Do(func(sess *session.Context, args action.Args) error {
+		fn(t, sess)
+		return nil
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+}
+
+// waitDone reports whether ctx is done within a second.
+func waitDone(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+func TestWithCancel(t *testing.T) {
+	runSession(t, func(t *testing.T, sess *session.Context) {
+		t.Run("cancel does not destroy parent", func(t *testing.T) {
+			derived, cancel := sess.WithCancel()
+			cancel()
+			testutils.True(t, waitDone(derived), "derived session must be done")
+			testutils.ErrorIs(t, derived.Err(), context.Canceled)
+			testutils.NoError(t, sess.Err(), "parent must not be destroyed")
+			testutils.True(t, sess.Valid(), "parent must stay valid")
+		})
+
+		t.Run("destroyed parent", func(t *testing.T) {
+			parent, err := sess.Child("parent")
+			testutils.NoError(t, err)
+			derived, cancel := parent.WithCancel()
+			defer cancel()
+			timed, tcancel := parent.WithTimeout(time.Hour)
+			defer tcancel()
+
+			errDestroyed := errors.New("parent destroyed")
+			parent.Destroy(errDestroyed)
+			testutils.True(t, waitDone(derived), "derived session must be done")
+			testutils.True(t, waitDone(timed), "session with timeout must be done")
+			testutils.ErrorIs(t, derived.Err(), errDestroyed)
+			testutils.ErrorIs(t, timed.Err(), errDestroyed)
+
+			// sessions derived from destroyed session are done immediately.
+			late, lcancel := parent.WithCancel()
+			defer lcancel()
+			testutils.True(t, waitDone(late), "session derived from destroyed parent must be done")
+			testutils.NoError(t, sess.Err(), "application session must not be destroyed")
+		})
+	})
+}
+
+func TestWithDeadline(t *testing.T) {
+	runSession(t, func(t *testing.T, sess *session.Context) {
+		now := time.Now()
+		parent, cancel := sess.WithDeadline(now.Add(time.Hour))
+		defer cancel()
+
+		t.Run("parent deadline is earlier", func(t *testing.T) {
+			derived, cancel := parent.WithDeadline(now.Add(2 * time.Hour))
+			defer cancel()
+			deadline, ok := derived.Deadline()
+			testutils.True(t, ok, "derived session must have deadline")
+			testutils.True(t, deadline.Equal(now.Add(time.Hour)), "earlier parent deadline must win")
+		})
+
+		t.Run("own deadline is earlier", func(t *testing.T) {
+			derived, cancel := parent.WithDeadline(now.Add(time.Minute))
+			defer cancel()
+			deadline, ok := derived.Deadline()
+			testutils.True(t, ok, "derived session must have deadline")
+			testutils.True(t, deadline.Equal(now.Add(time.Minute)), "earlier own deadline must win")
+		})
+
+		t.Run("deadline exceeded", func(t *testing.T) {
+			derived, cancel := parent.WithTimeout(time.Millisecond)
+			defer cancel()
+			testutils.True(t, waitDone(derived), "derived session must be done after deadline")
+			testutils.ErrorIs(t, derived.Err(), context.DeadlineExceeded)
+			testutils.NoError(t, parent.Err(), "parent must not be destroyed")
+		})
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	runSession(t, func(t *testing.T, sess *session.Context) {
+		timeout, cancel := context.WithTimeout(sess, time.Hour)
+		defer cancel()
+
+		tests := []struct {
+			name   string
+			ctx    context.Context
+			wantOK bool
+		}{
+			{name: "session", ctx: sess, wantOK: true},
+			{name: "with timeout", ctx: timeout, wantOK: true},
+			{name: "new context", ctx: session.NewContext(context.Background(), sess), wantOK: true},
+			{name: "nested", ctx: context.WithValue(session.NewContext(context.Background(), sess), struct{}{}, 1), wantOK: true},
+			{name: "background", ctx: context.Background()},
+			{name: "nil"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, ok := session.FromContext(tt.ctx)
+				testutils.Equal(t, tt.wantOK, ok)
+				if tt.wantOK {
+					testutils.True(t, got == sess, "session must be found")
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import "github.com/happy-sdk/happy/sdk/cli/help"
+
+// AttachDocs is used internally by the SDK to provide documentation
+// topics of application and addons.
+func AttachDocs(c *Context, topics []*help.Topic) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.docs = topics
+}
+
+// Docs returns documentation topics provided by application and addons,
+// same topics are rendered by help <topic> command.
+func (c *Context) Docs() []*help.Topic {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.parent != nil {
+		return c.parent.Docs()
+	}
+	return c.docs
+}
+
+// AttachHelpIndex is used internally by the SDK to provide searchable
+// index of commands and flags.
+func AttachHelpIndex(c *Context, index func() []help.Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.helpIndex = index
+}
+
+// HelpIndex returns searchable help entries of commands and flags,
+// topics are not included, see Docs.
+func (c *Context) HelpIndex() []help.Entry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.parent != nil {
+		return c.parent.HelpIndex()
+	}
+	if c.helpIndex == nil {
+		return nil
+	}
+	return c.helpIndex()
+}
@@ -0,0 +1,13 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+//go:build !windows
+
+package session
+
+import "os"
+
+func isElevated() bool {
+	return os.Geteuid() == 0
+}
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import "time"
+
+// EngineDriver drives engine loop of application which is configured
+// with app.engine.mode manual or external instead of internal ticker.
+type EngineDriver interface {
+	// Step runs single engine frame, tick and tock actions, at current
+	// session time.
+	Step() error
+	// StepAt runs single engine frame at now.
+	StepAt(now time.Time) error
+	// Due returns channel receiving time when frame is due according to
+	// app.engine.throttle_ticks, it is nil in manual mode.
+	Due() <-chan time.Time
+}
+
+// AttachEngineDriver is used internally by the SDK to provide engine
+// driver.
+func AttachEngineDriver(c *Context, d EngineDriver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.engine = d
+}
+
+// Engine returns driver of engine loop, ok is false when engine runs
+// its own ticker or application has no tick action.
+func (c *Context) Engine() (d EngineDriver, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.parent != nil {
+		return c.parent.Engine()
+	}
+	return c.engine, c.engine != nil
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var ErrEnv = fmt.Errorf("%w: env", Error)
+
+// Env is process environment of the session. Variables declared with
+// Main.WithEnv and command.Config.Env are applied to process environment
+// for the duration of the command execution and restored afterwards.
+type Env struct {
+	mu        sync.RWMutex
+	overrides []string
+	restore   []func()
+}
+
+// Get returns value of environment variable key.
+func (e *Env) Get(key string) string {
+	v, _ := e.Lookup(key)
+	return v
+}
+
+// Lookup returns value of environment variable key and reports
+// whether it is set.
+func (e *Env) Lookup(key string) (string, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return os.LookupEnv(key)
+}
+
+// Environ returns copy of process environment in the form "key=value".
+func (e *Env) Environ() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return os.Environ()
+}
+
+// Overrides returns variables applied by application and current command
+// in order they were applied. Entries without "=" are cleared variables.
+func (e *Env) Overrides() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return slices.Clone(e.overrides)
+}
+
+// Apply sets or clears environment variables. Entry in form "key=value"
+// sets variable, entry "key" without "=" clears it. Previous values are
+// restored when Restore is called.
+func (e *Env) Apply(entries ...string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, entry := range entries {
+		if entry == "" {
+			continue
+		}
+		key, value, set := strings.Cut(entry, "=")
+		if key == "" || strings.ContainsAny(key, " \t\n") {
+			return fmt.Errorf("%w: invalid entry %q", ErrEnv, entry)
+		}
+		prev, had := os.LookupEnv(key)
+		var err error
+		if set {
+			err = os.Setenv(key, value)
+		} else {
+			err = os.Unsetenv(key)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %s: %s", ErrEnv, key, err.Error())
+		}
+		e.restore = append(e.restore, func() {
+			if had {
+				_ = os.Setenv(key, prev)
+			} else {
+				_ = os.Unsetenv(key)
+			}
+		})
+		e.overrides = append(e.overrides, entry)
+	}
+	return nil
+}
+
+// Restore restores environment to state before first Apply call.
+func (e *Env) Restore() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := len(e.restore) - 1; i >= 0; i-- {
+		e.restore[i]()
+	}
+	e.restore = nil
+	e.overrides = nil
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/happy-sdk/happy/sdk/internal"
+)
+
+// featuresFile is the file in profile directory where install id
+// and experiment assignments are persisted.
+const featuresFile = "features.json"
+
+// Features provides experiment bucketing. Installation is assigned to
+// variant of experiment deterministically by install id, assignment is
+// persisted in profile directory so a given install stays in its bucket.
+type Features struct {
+	mu     sync.Mutex
+	sess   *Context
+	loaded bool
+	state  featuresState
+}
+
+type featuresState struct {
+	InstallID string            `json:"install_id"`
+	Variants  map[string]string `json:"variants"`
+}
+
+// Variant returns variant of experiment name assigned to this install.
+// Variant stays same between runs as long as it is in variants.
+// Empty string is returned when variants is empty.
+func (f *Features) Variant(name string, variants []string) string {
+	if len(variants) == 0 {
+		return ""
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.load()
+
+	if v, ok := f.state.Variants[name]; ok && slices.Contains(variants, v) {
+		return v
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(f.state.InstallID + ":" + name))
+	v := variants[h.Sum64()%uint64(len(variants))]
+	f.state.Variants[name] = v
+	f.save()
+	internal.Log(f.sess.Log(), "experiment variant assigned",
+		slog.String("experiment", name),
+		slog.String("variant", v))
+	return v
+}
+
+// Variants returns all persisted experiment assignments.
+func (f *Features) Variants() map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.load()
+	return maps.Clone(f.state.Variants)
+}
+
+// InstallID returns persistent id of this installation used for bucketing.
+func (f *Features) InstallID() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.load()
+	return f.state.InstallID
+}
+
+func (f *Features) path() string {
+	dir := f.sess.Get("app.fs.path.profile").String()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, featuresFile)
+}
+
+func (f *Features) load() {
+	if f.loaded {
+		return
+	}
+	f.loaded = true
+	if p := f.path(); p != "" {
+		data, err := os.ReadFile(p)
+		if err == nil {
+			if err := json.Unmarshal(data, &f.state); err != nil {
+				f.sess.Log().Warn("failed to load features state", slog.String("err", err.Error()))
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			f.sess.Log().Warn("failed to read features state", slog.String("err", err.Error()))
+		}
+	}
+	if f.state.Variants == nil {
+		f.state.Variants = make(map[string]string)
+	}
+	if f.state.InstallID == "" {
+		id := make([]byte, 16)
+		_, _ = rand.Read(id)
+		f.state.InstallID = hex.EncodeToString(id)
+		f.save()
+	}
+}
+
+func (f *Features) save() {
+	p := f.path()
+	if p == "" {
+		return
+	}
+	data, err := json.MarshalIndent(f.state, "", "  ")
+	if err != nil {
+		f.sess.Log().Warn("failed to encode features state", slog.String("err", err.Error()))
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		f.sess.Log().Warn("failed to save features state", slog.String("err", err.Error()))
+		return
+	}
+	if err := os.WriteFile(p, data, 0600); err != nil {
+		f.sess.Log().Warn("failed to save features state", slog.String("err", err.Error()))
+	}
+}
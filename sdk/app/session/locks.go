@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+)
+
+var ErrLocked = fmt.Errorf("%w: resource is locked", Error)
+
+var lockName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// lockRetryInterval is how often locks held by other processes are retried.
+const lockRetryInterval = 100 * time.Millisecond
+
+// Lock acquires advisory file locks with given names in application
+// state directory, so that invocations of the same application do not
+// use shared resources concurrently. When any lock is held by other
+// process Lock retries until wait elapses or session is destroyed and
+// then returns ErrLocked. Returned release func releases all locks,
+// locks are also released by the system when process exits.
+func (c *Context) Lock(wait time.Duration, names ...string) (release func() error, err error) {
+	dir := c.Get("app.fs.path.state").String()
+	if dir == "" {
+		return nil, fmt.Errorf("%w: state directory not available for locks", Error)
+	}
+	dir = filepath.Join(dir, "locks")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+
+	// acquire locks in same order in every process to avoid deadlocks
+	names = slices.Clone(names)
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	var held []*os.File
+	release = func() error {
+		var errs []error
+		for i := len(held) - 1; i >= 0; i-- {
+			errs = append(errs, unlockFile(held[i]), held[i].Close())
+		}
+		held = nil
+		return errors.Join(errs...)
+	}
+
+	deadline := time.Now().Add(wait)
+	for _, name := range names {
+		if !lockName.MatchString(name) {
+			_ = release()
+			return nil, fmt.Errorf("%w: invalid lock name %q", Error, name)
+		}
+		f, err := os.OpenFile(filepath.Join(dir, name+".lock"), os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			_ = release()
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		for {
+			locked, err := tryLockFile(f)
+			if err != nil {
+				_ = f.Close()
+				_ = release()
+				return nil, fmt.Errorf("%w: lock %s: %s", Error, name, err.Error())
+			}
+			if locked {
+				held = append(held, f)
+				break
+			}
+			if time.Now().After(deadline) {
+				_ = f.Close()
+				_ = release()
+				return nil, fmt.Errorf("%w: %s is used by other process", ErrLocked, name)
+			}
+			select {
+			case <-c.Done():
+				_ = f.Close()
+				_ = release()
+				return nil, fmt.Errorf("%w: %s: %w", ErrLocked, name, c.Err())
+			case <-time.After(lockRetryInterval):
+			}
+		}
+	}
+	return release, nil
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/happy-sdk/happy"
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/paths"
+)
+
+func TestLock(t *testing.T) {
+	stateDir := t.TempDir()
+	locksDir := filepath.Join(stateDir, "locks")
+
+	tests := []struct {
+		name string
+		// hold locks before calling Lock
+		hold []string
+		// stale lock files left by process which exited
+		stale   []string
+		names   []string
+		wantErr error
+	}{
+		{name: "single", names: []string{"db"}},
+		{name: "multiple with duplicates", names: []string{"db", "cache", "db"}},
+		{name: "released lock", names: []string{"db"}},
+		{name: "stale lock file", stale: []string{"db"}, names: []string{"db"}},
+		{name: "held", hold: []string{"db"}, names: []string{"db"}, wantErr: session.ErrLocked},
+		{name: "one of held", hold: []string{"db"}, names: []string{"cache", "db"}, wantErr: session.ErrLocked},
+		{name: "invalid name", names: []string{"../db"}, wantErr: session.Error},
+		{name: "empty name", names: []string{""}, wantErr: session.Error},
+	}
+
+	errs := make([]error, len(tests))
+	main := app.New(happy.Settings{
+		Slug: "happy-locks-test",
+		FS: paths.Settings{
+			StateDir: settings.String(stateDir),
+		},
+	})
+	main.WithLogger(logging.NewTestLogger(logging.LevelError))
+	main.Do(func(sess *session.Context, args action.Args) error {
+		for i, tt := range tests {
+			for _, name := range tt.stale {
+				if err := os.MkdirAll(locksDir, 0700); err != nil {
+					return err
+				}
+				if err := os.WriteFile(filepath.Join(locksDir, name+".lock"), []byte("12345"), 0600); err != nil {
+					return err
+				}
+			}
+			holdRelease, err := sess.Lock(0, tt.hold...)
+			if err != nil {
+				return err
+			}
+			release, err := sess.Lock(0, tt.names...)
+			errs[i] = err
+			if err == nil {
+				// locks must be released so that next case can acquire them
+				errs[i] = release()
+			}
+			if err := holdRelease(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+	_, err := os.Stat(filepath.Join(locksDir, "cache.lock"))
+	testutils.NoError(t, err, "locks must be created in state directory")
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == nil {
+				testutils.NoError(t, errs[i])
+				return
+			}
+			testutils.ErrorIs(t, errs[i], tt.wantErr)
+			if !errors.Is(tt.wantErr, session.ErrLocked) {
+				testutils.False(t, errors.Is(errs[i], session.ErrLocked), "must not be reported as locked")
+			}
+		})
+	}
+}
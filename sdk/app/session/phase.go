@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/happy-sdk/happy/sdk/events"
+	"github.com/happy-sdk/happy/sdk/internal"
+)
+
+// ErrPhase is returned when API is used in session phase which does not allow it.
+var ErrPhase = fmt.Errorf("%w:phase", Error)
+
+// Phase is a stage of session life cycle. Session moves only forward
+// through phases in the order they are declared.
+type Phase uint32
+
+const (
+	// PhaseConfiguring is phase of session created by initializer,
+	// settings and options are being loaded and addons configured.
+	PhaseConfiguring Phase = iota
+	// PhaseStarting is phase of application boot, services are
+	// registered and started and Before actions are executed.
+	PhaseStarting
+	// PhaseReady is phase of running application, it starts when
+	// session Ready channel is closed.
+	PhaseReady
+	// PhaseDraining is phase of application shutdown, it starts when
+	// command Do action returns. Event loop is stopped, After actions
+	// and exit functions are called and settings saved.
+	PhaseDraining
+	// PhaseDestroyed is phase of destroyed session, it is not
+	// dispatched as event since event loop is already stopped.
+	PhaseDestroyed
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseConfiguring:
+		return "configuring"
+	case PhaseStarting:
+		return "starting"
+	case PhaseReady:
+		return "ready"
+	case PhaseDraining:
+		return "draining"
+	case PhaseDestroyed:
+		return "destroyed"
+	}
+	return fmt.Sprintf("phase(%d)", uint32(p))
+}
+
+// PhaseEvent is dispatched when session enters a new phase, value
+// of the event is name of the phase.
+var PhaseEvent = events.New("session", "phase")
+
+// Phase returns current phase of the session. Child session reports
+// phase of the parent until it is destroyed.
+func (c *Context) Phase() Phase {
+	if c.parent != nil {
+		if c.Err() != nil {
+			return PhaseDestroyed
+		}
+		return c.parent.Phase()
+	}
+	return Phase(c.phase.Load())
+}
+
+// SetPhase is used internally by the SDK to move application session
+// to the next phase of its life cycle. Moving session back to previous
+// phase returns ErrPhase.
+func SetPhase(c *Context, phase Phase) error {
+	if c.parent != nil {
+		return fmt.Errorf("%w: can not set phase of child session %s", ErrPhase, c.name)
+	}
+	if phase > PhaseDestroyed {
+		return fmt.Errorf("%w: unknown %s", ErrPhase, phase)
+	}
+	for {
+		current := Phase(c.phase.Load())
+		if phase <= current {
+			return fmt.Errorf("%w: can not move session from %s to %s", ErrPhase, current, phase)
+		}
+		if c.phase.CompareAndSwap(uint32(current), uint32(phase)) {
+			break
+		}
+	}
+	internal.Log(c.Log(), "session phase", slog.String("phase", phase.String()))
+	if phase < PhaseDestroyed {
+		c.dispatchPhase(phase)
+	}
+	return nil
+}
+
+// dispatchPhase notifies event listeners about phase change, event is
+// dropped when nobody drains event channel anymore e.g. on shutdown.
+func (c *Context) dispatchPhase(phase Phase) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.evch == nil {
+		return
+	}
+	select {
+	case c.evch <- PhaseEvent.Create(phase.String(), nil):
+	default:
+		internal.Log(c.logger, "session phase event dropped", slog.String("phase", phase.String()))
+	}
+}
+
+// checkPhase returns ErrPhase when session is not in one of given phases.
+func (c *Context) checkPhase(op string, allowed ...Phase) error {
+	current := c.Phase()
+	for _, phase := range allowed {
+		if phase == current {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not allowed while session is %s", ErrPhase, op, current)
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/happy-sdk/happy"
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/options"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/logging"
+)
+
+func TestPhase(t *testing.T) {
+	type observed struct {
+		phase  session.Phase
+		valid  bool
+		setErr error
+	}
+	var (
+		child *session.Context
+		got   = map[string]observed{}
+	)
+	observe := func(name string, sess *session.Context) {
+		got[name] = observed{
+			phase:  sess.Phase(),
+			valid:  sess.Valid(),
+			setErr: sess.Opts().Set("phase.test", name),
+		}
+	}
+
+	main := app.New(happy.Settings{Slug: "happy-phase-test"})
+	main.WithLogger(logging.NewTestLogger(logging.LevelError))
+	main.WithOptions(options.NewOption("phase.test", "", "phase test", options.KindRuntime, nil))
+	main.Before(func(sess *session.Context, args action.Args) error {
+		observe("before", sess)
+		return nil
+	})
+	main.Do(func(sess *session.Context, args action.Args) error {
+		observe("do", sess)
+		var err error
+		child, err = sess.Child("worker")
+		if err != nil {
+			return err
+		}
+		testutils.Equal(t, session.PhaseReady, child.Phase(), "child must report parent phase")
+		return nil
+	})
+	main.AfterAlways(func(sess *session.Context, err error) error {
+		observe("after", sess)
+		return nil
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+
+	tests := []struct {
+		name      string
+		wantPhase session.Phase
+		wantValid bool
+		wantErr   error
+	}{
+		{name: "before", wantPhase: session.PhaseStarting, wantValid: true},
+		{name: "do", wantPhase: session.PhaseReady, wantValid: true},
+		{name: "after", wantPhase: session.PhaseDraining, wantErr: session.ErrPhase},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, ok := got[tt.name]
+			if !testutils.True(t, ok, "action must be called") {
+				return
+			}
+			testutils.Equal(t, tt.wantPhase, o.phase)
+			testutils.Equal(t, tt.wantValid, o.valid, "valid")
+			if tt.wantErr == nil {
+				testutils.NoError(t, o.setErr)
+			} else {
+				testutils.ErrorIs(t, o.setErr, tt.wantErr)
+			}
+		})
+	}
+	if testutils.True(t, child != nil, "child must be created") {
+		testutils.Equal(t, session.PhaseDestroyed, child.Phase())
+	}
+}
+
+func TestPhaseString(t *testing.T) {
+	tests := []struct {
+		phase session.Phase
+		want  string
+	}{
+		{session.PhaseConfiguring, "configuring"},
+		{session.PhaseStarting, "starting"},
+		{session.PhaseReady, "ready"},
+		{session.PhaseDraining, "draining"},
+		{session.PhaseDestroyed, "destroyed"},
+		{session.PhaseDestroyed + 1, "phase(5)"},
+	}
+	for _, tt := range tests {
+		testutils.Equal(t, tt.want, tt.phase.String())
+	}
+}
@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import "github.com/happy-sdk/happy/sdk/project"
+
+// AttachProject is used internally by the SDK to provide project context.
+func AttachProject(c *Context, p *project.Project) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.project = p
+}
+
+// Project returns project which working directory belongs to or nil
+// when application is not run within a project.
+func (c *Context) Project() *project.Project {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.parent != nil {
+		return c.parent.Project()
+	}
+	return c.project
+}
@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// RuntimeInfo describes environment application runs in. Subsystems use
+// it to adjust defaults e.g. spinners and prompts are disabled when
+// Interactive reports false and logs are JSON formatted in Kubernetes.
+type RuntimeInfo struct {
+	// Container is true when application runs in container.
+	Container bool `json:"container"`
+	// ContainerRuntime is detected container runtime e.g. docker, podman,
+	// kubernetes or value of container environment variable.
+	ContainerRuntime string `json:"container_runtime,omitempty"`
+	// Kubernetes is true when application runs in Kubernetes pod.
+	Kubernetes bool `json:"kubernetes"`
+	// CPULimit is number of CPUs available by cgroup quota, 0 when unlimited.
+	CPULimit float64 `json:"cpu_limit,omitempty"`
+	// MemoryLimit is cgroup memory limit in bytes, 0 when unlimited.
+	MemoryLimit int64 `json:"memory_limit,omitempty"`
+	// CI is true when application runs in continuous integration.
+	CI bool `json:"ci"`
+	// CIProvider is detected CI provider e.g. github-actions.
+	CIProvider string `json:"ci_provider,omitempty"`
+	// SSH is true when application runs in SSH session.
+	SSH bool `json:"ssh"`
+	// TTY is true when stdout is terminal.
+	TTY bool `json:"tty"`
+	// StdinTTY is true when stdin is terminal.
+	StdinTTY bool `json:"stdin_tty"`
+}
+
+// Interactive reports whether user can interact with application,
+// stdin and stdout are terminals and application does not run in CI.
+func (r RuntimeInfo) Interactive() bool {
+	return r.TTY && r.StdinTTY && !r.CI
+}
+
+// Runtime returns detected runtime environment information.
+func (c *Context) Runtime() RuntimeInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.runtime == nil {
+		info := DetectRuntime()
+		c.runtime = &info
+	}
+	return *c.runtime
+}
+
+var ciProviders = []struct {
+	env, name string
+}{
+	{"GITHUB_ACTIONS", "github-actions"},
+	{"GITLAB_CI", "gitlab"},
+	{"CIRCLECI", "circleci"},
+	{"TRAVIS", "travis"},
+	{"JENKINS_URL", "jenkins"},
+	{"BUILDKITE", "buildkite"},
+	{"TF_BUILD", "azure-pipelines"},
+	{"TEAMCITY_VERSION", "teamcity"},
+	{"BITBUCKET_BUILD_NUMBER", "bitbucket"},
+	{"DRONE", "drone"},
+	{"WOODPECKER", "woodpecker"},
+	{"CODEBUILD_BUILD_ID", "codebuild"},
+}
+
+// DetectRuntime detects runtime environment of current process.
+func DetectRuntime() RuntimeInfo {
+	var info RuntimeInfo
+
+	for _, p := range ciProviders {
+		if os.Getenv(p.env) != "" {
+			info.CI = true
+			info.CIProvider = p.name
+			break
+		}
+	}
+	if ci := strings.ToLower(os.Getenv("CI")); ci == "true" || ci == "1" {
+		info.CI = true
+	}
+
+	info.SSH = os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_CLIENT") != "" || os.Getenv("SSH_TTY") != ""
+	info.TTY = isCharDevice(os.Stdout)
+	info.StdinTTY = isCharDevice(os.Stdin)
+
+	info.Kubernetes = os.Getenv("KUBERNETES_SERVICE_HOST") != ""
+	switch {
+	case info.Kubernetes:
+		info.ContainerRuntime = "kubernetes"
+	case exists("/.dockerenv"):
+		info.ContainerRuntime = "docker"
+	case exists("/run/.containerenv"):
+		info.ContainerRuntime = "podman"
+	case os.Getenv("container") != "":
+		info.ContainerRuntime = os.Getenv("container")
+	default:
+		info.ContainerRuntime = cgroupRuntime()
+	}
+	info.Container = info.ContainerRuntime != ""
+	info.CPULimit, info.MemoryLimit = cgroupLimits()
+	return info
+}
+
+func isCharDevice(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func exists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+func cgroupRuntime() string {
+	data, err := os.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return ""
+	}
+	s := string(data)
+	for _, rt := range []string{"kubepods", "docker", "containerd", "lxc"} {
+		if strings.Contains(s, rt) {
+			if rt == "kubepods" {
+				return "kubernetes"
+			}
+			return rt
+		}
+	}
+	return ""
+}
+
+// cgroupLimits reads cgroup v2 limits and falls back to cgroup v1.
+func cgroupLimits() (cpu float64, mem int64) {
+	if data, err := os.ReadFile("/sys/fs/cgroup/cpu.max"); err == nil {
+		cpu = parseCPUMax(string(data))
+	} else {
+		quota := readInt("/sys/fs/cgroup/cpu/cpu.cfs_quota_us")
+		period := readInt("/sys/fs/cgroup/cpu/cpu.cfs_period_us")
+		if quota > 0 && period > 0 {
+			cpu = float64(quota) / float64(period)
+		}
+	}
+
+	if exists("/sys/fs/cgroup/memory.max") {
+		mem = readInt("/sys/fs/cgroup/memory.max")
+	} else {
+		mem = readInt("/sys/fs/cgroup/memory/memory.limit_in_bytes")
+	}
+	// cgroup v1 reports unlimited memory as very large number
+	if mem < 0 || mem >= 1<<60 {
+		mem = 0
+	}
+	return cpu, mem
+}
+
+// parseCPUMax parses cgroup v2 cpu.max "<quota> <period>" where quota
+// may be "max" for unlimited.
+func parseCPUMax(s string) float64 {
+	fields := strings.Fields(s)
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0
+	}
+	quota, err1 := strconv.ParseFloat(fields[0], 64)
+	period, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil || period <= 0 {
+		return 0
+	}
+	return quota / period
+}
+
+func readInt(p string) int64 {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return 0
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
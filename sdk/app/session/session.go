@@ -11,17 +11,23 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/happy-sdk/happy/pkg/options"
 	"github.com/happy-sdk/happy/pkg/settings"
 	"github.com/happy-sdk/happy/pkg/vars"
+	"github.com/happy-sdk/happy/sdk/cli/help"
 	"github.com/happy-sdk/happy/sdk/custom"
+	"github.com/happy-sdk/happy/sdk/datetime"
 	"github.com/happy-sdk/happy/sdk/events"
 	"github.com/happy-sdk/happy/sdk/internal"
 	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/project"
 	"github.com/happy-sdk/happy/sdk/services/service"
+	"github.com/happy-sdk/happy/sdk/store"
 )
 
 var (
@@ -34,7 +40,7 @@ type Register interface {
 	Log() logging.Logger
 	Settings() *settings.Profile
 	Opts() *options.Options
-	Time(t time.Time) time.Time
+	Time() *datetime.LocalClock
 	Has(key string) bool
 	Get(key string) vars.Variable
 }
@@ -45,18 +51,23 @@ type Context struct {
 	logger  logging.Logger
 	profile *settings.Profile
 	opts    *options.Options
-	timeloc *time.Location
+	clock   *datetime.LocalClock
+	env     *Env
+
+	features  *Features
+	telemetry *Telemetry
+	runtime   *RuntimeInfo
+	store     *store.Store
 
 	err             error
 	allowUserCancel bool
-	disposed        bool
-	valid           bool
+
+	phase atomic.Uint32
 
 	done chan struct{}
 
 	evch chan<- events.Event
 
-	isReady     bool
 	ready       context.Context
 	readyCancel context.CancelFunc
 	readyEvent  events.Event
@@ -70,12 +81,37 @@ type Context struct {
 
 	svss map[string]*service.Info
 	apis map[string]custom.API
+
+	invoker     CommandInvoker
+	broadcaster Broadcaster
+	startup     *StartupReport
+	project     *project.Project
+	engine      EngineDriver
+	docs        []*help.Topic
+	helpIndex   func() []help.Entry
+
+	parent        *Context
+	name          string
+	children      map[*Context]struct{}
+	childObserver ChildObserver
+	deadline      time.Time
+	// owned is set for sessions created with Owned.
+	owned bool
 }
 
 // Deadline returns the time when work done on behalf of this context
 // should be canceled. Deadline returns ok==false when no deadline is
 // set. Successive calls to Deadline return the same results.
 func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	c.mu.RLock()
+	deadline, parent := c.deadline, c.parent
+	c.mu.RUnlock()
+	if !deadline.IsZero() {
+		return deadline, true
+	}
+	if parent != nil {
+		return parent.Deadline()
+	}
 	return
 }
 
@@ -83,6 +119,9 @@ func (c *Context) Deadline() (deadline time.Time, ok bool) {
 // SIGINT or SIGTERM while application is running. By default this is not allowed.
 // It returns a Done channel which blocks until application is closed by user or signal is reveived.
 func (c *Context) Wait() <-chan struct{} {
+	if parent := c.ownedParent(); parent != nil {
+		return parent.Wait()
+	}
 	c.mu.Lock()
 	c.allowUserCancel = true
 	c.mu.Unlock()
@@ -93,7 +132,7 @@ func (c *Context) Wait() <-chan struct{} {
 
 // Done enables you to hook into chan to know when application exits
 // however DO NOT use that for graceful shutdown actions.
-// Use Application.AddExitFunc or Cloesed instead.
+// Use Main.OnExit or addon OnExit instead.
 func (c *Context) Done() <-chan struct{} {
 	c.mu.Lock()
 	if c.done == nil {
@@ -123,10 +162,10 @@ func (*Context) String() string {
 
 // Value returns the value associated with this context for key, or nil
 func (c *Context) Value(key any) any {
-	c.mu.RLock()
-	disposed := c.disposed
-	c.mu.RUnlock()
-	if disposed {
+	if _, ok := key.(contextKey); ok {
+		return c
+	}
+	if c.Phase() == PhaseDestroyed {
 		return ErrDestroyed
 	}
 
@@ -135,6 +174,9 @@ func (c *Context) Value(key any) any {
 		if v, ok := c.opts.Load(k); ok {
 			return v
 		}
+		if c.parent != nil {
+			return c.parent.Value(k)
+		}
 	case *int:
 		if c.terminate != nil && c.terminate.Err() != nil {
 			if c.allowUserCancel {
@@ -174,9 +216,11 @@ func (c *Context) Destroy(err error) {
 		return
 	}
 
+	if c.parent == nil {
+		_ = SetPhase(c, PhaseDestroyed)
+		c.closeStore()
+	}
 	c.mu.Lock()
-	c.disposed = true
-
 	// s.err is nil otherwise we would not be here
 	c.err = err
 	if c.err == nil {
@@ -205,6 +249,13 @@ func (c *Context) Destroy(err error) {
 	}
 
 	c.mu.Unlock()
+
+	if c.parent != nil {
+		c.parent.removeChild(c)
+	}
+	if parent := c.ownedParent(); parent != nil {
+		parent.Destroy(err)
+	}
 }
 
 func (c *Context) Log() logging.Logger {
@@ -234,25 +285,61 @@ func (c *Context) Opts() *options.Options {
 	return opts
 }
 
-// Valid returns true if the session is valid, false otherwise.
+// Valid returns true if application has started and session
+// is not yet draining or destroyed, false otherwise.
 func (c *Context) Valid() bool {
+	phase := c.Phase()
+	return phase >= PhaseStarting && phase < PhaseDraining
+}
+
+// Time returns session clock which reports time in the configured
+// app.datetime.location. Use it instead of package time so that
+// application can be driven with fake clock in tests.
+func (c *Context) Time() *datetime.LocalClock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.clock
+}
+
+// Env returns session environment which reflects variables applied
+// by Main.WithEnv and command.Config.Env.
+func (c *Context) Env() *Env {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.valid
+	return c.env
+}
+
+// IsElevated reports whether application runs with elevated privileges,
+// as root on Unix and as Administrator with elevated token on Windows.
+func (c *Context) IsElevated() bool {
+	return isElevated()
+}
+
+// Features returns experiment bucketing helper of the session.
+func (c *Context) Features() *Features {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.features == nil {
+		c.features = &Features{sess: c}
+	}
+	return c.features
 }
 
-// Time returns the time in the session's time location.
-func (s *Context) Time(t time.Time) time.Time {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return t.In(s.timeloc)
+// Telemetry returns opt-in usage telemetry recorder of the session.
+func (c *Context) Telemetry() *Telemetry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.telemetry == nil {
+		c.telemetry = &Telemetry{sess: c}
+	}
+	return c.telemetry
 }
 
 func (c *Context) Has(key string) bool {
 	if c.profile != nil && c.profile.Has(key) {
 		return true
 	}
-	return c.opts.Has(key)
+	return c.opts.Has(key) || (c.parent != nil && c.parent.Has(key))
 }
 
 func (c *Context) Get(key string) vars.Variable {
@@ -263,6 +350,9 @@ func (c *Context) Get(key string) vars.Variable {
 	if c.profile != nil && c.profile.Has(key) {
 		return c.profile.Get(key).Value()
 	}
+	if c.parent != nil && !c.opts.Has(key) {
+		return c.parent.Get(key)
+	}
 	return c.opts.Get(key)
 }
 
@@ -271,10 +361,10 @@ func (c *Context) Get(key string) vars.Variable {
 func (c *Context) Ready() <-chan struct{} {
 	c.mu.RLock()
 	d := c.ready.Done()
-	if !c.isReady {
+	c.mu.RUnlock()
+	if c.Phase() < PhaseReady {
 		internal.Log(c.Log(), "waiting session to become ready")
 	}
-	c.mu.RUnlock()
 	return d
 }
 
@@ -284,17 +374,48 @@ func (c *Context) Dispatch(ev events.Event) {
 		return
 	}
 
+	if err := c.checkPhase("dispatch", PhaseConfiguring, PhaseStarting, PhaseReady); err != nil {
+		c.Log().Warn("event dropped",
+			slog.String("scope", ev.Scope()),
+			slog.String("key", ev.Key()),
+			slog.String("err", err.Error()))
+		return
+	}
+
 	c.mu.Lock()
-	if !c.isReady && ev == c.readyEvent {
+	if c.parent == nil && ev == c.readyEvent && c.Phase() < PhaseReady {
 		c.readyEvent = nil
-		c.isReady = true
 		c.readyCancel()
 		c.mu.Unlock()
 		internal.Log(c.Log(), "session is ready")
+		_ = SetPhase(c, PhaseReady)
 		return
 	}
 	c.evch <- ev
 	c.mu.Unlock()
+
+	if events.IsBroadcast(ev) {
+		if broadcaster := c.getBroadcaster(); broadcaster != nil {
+			go func() {
+				if err := broadcaster(ev); err != nil {
+					c.Log().Warn("failed to broadcast event",
+						slog.String("scope", ev.Scope()),
+						slog.String("key", ev.Key()),
+						slog.String("err", err.Error()))
+				}
+			}()
+		}
+	}
+}
+
+func (c *Context) getBroadcaster() Broadcaster {
+	c.mu.RLock()
+	broadcaster, parent := c.broadcaster, c.parent
+	c.mu.RUnlock()
+	if broadcaster == nil && parent != nil {
+		return parent.getBroadcaster()
+	}
+	return broadcaster
 }
 
 func (c *Context) CanRecover(err error) bool {
@@ -313,6 +434,9 @@ func (c *Context) CanRecover(err error) bool {
 func (c *Context) ServiceInfo(svcurl string) (*service.Info, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	if c.svss == nil && c.parent != nil {
+		return c.parent.ServiceInfo(svcurl)
+	}
 	svcinfo, ok := c.svss[svcurl]
 	if !ok {
 		return nil, fmt.Errorf("%w: unknown service %s", Error, svcurl)
@@ -320,27 +444,47 @@ func (c *Context) ServiceInfo(svcurl string) (*service.Info, error) {
 	return svcinfo, nil
 }
 
+// Services returns info of all registered services sorted by address.
+func (c *Context) Services() []*service.Info {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.svss == nil && c.parent != nil {
+		return c.parent.Services()
+	}
+	infos := make([]*service.Info, 0, len(c.svss))
+	for _, info := range c.svss {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Addr().String() < infos[j].Addr().String()
+	})
+	return infos
+}
+
 func (c *Context) Describe(key string) string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	if c.profile != nil && c.profile.Has(key) {
 		return c.profile.Get(key).Description()
 	}
+	if c.parent != nil && !c.opts.Has(key) {
+		return c.parent.Describe(key)
+	}
 	return c.opts.Describe(key)
 }
 
-func (c *Context) start() (err error) {
+func (c *Context) start(clock datetime.Clock) (err error) {
 	c.ready, c.readyCancel = context.WithCancel(context.Background())
 	c.terminate, c.terminateStop = signal.NotifyContext(c, os.Interrupt)
 	c.kill, c.killStop = signal.NotifyContext(c, os.Kill)
+	timeloc := time.Local
 	if timelocStr := c.Get("app.datetime.location").String(); timelocStr != "" {
-		c.timeloc, err = time.LoadLocation(timelocStr)
+		timeloc, err = time.LoadLocation(timelocStr)
 		if err != nil {
 			return fmt.Errorf("failed to load time location: %w", err)
 		}
-	} else {
-		c.timeloc = time.Local
 	}
+	c.clock = datetime.NewLocalClock(clock, timeloc)
 	internal.LogDepth(c.Log(), 1, "session started")
 	return err
 }
@@ -379,20 +523,59 @@ func AttachServiceInfo(c *Context, svcinfo *service.Info) error {
 	return nil
 }
 
+// Broadcaster delivers broadcast events to other running instances
+// of the application.
+type Broadcaster func(ev events.Event) error
+
+// AttachBroadcaster is used internally by the SDK to deliver events
+// marked with events.Broadcast to other instances.
+func AttachBroadcaster(c *Context, broadcaster Broadcaster) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.broadcaster = broadcaster
+}
+
+// CommandInvoker runs registered command within the session.
+type CommandInvoker func(sess *Context, name string, args ...string) error
+
+// AttachCommandInvoker is used internally by the SDK to enable InvokeCommand.
+func AttachCommandInvoker(c *Context, invoker CommandInvoker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invoker = invoker
+}
+
+// InvokeCommand runs another registered command inside the same session,
+// name is space separated path of the command e.g. "db migrate". Flags
+// in args are parsed and Before, Do and After actions of the command are
+// executed. Recursive invocations return error.
+func (c *Context) InvokeCommand(name string, args ...string) error {
+	c.mu.RLock()
+	invoker := c.invoker
+	c.mu.RUnlock()
+	if invoker == nil {
+		return fmt.Errorf("%w: command invocation is not available", Error)
+	}
+	return invoker(c, name, args...)
+}
+
 // Config is a session builder used internally by the SDK to initialize a session.
 type Config struct {
 	Logger       logging.Logger
 	Profile      *settings.Profile
 	Opts         *options.Options
 	TimeLocation *time.Location
-	ReadyEvent   events.Event
-	EventCh      chan<- events.Event
-	APIs         map[string]custom.API
+	// Clock used by session, datetime.SystemClock when nil.
+	Clock      datetime.Clock
+	ReadyEvent events.Event
+	EventCh    chan<- events.Event
+	APIs       map[string]custom.API
 }
 
 func (c *Config) Init() (*Context, error) {
 	sess := &Context{
 		apis: c.APIs,
+		env:  &Env{},
 	}
 
 	if c.Logger == nil {
@@ -420,8 +603,11 @@ func (c *Config) Init() (*Context, error) {
 	sess.evch = c.EventCh
 
 	sess.opts = c.Opts
+	sess.opts.AddWriteGuard(func(owner, key string) error {
+		return sess.checkPhase("setting option "+key, PhaseConfiguring, PhaseStarting, PhaseReady)
+	})
 
-	if err := sess.start(); err != nil {
+	if err := sess.start(c.Clock); err != nil {
 		return nil, fmt.Errorf("%w: %v", Error, err)
 	}
 
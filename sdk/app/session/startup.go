@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+)
+
+// StartupReport summarizes application startup, it is used to debug
+// slow or misconfigured boots.
+type StartupReport struct {
+	// Profile is name of loaded settings profile.
+	Profile string `json:"profile"`
+	// ProfileMode is devel or production.
+	ProfileMode string `json:"profile_mode"`
+	// Preferences is path to loaded profile preferences file.
+	Preferences string           `json:"preferences,omitempty"`
+	Settings    []StartupSetting `json:"settings"`
+	Addons      []StartupAddon   `json:"addons"`
+	Services    []string         `json:"services"`
+	Phases      []StartupPhase   `json:"phases"`
+	Took        time.Duration    `json:"took"`
+}
+
+// StartupSetting is setting which value does not come from its default.
+type StartupSetting struct {
+	Key string `json:"key"`
+	// Source is where value comes from: app, system, profile, project,
+	// env, flag or policy.
+	Source string `json:"source"`
+	// Path is file or variable value was read from.
+	Path  string `json:"path,omitempty"`
+	Value string `json:"value"`
+	// Overridden are values from lower configuration layers overridden
+	// by this value, nearest first.
+	Overridden []StartupSetting `json:"overridden,omitempty"`
+}
+
+type StartupAddon struct {
+	Slug    string `json:"slug"`
+	Version string `json:"version"`
+	// Took is time spent extending settings and options and in
+	// register and lifecycle hooks of addon.
+	Took time.Duration `json:"took"`
+}
+
+// StartupPhase is time spent in initialization phase.
+type StartupPhase struct {
+	Name string        `json:"name"`
+	Took time.Duration `json:"took"`
+}
+
+// String returns startup report formatted as tables.
+func (r *StartupReport) String() string {
+	summary := textfmt.Table{Title: "Startup"}
+	summary.AddRow("profile", r.Profile)
+	summary.AddRow("profile mode", r.ProfileMode)
+	if r.Preferences != "" {
+		summary.AddRow("preferences", r.Preferences)
+	}
+	summary.AddRow("took", r.Took.String())
+
+	phases := textfmt.Table{Title: "Phases", WithHeader: true}
+	phases.AddRow("PHASE", "TOOK")
+	for _, p := range r.Phases {
+		phases.AddRow(p.Name, p.Took.String())
+	}
+
+	settings := textfmt.Table{Title: "Settings", WithHeader: true}
+	settings.AddRow("KEY", "SOURCE", "VALUE", "PATH")
+	for _, s := range r.Settings {
+		settings.AddRow(s.Key, s.Source, s.Value, s.Path)
+	}
+
+	addons := textfmt.Table{Title: "Addons", WithHeader: true}
+	addons.AddRow("SLUG", "VERSION", "TOOK")
+	for _, a := range r.Addons {
+		addons.AddRow(a.Slug, a.Version, a.Took.String())
+	}
+
+	services := textfmt.Table{Title: "Services", WithHeader: true}
+	services.AddRow("SERVICE")
+	for _, s := range r.Services {
+		services.AddRow(s)
+	}
+
+	return fmt.Sprintf("%s\n%s\n%s\n%s\n%s",
+		summary.String(), phases.String(), settings.String(), addons.String(), services.String())
+}
+
+// Setting returns startup record of setting key, it reports false
+// when setting has its default value.
+func (r *StartupReport) Setting(key string) (StartupSetting, bool) {
+	for _, s := range r.Settings {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return StartupSetting{}, false
+}
+
+// AttachStartupReport is used internally by the SDK to provide startup report.
+func AttachStartupReport(c *Context, report *StartupReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.startup = report
+}
+
+// StartupReport returns application startup report or nil
+// when it is not available.
+func (c *Context) StartupReport() *StartupReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.parent != nil {
+		return c.parent.StartupReport()
+	}
+	return c.startup
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/happy-sdk/happy/sdk/store"
+)
+
+// storeLockWait is how long write transactions wait for other
+// processes to release the store.
+const storeLockWait = 5 * time.Second
+
+// Store returns application state store kept in store.json of
+// application data directory. Store is opened on first use and shared
+// with child sessions, write transactions hold "store" session lock so
+// that invocations of the application do not overwrite each other.
+// Store is closed when session is destroyed.
+func (c *Context) Store() (*store.Store, error) {
+	if c.parent != nil {
+		return c.parent.Store()
+	}
+	if err := c.checkPhase("store", PhaseConfiguring, PhaseStarting, PhaseReady, PhaseDraining); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.store != nil {
+		return c.store, nil
+	}
+	dir := c.Get("app.fs.path.data").String()
+	if dir == "" {
+		return nil, fmt.Errorf("%w: data directory not available for store", Error)
+	}
+	s, err := store.Open(filepath.Join(dir, "store.json"), store.Options{
+		Lock: func() (func() error, error) {
+			return c.Lock(storeLockWait, "store")
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.store = s
+	return s, nil
+}
+
+// closeStore closes store opened by Store.
+func (c *Context) closeStore() {
+	c.mu.Lock()
+	s := c.store
+	c.mu.Unlock()
+	if s == nil {
+		return
+	}
+	if err := s.Close(); err != nil {
+		c.Log().Warn("failed to close store", slog.String("err", err.Error()))
+	}
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package session
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// TelemetryQueueFile is the file in application cache directory where
+// telemetry events are buffered until uploaded.
+const TelemetryQueueFile = "telemetry/queue.jsonl"
+
+// TelemetryEvent is anonymous usage event.
+type TelemetryEvent struct {
+	Name      string         `json:"name"`
+	Time      time.Time      `json:"time"`
+	InstallID string         `json:"install_id"`
+	App       string         `json:"app"`
+	Version   string         `json:"version"`
+	OS        string         `json:"os"`
+	Arch      string         `json:"arch"`
+	Attrs     map[string]any `json:"attrs,omitempty"`
+}
+
+// Telemetry records anonymous usage events to local queue when user
+// has opted in with app.telemetry.enabled, otherwise events are discarded.
+type Telemetry struct {
+	mu   sync.Mutex
+	sess *Context
+}
+
+// Enabled reports whether user has opted in to telemetry.
+func (t *Telemetry) Enabled() bool {
+	return t.sess.Has("app.telemetry.enabled") && t.sess.Get("app.telemetry.enabled").Bool()
+}
+
+// Event records telemetry event name with attributes.
+func (t *Telemetry) Event(name string, attrs ...slog.Attr) {
+	if !t.Enabled() {
+		return
+	}
+	ev := TelemetryEvent{
+		Name:      name,
+		Time:      t.sess.Time().Now().UTC(),
+		InstallID: t.sess.Features().InstallID(),
+		App:       t.sess.Get("app.slug").String(),
+		Version:   t.sess.Get("app.version").String(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+	if len(attrs) > 0 {
+		ev.Attrs = make(map[string]any, len(attrs))
+		for _, attr := range attrs {
+			ev.Attrs[attr.Key] = attr.Value.Resolve().Any()
+		}
+	}
+	if err := t.append(ev); err != nil {
+		t.sess.Log().Debug("failed to queue telemetry event", slog.String("err", err.Error()))
+	}
+}
+
+// QueuePath returns path of the local event queue.
+func (t *Telemetry) QueuePath() string {
+	return filepath.Join(t.sess.Get("app.fs.path.cache").String(), TelemetryQueueFile)
+}
+
+func (t *Telemetry) append(ev TelemetryEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := t.QueuePath()
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Lock locks the queue, it is used by uploader while reading
+// and truncating the queue.
+func (t *Telemetry) Lock() { t.mu.Lock() }
+
+// Unlock unlocks the queue.
+func (t *Telemetry) Unlock() { t.mu.Unlock() }
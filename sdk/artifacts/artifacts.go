@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package artifacts produces SHA256SUMS for release artifacts, signs
+// them with pluggable signers and verifies downloaded artifacts
+// against checksums and signatures.
+package artifacts
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/validate"
+)
+
+var (
+	Error = errors.New("artifacts")
+	// ErrChecksum is returned when artifact does not match its checksum.
+	ErrChecksum = fmt.Errorf("%w: checksum mismatch", Error)
+	// ErrNoChecksum is returned when checksums have no entry for artifact.
+	ErrNoChecksum = fmt.Errorf("%w: no checksum", Error)
+)
+
+// ChecksumsFile is name of checksums file written to artifacts directory.
+const ChecksumsFile = "SHA256SUMS"
+
+const (
+	SignerNone     = "none"
+	SignerCosign   = "cosign"
+	SignerMinisign = "minisign"
+	SignerGPG      = "gpg"
+)
+
+type Settings struct {
+	Signer     settings.String `key:"signer,save" default:"none" desc:"Signer of release artifacts: none, cosign, minisign or gpg"`
+	Key        settings.String `key:"key,save" default:"" desc:"Signing key, path to cosign or minisign secret key or gpg key id"`
+	PublicKey  settings.String `key:"public_key,save" default:"" desc:"Public key verifying downloaded artifacts, path to cosign or minisign public key"`
+	Credential settings.String `key:"credential,save" default:"artifacts-signing" desc:"Name of credential holding signing key password in credential store"`
+}
+
+func (s Settings) Blueprint() (*settings.Blueprint, error) {
+	b, err := settings.New(s)
+	if err != nil {
+		return nil, err
+	}
+	b.AddValidator("signer", "", func(s settings.Setting) error {
+		err := validate.OneOf(SignerNone, SignerCosign, SignerMinisign, SignerGPG)(s.Value().String())
+		if err != nil {
+			return fmt.Errorf("%w: signer %w", settings.ErrSetting, err)
+		}
+		return nil
+	})
+	return b, nil
+}
+
+// Checksums returns SHA256SUMS content for files in dir, file names are
+// relative to dir and sorted.
+func Checksums(dir string, files ...string) ([]byte, error) {
+	sort.Strings(files)
+	var out bytes.Buffer
+	for _, name := range files {
+		sum, err := fileSum(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&out, "%s  %s\n", sum, filepath.ToSlash(name))
+	}
+	return out.Bytes(), nil
+}
+
+// WriteChecksums writes ChecksumsFile for all regular files in dir,
+// except checksums and signature files, and returns its path.
+func WriteChecksums(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ChecksumsFile) || isSignature(name) {
+			continue
+		}
+		files = append(files, name)
+	}
+	data, err := Checksums(dir, files...)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, ChecksumsFile)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return path, nil
+}
+
+// Sign writes checksums of artifacts in dir and signs checksums file
+// with signer, it returns paths of checksums file and signature.
+func Sign(ctx context.Context, signer Signer, dir string) (sums, sig string, err error) {
+	if sums, err = WriteChecksums(dir); err != nil {
+		return "", "", err
+	}
+	if signer == nil {
+		return sums, "", nil
+	}
+	if sig, err = signer.Sign(ctx, sums); err != nil {
+		return "", "", err
+	}
+	return sums, sig, nil
+}
+
+// VerifyChecksum verifies that content of r matches checksum of name
+// in SHA256SUMS content sums.
+func VerifyChecksum(sums []byte, name string, r io.Reader) error {
+	want, err := lookup(sums, name)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("%w: %s", ErrChecksum, name)
+	}
+	return nil
+}
+
+// VerifyDownload verifies downloaded file against checksums file and
+// its signature, signature is verified when verifier is not nil. File
+// is looked up in checksums by its base name.
+func VerifyDownload(ctx context.Context, verifier Signer, file, sums, sig string) error {
+	if verifier != nil {
+		if err := verifier.Verify(ctx, sums, sig); err != nil {
+			return err
+		}
+	}
+	data, err := os.ReadFile(sums)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer f.Close()
+	return VerifyChecksum(data, filepath.Base(file), f)
+}
+
+func lookup(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		sum, file, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok {
+			continue
+		}
+		// binary mode entries are prefixed with *
+		file = strings.TrimPrefix(strings.TrimSpace(file), "*")
+		if file == name {
+			return strings.ToLower(sum), nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrNoChecksum, name)
+}
+
+func fileSum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func isSignature(name string) bool {
+	switch filepath.Ext(name) {
+	case ".sig", ".asc", ".minisig":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/auth"
+)
+
+// Signer signs files with detached signatures and verifies them.
+type Signer interface {
+	Name() string
+	// Sign signs file and returns path of detached signature.
+	Sign(ctx context.Context, file string) (string, error)
+	// Verify verifies detached signature sig of file.
+	Verify(ctx context.Context, file, sig string) error
+}
+
+// SignerFromSettings returns signer configured with app.artifacts
+// settings or nil when signer is none. Signing key password is loaded
+// from credential store of current profile.
+func SignerFromSettings(sess *session.Context) (Signer, error) {
+	name := sess.Get("app.artifacts.signer").String()
+	if name == "" || name == SignerNone {
+		return nil, nil
+	}
+	var password string
+	tok, err := auth.ProfileStore(sess).Load(sess.Get("app.artifacts.credential").String())
+	switch {
+	case err == nil:
+		password = tok.AccessToken
+	case !errors.Is(err, auth.ErrNotLoggedIn):
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+
+	key := sess.Get("app.artifacts.key").String()
+	pub := sess.Get("app.artifacts.public_key").String()
+	switch name {
+	case SignerCosign:
+		return &Cosign{Key: key, PublicKey: pub, Password: password}, nil
+	case SignerMinisign:
+		return &Minisign{SecretKey: key, PublicKey: pub, Password: password}, nil
+	case SignerGPG:
+		return &GPG{KeyID: key, Passphrase: password}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signer %q", Error, name)
+}
+
+// Cosign signs with cosign sign-blob.
+type Cosign struct {
+	Key       string
+	PublicKey string
+	Password  string
+}
+
+func (s *Cosign) Name() string { return SignerCosign }
+
+func (s *Cosign) Sign(ctx context.Context, file string) (string, error) {
+	sig := file + ".sig"
+	cmd := exec.CommandContext(ctx, "cosign", "sign-blob", "--yes", "--key", s.Key, "--output-signature", sig, file)
+	cmd.Env = append(os.Environ(), "COSIGN_PASSWORD="+s.Password)
+	return sig, run(cmd)
+}
+
+func (s *Cosign) Verify(ctx context.Context, file, sig string) error {
+	return run(exec.CommandContext(ctx, "cosign", "verify-blob", "--key", s.PublicKey, "--signature", sig, file))
+}
+
+// Minisign signs with minisign.
+type Minisign struct {
+	SecretKey string
+	PublicKey string
+	Password  string
+}
+
+func (s *Minisign) Name() string { return SignerMinisign }
+
+func (s *Minisign) Sign(ctx context.Context, file string) (string, error) {
+	sig := file + ".minisig"
+	cmd := exec.CommandContext(ctx, "minisign", "-S", "-s", s.SecretKey, "-m", file, "-x", sig)
+	cmd.Stdin = strings.NewReader(s.Password + "\n")
+	return sig, run(cmd)
+}
+
+func (s *Minisign) Verify(ctx context.Context, file, sig string) error {
+	return run(exec.CommandContext(ctx, "minisign", "-V", "-p", s.PublicKey, "-m", file, "-x", sig))
+}
+
+// GPG signs with armored detached gpg signatures, empty KeyID uses
+// default key.
+type GPG struct {
+	KeyID      string
+	Passphrase string
+}
+
+func (s *GPG) Name() string { return SignerGPG }
+
+func (s *GPG) Sign(ctx context.Context, file string) (string, error) {
+	sig := file + ".asc"
+	args := []string{"--batch", "--yes", "--armor", "--detach-sign", "--output", sig}
+	if s.KeyID != "" {
+		args = append(args, "--local-user", s.KeyID)
+	}
+	if s.Passphrase != "" {
+		args = append(args, "--pinentry-mode", "loopback", "--passphrase-fd", "0")
+	}
+	cmd := exec.CommandContext(ctx, "gpg", append(args, file)...)
+	cmd.Stdin = strings.NewReader(s.Passphrase + "\n")
+	return sig, run(cmd)
+}
+
+func (s *GPG) Verify(ctx context.Context, file, sig string) error {
+	return run(exec.CommandContext(ctx, "gpg", "--batch", "--verify", sig, file))
+}
+
+func run(cmd *exec.Cmd) error {
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %s: %s", Error, cmd.Args[0], err.Error(), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
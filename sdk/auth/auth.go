@@ -0,0 +1,325 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package auth provides OAuth2 login helpers for happy applications.
+// It implements the device authorization grant (RFC 8628) and the
+// authorization code grant with PKCE (RFC 7636) using a loopback redirect,
+// persists tokens through a Store and refreshes them automatically.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/internal"
+)
+
+var (
+	Error                    = errors.New("auth")
+	ErrNotLoggedIn           = fmt.Errorf("%w: not logged in", Error)
+	ErrAuthorizationPending  = fmt.Errorf("%w: authorization_pending", Error)
+	ErrSlowDown              = fmt.Errorf("%w: slow_down", Error)
+	ErrAccessDenied          = fmt.Errorf("%w: access_denied", Error)
+	ErrExpiredToken          = fmt.Errorf("%w: expired_token", Error)
+	ErrDeviceFlowUnsupported = fmt.Errorf("%w: provider does not support device flow", Error)
+)
+
+// Endpoint contains the OAuth2 endpoints of the provider.
+type Endpoint struct {
+	DeviceAuthURL string
+	AuthURL       string
+	TokenURL      string
+}
+
+var (
+	// GitHub OAuth2 endpoints.
+	GitHub = Endpoint{
+		DeviceAuthURL: "https://github.com/login/device/code",
+		AuthURL:       "https://github.com/login/oauth/authorize",
+		TokenURL:      "https://github.com/login/oauth/access_token",
+	}
+	// Google OAuth2 endpoints.
+	Google = Endpoint{
+		DeviceAuthURL: "https://oauth2.googleapis.com/device/code",
+		AuthURL:       "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:      "https://oauth2.googleapis.com/token",
+	}
+)
+
+// Config describes the OAuth2 client.
+type Config struct {
+	// Provider is the name used to store tokens, e.g. "github".
+	Provider     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	Endpoint     Endpoint
+	// HTTPClient used to talk to the provider, http.DefaultClient when nil.
+	HTTPClient *http.Client
+}
+
+// Token is the OAuth2 token persisted by the Store.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// expiryDelta refreshes tokens shortly before they expire.
+const expiryDelta = 30 * time.Second
+
+// Valid reports whether token has access token which has not expired.
+func (t *Token) Valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	if t.Expiry.IsZero() {
+		return true
+	}
+	return time.Now().Add(expiryDelta).Before(t.Expiry)
+}
+
+// Client performs logins for configured provider and keeps
+// tokens in the provided Store.
+type Client struct {
+	mu    sync.Mutex
+	cnf   Config
+	store Store
+}
+
+// New returns new auth client. When store is nil tokens are kept
+// in the credentials directory of the current profile.
+func New(cnf Config, store Store) *Client {
+	if cnf.HTTPClient == nil {
+		cnf.HTTPClient = http.DefaultClient
+	}
+	return &Client{
+		cnf:   cnf,
+		store: store,
+	}
+}
+
+// Provider returns the provider name of the client.
+func (c *Client) Provider() string {
+	return c.cnf.Provider
+}
+
+// Token returns stored token for the provider and refreshes it
+// when it has expired and refresh token is available.
+func (c *Client) Token(sess *session.Context) (*Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	store := c.getStore(sess)
+	tok, err := store.Load(c.cnf.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if tok.Valid() {
+		return tok, nil
+	}
+	if tok.RefreshToken == "" {
+		return nil, fmt.Errorf("%w: token expired", ErrNotLoggedIn)
+	}
+	internal.Log(sess.Log(), "refreshing oauth2 token", slog.String("provider", c.cnf.Provider))
+	refreshed, err := c.exchange(sess, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {tok.RefreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Some providers do not rotate refresh tokens.
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = tok.RefreshToken
+	}
+	if err := store.Save(c.cnf.Provider, refreshed); err != nil {
+		return nil, err
+	}
+	return refreshed, nil
+}
+
+// LoggedIn reports whether there is usable token for the provider.
+func (c *Client) LoggedIn(sess *session.Context) bool {
+	_, err := c.Token(sess)
+	return err == nil
+}
+
+// Logout deletes stored token for the provider.
+func (c *Client) Logout(sess *session.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	internal.Log(sess.Log(), "deleting oauth2 token", slog.String("provider", c.cnf.Provider))
+	return c.getStore(sess).Delete(c.cnf.Provider)
+}
+
+// HTTPClient returns http client which authorizes requests with
+// the current token, refreshing it when needed.
+func (c *Client) HTTPClient(sess *session.Context) *http.Client {
+	return &http.Client{
+		Transport: &transport{
+			sess:   sess,
+			client: c,
+			base:   c.cnf.HTTPClient.Transport,
+		},
+	}
+}
+
+func (c *Client) save(sess *session.Context, tok *Token) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.getStore(sess).Save(c.cnf.Provider, tok)
+}
+
+// getStore returns configured store or ProfileStore when none was provided.
+func (c *Client) getStore(sess *session.Context) Store {
+	if c.store == nil {
+		return ProfileStore(sess)
+	}
+	return c.store
+}
+
+// exchange posts values to token endpoint and decodes the token response.
+func (c *Client) exchange(ctx context.Context, vals url.Values) (*Token, error) {
+	vals.Set("client_id", c.cnf.ClientID)
+	if c.cnf.ClientSecret != "" {
+		vals.Set("client_secret", c.cnf.ClientSecret)
+	}
+	var resp tokenResponse
+	if err := c.post(ctx, c.cnf.Endpoint.TokenURL, vals, &resp); err != nil {
+		return nil, err
+	}
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+	if resp.AccessToken == "" {
+		return nil, fmt.Errorf("%w: token response has no access_token", Error)
+	}
+	tok := &Token{
+		AccessToken:  resp.AccessToken,
+		TokenType:    resp.TokenType,
+		RefreshToken: resp.RefreshToken,
+		Scope:        resp.Scope,
+	}
+	if resp.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
+
+func (c *Client) post(ctx context.Context, endpoint string, vals url.Values, dest any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(vals.Encode()))
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.cnf.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := json.Unmarshal(body, dest); err != nil {
+		// Some providers answer with form encoded body.
+		if vals, perr := url.ParseQuery(string(body)); perr == nil && len(vals) > 0 {
+			return decodeForm(vals, dest)
+		}
+		return fmt.Errorf("%w: failed to decode response (%s): %s", Error, res.Status, err.Error())
+	}
+	return nil
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Error        string `json:"error"`
+	ErrorDesc    string `json:"error_description"`
+}
+
+func (r tokenResponse) err() error {
+	switch r.Error {
+	case "":
+		return nil
+	case "authorization_pending":
+		return ErrAuthorizationPending
+	case "slow_down":
+		return ErrSlowDown
+	case "access_denied":
+		return ErrAccessDenied
+	case "expired_token":
+		return ErrExpiredToken
+	}
+	if r.ErrorDesc != "" {
+		return fmt.Errorf("%w: %s: %s", Error, r.Error, r.ErrorDesc)
+	}
+	return fmt.Errorf("%w: %s", Error, r.Error)
+}
+
+func decodeForm(vals url.Values, dest any) error {
+	m := make(map[string]any, len(vals))
+	for k := range vals {
+		m[k] = vals.Get(k)
+	}
+	if v := vals.Get("expires_in"); v != "" {
+		var n int64
+		if _, err := fmt.Sscan(v, &n); err == nil {
+			m["expires_in"] = n
+		}
+	}
+	if v := vals.Get("interval"); v != "" {
+		var n int64
+		if _, err := fmt.Sscan(v, &n); err == nil {
+			m["interval"] = n
+		}
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return json.Unmarshal(data, dest)
+}
+
+type transport struct {
+	sess   *session.Context
+	client *Client
+	base   http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tok, err := t.client.Token(t.sess)
+	if err != nil {
+		return nil, err
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	r := req.Clone(req.Context())
+	typ := tok.TokenType
+	if typ == "" || strings.EqualFold(typ, "bearer") {
+		typ = "Bearer"
+	}
+	r.Header.Set("Authorization", typ+" "+tok.AccessToken)
+	return base.RoundTrip(r)
+}
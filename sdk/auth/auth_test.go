@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package auth_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/sdk/auth"
+)
+
+func TestTokenValid(t *testing.T) {
+	var nilTok *auth.Token
+	testutils.False(t, nilTok.Valid(), "nil token")
+	testutils.False(t, (&auth.Token{}).Valid(), "empty token")
+	testutils.True(t, (&auth.Token{AccessToken: "x"}).Valid(), "token without expiry")
+	testutils.False(t, (&auth.Token{AccessToken: "x", Expiry: time.Now().Add(time.Second)}).Valid(), "token about to expire")
+	testutils.True(t, (&auth.Token{AccessToken: "x", Expiry: time.Now().Add(time.Hour)}).Valid(), "valid token")
+}
+
+func TestFileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "credentials")
+	store := auth.NewFileStore(dir)
+
+	_, err := store.Load("github")
+	testutils.True(t, errors.Is(err, auth.ErrNotLoggedIn), "expected ErrNotLoggedIn")
+
+	testutils.NoError(t, store.Save("github", &auth.Token{AccessToken: "abc", RefreshToken: "def"}))
+	info, err := os.Stat(filepath.Join(dir, "github.json"))
+	testutils.NoError(t, err)
+	testutils.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	tok, err := store.Load("github")
+	testutils.NoError(t, err)
+	testutils.Equal(t, "abc", tok.AccessToken)
+	testutils.Equal(t, "def", tok.RefreshToken)
+
+	testutils.NoError(t, store.Delete("github"))
+	testutils.NoError(t, store.Delete("github"))
+	_, err = store.Load("github")
+	testutils.True(t, errors.Is(err, auth.ErrNotLoggedIn), "expected ErrNotLoggedIn after delete")
+
+	testutils.Error(t, store.Save("../github", &auth.Token{AccessToken: "abc"}))
+}
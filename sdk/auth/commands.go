@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package auth
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/cli/render"
+)
+
+// Commands returns login and logout commands for the client.
+//
+//	main.WithCommands(auth.Commands(client)...)
+func Commands(c *Client) []*command.Command {
+	return []*command.Command{
+		LoginCommand(c),
+		LogoutCommand(c),
+	}
+}
+
+// LoginCommand returns login command which uses device flow by default
+// and browser flow with PKCE when --browser flag is set.
+func LoginCommand(c *Client) *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "login",
+		Category:    "Authentication",
+		Description: settings.String(fmt.Sprintf("Log in to %s", c.Provider())),
+	})
+
+	cmd.WithFlags(
+		varflag.BoolFunc("browser", false, "Log in with browser instead of device code", "b"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		if args.Flag("browser").Var().Bool() || c.cnf.Endpoint.DeviceAuthURL == "" {
+			_, err := c.BrowserLogin(sess, func(authURL string) error {
+				sess.Log().Println("Open following url in your browser to log in:")
+				sess.Log().Println(render.URL(authURL))
+				openBrowser(authURL)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			_, err := c.DeviceLogin(sess, func(dc DeviceCode) {
+				uri := dc.VerificationURI
+				if dc.VerificationURIComplete != "" {
+					uri = dc.VerificationURIComplete
+				}
+				sess.Log().Println(fmt.Sprintf("Open %s and enter code: %s", render.URL(uri), dc.UserCode))
+			})
+			if err != nil {
+				return err
+			}
+		}
+		sess.Log().Println(fmt.Sprintf("Logged in to %s", c.Provider()))
+		return nil
+	})
+	return cmd
+}
+
+// LogoutCommand returns logout command which removes stored credentials.
+func LogoutCommand(c *Client) *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "logout",
+		Category:    "Authentication",
+		Description: settings.String(fmt.Sprintf("Log out from %s", c.Provider())),
+	})
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		if err := c.Logout(sess); err != nil {
+			return err
+		}
+		sess.Log().Println(fmt.Sprintf("Logged out from %s", c.Provider()))
+		return nil
+	})
+	return cmd
+}
+
+// openBrowser tries to open url in default browser, errors are ignored
+// since url is also printed for user.
+func openBrowser(u string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	_ = cmd.Start()
+}
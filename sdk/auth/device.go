@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/internal"
+)
+
+// DeviceCode is the device authorization response which user
+// needs to complete in the browser.
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+	// Google uses verification_url instead of verification_uri.
+	VerificationURL string `json:"verification_url,omitempty"`
+	Error           string `json:"error,omitempty"`
+	ErrorDesc       string `json:"error_description,omitempty"`
+}
+
+// DeviceLogin performs OAuth2 device authorization grant. The prompt is called
+// once with the code user must enter at the verification uri, then the token
+// endpoint is polled until user has authorized the device or code expires.
+func (c *Client) DeviceLogin(sess *session.Context, prompt func(DeviceCode)) (*Token, error) {
+	if c.cnf.Endpoint.DeviceAuthURL == "" {
+		return nil, ErrDeviceFlowUnsupported
+	}
+	vals := url.Values{"client_id": {c.cnf.ClientID}}
+	if len(c.cnf.Scopes) > 0 {
+		vals.Set("scope", strings.Join(c.cnf.Scopes, " "))
+	}
+
+	var dc DeviceCode
+	if err := c.post(sess, c.cnf.Endpoint.DeviceAuthURL, vals, &dc); err != nil {
+		return nil, err
+	}
+	if dc.Error != "" {
+		return nil, tokenResponse{Error: dc.Error, ErrorDesc: dc.ErrorDesc}.err()
+	}
+	if dc.VerificationURI == "" {
+		dc.VerificationURI = dc.VerificationURL
+	}
+	if dc.DeviceCode == "" || dc.VerificationURI == "" {
+		return nil, fmt.Errorf("%w: invalid device authorization response", Error)
+	}
+	if prompt != nil {
+		prompt(dc)
+	}
+
+	interval := time.Duration(dc.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	expiresIn := time.Duration(dc.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 15 * time.Minute
+	}
+	deadline := time.NewTimer(expiresIn)
+	defer deadline.Stop()
+
+	internal.Log(sess.Log(), "waiting for device authorization",
+		slog.String("provider", c.cnf.Provider),
+		slog.Duration("interval", interval),
+	)
+
+	for {
+		select {
+		case <-sess.Done():
+			return nil, fmt.Errorf("%w: %s", Error, sess.Err())
+		case <-deadline.C:
+			return nil, ErrExpiredToken
+		case <-time.After(interval):
+		}
+		tok, err := c.exchange(sess, url.Values{
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"device_code": {dc.DeviceCode},
+		})
+		switch {
+		case err == nil:
+			if err := c.save(sess, tok); err != nil {
+				return nil, err
+			}
+			return tok, nil
+		case errors.Is(err, ErrAuthorizationPending):
+			continue
+		case errors.Is(err, ErrSlowDown):
+			interval += 5 * time.Second
+			continue
+		default:
+			return nil, err
+		}
+	}
+}
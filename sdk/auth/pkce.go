@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/internal"
+)
+
+// BrowserLogin performs OAuth2 authorization code grant with PKCE.
+// It starts a loopback listener for redirect and calls open with
+// the authorization url which should be opened in user's browser.
+func (c *Client) BrowserLogin(sess *session.Context, open func(authURL string) error) (*Token, error) {
+	if c.cnf.Endpoint.AuthURL == "" {
+		return nil, fmt.Errorf("%w: provider has no authorization endpoint", Error)
+	}
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	redirectURI := fmt.Sprintf("http://%s/callback", ln.Addr().String())
+
+	type result struct {
+		code string
+		err  error
+	}
+	resCh := make(chan result, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var res result
+		switch {
+		case q.Get("state") != state:
+			res.err = fmt.Errorf("%w: state mismatch", Error)
+		case q.Get("error") != "":
+			res.err = tokenResponse{Error: q.Get("error"), ErrorDesc: q.Get("error_description")}.err()
+		case q.Get("code") == "":
+			res.err = fmt.Errorf("%w: authorization code missing", Error)
+		default:
+			res.code = q.Get("code")
+		}
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Login successful, you can close this window.")
+		}
+		select {
+		case resCh <- res:
+		default:
+		}
+	})
+	srv := &http.Server{Handler: mux}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			resCh <- result{err: fmt.Errorf("%w: %s", Error, err.Error())}
+		}
+	}()
+	defer srv.Close()
+
+	vals := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.cnf.ClientID},
+		"redirect_uri":          {redirectURI},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	if len(c.cnf.Scopes) > 0 {
+		vals.Set("scope", strings.Join(c.cnf.Scopes, " "))
+	}
+	authURL := c.cnf.Endpoint.AuthURL
+	if strings.Contains(authURL, "?") {
+		authURL += "&" + vals.Encode()
+	} else {
+		authURL += "?" + vals.Encode()
+	}
+
+	internal.Log(sess.Log(), "waiting for browser authorization",
+		slog.String("provider", c.cnf.Provider),
+		slog.String("redirect_uri", redirectURI),
+	)
+	if open != nil {
+		if err := open(authURL); err != nil {
+			return nil, err
+		}
+	}
+
+	var res result
+	select {
+	case <-sess.Done():
+		return nil, fmt.Errorf("%w: %s", Error, sess.Err())
+	case res = <-resCh:
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	tok, err := c.exchange(sess, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {res.code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.save(sess, tok); err != nil {
+		return nil, err
+	}
+	return tok, nil
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
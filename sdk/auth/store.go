@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+// Store persists tokens by provider name.
+type Store interface {
+	Load(provider string) (*Token, error)
+	Save(provider string, tok *Token) error
+	Delete(provider string) error
+}
+
+// FileStore stores tokens as json files readable only by current user.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns store which keeps credentials in dir.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// ProfileStore returns FileStore which keeps credentials in
+// credentials directory of the current profile.
+func ProfileStore(sess *session.Context) *FileStore {
+	return NewFileStore(filepath.Join(sess.Get("app.fs.path.profile").String(), "credentials"))
+}
+
+// Dir returns the directory where credentials are stored.
+func (s *FileStore) Dir() string {
+	return s.dir
+}
+
+func (s *FileStore) Load(provider string) (*Token, error) {
+	file, err := s.file(provider)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotLoggedIn, provider)
+		}
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	tok := &Token{}
+	if err := json.Unmarshal(data, tok); err != nil {
+		return nil, fmt.Errorf("%w: corrupted credentials for %s: %s", Error, provider, err.Error())
+	}
+	return tok, nil
+}
+
+func (s *FileStore) Save(provider string, tok *Token) error {
+	if tok == nil {
+		return fmt.Errorf("%w: can not save nil token", Error)
+	}
+	file, err := s.file(provider)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return nil
+}
+
+func (s *FileStore) Delete(provider string) error {
+	file, err := s.file(provider)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return nil
+}
+
+func (s *FileStore) file(provider string) (string, error) {
+	if s.dir == "" {
+		return "", fmt.Errorf("%w: credentials directory not set", Error)
+	}
+	if provider == "" || strings.ContainsAny(provider, `/\`) || provider == "." || provider == ".." {
+		return "", fmt.Errorf("%w: invalid provider name %q", Error, provider)
+	}
+	return filepath.Join(s.dir, provider+".json"), nil
+}
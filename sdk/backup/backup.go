@@ -0,0 +1,613 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package backup exports application data to a single archive and
+// imports it back, e.g. when migrating to another machine. Archive
+// holds files of the current settings profile, content of the state
+// store and selected files of the application data directory. It is
+// gzipped tar archive, optionally encrypted with passphrase using
+// AES-256-GCM.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/config"
+	"github.com/happy-sdk/happy/sdk/store"
+)
+
+var (
+	Error         = errors.New("backup")
+	ErrConflict   = fmt.Errorf("%w: conflict", Error)
+	ErrPassphrase = fmt.Errorf("%w: invalid passphrase or corrupted archive", Error)
+	// ErrEncryptedProfile is returned by Export when profile is
+	// encrypted and archive would not be, since profile files are
+	// exported decrypted.
+	ErrEncryptedProfile = fmt.Errorf("%w: profile is encrypted, passphrase is required to export it", Error)
+)
+
+// Conflict policies applied on import when archive entry differs from
+// existing file or store value.
+const (
+	ConflictFail      = "fail"
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictRename    = "rename"
+)
+
+// RenameSuffix is appended to names of imported files and store keys
+// conflicting with existing ones when ConflictRename is used.
+const RenameSuffix = ".imported"
+
+const (
+	manifestName  = "manifest.json"
+	storeName     = "store.json"
+	profilePrefix = "profile/"
+	dataPrefix    = "data/"
+)
+
+// Manifest describes archive content.
+type Manifest struct {
+	App       string    `json:"app"`
+	Version   string    `json:"version"`
+	Profile   string    `json:"profile"`
+	CreatedAt time.Time `json:"created_at"`
+	// Files are archive names of profile and data files.
+	Files []string `json:"files"`
+	// Store is set when archive holds state store content.
+	Store bool `json:"store"`
+}
+
+// ExportOptions configures Export.
+type ExportOptions struct {
+	// Data are patterns of data directory files to include, matched
+	// with path.Match against slash separated paths relative to data
+	// directory. Pattern matching directory includes all its files.
+	Data []string
+	// Passphrase encrypts archive when not empty, it is required when
+	// profile is encrypted.
+	Passphrase string
+}
+
+// ImportOptions configures Import.
+type ImportOptions struct {
+	// OnConflict is conflict policy, ConflictFail when empty.
+	OnConflict string
+	// Passphrase decrypts encrypted archive.
+	Passphrase string
+}
+
+// Report describes result of Import. Store values are reported as
+// store:<bucket>/<key>.
+type Report struct {
+	Manifest Manifest
+	// Written are files and store values written.
+	Written []string
+	// Unchanged is number of entries equal to existing ones.
+	Unchanged int
+	// Conflicts are entries differing from existing ones.
+	Conflicts []string
+	// Skipped are conflicting entries which were kept.
+	Skipped []string
+	// Renamed are conflicting entries imported with RenameSuffix.
+	Renamed []string
+}
+
+type entry struct {
+	name string
+	mode fs.FileMode
+	data []byte
+}
+
+// storeDump is state store content in archive.
+type storeDump struct {
+	Version int                                   `json:"version"`
+	Buckets map[string]map[string]json.RawMessage `json:"buckets"`
+}
+
+// Export writes archive of application data to w.
+func Export(sess *session.Context, w io.Writer, opts ExportOptions) (*Manifest, error) {
+	m := &Manifest{
+		App:       sess.Get("app.slug").String(),
+		Version:   sess.Get("app.version").String(),
+		Profile:   sess.Get("app.profile.name").String(),
+		CreatedAt: sess.Time().Now().UTC(),
+	}
+	var entries []entry
+
+	if !sess.Get("app.config.disabled").Bool() {
+		// profile cache is compiled from preferences and remote
+		// configuration is fetched again on next start.
+		dir := sess.Get("app.fs.path.profile").String()
+		files, err := collect(dir, profilePrefix, func(rel string) bool {
+			return rel != config.CacheFilename && rel != config.RemoteFilename
+		})
+		if err != nil {
+			return nil, err
+		}
+		// encrypted profile files are exported decrypted so that they can
+		// be imported with other key, archive must be protected with
+		// Passphrase then.
+		for i, e := range files {
+			p := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(e.name, profilePrefix)))
+			enc, err := config.Encrypted(p)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", Error, err)
+			}
+			if enc && opts.Passphrase == "" {
+				return nil, fmt.Errorf("%w: %s", ErrEncryptedProfile, m.Profile)
+			}
+			if files[i].data, err = config.DecryptFile(p, e.data); err != nil {
+				return nil, fmt.Errorf("%w: %w", Error, err)
+			}
+		}
+		entries = append(entries, files...)
+	}
+
+	st, err := sess.Store()
+	if err != nil {
+		return nil, err
+	}
+	dump, err := dumpStore(st)
+	if err != nil {
+		return nil, err
+	}
+	if len(dump.Buckets) > 0 || dump.Version > 0 {
+		data, err := json.Marshal(dump)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		entries = append(entries, entry{name: storeName, mode: 0600, data: data})
+		m.Store = true
+	}
+
+	if len(opts.Data) > 0 {
+		dataDir := sess.Get("app.fs.path.data").String()
+		files, err := collect(dataDir, dataPrefix, func(rel string) bool {
+			// store is exported from its content, not as file.
+			if rel == filepath.Base(st.Path()) || strings.HasPrefix(rel, "."+filepath.Base(st.Path())) {
+				return false
+			}
+			return matchAny(opts.Data, rel)
+		})
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, files...)
+	}
+
+	for _, e := range entries {
+		if e.name != storeName {
+			m.Files = append(m.Files, e.name)
+		}
+	}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	entries = append([]entry{{name: manifestName, mode: 0600, data: manifest}}, entries...)
+
+	data, err := pack(entries, m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Passphrase != "" {
+		if data, err = encrypt(data, opts.Passphrase); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return m, nil
+}
+
+// Import restores application data from archive read from r into
+// current profile, state store and data directory. With ConflictFail
+// policy nothing is written when any entry conflicts, report lists
+// conflicts and ErrConflict is returned. Imported settings take effect
+// next time application starts.
+func Import(sess *session.Context, r io.Reader, opts ImportOptions) (*Report, error) {
+	policy := opts.OnConflict
+	switch policy {
+	case "":
+		policy = ConflictFail
+	case ConflictFail, ConflictSkip, ConflictOverwrite, ConflictRename:
+	default:
+		return nil, fmt.Errorf("%w: unknown conflict policy %q", Error, policy)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if encrypted(data) {
+		if opts.Passphrase == "" {
+			return nil, fmt.Errorf("%w: archive is encrypted, passphrase is required", Error)
+		}
+		if data, err = decrypt(data, opts.Passphrase); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{}
+	manifest, ok := entries[manifestName]
+	if !ok {
+		return nil, fmt.Errorf("%w: archive has no manifest", Error)
+	}
+	if err := json.Unmarshal(manifest.data, &report.Manifest); err != nil {
+		return nil, fmt.Errorf("%w: invalid manifest: %s", Error, err.Error())
+	}
+
+	// plan file writes, destinations are resolved before anything
+	// is written so that invalid archive leaves data intact.
+	type fileWrite struct {
+		entry
+		dest     string
+		profile  bool
+		conflict bool
+	}
+	var writes []fileWrite
+	for _, name := range report.Manifest.Files {
+		e, ok := entries[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: archive has no %s listed in manifest", Error, name)
+		}
+		var dir, rel string
+		switch {
+		case strings.HasPrefix(name, profilePrefix):
+			dir, rel = sess.Get("app.fs.path.profile").String(), strings.TrimPrefix(name, profilePrefix)
+		case strings.HasPrefix(name, dataPrefix):
+			dir, rel = sess.Get("app.fs.path.data").String(), strings.TrimPrefix(name, dataPrefix)
+		default:
+			return nil, fmt.Errorf("%w: unexpected archive entry %s", Error, name)
+		}
+		if dir == "" || !filepath.IsLocal(filepath.FromSlash(rel)) {
+			return nil, fmt.Errorf("%w: can not import %s", Error, name)
+		}
+		w := fileWrite{
+			entry:   e,
+			dest:    filepath.Join(dir, filepath.FromSlash(rel)),
+			profile: strings.HasPrefix(name, profilePrefix),
+		}
+		current, err := os.ReadFile(w.dest)
+		if err == nil && w.profile {
+			if current, err = config.DecryptFile(w.dest, current); err != nil {
+				return nil, fmt.Errorf("%w: %w", Error, err)
+			}
+		}
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		case bytes.Equal(current, e.data):
+			report.Unchanged++
+			continue
+		default:
+			w.conflict = true
+			report.Conflicts = append(report.Conflicts, name)
+		}
+		writes = append(writes, w)
+	}
+
+	var dump *storeDump
+	if e, ok := entries[storeName]; ok && report.Manifest.Store {
+		dump = &storeDump{}
+		if err := json.Unmarshal(e.data, dump); err != nil {
+			return nil, fmt.Errorf("%w: invalid store content: %s", Error, err.Error())
+		}
+	}
+	st, err := sess.Store()
+	if err != nil {
+		return nil, err
+	}
+	if dump != nil {
+		if err := st.View(func(tx *store.Tx) error {
+			conflicts, _, err := storeConflicts(tx, dump)
+			report.Conflicts = append(report.Conflicts, conflicts...)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if policy == ConflictFail && len(report.Conflicts) > 0 {
+		return report, fmt.Errorf("%w: %d entries differ from existing data", ErrConflict, len(report.Conflicts))
+	}
+
+	if dump != nil {
+		if err := st.Update(func(tx *store.Tx) error {
+			return importStore(tx, dump, policy, report)
+		}); err != nil {
+			return report, err
+		}
+	}
+
+	for _, w := range writes {
+		dest, name := w.dest, w.name
+		if w.conflict {
+			switch policy {
+			case ConflictSkip:
+				report.Skipped = append(report.Skipped, name)
+				continue
+			case ConflictRename:
+				dest, name = dest+RenameSuffix, name+RenameSuffix
+				report.Renamed = append(report.Renamed, name)
+			}
+		}
+		data := w.data
+		if w.profile {
+			var err error
+			if data, err = config.EncryptFile(dest, data); err != nil {
+				return report, fmt.Errorf("%w: %w", Error, err)
+			}
+		}
+		if err := writeFile(dest, data, w.mode); err != nil {
+			return report, err
+		}
+		report.Written = append(report.Written, name)
+	}
+	return report, nil
+}
+
+// dumpStore returns content of the state store.
+func dumpStore(st *store.Store) (*storeDump, error) {
+	dump := &storeDump{Buckets: make(map[string]map[string]json.RawMessage)}
+	err := st.View(func(tx *store.Tx) error {
+		dump.Version = tx.Version()
+		for _, name := range tx.Buckets() {
+			values := make(map[string]json.RawMessage)
+			if err := store.NewBucket[json.RawMessage](name).Range(tx, func(key string, v json.RawMessage) bool {
+				values[key] = v
+				return true
+			}); err != nil {
+				return err
+			}
+			dump.Buckets[name] = values
+		}
+		return nil
+	})
+	return dump, err
+}
+
+// storeConflicts returns store values of dump differing from existing
+// ones and whether store is empty.
+func storeConflicts(tx *store.Tx, dump *storeDump) (conflicts []string, empty bool, err error) {
+	empty = tx.Version() == 0 && len(tx.Buckets()) == 0
+	if !empty && dump.Version != tx.Version() {
+		return nil, empty, fmt.Errorf("%w: archive store version %d differs from local store version %d", Error, dump.Version, tx.Version())
+	}
+	for _, name := range sortedKeys(dump.Buckets) {
+		bucket := store.NewBucket[json.RawMessage](name)
+		for _, key := range sortedKeys(dump.Buckets[name]) {
+			current, err := bucket.Get(tx, key)
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, empty, err
+			}
+			if !jsonEqual(current, dump.Buckets[name][key]) {
+				conflicts = append(conflicts, "store:"+name+"/"+key)
+			}
+		}
+	}
+	return conflicts, empty, nil
+}
+
+func importStore(tx *store.Tx, dump *storeDump, policy string, report *Report) error {
+	conflicts, empty, err := storeConflicts(tx, dump)
+	if err != nil {
+		return err
+	}
+	if policy == ConflictFail && len(conflicts) > 0 {
+		return fmt.Errorf("%w: store changed during import", ErrConflict)
+	}
+	if empty {
+		if err := tx.SetVersion(dump.Version); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(dump.Buckets) {
+		bucket := store.NewBucket[json.RawMessage](name)
+		for _, key := range sortedKeys(dump.Buckets[name]) {
+			v := dump.Buckets[name][key]
+			id := "store:" + name + "/" + key
+			current, err := bucket.Get(tx, key)
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+			case err != nil:
+				return err
+			case jsonEqual(current, v):
+				report.Unchanged++
+				continue
+			case policy == ConflictSkip:
+				report.Skipped = append(report.Skipped, id)
+				continue
+			case policy == ConflictRename:
+				key, id = key+RenameSuffix, id+RenameSuffix
+				report.Renamed = append(report.Renamed, id)
+			}
+			if err := bucket.Put(tx, key, v); err != nil {
+				return err
+			}
+			report.Written = append(report.Written, id)
+		}
+	}
+	return nil
+}
+
+// collect returns regular files below dir as archive entries named
+// prefix+relative path, include filters files when not nil.
+func collect(dir, prefix string, include func(rel string) bool) ([]entry, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	var entries []entry
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if include != nil && !include(rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{name: prefix + rel, mode: info.Mode().Perm(), data: data})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return entries, nil
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(pattern, "/")
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if strings.HasPrefix(rel, pattern+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func pack(entries []entry, modTime time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     e.name,
+			Mode:     int64(e.mode),
+			Size:     int64(len(e.data)),
+			ModTime:  modTime,
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		if _, err := tw.Write(e.data); err != nil {
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return buf.Bytes(), nil
+}
+
+func unpack(data []byte) (map[string]entry, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a backup archive: %s", Error, err.Error())
+	}
+	defer gz.Close()
+	entries := make(map[string]entry)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		mode := fs.FileMode(hdr.Mode).Perm()
+		if mode == 0 {
+			mode = 0600
+		}
+		entries[hdr.Name] = entry{name: hdr.Name, mode: mode, data: data}
+	}
+}
+
+// writeFile writes data to file at path atomically.
+func writeFile(p string, data []byte, perm fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+"-*")
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return nil
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/happy-sdk/happy"
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/backup"
+	"github.com/happy-sdk/happy/sdk/config"
+	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/paths"
+	"github.com/happy-sdk/happy/sdk/store"
+)
+
+var notes = store.NewBucket[string]("notes")
+
+// run runs application with own directories below base and calls do.
+func run(t *testing.T, base string, do func(sess *session.Context) error) {
+	t.Helper()
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"happy-backup-test"}
+	main := app.New(happy.Settings{
+		Slug: "happy-backup-test",
+		FS: paths.Settings{
+			ConfigDir: settings.String(filepath.Join(base, "config")),
+			DataDir:   settings.String(filepath.Join(base, "data")),
+			StateDir:  settings.String(filepath.Join(base, "state")),
+			CacheDir:  settings.String(filepath.Join(base, "cache")),
+		},
+	})
+	main.WithLogger(logging.NewTestLogger(logging.LevelError))
+	main.Do(func(sess *session.Context, args action.Args) error {
+		return do(sess)
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+}
+
+func putNote(sess *session.Context, key, value string) error {
+	st, err := sess.Store()
+	if err != nil {
+		return err
+	}
+	return st.Update(func(tx *store.Tx) error {
+		return notes.Put(tx, key, value)
+	})
+}
+
+func getNote(sess *session.Context, key string) (string, error) {
+	st, err := sess.Store()
+	if err != nil {
+		return "", err
+	}
+	var v string
+	err = st.View(func(tx *store.Tx) error {
+		v, err = notes.Get(tx, key)
+		return err
+	})
+	return v, err
+}
+
+func TestExportImport(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	const passphrase = "correct horse battery staple"
+
+	var archive bytes.Buffer
+	run(t, src, func(sess *session.Context) error {
+		if err := putNote(sess, "a", "from source"); err != nil {
+			return err
+		}
+		dir := filepath.Join(sess.Get("app.fs.path.data").String(), "docs")
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("source"), 0600); err != nil {
+			return err
+		}
+		m, err := backup.Export(sess, &archive, backup.ExportOptions{
+			Data:       []string{"docs"},
+			Passphrase: passphrase,
+		})
+		if err != nil {
+			return err
+		}
+		testutils.True(t, m.Store, "store must be exported")
+		testutils.True(t, bytes.HasPrefix(archive.Bytes(), []byte("HAPPYBAK1")), "archive must be encrypted")
+		return nil
+	})
+
+	tests := []struct {
+		name       string
+		opts       backup.ImportOptions
+		setup      func(sess *session.Context) error
+		wantErr    error
+		wantNote   string
+		wantFile   string
+		wantRename bool
+	}{
+		{
+			name:    "wrong passphrase",
+			opts:    backup.ImportOptions{Passphrase: "wrong"},
+			wantErr: backup.ErrPassphrase,
+		},
+		{
+			// nothing is imported when any entry conflicts.
+			name: "conflict fails",
+			opts: backup.ImportOptions{Passphrase: passphrase},
+			setup: func(sess *session.Context) error {
+				return putNote(sess, "a", "local")
+			},
+			wantErr:  backup.ErrConflict,
+			wantNote: "local",
+		},
+		{
+			name:     "conflict skipped",
+			opts:     backup.ImportOptions{Passphrase: passphrase, OnConflict: backup.ConflictSkip},
+			wantNote: "local",
+			wantFile: "source",
+		},
+		{
+			name:       "conflict renamed",
+			opts:       backup.ImportOptions{Passphrase: passphrase, OnConflict: backup.ConflictRename},
+			wantNote:   "local",
+			wantFile:   "source",
+			wantRename: true,
+		},
+		{
+			name:     "conflict overwritten",
+			opts:     backup.ImportOptions{Passphrase: passphrase, OnConflict: backup.ConflictOverwrite},
+			wantNote: "from source",
+			wantFile: "source",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run(t, dst, func(sess *session.Context) error {
+				if tt.setup != nil {
+					if err := tt.setup(sess); err != nil {
+						return err
+					}
+				}
+				_, err := backup.Import(sess, bytes.NewReader(archive.Bytes()), tt.opts)
+				if tt.wantErr != nil {
+					testutils.ErrorIs(t, err, tt.wantErr)
+				} else {
+					testutils.NoError(t, err)
+				}
+				note, err := getNote(sess, "a")
+				if tt.wantNote == "" {
+					testutils.ErrorIs(t, err, store.ErrNotFound)
+				} else {
+					testutils.NoError(t, err)
+					testutils.Equal(t, tt.wantNote, note)
+				}
+				data, err := os.ReadFile(filepath.Join(sess.Get("app.fs.path.data").String(), "docs", "readme.txt"))
+				if tt.wantFile == "" {
+					testutils.ErrorIs(t, err, os.ErrNotExist)
+				} else {
+					testutils.NoError(t, err)
+					testutils.Equal(t, tt.wantFile, string(data))
+				}
+				if tt.wantRename {
+					note, err := getNote(sess, "a"+backup.RenameSuffix)
+					testutils.NoError(t, err)
+					testutils.Equal(t, "from source", note)
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestExportEncryptedProfile(t *testing.T) {
+	run(t, t.TempDir(), func(sess *session.Context) error {
+		dir := sess.Get("app.fs.path.profile").String()
+		path := filepath.Join(dir, "secrets.preferences")
+		config.Unlock(dir, config.NewKey([]byte("secret")), true)
+		data, err := config.EncryptFile(path, []byte("token=abc"))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return err
+		}
+
+		var archive bytes.Buffer
+		_, err = backup.Export(sess, &archive, backup.ExportOptions{})
+		testutils.ErrorIs(t, err, backup.ErrEncryptedProfile)
+		testutils.Equal(t, 0, archive.Len(), "nothing must be written")
+
+		_, err = backup.Export(sess, &archive, backup.ExportOptions{Passphrase: "archive"})
+		testutils.NoError(t, err)
+		testutils.False(t, bytes.Contains(archive.Bytes(), []byte("token=abc")), "archive must not hold plaintext profile")
+		return nil
+	})
+}
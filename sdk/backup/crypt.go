@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package backup
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/happy-sdk/happy/sdk/internal/crypt"
+)
+
+// encMagic starts encrypted archives, it is followed by salt, nonce
+// and AES-256-GCM sealed gzipped tar archive.
+const encMagic = "HAPPYBAK1"
+
+const (
+	saltSize   = crypt.SaltSize
+	nonceSize  = 12
+	headerSize = len(encMagic) + saltSize + nonceSize
+)
+
+// encrypted reports whether data is encrypted archive.
+func encrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encMagic))
+}
+
+func encrypt(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	out := make([]byte, 0, headerSize+len(data)+gcm.Overhead())
+	out = append(out, encMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	// header is authenticated so that it can not be altered.
+	return gcm.Seal(out, nonce, data, out), nil
+}
+
+func decrypt(data []byte, passphrase string) ([]byte, error) {
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("%w: encrypted archive is truncated", Error)
+	}
+	salt := data[len(encMagic) : len(encMagic)+saltSize]
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := data[len(encMagic)+saltSize : headerSize]
+	out, err := gcm.Open(nil, nonce, data[headerSize:], data[:headerSize])
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	return out, nil
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(crypt.Key([]byte(passphrase), salt))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return gcm, nil
+}
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/pkg/validate"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/artifacts"
+	"github.com/happy-sdk/happy/sdk/cli"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/cli/render"
+)
+
+// Command returns build command which builds matrix configured with
+// app.build settings.
+func Command() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "build",
+		Category:         "Release",
+		Description:      "Cross-compile binaries for configured targets into dist directory",
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.AddInfo("Targets, tags, ldflags and version variable are read from app.build settings, which project local config file can override.")
+
+	cmd.WithFlags(
+		varflag.StringFunc("target", "", "comma separated goos/goarch targets overriding app.build.targets"),
+		varflag.StringFunc("version", "", "version injected into app.build.version_var, git describe by default"),
+		varflag.BoolFunc("sign", false, "write SHA256SUMS and sign it with signer configured with app.artifacts settings"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		m, err := MatrixFromSettings(sess)
+		if err != nil {
+			return err
+		}
+		if targets := args.Flag("target").String(); targets != "" {
+			m.Targets = nil
+			for _, t := range strings.Split(targets, ",") {
+				target, err := ParseTarget(t)
+				if err != nil {
+					return err
+				}
+				m.Targets = append(m.Targets, target)
+			}
+		}
+		if version := args.Flag("version").String(); version != "" {
+			if err := validate.Semver()(version); err != nil {
+				return fmt.Errorf("%w: --version %w", cli.ErrCommandFlags, err)
+			}
+			m.Version = version
+		}
+
+		results, err := m.Run(sess, sess)
+
+		table := textfmt.Table{
+			Title:      "Build",
+			WithHeader: true,
+		}
+		table.AddRow("TARGET", "STATUS", "TOOK", "OUTPUT")
+		for _, res := range results {
+			status, output := "ok", res.Path
+			if res.Err != nil {
+				status, output = "failed", res.Log
+			}
+			table.AddRow(res.Target.String(), status, res.Took.String(), output)
+		}
+		sess.Log().Println(table.String())
+		if err != nil {
+			return err
+		}
+
+		if !args.Flag("sign").Var().Bool() {
+			return nil
+		}
+		signer, err := artifacts.SignerFromSettings(sess)
+		if err != nil {
+			return err
+		}
+		sums, sig, err := artifacts.Sign(sess, signer, m.distDir())
+		if err != nil {
+			return err
+		}
+		sess.Log().Println(fmt.Sprintf("checksums: %s", render.Path(sums, filepath.Base(sums))))
+		if sig != "" {
+			sess.Log().Println(fmt.Sprintf("signature: %s", render.Path(sig, filepath.Base(sig))))
+		}
+		return nil
+	})
+
+	return cmd
+}
@@ -0,0 +1,294 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package build cross-compiles Go packages for matrix of targets in
+// parallel and lays out binaries in dist directory, which is consumed
+// by the releaser and artifacts signer.
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/version"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/internal"
+)
+
+var Error = errors.New("build")
+
+// LogsDir is directory within dist where per target build logs are written.
+const LogsDir = "logs"
+
+type Settings struct {
+	Targets    settings.StringSlice `key:"targets,save" default:"" desc:"Build targets as goos/goarch, current platform when empty"`
+	Package    settings.String      `key:"package,save" default:"." desc:"Package to build relative to project root"`
+	Name       settings.String      `key:"name,save" default:"" desc:"Binary name, base name of package or project root when empty"`
+	Tags       settings.StringSlice `key:"tags,save" default:"" desc:"Build tags"`
+	Ldflags    settings.String      `key:"ldflags,save" default:"-s -w" desc:"Linker flags"`
+	VersionVar settings.String      `key:"version_var,save" default:"" desc:"Package variable set to build version with -X, e.g. main.version"`
+	Dist       settings.String      `key:"dist,save" default:"dist" desc:"Output directory relative to project root"`
+	Parallel   settings.Uint        `key:"parallel,save" default:"0" desc:"Number of parallel builds, number of CPUs when 0"`
+	CGO        settings.Bool        `key:"cgo,save" default:"false" desc:"Enable cgo"`
+}
+
+func (s Settings) Blueprint() (*settings.Blueprint, error) {
+	b, err := settings.New(s)
+	if err != nil {
+		return nil, err
+	}
+	b.AddValidator("targets", "", func(s settings.Setting) error {
+		for _, t := range splitList(s.Value().String()) {
+			if _, err := ParseTarget(t); err != nil {
+				return fmt.Errorf("%w: %s", settings.ErrSetting, err.Error())
+			}
+		}
+		return nil
+	})
+	return b, nil
+}
+
+// Target is single build target.
+type Target struct {
+	GOOS   string
+	GOARCH string
+}
+
+// ParseTarget parses target in goos/goarch form.
+func ParseTarget(s string) (Target, error) {
+	goos, goarch, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+		return Target{}, fmt.Errorf("%w: invalid target %q, expected goos/goarch", Error, s)
+	}
+	return Target{GOOS: goos, GOARCH: goarch}, nil
+}
+
+func (t Target) String() string {
+	return t.GOOS + "/" + t.GOARCH
+}
+
+// Matrix describes builds of package for set of targets.
+type Matrix struct {
+	// Root is directory builds are run in.
+	Root    string
+	Package string
+	Name    string
+	// Version is injected into pkg/version and into VersionVar when set.
+	Version    string
+	VersionVar string
+	Tags       []string
+	Ldflags    string
+	// Dist is output directory, relative paths are relative to Root.
+	Dist     string
+	Parallel int
+	CGO      bool
+	Targets  []Target
+}
+
+// Result is result of single target build.
+type Result struct {
+	Target Target
+	// Path is path of built binary.
+	Path string
+	// Log is path of build log.
+	Log  string
+	Took time.Duration
+	Err  error
+}
+
+// MatrixFromSettings returns matrix configured with app.build settings,
+// project local config file can override them. Root is project root when
+// application runs within project and version is described by git.
+func MatrixFromSettings(sess *session.Context) (*Matrix, error) {
+	m := &Matrix{
+		Package:    sess.Get("app.build.package").String(),
+		Name:       sess.Get("app.build.name").String(),
+		VersionVar: sess.Get("app.build.version_var").String(),
+		Tags:       splitList(sess.Get("app.build.tags").String()),
+		Ldflags:    sess.Get("app.build.ldflags").String(),
+		Dist:       sess.Get("app.build.dist").String(),
+		Parallel:   int(sess.Get("app.build.parallel").Uint()),
+		CGO:        sess.Get("app.build.cgo").Bool(),
+	}
+	if proj := sess.Project(); proj != nil {
+		m.Root = proj.Root
+	} else {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		m.Root = wd
+	}
+	for _, t := range splitList(sess.Get("app.build.targets").String()) {
+		target, err := ParseTarget(t)
+		if err != nil {
+			return nil, err
+		}
+		m.Targets = append(m.Targets, target)
+	}
+	m.Version = describe(m.Root)
+	return m, nil
+}
+
+// Run builds all targets, at most Parallel at once, and returns
+// results in order of targets. Error is returned when any build fails.
+func (m *Matrix) Run(ctx context.Context, sess *session.Context) ([]Result, error) {
+	targets := m.Targets
+	if len(targets) == 0 {
+		targets = []Target{{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH}}
+	}
+	dist := m.distDir()
+	if err := os.MkdirAll(filepath.Join(dist, LogsDir), 0750); err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	parallel := m.Parallel
+	if parallel <= 0 {
+		parallel = runtime.NumCPU()
+	}
+
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, parallel)
+		results = make([]Result, len(targets))
+		ldflags = m.ldflags(time.Now())
+	)
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = m.build(ctx, sess, dist, ldflags, target)
+		}(i, target)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, res := range results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w (see %s)", res.Target, res.Err, res.Log))
+		}
+	}
+	if len(errs) > 0 {
+		return results, fmt.Errorf("%w: %w", Error, errors.Join(errs...))
+	}
+	return results, nil
+}
+
+func (m *Matrix) build(ctx context.Context, sess *session.Context, dist, ldflags string, target Target) Result {
+	start := time.Now()
+	res := Result{
+		Target: target,
+		Path:   filepath.Join(dist, m.binaryName(target)),
+		Log:    filepath.Join(dist, LogsDir, target.GOOS+"_"+target.GOARCH+".log"),
+	}
+	logf, err := os.Create(res.Log)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer logf.Close()
+
+	args := []string{"build", "-trimpath", "-o", res.Path}
+	if len(m.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(m.Tags, ","))
+	}
+	args = append(args, "-ldflags", ldflags)
+	pkg := m.Package
+	if pkg == "" {
+		pkg = "."
+	}
+	args = append(args, pkg)
+
+	cgo := "0"
+	if m.CGO {
+		cgo = "1"
+	}
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = m.Root
+	cmd.Env = append(os.Environ(), "GOOS="+target.GOOS, "GOARCH="+target.GOARCH, "CGO_ENABLED="+cgo)
+	cmd.Stdout = logf
+	cmd.Stderr = logf
+	fmt.Fprintf(logf, "# %s\n", strings.Join(cmd.Args, " "))
+
+	internal.Log(sess.Log(), "build", slog.String("target", target.String()), slog.String("output", res.Path))
+	res.Err = cmd.Run()
+	res.Took = time.Since(start)
+	return res
+}
+
+// ldflags returns linker flags, build version and time are always
+// injected into pkg/version so that --version reports them.
+func (m *Matrix) ldflags(buildTime time.Time) string {
+	ldflags := m.Ldflags
+	if m.Version != "" {
+		ldflags += " -X " + version.LdflagsVersion + "=" + m.Version
+		if m.VersionVar != "" {
+			ldflags += " -X " + m.VersionVar + "=" + m.Version
+		}
+	}
+	ldflags += " -X " + version.LdflagsTime + "=" + buildTime.UTC().Format(time.RFC3339)
+	return strings.TrimSpace(ldflags)
+}
+
+func (m *Matrix) distDir() string {
+	dist := m.Dist
+	if dist == "" {
+		dist = "dist"
+	}
+	if !filepath.IsAbs(dist) {
+		dist = filepath.Join(m.Root, dist)
+	}
+	return dist
+}
+
+// binaryName returns name of binary in dist directory,
+// <name>_<version>_<goos>_<goarch>[.exe].
+func (m *Matrix) binaryName(target Target) string {
+	name := m.Name
+	if name == "" {
+		name = filepath.Base(filepath.Join(m.Root, m.Package))
+	}
+	parts := []string{name}
+	if m.Version != "" {
+		parts = append(parts, m.Version)
+	}
+	parts = append(parts, target.GOOS, target.GOARCH)
+	bin := strings.Join(parts, "_")
+	if target.GOOS == "windows" {
+		bin += ".exe"
+	}
+	return bin
+}
+
+// describe returns version of git repository in dir or empty string.
+func describe(dir string) string {
+	cmd := exec.Command("git", "describe", "--tags", "--always", "--dirty")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, "|") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package changelog models changelog as releases with sections of
+// entries, renders it as Markdown release notes or keep-a-changelog
+// document and parses existing CHANGELOG.md so that it can be updated
+// idempotently.
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+var Error = errors.New("changelog")
+
+// Unreleased is version of release collecting unreleased changes.
+const Unreleased = "Unreleased"
+
+// Section titles of keep-a-changelog format and breaking changes.
+const (
+	SectionBreaking   = "Breaking Changes"
+	SectionAdded      = "Added"
+	SectionChanged    = "Changed"
+	SectionDeprecated = "Deprecated"
+	SectionRemoved    = "Removed"
+	SectionFixed      = "Fixed"
+	SectionSecurity   = "Security"
+)
+
+// sectionOrder is order sections are rendered in, other sections
+// are rendered after these in order they were added.
+var sectionOrder = []string{
+	SectionBreaking,
+	SectionAdded,
+	SectionChanged,
+	SectionDeprecated,
+	SectionRemoved,
+	SectionFixed,
+	SectionSecurity,
+}
+
+// Changelog is list of releases, newest first.
+type Changelog struct {
+	Title string
+	// Preamble is text between title and first release.
+	Preamble string
+	Releases []Release
+}
+
+// Release is changes of single version.
+type Release struct {
+	// Version is released version or Unreleased.
+	Version string
+	// Date is release date, zero for unreleased changes.
+	Date time.Time
+	// Link is URL of release or compare view.
+	Link     string
+	Sections []Section
+}
+
+// Section is group of entries e.g. Added or Fixed.
+type Section struct {
+	Title   string
+	Entries []Entry
+}
+
+// Entry is single change.
+type Entry struct {
+	Text   string
+	Scope  string
+	Hash   string
+	Author string
+}
+
+// New returns empty changelog with keep-a-changelog title and preamble.
+func New() *Changelog {
+	return &Changelog{
+		Title: "Changelog",
+		Preamble: "All notable changes to this project will be documented in this file.\n\n" +
+			"The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),\n" +
+			"and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).",
+	}
+}
+
+// Release returns release of version, it reports false when there is
+// no such release.
+func (c *Changelog) Release(version string) (*Release, bool) {
+	for i := range c.Releases {
+		if c.Releases[i].Version == version {
+			return &c.Releases[i], true
+		}
+	}
+	return nil, false
+}
+
+// Upsert adds release r or merges it into existing release of same
+// version, entries already present are not added again so that
+// updating changelog with same release is idempotent. New releases are
+// added after unreleased changes before older releases.
+func (c *Changelog) Upsert(r Release) {
+	if existing, ok := c.Release(r.Version); ok {
+		if !r.Date.IsZero() {
+			existing.Date = r.Date
+		}
+		if r.Link != "" {
+			existing.Link = r.Link
+		}
+		for _, s := range r.Sections {
+			for _, e := range s.Entries {
+				existing.Add(s.Title, e)
+			}
+		}
+		return
+	}
+	pos := 0
+	if len(c.Releases) > 0 && c.Releases[0].Version == Unreleased && r.Version != Unreleased {
+		pos = 1
+	}
+	c.Releases = append(c.Releases[:pos], append([]Release{r}, c.Releases[pos:]...)...)
+}
+
+// Add adds entry to section of release unless section already has
+// entry with same text.
+func (r *Release) Add(section string, e Entry) {
+	for i := range r.Sections {
+		if r.Sections[i].Title != section {
+			continue
+		}
+		for _, existing := range r.Sections[i].Entries {
+			if existing.Text == e.Text {
+				return
+			}
+		}
+		r.Sections[i].Entries = append(r.Sections[i].Entries, e)
+		return
+	}
+	r.Sections = append(r.Sections, Section{Title: section, Entries: []Entry{e}})
+}
+
+// Section returns section of release, it reports false when there is
+// no such section.
+func (r *Release) Section(title string) (*Section, bool) {
+	for i := range r.Sections {
+		if r.Sections[i].Title == title {
+			return &r.Sections[i], true
+		}
+	}
+	return nil, false
+}
+
+// Empty reports whether release has no entries.
+func (r *Release) Empty() bool {
+	for _, s := range r.Sections {
+		if len(s.Entries) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Update reads changelog file at path, upserts release r and writes it
+// back in keep-a-changelog format. Missing file is created.
+func Update(path string, r Release) error {
+	c := New()
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if c, err = Parse(data); err != nil {
+			return err
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	c.Upsert(r)
+	if err := os.WriteFile(path, c.KeepAChangelog(), 0644); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return nil
+}
+
+// sortedSections returns sections in render order.
+func (r *Release) sortedSections() []Section {
+	var sorted []Section
+	for _, title := range sectionOrder {
+		if s, ok := r.Section(title); ok {
+			sorted = append(sorted, *s)
+		}
+	}
+	for _, s := range r.Sections {
+		known := false
+		for _, title := range sectionOrder {
+			if s.Title == title {
+				known = true
+				break
+			}
+		}
+		if !known {
+			sorted = append(sorted, s)
+		}
+	}
+	return sorted
+}
+
+func (e Entry) String() string {
+	var b strings.Builder
+	if e.Scope != "" {
+		b.WriteString("**" + e.Scope + ":** ")
+	}
+	b.WriteString(e.Text)
+	if e.Hash != "" {
+		b.WriteString(" (" + e.Hash + ")")
+	}
+	return b.String()
+}
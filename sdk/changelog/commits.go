@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package changelog
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Commit is commit to be added to changelog.
+type Commit struct {
+	Hash    string
+	Author  string
+	Message string
+}
+
+var conventionalRegex = regexp.MustCompile(`^(?P<Type>[a-zA-Z]+)(?:\((?P<Scope>[^\)]*)\))?(?P<Breaking>!)?: (?P<Subject>.+)$`)
+
+// commitSections maps conventional commit types to sections, commits
+// of other types are not added to changelog.
+var commitSections = map[string]string{
+	"feat":     SectionAdded,
+	"fix":      SectionFixed,
+	"perf":     SectionChanged,
+	"refactor": SectionChanged,
+	"deps":     SectionChanged,
+	"revert":   SectionRemoved,
+	"security": SectionSecurity,
+}
+
+// NewRelease returns release of version from conventional commits,
+// use zero date for unreleased changes.
+func NewRelease(version string, date time.Time, commits []Commit) Release {
+	r := Release{Version: version, Date: date}
+	for _, c := range commits {
+		subject, body, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+		m := conventionalRegex.FindStringSubmatch(strings.TrimSpace(subject))
+		if m == nil {
+			continue
+		}
+		typ, scope, breaking, text := strings.ToLower(m[1]), m[2], m[3] == "!", m[4]
+		entry := Entry{Text: text, Scope: scope, Hash: c.Hash, Author: c.Author}
+
+		for _, line := range strings.Split(body, "\n") {
+			if note, ok := strings.CutPrefix(strings.TrimSpace(line), "BREAKING CHANGE:"); ok {
+				r.Add(SectionBreaking, Entry{Text: strings.TrimSpace(note), Scope: scope, Hash: c.Hash, Author: c.Author})
+				breaking = false
+			}
+		}
+		if breaking {
+			r.Add(SectionBreaking, entry)
+			continue
+		}
+		if section, ok := commitSections[typ]; ok {
+			r.Add(section, entry)
+		}
+	}
+	return r
+}
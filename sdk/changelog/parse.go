@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package changelog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	releaseRegex = regexp.MustCompile(`^##\s+\[?([^\]\s]+)\]?(?:\s+-\s+(\d{4}-\d{2}-\d{2}))?`)
+	linkRegex    = regexp.MustCompile(`^\[([^\]]+)\]:\s*(\S+)\s*$`)
+	hashRegex    = regexp.MustCompile(`^(.*) \(([0-9a-f]{7,40})\)$`)
+	scopeRegex   = regexp.MustCompile(`^\*\*([^*]+):\*\* (.*)$`)
+)
+
+// Parse parses Markdown changelog in keep-a-changelog format. Release
+// headings may be "## [1.0.0] - 2024-01-02", "## [Unreleased]" or
+// "## v1.0.0", entries are list items under "### Section" headings.
+// Text outside of releases and sections is kept as preamble.
+func Parse(data []byte) (*Changelog, error) {
+	c := &Changelog{}
+	var (
+		release  *Release
+		section  string
+		preamble []string
+		links    = make(map[string]string)
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for ln := 1; scanner.Scan(); ln++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "# ") && c.Title == "" && release == nil:
+			c.Title = strings.TrimSpace(trimmed[2:])
+		case strings.HasPrefix(trimmed, "## "):
+			m := releaseRegex.FindStringSubmatch(trimmed)
+			if m == nil {
+				return nil, fmt.Errorf("%w: line %d: invalid release heading %q", Error, ln, trimmed)
+			}
+			r := Release{Version: m[1]}
+			if m[2] != "" {
+				date, err := time.Parse(DateFormat, m[2])
+				if err != nil {
+					return nil, fmt.Errorf("%w: line %d: %s", Error, ln, err.Error())
+				}
+				r.Date = date
+			}
+			c.Releases = append(c.Releases, r)
+			release, section = &c.Releases[len(c.Releases)-1], ""
+		case strings.HasPrefix(trimmed, "### ") && release != nil:
+			section = strings.TrimSpace(trimmed[4:])
+		case linkRegex.MatchString(trimmed):
+			m := linkRegex.FindStringSubmatch(trimmed)
+			links[m[1]] = m[2]
+		case (strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ")) && release != nil && section != "":
+			release.Add(section, parseEntry(trimmed[2:]))
+		case trimmed != "" && release != nil && section != "" && line != trimmed:
+			// continuation of previous entry
+			if s, ok := release.Section(section); ok && len(s.Entries) > 0 {
+				e := &s.Entries[len(s.Entries)-1]
+				e.Text += " " + trimmed
+			}
+		case release == nil:
+			preamble = append(preamble, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	c.Preamble = strings.TrimSpace(strings.Join(preamble, "\n"))
+	for i := range c.Releases {
+		c.Releases[i].Link = links[c.Releases[i].Version]
+	}
+	return c, nil
+}
+
+func parseEntry(s string) Entry {
+	var e Entry
+	if m := hashRegex.FindStringSubmatch(s); m != nil {
+		s, e.Hash = m[1], m[2]
+	}
+	if m := scopeRegex.FindStringSubmatch(s); m != nil {
+		e.Scope, s = m[1], m[2]
+	}
+	e.Text = s
+	return e
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package changelog
+
+import (
+	"strings"
+)
+
+// DateFormat is format of release dates.
+const DateFormat = "2006-01-02"
+
+// KeepAChangelog renders changelog in keep-a-changelog format.
+func (c *Changelog) KeepAChangelog() []byte {
+	var b strings.Builder
+	if c.Title != "" {
+		b.WriteString("# " + c.Title + "\n\n")
+	}
+	if c.Preamble != "" {
+		b.WriteString(strings.TrimSpace(c.Preamble) + "\n\n")
+	}
+
+	var links []string
+	for _, r := range c.Releases {
+		b.WriteString("## [" + r.Version + "]")
+		if !r.Date.IsZero() {
+			b.WriteString(" - " + r.Date.Format(DateFormat))
+		}
+		b.WriteString("\n\n")
+		for _, s := range r.sortedSections() {
+			if len(s.Entries) == 0 {
+				continue
+			}
+			b.WriteString("### " + s.Title + "\n\n")
+			for _, e := range s.Entries {
+				b.WriteString("- " + e.String() + "\n")
+			}
+			b.WriteString("\n")
+		}
+		if r.Link != "" {
+			links = append(links, "["+r.Version+"]: "+r.Link)
+		}
+	}
+	for _, link := range links {
+		b.WriteString(link + "\n")
+	}
+	return []byte(strings.TrimRight(b.String(), "\n") + "\n")
+}
+
+// Markdown renders release as Markdown release notes, heading is
+// omitted so that notes can be used as body of release.
+func (r *Release) Markdown() string {
+	var b strings.Builder
+	for _, s := range r.sortedSections() {
+		if len(s.Entries) == 0 {
+			continue
+		}
+		b.WriteString("### " + s.Title + "\n\n")
+		for _, e := range s.Entries {
+			b.WriteString("* " + e.String() + "\n")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
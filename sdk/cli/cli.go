@@ -16,6 +16,7 @@ import (
 	"strings"
 
 	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/validate"
 	"github.com/happy-sdk/happy/pkg/vars/varflag"
 	"github.com/happy-sdk/happy/sdk/app/session"
 	"github.com/happy-sdk/happy/sdk/logging"
@@ -31,19 +32,27 @@ var (
 // Common CLI flags which are automatically attached to the CLI ubnless disabled ins settings.
 // You still can manually add them to your CLI if you want to.
 var (
-	FlagVersion     = varflag.BoolFunc("version", false, "print application version")
-	FlagHelp        = varflag.BoolFunc("help", false, "display help or help for the command. [...command --help]", "h")
-	FlagX           = varflag.BoolFunc("x", false, "the -x flag prints all the cli commands as they are executed.")
-	FlagSystemDebug = varflag.BoolFunc("system-debug", false, "enable system debug log level (very verbose)")
-	FlagDebug       = varflag.BoolFunc("debug", false, "enable debug log level")
-	FlagVerbose     = varflag.BoolFunc("verbose", false, "enable verbose log level", "v")
+	FlagVersion      = varflag.BoolFunc("version", false, "print application version")
+	FlagHelp         = varflag.BoolFunc("help", false, "display help or help for the command. [...command --help]", "h")
+	FlagX            = varflag.BoolFunc("x", false, "the -x flag prints all the cli commands as they are executed.")
+	FlagSystemDebug  = varflag.BoolFunc("system-debug", false, "enable system debug log level (very verbose)")
+	FlagDebug        = varflag.BoolFunc("debug", false, "enable debug log level")
+	FlagVerbose      = varflag.BoolFunc("verbose", false, "enable verbose log level", "v")
+	FlagPrintStartup = varflag.BoolFunc("print-startup", false, "print startup report, settings sources, addons, services and time spent per init phase")
+	FlagOutput       = varflag.OptionFunc("output", []string{"text"}, []string{"text", "json"}, "output format, json writes command result and exit metadata to stdout")
+	FlagWaitLock     = varflag.DurationFunc("wait-lock", 0, "wait up to given duration for resources locked by other invocations, by default fail immediately")
+	FlagSet          = varflag.StringFunc("set", "", "override setting for this invocation as key=value, can be repeated")
+	FlagNotify       = varflag.BoolFunc("notify", false, "send desktop notification when command completes")
 )
 
 type Settings struct {
 	MainMinArgs        settings.Uint `default:"0" desc:"Minimum number of arguments for a application main"`
 	MainMaxArgs        settings.Uint `default:"0" desc:"Maximum number of arguments for a application main"`
 	WithoutConfigCmd   settings.Bool `default:"false" desc:"Do not include the config command in the CLI"`
+	WithoutDoctorCmd   settings.Bool `default:"false" desc:"Do not include the doctor command in the CLI"`
+	WithoutInfoCmd     settings.Bool `default:"false" desc:"Do not include the info command in the CLI"`
 	WithoutGlobalFlags settings.Bool `default:"false" desc:"Do not include the global flags automatically in the CLI"`
+	UsageHelp          settings.Bool `default:"false" desc:"Print full command help on usage errors instead of usage lines"`
 }
 
 func (s Settings) Blueprint() (*settings.Blueprint, error) {
@@ -87,6 +96,19 @@ func AskForInput(q string) string {
 	return strings.TrimSpace(response)
 }
 
+// AskForValidInput asks for input until it passes validators, invalid
+// answers are explained and question is asked again.
+func AskForValidInput(q string, validators ...validate.Validator) string {
+	for {
+		response := AskForInput(q)
+		err := validate.Check(response, validators...)
+		if err == nil {
+			return response
+		}
+		fmt.Fprintln(os.Stdout, err.Error())
+	}
+}
+
 // Exec wraps ExecRaw to return output as string.
 func Exec(sess *session.Context, cmd *exec.Cmd) (string, error) {
 	out, err := ExecRaw(sess, cmd)
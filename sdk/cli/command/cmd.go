@@ -5,10 +5,15 @@
 package command
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/happy-sdk/happy/pkg/settings"
@@ -20,6 +25,10 @@ import (
 
 // Command is building command chain from provided root command.
 func Compile(root *Command) (*Cmd, *logging.QueueLogger, error) {
+	osargs, passthrough := splitPassthrough(os.Args)
+	if err := root.expand(osargs); err != nil {
+		return nil, root.cnflog, err
+	}
 
 	if err := root.verify(); err != nil {
 		return nil, root.cnflog, err
@@ -30,16 +39,29 @@ func Compile(root *Command) (*Cmd, *logging.QueueLogger, error) {
 	}
 	defer root.mu.Unlock()
 
-	if err := root.flags.Parse(os.Args); err != nil {
-		return nil, root.cnflog, err
+	if err := root.flags.Parse(osargs); err != nil {
+		var aerr *varflag.ArgError
+		if errors.As(err, &aerr) {
+			acmd := root.activeCommand()
+			return nil, root.cnflog, acmd.usageError(fmt.Errorf("%w%s", err, acmd.didYouMean(aerr.Arg)))
+		}
+		return nil, root.cnflog, root.usageError(err)
 	}
 
 	acmd, err := root.getActiveCommand()
 	if err != nil {
 		return nil, root.cnflog, err
 	}
+	if err := acmd.disabledErr(); err != nil {
+		return nil, root.cnflog, err
+	}
 
-	cmd := &Cmd{}
+	cmd := &Cmd{
+		passthrough: passthrough,
+		root:        root,
+		active:      acmd,
+		invoking:    map[*Command]bool{acmd: true},
+	}
 
 	if acmd == root {
 		cmd.isRoot = true
@@ -78,38 +100,50 @@ func Compile(root *Command) (*Cmd, *logging.QueueLogger, error) {
 
 	cmd.beforeAction = acmd.beforeAction
 	cmd.doAction = acmd.doAction
+	cmd.doResultAction = acmd.doResultAction
 	cmd.afterSuccessAction = acmd.afterSuccessAction
+	cmd.afterResultAction = acmd.afterResultAction
 	cmd.afterFailureAction = acmd.afterFailureAction
 	cmd.afterAlwaysAction = acmd.afterAlwaysAction
 
-	var catdesc = make(map[string]string)
+	var (
+		catdesc   = make(map[string]string)
+		catweight = make(map[string]int)
+	)
 	if acmd.parent != nil {
 		cmd.parent = compileParent(acmd.parent)
-		if acmd.parent.catdesc != nil {
-			for k, v := range acmd.parent.catdesc {
-				catdesc[k] = v
-			}
-		}
+		maps.Copy(catdesc, acmd.parent.catdesc)
+		maps.Copy(catweight, acmd.parent.catweight)
 	}
 
-	for k, v := range cmd.catdesc {
-		catdesc[k] = v
-	}
-	for _, scmd := range acmd.subCommands {
-		cmd.subcmds = append(cmd.subcmds, SubCmdInfo{
-			Name:        scmd.cnf.Get("name").String(),
-			Description: scmd.cnf.Get("description").String(),
-			Category:    scmd.cnf.Get("category").String(),
-		})
-		for k, v := range scmd.catdesc {
-			catdesc[k] = v
-		}
-	}
+	maps.Copy(catdesc, acmd.catdesc)
+	maps.Copy(catweight, acmd.catweight)
 	cmd.catdesc = catdesc
+	cmd.catweight = catweight
 
 	return cmd, root.cnflog, nil
 }
 
+// loadSubCommands collects subcommand info and categories of active
+// command. It is deferred until help needs them, since lazy
+// subcommands are materialized to describe them.
+func (c *Cmd) loadSubCommands() {
+	c.subOnce.Do(func() {
+		if c.active == nil {
+			return
+		}
+		for _, scmd := range c.active.subCommandList() {
+			c.subcmds = append(c.subcmds, SubCmdInfo{
+				Name:        scmd.cnf.Get("name").String(),
+				Description: scmd.cnf.Get("description").String(),
+				Category:    scmd.cnf.Get("category").String(),
+			})
+			maps.Copy(c.catdesc, scmd.catdesc)
+			maps.Copy(c.catweight, scmd.catweight)
+		}
+	})
+}
+
 func compileParent(cmd *Command) *Cmd {
 	c := &Cmd{
 		cnf:              cmd.cnf,
@@ -121,8 +155,13 @@ func compileParent(cmd *Command) *Cmd {
 		flags:            cmd.flags,
 	}
 
+	// After actions are shared with Before action.
 	if c.cnf.Get("shared_before_action").Value().Bool() {
 		c.beforeAction = cmd.beforeAction
+		c.afterSuccessAction = cmd.afterSuccessAction
+		c.afterResultAction = cmd.afterResultAction
+		c.afterFailureAction = cmd.afterFailureAction
+		c.afterAlwaysAction = cmd.afterAlwaysAction
 	}
 
 	if cmd.parent != nil {
@@ -147,22 +186,38 @@ type Cmd struct {
 	parents          []string
 	isWrapperCommand bool
 	catdesc          map[string]string
+	catweight        map[string]int
 	usage            []string
 	info             []string
 
 	beforeAction       action.WithArgs
 	doAction           action.WithArgs
+	doResultAction     action.WithArgsResult
 	afterSuccessAction action.Action
+	afterResultAction  action.WithResult
 	afterFailureAction action.WithPrevErr
 	afterAlwaysAction  action.WithPrevErr
 
+	result      *action.Result
+	passthrough []string
+
 	parent *Cmd
+	// shared are parent commands which share actions with command,
+	// in order their Before actions were called.
+	shared []*Cmd
+
+	// used by Invoke
+	root     *Command
+	active   *Command
+	invmu    sync.Mutex
+	invoking map[*Command]bool
 
 	// used in help menu
 	globalFlags []varflag.Flag
 	sharedFlags []varflag.Flag
 	ownFlags    []varflag.Flag
 
+	subOnce sync.Once
 	subcmds []SubCmdInfo
 }
 
@@ -174,6 +229,19 @@ func (c *Cmd) Name() string {
 	return c.cnf.Get("name").String()
 }
 
+// Path returns full command path e.g. "app sub".
+func (c *Cmd) Path() string {
+	return strings.Join(append(append([]string{}, c.parents...), c.Name()), " ")
+}
+
+// Result returns Result of DoWithResult action, nil when command has
+// not been executed or action did not return result.
+func (c *Cmd) Result() *action.Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.result
+}
+
 func (c *Cmd) Usage() []string {
 	return c.usage
 }
@@ -211,17 +279,105 @@ func (c *Cmd) GlobalFlags() []varflag.Flag {
 }
 
 func (c *Cmd) SubCommands() []SubCmdInfo {
+	c.loadSubCommands()
 	return c.subcmds
 }
 
+// Fingerprint returns checksum of everything help of the command is
+// rendered from. It does not materialize lazy subcommands, they are
+// identified by name, so changing their description does not change
+// fingerprint until application version changes.
+func (c *Cmd) Fingerprint() string {
+	h := sha256.New()
+	write := func(s ...string) {
+		for _, v := range s {
+			fmt.Fprintf(h, "%d:%s;", len(v), v)
+		}
+	}
+	write(c.parents...)
+	write(c.Name(), fmt.Sprint(c.isRoot))
+	write(c.usage...)
+	write(c.info...)
+	for _, flags := range [][]varflag.Flag{c.ownFlags, c.sharedFlags, c.globalFlags} {
+		write("flags")
+		for _, flag := range flags {
+			write(flag.Flag(), flag.UsageAliases(), flag.Usage())
+		}
+	}
+	cats := make([]string, 0, len(c.catdesc)+len(c.catweight))
+	for cat := range c.catdesc {
+		cats = append(cats, cat)
+	}
+	for cat := range c.catweight {
+		cats = append(cats, cat)
+	}
+	slices.Sort(cats)
+	for _, cat := range slices.Compact(cats) {
+		write(cat, c.catdesc[cat], fmt.Sprint(c.catweight[cat]))
+	}
+	if c.active != nil {
+		for _, name := range c.active.subCommandNames() {
+			write(name)
+			if scmd, ok := c.active.getSubCommand(name); ok {
+				write(scmd.cnf.Get("description").String(), scmd.cnf.Get("category").String())
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 func (c *Cmd) Categories() map[string]string {
+	c.loadSubCommands()
 	return c.catdesc
 }
 
+// CategoryWeights returns category weights set with Command.WithCategory.
+func (c *Cmd) CategoryWeights() map[string]int {
+	c.loadSubCommands()
+	return c.catweight
+}
+
 func (c *Cmd) IsImmediate() bool {
 	return c.cnf.Get("immediate").Value().Bool()
 }
 
+// Env returns environment variables declared by command and its parents,
+// parent entries come first.
+func (c *Cmd) Env() []string {
+	var env []string
+	if c.parent != nil {
+		env = c.parent.Env()
+	}
+	for _, entry := range strings.Split(c.cnf.Get("env").String(), "|") {
+		if entry != "" {
+			env = append(env, entry)
+		}
+	}
+	return env
+}
+
+// RequiresServices returns services command requires to be running for Do action.
+func (c *Cmd) RequiresServices() []string {
+	var svcs []string
+	for _, svc := range strings.Split(c.cnf.Get("requires_services").String(), "|") {
+		if svc != "" {
+			svcs = append(svcs, svc)
+		}
+	}
+	return svcs
+}
+
+// Locks returns names of shared resources command locks while running.
+func (c *Cmd) Locks() []string {
+	var locks []string
+	for _, lock := range strings.Split(c.cnf.Get("locks").String(), "|") {
+		if lock != "" {
+			locks = append(locks, lock)
+		}
+	}
+	return locks
+}
+
 func (c *Cmd) IsWrapper() bool {
 	return c.isWrapperCommand
 }
@@ -229,6 +385,7 @@ func (c *Cmd) IsWrapper() bool {
 func (c *Cmd) ExecBefore(sess *session.Context) (err error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	defer action.Recover(&err)
 
 	args, err := c.getArgs()
 	if err != nil {
@@ -236,7 +393,7 @@ func (c *Cmd) ExecBefore(sess *session.Context) (err error) {
 	}
 
 	if c.parent != nil && !c.sharedCalled && !c.cnf.Get("skip_shared_before").Value().Bool() {
-		if err := c.parent.callSharedBeforeAction(sess); err != nil {
+		if err := c.parent.callSharedBeforeAction(sess, &c.shared); err != nil {
 			return err
 		}
 		// dereference parent
@@ -261,8 +418,9 @@ func (c *Cmd) ExecBefore(sess *session.Context) (err error) {
 func (c *Cmd) ExecDo(sess *session.Context) (err error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	defer action.Recover(&err)
 
-	if c.doAction == nil {
+	if c.doAction == nil && c.doResultAction == nil {
 		return nil
 	}
 
@@ -271,7 +429,12 @@ func (c *Cmd) ExecDo(sess *session.Context) (err error) {
 		return err
 	}
 
-	if err := c.doAction(sess, args); err != nil {
+	if c.doResultAction != nil {
+		c.result, err = c.doResultAction(sess, args)
+	} else {
+		err = c.doAction(sess, args)
+	}
+	if err != nil {
 		sess.Log().Debug("do action",
 			slog.String("cmd", c.cnf.Get("name").String()),
 			slog.String("err", err.Error()),
@@ -281,21 +444,44 @@ func (c *Cmd) ExecDo(sess *session.Context) (err error) {
 
 	// dereference do action
 	c.doAction = nil
+	c.doResultAction = nil
 	return err
 }
 
-func (c *Cmd) ExecAfterFailure(sess *session.Context, err error) error {
+// ExecAfterFailure calls AfterFailure actions, see ExecAfterAlways.
+func (c *Cmd) ExecAfterFailure(sess *session.Context, prevErr error) (err error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	return c.execAfter(sess, "after failure action", func(cmd *Cmd) error {
+		return cmd.afterFailure(sess, prevErr)
+	})
+}
+
+// ExecAfterSuccess calls AfterSuccess actions, see ExecAfterAlways.
+// When AfterSuccess action fails, AfterFailure actions of remaining
+// parent commands are called with its error instead, so that success
+// actions never follow failure.
+func (c *Cmd) ExecAfterSuccess(sess *session.Context) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var failed error
+	return c.execAfter(sess, "after success action", func(cmd *Cmd) error {
+		if failed != nil {
+			return cmd.afterFailure(sess, failed)
+		}
+		if err := action.Try(func() error { return cmd.afterSuccess(sess, c.result) }); err != nil {
+			failed = err
+			return err
+		}
+		return nil
+	})
+}
+
+func (c *Cmd) afterFailure(sess *session.Context, prevErr error) error {
 	if c.afterFailureAction == nil {
 		return nil
 	}
-
-	if err := c.afterFailureAction(sess, err); err != nil {
-		sess.Log().Debug("after failure action",
-			slog.String("cmd", c.cnf.Get("name").String()),
-			slog.String("err", err.Error()),
-		)
+	if err := c.afterFailureAction(sess, prevErr); err != nil {
 		return err
 	}
 	// dereference after failure action
@@ -303,60 +489,82 @@ func (c *Cmd) ExecAfterFailure(sess *session.Context, err error) error {
 	return nil
 }
 
-func (c *Cmd) ExecAfterSuccess(sess *session.Context) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if c.afterSuccessAction == nil {
+func (c *Cmd) afterSuccess(sess *session.Context, result *action.Result) error {
+	if c.afterSuccessAction == nil && c.afterResultAction == nil {
 		return nil
 	}
-
-	if err := c.afterSuccessAction(sess); err != nil {
-		sess.Log().Debug("after success action",
-			slog.String("cmd", c.cnf.Get("name").String()),
-			slog.String("err", err.Error()),
-		)
+	var err error
+	if c.afterResultAction != nil {
+		err = c.afterResultAction(sess, result)
+	} else {
+		err = c.afterSuccessAction(sess)
+	}
+	if err != nil {
 		return err
 	}
-
 	// dereference after success action
 	c.afterSuccessAction = nil
+	c.afterResultAction = nil
 	return nil
 }
 
-func (c *Cmd) ExecAfterAlways(sess *session.Context, err error) error {
+// ExecAfterAlways calls AfterAlways action of the command and then
+// AfterAlways actions of parent commands which shared Before action
+// with the command, nearest parent first, so that After actions are
+// called in reverse order of Before actions. All actions are called,
+// errors are joined.
+func (c *Cmd) ExecAfterAlways(sess *session.Context, prevErr error) (err error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-
-	if c.afterAlwaysAction == nil {
+	return c.execAfter(sess, "after always action", func(cmd *Cmd) error {
+		if cmd.afterAlwaysAction == nil {
+			return nil
+		}
+		if err := cmd.afterAlwaysAction(sess, prevErr); err != nil {
+			return err
+		}
+		// dereference after always action
+		cmd.afterAlwaysAction = nil
 		return nil
-	}
+	})
+}
 
-	if err := c.afterAlwaysAction(sess, err); err != nil {
-		sess.Log().Debug("after always action",
-			slog.String("cmd", c.cnf.Get("name").String()),
-			slog.String("err", err.Error()),
-		)
-		return err
+// execAfter calls fn for command and shared parents in reverse order
+// of their Before actions.
+func (c *Cmd) execAfter(sess *session.Context, name string, fn func(cmd *Cmd) error) error {
+	cmds := []*Cmd{c}
+	for i := len(c.shared) - 1; i >= 0; i-- {
+		cmds = append(cmds, c.shared[i])
 	}
-
-	// dereference after always action
-	c.afterAlwaysAction = nil
-	return nil
+	var errs []error
+	for _, cmd := range cmds {
+		if err := action.Try(func() error { return fn(cmd) }); err != nil {
+			sess.Log().Debug(name,
+				slog.String("cmd", cmd.cnf.Get("name").String()),
+				slog.String("err", err.Error()),
+			)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
-func (c *Cmd) callSharedBeforeAction(sess *session.Context) error {
+// callSharedBeforeAction calls shared Before actions of parent commands
+// starting from root and appends commands which share actions to shared.
+func (c *Cmd) callSharedBeforeAction(sess *session.Context, shared *[]*Cmd) (err error) {
+	defer action.Recover(&err)
 	if c.parent != nil {
-		if err := c.parent.callSharedBeforeAction(sess); err != nil {
+		if err := c.parent.callSharedBeforeAction(sess, shared); err != nil {
 			return err
 		}
 		// dereference parent
 		c.parent = nil
 	}
-	if c.beforeAction == nil {
+	if !c.cnf.Get("shared_before_action").Value().Bool() {
 		return nil
 	}
-	if c.cnf.Get("shared_before_action").Value().Bool() {
-		c.sharedCalled = true
+	c.sharedCalled = true
+	if c.beforeAction != nil {
 		if err := c.beforeAction(sess, action.NewArgs(c.flags)); err != nil {
 			sess.Log().Debug("shared before action",
 				slog.String("cmd", c.cnf.Get("name").String()),
@@ -367,6 +575,7 @@ func (c *Cmd) callSharedBeforeAction(sess *session.Context) error {
 		// dereference before action
 		c.beforeAction = nil
 	}
+	*shared = append(*shared, c)
 	return nil
 }
 
@@ -374,32 +583,69 @@ func (c *Cmd) SkipSharedBeforeAction() bool {
 	return c.cnf.Get("skip_shared_before").Value().Bool()
 }
 
+// HasBefore reports whether command or its parents may have Before action.
 func (c *Cmd) HasBefore() bool {
-	return c.beforeAction != nil
+	return c.beforeAction != nil || c.parent != nil
 }
 
 func (c *Cmd) getArgs() (action.Args, error) {
-	args := action.NewArgs(c.flags)
+	passthrough := c.passthrough
+	switch c.cnf.Get("flag_parsing").String() {
+	case FlagParsingStrict:
+		for _, arg := range c.flags.Args() {
+			if isFlag(arg.String()) {
+				return nil, c.usageError(fmt.Errorf("%w: %s: unknown flag %s%s", ErrFlags, c.cnf.Get("name").String(), arg.String(), c.didYouMean(arg.String())))
+			}
+		}
+	case FlagParsingPassthrough:
+		passthrough = nil
+		for _, arg := range c.flags.Args() {
+			passthrough = append(passthrough, arg.String())
+		}
+		passthrough = append(passthrough, c.passthrough...)
+	}
+
+	args := action.NewArgs(c.flags, passthrough...)
 	argnmin := c.cnf.Get("min_args").Value().Uint()
 	argnmax := c.cnf.Get("max_args").Value().Uint()
 	name := c.cnf.Get("name").String()
 
 	if argnmin == 0 && argnmax == 0 && args.Argn() > 0 {
-		return args, fmt.Errorf("%w: %s does not accept arguments", Error, name)
+		return args, c.usageError(fmt.Errorf("%w: %s does not accept arguments%s", Error, name, c.didYouMean(args.Arg(0).String())))
 	}
 
 	if args.Argn() < argnmin {
 		if err := c.cnf.Get("min_args_err").Value(); !err.Empty() {
-			return args, errors.New(err.String())
+			return args, c.usageError(errors.New(err.String()))
 		}
-		return args, fmt.Errorf("%w: %s: requires min %d arguments, %d provided", Error, name, argnmin, args.Argn())
+		return args, c.usageError(fmt.Errorf("%w: %s: requires min %d arguments, %d provided", Error, name, argnmin, args.Argn()))
 	}
 	if args.Argn() > argnmax {
 		if err := c.cnf.Get("max_args_err").Value(); !err.Empty() {
-			return args, errors.New(err.String())
+			return args, c.usageError(errors.New(err.String()))
 		}
-		return args, fmt.Errorf("%w: %s: accepts max %d arguments, %d provided, extra %v", Error, name, argnmax, args.Argn(), args.Args()[argnmax:args.Argn()])
+		return args, c.usageError(fmt.Errorf("%w: %s: accepts max %d arguments, %d provided, extra %v", Error, name, argnmax, args.Argn(), args.Args()[argnmax:args.Argn()]))
 	}
 
 	return args, nil
 }
+
+// splitPassthrough splits args at first "--", arguments after it
+// are not parsed as flags.
+func splitPassthrough(args []string) ([]string, []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+// isFlag reports whether arg looks like flag, negative numbers are not flags.
+func isFlag(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' {
+		return false
+	}
+	c := arg[1]
+	return c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+func TestSplitPassthrough(t *testing.T) {
+	tests := []struct {
+		args        string
+		want        string
+		passthrough string
+	}{
+		{"", "", ""},
+		{"app --v", "app --v", ""},
+		{"app -- --v", "app", "--v"},
+		{"app --", "app", ""},
+		{"app a -- b -- c", "app a", "b -- c"},
+		{"-- a", "", "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			args, passthrough := splitPassthrough(strings.Fields(tt.args))
+			testutils.Equal(t, tt.want, strings.Join(args, " "), "args")
+			testutils.Equal(t, tt.passthrough, strings.Join(passthrough, " "), "passthrough")
+		})
+	}
+}
+
+func TestIsFlag(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want bool
+	}{
+		{"-v", true},
+		{"--verbose", true},
+		{"-V", true},
+		{"-", false},
+		{"-1", false},
+		{"-0.5", false},
+		{"value", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		testutils.Equal(t, tt.want, isFlag(tt.arg), tt.arg)
+	}
+}
+
+func TestGetArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		parsing     string
+		maxArgs     uint
+		args        string
+		want        string
+		passthrough string
+		wantErr     bool
+	}{
+		{
+			name:    "relaxed",
+			parsing: FlagParsingRelaxed,
+			maxArgs: 2,
+			args:    "--out x a",
+			want:    "a",
+		},
+		{
+			name:        "relaxed after split",
+			parsing:     FlagParsingRelaxed,
+			maxArgs:     1,
+			args:        "a -- --out b",
+			want:        "a",
+			passthrough: "--out b",
+		},
+		{
+			name:    "relaxed too many args",
+			parsing: FlagParsingRelaxed,
+			maxArgs: 1,
+			args:    "a b",
+			wantErr: true,
+		},
+		{
+			name:    "relaxed no args accepted",
+			parsing: FlagParsingRelaxed,
+			args:    "a",
+			wantErr: true,
+		},
+		{
+			name:    "strict",
+			parsing: FlagParsingStrict,
+			maxArgs: 1,
+			args:    "--out x -1",
+			want:    "-1",
+		},
+		{
+			name:    "strict unknown flag",
+			parsing: FlagParsingStrict,
+			maxArgs: 2,
+			args:    "a --unknown",
+			wantErr: true,
+		},
+		{
+			name:        "strict unknown flag after split",
+			parsing:     FlagParsingStrict,
+			maxArgs:     1,
+			args:        "a -- --unknown",
+			want:        "a",
+			passthrough: "--unknown",
+		},
+		{
+			name:        "passthrough",
+			parsing:     FlagParsingPassthrough,
+			maxArgs:     3,
+			args:        "a --out x b -- c",
+			want:        "a b",
+			passthrough: "a b c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New(Config{
+				Name:        "test",
+				MaxArgs:     settings.Uint(tt.maxArgs),
+				FlagParsing: settings.String(tt.parsing),
+			}).WithFlags(varflag.StringFunc("out", "", "output"))
+			cmd.Do(func(*session.Context, action.Args) error { return nil })
+			// own flags are added only to subcommands
+			New(Config{Name: "app"}).WithSubCommands(cmd)
+
+			inv, err := cmd.invocation(strings.Fields(tt.args))
+			if tt.wantErr && err != nil {
+				testutils.ErrorIs(t, err, ErrInvoke)
+				return
+			}
+			if !testutils.NoError(t, err) {
+				return
+			}
+
+			args, err := inv.getArgs()
+			if tt.wantErr {
+				testutils.Error(t, err)
+				return
+			}
+			testutils.NoError(t, err)
+			var got []string
+			for _, arg := range args.Args() {
+				got = append(got, arg.String())
+			}
+			testutils.Equal(t, tt.want, strings.Join(got, " "), "args")
+			testutils.Equal(t, tt.passthrough, strings.Join(args.Passthrough(), " "), "passthrough")
+		})
+	}
+}
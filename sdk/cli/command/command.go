@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
 	"strings"
 	"sync"
 
@@ -21,6 +22,8 @@ var (
 	Error          = errors.New("command")
 	ErrFlags       = errors.New("command flags error")
 	ErrHasNoParent = errors.New("command has no parent command")
+	ErrInvoke      = errors.New("command invocation failed")
+	ErrDisabled    = errors.New("command disabled")
 )
 
 type Config struct {
@@ -35,15 +38,53 @@ type Config struct {
 	// MaxArgs Maximum argument count for command
 	MaxArgs    settings.Uint `key:"max_args" default:"0" mutation:"once"`
 	MaxArgsErr settings.String
-	// SharedBeforeAction share Before action for all its subcommands
+	// SharedBeforeAction share Before action for all its subcommands.
+	// AfterSuccess, AfterFailure and AfterAlways actions are shared as
+	// well, so they are called also when subcommand is executed, after
+	// After actions of the subcommand and nearest parent first. When
+	// AfterSuccess action fails, AfterFailure actions of remaining
+	// parents are called with its error instead of AfterSuccess.
 	SharedBeforeAction settings.Bool `key:"shared_before_action" default:"false"`
 	// Indicates that the command should be executed immediately, without waiting for the full runtime setup.
 	Immediate settings.Bool `key:"immediate" default:"false"`
 	// SkipSharedBefore indicates that the BeforeAlways any shared before actions provided
 	// by parent commands should be skipped.
 	SkipSharedBefore settings.Bool `key:"skip_shared_before" default:"false"`
+	// Env environment variables set for the duration of command execution
+	// and restored afterwards. Entry "KEY=value" sets and "KEY" clears variable.
+	// Env of parent commands is applied before command's own Env.
+	Env settings.StringSlice `key:"env" mutation:"once"`
+	// FlagParsing mode, "relaxed" passes unknown flags to arguments,
+	// "strict" returns error on unknown flags and "passthrough" exposes
+	// all arguments and unknown flags in original order via
+	// action.Args.Passthrough. Arguments after "--" are never parsed as
+	// flags and are always available via action.Args.Passthrough.
+	FlagParsing settings.String `key:"flag_parsing" default:"relaxed" mutation:"once"`
+	// RequiresServices are services started before Do action of the
+	// command, entries are service selectors matching service names, slugs
+	// or addresses e.g. "db-*", "cache?optional" or "?all".
+	// Services are loaded concurrently with Before actions, when either
+	// fails started services are stopped and After actions receive
+	// *action.GroupError. Services are stopped after Do unless
+	// app.services.keep_required is true.
+	RequiresServices settings.StringSlice `key:"requires_services" mutation:"once"`
+	// Locks are names of shared resources command uses e.g. "db". Locks
+	// are acquired before Before action and released on exit, so that
+	// other invocations of the application using same resources wait
+	// or fail, see --wait-lock flag.
+	Locks settings.StringSlice `key:"locks" mutation:"once"`
+	// ExposeMCP exposes command as tool to MCP clients e.g. AI
+	// assistants connected to mcp addon. Commands are not exposed by
+	// default, expose only commands which are safe to run unattended.
+	ExposeMCP settings.Bool `key:"expose_mcp" default:"false" mutation:"once"`
 }
 
+const (
+	FlagParsingRelaxed     = "relaxed"
+	FlagParsingStrict      = "strict"
+	FlagParsingPassthrough = "passthrough"
+)
+
 func (s Config) Blueprint() (*settings.Blueprint, error) {
 
 	b, err := settings.New(s)
@@ -61,12 +102,24 @@ type Command struct {
 	usage []string
 
 	flags       varflag.Flags
+	flagFuncs   []varflag.FlagCreateFunc
 	parent      *Command
 	subCommands map[string]*Command
 
+	// lazymu guards subCommands and lazy once command is verified.
+	lazymu sync.Mutex
+	// lazy are factories of subcommands added with WithSubCommandFunc
+	// which are not materialized yet.
+	lazy     map[string]func() *Command
+	verified bool
+	// disabled is reason command was disabled with Disable.
+	disabled string
+
 	beforeAction       action.WithArgs
 	doAction           action.WithArgs
+	doResultAction     action.WithArgsResult
 	afterSuccessAction action.Action
+	afterResultAction  action.WithResult
 	afterFailureAction action.WithPrevErr
 	afterAlwaysAction  action.WithPrevErr
 
@@ -74,7 +127,8 @@ type Command struct {
 
 	parents []string
 
-	catdesc map[string]string
+	catdesc   map[string]string
+	catweight map[string]int
 
 	logName string
 	err     error
@@ -86,8 +140,9 @@ type Command struct {
 
 func New(s Config) *Command {
 	c := &Command{
-		catdesc: make(map[string]string),
-		cnflog:  logging.NewQueueLogger(),
+		catdesc:   make(map[string]string),
+		catweight: make(map[string]int),
+		cnflog:    logging.NewQueueLogger(),
 	}
 	if err := c.configure(&s); err != nil {
 		c.err = fmt.Errorf("%w: %s", Error, err.Error())
@@ -135,7 +190,7 @@ func (c *Command) AfterSuccess(a action.Action) *Command {
 		return c
 	}
 	defer c.mu.Unlock()
-	if c.afterSuccessAction != nil {
+	if c.afterSuccessAction != nil || c.afterResultAction != nil {
 		c.error(fmt.Errorf("%w: attempt to override AfterSuccess action for %s", Error, c.cnf.Get("name").String()))
 		return c
 	}
@@ -143,6 +198,21 @@ func (c *Command) AfterSuccess(a action.Action) *Command {
 	return c
 }
 
+// AfterSuccessWithResult sets AfterSuccess action which receives
+// Result returned by DoWithResult action.
+func (c *Command) AfterSuccessWithResult(a action.WithResult) *Command {
+	if !c.tryLock("AfterSuccessWithResult") {
+		return c
+	}
+	defer c.mu.Unlock()
+	if c.afterSuccessAction != nil || c.afterResultAction != nil {
+		c.error(fmt.Errorf("%w: attempt to override AfterSuccess action for %s", Error, c.cnf.Get("name").String()))
+		return c
+	}
+	c.afterResultAction = a
+	return c
+}
+
 func (c *Command) Before(a action.WithArgs) *Command {
 	if !c.tryLock("Before") {
 		return c
@@ -166,19 +236,47 @@ func (c *Command) DescribeCategory(cat, desc string) *Command {
 	return c
 }
 
+// WithCategory sets weight of the category used to order categories
+// in help output. Categories with lower weight are listed first,
+// categories with equal weight are ordered alphabetically.
+func (c *Command) WithCategory(cat string, weight int) *Command {
+	if !c.tryLock("WithCategory") {
+		return c
+	}
+	defer c.mu.Unlock()
+	c.catweight[strings.ToLower(cat)] = weight
+	return c
+}
+
 func (c *Command) Do(action action.WithArgs) *Command {
 	if !c.tryLock("Do") {
 		return c
 	}
 	defer c.mu.Unlock()
-	if c.doAction != nil {
-		c.err = fmt.Errorf("%w: attempt to override Before action for %s", Error, c.cnf.Get("name").String())
+	if c.doAction != nil || c.doResultAction != nil {
+		c.err = fmt.Errorf("%w: attempt to override Do action for %s", Error, c.cnf.Get("name").String())
 		return c
 	}
 	c.doAction = action
 	return c
 }
 
+// DoWithResult sets Do action which returns Result. Result is passed to
+// AfterSuccessWithResult action and written to stdout as JSON when
+// --output=json flag is set.
+func (c *Command) DoWithResult(a action.WithArgsResult) *Command {
+	if !c.tryLock("DoWithResult") {
+		return c
+	}
+	defer c.mu.Unlock()
+	if c.doAction != nil || c.doResultAction != nil {
+		c.err = fmt.Errorf("%w: attempt to override Do action for %s", Error, c.cnf.Get("name").String())
+		return c
+	}
+	c.doResultAction = a
+	return c
+}
+
 func (c *Command) WithFlags(ffns ...varflag.FlagCreateFunc) *Command {
 	for _, fn := range ffns {
 		c.withFlag(fn)
@@ -200,7 +298,10 @@ func (c *Command) withFlag(ffn varflag.FlagCreateFunc) *Command {
 
 	if err := c.flags.Add(f); err != nil {
 		c.error(fmt.Errorf("%w: %s", ErrFlags, err.Error()))
+		return c
 	}
+	// keep create func so that fresh flags can be created for invocations
+	c.flagFuncs = append(c.flagFuncs, ffn)
 	return c
 }
 
@@ -230,26 +331,189 @@ func (c *Command) withSubCommand(cmd *Command) *Command {
 		return c
 	}
 
-	if cmd.err != nil {
-		c.error(cmd.err)
+	c.lazymu.Lock()
+	defer c.lazymu.Unlock()
+	if err := c.attach(cmd); err != nil {
+		c.error(err)
+	}
+	return c
+}
+
+// WithSubCommandFunc adds subcommand which is created by fn only when
+// it is needed, that is when it is named in command line arguments,
+// invoked, or listed in help. It keeps startup of CLIs with large
+// number of subcommands fast, since commands which are not used are
+// never constructed. Command returned by fn must have given name.
+func (c *Command) WithSubCommandFunc(name string, fn func() *Command) *Command {
+	if !c.tryLock("WithSubCommandFunc") {
 		return c
 	}
+	defer c.mu.Unlock()
+	if fn == nil {
+		return c
+	}
+
+	c.lazymu.Lock()
+	defer c.lazymu.Unlock()
+	if _, exists := c.subCommands[name]; exists {
+		c.error(fmt.Errorf("%w: subcommand %s already attached to %s", Error, name, c.logName))
+		return c
+	}
+	if c.lazy == nil {
+		c.lazy = make(map[string]func() *Command)
+	}
+	c.lazy[name] = fn
+	return c
+}
+
+// Disable disables subcommand at space separated path of command names
+// e.g. "config set". Disabled command and its subcommands are omitted
+// from help and fail with ErrDisabled when they are used. It reports
+// false when there is no such subcommand.
+func (c *Command) Disable(path, reason string) bool {
+	cmd, err := c.find(path)
+	if err != nil {
+		return false
+	}
+	// disabled is guarded by lazymu of parent along with subCommands.
+	cmd.parent.lazymu.Lock()
+	defer cmd.parent.lazymu.Unlock()
+	cmd.disabled = reason
+	return true
+}
+
+// disabledErr returns error when c or any of its parents is disabled.
+func (c *Command) disabledErr() error {
+	for cmd := c; cmd.parent != nil; cmd = cmd.parent {
+		cmd.parent.lazymu.Lock()
+		reason := cmd.disabled
+		cmd.parent.lazymu.Unlock()
+		if reason != "" {
+			return fmt.Errorf("%w: %s: %s", ErrDisabled, c.logName, reason)
+		}
+	}
+	return nil
+}
+
+// attach attaches cmd as subcommand, caller must hold lazymu. Command
+// attached after c was verified is verified as well.
+func (c *Command) attach(cmd *Command) error {
+	if cmd.err != nil {
+		return cmd.err
+	}
+	name := cmd.cnf.Get("name").String()
+	if _, exists := c.lazy[name]; exists {
+		return fmt.Errorf("%w: subcommand %s already attached to %s", Error, name, c.logName)
+	}
 	if c.subCommands == nil {
 		c.subCommands = make(map[string]*Command)
 	}
 	if err := c.flags.AddSet(cmd.flags); err != nil {
-		c.error(fmt.Errorf(
+		return fmt.Errorf(
 			"%w: failed to attach subcommand %s flags to %s",
 			Error,
-			cmd.cnf.Get("name").String(),
+			name,
 			c.cnf.Get("name").String(),
-		))
-		return c
+		)
 	}
 	cmd.parent = c
 
-	c.subCommands[cmd.cnf.Get("name").String()] = cmd
-	return c
+	c.subCommands[name] = cmd
+	if !c.verified {
+		return nil
+	}
+	if err := c.cnflog.ConsumeQueue(cmd.cnflog); err != nil {
+		return err
+	}
+	cmd.parents = append(c.parents[:len(c.parents):len(c.parents)], c.cnf.Get("name").String())
+	return cmd.verify()
+}
+
+// materialize returns subcommand with given name creating it when it
+// was added with WithSubCommandFunc. It returns nil when c has no such
+// subcommand.
+func (c *Command) materialize(name string) (*Command, error) {
+	c.lazymu.Lock()
+	defer c.lazymu.Unlock()
+	if cmd, exists := c.subCommands[name]; exists {
+		return cmd, nil
+	}
+	fn, exists := c.lazy[name]
+	if !exists {
+		return nil, nil
+	}
+	delete(c.lazy, name)
+	cmd := fn()
+	if cmd == nil {
+		return nil, fmt.Errorf("%w: subcommand %s of %s is nil", Error, name, c.logName)
+	}
+	if got := cmd.cnf.Get("name").String(); got != name && cmd.err == nil {
+		return nil, fmt.Errorf("%w: subcommand %s of %s created with name %s", Error, name, c.logName, got)
+	}
+	if err := c.attach(cmd); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// subCommandList returns all subcommands in order of their names,
+// materializing the lazy ones. Subcommands failing to materialize are
+// omitted.
+func (c *Command) subCommandList() []*Command {
+	for _, name := range c.subCommandNames() {
+		_, _ = c.materialize(name)
+	}
+	c.lazymu.Lock()
+	defer c.lazymu.Unlock()
+	names := make([]string, 0, len(c.subCommands))
+	for name, cmd := range c.subCommands {
+		if cmd.disabled == "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	cmds := make([]*Command, 0, len(names))
+	for _, name := range names {
+		cmds = append(cmds, c.subCommands[name])
+	}
+	return cmds
+}
+
+// subCommandNames returns names of all subcommands without
+// materializing the lazy ones.
+func (c *Command) subCommandNames() []string {
+	c.lazymu.Lock()
+	defer c.lazymu.Unlock()
+	names := make([]string, 0, len(c.subCommands)+len(c.lazy))
+	for name, cmd := range c.subCommands {
+		if cmd.disabled == "" {
+			names = append(names, name)
+		}
+	}
+	for name := range c.lazy {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// expand materializes lazy subcommands named in command line args so
+// that their flags are known when args are parsed.
+func (c *Command) expand(args []string) error {
+	cmd := c
+	for i, arg := range args {
+		if i == 0 || isFlag(arg) {
+			continue
+		}
+		scmd, err := cmd.materialize(arg)
+		if err != nil {
+			return err
+		}
+		if scmd != nil {
+			cmd = scmd
+		}
+	}
+	return nil
 }
 
 func (c *Command) Err() error {
@@ -310,6 +574,12 @@ func (c *Command) configure(s *Config) error {
 		return err
 	}
 
+	switch mode := c.cnf.Get("flag_parsing").String(); mode {
+	case FlagParsingRelaxed, FlagParsingStrict, FlagParsingPassthrough:
+	default:
+		return fmt.Errorf("%w: invalid flag parsing mode %q", ErrFlags, mode)
+	}
+
 	if minargs := c.cnf.Get("min_args").Value().Int(); minargs > c.cnf.Get("max_args").Value().Int() {
 		if err := c.cnf.Set("max_args", minargs); err != nil {
 			return err
@@ -341,7 +611,8 @@ func (c *Command) verify() error {
 	if c.flags.Len() > 0 {
 		usage = append(usage, "[flags]")
 	}
-	if c.subCommands != nil {
+	hasSubCommands := len(c.subCommands) > 0 || len(c.lazy) > 0
+	if hasSubCommands {
 		usage = append(usage, "[subcommand]")
 	}
 	c.usage = append(c.usage, strings.Join(usage, " "))
@@ -386,12 +657,12 @@ func (c *Command) verify() error {
 		return c.err
 	}
 
-	if c.doAction == nil {
+	if c.doAction == nil && c.doResultAction == nil {
 		if !c.isWrapperCommand {
-			c.isWrapperCommand = len(c.subCommands) > 0
+			c.isWrapperCommand = hasSubCommands
 		}
 
-		if c.subCommands != nil {
+		if hasSubCommands {
 			goto SubCommands
 		} else {
 			return fmt.Errorf("%w: command (%s) must have Do action or atleeast one subcommand", Error, name)
@@ -411,6 +682,9 @@ SubCommands:
 			}
 		}
 	}
+	c.lazymu.Lock()
+	c.verified = true
+	c.lazymu.Unlock()
 	return nil
 }
 
@@ -423,25 +697,32 @@ func (c *Command) Usage(usage string) {
 }
 
 func (c *Command) getActiveCommand() (*Command, error) {
+	acmd := c.activeCommand()
+	args := acmd.flags.Args()
+	if !acmd.flags.AcceptsArgs() && len(args) > 0 {
+		arg := args[0].String()
+		return nil, acmd.usageError(fmt.Errorf("%w: unknown subcommand: %s for %s%s", Error, arg, acmd.logName, acmd.didYouMean(arg)))
+	}
+	return acmd, nil
+}
+
+// activeCommand returns deepest command present in parsed flags.
+func (c *Command) activeCommand() *Command {
 	subtree := c.flags.GetActiveSets()
 
 	// Skip self
 	for _, subset := range subtree {
 		cmd, exists := c.getSubCommand(subset.Name())
 		if exists {
-			return cmd.getActiveCommand()
+			return cmd.activeCommand()
 		}
 	}
-
-	args := c.flags.Args()
-	if !c.flags.AcceptsArgs() && len(args) > 0 {
-		return nil, fmt.Errorf("%w: unknown subcommand: %s for %s", Error, args[0].String(), c.logName)
-	}
-
-	return c, nil
+	return c
 }
 
 func (c *Command) getSubCommand(name string) (cmd *Command, exists bool) {
+	c.lazymu.Lock()
+	defer c.lazymu.Unlock()
 	if cmd, exists := c.subCommands[name]; exists {
 		return cmd, exists
 	}
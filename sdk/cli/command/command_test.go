@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package command
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+// lazyTree returns root command with lazy subcommands "gen", "other"
+// and "other sub", created records names of materialized commands.
+func lazyTree(created *[]string) *Command {
+	do := func(sess *session.Context, args action.Args) error { return nil }
+	lazy := func(name string, fn func(cmd *Command)) func() *Command {
+		return func() *Command {
+			*created = append(*created, name)
+			cmd := New(Config{Name: settings.String(name), Description: settings.String(name + " command")})
+			fn(cmd)
+			return cmd
+		}
+	}
+	root := New(Config{Name: "app"}).Do(do)
+	root.WithSubCommands(New(Config{Name: "eager"}).Do(do))
+	root.WithSubCommandFunc("gen", lazy("gen", func(cmd *Command) {
+		cmd.WithFlags(varflag.StringFunc("out", "", "output"))
+		cmd.Do(do)
+	}))
+	root.WithSubCommandFunc("other", lazy("other", func(cmd *Command) {
+		cmd.WithSubCommandFunc("sub", lazy("sub", func(cmd *Command) {
+			cmd.Do(do)
+		}))
+	}))
+	return root
+}
+
+func TestLazySubCommands(t *testing.T) {
+	tests := []struct {
+		args        string
+		wantActive  string
+		wantPath    string
+		wantCreated string
+		wantOut     string
+	}{
+		{args: "app", wantActive: "app", wantPath: "app"},
+		{args: "app eager", wantActive: "eager", wantPath: "app eager"},
+		{args: "app gen --out file", wantActive: "gen", wantPath: "app gen", wantCreated: "gen", wantOut: "file"},
+		{args: "app other sub", wantActive: "sub", wantPath: "app other sub", wantCreated: "other,sub"},
+		{args: "app --debug other", wantActive: "other", wantPath: "app other", wantCreated: "other"},
+	}
+	defer func(args []string) { os.Args = args }(os.Args)
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			var created []string
+			root := lazyTree(&created)
+			root.WithFlags(varflag.BoolFunc("debug", false, "debug"))
+			os.Args = strings.Fields(tt.args)
+
+			cmd, _, err := Compile(root)
+			if !testutils.NoError(t, err) {
+				return
+			}
+			testutils.Equal(t, tt.wantActive, cmd.Name())
+			testutils.Equal(t, tt.wantPath, cmd.Path())
+			testutils.Equal(t, tt.wantCreated, strings.Join(created, ","), "created commands")
+			if tt.wantOut != "" {
+				testutils.Equal(t, tt.wantOut, cmd.Flag("out").String())
+			}
+		})
+	}
+}
+
+func TestLazySubCommandsHelp(t *testing.T) {
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"app"}
+
+	var created []string
+	cmd, _, err := Compile(lazyTree(&created))
+	if !testutils.NoError(t, err) {
+		return
+	}
+	testutils.Equal(t, 0, len(created), "commands created before help")
+	fingerprint := cmd.Fingerprint()
+	testutils.Equal(t, 0, len(created), "commands created by fingerprint")
+	testutils.Equal(t, fingerprint, cmd.Fingerprint(), "fingerprint must be stable")
+
+	var names []string
+	for _, scmd := range cmd.SubCommands() {
+		names = append(names, scmd.Name+": "+scmd.Description)
+	}
+	sort.Strings(names)
+	testutils.Equal(t, "eager: ,gen: gen command,other: other command", strings.Join(names, ","))
+	testutils.Equal(t, "gen,other", strings.Join(created, ","), "created commands")
+
+	sub, err := cmd.root.lookup("other sub")
+	if !testutils.NoError(t, err) {
+		return
+	}
+	testutils.Equal(t, "app other sub", sub.path())
+	testutils.Equal(t, "gen,other,sub", strings.Join(created, ","), "created commands")
+}
+
+func TestLazySubCommandsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func() *Command
+	}{
+		{name: "nil", fn: func() *Command { return nil }},
+		{name: "other name", fn: func() *Command { return New(Config{Name: "other"}) }},
+		{name: "invalid", fn: func() *Command { return New(Config{Name: "gen", FlagParsing: "invalid"}) }},
+	}
+	defer func(args []string) { os.Args = args }(os.Args)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := New(Config{Name: "app"}).
+				Do(func(sess *session.Context, args action.Args) error { return nil }).
+				WithSubCommandFunc("gen", tt.fn)
+
+			os.Args = []string{"app", "gen"}
+			_, _, err := Compile(root)
+			testutils.Error(t, err)
+		})
+	}
+}
+
+func TestDisable(t *testing.T) {
+	tests := []struct {
+		args    string
+		wantErr bool
+	}{
+		{args: "app"},
+		{args: "app eager"},
+		{args: "app gen", wantErr: true},
+		{args: "app other sub", wantErr: true},
+	}
+	defer func(args []string) { os.Args = args }(os.Args)
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			var created []string
+			root := lazyTree(&created)
+			testutils.True(t, root.Disable("gen", "disabled by policy"), "gen disabled")
+			testutils.True(t, root.Disable("other", "disabled by policy"), "other disabled")
+			testutils.False(t, root.Disable("missing", "disabled by policy"), "missing disabled")
+
+			os.Args = strings.Fields(tt.args)
+			cmd, _, err := Compile(root)
+			if tt.wantErr {
+				testutils.ErrorIs(t, err, ErrDisabled)
+				return
+			}
+			if !testutils.NoError(t, err) {
+				return
+			}
+			var names []string
+			for _, scmd := range cmd.SubCommands() {
+				names = append(names, scmd.Name)
+			}
+			if tt.args == "app" {
+				testutils.Equal(t, "eager", strings.Join(names, ","), "disabled commands are not listed")
+			}
+			_, err = root.lookup("other sub")
+			testutils.ErrorIs(t, err, ErrDisabled)
+		})
+	}
+}
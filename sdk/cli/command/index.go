@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package command
+
+import (
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/cli/help"
+)
+
+// HelpIndex returns searchable help entries of all commands in the
+// command tree and their flags. Global flags are listed once for root
+// command.
+func (c *Cmd) HelpIndex() []help.Entry {
+	if c.root == nil {
+		return nil
+	}
+	var entries []help.Entry
+	c.root.helpIndex(&entries, make(map[varflag.Flag]bool))
+	return entries
+}
+
+func (c *Command) helpIndex(entries *[]help.Entry, seen map[varflag.Flag]bool) {
+	path := c.path()
+	if c.parent != nil {
+		*entries = append(*entries, help.Entry{
+			Kind:        help.KindCommand,
+			Name:        c.cnf.Get("name").String(),
+			Command:     path,
+			Description: c.cnf.Get("description").String(),
+			Text:        strings.Join(c.info, "\n\n"),
+			Usage:       c.usage,
+			MinArgs:     uint(c.cnf.Get("min_args").Value().Int()),
+			MaxArgs:     uint(c.cnf.Get("max_args").Value().Int()),
+			ExposeMCP:   c.cnf.Get("expose_mcp").Value().Bool(),
+		})
+	}
+	for _, flag := range c.flags.Flags() {
+		if flag.Hidden() || seen[flag] {
+			continue
+		}
+		seen[flag] = true
+		e := help.Entry{
+			Kind:        help.KindFlag,
+			Name:        flag.Flag(),
+			Command:     path,
+			Description: flag.Usage(),
+			FlagType:    flagType(flag),
+			Default:     flag.Default().String(),
+			Required:    flag.Required(),
+		}
+		if opt, ok := flag.(*varflag.OptionFlag); ok {
+			e.Options = opt.Options()
+		}
+		*entries = append(*entries, e)
+	}
+	for _, scmd := range c.subCommandList() {
+		scmd.helpIndex(entries, seen)
+	}
+}
+
+func flagType(flag varflag.Flag) string {
+	switch flag.(type) {
+	case *varflag.BoolFlag:
+		return "bool"
+	case *varflag.IntFlag:
+		return "int"
+	case *varflag.UintFlag:
+		return "uint"
+	case *varflag.Float64Flag:
+		return "float"
+	case *varflag.DurationFlag:
+		return "duration"
+	case *varflag.OptionFlag:
+		return "option"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/internal"
+)
+
+// Invoke runs another command of the same command tree within the session.
+// Path is space separated path of the subcommand e.g. "db migrate" and args
+// are parsed with flags of that command and its parents. Before, Do and
+// After actions of the invoked command are executed, shared before actions
+// of its parents are not since they already ran for the active command.
+// Invoking command which is already running returns ErrInvoke.
+func (c *Cmd) Invoke(sess *session.Context, path string, args ...string) (*action.Result, error) {
+	if c.root == nil {
+		return nil, fmt.Errorf("%w: command tree not available", ErrInvoke)
+	}
+	target, err := c.root.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invmu.Lock()
+	if c.invoking[target] {
+		c.invmu.Unlock()
+		return nil, fmt.Errorf("%w: recursive invocation of %q", ErrInvoke, path)
+	}
+	c.invoking[target] = true
+	c.invmu.Unlock()
+	defer func() {
+		c.invmu.Lock()
+		delete(c.invoking, target)
+		c.invmu.Unlock()
+	}()
+
+	inv, err := target.invocation(args)
+	if err != nil {
+		return nil, err
+	}
+	inv.root = c.root
+
+	internal.Log(sess.Log(), "invoking command",
+		slog.String("cmd", path),
+		slog.String("args", strings.Join(args, " ")))
+
+	err = inv.ExecBefore(sess)
+	if err == nil {
+		err = inv.ExecDo(sess)
+	}
+	if err != nil {
+		if e := inv.ExecAfterFailure(sess, err); e != nil {
+			err = errors.Join(err, e)
+		}
+	} else {
+		err = inv.ExecAfterSuccess(sess)
+	}
+	if e := inv.ExecAfterAlways(sess, err); e != nil && err == nil {
+		err = e
+	}
+	return inv.result, err
+}
+
+// lookup returns enabled subcommand by space separated path.
+func (c *Command) lookup(path string) (*Command, error) {
+	cmd, err := c.find(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.disabledErr(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvoke, err)
+	}
+	return cmd, nil
+}
+
+// find returns subcommand by space separated path.
+func (c *Command) find(path string) (*Command, error) {
+	cmd := c
+	for _, name := range strings.Fields(path) {
+		scmd, err := cmd.materialize(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvoke, err.Error())
+		}
+		if scmd == nil {
+			return nil, fmt.Errorf("%w: unknown command %q", ErrInvoke, path)
+		}
+		cmd = scmd
+	}
+	if cmd == c {
+		return nil, fmt.Errorf("%w: command path is empty", ErrInvoke)
+	}
+	return cmd, nil
+}
+
+// invocation creates executable command with fresh flags parsed from args.
+func (c *Command) invocation(args []string) (*Cmd, error) {
+	name := c.cnf.Get("name").String()
+	if c.doAction == nil && c.doResultAction == nil {
+		return nil, fmt.Errorf("%w: %s has no Do action", ErrInvoke, name)
+	}
+	flags, err := varflag.NewFlagSet(name, c.cnf.Get("max_args").Value().Int())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvoke, err.Error())
+	}
+	// own flags and flags shared by parents, global flags are not accepted
+	for cmd := c; cmd != nil && cmd.parent != nil; cmd = cmd.parent {
+		for _, ffn := range cmd.flagFuncs {
+			f, err := ffn()
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvoke, err.Error())
+			}
+			if err := flags.Add(f); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvoke, err.Error())
+			}
+		}
+	}
+	args, passthrough := splitPassthrough(args)
+	if err := flags.Parse(append([]string{name}, args...)); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvoke, name, err.Error())
+	}
+
+	return &Cmd{
+		passthrough:        passthrough,
+		cnf:                c.cnf,
+		flags:              flags,
+		parents:            c.parents,
+		usage:              c.usage,
+		info:               c.info,
+		beforeAction:       c.beforeAction,
+		doAction:           c.doAction,
+		doResultAction:     c.doResultAction,
+		afterSuccessAction: c.afterSuccessAction,
+		afterResultAction:  c.afterResultAction,
+		afterFailureAction: c.afterFailureAction,
+		afterAlwaysAction:  c.afterAlwaysAction,
+	}, nil
+}
@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package command_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/happy-sdk/happy"
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/logging"
+)
+
+func TestInvoke(t *testing.T) {
+	var greeted string
+
+	greet := command.New(command.Config{Name: "greet"}).
+		WithFlags(varflag.StringFunc("name", "world", "who to greet"))
+	greet.Do(func(sess *session.Context, args action.Args) error {
+		greeted = args.Flag("name").String()
+		return nil
+	})
+
+	// loop invokes itself
+	loop := command.New(command.Config{Name: "loop"})
+	loop.Do(func(sess *session.Context, args action.Args) error {
+		return sess.InvokeCommand("loop")
+	})
+
+	// ping and pong invoke each other
+	ping := command.New(command.Config{Name: "ping"})
+	ping.Do(func(sess *session.Context, args action.Args) error {
+		return sess.InvokeCommand("pong")
+	})
+	pong := command.New(command.Config{Name: "pong"})
+	pong.Do(func(sess *session.Context, args action.Args) error {
+		return sess.InvokeCommand("ping")
+	})
+
+	// outer invokes greet twice, sequential invocations are not recursive
+	outer := command.New(command.Config{Name: "outer"})
+	outer.Do(func(sess *session.Context, args action.Args) error {
+		if err := sess.InvokeCommand("greet", "--name", "first"); err != nil {
+			return err
+		}
+		return sess.InvokeCommand("greet", "--name", "second")
+	})
+
+	db := command.New(command.Config{Name: "db"})
+	migrate := command.New(command.Config{Name: "migrate"})
+	migrate.Do(func(sess *session.Context, args action.Args) error {
+		greeted = "migrated"
+		return nil
+	})
+	db.WithSubCommands(migrate)
+
+	tests := []struct {
+		name    string
+		path    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "default flag", path: "greet", want: "world"},
+		{name: "flag", path: "greet", args: []string{"--name", "happy"}, want: "happy"},
+		{name: "subcommand path", path: "db migrate", want: "migrated"},
+		{name: "sequential invocations", path: "outer", want: "second"},
+		{name: "self recursion", path: "loop", wantErr: true},
+		{name: "mutual recursion", path: "ping", wantErr: true},
+		{name: "unknown command", path: "missing", wantErr: true},
+		{name: "empty path", path: " ", wantErr: true},
+		{name: "no do action", path: "db", wantErr: true},
+		{name: "unknown flag", path: "greet", args: []string{"--unknown"}, wantErr: true},
+		// commands remain invocable after failed invocations
+		{name: "after failures", path: "greet", want: "world"},
+	}
+
+	results := make([]error, len(tests))
+	values := make([]string, len(tests))
+
+	main := app.New(happy.Settings{Slug: "happy-invoke-test"})
+	main.WithLogger(logging.NewTestLogger(logging.LevelError))
+	main.WithCommands(greet, loop, ping, pong, outer, db)
+	main.Do(func(sess *session.Context, args action.Args) error {
+		for i, tt := range tests {
+			greeted = ""
+			results[i] = sess.InvokeCommand(tt.path, tt.args...)
+			values[i] = greeted
+		}
+		return nil
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr {
+				testutils.ErrorIs(t, results[i], command.ErrInvoke)
+				return
+			}
+			testutils.NoError(t, results[i])
+			testutils.Equal(t, tt.want, values[i])
+		})
+	}
+}
+
+func TestInvokeSharedAfterActions(t *testing.T) {
+	var calls []string
+	record := func(name string) {
+		calls = append(calls, name)
+	}
+	errAfter := errors.New("after success")
+
+	build := func(failSub bool) *command.Command {
+		parent := command.New(command.Config{Name: "svc", SharedBeforeAction: true})
+		parent.Before(func(sess *session.Context, args action.Args) error {
+			record("svc before")
+			return nil
+		})
+		parent.AfterSuccess(func(sess *session.Context) error {
+			record("svc after success")
+			return nil
+		})
+		parent.AfterFailure(func(sess *session.Context, err error) error {
+			record("svc after failure: " + err.Error())
+			return nil
+		})
+		parent.AfterAlways(func(sess *session.Context, err error) error {
+			record("svc after always")
+			return nil
+		})
+		sub := command.New(command.Config{Name: "run"})
+		sub.Do(func(sess *session.Context, args action.Args) error {
+			record("run do")
+			return nil
+		})
+		sub.AfterSuccess(func(sess *session.Context) error {
+			record("run after success")
+			if failSub {
+				return errAfter
+			}
+			return nil
+		})
+		parent.WithSubCommands(sub)
+		return parent
+	}
+
+	tests := []struct {
+		name    string
+		failSub bool
+		want    []string
+	}{
+		{
+			name: "success",
+			want: []string{"svc before", "run do", "run after success", "svc after success", "svc after always"},
+		},
+		{
+			// parent gets AfterFailure once AfterSuccess of subcommand failed.
+			name:    "after success fails",
+			failSub: true,
+			want:    []string{"svc before", "run do", "run after success", "svc after failure: after success", "svc after always"},
+		},
+	}
+	defer func(args []string) { os.Args = args }(os.Args)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			os.Args = []string{"happy-after-test", "svc", "run"}
+			main := app.New(happy.Settings{Slug: "happy-after-test"})
+			main.WithLogger(logging.NewTestLogger(logging.LevelQuiet))
+			main.WithCommands(build(tt.failSub))
+			err := main.RunCtx(context.Background())
+			if tt.failSub {
+				testutils.Error(t, err)
+			} else {
+				testutils.NoError(t, err)
+			}
+			testutils.Equal(t, strings.Join(tt.want, "\n"), strings.Join(calls, "\n"))
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+)
+
+// UsageError is returned when command line arguments or flags do not
+// match command definition. Application prints help or usage of the
+// command together with the error instead of just failing.
+type UsageError struct {
+	// Command is full command path e.g. "app sub".
+	Command string
+	// Usage lines of the command.
+	Usage []string
+	// Cmd is compiled command, nil when error occurred while compiling.
+	Cmd *Cmd
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// AsUsageError reports whether err is or wraps UsageError.
+func AsUsageError(err error) (*UsageError, bool) {
+	var uerr *UsageError
+	if errors.As(err, &uerr) {
+		return uerr, true
+	}
+	return nil, false
+}
+
+func (c *Command) usageError(err error) error {
+	return &UsageError{
+		Command: c.path(),
+		Usage:   c.usage,
+		Err:     err,
+	}
+}
+
+// path returns full command path e.g. "app sub".
+func (c *Command) path() string {
+	return strings.Join(append(append([]string{}, c.parents...), c.cnf.Get("name").String()), " ")
+}
+
+func (c *Cmd) usageError(err error) error {
+	return &UsageError{
+		Command: c.Path(),
+		Usage:   c.usage,
+		Cmd:     c,
+		Err:     err,
+	}
+}
+
+// didYouMean returns hint naming flag of c, its parents or subcommand
+// of c closest to mistyped arg.
+func (c *Command) didYouMean(arg string) string {
+	var flags []varflag.Flag
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		flags = append(flags, cmd.flags.Flags()...)
+	}
+	return didYouMean(arg, flags, c.subCommandNames())
+}
+
+func (c *Cmd) didYouMean(arg string) string {
+	var subcmds []string
+	if c.active != nil {
+		subcmds = c.active.subCommandNames()
+	}
+	return didYouMean(arg, c.flags.Flags(), subcmds)
+}
+
+// didYouMean returns ", did you mean 'x'?" when arg looks like typo of
+// one of the flags or subcommands and empty string otherwise.
+func didYouMean(arg string, flags []varflag.Flag, subcmds []string) string {
+	var candidates []string
+	if isFlag(arg) {
+		arg, _, _ = strings.Cut(arg, "=")
+		for _, flag := range flags {
+			if !flag.Hidden() {
+				candidates = append(candidates, flag.Flag())
+			}
+		}
+	} else {
+		candidates = subcmds
+	}
+	if s := textfmt.Suggest(arg, candidates); s != "" {
+		return fmt.Sprintf(", did you mean '%s'?", s)
+	}
+	return ""
+}
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/backup"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+)
+
+// Export returns command which writes profile, state store and
+// selected data directory files to archive, see package backup.
+func Export() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "export",
+		Category:         "Configuration",
+		Description:      "Export application data to archive",
+		MinArgs:          1,
+		MaxArgs:          1,
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.Usage("[flags] <file>")
+	cmd.AddInfo("Archive holds files of the current settings profile and content of the state store. Files of the data directory are included with --data. Set --passphrase-env to name of environment variable holding passphrase to encrypt the archive, it is required when the profile is encrypted.")
+
+	cmd.WithFlags(
+		varflag.StringFunc("data", "", "comma separated patterns of data directory files to include"),
+		varflag.StringFunc("passphrase-env", "", "environment variable holding passphrase to encrypt archive"),
+		varflag.BoolFunc("force", false, "overwrite existing archive", "f"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		file := args.Arg(0).String()
+		if _, err := os.Stat(file); err == nil && !args.Flag("force").Var().Bool() {
+			return fmt.Errorf("%w: %s already exists, use --force to overwrite", backup.Error, file)
+		}
+		passphrase, err := passphraseFromEnv(args.Flag("passphrase-env").String())
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		m, err := backup.Export(sess, &buf, backup.ExportOptions{
+			Data:       splitComma(args.Flag("data").String()),
+			Passphrase: passphrase,
+		})
+		if err != nil {
+			return err
+		}
+		if err := writeArchive(file, buf.Bytes()); err != nil {
+			return err
+		}
+		sess.Log().Ok("application data exported",
+			slog.String("file", file),
+			slog.Int("files", len(m.Files)),
+			slog.Bool("store", m.Store),
+			slog.Bool("encrypted", passphrase != ""))
+		return nil
+	})
+	return cmd
+}
+
+// Import returns command which restores application data from archive
+// created by Export command.
+func Import() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "import",
+		Category:         "Configuration",
+		Description:      "Import application data from archive",
+		MinArgs:          1,
+		MaxArgs:          1,
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.Usage("[flags] <file>")
+	cmd.AddInfo("Data is imported into the current settings profile. By default nothing is imported when any file or store value differs from existing one, use --on-conflict to skip, overwrite or rename conflicting entries. Imported settings take effect next time application starts.")
+
+	cmd.WithFlags(
+		varflag.OptionFunc("on-conflict", []string{backup.ConflictFail}, []string{
+			backup.ConflictFail,
+			backup.ConflictSkip,
+			backup.ConflictOverwrite,
+			backup.ConflictRename,
+		}, "how entries differing from existing data are imported"),
+		varflag.StringFunc("passphrase-env", "", "environment variable holding passphrase of encrypted archive"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		f, err := os.Open(args.Arg(0).String())
+		if err != nil {
+			return fmt.Errorf("%w: %s", backup.Error, err.Error())
+		}
+		defer f.Close()
+		passphrase, err := passphraseFromEnv(args.Flag("passphrase-env").String())
+		if err != nil {
+			return err
+		}
+
+		report, err := backup.Import(sess, f, backup.ImportOptions{
+			OnConflict: args.Flag("on-conflict").String(),
+			Passphrase: passphrase,
+		})
+		if errors.Is(err, backup.ErrConflict) && report != nil {
+			for _, name := range report.Conflicts {
+				sess.Log().Warn("conflict", slog.String("entry", name))
+			}
+		}
+		if err != nil {
+			return err
+		}
+		for _, name := range report.Renamed {
+			sess.Log().Notice("imported with new name", slog.String("entry", name))
+		}
+		sess.Log().Ok("application data imported",
+			slog.String("app", report.Manifest.App),
+			slog.String("created", report.Manifest.CreatedAt.Format("2006-01-02 15:04:05")),
+			slog.Int("written", len(report.Written)),
+			slog.Int("unchanged", report.Unchanged),
+			slog.Int("skipped", len(report.Skipped)))
+		return nil
+	})
+	return cmd
+}
+
+func passphraseFromEnv(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	passphrase := os.Getenv(name)
+	if passphrase == "" {
+		return "", fmt.Errorf("%w: passphrase environment variable %s is not set", backup.Error, name)
+	}
+	return passphrase, nil
+}
+
+// writeArchive writes archive readable only by current user.
+func writeArchive(file string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+"-*")
+	if err != nil {
+		return fmt.Errorf("%w: %s", backup.Error, err.Error())
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %s", backup.Error, err.Error())
+	}
+	if err := tmp.Chmod(fs.FileMode(0600)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %s", backup.Error, err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%w: %s", backup.Error, err.Error())
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return fmt.Errorf("%w: %s", backup.Error, err.Error())
+	}
+	return nil
+}
+
+func splitComma(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,276 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"runtime/metrics"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/strings/humanize"
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/cli/help"
+)
+
+// BenchResult is latency distribution and allocations of benchmarked
+// command, it is also format of baseline file.
+type BenchResult struct {
+	Command   string        `json:"command"`
+	Runs      int           `json:"runs"`
+	Failures  int           `json:"failures"`
+	Min       time.Duration `json:"min"`
+	Mean      time.Duration `json:"mean"`
+	P50       time.Duration `json:"p50"`
+	P90       time.Duration `json:"p90"`
+	P99       time.Duration `json:"p99"`
+	Max       time.Duration `json:"max"`
+	AllocsOp  uint64        `json:"allocs_op"`
+	BytesOp   uint64        `json:"bytes_op"`
+	GoVersion string        `json:"go_version"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// Bench returns command which runs another registered command N times
+// after warmup runs and reports latency distribution and allocations.
+// Result can be saved as baseline and later runs compared against it,
+// comparison fails when mean or p90 latency regresses over threshold.
+//
+//	app bench --count 50 --save bench.json -- deploy --dry-run
+//	app bench --count 50 --baseline bench.json -- deploy --dry-run
+func Bench() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "bench",
+		Category:         "Diagnostics",
+		Description:      "Benchmark execution of a command",
+		SkipSharedBefore: true,
+	})
+	cmd.Usage("[flags] -- <command> [args...]")
+	cmd.AddInfo("Benchmarked command is executed within the same session, allocations are read from runtime metrics and include allocations of background goroutines.")
+
+	cmd.WithFlags(
+		varflag.UintFunc("count", 10, "number of measured runs", "n"),
+		varflag.UintFunc("warmup", 1, "number of runs before measuring"),
+		varflag.StringFunc("save", "", "save result as baseline to file"),
+		varflag.StringFunc("baseline", "", "compare result against baseline file"),
+		varflag.Float64Func("threshold", 10, "allowed regression of mean and p90 latency in percent"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		path, cmdargs, err := benchTarget(sess, args.Passthrough())
+		if err != nil {
+			return err
+		}
+		count := int(args.Flag("count").Var().Uint())
+		if count == 0 {
+			return fmt.Errorf("%w: --count must be greater than 0", cli.ErrCommandFlags)
+		}
+
+		for range args.Flag("warmup").Var().Uint() {
+			if err := sess.InvokeCommand(path, cmdargs...); err != nil {
+				return fmt.Errorf("%w: warmup run of %q failed: %s", command.Error, path, err)
+			}
+		}
+
+		samples := []metrics.Sample{
+			{Name: "/gc/heap/allocs:objects"},
+			{Name: "/gc/heap/allocs:bytes"},
+		}
+		var (
+			durations = make([]time.Duration, 0, count)
+			failures  int
+			objects   uint64
+			bytes     uint64
+		)
+		for range count {
+			metrics.Read(samples)
+			o, b := samples[0].Value.Uint64(), samples[1].Value.Uint64()
+			start := time.Now()
+			err := sess.InvokeCommand(path, cmdargs...)
+			durations = append(durations, time.Since(start))
+			metrics.Read(samples)
+			objects += samples[0].Value.Uint64() - o
+			bytes += samples[1].Value.Uint64() - b
+			if err != nil {
+				failures++
+			}
+		}
+
+		res := newBenchResult(strings.Join(append([]string{path}, cmdargs...), " "), durations)
+		res.Failures = failures
+		res.AllocsOp = objects / uint64(count)
+		res.BytesOp = bytes / uint64(count)
+		res.CreatedAt = sess.Time().Now()
+
+		var base *BenchResult
+		if file := args.Flag("baseline").String(); file != "" {
+			if base, err = readBenchResult(file); err != nil {
+				return err
+			}
+		}
+		sess.Log().Println(benchTable(res, base).String())
+
+		if file := args.Flag("save").String(); file != "" {
+			data, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return fmt.Errorf("%w: %s", command.Error, err)
+			}
+			if err := os.WriteFile(file, append(data, '\n'), 0o644); err != nil {
+				return fmt.Errorf("%w: failed to save baseline: %s", command.Error, err)
+			}
+			sess.Log().Println("baseline saved to " + file)
+		}
+
+		if failures > 0 {
+			return fmt.Errorf("%w: %d of %d runs of %q failed", command.Error, failures, count, path)
+		}
+		if base != nil {
+			threshold := args.Flag("threshold").Var().Float64()
+			for _, m := range []struct {
+				name      string
+				cur, base time.Duration
+			}{{"mean", res.Mean, base.Mean}, {"p90", res.P90, base.P90}} {
+				if d := delta(float64(m.cur), float64(m.base)); d > threshold {
+					return fmt.Errorf("%w: %s latency regressed %.1f%% over baseline, threshold %.1f%%", command.Error, m.name, d, threshold)
+				}
+			}
+		}
+		return nil
+	})
+	return cmd
+}
+
+// benchTarget splits passthrough arguments to path of registered
+// command and its arguments, longest matching command path is used.
+func benchTarget(sess *session.Context, argv []string) (string, []string, error) {
+	if len(argv) == 0 {
+		return "", nil, fmt.Errorf("%w: command to benchmark is required after --", cli.ErrCommandArgs)
+	}
+	known := make(map[string]bool)
+	var names []string
+	for _, e := range sess.HelpIndex() {
+		if e.Kind != help.KindCommand {
+			continue
+		}
+		// strip root command name
+		_, path, _ := strings.Cut(e.Command, " ")
+		known[path] = true
+		names = append(names, path)
+	}
+	for i := len(argv); i > 0; i-- {
+		if path := strings.Join(argv[:i], " "); known[path] {
+			if path == "bench" || strings.HasSuffix(path, " bench") {
+				return "", nil, fmt.Errorf("%w: bench can not benchmark itself", cli.ErrCommandArgs)
+			}
+			return path, argv[i:], nil
+		}
+	}
+	if s := textfmt.Suggest(argv[0], names); s != "" {
+		return "", nil, fmt.Errorf("%w: unknown command %q, did you mean '%s'?", cli.ErrCommandArgs, argv[0], s)
+	}
+	return "", nil, fmt.Errorf("%w: unknown command %q", cli.ErrCommandArgs, argv[0])
+}
+
+func newBenchResult(cmd string, durations []time.Duration) BenchResult {
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return BenchResult{
+		Command:   cmd,
+		Runs:      len(sorted),
+		Min:       sorted[0],
+		Mean:      total / time.Duration(len(sorted)),
+		P50:       percentile(sorted, 50),
+		P90:       percentile(sorted, 90),
+		P99:       percentile(sorted, 99),
+		Max:       sorted[len(sorted)-1],
+		GoVersion: runtime.Version(),
+	}
+}
+
+// percentile returns nearest rank percentile p of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(0, min(i, len(sorted)-1))]
+}
+
+func readBenchResult(file string) (*BenchResult, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read baseline: %s", command.Error, err)
+	}
+	res := &BenchResult{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, fmt.Errorf("%w: invalid baseline %s: %s", command.Error, file, err)
+	}
+	return res, nil
+}
+
+func benchTable(res BenchResult, base *BenchResult) *textfmt.Table {
+	table := &textfmt.Table{}
+	if base == nil {
+		table.AddRow("COMMAND", res.Command)
+		table.AddRow("RUNS", fmt.Sprint(res.Runs))
+	} else {
+		table.AddRow("COMMAND", res.Command, "BASELINE", "DELTA")
+		table.AddRow("RUNS", fmt.Sprint(res.Runs), fmt.Sprint(base.Runs), "")
+	}
+	table.AddDivider()
+	rows := []struct {
+		name      string
+		cur, base float64
+		format    func(float64) string
+	}{
+		{"min", float64(res.Min), 0, fmtDuration},
+		{"mean", float64(res.Mean), 0, fmtDuration},
+		{"p50", float64(res.P50), 0, fmtDuration},
+		{"p90", float64(res.P90), 0, fmtDuration},
+		{"p99", float64(res.P99), 0, fmtDuration},
+		{"max", float64(res.Max), 0, fmtDuration},
+		{"allocs/op", float64(res.AllocsOp), 0, func(v float64) string { return fmt.Sprint(uint64(v)) }},
+		{"bytes/op", float64(res.BytesOp), 0, func(v float64) string { return humanize.IBytes(uint64(v)) }},
+	}
+	if base != nil {
+		for i, b := range []float64{
+			float64(base.Min), float64(base.Mean), float64(base.P50), float64(base.P90),
+			float64(base.P99), float64(base.Max), float64(base.AllocsOp), float64(base.BytesOp),
+		} {
+			rows[i].base = b
+		}
+	}
+	for _, row := range rows {
+		if base == nil {
+			table.AddRow(row.name, row.format(row.cur))
+			continue
+		}
+		table.AddRow(row.name, row.format(row.cur), row.format(row.base), fmt.Sprintf("%+.1f%%", delta(row.cur, row.base)))
+	}
+	return table
+}
+
+func fmtDuration(v float64) string {
+	return time.Duration(v).String()
+}
+
+// delta returns change of cur compared to base in percent.
+func delta(cur, base float64) float64 {
+	if base == 0 {
+		return 0
+	}
+	return (cur - base) / base * 100
+}
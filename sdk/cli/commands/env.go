@@ -0,0 +1,276 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/options"
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+)
+
+// redacted is shown in place of values which look like secrets.
+const redacted = "<redacted>"
+
+// Snapshot is diagnostic snapshot of the application session
+// suitable to be attached to bug reports.
+type Snapshot struct {
+	CreatedAt time.Time           `json:"created_at"`
+	Settings  map[string]string   `json:"settings"`
+	Options   map[string]string   `json:"options"`
+	Paths     map[string]string   `json:"paths"`
+	Env       []string            `json:"env,omitempty"`
+	Platform  Platform            `json:"platform"`
+	Terminal  Terminal            `json:"terminal"`
+	Runtime   session.RuntimeInfo `json:"runtime"`
+	Addons    []string            `json:"addons"`
+	Services  []ServiceState      `json:"services"`
+}
+
+// Platform describes the platform application is running on.
+type Platform struct {
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	GoVersion  string `json:"go_version"`
+	NumCPU     int    `json:"num_cpu"`
+	Executable string `json:"executable"`
+}
+
+// Terminal describes detected terminal capabilities.
+type Terminal struct {
+	Interactive bool   `json:"interactive"`
+	Term        string `json:"term"`
+	ColorTerm   string `json:"colorterm"`
+	NoColor     bool   `json:"no_color"`
+	Columns     string `json:"columns"`
+}
+
+// ServiceState describes state of registered service.
+type ServiceState struct {
+	Name      string    `json:"name"`
+	Addr      string    `json:"addr"`
+	Running   bool      `json:"running"`
+	Instances int       `json:"instances"`
+	Failed    bool      `json:"failed"`
+	StartedAt time.Time `json:"started_at"`
+	StoppedAt time.Time `json:"stopped_at"`
+	Errors    []string  `json:"errors,omitempty"`
+}
+
+// NewSnapshot collects diagnostic snapshot of the session.
+// Values of settings and options which look like secrets are redacted.
+func NewSnapshot(sess *session.Context) Snapshot {
+	snap := Snapshot{
+		CreatedAt: sess.Time().Now(),
+		Settings:  make(map[string]string),
+		Options:   make(map[string]string),
+		Paths:     make(map[string]string),
+		Env:       sess.Env().Overrides(),
+	}
+
+	for _, s := range sess.Settings().All() {
+		snap.Settings[s.Key()] = redact(s.Key(), s.Value().String())
+	}
+
+	sess.Opts().Range(func(opt options.Option) bool {
+		name := opt.Name()
+		if strings.HasPrefix(name, "app.fs.path.") {
+			snap.Paths[strings.TrimPrefix(name, "app.fs.path.")] = opt.Value().String()
+			return true
+		}
+		snap.Options[name] = redact(name, opt.Value().String())
+		return true
+	})
+
+	exe, _ := os.Executable()
+	snap.Platform = Platform{
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		GoVersion:  runtime.Version(),
+		NumCPU:     runtime.NumCPU(),
+		Executable: exe,
+	}
+
+	_, noColor := os.LookupEnv("NO_COLOR")
+	snap.Terminal = Terminal{
+		Interactive: isTerminal(os.Stdout),
+		Term:        os.Getenv("TERM"),
+		ColorTerm:   os.Getenv("COLORTERM"),
+		NoColor:     noColor,
+		Columns:     os.Getenv("COLUMNS"),
+	}
+
+	snap.Runtime = sess.Runtime()
+
+	if addons := sess.Get("app.addons").String(); addons != "" {
+		snap.Addons = strings.Split(addons, ",")
+	}
+
+	for _, info := range sess.Services() {
+		state := ServiceState{
+			Name:      info.Name(),
+			Addr:      info.Addr().String(),
+			Running:   info.Running(),
+			Instances: info.Instances(),
+			Failed:    info.Failed(),
+			StartedAt: info.StartedAt(),
+			StoppedAt: info.StoppedAt(),
+		}
+		for _, err := range info.Errs() {
+			state.Errors = append(state.Errors, err.Error())
+		}
+		sort.Strings(state.Errors)
+		snap.Services = append(snap.Services, state)
+	}
+	return snap
+}
+
+// String returns human readable representation of the snapshot.
+func (s Snapshot) String() string {
+	var b strings.Builder
+
+	platform := textfmt.Table{Title: "Platform"}
+	platform.AddRow("os", s.Platform.OS)
+	platform.AddRow("arch", s.Platform.Arch)
+	platform.AddRow("go", s.Platform.GoVersion)
+	platform.AddRow("cpus", fmt.Sprint(s.Platform.NumCPU))
+	platform.AddRow("executable", s.Platform.Executable)
+	b.WriteString(platform.String())
+
+	term := textfmt.Table{Title: "Terminal"}
+	term.AddRow("interactive", fmt.Sprint(s.Terminal.Interactive))
+	term.AddRow("TERM", s.Terminal.Term)
+	term.AddRow("COLORTERM", s.Terminal.ColorTerm)
+	term.AddRow("NO_COLOR", fmt.Sprint(s.Terminal.NoColor))
+	term.AddRow("COLUMNS", s.Terminal.Columns)
+	b.WriteString(term.String())
+
+	rt := textfmt.Table{Title: "Runtime"}
+	rt.AddRow("container", s.Runtime.ContainerRuntime)
+	rt.AddRow("cpu limit", fmt.Sprint(s.Runtime.CPULimit))
+	rt.AddRow("memory limit", fmt.Sprint(s.Runtime.MemoryLimit))
+	rt.AddRow("ci", s.Runtime.CIProvider)
+	rt.AddRow("ssh", fmt.Sprint(s.Runtime.SSH))
+	rt.AddRow("interactive", fmt.Sprint(s.Runtime.Interactive()))
+	b.WriteString(rt.String())
+
+	b.WriteString(mapTable("Paths", s.Paths))
+	b.WriteString(mapTable("Settings", s.Settings))
+	b.WriteString(mapTable("Options", s.Options))
+
+	if len(s.Env) > 0 {
+		env := textfmt.Table{Title: "Environment overrides"}
+		for _, e := range s.Env {
+			env.AddRow(e)
+		}
+		b.WriteString(env.String())
+	}
+
+	addons := textfmt.Table{Title: "Addons"}
+	for _, a := range s.Addons {
+		addons.AddRow(a)
+	}
+	if len(s.Addons) == 0 {
+		addons.AddRow("-")
+	}
+	b.WriteString(addons.String())
+
+	svcs := textfmt.Table{Title: "Services", WithHeader: true}
+	svcs.AddRow("NAME", "ADDR", "RUNNING", "FAILED", "ERRORS")
+	for _, svc := range s.Services {
+		svcs.AddRow(svc.Name, svc.Addr, fmt.Sprint(svc.Running), fmt.Sprint(svc.Failed), strings.Join(svc.Errors, "; "))
+	}
+	b.WriteString(svcs.String())
+	return b.String()
+}
+
+// Env returns command which prints diagnostic snapshot of the
+// application environment in text or JSON format.
+func Env() *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "env",
+		Category:    "Diagnostics",
+		Description: "Print diagnostic snapshot of application environment",
+	})
+
+	cmd.AddInfo("Snapshot contains resolved settings, options, paths, platform and terminal capabilities, loaded addons and service states. Values which look like secrets are redacted, attach the output to bug reports.")
+
+	cmd.WithFlags(
+		varflag.BoolFunc("json", false, "Print snapshot as JSON"),
+		varflag.StringFunc("file", "", "Write snapshot to file instead of stdout", "f"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		snap := NewSnapshot(sess)
+
+		var out []byte
+		if args.Flag("json").Var().Bool() {
+			data, err := json.MarshalIndent(snap, "", "  ")
+			if err != nil {
+				return err
+			}
+			out = append(data, '\n')
+		} else {
+			out = []byte(snap.String())
+		}
+
+		if file := args.Flag("file").String(); file != "" {
+			if err := os.WriteFile(file, out, 0600); err != nil {
+				return err
+			}
+			sess.Log().Ok("snapshot written", slog.String("file", file))
+			return nil
+		}
+		sess.Log().Println(strings.TrimSuffix(string(out), "\n"))
+		return nil
+	})
+
+	return cmd
+}
+
+func mapTable(title string, m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	table := textfmt.Table{Title: title}
+	for _, k := range keys {
+		table.AddRow(k, m[k])
+	}
+	return table.String()
+}
+
+func redact(key, value string) string {
+	if value == "" {
+		return value
+	}
+	lkey := strings.ToLower(key)
+	for _, s := range []string{"secret", "token", "password", "passwd", "apikey", "api_key", "credential"} {
+		if strings.Contains(lkey, s) {
+			return redacted
+		}
+	}
+	return value
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/cli/ansicolor"
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/cli/help"
+	"golang.org/x/text/language"
+)
+
+// Help returns help command which renders help topics, without
+// arguments available topics are listed. Topic language is selected
+// from LC_ALL, LC_MESSAGES or LANG environment variables. With --search
+// commands, flags and topics matching the term are listed ranked by
+// relevance.
+func Help(topics ...*help.Topic) *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "help",
+		Description: "Show help topic or search commands, flags and topics",
+		MaxArgs:     1,
+	})
+	cmd.Usage("[topic]")
+	cmd.Usage("--search <term>")
+	cmd.WithFlags(
+		varflag.StringFunc("search", "", "search commands, flags and help topics"),
+	)
+
+	index := make(map[string]*help.Topic, len(topics))
+	for _, topic := range topics {
+		index[topic.Name()] = topic
+	}
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		if term := args.Flag("search").String(); term != "" {
+			return search(sess, term, topics)
+		}
+		if args.Argn() == 0 && len(index) == 0 {
+			sess.Log().Println(fmt.Sprintf("Run '%s help --search <term>' to search commands and flags, see --help for commands.", sess.Get("app.slug").String()))
+			return nil
+		}
+		if args.Argn() == 0 {
+			names := make([]string, 0, len(index))
+			for name := range index {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			var b strings.Builder
+			b.WriteString("HELP TOPICS\n\n")
+			for _, name := range names {
+				fmt.Fprintf(&b, "  %-20s %s\n", name, index[name].Title())
+			}
+			fmt.Fprintf(&b, "\nRun '%s help <topic>' to read topic, see --help for commands.", sess.Get("app.slug").String())
+			sess.Log().Println(b.String())
+			return nil
+		}
+
+		name := strings.ToLower(args.Arg(0).String())
+		topic, ok := index[name]
+		if !ok {
+			names := make([]string, 0, len(index))
+			for name := range index {
+				names = append(names, name)
+			}
+			if s := textfmt.Suggest(name, names); s != "" {
+				return fmt.Errorf("%w: unknown help topic %q, did you mean '%s'?", command.Error, name, s)
+			}
+			return fmt.Errorf("%w: unknown help topic %q", command.Error, name)
+		}
+		content := topic.Content(envLanguage(sess))
+		if !sess.Runtime().TTY {
+			sess.Log().Println(content)
+			return nil
+		}
+		sess.Log().Println(help.RenderMarkdown(content, help.Style{
+			Primary:     ansicolor.Style{Format: ansicolor.Bold | ansicolor.Underline},
+			Info:        ansicolor.Style{Format: ansicolor.Faint},
+			Description: ansicolor.Style{Format: ansicolor.Italic},
+		}))
+		return nil
+	})
+	return cmd
+}
+
+func search(sess *session.Context, term string, topics []*help.Topic) error {
+	entries := sess.HelpIndex()
+	lang := envLanguage(sess)
+	for _, topic := range topics {
+		entries = append(entries, help.Entry{
+			Kind:        help.KindTopic,
+			Name:        topic.Name(),
+			Command:     sess.Get("app.slug").String() + " help",
+			Description: topic.Title(),
+			Text:        topic.Content(lang),
+		})
+	}
+	matches := help.Search(term, entries)
+	if len(matches) == 0 {
+		sess.Log().Println(fmt.Sprintf("no commands, flags or help topics match %q", term))
+		return nil
+	}
+	table := textfmt.Table{}
+	for _, m := range matches {
+		name := m.Command
+		if m.Kind != help.KindCommand {
+			name += " " + m.Name
+		}
+		table.AddRow(string(m.Kind), name, m.Description)
+	}
+	sess.Log().Println(fmt.Sprintf("SEARCH RESULTS FOR %q\n\n%s", term, table.String()))
+	return nil
+}
+
+func envLanguage(sess *session.Context) language.Tag {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := sess.Env().Get(key)
+		if v == "" || v == "C" || v == "POSIX" {
+			continue
+		}
+		// en_US.UTF-8 -> en-US
+		v, _, _ = strings.Cut(v, ".")
+		if tag, err := language.Parse(strings.ReplaceAll(v, "_", "-")); err == nil {
+			return tag
+		}
+	}
+	return language.English
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+)
+
+// Info returns info command which prints application information,
+// with --startup flag startup report is printed.
+func Info() *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "info",
+		Description: "Print application information",
+		Category:    "Configuration",
+	})
+
+	cmd.WithFlags(
+		varflag.BoolFunc("startup", false, "print startup report, settings sources, addons, services and time spent per init phase"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		if args.Flag("startup").Var().Bool() {
+			report := sess.StartupReport()
+			if report == nil {
+				return fmt.Errorf("%w: startup report is not available", command.Error)
+			}
+			sess.Log().Println(report.String())
+			return nil
+		}
+
+		table := textfmt.Table{
+			Title: sess.Get("app.name").String(),
+		}
+		for _, key := range []string{
+			"app.slug",
+			"app.version",
+			"app.module",
+			"app.profile.name",
+			"app.pid",
+			"app.instance.id",
+			"app.fs.path.config",
+			"app.fs.path.cache",
+			"app.fs.path.profile",
+		} {
+			if sess.Has(key) {
+				table.AddRow(key, sess.Get(key).String())
+			}
+		}
+		info := sess.BuildInfo()
+		if info.Revision != "" {
+			revision := info.Revision
+			if info.Modified {
+				revision += " (modified)"
+			}
+			table.AddRow("revision", revision)
+		}
+		if info.Time != "" {
+			table.AddRow("build time", info.Time)
+		}
+		table.AddRow("platform", info.OS+"/"+info.Arch)
+		table.AddRow("go", info.GoVersion)
+		sess.Log().Println(table.String())
+		return nil
+	})
+	return cmd
+}
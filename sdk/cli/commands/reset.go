@@ -0,0 +1,367 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package commands provides optional built-in commands which
+// applications can add with app.Main.WithCommands.
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/strings/humanize"
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/internal/fsutils"
+	"github.com/happy-sdk/happy/sdk/paths"
+)
+
+// Category is a group of files created by the application.
+type Category struct {
+	Name        string
+	Description string
+	Paths       []string
+}
+
+// Size returns total size of existing paths in category.
+func (c Category) Size() int64 {
+	var size int64
+	for _, p := range c.Paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			size += info.Size()
+			continue
+		}
+		if s, err := fsutils.DirSize(p); err == nil {
+			size += s
+		}
+	}
+	return size
+}
+
+// Inventory returns everything the application has created on disk
+// grouped by category. Paths which do not exist are omitted.
+func Inventory(sess *session.Context) []Category {
+	return layoutOf(sess).inventory()
+}
+
+// root is application directory reset may remove data from.
+type root struct {
+	path string
+	// overridden roots were chosen by user, the directory itself is kept
+	// and only entries matching known patterns are owned by application.
+	// When known is empty all entries of overridden root are owned.
+	overridden bool
+	known      []string
+}
+
+// layout holds application directories reset operates on.
+type layout struct {
+	config root
+	cache  root
+	data   root
+	state  root
+	// tmp is base directory of instance tmp directories and
+	// currentTmp is tmp directory of current instance.
+	tmp        root
+	currentTmp string
+}
+
+func layoutOf(sess *session.Context) layout {
+	dir := func(name, key string, known ...string) root {
+		return root{
+			path:       sess.Get(key).String(),
+			overridden: paths.Overridden(sess, name),
+			known:      known,
+		}
+	}
+	// tmp path is <tmp>/<slug>/instance-<id>
+	currentTmp := sess.Get("app.fs.path.tmp").String()
+	tmp := dir("tmp", "app.fs.path.tmp", "instance-*")
+	tmp.path = filepath.Dir(currentTmp)
+	return layout{
+		config:     dir("config", "app.fs.path.config", ".default.profile", "profiles", "pids"),
+		cache:      dir("cache", "app.fs.path.cache_root", "profiles"),
+		data:       dir("data", "app.fs.path.data"),
+		state:      dir("state", "app.fs.path.state", "stats", "checkpoints", "locks"),
+		tmp:        tmp,
+		currentTmp: currentTmp,
+	}
+}
+
+func (l layout) roots() []root {
+	return []root{l.config, l.cache, l.data, l.state, l.tmp}
+}
+
+// owns reports whether p is within application owned directories and
+// can be removed.
+func (l layout) owns(p string) bool {
+	if p == "" {
+		return false
+	}
+	for _, r := range l.roots() {
+		if r.owns(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r root) owns(p string) bool {
+	if r.path == "" || r.path == "." {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Clean(r.path), filepath.Clean(p))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	if !r.overridden {
+		return true
+	}
+	if rel == "." {
+		return false
+	}
+	if len(r.known) == 0 {
+		return true
+	}
+	first, _, _ := strings.Cut(rel, string(filepath.Separator))
+	for _, pattern := range r.known {
+		if ok, _ := filepath.Match(pattern, first); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// content returns removable paths of root.
+func (r root) content() []string {
+	if r.path == "" {
+		return nil
+	}
+	if !r.overridden {
+		return existing(r.path)
+	}
+	if len(r.known) == 0 {
+		return existing(glob(filepath.Join(r.path, "*"))...)
+	}
+	var res []string
+	for _, pattern := range r.known {
+		res = append(res, glob(filepath.Join(r.path, pattern))...)
+	}
+	return existing(res...)
+}
+
+func (l layout) inventory() []Category {
+	configDir := l.config.path
+	categories := []Category{
+		{
+			Name:        "config",
+			Description: "Settings profiles and preferences",
+			Paths: existing(
+				append([]string{filepath.Join(configDir, ".default.profile")},
+					glob(filepath.Join(configDir, "profiles", "*", "profile.preferences"))...)...,
+			),
+		},
+		{
+			Name:        "credentials",
+			Description: "Stored login credentials",
+			Paths:       existing(glob(filepath.Join(configDir, "profiles", "*", "credentials"))...),
+		},
+		{
+			Name:        "cache",
+			Description: "Cached data of all profiles",
+			Paths:       l.cache.content(),
+		},
+		{
+			Name:        "data",
+			Description: "Application data",
+			Paths:       l.data.content(),
+		},
+		{
+			Name:        "state",
+			Description: "Stats history, checkpoints and locks",
+			Paths:       l.state.content(),
+		},
+		{
+			Name:        "instances",
+			Description: "Instance pid files and broadcast sockets",
+			Paths:       existing(filepath.Join(configDir, "pids")),
+		},
+		{
+			Name:        "tmp",
+			Description: "Temporary files of previous instances",
+			Paths:       existing(without(glob(filepath.Join(l.tmp.path, "instance-*")), l.currentTmp)...),
+		},
+	}
+	for i := range categories {
+		var owned []string
+		for _, p := range categories[i].Paths {
+			if l.owns(p) {
+				owned = append(owned, p)
+			}
+		}
+		categories[i].Paths = owned
+	}
+	return categories
+}
+
+// Reset returns command which lists everything the application has created
+// and removes selected categories, --all removes everything effectively
+// uninstalling application data.
+func Reset() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "reset",
+		Category:         "Configuration",
+		Description:      "Reset application by removing its data",
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.AddInfo("Without flags the command lists data created by the application. Select categories to remove with flags or use --all to remove everything.")
+
+	cmd.WithFlags(
+		varflag.BoolFunc("all", false, "Remove all application data", "a"),
+		varflag.BoolFunc("config", false, "Remove settings profiles and preferences"),
+		varflag.BoolFunc("credentials", false, "Remove stored login credentials"),
+		varflag.BoolFunc("cache", false, "Remove cached data"),
+		varflag.BoolFunc("data", false, "Remove application data"),
+		varflag.BoolFunc("state", false, "Remove stats history, checkpoints and locks"),
+		varflag.BoolFunc("instances", false, "Remove instance pid files"),
+		varflag.BoolFunc("tmp", false, "Remove temporary files of previous instances"),
+		varflag.BoolFunc("yes", false, "Do not ask for confirmation", "y"),
+		varflag.BoolFunc("dry-run", false, "Only show what would be removed"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		l := layoutOf(sess)
+		inventory := l.inventory()
+		all := args.Flag("all").Var().Bool()
+
+		var selected []Category
+		for _, c := range inventory {
+			if all || args.Flag(c.Name).Var().Bool() {
+				selected = append(selected, c)
+			}
+		}
+
+		if len(selected) == 0 {
+			sess.Log().Println(inventoryTable("Application data", inventory))
+			return nil
+		}
+
+		sess.Log().Println(inventoryTable("Following data will be removed", selected))
+		if args.Flag("dry-run").Var().Bool() {
+			return nil
+		}
+		if !args.Flag("yes").Var().Bool() && !cli.AskForConfirmation("Remove selected data?") {
+			sess.Log().Println("reset canceled")
+			return nil
+		}
+
+		var errs []error
+		for _, c := range selected {
+			for _, p := range c.Paths {
+				if !l.owns(p) {
+					errs = append(errs, fmt.Errorf("%s: refusing to remove %s outside of application directories", c.Name, p))
+					continue
+				}
+				internal.Log(sess.Log(), "removing", slog.String("category", c.Name), slog.String("path", p))
+				if err := os.RemoveAll(p); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", c.Name, err))
+				}
+			}
+		}
+
+		if all {
+			for _, r := range []root{l.config, l.cache, l.data, l.state} {
+				pruneEmpty(r)
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+		sess.Log().Ok("application data removed")
+		return nil
+	})
+
+	return cmd
+}
+
+func inventoryTable(title string, categories []Category) string {
+	table := textfmt.Table{
+		Title:      title,
+		WithHeader: true,
+	}
+	table.AddRow("CATEGORY", "SIZE", "DESCRIPTION", "PATHS")
+	for _, c := range categories {
+		paths := "-"
+		if len(c.Paths) > 0 {
+			paths = strings.Join(c.Paths, ", ")
+		}
+		table.AddRow(c.Name, humanize.Bytes(uint64(c.Size())), c.Description, paths)
+	}
+	return table.String()
+}
+
+func glob(pattern string) []string {
+	matches, _ := filepath.Glob(pattern)
+	return matches
+}
+
+func existing(paths ...string) []string {
+	var res []string
+	for _, p := range paths {
+		if p == "" || p == "." {
+			continue
+		}
+		if _, err := os.Stat(p); err == nil {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+func without(paths []string, exclude string) []string {
+	var res []string
+	for _, p := range paths {
+		if p != exclude {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// pruneEmpty removes empty directories under root including root unless
+// root is overridden by user.
+func pruneEmpty(r root) {
+	if r.path == "" {
+		return
+	}
+	var dirs []string
+	_ = filepath.WalkDir(r.path, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if i == 0 && r.overridden {
+			break
+		}
+		_ = os.Remove(dirs[i])
+	}
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package commands_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/happy-sdk/happy"
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/commands"
+	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/paths"
+)
+
+func TestInventoryOverriddenPaths(t *testing.T) {
+	base := t.TempDir()
+	userCache := filepath.Join(base, "cache")
+	userState := filepath.Join(base, "state")
+
+	// files of other applications in directories chosen by user
+	foreign := []string{
+		filepath.Join(userCache, "other-app", "cache.db"),
+		filepath.Join(userState, "notes.txt"),
+	}
+	for _, p := range foreign {
+		testutils.NoError(t, os.MkdirAll(filepath.Dir(p), 0700))
+		testutils.NoError(t, os.WriteFile(p, []byte("keep"), 0600))
+	}
+
+	main := app.New(happy.Settings{
+		Slug: "happy-reset-inventory-test",
+		FS: paths.Settings{
+			CacheDir: settings.String(userCache),
+			StateDir: settings.String(userState),
+		},
+	})
+	main.WithLogger(logging.NewTestLogger(logging.LevelError))
+
+	var inventory []commands.Category
+	main.Do(func(sess *session.Context, args action.Args) error {
+		history := filepath.Join(userState, "stats", "history.jsonl")
+		if err := os.MkdirAll(filepath.Dir(history), 0700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(history, nil, 0600); err != nil {
+			return err
+		}
+		if _, err := paths.Ensure(sess, "data"); err != nil {
+			return err
+		}
+		inventory = commands.Inventory(sess)
+		return nil
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+
+	selected := make(map[string][]string)
+	for _, c := range inventory {
+		selected[c.Name] = c.Paths
+		for _, p := range c.Paths {
+			testutils.True(t, p != userCache, "overridden cache dir must not be selected")
+			testutils.True(t, p != userState, "overridden state dir must not be selected")
+			for _, f := range foreign {
+				testutils.False(t, strings.HasPrefix(f, p), c.Name+": "+p+" contains "+f)
+			}
+		}
+	}
+
+	tests := []struct {
+		category string
+		want     string
+	}{
+		{"cache", filepath.Join(userCache, "profiles")},
+		{"state", filepath.Join(userState, "stats")},
+	}
+	for _, tt := range tests {
+		testutils.Equal(t, tt.want, strings.Join(selected[tt.category], ","), tt.category)
+	}
+	testutils.Equal(t, 1, len(selected["data"]), "data")
+}
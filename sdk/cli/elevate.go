@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package cli
+
+import (
+	"errors"
+	"os"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+var ErrElevate = errors.New("failed to relaunch elevated")
+
+// RelaunchElevated starts current executable with elevated privileges,
+// using sudo on Unix and UAC prompt on Windows. Arguments default to
+// current command line arguments. On Unix it waits for the elevated
+// process, on Windows it returns once process is started. Caller should
+// exit after successful relaunch.
+func RelaunchElevated(sess *session.Context, args ...string) error {
+	if len(args) == 0 {
+		args = os.Args[1:]
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return errors.Join(ErrElevate, err)
+	}
+	if err := relaunchElevated(sess, exe, args); err != nil {
+		return errors.Join(ErrElevate, err)
+	}
+	return nil
+}
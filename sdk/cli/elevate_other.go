@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+//go:build !windows
+
+package cli
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+func relaunchElevated(sess *session.Context, exe string, args []string) error {
+	cmd := exec.Command("sudo", append([]string{exe}, args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = sess.Env().Environ()
+	return cmd.Run()
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package cli
+
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+var (
+	shell32           = syscall.NewLazyDLL("shell32.dll")
+	procShellExecuteW = shell32.NewProc("ShellExecuteW")
+)
+
+func relaunchElevated(sess *session.Context, exe string, args []string) error {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = syscall.EscapeArg(arg)
+	}
+	verb, _ := syscall.UTF16PtrFromString("runas")
+	file, err := syscall.UTF16PtrFromString(exe)
+	if err != nil {
+		return err
+	}
+	params, err := syscall.UTF16PtrFromString(strings.Join(quoted, " "))
+	if err != nil {
+		return err
+	}
+	dir, err := syscall.UTF16PtrFromString(sess.Get("app.fs.path.wd").String())
+	if err != nil {
+		return err
+	}
+	const swShowNormal = 1
+	r, _, err := procShellExecuteW.Call(0,
+		uintptr(unsafe.Pointer(verb)),
+		uintptr(unsafe.Pointer(file)),
+		uintptr(unsafe.Pointer(params)),
+		uintptr(unsafe.Pointer(dir)),
+		swShowNormal)
+	// ShellExecute returns value greater than 32 on success
+	if r <= 32 {
+		return err
+	}
+	return nil
+}
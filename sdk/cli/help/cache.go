@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package help
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// cacheFormat is bumped when format of cached help changes.
+const cacheFormat = "happy-help-cache-v1"
+
+// ErrCache is returned when rendered help can not be cached.
+var ErrCache = errors.New("help cache")
+
+// Sum returns checksum of parts, use it to name cache file and to
+// identify sources rendered help was built from.
+func Sum(parts ...string) string {
+	h := sha256.New()
+	for _, part := range append([]string{cacheFormat}, parts...) {
+		fmt.Fprintf(h, "%d:%s;", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ReadCache returns help cached at path, it reports false when cache
+// does not exist or it was rendered from other sources than sum.
+func ReadCache(path, sum string) ([]byte, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	header, body, ok := bytes.Cut(data, []byte("\n"))
+	if !ok || string(header) != sum {
+		return nil, false
+	}
+	return body, true
+}
+
+// WriteCache writes help rendered from sources identified by sum to
+// path atomically, replacing help cached for previous sources.
+func WriteCache(path, sum string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("%w: %s", ErrCache, err.Error())
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrCache, err.Error())
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	_, _ = io.WriteString(w, sum+"\n")
+	_, _ = w.Write(data)
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %s", ErrCache, err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%w: %s", ErrCache, err.Error())
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("%w: %s", ErrCache, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package help
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+)
+
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "help", Sum("app sub", "80", "en_US.UTF-8")[:16]+".txt")
+	sum := Sum("v1.0.0", "fingerprint")
+	rendered := []byte("  app - v1.0.0\n\n  app sub [flags]\n")
+
+	tests := []struct {
+		name    string
+		change  func(t *testing.T)
+		sum     string
+		wantHit bool
+	}{
+		{name: "unchanged", sum: sum, wantHit: true},
+		{name: "version changed", sum: Sum("v1.0.1", "fingerprint")},
+		{name: "command tree changed", sum: Sum("v1.0.0", "other")},
+		{
+			name: "missing",
+			change: func(t *testing.T) {
+				testutils.NoError(t, os.Remove(path))
+			},
+			sum: sum,
+		},
+		{
+			name: "corrupted",
+			change: func(t *testing.T) {
+				testutils.NoError(t, os.WriteFile(path, []byte("garbage"), 0600))
+			},
+			sum: sum,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutils.NoError(t, WriteCache(path, sum, rendered))
+			if tt.change != nil {
+				tt.change(t)
+			}
+			data, ok := ReadCache(path, tt.sum)
+			testutils.Equal(t, tt.wantHit, ok, "cache hit")
+			if ok {
+				testutils.Equal(t, string(rendered), string(data))
+			}
+		})
+	}
+}
@@ -6,15 +6,21 @@ package help
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/happy-sdk/happy/pkg/cli/ansicolor"
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
 	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/cli/render"
 )
 
 type Help struct {
+	w           io.Writer
 	style       Style
 	info        *Info
 	cmds        map[string][]commandInfo
@@ -22,6 +28,7 @@ type Help struct {
 	sharedFlags []flagInfo
 	globalFlags []flagInfo
 	catdesc     map[string]string
+	catweight   map[string]int
 }
 
 type commandInfo struct {
@@ -47,10 +54,12 @@ type Style struct {
 
 func New(info Info, style Style) *Help {
 	return &Help{
-		style:   style,
-		info:    &info,
-		cmds:    make(map[string][]commandInfo),
-		catdesc: make(map[string]string),
+		w:         os.Stdout,
+		style:     style,
+		info:      &info,
+		cmds:      make(map[string][]commandInfo),
+		catdesc:   make(map[string]string),
+		catweight: make(map[string]int),
 	}
 }
 
@@ -102,13 +111,29 @@ func (h *Help) AddCommandFlags(flags []varflag.Flag) {
 		})
 	}
 }
+
 func (h *Help) AddCategoryDescriptions(catdescs map[string]string) {
 	for category, desc := range catdescs {
 		h.catdesc[category] = desc
 	}
 }
 
+// AddCategoryWeights sets weights used to order categories, categories
+// with lower weight are printed first and equal weights alphabetically.
+func (h *Help) AddCategoryWeights(weights map[string]int) {
+	for category, weight := range weights {
+		h.catweight[strings.ToLower(category)] = weight
+	}
+}
+
+// Print prints help to stdout.
 func (h *Help) Print() error {
+	return h.Fprint(os.Stdout)
+}
+
+// Fprint writes help to w.
+func (h *Help) Fprint(w io.Writer) error {
+	h.w = w
 	if err := h.printBanner(); err != nil {
 		return err
 	}
@@ -119,21 +144,44 @@ func (h *Help) Print() error {
 	if err := h.printCommands(); err != nil {
 		return err
 	}
+	h.dedupeFlags()
 	if err := h.printCommandFlags(); err != nil {
 		return err
 	}
+	if (len(h.flags) > 0 || len(h.sharedFlags) > 0) && len(h.globalFlags) > 0 {
+		// separate command flags from global flags
+		fmt.Fprintln(h.w)
+		fmt.Fprintln(h.w, " "+h.style.Description.String(strings.Repeat("─", 40)))
+	}
 	if err := h.printGlobalFlags(); err != nil {
 		return err
 	}
-	fmt.Println("")
+	fmt.Fprintln(h.w)
 	return nil
 }
 
+// dedupeFlags removes global and shared flags from command flags
+// so that each flag is listed only in its own group.
+func (h *Help) dedupeFlags() {
+	global := make(map[string]bool, len(h.globalFlags))
+	for _, flag := range h.globalFlags {
+		global[flag.Flag] = true
+	}
+	shared := make(map[string]bool, len(h.sharedFlags))
+	h.sharedFlags = slices.DeleteFunc(h.sharedFlags, func(f flagInfo) bool {
+		shared[f.Flag] = true
+		return global[f.Flag]
+	})
+	h.flags = slices.DeleteFunc(h.flags, func(f flagInfo) bool {
+		return global[f.Flag] || shared[f.Flag]
+	})
+}
+
 func (h *Help) printCommands() error {
 	// commands
 	if len(h.cmds) > 0 {
-		fmt.Println("")
-		fmt.Println(h.style.Primary.String(" COMMANDS:"))
+		fmt.Fprintln(h.w)
+		fmt.Fprintln(h.w, h.style.Primary.String(" COMMANDS:"))
 		var categories []string
 
 		var maxNameLength int
@@ -150,8 +198,14 @@ func (h *Help) printCommands() error {
 			}
 		}
 
-		// Sort the categories alphabetically
-		sort.Strings(categories)
+		// Sort the categories by weight and alphabetically
+		sort.Slice(categories, func(i, j int) bool {
+			wi, wj := h.catweight[strings.ToLower(categories[i])], h.catweight[strings.ToLower(categories[j])]
+			if wi != wj {
+				return wi < wj
+			}
+			return categories[i] < categories[j]
+		})
 
 		// Handle "default" category
 
@@ -161,7 +215,7 @@ func (h *Help) printCommands() error {
 			sort.Slice(commands, func(i, j int) bool {
 				return commands[i].name < commands[j].name
 			})
-			fmt.Println("")
+			fmt.Fprintln(h.w)
 			for _, cmd := range commands {
 				h.printSubcommand(maxNameLength, cmd.name, cmd.description)
 			}
@@ -169,9 +223,9 @@ func (h *Help) printCommands() error {
 
 		// Print other categories
 		for _, category := range categories {
-			fmt.Println("")
-			fmt.Println(" ", h.style.Category.String(strings.ToUpper(category))+h.getCategoryDesc(category))
-			fmt.Println("")
+			fmt.Fprintln(h.w)
+			fmt.Fprintln(h.w, " ", h.style.Category.String(strings.ToUpper(category))+h.getCategoryDesc(category))
+			fmt.Fprintln(h.w)
 			commands := h.cmds[category]
 
 			// Sort commands within each category alphabetically
@@ -189,9 +243,9 @@ func (h *Help) printCommands() error {
 
 func (h *Help) printCommandFlags() error {
 	if len(h.flags) > 0 {
-		fmt.Println("")
-		fmt.Println(h.style.Primary.String(" FLAGS:"))
-		fmt.Println("")
+		fmt.Fprintln(h.w)
+		fmt.Fprintln(h.w, h.style.Primary.String(" FLAGS:"))
+		fmt.Fprintln(h.w)
 
 		// Sort the globalFlags by flag name
 		sort.Slice(h.flags, func(i, j int) bool {
@@ -203,10 +257,10 @@ func (h *Help) printCommandFlags() error {
 			maxAliasLength int
 		)
 		for _, flag := range h.flags {
-			if mfl := len(flag.Flag); mfl > maxFlagLength {
+			if mfl := textfmt.Width(flag.Flag); mfl > maxFlagLength {
 				maxFlagLength = mfl
 			}
-			if mal := len(flag.UsageAliases); mal > maxAliasLength {
+			if mal := textfmt.Width(flag.UsageAliases); mal > maxAliasLength {
 				maxAliasLength = mal
 			}
 		}
@@ -217,9 +271,9 @@ func (h *Help) printCommandFlags() error {
 	}
 
 	if len(h.sharedFlags) > 0 {
-		fmt.Println("")
-		fmt.Println(h.style.Primary.String(" SHARED FLAGS:"))
-		fmt.Println("")
+		fmt.Fprintln(h.w)
+		fmt.Fprintln(h.w, h.style.Primary.String(" SHARED FLAGS:"))
+		fmt.Fprintln(h.w)
 
 		// Sort the globalFlags by flag name
 		sort.Slice(h.sharedFlags, func(i, j int) bool {
@@ -231,10 +285,10 @@ func (h *Help) printCommandFlags() error {
 			maxAliasLength int
 		)
 		for _, flag := range h.sharedFlags {
-			if mfl := len(flag.Flag); mfl > maxFlagLength {
+			if mfl := textfmt.Width(flag.Flag); mfl > maxFlagLength {
 				maxFlagLength = mfl
 			}
-			if mal := len(flag.UsageAliases); mal > maxAliasLength {
+			if mal := textfmt.Width(flag.UsageAliases); mal > maxAliasLength {
 				maxAliasLength = mal
 			}
 		}
@@ -248,9 +302,9 @@ func (h *Help) printCommandFlags() error {
 }
 func (h *Help) printGlobalFlags() error {
 	if len(h.globalFlags) > 0 {
-		fmt.Println("")
-		fmt.Println(h.style.Primary.String(" GLOBAL FLAGS:"))
-		fmt.Println("")
+		fmt.Fprintln(h.w)
+		fmt.Fprintln(h.w, h.style.Primary.String(" GLOBAL FLAGS:"))
+		fmt.Fprintln(h.w)
 
 		// Sort the globalFlags by flag name
 		sort.Slice(h.globalFlags, func(i, j int) bool {
@@ -262,10 +316,10 @@ func (h *Help) printGlobalFlags() error {
 			maxAliasLength int
 		)
 		for _, flag := range h.globalFlags {
-			if mfl := len(flag.Flag); mfl > maxFlagLength {
+			if mfl := textfmt.Width(flag.Flag); mfl > maxFlagLength {
 				maxFlagLength = mfl
 			}
-			if mal := len(flag.UsageAliases); mal > maxAliasLength {
+			if mal := textfmt.Width(flag.UsageAliases); mal > maxAliasLength {
 				maxAliasLength = mal
 			}
 		}
@@ -282,56 +336,67 @@ func (h *Help) printFlag(maxFlagLength, maxAliasLength int, flag flagInfo) {
 	if aliases == "" {
 		aliases = strings.Repeat(" ", maxAliasLength)
 	}
-	fstr := fmt.Sprintf(
-		"  %-"+fmt.Sprint(maxFlagLength)+"s %-"+fmt.Sprint(maxAliasLength+2)+"s ",
-		flag.Flag,
-		aliases,
-	)
+	fstr := "  " + textfmt.Pad(flag.Flag, maxFlagLength, textfmt.AlignLeft) +
+		" " + textfmt.Pad(aliases, maxAliasLength+2, textfmt.AlignLeft) + " "
 
 	prefix := strings.Repeat(" ", maxFlagLength+maxAliasLength+7)
 	desc := wordWrapWithPrefix(flag.Usage, prefix, 80)
 
-	fmt.Println(fstr, desc)
+	fmt.Fprintln(h.w, fstr, desc)
 }
 
 func (h *Help) printSubcommand(maxNameLength int, name, description string) {
 	prefix := strings.Repeat(" ", maxNameLength+2)
 	desc := wordWrapWithPrefix(description, prefix, 80)
 
-	str := fmt.Sprintf("  %-"+fmt.Sprint(maxNameLength+10)+"s  %s", ansicolor.Format(name, ansicolor.Bold), desc)
-	fmt.Println(str)
+	name = textfmt.Pad(ansicolor.Format(name, ansicolor.Bold), maxNameLength-2, textfmt.AlignLeft)
+	fmt.Fprintln(h.w, "  "+name+"  "+desc)
 }
 
 func (h *Help) printBanner() error {
 	name := h.style.Primary.String(h.info.Name)
 	version := h.style.Version.String(h.info.Version)
 
-	fmt.Println(" ", name, "-", version)
+	fmt.Fprintln(h.w, " ", name, "-", version)
 
 	copyr := h.info.copyright()
 	if copyr != "" {
-		fmt.Println(" ", h.style.Credits.String(copyr))
+		fmt.Fprintln(h.w, " ", h.style.Credits.String(copyr))
 	}
 	license := h.info.license()
 	if license != "" {
-		fmt.Println(" ", h.style.License.String(license))
+		fmt.Fprintln(h.w, " ", h.style.License.String(license))
 	}
 	description := h.info.description()
 	if description != "" {
-		fmt.Println(" ", h.style.Description.String(description))
+		fmt.Fprintln(h.w, " ", h.style.Description.String(description))
 	}
-	fmt.Println("")
+	fmt.Fprintln(h.w)
 	for _, usage := range h.info.Usage {
-		fmt.Println(" ", ansicolor.Format(usage, ansicolor.Bold))
+		fmt.Fprintln(h.w, " ", ansicolor.Format(usage, ansicolor.Bold))
 	}
 	return nil
 }
 
+// PrintUsage prints usage lines only followed by hint to see full help
+// of command.
+func (h *Help) PrintUsage(command string) error {
+	for _, usage := range h.info.Usage {
+		fmt.Fprintln(h.w, " ", ansicolor.Format(usage, ansicolor.Bold))
+	}
+	if command != "" {
+		fmt.Fprintln(h.w)
+		fmt.Fprintln(h.w, " ", h.style.Description.String(fmt.Sprintf("see '%s --help' for more information", command)))
+	}
+	fmt.Fprintln(h.w)
+	return nil
+}
+
 func (h *Help) printInfo() error {
 	if len(h.info.Info) > 0 {
-		fmt.Println("")
+		fmt.Fprintln(h.w)
 		for _, info := range h.info.Info {
-			fmt.Println(" ", h.style.Info.String(info))
+			fmt.Fprintln(h.w, " ", h.style.Info.String(render.Links(info)))
 		}
 	}
 	return nil
@@ -388,45 +453,18 @@ func (i *Info) description() string {
 	return "\n  " + wordWrapWithPrefix(i.Description, "  ", 100)
 }
 
+// wordWrapWithPrefix wraps input to lineLength columns and prefixes
+// all but first line with prefix.
 func wordWrapWithPrefix(input, prefix string, lineLength int) string {
-	var result strings.Builder
-	var line strings.Builder
-	words := strings.Fields(input)
-
-	firstLine := true
-
-	for _, word := range words {
-		if line.Len()+len(word)+1 <= lineLength { // +1 for the space between words
-			if line.Len() > 0 {
-				line.WriteByte(' ')
-			}
-			line.WriteString(word)
-		} else {
-			if !firstLine {
-				result.WriteString("\n" + prefix)
-			}
-			result.WriteString(line.String())
-			line.Reset()
-			line.WriteString(word)
-			firstLine = false
-		}
-	}
-
-	if line.Len() > 0 {
-		if !firstLine {
-			result.WriteString("\n" + prefix)
-		}
-		result.WriteString(line.String())
-	}
-
-	return result.String()
+	wrapped := textfmt.Wrap(strings.Join(strings.Fields(input), " "), lineLength)
+	return strings.ReplaceAll(wrapped, "\n", "\n"+prefix)
 }
 
 func getMaxNameLength(commands []commandInfo) int {
 	max := 0
 	for _, cmd := range commands {
-		if len(cmd.name) > max {
-			max = len(cmd.name)
+		if w := textfmt.Width(cmd.name); w > max {
+			max = w
 		}
 	}
 	return max
@@ -3,3 +3,54 @@
 // Copyright © 2024 The Happy Authors
 
 package help
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	entries := []Entry{
+		{Kind: KindCommand, Name: "deploy", Command: "app deploy", Description: "Deploy application"},
+		{Kind: KindCommand, Name: "status", Command: "app status", Description: "Show deploy status"},
+		{Kind: KindFlag, Name: "--dry-run", Command: "app deploy", Description: "print actions only"},
+		{Kind: KindTopic, Name: "profiles", Description: "Profiles", Text: "profiles can be used to deploy"},
+	}
+	tests := []struct {
+		term string
+		want []string
+	}{
+		{"deploy", []string{"deploy", "status", "profiles"}},
+		{"depoly", []string{"deploy"}},
+		{"dry", []string{"--dry-run"}},
+		{"deploy status", []string{"status"}},
+		{"nothing", nil},
+	}
+	for _, tt := range tests {
+		got := Search(tt.term, entries)
+		var names []string
+		for _, e := range got {
+			names = append(names, e.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Search(%q) = %v, want %v", tt.term, names, tt.want)
+		}
+	}
+}
+
+func TestFprint(t *testing.T) {
+	h := New(Info{Name: "app", Version: "v1.0.0", Usage: []string{"app [flags]"}}, Style{})
+	h.AddCommand("", "sub", "sub command")
+
+	var buf bytes.Buffer
+	if err := h.Fprint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"app", "v1.0.0", "app [flags]", "COMMANDS:", "sub command"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("help does not contain %q:\n%s", want, out)
+		}
+	}
+}
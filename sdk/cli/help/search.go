@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package help
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+)
+
+// EntryKind is kind of searchable help entry.
+type EntryKind string
+
+const (
+	KindCommand EntryKind = "command"
+	KindFlag    EntryKind = "flag"
+	KindTopic   EntryKind = "topic"
+)
+
+// Entry is searchable help entry.
+type Entry struct {
+	Kind EntryKind
+	// Name of the command, flag or topic.
+	Name string
+	// Command is path of command flag belongs to or command path.
+	Command     string
+	Description string
+	// Text is additional searched text e.g. topic content or info of
+	// command.
+	Text  string
+	Score int
+
+	// Usage lines, argument limits and MCP exposure of command entries.
+	Usage     []string
+	MinArgs   uint
+	MaxArgs   uint
+	ExposeMCP bool
+
+	// FlagType is type of flag entry value: bool, string, int, uint,
+	// float, duration or option. Default is default value of flag and
+	// Options are values accepted by option flag.
+	FlagType string
+	Default  string
+	Options  []string
+	Required bool
+}
+
+// Search returns entries matching all words of term ranked by score.
+// Exact and prefix matches of name rank above fuzzy matches of name,
+// which rank above matches in description and text.
+func Search(term string, entries []Entry) []Entry {
+	words := strings.Fields(strings.ToLower(term))
+	if len(words) == 0 {
+		return nil
+	}
+	var matches []Entry
+	for _, e := range entries {
+		e.Score = 0
+		for _, word := range words {
+			score := e.score(word)
+			if score == 0 {
+				e.Score = 0
+				break
+			}
+			e.Score += score
+		}
+		if e.Score > 0 {
+			matches = append(matches, e)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Command+" "+matches[i].Name < matches[j].Command+" "+matches[j].Name
+	})
+	return matches
+}
+
+func (e Entry) score(word string) int {
+	name := strings.ToLower(strings.TrimLeft(e.Name, "-"))
+	switch {
+	case name == word:
+		return 100
+	case strings.HasPrefix(name, word):
+		return 80
+	case strings.Contains(name, word):
+		return 60
+	}
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' || r == ' ' }) {
+		if textfmt.Suggest(word, []string{part}) != "" {
+			return 40
+		}
+	}
+	if strings.Contains(strings.ToLower(e.Description), word) {
+		return 20
+	}
+	if strings.Contains(strings.ToLower(e.Text), word) {
+		return 10
+	}
+	return 0
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package help
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/cli/ansicolor"
+	"github.com/happy-sdk/happy/sdk/cli/render"
+	"golang.org/x/text/language"
+)
+
+// Topic is help page which is not bound to command e.g. conceptual
+// documentation about configuration or profiles. Content is Markdown.
+type Topic struct {
+	name    string
+	langs   []language.Tag
+	content map[language.Tag]string
+}
+
+// NewTopic returns topic with English content.
+func NewTopic(name, content string) *Topic {
+	t := &Topic{
+		name:    strings.ToLower(strings.TrimSpace(name)),
+		content: make(map[language.Tag]string),
+	}
+	return t.Translate(language.English, content)
+}
+
+// Translate adds content translated to lang.
+func (t *Topic) Translate(lang language.Tag, content string) *Topic {
+	if _, ok := t.content[lang]; !ok {
+		t.langs = append(t.langs, lang)
+	}
+	t.content[lang] = content
+	return t
+}
+
+func (t *Topic) Name() string {
+	return t.name
+}
+
+// Title returns first heading of English content or topic name.
+func (t *Topic) Title() string {
+	for _, line := range strings.Split(t.content[language.English], "\n") {
+		if title, ok := strings.CutPrefix(strings.TrimSpace(line), "#"); ok {
+			return strings.TrimSpace(strings.TrimLeft(title, "#"))
+		}
+	}
+	return t.name
+}
+
+// Content returns content in lang, content in base language of lang
+// or English content when there is no translation.
+func (t *Topic) Content(lang language.Tag) string {
+	if content, ok := t.content[lang]; ok {
+		return content
+	}
+	base, _, _ := strings.Cut(lang.String(), "-")
+	for _, l := range t.langs {
+		if lbase, _, _ := strings.Cut(l.String(), "-"); lbase == base {
+			return t.content[l]
+		}
+	}
+	return t.content[language.English]
+}
+
+var (
+	mdBold   = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	mdCode   = regexp.MustCompile("`([^`]+)`")
+	mdItalic = regexp.MustCompile(`(^|\s)[*_]([^*_]+)[*_]`)
+	mdLink   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+)
+
+// RenderMarkdown renders subset of Markdown for terminal output,
+// headings, lists, code blocks, inline emphasis and links are supported.
+// Links are rendered as terminal hyperlinks where supported.
+func RenderMarkdown(md string, style Style) string {
+	var (
+		b     strings.Builder
+		fence bool
+	)
+	for _, line := range strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			fence = !fence
+			continue
+		}
+		if fence {
+			b.WriteString("    " + style.Info.String(line) + "\n")
+			continue
+		}
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			title := strings.TrimSpace(trimmed[level:])
+			if level == 1 {
+				title = strings.ToUpper(title)
+			}
+			b.WriteString(" " + style.Primary.String(title) + "\n")
+		case strings.HasPrefix(trimmed, "- "), strings.HasPrefix(trimmed, "* "):
+			indent := strings.Repeat(" ", len(line)-len(strings.TrimLeft(line, " ")))
+			b.WriteString("  " + indent + "• " + renderInline(trimmed[2:], style) + "\n")
+		case strings.HasPrefix(trimmed, "> "):
+			b.WriteString("  │ " + style.Description.String(renderInline(trimmed[2:], style)) + "\n")
+		case trimmed == "":
+			b.WriteString("\n")
+		default:
+			b.WriteString("  " + renderInline(trimmed, style) + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func renderInline(s string, style Style) string {
+	s = mdLink.ReplaceAllStringFunc(s, func(m string) string {
+		sub := mdLink.FindStringSubmatch(m)
+		return render.Link(sub[2], sub[1])
+	})
+	s = mdCode.ReplaceAllStringFunc(s, func(m string) string {
+		return style.Info.String(m[1 : len(m)-1])
+	})
+	s = mdBold.ReplaceAllStringFunc(s, func(m string) string {
+		return ansicolor.Format(m[2:len(m)-2], ansicolor.Bold)
+	})
+	s = mdItalic.ReplaceAllString(s, "${1}"+ansicolor.Format("${2}", ansicolor.Italic))
+	return s
+}
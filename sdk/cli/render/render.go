@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package render provides helpers to emit clickable URLs and file
+// paths in help, error messages and command output. Links are written
+// as OSC 8 hyperlinks when stdout is a terminal supporting them and as
+// plain text otherwise.
+package render
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/happy-sdk/happy/pkg/cli/ansicolor"
+)
+
+var (
+	mu         sync.RWMutex
+	detected   bool
+	hyperlinks bool
+)
+
+// Hyperlinks reports whether stdout supports OSC 8 hyperlinks, support
+// is detected once with ansicolor.HyperlinksSupported.
+func Hyperlinks() bool {
+	mu.RLock()
+	if detected {
+		defer mu.RUnlock()
+		return hyperlinks
+	}
+	mu.RUnlock()
+	mu.Lock()
+	defer mu.Unlock()
+	if !detected {
+		hyperlinks = ansicolor.HyperlinksSupported(os.Stdout)
+		detected = true
+	}
+	return hyperlinks
+}
+
+// SetHyperlinks overrides detected hyperlink support.
+func SetHyperlinks(enabled bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	hyperlinks = enabled
+	detected = true
+}
+
+// Link returns text linking to url. Without hyperlink support text is
+// followed by url in parentheses unless they are equal.
+func Link(url, text string) string {
+	if text == "" {
+		text = url
+	}
+	if Hyperlinks() {
+		return ansicolor.Hyperlink(url, text)
+	}
+	if text == url {
+		return text
+	}
+	return text + " (" + url + ")"
+}
+
+// URL returns clickable url.
+func URL(url string) string {
+	return Link(url, url)
+}
+
+// Path returns text linking to file or directory path, empty text
+// displays path. Without hyperlink support text is returned as is.
+func Path(path, text string) string {
+	if text == "" {
+		text = path
+	}
+	if !Hyperlinks() {
+		return text
+	}
+	return ansicolor.Hyperlink(FileURL(path), text)
+}
+
+// FileURL returns file URL of path, relative paths are resolved
+// against working directory.
+func FileURL(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// windows drive letter
+		path = "/" + path
+	}
+	host, _ := os.Hostname()
+	u := url.URL{Scheme: "file", Host: host, Path: path}
+	return u.String()
+}
+
+var bareURL = regexp.MustCompile(`https?://[^\s<>"'()]+[^\s<>"'().,;:!?]`)
+
+// Links makes bare http and https URLs in s clickable.
+func Links(s string) string {
+	if !Hyperlinks() {
+		return s
+	}
+	return bareURL.ReplaceAllStringFunc(s, URL)
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"sort"
+)
+
+// CacheFilename is name of compiled profile cache stored next to
+// profile preferences.
+const CacheFilename = "profile.cache"
+
+// cacheFormat is bumped when Cache encoding changes.
+const cacheFormat = "happy-profile-cache-v1"
+
+// Cache is compiled binary form of configuration layers resolved and
+// validated for settings schema. It is valid as long as Sum matches
+// checksum of its sources.
+type Cache struct {
+	Sum    string
+	Layers []Layer
+}
+
+// Resolver returns resolver of cached layers.
+func (c *Cache) Resolver() *Resolver {
+	r := &Resolver{}
+	for _, layer := range c.Layers {
+		r.Add(layer)
+	}
+	return r
+}
+
+// SourceSum returns checksum of configuration sources. Identity
+// identifies settings schema e.g. module, version and setting keys,
+// files are hashed by their content and layers by their values.
+func SourceSum(identity []string, files []string, layers ...Layer) string {
+	h := sha256.New()
+	write := func(s string) {
+		fmt.Fprintf(h, "%d:%s;", len(s), s)
+	}
+	write(cacheFormat)
+	for _, id := range identity {
+		write(id)
+	}
+	for _, path := range files {
+		write(path)
+		hashFile(h, path)
+	}
+	for _, layer := range layers {
+		write(string(layer.Source))
+		write(layer.Path)
+		keys := make([]string, 0, len(layer.Values))
+		for key := range layer.Values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			write(key)
+			write(layer.Values[key])
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func hashFile(h hash.Hash, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// missing and unreadable files differ from any content
+		fmt.Fprintf(h, "!%T;", err)
+		return
+	}
+	fmt.Fprintf(h, "%d:", len(data))
+	h.Write(data)
+}
+
+// ReadCache reads cache at path, it reports false when cache does not
+// exist, can not be decoded or was compiled from other sources than sum.
+func ReadCache(path, sum string) (*Cache, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	// cache written before profile was encrypted is compiled again.
+	if encrypted(data) != encrypts(path) {
+		return nil, false
+	}
+	if data, err = DecryptFile(path, data); err != nil {
+		return nil, false
+	}
+	c := &Cache{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(c); err != nil {
+		return nil, false
+	}
+	if c.Sum != sum {
+		return nil, false
+	}
+	return c, true
+}
+
+// WriteCache writes cache to path atomically so that concurrently
+// starting instances never read partially written cache.
+func WriteCache(path string, c *Cache) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(c); err != nil {
+		return fmt.Errorf("%w: cache: %s", ErrProfile, err.Error())
+	}
+	data, err := EncryptFile(path, buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("%w: cache: %s", ErrProfile, err.Error())
+	}
+	if err := writeFile(path, data, 0600); err != nil {
+		return fmt.Errorf("%w: cache: %s", ErrProfile, err.Error())
+	}
+	return nil
+}
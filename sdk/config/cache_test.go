@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+)
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	prefs := filepath.Join(dir, "profile.preferences")
+	testutils.NoError(t, os.WriteFile(prefs, []byte("v1"), 0600))
+
+	identity := []string{"example.com/app", "v1.0.0", "app.name"}
+	env := Layer{Source: SourceEnv, Values: map[string]string{"app.name": "env"}}
+	sum := SourceSum(identity, []string{prefs}, env)
+
+	cachePath := filepath.Join(dir, CacheFilename)
+	layers := []Layer{
+		{Source: SourceProfile, Path: prefs, Values: map[string]string{"app.name": "profile"}},
+		env,
+	}
+	testutils.NoError(t, WriteCache(cachePath, &Cache{Sum: sum, Layers: layers}))
+
+	tests := []struct {
+		name    string
+		change  func(t *testing.T)
+		sum     func() string
+		wantHit bool
+	}{
+		{
+			name:    "unchanged",
+			sum:     func() string { return SourceSum(identity, []string{prefs}, env) },
+			wantHit: true,
+		},
+		{
+			name: "file changed",
+			change: func(t *testing.T) {
+				testutils.NoError(t, os.WriteFile(prefs, []byte("v2"), 0600))
+			},
+			sum: func() string { return SourceSum(identity, []string{prefs}, env) },
+		},
+		{
+			name: "file removed",
+			change: func(t *testing.T) {
+				testutils.NoError(t, os.Remove(prefs))
+			},
+			sum: func() string { return SourceSum(identity, []string{prefs}, env) },
+		},
+		{
+			name: "layer changed",
+			sum: func() string {
+				return SourceSum(identity, []string{prefs}, Layer{Source: SourceEnv, Values: map[string]string{"app.name": "other"}})
+			},
+		},
+		{
+			name: "schema changed",
+			sum: func() string {
+				return SourceSum([]string{"example.com/app", "v1.0.1", "app.name"}, []string{prefs}, env)
+			},
+		},
+		{
+			name: "corrupted",
+			change: func(t *testing.T) {
+				testutils.NoError(t, os.WriteFile(cachePath, []byte("garbage"), 0600))
+			},
+			sum: func() string { return sum },
+		},
+		{
+			name: "missing",
+			change: func(t *testing.T) {
+				testutils.NoError(t, os.Remove(cachePath))
+			},
+			sum: func() string { return sum },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutils.NoError(t, os.WriteFile(prefs, []byte("v1"), 0600))
+			testutils.NoError(t, WriteCache(cachePath, &Cache{Sum: sum, Layers: layers}))
+			if tt.change != nil {
+				tt.change(t)
+			}
+			cache, ok := ReadCache(cachePath, tt.sum())
+			testutils.Equal(t, tt.wantHit, ok, "cache hit")
+			if !ok {
+				return
+			}
+			val, found := cache.Resolver().Explain("app.name")
+			testutils.True(t, found, "app.name must be resolved")
+			testutils.Equal(t, "env", val.Value)
+			testutils.Equal(t, 1, len(val.Overridden), "overridden values")
+		})
+	}
+}
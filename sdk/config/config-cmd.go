@@ -5,25 +5,26 @@
 package config
 
 import (
-	"bytes"
-	"encoding/gob"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 
+	"github.com/happy-sdk/happy/pkg/diff"
 	"github.com/happy-sdk/happy/pkg/options"
 	"github.com/happy-sdk/happy/pkg/settings"
 	"github.com/happy-sdk/happy/pkg/strings/textfmt"
 	"github.com/happy-sdk/happy/pkg/vars"
 	"github.com/happy-sdk/happy/pkg/vars/varflag"
 	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/addon"
 	"github.com/happy-sdk/happy/sdk/app/session"
 	"github.com/happy-sdk/happy/sdk/cli/command"
 	"github.com/happy-sdk/happy/sdk/internal"
 )
 
-func Command() *command.Command {
+// Command returns config command, addons are listed by config addons subcommand.
+func Command(addons ...addon.Info) *command.Command {
 	cmd := command.New(command.Config{
 		Name:             "config",
 		Category:         "Configuration",
@@ -40,6 +41,8 @@ func Command() *command.Command {
 		configSet(),
 		configGet(),
 		configReset(),
+		configRefresh(),
+		configAddons(addons),
 	)
 
 	return cmd
@@ -104,7 +107,11 @@ func configLs() *command.Command {
 			if s.Mutability() != settings.SettingImmutable && s.Default().String() != s.Value().String() {
 				defval = s.Default().String()
 			}
-			table.AddRow(s.Key(), s.Kind().String(), fmt.Sprint(s.IsSet()), fmt.Sprint(s.Mutability()), s.Value().String(), defval)
+			mutability := fmt.Sprint(s.Mutability())
+			if Managed(sess, s.Key()) {
+				mutability = "managed"
+			}
+			table.AddRow(s.Key(), s.Kind().String(), fmt.Sprint(s.IsSet()), mutability, s.Value().String(), defval)
 		}
 		sess.Log().Println(table.String())
 
@@ -128,7 +135,11 @@ func configLs() *command.Command {
 			if s.Mutability() != settings.SettingImmutable && s.Default().String() != s.Value().String() {
 				defval = s.Default().String()
 			}
-			apptable.AddRow(s.Key(), s.Kind().String(), fmt.Sprint(s.IsSet()), fmt.Sprint(s.Mutability()), s.Value().String(), defval)
+			mutability := fmt.Sprint(s.Mutability())
+			if Managed(sess, s.Key()) {
+				mutability = "managed"
+			}
+			apptable.AddRow(s.Key(), s.Kind().String(), fmt.Sprint(s.IsSet()), mutability, s.Value().String(), defval)
 		}
 		sess.Log().Println(apptable.String())
 
@@ -159,70 +170,175 @@ func configOpts() *command.Command {
 	return cmd
 }
 
-func configSet() *command.Command {
+func configRefresh() *command.Command {
 	cmd := command.New(command.Config{
-		Name:        "set",
-		Description: "Set a setting value",
-		MinArgs:     2,
+		Name:        "refresh",
+		Description: "Fetch remote configuration now",
 	})
 
 	cmd.Usage("--profile=<profile-name>")
+	cmd.AddInfo("Remote configuration is fetched on start when cached copy is older than app.config.remote_interval. Fetched configuration takes effect next time application starts.")
 
 	cmd.Do(func(sess *session.Context, args action.Args) error {
-		key := args.Arg(0).String()
-		if !sess.Settings().Has(key) {
-			return fmt.Errorf("setting %q does not exist", key)
+		rawurl := sess.Get("app.config.remote").String()
+		if rawurl == "" {
+			return fmt.Errorf("%w: app.config.remote is not set", ErrRemote)
 		}
-		value := args.Arg(1).String()
-
-		if err := sess.Settings().Validate(key, value); err != nil {
+		remote, err := NewRemote(
+			rawurl,
+			sess.Get("app.config.remote_key").String(),
+			sess.Get("app.config.remote_interval").Duration(),
+			filepath.Join(sess.Get("app.fs.path.profile").String(), RemoteFilename),
+		)
+		if err != nil {
 			return err
 		}
+		changed, err := remote.Refresh(sess)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			sess.Log().Ok("remote configuration is up to date", slog.String("url", rawurl))
+			return nil
+		}
+		sess.Log().Ok("remote configuration updated, it takes effect on next start", slog.String("url", rawurl))
+		return nil
+	})
 
-		profileFilePath := filepath.Join(sess.Get("app.fs.path.profile").String(), "profile.preferences")
-		internal.Log(sess.Log(), "profile.save",
-			slog.String("profile", sess.Get("app.profile.name").String()),
-			slog.String("file", profileFilePath),
-		)
+	return cmd
+}
 
-		profile := sess.Settings().All()
-		pd := vars.Map{}
-		for _, setting := range profile {
-			if setting.Persistent() || setting.UserDefined() {
-				if setting.Key() == key {
-					if err := pd.Store(setting.Key(), value); err != nil {
-						return err
-					}
-				} else if setting.IsSet() {
-					if err := pd.Store(setting.Key(), setting.Value().String()); err != nil {
-						return err
-					}
-				}
-			}
+func configAddons(addons []addon.Info) *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "addons",
+		Description: "List loaded addons and permissions they declare",
+	})
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		table := textfmt.Table{
+			Title:      "Addons",
+			WithHeader: true,
 		}
-		pddata := pd.ToKeyValSlice()
-		var dest bytes.Buffer
-		enc := gob.NewEncoder(&dest)
-		if err := enc.Encode(pddata); err != nil {
-			return err
+		table.AddRow("SLUG", "VERSION", "MODULE", "PERMISSIONS")
+		for _, info := range addons {
+			perms := info.Permissions.String()
+			if perms == "" {
+				perms = "-"
+			}
+			table.AddRow(info.Slug, info.Version.String(), info.Module, perms)
 		}
+		sess.Log().Println(table.String())
+		return nil
+	})
+
+	return cmd
+}
+
+func configSet() *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "set",
+		Description: "Set a setting value",
+		MinArgs:     2,
+	})
 
-		if err := os.WriteFile(profileFilePath, dest.Bytes(), 0600); err != nil {
+	cmd.Usage("--profile=<profile-name> [--dry-run [--json]] <key> <value>")
+
+	cmd.WithFlags(
+		varflag.BoolFunc("dry-run", false, "Only show changes to profile preferences without saving them"),
+		varflag.BoolFunc("json", false, "Print dry run changes as JSON"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		key, value := args.Arg(0).String(), args.Arg(1).String()
+		if !args.Flag("dry-run").Var().Bool() {
+			return SaveSetting(sess, key, value)
+		}
+		changes, err := PreviewSetting(sess, key, value)
+		if err != nil {
 			return err
 		}
-
-		internal.Log(
-			sess.Log(),
-			"saved profile",
-			slog.String("profile", sess.Get("app.profile.name").String()),
-			slog.String("file", profileFilePath),
-		)
+		switch {
+		case args.Flag("json").Var().Bool():
+			data, err := changes.JSON()
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+		case len(changes) == 0:
+			sess.Log().Println("no changes")
+		case diff.ColorEnabled(os.Stdout):
+			fmt.Print(changes.Colorized())
+		default:
+			fmt.Print(changes.String())
+		}
 		return nil
 	})
 
 	return cmd
 }
 
+// SaveSetting validates value of setting key and saves it
+// to the preferences of current profile.
+func SaveSetting(sess *session.Context, key, value string) error {
+	if !sess.Settings().Has(key) {
+		return fmt.Errorf("setting %q does not exist", key)
+	}
+	if Managed(sess, key) {
+		return fmt.Errorf("setting %q is managed by system policy", key)
+	}
+
+	if err := sess.Settings().Validate(key, value); err != nil {
+		return err
+	}
+
+	profileFilePath := filepath.Join(sess.Get("app.fs.path.profile").String(), "profile.preferences")
+	internal.Log(sess.Log(), "profile.save",
+		slog.String("profile", sess.Get("app.profile.name").String()),
+		slog.String("file", profileFilePath),
+	)
+
+	// only profile layer is saved, values from other configuration
+	// layers must not leak into profile preferences.
+	if err := UpdatePreferences(profileFilePath, sess.Get("app.config.backups").Int(), map[string]string{key: value}); err != nil {
+		return err
+	}
+
+	internal.Log(
+		sess.Log(),
+		"saved profile",
+		slog.String("profile", sess.Get("app.profile.name").String()),
+		slog.String("file", profileFilePath),
+	)
+	return nil
+}
+
+// PreviewSetting validates value of setting key and returns changes
+// SaveSetting would make to the preferences of current profile.
+func PreviewSetting(sess *session.Context, key, value string) (diff.Changes, error) {
+	if !sess.Settings().Has(key) {
+		return nil, fmt.Errorf("setting %q does not exist", key)
+	}
+	if Managed(sess, key) {
+		return nil, fmt.Errorf("setting %q is managed by system policy", key)
+	}
+
+	if err := sess.Settings().Validate(key, value); err != nil {
+		return nil, err
+	}
+
+	profileFilePath := filepath.Join(sess.Get("app.fs.path.profile").String(), "profile.preferences")
+	prefs, err := ReadPreferences(profileFilePath)
+	if err != nil {
+		return nil, err
+	}
+	next := make(map[string]string, len(prefs)+1)
+	for k, v := range prefs {
+		next[k] = v
+	}
+	next[key] = value
+	return diff.Values(prefs, next), nil
+}
+
 func configGet() *command.Command {
 	cmd := command.New(command.Config{
 		Name:        "get",
@@ -230,10 +346,18 @@ func configGet() *command.Command {
 		MinArgs:     1,
 	})
 
-	cmd.Usage("--profile=<profile-name>")
+	cmd.Usage("--profile=<profile-name> [--explain] <key>")
+
+	cmd.WithFlags(
+		varflag.BoolFunc("explain", false, "show configuration layer setting value came from and values it overrides"),
+	)
 
 	cmd.Do(func(sess *session.Context, args action.Args) error {
-		key := sess.Get(args.Arg(0).String())
+		name := args.Arg(0).String()
+		if args.Flag("explain").Var().Bool() {
+			return explainSetting(sess, name)
+		}
+		key := sess.Get(name)
 		if key != vars.EmptyVariable {
 			fmt.Println(key.String())
 		}
@@ -243,6 +367,40 @@ func configGet() *command.Command {
 	return cmd
 }
 
+func explainSetting(sess *session.Context, key string) error {
+	if !sess.Settings().Has(key) {
+		return fmt.Errorf("setting %q does not exist", key)
+	}
+	setting := sess.Settings().Get(key)
+
+	table := textfmt.Table{
+		Title:      fmt.Sprintf("Setting %s", key),
+		WithHeader: true,
+	}
+	table.AddRow("SOURCE", "VALUE", "PATH", "")
+
+	var record session.StartupSetting
+	if report := sess.StartupReport(); report != nil {
+		record, _ = report.Setting(key)
+	}
+	if record.Source == "" {
+		record.Source = string(SourceDefault)
+	}
+	state := "active"
+	if record.Source == string(SourcePolicy) {
+		state = "managed"
+	}
+	table.AddRow(record.Source, setting.Value().String(), record.Path, state)
+	for _, o := range record.Overridden {
+		table.AddRow(o.Source, o.Value, o.Path, "overridden")
+	}
+	if record.Source != string(SourceDefault) {
+		table.AddRow(string(SourceDefault), setting.Default().String(), "", "overridden")
+	}
+	sess.Log().Println(table.String())
+	return nil
+}
+
 func configReset() *command.Command {
 	cmd := command.New(command.Config{
 		Name:        "reset",
@@ -262,7 +420,7 @@ func configReset() *command.Command {
 				slog.String("file", profileFilePath),
 			)
 
-			if err := os.WriteFile(profileFilePath, []byte{}, 0600); err != nil {
+			if err := replacePreferences(profileFilePath, sess.Get("app.config.backups").Int(), nil); err != nil {
 				return err
 			}
 
@@ -279,6 +437,9 @@ func configReset() *command.Command {
 		if !sess.Settings().Has(key) {
 			return fmt.Errorf("setting %q does not exist", key)
 		}
+		if Managed(sess, key) {
+			return fmt.Errorf("setting %q is managed by system policy", key)
+		}
 
 		profileFilePath := filepath.Join(sess.Get("app.fs.path.profile").String(), "profile.preferences")
 		internal.Log(sess.Log(), "profile.save",
@@ -286,27 +447,12 @@ func configReset() *command.Command {
 			slog.String("file", profileFilePath),
 		)
 
-		profile := sess.Settings().All()
-		pd := vars.Map{}
-		for _, setting := range profile {
-			if setting.Persistent() || setting.UserDefined() {
-				if setting.Key() == key {
-					continue
-				} else if setting.IsSet() {
-					if err := pd.Store(setting.Key(), setting.Value().String()); err != nil {
-						return err
-					}
-				}
-			}
-		}
-		pddata := pd.ToKeyValSlice()
-		var dest bytes.Buffer
-		enc := gob.NewEncoder(&dest)
-		if err := enc.Encode(pddata); err != nil {
+		prefs, err := ReadPreferences(profileFilePath)
+		if err != nil {
 			return err
 		}
-
-		if err := os.WriteFile(profileFilePath, dest.Bytes(), 0600); err != nil {
+		delete(prefs, key)
+		if err := savePreferences(profileFilePath, sess.Get("app.config.backups").Int(), prefs); err != nil {
 			return err
 		}
 
@@ -27,6 +27,32 @@ type Settings struct {
 	// the -x-prod flag, which is added by the devel package when the AllowProd option is enabled.
 	// This allows to load the standard profile even when running in development mode e.g. go run.
 	EnableProfileDevel settings.Bool `default:"false" desc:"Enable profile development mode."`
+
+	// Strict fails initialization when system config, profile preferences,
+	// project config or environment contain keys which are not in the
+	// settings blueprint, instead of silently ignoring them.
+	Strict settings.Bool `default:"false" desc:"Fail on unknown settings keys in configuration layers and environment."`
+
+	// Backups is number of previous versions of profile preferences kept
+	// when preferences are saved. Corrupted preferences are restored from
+	// the most recent valid backup at startup.
+	Backups settings.Uint `default:"3" desc:"Number of profile preferences backups kept for recovery."`
+
+	// Encrypt encrypts profile preferences, their backups and compiled
+	// profile cache at rest with AES-256-GCM. Key is derived from secret
+	// kept in OS keyring or passphrase which is prompted on terminal or
+	// given with environment variable, see ProfileKey. Existing profile
+	// is encrypted on next start, and decrypted when Encrypt is disabled.
+	Encrypt settings.Bool `default:"false" desc:"Encrypt profile preferences at rest."`
+
+	// Remote is HTTPS URL of centrally managed configuration document
+	// merged above profile and project config and below environment
+	// and flags. Document must be signed with key matching RemoteKey,
+	// it is fetched again when cached copy is older than RemoteInterval
+	// or with config refresh command, see Remote.
+	Remote         settings.String   `desc:"HTTPS URL of remote configuration."`
+	RemoteKey      settings.String   `desc:"Base64 encoded Ed25519 public key verifying remote configuration."`
+	RemoteInterval settings.Duration `default:"1h" desc:"Minimum interval between remote configuration fetches."`
 }
 
 func (s Settings) Blueprint() (*settings.Blueprint, error) {
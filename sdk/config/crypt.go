@@ -0,0 +1,243 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/happy-sdk/happy/sdk/internal/crypt"
+)
+
+// encMagic starts encrypted profile files, it is followed by salt,
+// nonce and AES-256-GCM sealed file content.
+const encMagic = "HAPPYPRF1"
+
+const (
+	nonceSize  = 12
+	headerSize = len(encMagic) + crypt.SaltSize + nonceSize
+)
+
+var (
+	// ErrLocked is returned when encrypted profile file is read before
+	// key of its profile is given with Unlock.
+	ErrLocked = fmt.Errorf("%w: profile is encrypted and locked", ErrProfile)
+	// ErrKey is returned when encrypted profile file can not be
+	// decrypted with the key of its profile.
+	ErrKey = fmt.Errorf("%w: profile can not be decrypted, wrong key or damaged file", ErrProfile)
+)
+
+// Key encrypts profile files at rest. File key is derived from secret
+// and salt stored in the file, derived keys are kept so that secret is
+// stretched once per salt rather than on every read and write.
+type Key struct {
+	mu     sync.Mutex
+	secret []byte
+	// salt is used to encrypt files, it is taken from first decrypted
+	// file or generated on first write.
+	salt  []byte
+	aeads map[string]cipher.AEAD
+}
+
+// NewKey returns key of profile encrypted with secret.
+func NewKey(secret []byte) *Key {
+	return &Key{
+		secret: append([]byte(nil), secret...),
+		aeads:  make(map[string]cipher.AEAD),
+	}
+}
+
+func (k *Key) seal(data []byte) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.salt == nil {
+		salt := make([]byte, crypt.SaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrProfile, err.Error())
+		}
+		k.salt = salt
+	}
+	aead, err := k.aead(k.salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrProfile, err.Error())
+	}
+	out := make([]byte, 0, headerSize+len(data)+aead.Overhead())
+	out = append(out, encMagic...)
+	out = append(out, k.salt...)
+	out = append(out, nonce...)
+	// header is authenticated so that it can not be altered.
+	return aead.Seal(out, nonce, data, out), nil
+}
+
+func (k *Key) open(data []byte) ([]byte, error) {
+	if len(data) < headerSize {
+		return nil, ErrKey
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	salt := data[len(encMagic) : len(encMagic)+crypt.SaltSize]
+	aead, err := k.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+	out, err := aead.Open(nil, data[len(encMagic)+crypt.SaltSize:headerSize], data[headerSize:], data[:headerSize])
+	if err != nil {
+		return nil, ErrKey
+	}
+	if k.salt == nil {
+		k.salt = append([]byte(nil), salt...)
+	}
+	return out, nil
+}
+
+// aead returns cipher for salt, caller must hold k.mu.
+func (k *Key) aead(salt []byte) (cipher.AEAD, error) {
+	if aead, ok := k.aeads[string(salt)]; ok {
+		return aead, nil
+	}
+	block, err := aes.NewCipher(crypt.Key(k.secret, salt))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrProfile, err.Error())
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrProfile, err.Error())
+	}
+	k.aeads[string(salt)] = aead
+	return aead, nil
+}
+
+type unlocked struct {
+	key     *Key
+	encrypt bool
+}
+
+// profileKeys holds keys of unlocked profile directories.
+var profileKeys = struct {
+	sync.RWMutex
+	dirs map[string]unlocked
+}{dirs: make(map[string]unlocked)}
+
+// Unlock sets key of profile files in directory dir. Encrypted files
+// are decrypted with key and when encrypt is true files are encrypted
+// when they are written. Profile files are read and written with key
+// of their directory by all functions of this package.
+func Unlock(dir string, key *Key, encrypt bool) {
+	profileKeys.Lock()
+	defer profileKeys.Unlock()
+	profileKeys.dirs[filepath.Clean(dir)] = unlocked{key: key, encrypt: encrypt}
+}
+
+func keyOf(path string) (unlocked, bool) {
+	profileKeys.RLock()
+	defer profileKeys.RUnlock()
+	u, ok := profileKeys.dirs[filepath.Dir(filepath.Clean(path))]
+	return u, ok
+}
+
+// encrypts reports whether profile file at path is encrypted when it
+// is written.
+func encrypts(path string) bool {
+	u, ok := keyOf(path)
+	return ok && u.encrypt
+}
+
+func encrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encMagic))
+}
+
+// Encrypted reports whether profile file at path is encrypted, missing
+// file is not.
+func Encrypted(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%w: %s", ErrProfile, err.Error())
+	}
+	defer f.Close()
+	head := make([]byte, len(encMagic))
+	n, _ := f.Read(head)
+	return encrypted(head[:n]), nil
+}
+
+// DecryptFile returns content of profile file at path from data read
+// from it, data of files which are not encrypted is returned as is.
+func DecryptFile(path string, data []byte) ([]byte, error) {
+	if !encrypted(data) {
+		return data, nil
+	}
+	u, ok := keyOf(path)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrLocked, path)
+	}
+	out, err := u.key.open(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, path)
+	}
+	return out, nil
+}
+
+// EncryptFile returns data to be written to profile file at path, it is
+// encrypted when profile directory was unlocked for encryption.
+func EncryptFile(path string, data []byte) ([]byte, error) {
+	u, ok := keyOf(path)
+	if !ok || !u.encrypt {
+		return data, nil
+	}
+	return u.key.seal(data)
+}
+
+// ConvertPreferences rewrites preferences file at path and up to
+// backups of its backups encrypted or decrypted as selected with Unlock
+// for their directory. Profile cache is removed so that it is compiled
+// again in the same form.
+func ConvertPreferences(path string, backups int) error {
+	files := []string{path}
+	for n := 1; n <= backups; n++ {
+		files = append(files, BackupPath(path, n))
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("%w: %s", ErrProfile, err.Error())
+		}
+		if encrypted(data) == encrypts(file) {
+			continue
+		}
+		plain, err := DecryptFile(file, data)
+		if err != nil {
+			return err
+		}
+		out, err := EncryptFile(file, plain)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(file, out, 0600); err != nil {
+			return fmt.Errorf("%w: %s", ErrProfile, err.Error())
+		}
+	}
+	cache := filepath.Join(filepath.Dir(path), CacheFilename)
+	if err := os.Remove(cache); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrProfile, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+)
+
+func TestEncryptedPreferences(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "profile.preferences")
+	data, err := EncodePreferences(map[string]string{"app.theme": "dark"})
+	testutils.NoError(t, err)
+	testutils.NoError(t, os.WriteFile(path, data, 0600))
+	testutils.NoError(t, os.WriteFile(filepath.Join(dir, CacheFilename), []byte("plain"), 0600))
+
+	key := NewKey([]byte("secret"))
+	Unlock(dir, key, true)
+	testutils.NoError(t, ConvertPreferences(path, 3))
+	enc, err := Encrypted(path)
+	testutils.NoError(t, err)
+	testutils.True(t, enc, "preferences must be encrypted")
+	_, err = os.Stat(filepath.Join(dir, CacheFilename))
+	testutils.ErrorIs(t, err, os.ErrNotExist)
+
+	testutils.NoError(t, UpdatePreferences(path, 3, map[string]string{"app.size": "12"}))
+	prefs, err := ReadPreferences(path)
+	testutils.NoError(t, err)
+	testutils.EqualAny(t, map[string]string{"app.theme": "dark", "app.size": "12"}, prefs)
+	enc, err = Encrypted(BackupPath(path, 1))
+	testutils.NoError(t, err)
+	testutils.True(t, enc, "backup must be encrypted")
+
+	// file which can not be decrypted must not be taken as corrupted.
+	other := filepath.Join(t.TempDir(), "profile.preferences")
+	raw, err := os.ReadFile(path)
+	testutils.NoError(t, err)
+	testutils.NoError(t, os.WriteFile(other, raw, 0600))
+	_, err = ReadPreferences(other)
+	testutils.ErrorIs(t, err, ErrLocked)
+	Unlock(filepath.Dir(other), NewKey([]byte("wrong")), true)
+	_, err = ReadPreferences(other)
+	testutils.ErrorIs(t, err, ErrKey)
+	testutils.False(t, errors.Is(err, ErrCorrupted), "wrong key is not corruption")
+
+	Unlock(dir, key, false)
+	testutils.NoError(t, ConvertPreferences(path, 3))
+	enc, err = Encrypted(path)
+	testutils.NoError(t, err)
+	testutils.False(t, enc, "preferences must be decrypted")
+	prefs, err = ReadPreferences(path)
+	testutils.NoError(t, err)
+	testutils.EqualAny(t, map[string]string{"app.theme": "dark", "app.size": "12"}, prefs)
+}
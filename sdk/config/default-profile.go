@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrProfile = errors.New("profile")
+
+// DefaultProfileFilename is name of the reference copy of embedded
+// default profile written to application config directory.
+const DefaultProfileFilename = "default-profile.toml"
+
+// ParseProfile parses profile preferences from TOML document. Tables
+// and dotted keys form setting keys e.g. enabled = true in [app.stats]
+// table sets app.stats.enabled. Strings, numbers, booleans and single
+// line arrays are supported, array values are joined with "|" as
+// settings.StringSlice expects.
+func ParseProfile(data []byte) (map[string]string, error) {
+	prefs := make(map[string]string)
+	var table string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for ln := 1; scanner.Scan(); ln++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("%w: line %d: invalid table %s", ErrProfile, ln, line)
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, rawval, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d: expected key = value", ErrProfile, ln)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		if key == "" {
+			return nil, fmt.Errorf("%w: line %d: empty key", ErrProfile, ln)
+		}
+		if table != "" {
+			key = table + "." + key
+		}
+		val, err := parseValue(strings.TrimSpace(rawval))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %s: %s", ErrProfile, ln, key, err.Error())
+		}
+		if _, ok := prefs[key]; ok {
+			return nil, fmt.Errorf("%w: line %d: duplicated key %s", ErrProfile, ln, key)
+		}
+		prefs[key] = val
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrProfile, err.Error())
+	}
+	return prefs, nil
+}
+
+// EncodePreferences encodes preferences in profile.preferences file format.
+func EncodePreferences(prefs map[string]string) ([]byte, error) {
+	keys := make([]string, 0, len(prefs))
+	for key := range prefs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	data := make([]string, 0, len(keys))
+	for _, key := range keys {
+		data = append(data, key+"="+prefs[key])
+	}
+	var dest bytes.Buffer
+	if err := gob.NewEncoder(&dest).Encode(data); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrProfile, err.Error())
+	}
+	return dest.Bytes(), nil
+}
+
+func parseValue(raw string) (string, error) {
+	switch {
+	case raw == "":
+		return "", errors.New("missing value")
+	case strings.HasPrefix(raw, `"""`), strings.HasPrefix(raw, "'''"):
+		return "", errors.New("multi-line strings are not supported")
+	case strings.HasPrefix(raw, `"`):
+		return strconv.Unquote(raw)
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return "", errors.New("unterminated literal string")
+		}
+		return raw[1 : len(raw)-1], nil
+	case strings.HasPrefix(raw, "["):
+		if !strings.HasSuffix(raw, "]") {
+			return "", errors.New("arrays must be on single line")
+		}
+		var vals []string
+		for _, elem := range splitArray(raw[1 : len(raw)-1]) {
+			if elem = strings.TrimSpace(elem); elem == "" {
+				continue
+			}
+			val, err := parseValue(elem)
+			if err != nil {
+				return "", err
+			}
+			vals = append(vals, val)
+		}
+		return strings.Join(vals, "|"), nil
+	case raw == "true", raw == "false":
+		return raw, nil
+	}
+	// numbers, dates and times are passed as is
+	return strings.ReplaceAll(raw, "_", ""), nil
+}
+
+// splitArray splits array elements on commas outside of strings.
+func splitArray(s string) []string {
+	var (
+		elems []string
+		quote rune
+		start int
+	)
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote && (quote == '\'' || i == 0 || s[i-1] != '\\') {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			elems = append(elems, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(elems, s[start:])
+}
+
+// stripComment removes comment starting with # outside of strings.
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote && (quote == '\'' || i == 0 || line[i-1] != '\\') {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	errNoKeyring  = errors.New("keyring is not available")
+	errNoSecret   = errors.New("secret not found")
+	errNoTerminal = errors.New("terminal is not available")
+)
+
+// PassphraseEnv returns name of environment variable holding passphrase
+// of encrypted profiles of application slug, e.g. MYAPP_PROFILE_PASSPHRASE.
+func PassphraseEnv(slug string) string {
+	return EnvName(slug, "profile.passphrase")
+}
+
+// ProfileKey returns key of encrypted profile of application slug.
+// Secret is taken from environment variable named by PassphraseEnv,
+// from OS keyring or passphrase is prompted on terminal. When create
+// is true the profile is not encrypted yet, then random secret is
+// stored in OS keyring when it is available and new passphrase is
+// prompted twice otherwise.
+func ProfileKey(slug, profile string, create bool) (*Key, error) {
+	if passphrase := os.Getenv(PassphraseEnv(slug)); passphrase != "" {
+		return NewKey([]byte(passphrase)), nil
+	}
+
+	account := "profile:" + profile
+	secret, err := keyringGet(slug, account)
+	switch {
+	case err == nil:
+		return NewKey(secret), nil
+	case errors.Is(err, errNoSecret) && create:
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrProfile, err.Error())
+		}
+		secret = []byte(hex.EncodeToString(raw))
+		if err := keyringSet(slug, account, secret); err == nil {
+			return NewKey(secret), nil
+		}
+	}
+
+	prompt := fmt.Sprintf("Passphrase of %s profile %s: ", slug, profile)
+	passphrase, err := readPassphrase(prompt)
+	if err == nil && create {
+		var again []byte
+		if again, err = readPassphrase("Repeat passphrase: "); err == nil && !bytes.Equal(passphrase, again) {
+			return nil, fmt.Errorf("%w: passphrases do not match", ErrProfile)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: profile %s is encrypted, no key in OS keyring and passphrase can not be prompted (%s), set %s",
+			ErrProfile, profile, err.Error(), PassphraseEnv(slug))
+	}
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("%w: empty passphrase", ErrProfile)
+	}
+	return NewKey(passphrase), nil
+}
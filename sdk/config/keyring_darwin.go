@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// keyringGet looks up secret from login keychain.
+func keyringGet(service, account string) ([]byte, error) {
+	cmd := exec.Command("/usr/bin/security", "find-generic-password", "-s", service, "-a", account, "-w")
+	out, err := cmd.Output()
+	if err != nil {
+		// security exits with 44 when item is not found.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+			return nil, errNoSecret
+		}
+		return nil, errNoKeyring
+	}
+	if out = bytes.TrimSpace(out); len(out) == 0 {
+		return nil, errNoSecret
+	}
+	return out, nil
+}
+
+// keyringSet stores secret to login keychain, command is passed on
+// stdin of interactive mode so that secret is not in process arguments.
+func keyringSet(service, account string, secret []byte) error {
+	cmd := exec.Command("/usr/bin/security", "-i")
+	cmd.Stdin = bytes.NewBufferString(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		strconv.Quote(service), strconv.Quote(account), strconv.Quote(string(secret))))
+	return cmd.Run()
+}
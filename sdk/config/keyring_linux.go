@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+)
+
+// keyringGet looks up secret from Secret Service with secret-tool.
+func keyringGet(service, account string) ([]byte, error) {
+	bin, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, errNoKeyring
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(bin, "lookup", "service", service, "account", account)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// secret-tool exits with 1 without message when secret is not found.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && stderr.Len() == 0 {
+			return nil, errNoSecret
+		}
+		return nil, errNoKeyring
+	}
+	if out = bytes.TrimSpace(out); len(out) == 0 {
+		return nil, errNoSecret
+	}
+	return out, nil
+}
+
+// keyringSet stores secret to Secret Service, secret is passed on stdin.
+func keyringSet(service, account string, secret []byte) error {
+	bin, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return errNoKeyring
+	}
+	cmd := exec.Command(bin, "store", "--label="+service+" "+account, "service", service, "account", account)
+	cmd.Stdin = bytes.NewReader(secret)
+	return cmd.Run()
+}
@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+//go:build !linux && !darwin
+
+package config
+
+func keyringGet(service, account string) ([]byte, error) {
+	return nil, errNoKeyring
+}
+
+func keyringSet(service, account string, secret []byte) error {
+	return errNoKeyring
+}
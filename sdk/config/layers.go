@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Source is configuration layer which provides setting value.
+type Source string
+
+// Configuration layers in order of precedence, value from later layer
+// overrides value from earlier one. Remote is centrally managed
+// configuration, see Remote, and policy forces values set by
+// administrator, see ReadPolicy.
+const (
+	SourceDefault Source = "default"
+	SourceSystem  Source = "system"
+	SourceProfile Source = "profile"
+	SourceProject Source = "project"
+	SourceRemote  Source = "remote"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+	SourcePolicy  Source = "policy"
+)
+
+// Layer is set of setting values provided by single source.
+type Layer struct {
+	Source Source
+	// Path is file or variable values were read from.
+	Path   string
+	Values map[string]string
+}
+
+// Value is resolved setting value with its provenance.
+type Value struct {
+	Key    string
+	Value  string
+	Source Source
+	Path   string
+	// Overridden are values from lower layers overridden by this value,
+	// nearest first.
+	Overridden []Value
+}
+
+// Resolver resolves setting values from configuration layers.
+type Resolver struct {
+	layers []Layer
+}
+
+// Add adds layer overriding values of previously added layers.
+func (r *Resolver) Add(layer Layer) {
+	if len(layer.Values) == 0 {
+		return
+	}
+	r.layers = append(r.layers, layer)
+}
+
+// Layers returns added layers in order of precedence.
+func (r *Resolver) Layers() []Layer {
+	return append([]Layer(nil), r.layers...)
+}
+
+// Values returns resolved values.
+func (r *Resolver) Values() map[string]string {
+	values := make(map[string]string)
+	for _, layer := range r.layers {
+		for key, val := range layer.Values {
+			values[key] = val
+		}
+	}
+	return values
+}
+
+// Explain returns resolved value of key with provenance, it reports
+// false when no layer provides the key.
+func (r *Resolver) Explain(key string) (Value, bool) {
+	var (
+		res   Value
+		found bool
+	)
+	for i := len(r.layers) - 1; i >= 0; i-- {
+		val, ok := r.layers[i].Values[key]
+		if !ok {
+			continue
+		}
+		v := Value{Key: key, Value: val, Source: r.layers[i].Source, Path: r.layers[i].Path}
+		if !found {
+			res, found = v, true
+			continue
+		}
+		res.Overridden = append(res.Overridden, v)
+	}
+	return res, found
+}
+
+// SystemFile returns path of system wide configuration file of
+// application, /etc/<slug>/config.toml or %ProgramData%\<slug>\config.toml
+// on Windows.
+func SystemFile(slug string) string {
+	return filepath.Join(systemDir(slug), "config.toml")
+}
+
+// systemDir returns directory of system wide files of application.
+func systemDir(slug string) string {
+	if runtime.GOOS == "windows" {
+		dir := os.Getenv("ProgramData")
+		if dir == "" {
+			dir = `C:\ProgramData`
+		}
+		return filepath.Join(dir, slug)
+	}
+	return filepath.Join("/etc", slug)
+}
+
+// ProjectFilename returns name of project local configuration file.
+func ProjectFilename(slug string) string {
+	return "." + slug + ".toml"
+}
+
+// FindProjectFile looks up project local configuration file starting
+// from dir and walking up to the root, it returns empty string when
+// file is not found.
+func FindProjectFile(slug, dir string) string {
+	name := ProjectFilename(slug)
+	for {
+		p := filepath.Join(dir, name)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ReadLayer reads TOML configuration file at path as layer of source,
+// missing file results in empty layer.
+func ReadLayer(source Source, path string) (Layer, error) {
+	layer := Layer{Source: source, Path: path}
+	if path == "" {
+		return layer, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return layer, nil
+		}
+		return layer, fmt.Errorf("%w: %s", ErrProfile, err.Error())
+	}
+	if layer.Values, err = ParseProfile(data); err != nil {
+		return layer, fmt.Errorf("%w: %s", err, path)
+	}
+	return layer, nil
+}
+
+// EnvName returns name of environment variable overriding setting key,
+// e.g. MYAPP_APP_LOGGING_LEVEL for app.logging.level of myapp.
+func EnvName(slug, key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, slug+"_"+key)
+}
+
+// EnvLayer returns layer of settings keys set with environment variables.
+func EnvLayer(slug string, keys []string) Layer {
+	layer := Layer{Source: SourceEnv, Values: make(map[string]string)}
+	for _, key := range keys {
+		if val, ok := os.LookupEnv(EnvName(slug, key)); ok {
+			layer.Values[key] = val
+		}
+	}
+	return layer
+}
+
+// FlagLayer returns layer of key=value pairs given with --set flag.
+func FlagLayer(pairs []string) (Layer, error) {
+	layer := Layer{Source: SourceFlag, Path: "--set", Values: make(map[string]string)}
+	for _, pair := range pairs {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return layer, fmt.Errorf("%w: invalid --set %q expected key=value", ErrProfile, pair)
+		}
+		layer.Values[key] = val
+	}
+	return layer, nil
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+const (
+	// TelemetryKey is setting forced off by policy disable_telemetry.
+	TelemetryKey = "app.telemetry.enabled"
+	// UpdateKey is setting forced on by policy disable_update.
+	UpdateKey = "app.update.disabled"
+)
+
+// Policy is system policy set by administrator to lock down
+// application. Settings it forces override all other configuration
+// layers and can not be changed by user.
+//
+// Policy file is TOML document e.g.
+//
+//	disable_telemetry = true
+//	disable_update = true
+//	disable_commands = ["update", "config set"]
+//	disable_addons = ["docs"]
+//
+//	[settings]
+//	app.stats.enabled = false
+type Policy struct {
+	// Path is file policy was read from.
+	Path string
+	// Settings are forced setting values.
+	Settings map[string]string
+	// Commands are disabled commands given as space separated paths
+	// of command names below root command.
+	Commands []string
+	// Addons are slugs of disabled addons.
+	Addons []string
+	// DisableTelemetry disables usage telemetry.
+	DisableTelemetry bool
+	// DisableUpdate disables application update checks.
+	DisableUpdate bool
+}
+
+// PolicyFile returns path of system policy file of application,
+// /etc/<slug>/policy.toml or %ProgramData%\<slug>\policy.toml on Windows.
+// File is only read and it should be writable only by administrator.
+func PolicyFile(slug string) string {
+	return filepath.Join(systemDir(slug), "policy.toml")
+}
+
+// ReadPolicy reads policy file at path, missing file results in empty
+// policy. Unknown policy keys are reported as error so that mistakes
+// in policy do not go unnoticed.
+func ReadPolicy(path string) (*Policy, error) {
+	p := &Policy{Path: path, Settings: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return p, nil
+		}
+		return nil, fmt.Errorf("%w: policy: %s", ErrProfile, err.Error())
+	}
+	values, err := ParseProfile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: policy %s", err, path)
+	}
+	for key, val := range values {
+		if skey, ok := strings.CutPrefix(key, "settings."); ok {
+			p.Settings[skey] = val
+			continue
+		}
+		switch key {
+		case "disable_telemetry":
+			p.DisableTelemetry, err = strconv.ParseBool(val)
+		case "disable_update":
+			p.DisableUpdate, err = strconv.ParseBool(val)
+		case "disable_commands":
+			p.Commands = policyList(val)
+		case "disable_addons":
+			p.Addons = policyList(val)
+		default:
+			return nil, fmt.Errorf("%w: policy %s: unknown key %s", ErrProfile, path, key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: policy %s: %s: %s", ErrProfile, path, key, err.Error())
+		}
+	}
+	return p, nil
+}
+
+// Layer returns configuration layer of settings forced by policy,
+// keys are settings keys of application schema. Settings disabled with
+// disable_telemetry and disable_update are forced only when application
+// has them.
+func (p *Policy) Layer(keys []string) Layer {
+	layer := Layer{Source: SourcePolicy, Path: p.Path, Values: make(map[string]string, len(p.Settings))}
+	for key, val := range p.Settings {
+		layer.Values[key] = val
+	}
+	if p.DisableTelemetry && slices.Contains(keys, TelemetryKey) {
+		layer.Values[TelemetryKey] = "false"
+	}
+	if p.DisableUpdate && slices.Contains(keys, UpdateKey) {
+		layer.Values[UpdateKey] = "true"
+	}
+	return layer
+}
+
+// AddonDisabled reports whether addon with slug is disabled.
+func (p *Policy) AddonDisabled(slug string) bool {
+	return slices.Contains(p.Addons, slug)
+}
+
+// Managed reports whether setting key is forced by system policy,
+// such setting can not be changed by user.
+func Managed(sess *session.Context, key string) bool {
+	report := sess.StartupReport()
+	if report == nil {
+		return false
+	}
+	s, ok := report.Setting(key)
+	return ok && s.Source == string(SourcePolicy)
+}
+
+// policyList splits array value joined by ParseProfile.
+func policyList(val string) []string {
+	var list []string
+	for _, v := range strings.Split(val, "|") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
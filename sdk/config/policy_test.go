@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+)
+
+func TestReadPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		keys      []string
+		want      map[string]string
+		wantCmds  []string
+		wantAddon string
+		wantErr   bool
+	}{
+		{
+			name: "missing",
+			want: map[string]string{},
+		},
+		{
+			name: "lockdown",
+			data: `
+disable_telemetry = true
+disable_update = true
+disable_commands = ["update", "config set"]
+disable_addons = ["docs"]
+
+[settings]
+app.stats.enabled = false
+`,
+			keys: []string{TelemetryKey, UpdateKey, "app.stats.enabled"},
+			want: map[string]string{
+				TelemetryKey:        "false",
+				UpdateKey:           "true",
+				"app.stats.enabled": "false",
+			},
+			wantCmds:  []string{"update", "config set"},
+			wantAddon: "docs",
+		},
+		{
+			// settings application does not have are not forced.
+			name: "no telemetry",
+			data: "disable_telemetry = true\n",
+			want: map[string]string{},
+		},
+		{
+			name:    "unknown key",
+			data:    "disable_everything = true\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid bool",
+			data:    `disable_update = "yes"` + "\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.toml")
+			if tt.data != "" {
+				testutils.NoError(t, os.WriteFile(path, []byte(tt.data), 0600))
+			}
+			p, err := ReadPolicy(path)
+			if tt.wantErr {
+				testutils.ErrorIs(t, err, ErrProfile)
+				return
+			}
+			if !testutils.NoError(t, err) {
+				return
+			}
+			layer := p.Layer(tt.keys)
+			testutils.Equal(t, SourcePolicy, layer.Source)
+			testutils.EqualAny(t, tt.want, layer.Values)
+			testutils.EqualAny(t, tt.wantCmds, p.Commands)
+			if tt.wantAddon != "" {
+				testutils.True(t, p.AddonDisabled(tt.wantAddon), "addon disabled")
+			}
+		})
+	}
+}
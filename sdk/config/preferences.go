@@ -0,0 +1,224 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/vars"
+)
+
+// ErrCorrupted is returned when profile preferences file can not be
+// decoded.
+var ErrCorrupted = errors.New("corrupted profile preferences")
+
+// UpdatePreferences sets changed values in preferences file at path.
+// File is replaced atomically and it is not written at all when changes
+// do not modify it. Up to backups previous versions of the file are kept
+// to recover corrupted preferences, see RecoverPreferences.
+func UpdatePreferences(path string, backups int, changes map[string]string) error {
+	prefs, err := ReadPreferences(path)
+	if err != nil {
+		return err
+	}
+	for key, val := range changes {
+		prefs[key] = val
+	}
+	return savePreferences(path, backups, prefs)
+}
+
+// savePreferences writes prefs to preferences file at path unless file
+// already contains them.
+func savePreferences(path string, backups int, prefs map[string]string) error {
+	data, err := EncodePreferences(prefs)
+	if err != nil {
+		return err
+	}
+	return replacePreferences(path, backups, data)
+}
+
+// replacePreferences replaces preferences file at path with data after
+// rotating its backups.
+func replacePreferences(path string, backups int, data []byte) error {
+	if current, err := os.ReadFile(path); err == nil {
+		plain, err := DecryptFile(path, current)
+		if err == nil && bytes.Equal(plain, data) && encrypted(current) == encrypts(path) {
+			return nil
+		}
+		if err == nil && backups > 0 {
+			// backup is kept in the form it was stored.
+			if _, err := decodePreferences(plain); err == nil {
+				if err := rotateBackups(path, backups, current); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	out, err := EncryptFile(path, data)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, out, 0600)
+}
+
+// BackupPath returns path of n-th backup of preferences file at path,
+// backup 1 is the most recent one.
+func BackupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d.bak", path, n)
+}
+
+// rotateBackups shifts backups of preferences file at path by one
+// dropping the oldest and stores data as the most recent backup.
+func rotateBackups(path string, backups int, data []byte) error {
+	if err := os.Remove(BackupPath(path, backups)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for n := backups - 1; n > 0; n-- {
+		if err := os.Rename(BackupPath(path, n), BackupPath(path, n+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return writeFile(BackupPath(path, 1), data, 0600)
+}
+
+// Recovery describes how corrupted preferences were recovered.
+type Recovery struct {
+	// Corrupted is path where corrupted preferences file was moved.
+	Corrupted string
+	// Backup is path of backup preferences were restored from, empty
+	// when no valid backup was found and defaults are used.
+	Backup string
+}
+
+// RecoverPreferences recovers corrupted preferences file at path. It
+// moves the corrupted file aside and restores the most recent of up to
+// backups backups which can be decoded. When there is none, empty
+// preferences are written so that default settings are used.
+func RecoverPreferences(path string, backups int) (map[string]string, Recovery, error) {
+	var rec Recovery
+	rec.Corrupted = fmt.Sprintf("%s.corrupted-%s", path, time.Now().Format("20060102T150405"))
+	if err := os.Rename(path, rec.Corrupted); err != nil {
+		return nil, rec, fmt.Errorf("%w: %s", ErrProfile, err.Error())
+	}
+	for n := 1; n <= backups; n++ {
+		backup := BackupPath(path, n)
+		data, err := os.ReadFile(backup)
+		if err != nil {
+			continue
+		}
+		plain, err := DecryptFile(backup, data)
+		if err != nil {
+			continue
+		}
+		prefs, err := decodePreferences(plain)
+		if err != nil {
+			continue
+		}
+		if err := writeFile(path, data, 0600); err != nil {
+			return nil, rec, fmt.Errorf("%w: %s", ErrProfile, err.Error())
+		}
+		rec.Backup = backup
+		return prefs, rec, nil
+	}
+	empty, err := EncryptFile(path, nil)
+	if err != nil {
+		return nil, rec, err
+	}
+	if err := writeFile(path, empty, 0600); err != nil {
+		return nil, rec, fmt.Errorf("%w: %s", ErrProfile, err.Error())
+	}
+	return make(map[string]string), rec, nil
+}
+
+// ReadPreferences reads profile preferences file, missing file has no
+// preferences. It returns ErrCorrupted when file can not be decoded and
+// ErrLocked or ErrKey when encrypted file can not be decrypted.
+func ReadPreferences(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return make(map[string]string), nil
+		}
+		return nil, err
+	}
+	// file which can not be decrypted is not corrupted, it must not
+	// be recovered from backup.
+	if data, err = DecryptFile(path, data); err != nil {
+		return nil, err
+	}
+	prefs, err := decodePreferences(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrCorrupted, path, err.Error())
+	}
+	return prefs, nil
+}
+
+func decodePreferences(b []byte) (map[string]string, error) {
+	prefs := make(map[string]string)
+	var data []string
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&data); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	pd, err := vars.ParseMapFromSlice(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range pd.All() {
+		prefs[v.Name()] = v.Value().String()
+	}
+	return prefs, nil
+}
+
+// writeFile writes data to file at path atomically, so that readers
+// and concurrently starting instances never see partially written file.
+// Data is flushed to disk before file is renamed so that crash does not
+// leave empty or truncated file behind.
+func writeFile(path string, data []byte, perm fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// syncDir flushes directory entries of dir so that rename survives
+// crash. It is best effort, directories can not be synced on all
+// platforms e.g. on Windows.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
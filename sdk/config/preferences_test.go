@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+)
+
+func TestUpdatePreferences(t *testing.T) {
+	tests := []struct {
+		name      string
+		prefs     map[string]string
+		changes   map[string]string
+		want      map[string]string
+		wantWrite bool
+	}{
+		{
+			name:      "new file",
+			changes:   map[string]string{"app.theme": "dark"},
+			want:      map[string]string{"app.theme": "dark"},
+			wantWrite: true,
+		},
+		{
+			name:      "merge",
+			prefs:     map[string]string{"app.theme": "dark", "app.size": "10"},
+			changes:   map[string]string{"app.size": "12"},
+			want:      map[string]string{"app.theme": "dark", "app.size": "12"},
+			wantWrite: true,
+		},
+		{
+			name:    "unchanged",
+			prefs:   map[string]string{"app.theme": "dark"},
+			changes: map[string]string{"app.theme": "dark"},
+			want:    map[string]string{"app.theme": "dark"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "profile.preferences")
+			old := time.Now().Add(-time.Hour).Truncate(time.Second)
+			if tt.prefs != nil {
+				data, err := EncodePreferences(tt.prefs)
+				testutils.NoError(t, err)
+				testutils.NoError(t, os.WriteFile(path, data, 0600))
+				testutils.NoError(t, os.Chtimes(path, old, old))
+			}
+
+			testutils.NoError(t, UpdatePreferences(path, 0, tt.changes))
+
+			info, err := os.Stat(path)
+			testutils.NoError(t, err)
+			testutils.Equal(t, tt.wantWrite, !info.ModTime().Equal(old), "file written")
+			testutils.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+			got, err := ReadPreferences(path)
+			testutils.NoError(t, err)
+			testutils.Equal(t, len(tt.want), len(got))
+			for key, val := range tt.want {
+				testutils.Equal(t, val, got[key], key)
+			}
+
+			entries, err := os.ReadDir(dir)
+			testutils.NoError(t, err)
+			testutils.Equal(t, 1, len(entries), "temporary files must be removed")
+		})
+	}
+}
+
+func TestPreferencesBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.preferences")
+	for _, theme := range []string{"a", "b", "c", "d"} {
+		testutils.NoError(t, UpdatePreferences(path, 2, map[string]string{"app.theme": theme}))
+	}
+	for n, want := range map[int]string{1: "c", 2: "b"} {
+		prefs, err := ReadPreferences(BackupPath(path, n))
+		testutils.NoError(t, err)
+		testutils.Equal(t, want, prefs["app.theme"], BackupPath(path, n))
+	}
+	_, err := os.Stat(BackupPath(path, 3))
+	testutils.True(t, os.IsNotExist(err), "only 2 backups must be kept")
+
+	// corrupted file is never backed up
+	testutils.NoError(t, os.WriteFile(path, []byte("garbage"), 0600))
+	testutils.ErrorIs(t, UpdatePreferences(path, 2, map[string]string{"app.theme": "e"}), ErrCorrupted)
+	testutils.NoError(t, savePreferences(path, 2, map[string]string{"app.theme": "e"}))
+	prefs, err := ReadPreferences(BackupPath(path, 1))
+	testutils.NoError(t, err)
+	testutils.Equal(t, "c", prefs["app.theme"])
+}
+
+func TestRecoverPreferences(t *testing.T) {
+	encode := func(t *testing.T, theme string) []byte {
+		data, err := EncodePreferences(map[string]string{"app.theme": theme})
+		testutils.NoError(t, err)
+		return data
+	}
+	tests := []struct {
+		name       string
+		backups    map[int][]byte
+		want       string
+		wantBackup int
+	}{
+		{name: "no backups"},
+		{
+			name:       "latest backup",
+			backups:    map[int][]byte{1: encode(t, "dark"), 2: encode(t, "light")},
+			want:       "dark",
+			wantBackup: 1,
+		},
+		{
+			name:       "corrupted backup",
+			backups:    map[int][]byte{1: []byte("garbage"), 2: encode(t, "light")},
+			want:       "light",
+			wantBackup: 2,
+		},
+		{
+			name:    "backup beyond count",
+			backups: map[int][]byte{4: encode(t, "dark")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "profile.preferences")
+			testutils.NoError(t, os.WriteFile(path, []byte("garbage"), 0600))
+			for n, data := range tt.backups {
+				testutils.NoError(t, os.WriteFile(BackupPath(path, n), data, 0600))
+			}
+			_, err := ReadPreferences(path)
+			testutils.ErrorIs(t, err, ErrCorrupted)
+
+			prefs, rec, err := RecoverPreferences(path, 3)
+			testutils.NoError(t, err)
+			testutils.Equal(t, tt.want, prefs["app.theme"])
+			if tt.wantBackup > 0 {
+				testutils.Equal(t, BackupPath(path, tt.wantBackup), rec.Backup)
+			} else {
+				testutils.Equal(t, "", rec.Backup)
+			}
+
+			corrupted, err := os.ReadFile(rec.Corrupted)
+			testutils.NoError(t, err)
+			testutils.Equal(t, "garbage", string(corrupted), "corrupted file must be kept")
+
+			restored, err := ReadPreferences(path)
+			testutils.NoError(t, err)
+			testutils.Equal(t, tt.want, restored["app.theme"])
+		})
+	}
+}
@@ -0,0 +1,11 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+// readPassphrase is not supported on windows, passphrase is given with
+// environment variable.
+func readPassphrase(prompt string) ([]byte, error) {
+	return nil, errNoTerminal
+}
@@ -0,0 +1,228 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	// RemoteFilename is name of remote configuration cache stored next
+	// to profile preferences.
+	RemoteFilename = "remote.cache"
+	// SignatureHeader is response header holding base64 encoded Ed25519
+	// signature of remote configuration document.
+	SignatureHeader = "X-Signature"
+	// remoteMaxSize limits size of remote configuration document.
+	remoteMaxSize = 1 << 20
+	// remoteTimeout limits remote configuration request so that
+	// unreachable endpoint does not stall application start.
+	remoteTimeout = 5 * time.Second
+)
+
+// ErrRemote is returned when remote configuration can not be fetched
+// or verified.
+var ErrRemote = fmt.Errorf("%w: remote configuration", ErrProfile)
+
+// Remote is remote configuration source. Document is TOML in the same
+// format as system config file, it must be signed with Ed25519 private
+// key matching Key and signature is sent in SignatureHeader. Document
+// is cached in file at Path and fetched again when cache is older than
+// Interval, ETag of cached document is sent so that unchanged document
+// is not transferred again.
+type Remote struct {
+	// URL is HTTPS endpoint of remote configuration, plain HTTP is
+	// accepted only for loopback hosts.
+	URL string
+	// Key is Ed25519 public key verifying document signature.
+	Key ed25519.PublicKey
+	// Interval is minimum interval between fetches.
+	Interval time.Duration
+	// Path is path of cache file.
+	Path string
+	// Client is HTTP client used for requests, default client with
+	// short timeout is used when nil.
+	Client *http.Client
+}
+
+type remoteCache struct {
+	URL       string    `json:"url"`
+	ETag      string    `json:"etag,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Body      []byte    `json:"body"`
+	Signature []byte    `json:"signature"`
+}
+
+// NewRemote returns remote configuration source of url verified with
+// base64 encoded Ed25519 public key, cache is stored in file at path.
+func NewRemote(rawurl, key string, interval time.Duration, path string) (*Remote, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrRemote, err.Error())
+	}
+	if u.Scheme != "https" && (u.Scheme != "http" || !isLoopback(u.Hostname())) {
+		return nil, fmt.Errorf("%w: %s must use https", ErrRemote, rawurl)
+	}
+	if key == "" {
+		return nil, fmt.Errorf("%w: public key is required to verify %s", ErrRemote, rawurl)
+	}
+	pub, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: invalid Ed25519 public key", ErrRemote)
+	}
+	return &Remote{URL: rawurl, Key: pub, Interval: interval, Path: path}, nil
+}
+
+// Layer returns remote configuration layer. Cached document is used
+// while it is fresh, otherwise document is fetched. When fetching fails
+// cached document is used if there is one and error is returned along
+// with the layer.
+func (r *Remote) Layer(ctx context.Context) (Layer, error) {
+	c, cerr := r.readCache()
+	if cerr == nil && time.Since(c.FetchedAt) < r.Interval {
+		return r.layer(c)
+	}
+	next, _, err := r.fetch(ctx, c)
+	if err != nil {
+		if c == nil {
+			return Layer{Source: SourceRemote, Path: r.URL}, err
+		}
+		layer, lerr := r.layer(c)
+		return layer, errors.Join(err, lerr)
+	}
+	if err := r.writeCache(next); err != nil {
+		return Layer{Source: SourceRemote, Path: r.URL}, err
+	}
+	return r.layer(next)
+}
+
+// Refresh fetches remote configuration regardless of interval and
+// reports whether document has changed.
+func (r *Remote) Refresh(ctx context.Context) (bool, error) {
+	c, _ := r.readCache()
+	next, changed, err := r.fetch(ctx, c)
+	if err != nil {
+		return false, err
+	}
+	if err := r.writeCache(next); err != nil {
+		return false, err
+	}
+	return changed, nil
+}
+
+func (r *Remote) layer(c *remoteCache) (Layer, error) {
+	layer := Layer{Source: SourceRemote, Path: r.URL}
+	values, err := ParseProfile(c.Body)
+	if err != nil {
+		return layer, fmt.Errorf("%w: %w", ErrRemote, err)
+	}
+	layer.Values = values
+	return layer, nil
+}
+
+// readCache reads cached document, cache of other URL or which can not
+// be verified is not used.
+func (r *Remote) readCache() (*remoteCache, error) {
+	data, err := os.ReadFile(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	c := &remoteCache{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	if c.URL != r.URL || !ed25519.Verify(r.Key, c.Body, c.Signature) {
+		return nil, fs.ErrNotExist
+	}
+	return c, nil
+}
+
+func (r *Remote) writeCache(c *remoteCache) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrRemote, err.Error())
+	}
+	if err := writeFile(r.Path, data, 0600); err != nil {
+		return fmt.Errorf("%w: %s", ErrRemote, err.Error())
+	}
+	return nil
+}
+
+// fetch fetches document, prev is cached document or nil.
+func (r *Remote) fetch(ctx context.Context, prev *remoteCache) (*remoteCache, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %s", ErrRemote, err.Error())
+	}
+	if prev != nil && prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+	client := r.Client
+	if client == nil {
+		client = &http.Client{Timeout: remoteTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %s", ErrRemote, err.Error())
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if prev == nil {
+			return nil, false, fmt.Errorf("%w: %s responded not modified without cached document", ErrRemote, r.URL)
+		}
+		next := *prev
+		next.FetchedAt = time.Now()
+		return &next, false, nil
+	case http.StatusOK:
+	default:
+		return nil, false, fmt.Errorf("%w: %s responded %s", ErrRemote, r.URL, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, remoteMaxSize+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %s", ErrRemote, err.Error())
+	}
+	if len(body) > remoteMaxSize {
+		return nil, false, fmt.Errorf("%w: document exceeds %d bytes", ErrRemote, remoteMaxSize)
+	}
+	sig, err := base64.StdEncoding.DecodeString(resp.Header.Get(SignatureHeader))
+	if err != nil || !ed25519.Verify(r.Key, body, sig) {
+		return nil, false, fmt.Errorf("%w: invalid signature of %s", ErrRemote, r.URL)
+	}
+	if _, err := ParseProfile(body); err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrRemote, err)
+	}
+	next := &remoteCache{
+		URL:       r.URL,
+		ETag:      resp.Header.Get("ETag"),
+		FetchedAt: time.Now(),
+		Body:      body,
+		Signature: sig,
+	}
+	return next, prev == nil || !bytes.Equal(prev.Body, body), nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+)
+
+func TestRemote(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	testutils.NoError(t, err)
+
+	var (
+		doc      atomic.Value
+		badSig   atomic.Bool
+		requests atomic.Int32
+		notMod   atomic.Int32
+	)
+	doc.Store("[app.stats]\nenabled = true\n")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		body := []byte(doc.Load().(string))
+		etag := `"` + base64.RawURLEncoding.EncodeToString(body) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			notMod.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		sig := ed25519.Sign(priv, body)
+		if badSig.Load() {
+			sig[0] ^= 0xff
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set(SignatureHeader, base64.StdEncoding.EncodeToString(sig))
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	_, err = NewRemote("http://example.com/config.toml", base64.StdEncoding.EncodeToString(pub), time.Hour, "")
+	testutils.ErrorIs(t, err, ErrRemote, "plain http to remote host")
+	_, err = NewRemote(srv.URL, "", time.Hour, "")
+	testutils.ErrorIs(t, err, ErrRemote, "missing key")
+
+	path := filepath.Join(t.TempDir(), RemoteFilename)
+	remote, err := NewRemote(srv.URL, base64.StdEncoding.EncodeToString(pub), time.Hour, path)
+	if !testutils.NoError(t, err) {
+		return
+	}
+	ctx := context.Background()
+
+	layer, err := remote.Layer(ctx)
+	testutils.NoError(t, err)
+	testutils.Equal(t, SourceRemote, layer.Source)
+	testutils.Equal(t, "true", layer.Values["app.stats.enabled"])
+
+	// fresh cache is used without request.
+	_, err = remote.Layer(ctx)
+	testutils.NoError(t, err)
+	testutils.Equal(t, int32(1), requests.Load(), "requests")
+
+	changed, err := remote.Refresh(ctx)
+	testutils.NoError(t, err)
+	testutils.False(t, changed, "unchanged document")
+	testutils.Equal(t, int32(1), notMod.Load(), "not modified responses")
+
+	doc.Store("[app.stats]\nenabled = false\n")
+	changed, err = remote.Refresh(ctx)
+	testutils.NoError(t, err)
+	testutils.True(t, changed, "changed document")
+
+	// document with invalid signature is rejected and cache is kept.
+	doc.Store("[app.stats]\nenabled = true\n")
+	badSig.Store(true)
+	remote.Interval = 0
+	layer, err = remote.Layer(ctx)
+	testutils.ErrorIs(t, err, ErrRemote)
+	testutils.Equal(t, "false", layer.Values["app.stats.enabled"])
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/sdk/paths"
+)
+
+// ErrUnknownSetting is returned in strict mode for keys which are not
+// in the settings blueprint.
+var ErrUnknownSetting = errors.New("unknown setting")
+
+// Unknown is key of configuration layer not present in blueprint.
+type Unknown struct {
+	Key    string
+	Source Source
+	// Path is file or environment variable key was read from.
+	Path string
+	// Suggestion is closest known key, empty when none is close.
+	Suggestion string
+}
+
+func (u Unknown) Error() string {
+	msg := fmt.Sprintf("%s %q in %s", ErrUnknownSetting, u.Key, u.Source)
+	if u.Path != "" {
+		msg += " (" + u.Path + ")"
+	}
+	if u.Suggestion != "" {
+		msg += fmt.Sprintf(", did you mean %q?", u.Suggestion)
+	}
+	return msg
+}
+
+func (u Unknown) Unwrap() error {
+	return ErrUnknownSetting
+}
+
+// UnknownKeys returns keys of layer not present in sorted keys.
+func UnknownKeys(layer Layer, keys []string) []Unknown {
+	var unknown []Unknown
+	for key := range layer.Values {
+		if _, ok := slices.BinarySearch(keys, key); ok {
+			continue
+		}
+		unknown = append(unknown, Unknown{
+			Key:        key,
+			Source:     layer.Source,
+			Path:       layer.Path,
+			Suggestion: Suggest(key, keys),
+		})
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Key < unknown[j].Key })
+	return unknown
+}
+
+// UnknownEnv returns environment variables with application prefix
+// e.g. MYAPP_ which do not override any of keys. Directory overrides
+// such as MYAPP_CONFIG_DIR are known.
+func UnknownEnv(slug string, keys []string) []Unknown {
+	known := make(map[string]string, len(keys))
+	for _, key := range keys {
+		known[EnvName(slug, key)] = key
+	}
+	for _, dir := range paths.Dirs {
+		known[paths.EnvKey(slug, dir)] = ""
+	}
+	known[PassphraseEnv(slug)] = ""
+	prefix := EnvName(slug, "")
+
+	var unknown []Unknown
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if _, ok := known[name]; ok {
+			continue
+		}
+		u := Unknown{Key: name, Source: SourceEnv}
+		// suggest setting key for the closest environment name
+		names := make([]string, 0, len(known))
+		for n := range known {
+			names = append(names, n)
+		}
+		if s := Suggest(name, names); s != "" && known[s] != "" {
+			u.Suggestion = s
+		}
+		unknown = append(unknown, u)
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Key < unknown[j].Key })
+	return unknown
+}
+
+// Suggest returns candidate closest to s by edit distance or empty
+// string when no candidate is close enough to be likely typo.
+func Suggest(s string, candidates []string) string {
+	return textfmt.Suggest(s, candidates)
+}
@@ -0,0 +1,250 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package datetime
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock provides time and timers. SystemClock is used by default,
+// FakeClock can be used in tests to drive time manually.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the Clock counterpart of time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is the Clock counterpart of time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// SystemClock returns Clock backed by package time.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                  { return time.Now() }
+func (systemClock) Since(t time.Time) time.Duration { return time.Since(t) }
+func (systemClock) NewTimer(d time.Duration) Timer  { return systemTimer{time.NewTimer(d)} }
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTimer struct{ t *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time        { return t.t.C }
+func (t systemTimer) Stop() bool                 { return t.t.Stop() }
+func (t systemTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+type systemTicker struct{ t *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time   { return t.t.C }
+func (t systemTicker) Stop()                 { t.t.Stop() }
+func (t systemTicker) Reset(d time.Duration) { t.t.Reset(d) }
+
+// FakeClock is Clock which time only moves when Advance is called.
+// Timers and tickers fire in order of their deadlines while advancing,
+// like time.Ticker channels are buffered by one and ticks are dropped
+// when receiver is not keeping up.
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// NewFakeClock returns FakeClock set to start time.
+func NewFakeClock(start time.Time) *FakeClock {
+	c := &FakeClock{now: start}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	return c.add(d, 0)
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("datetime: non-positive interval for FakeClock.NewTicker")
+	}
+	return &fakeTicker{c.add(d, d)}
+}
+
+// Advance moves clock forward by d firing all timers and tickers
+// which deadline is reached.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	target := c.now.Add(d)
+	for {
+		sort.SliceStable(c.waiters, func(i, j int) bool {
+			return c.waiters[i].deadline.Before(c.waiters[j].deadline)
+		})
+		if len(c.waiters) == 0 || c.waiters[0].deadline.After(target) {
+			break
+		}
+		w := c.waiters[0]
+		c.now = w.deadline
+		select {
+		case w.ch <- c.now:
+		default:
+		}
+		if w.period > 0 {
+			w.deadline = w.deadline.Add(w.period)
+		} else {
+			c.waiters = c.waiters[1:]
+		}
+	}
+	c.now = target
+}
+
+// BlockUntil blocks until clock has at least n active timers and tickers.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *FakeClock) add(d, period time.Duration) *fakeWaiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &fakeWaiter{
+		clock:    c,
+		ch:       make(chan time.Time, 1),
+		deadline: c.now.Add(d),
+		period:   period,
+	}
+	c.waiters = append(c.waiters, w)
+	c.cond.Broadcast()
+	return w
+}
+
+func (c *FakeClock) remove(w *fakeWaiter) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, ww := range c.waiters {
+		if ww == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeWaiter struct {
+	clock    *FakeClock
+	ch       chan time.Time
+	deadline time.Time
+	period   time.Duration
+}
+
+func (w *fakeWaiter) C() <-chan time.Time { return w.ch }
+
+func (w *fakeWaiter) Stop() bool {
+	return w.clock.remove(w)
+}
+
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	active := w.clock.remove(w)
+	w.clock.mu.Lock()
+	w.deadline = w.clock.now.Add(d)
+	if w.period > 0 {
+		w.period = d
+	}
+	w.clock.waiters = append(w.clock.waiters, w)
+	w.clock.cond.Broadcast()
+	w.clock.mu.Unlock()
+	return active
+}
+
+type fakeTicker struct{ w *fakeWaiter }
+
+func (t *fakeTicker) C() <-chan time.Time   { return t.w.C() }
+func (t *fakeTicker) Stop()                 { t.w.Stop() }
+func (t *fakeTicker) Reset(d time.Duration) { t.w.Reset(d) }
+
+// LocalClock is Clock which reports time in configured location.
+type LocalClock struct {
+	clock Clock
+	loc   *time.Location
+}
+
+// NewLocalClock returns LocalClock reporting time of clock in loc.
+// SystemClock is used when clock is nil and time.Local when loc is nil.
+func NewLocalClock(clock Clock, loc *time.Location) *LocalClock {
+	if clock == nil {
+		clock = SystemClock()
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return &LocalClock{clock: clock, loc: loc}
+}
+
+// Now returns current time in clock location.
+func (c *LocalClock) Now() time.Time {
+	return c.clock.Now().In(c.loc)
+}
+
+func (c *LocalClock) Since(t time.Time) time.Duration {
+	return c.clock.Since(t)
+}
+
+func (c *LocalClock) NewTimer(d time.Duration) Timer {
+	return c.clock.NewTimer(d)
+}
+
+func (c *LocalClock) NewTicker(d time.Duration) Ticker {
+	return c.clock.NewTicker(d)
+}
+
+// In returns t in clock location.
+func (c *LocalClock) In(t time.Time) time.Time {
+	return t.In(c.loc)
+}
+
+// Location returns clock location.
+func (c *LocalClock) Location() *time.Location {
+	return c.loc
+}
+
+// Clock returns underlying clock.
+func (c *LocalClock) Clock() Clock {
+	return c.clock
+}
+
+// Fake returns underlying FakeClock or nil when clock is not fake.
+func (c *LocalClock) Fake() *FakeClock {
+	fake, _ := c.clock.(*FakeClock)
+	return fake
+}
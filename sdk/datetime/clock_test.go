@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package datetime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/sdk/datetime"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := datetime.NewFakeClock(start)
+
+	timer := clock.NewTimer(2 * time.Second)
+	ticker := clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	clock.Advance(time.Second)
+	testutils.Equal(t, start.Add(time.Second), clock.Now())
+	testutils.Equal(t, start.Add(time.Second), <-ticker.C())
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired too early")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	testutils.Equal(t, start.Add(2*time.Second), <-timer.C())
+	testutils.Equal(t, start.Add(2*time.Second), <-ticker.C())
+	testutils.False(t, timer.Stop(), "expired timer should not be active")
+	testutils.Equal(t, 2*time.Second, clock.Since(start))
+}
+
+func TestLocalClock(t *testing.T) {
+	loc := time.FixedZone("test", 3*60*60)
+	fake := datetime.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	clock := datetime.NewLocalClock(fake, loc)
+
+	testutils.Equal(t, loc, clock.Now().Location())
+	testutils.Equal(t, 3, clock.Now().Hour())
+	testutils.True(t, clock.Fake() == fake, "expected underlying fake clock")
+
+	fake.Advance(time.Hour)
+	testutils.Equal(t, 4, clock.Now().Hour())
+}
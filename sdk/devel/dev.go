@@ -0,0 +1,231 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package devel
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/options"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/services/watcher"
+)
+
+var ErrDev = errors.New("dev")
+
+// Command returns dev command which watches project source, rebuilds
+// and restarts the application on changes. Application is started with
+// the current profile and signals received by dev command are forwarded
+// to it. Arguments are passed to the application.
+func Command() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "dev",
+		Category:         "Development",
+		Description:      "Rebuild and restart application on source changes",
+		MaxArgs:          100,
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.Usage("[flags] [-- application args...]")
+	cmd.AddInfo("Watched files, ignored paths and build package are configured with app.devel.dev.* settings.")
+
+	cmd.WithFlags(
+		varflag.StringFunc("dir", "", "Project source directory, defaults to working directory"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		dir := args.Flag("dir").String()
+		if dir == "" {
+			dir = sess.Get("app.fs.path.wd").String()
+		}
+		var appArgs []string
+		for _, arg := range args.Args() {
+			appArgs = append(appArgs, arg.String())
+		}
+		appArgs = append(appArgs, args.Passthrough()...)
+
+		// preserve the profile
+		if !sess.Get("app.config.disabled").Bool() {
+			appArgs = append([]string{"--profile=" + sess.Get("app.profile.name").String()}, appArgs...)
+		}
+
+		r := &devRunner{
+			sess: sess,
+			dir:  dir,
+			bin:  filepath.Join(sess.Get("app.fs.path.tmp").String(), "dev", sess.Get("app.slug").String()),
+			args: appArgs,
+		}
+		if runtime.GOOS == "windows" {
+			r.bin += ".exe"
+		}
+		return r.run()
+	})
+	return cmd
+}
+
+type devRunner struct {
+	sess *session.Context
+	dir  string
+	bin  string
+	args []string
+	proc *exec.Cmd
+	done chan struct{}
+}
+
+func (r *devRunner) run() error {
+	w := watcher.New(watcher.Config{
+		Name:     "dev",
+		Root:     r.dir,
+		Patterns: splitList(r.sess.Get("app.devel.dev.watch").String()),
+		Ignore:   splitList(r.sess.Get("app.devel.dev.ignore").String()),
+		Debounce: options.GetAsOr(r.sess, "app.devel.dev.debounce", 300*time.Millisecond),
+	})
+	if err := w.Reset(); err != nil {
+		return fmt.Errorf("%w: %w", ErrDev, err)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	if err := r.build(); err != nil {
+		r.sess.Log().Error("build failed", slog.String("err", err.Error()))
+	} else {
+		r.start()
+	}
+	defer r.stop()
+
+	interval, err := options.GetAs[time.Duration](r.sess, "app.devel.dev.interval")
+	if err != nil || interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+	ticker := r.sess.Time().NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case sig := <-sigs:
+			internal.Log(r.sess.Log(), "forwarding signal", slog.String("signal", sig.String()))
+			if r.proc == nil {
+				return nil
+			}
+			_ = r.proc.Process.Signal(sig)
+			r.wait()
+			return nil
+		case <-r.sess.Done():
+			return nil
+		case now := <-ticker.C():
+			if err := w.Poll(now); err != nil {
+				internal.Log(r.sess.Log(), "dev poll failed", slog.String("err", err.Error()))
+				continue
+			}
+			changes := w.Debounced(now)
+			if len(changes) == 0 {
+				continue
+			}
+			r.sess.Log().Notice("source changed, rebuilding", slog.Int("changes", len(changes)))
+			if err := r.build(); err != nil {
+				r.sess.Log().Error("build failed", slog.String("err", err.Error()))
+				continue
+			}
+			r.stop()
+			r.start()
+		}
+	}
+}
+
+func (r *devRunner) build() error {
+	pkg := r.sess.Get("app.devel.dev.package").String()
+	if pkg == "" {
+		pkg = "."
+	}
+	started := r.sess.Time().Now()
+	cmd := exec.Command("go", "build", "-o", r.bin, pkg)
+	cmd.Dir = r.dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrDev, strings.TrimSpace(string(out)))
+	}
+	r.sess.Log().Ok("build succeeded", slog.String("took", r.sess.Time().Since(started).String()))
+	return nil
+}
+
+func (r *devRunner) start() {
+	proc := exec.Command(r.bin, r.args...)
+	proc.Dir = r.dir
+	proc.Stdin = os.Stdin
+	proc.Stdout = os.Stdout
+	proc.Stderr = os.Stderr
+	proc.Env = os.Environ()
+	if err := proc.Start(); err != nil {
+		r.sess.Log().Error("failed to start application", slog.String("err", err.Error()))
+		return
+	}
+	internal.Log(r.sess.Log(), "application started", slog.Int("pid", proc.Process.Pid))
+	r.proc = proc
+	r.done = make(chan struct{})
+	go func(proc *exec.Cmd, done chan struct{}) {
+		if err := proc.Wait(); err != nil {
+			internal.Log(r.sess.Log(), "application exited", slog.String("err", err.Error()))
+		}
+		close(done)
+	}(proc, r.done)
+}
+
+func (r *devRunner) stop() {
+	if r.proc == nil {
+		return
+	}
+	select {
+	case <-r.done:
+	default:
+		if runtime.GOOS == "windows" {
+			_ = r.proc.Process.Kill()
+		} else {
+			_ = r.proc.Process.Signal(syscall.SIGTERM)
+		}
+	}
+	r.wait()
+	r.proc = nil
+}
+
+// wait waits application to exit and kills it after grace period.
+func (r *devRunner) wait() {
+	if r.done == nil {
+		return
+	}
+	timer := r.sess.Time().NewTimer(r.sess.Get("app.devel.dev.grace_period").Duration())
+	defer timer.Stop()
+	select {
+	case <-r.done:
+	case <-timer.C():
+		r.sess.Log().Warn("application did not exit in time, killing it")
+		_ = r.proc.Process.Kill()
+		<-r.done
+	}
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, "|") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
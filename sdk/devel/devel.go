@@ -21,6 +21,9 @@ var (
 // compiles your application from source or uses go run .
 type Settings struct {
 	AllowProd settings.Bool `default:"false" desc:"Allow set app into production mode when running from source."`
+	// WithDevCmd adds dev command when application is running from source.
+	WithDevCmd settings.Bool `default:"false" desc:"Add dev command rebuilding and restarting application on source changes when running from source."`
+	Dev        DevSettings   `key:"dev"`
 }
 
 func (s Settings) Blueprint() (*settings.Blueprint, error) {
@@ -31,6 +34,20 @@ func (s Settings) Blueprint() (*settings.Blueprint, error) {
 	return b, nil
 }
 
+// DevSettings configures the dev command.
+type DevSettings struct {
+	Watch       settings.StringSlice `key:"watch" default:"**/*.go|go.mod|go.sum" mutation:"once" desc:"Glob patterns of watched source files"`
+	Ignore      settings.StringSlice `key:"ignore" default:".git|vendor|node_modules|**/testdata" mutation:"once" desc:"Glob patterns of ignored paths"`
+	Package     settings.String      `key:"package" default:"." mutation:"once" desc:"Package built by the dev command"`
+	Interval    settings.Duration    `key:"interval" default:"500ms" mutation:"once" desc:"Interval between source polls"`
+	Debounce    settings.Duration    `key:"debounce" default:"300ms" mutation:"once" desc:"Time without changes before rebuild"`
+	GracePeriod settings.Duration    `key:"grace_period" default:"5s" mutation:"once" desc:"Time given to application to exit before it is killed"`
+}
+
+func (s DevSettings) Blueprint() (*settings.Blueprint, error) {
+	return settings.New(s)
+}
+
 // RuntimeCallerStr is utility function to get the caller information.
 // It returns the file and line number of the caller in form of string.
 // e.g. /path/to/file.go:123
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package diagnostics provides diagnostics service which exposes
+// application state on expvar compatible JSON endpoint, so that
+// standard tooling can scrape it without custom integration.
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/vars"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	clicommands "github.com/happy-sdk/happy/sdk/cli/commands"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/networking/listener"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+	"github.com/happy-sdk/happy/sdk/stats"
+)
+
+var Error = errors.New("diagnostics")
+
+const (
+	ServiceName = "app-diagnostics"
+	// VarsPath is path of expvar compatible endpoint.
+	VarsPath = "/debug/vars"
+)
+
+type Settings struct {
+	Enabled settings.Bool   `key:"enabled,save" default:"false" mutation:"once" desc:"Enable diagnostics service"`
+	Address settings.String `key:"address,save" default:"127.0.0.1:6060" mutation:"once" desc:"Address diagnostics service listens on"`
+}
+
+func (s Settings) Blueprint() (*settings.Blueprint, error) {
+	return settings.New(s)
+}
+
+// Enabled reports whether diagnostics service is enabled.
+func Enabled(sess *session.Context) bool {
+	return sess.Get("app.diagnostics.enabled").Bool()
+}
+
+// AsService returns diagnostics service serving application options,
+// settings, stats from prof and service states on VarsPath.
+func AsService(prof *stats.Profiler) *services.Service {
+	svc := services.New(service.Config{
+		Name: ServiceName,
+	})
+
+	var (
+		mu  sync.Mutex
+		srv *http.Server
+	)
+
+	svc.OnStart(func(sess *session.Context) error {
+		addr := sess.Get("app.diagnostics.address").String()
+		ln, err := listener.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		mux := http.NewServeMux()
+		mux.Handle(VarsPath, Handler(sess, prof))
+
+		mu.Lock()
+		srv = &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+			BaseContext:       func(net.Listener) context.Context { return sess },
+		}
+		mu.Unlock()
+
+		go func() {
+			if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				sess.Log().Error("diagnostics service failed", slog.String("err", err.Error()))
+			}
+		}()
+		internal.Log(sess.Log(), "diagnostics service listening", slog.String("addr", ln.Addr().String()))
+		return nil
+	})
+
+	svc.OnStop(func(sess *session.Context, e error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if srv == nil {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(ctx)
+	})
+	return svc
+}
+
+// Handler returns http handler which writes expvar compatible JSON
+// object. Along with standard cmdline and memstats and variables
+// published with expvar package it contains options, settings with
+// secrets redacted, stats, samples of stats series and service states.
+func Handler(sess *session.Context, prof *stats.Profiler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snap := clicommands.NewSnapshot(sess)
+
+		out := map[string]any{
+			"cmdline":  os.Args,
+			"memstats": memstats(),
+			"options":  snap.Options,
+			"settings": snap.Settings,
+			"services": snap.Services,
+		}
+		if prof != nil {
+			st := make(map[string]string)
+			prof.State().Range(func(v vars.Variable) {
+				st[v.Name()] = v.String()
+			})
+			out["stats"] = st
+			series := make(map[string][]stats.Sample)
+			for _, s := range prof.AllSeries() {
+				series[s.Name()] = s.Samples()
+			}
+			if len(series) > 0 {
+				out["series"] = series
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprintf(w, "{\n")
+		first := true
+		write := func(key string, val []byte) {
+			if !first {
+				fmt.Fprintf(w, ",\n")
+			}
+			first = false
+			fmt.Fprintf(w, "%q: %s", key, val)
+		}
+		keys := make([]string, 0, len(out))
+		for key := range out {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			data, err := json.Marshal(out[key])
+			if err != nil {
+				data, _ = json.Marshal(err.Error())
+			}
+			write(key, data)
+		}
+		expvar.Do(func(kv expvar.KeyValue) {
+			if _, ok := out[kv.Key]; !ok {
+				write(kv.Key, []byte(kv.Value.String()))
+			}
+		})
+		fmt.Fprintf(w, "\n}\n")
+	})
+}
+
+func memstats() *runtime.MemStats {
+	ms := new(runtime.MemStats)
+	runtime.ReadMemStats(ms)
+	return ms
+}
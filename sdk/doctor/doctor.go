@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package doctor provides health checks of the application environment
+// and the doctor command which reports their results. Core checks are
+// provided by the SDK, addons can register own checks with
+// addon.RegisterDoctorCheck.
+package doctor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/pkg/validate"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/internal"
+)
+
+var (
+	Error        = errors.New("doctor")
+	ErrUnhealthy = fmt.Errorf("%w: unhealthy", Error)
+)
+
+// Status is the outcome of a check.
+type Status uint8
+
+const (
+	StatusPass Status = iota
+	StatusWarn
+	StatusFail
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPass:
+		return "pass"
+	case StatusWarn:
+		return "warn"
+	case StatusFail:
+		return "fail"
+	}
+	return "unknown"
+}
+
+func (s Status) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Result is the result of a check. Hint should tell user how to
+// remediate the problem when status is not StatusPass.
+type Result struct {
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// Pass returns passing result.
+func Pass(msg string) Result {
+	return Result{Status: StatusPass, Message: msg}
+}
+
+// Warn returns warning result with remediation hint.
+func Warn(msg, hint string) Result {
+	return Result{Status: StatusWarn, Message: msg, Hint: hint}
+}
+
+// Fail returns failing result with remediation hint.
+func Fail(msg, hint string) Result {
+	return Result{Status: StatusFail, Message: msg, Hint: hint}
+}
+
+// Check is a single health check.
+type Check struct {
+	Name        string
+	Description string
+	Run         func(sess *session.Context) Result
+}
+
+// NewCheck returns new check.
+func NewCheck(name, desc string, run func(sess *session.Context) Result) Check {
+	return Check{
+		Name:        name,
+		Description: desc,
+		Run:         run,
+	}
+}
+
+// Entry is result of check in report.
+type Entry struct {
+	Check string        `json:"check"`
+	Took  time.Duration `json:"took"`
+	Result
+}
+
+// Report contains results of all checks.
+type Report struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Run runs checks in order they are provided. Check which panics
+// or has no Run function is reported as failed.
+func Run(sess *session.Context, checks ...Check) Report {
+	var report Report
+	for _, check := range checks {
+		started := sess.Time().Now()
+		res := run(sess, check)
+		internal.Log(sess.Log(), "doctor check",
+			slog.String("check", check.Name),
+			slog.String("status", res.Status.String()))
+		report.Entries = append(report.Entries, Entry{
+			Check:  check.Name,
+			Took:   sess.Time().Since(started),
+			Result: res,
+		})
+	}
+	return report
+}
+
+func run(sess *session.Context, check Check) (res Result) {
+	if check.Run == nil {
+		return Fail("check has no run function", "")
+	}
+	if err := action.Try(func() error {
+		res = check.Run(sess)
+		return nil
+	}); err != nil {
+		return Fail(err.Error(), "report this as a bug of the check provider")
+	}
+	return res
+}
+
+// Count returns number of entries with given status.
+func (r Report) Count(status Status) int {
+	var n int
+	for _, e := range r.Entries {
+		if e.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed reports whether any of the checks failed.
+func (r Report) Failed() bool {
+	return r.Count(StatusFail) > 0
+}
+
+func (r Report) String() string {
+	table := textfmt.Table{
+		Title:      "Doctor report",
+		WithHeader: true,
+	}
+	table.AddRow("STATUS", "CHECK", "MESSAGE", "HINT")
+	for _, e := range r.Entries {
+		table.AddRow(strings.ToUpper(e.Status.String()), e.Check, e.Message, e.Hint)
+	}
+	table.AddDivider()
+	table.AddRow("", fmt.Sprintf("%d passed, %d warnings, %d failed",
+		r.Count(StatusPass), r.Count(StatusWarn), r.Count(StatusFail)))
+	return table.String()
+}
+
+// Command returns doctor command which runs core checks followed
+// by provided checks.
+func Command(checks ...Check) *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "doctor",
+		Category:    "Diagnostics",
+		Description: "Check application environment for common problems",
+	})
+
+	cmd.AddInfo("Runs health checks provided by the application and its addons and prints pass/warn/fail report with hints how to fix the problems.")
+
+	cmd.WithFlags(
+		varflag.BoolFunc("json", false, "Print report as JSON"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		report := Run(sess, append(CoreChecks(), checks...)...)
+		if args.Flag("json").Var().Bool() {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			sess.Log().Println(string(data))
+		} else {
+			sess.Log().Println(report.String())
+		}
+		if report.Failed() {
+			return fmt.Errorf("%w: %d checks failed", ErrUnhealthy, report.Count(StatusFail))
+		}
+		return nil
+	})
+	return cmd
+}
+
+// CoreChecks returns checks provided by the SDK.
+func CoreChecks() []Check {
+	return []Check{
+		NewCheck("config", "Settings profile is loaded and valid", checkConfig),
+		DirCheck("app.fs.path.config"),
+		DirCheck("app.fs.path.cache"),
+		DirCheck("app.fs.path.tmp"),
+	}
+}
+
+func checkConfig(sess *session.Context) Result {
+	profile := sess.Settings()
+	if profile == nil || !profile.Loaded() {
+		return Fail("settings profile is not loaded", "run config reset to restore default settings")
+	}
+	var invalid []string
+	for _, s := range profile.All() {
+		if !s.IsSet() {
+			continue
+		}
+		if err := profile.Validate(s.Key(), s.Value().String()); err != nil {
+			invalid = append(invalid, s.Key())
+		}
+	}
+	if len(invalid) > 0 {
+		return Fail(fmt.Sprintf("invalid settings: %s", strings.Join(invalid, ", ")),
+			"fix the values with config set or run config reset")
+	}
+	return Pass(fmt.Sprintf("profile %q is valid", profile.Name()))
+}
+
+// DirCheck returns check which verifies that directory referenced by
+// session option key exists and is writable.
+func DirCheck(key string) Check {
+	return NewCheck(key, "Directory exists and is writable", func(sess *session.Context) Result {
+		dir := sess.Get(key).String()
+		if dir == "" {
+			return Warn("path is not configured", "")
+		}
+		info, err := os.Stat(dir)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return Warn(fmt.Sprintf("%s does not exist", dir), "it is created when application needs it")
+			}
+			return Fail(err.Error(), fmt.Sprintf("check permissions of %s", filepath.Dir(dir)))
+		}
+		if !info.IsDir() {
+			return Fail(fmt.Sprintf("%s is not a directory", dir), fmt.Sprintf("remove %s", dir))
+		}
+		if err := validate.Writable()(dir); err != nil {
+			return Fail(fmt.Sprintf("%s is not writable", dir), fmt.Sprintf("check owner and permissions of %s", dir))
+		}
+		return Pass(dir)
+	})
+}
+
+// ValueCheck returns check which validates value of session setting or
+// option key, hint is shown when value is invalid.
+func ValueCheck(key, hint string, validators ...validate.Validator) Check {
+	return NewCheck("value."+key, fmt.Sprintf("%s is valid", key), func(sess *session.Context) Result {
+		value := sess.Get(key).String()
+		if err := validate.Check(value, validators...); err != nil {
+			return Fail(err.Error(), hint)
+		}
+		return Pass(value)
+	})
+}
+
+// BinaryCheck returns check which verifies that executable is in PATH.
+func BinaryCheck(name, hint string) Check {
+	return NewCheck("bin."+name, fmt.Sprintf("%s executable is available", name), func(sess *session.Context) Result {
+		p, err := exec.LookPath(name)
+		if err != nil {
+			return Fail(fmt.Sprintf("%s not found in PATH", name), hint)
+		}
+		return Pass(p)
+	})
+}
+
+// PortCheck returns check which verifies that tcp address can be listened on.
+func PortCheck(addr, hint string) Check {
+	return NewCheck("port."+addr, fmt.Sprintf("%s is free", addr), func(sess *session.Context) Result {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return Fail(fmt.Sprintf("%s is in use", addr), hint)
+		}
+		_ = ln.Close()
+		return Pass(fmt.Sprintf("%s is free", addr))
+	})
+}
@@ -0,0 +1,484 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package download downloads files over HTTP in concurrent chunks,
+// resumes interrupted downloads, verifies checksums and caches
+// downloaded files in application cache directory.
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/vars"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/events"
+	"github.com/happy-sdk/happy/sdk/internal"
+)
+
+var (
+	Error = errors.New("download")
+	// ErrChecksum is returned when downloaded file does not match
+	// expected checksum.
+	ErrChecksum = fmt.Errorf("%w: checksum mismatch", Error)
+	// ProgressEvent is dispatched with downloaded bytes as value and
+	// url, total and done payload when Manager.Events is true.
+	ProgressEvent = events.New("download", "progress")
+)
+
+const (
+	// CacheDir is directory within app.fs.path.cache downloads are cached in.
+	CacheDir = "downloads"
+
+	partSuffix  = ".part"
+	stateSuffix = ".part.json"
+)
+
+// Request describes single download.
+type Request struct {
+	URL string
+	// Dest is destination path, file is cached in Manager.CacheDir
+	// when empty.
+	Dest string
+	// Sum is expected hex encoded SHA-256 checksum, cached file is
+	// reused only when it matches.
+	Sum string
+	// Verify is called with path of downloaded file before it is moved
+	// to destination e.g. to verify signature with artifacts package.
+	Verify func(ctx context.Context, path string) error
+}
+
+// Progress is download progress reported to Manager.Progress.
+type Progress struct {
+	URL string
+	// Total is size of file or -1 when unknown.
+	Total int64
+	Done  int64
+}
+
+// Manager downloads files.
+type Manager struct {
+	Client *http.Client
+	// CacheDir is directory downloads without destination are stored in.
+	CacheDir string
+	// Concurrency is number of chunks downloaded in parallel.
+	Concurrency int
+	// ChunkSize is size of chunks, files smaller than chunk size and
+	// files from servers not supporting ranges are downloaded as whole.
+	ChunkSize int64
+	// Progress is called at most every ProgressInterval and when
+	// download completes.
+	Progress         func(Progress)
+	ProgressInterval time.Duration
+	// Events enables dispatching of ProgressEvent.
+	Events bool
+
+	sess *session.Context
+}
+
+// New returns manager caching downloads in application cache directory.
+func New(sess *session.Context) *Manager {
+	return &Manager{
+		Client:           http.DefaultClient,
+		CacheDir:         filepath.Join(sess.Get("app.fs.path.cache").String(), CacheDir),
+		Concurrency:      4,
+		ChunkSize:        8 << 20,
+		ProgressInterval: 250 * time.Millisecond,
+		sess:             sess,
+	}
+}
+
+// Get downloads file and returns its path. Cached or already
+// downloaded destination is returned without downloading when it
+// matches Request.Sum, or when Sum is empty and file exists.
+func (m *Manager) Get(ctx context.Context, req Request) (string, error) {
+	dest, err := m.dest(req)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(dest); err == nil {
+		if req.Sum == "" {
+			m.log("download cached", slog.String("url", req.URL), slog.String("path", dest))
+			return dest, nil
+		}
+		if sum, err := fileSum(dest); err == nil && strings.EqualFold(sum, req.Sum) {
+			m.log("download cached", slog.String("url", req.URL), slog.String("path", dest))
+			return dest, nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+
+	part := dest + partSuffix
+	if err := m.fetch(ctx, req.URL, part, dest+stateSuffix); err != nil {
+		return "", err
+	}
+	if req.Sum != "" {
+		sum, err := fileSum(part)
+		if err != nil {
+			return "", err
+		}
+		if !strings.EqualFold(sum, req.Sum) {
+			_ = os.Remove(part)
+			_ = os.Remove(dest + stateSuffix)
+			return "", fmt.Errorf("%w: %s: got %s, want %s", ErrChecksum, req.URL, sum, req.Sum)
+		}
+	}
+	if req.Verify != nil {
+		if err := req.Verify(ctx, part); err != nil {
+			_ = os.Remove(part)
+			_ = os.Remove(dest + stateSuffix)
+			return "", err
+		}
+	}
+	if err := os.Rename(part, dest); err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	_ = os.Remove(dest + stateSuffix)
+	m.log("downloaded", slog.String("url", req.URL), slog.String("path", dest))
+	return dest, nil
+}
+
+// GetAll downloads files concurrently and returns their paths in order
+// of requests, error is returned when any download fails.
+func (m *Manager) GetAll(ctx context.Context, reqs ...Request) ([]string, error) {
+	var (
+		wg    sync.WaitGroup
+		paths = make([]string, len(reqs))
+		errs  = make([]error, len(reqs))
+	)
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(i int, req Request) {
+			defer wg.Done()
+			paths[i], errs[i] = m.Get(ctx, req)
+		}(i, req)
+	}
+	wg.Wait()
+	return paths, errors.Join(errs...)
+}
+
+// CachePath returns path where download of rawURL is cached.
+func (m *Manager) CachePath(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		name = "download"
+	}
+	h := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(m.CacheDir, hex.EncodeToString(h[:8]), name), nil
+}
+
+func (m *Manager) dest(req Request) (string, error) {
+	if req.Dest != "" {
+		return req.Dest, nil
+	}
+	if m.CacheDir == "" {
+		return "", fmt.Errorf("%w: no destination and cache directory for %s", Error, req.URL)
+	}
+	return m.CachePath(req.URL)
+}
+
+// state is persisted next to partial download so that it can be resumed.
+type state struct {
+	URL  string `json:"url"`
+	Size int64  `json:"size"`
+	ETag string `json:"etag,omitempty"`
+	// Done marks completed chunks of chunked download.
+	Done []bool `json:"done,omitempty"`
+}
+
+func (m *Manager) fetch(ctx context.Context, rawURL, part, statePath string) error {
+	size, etag, ranges, err := m.head(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	st := loadState(statePath)
+	if st.URL != rawURL || st.Size != size || st.ETag != etag || etag == "" && size < 0 {
+		// partial data is of unknown origin or remote file changed
+		_ = os.Remove(part)
+		st = state{URL: rawURL, Size: size, ETag: etag}
+	}
+
+	chunk := m.ChunkSize
+	if chunk <= 0 {
+		chunk = 8 << 20
+	}
+	if !ranges || size <= chunk {
+		st.Done = nil
+		saveState(statePath, &st)
+		return m.stream(ctx, rawURL, part, size, ranges)
+	}
+	n := int((size + chunk - 1) / chunk)
+	if len(st.Done) != n {
+		st.Done = make([]bool, n)
+	}
+	return m.chunked(ctx, rawURL, part, statePath, &st, chunk)
+}
+
+func (m *Manager) head(ctx context.Context, rawURL string) (size int64, etag string, ranges bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return 0, "", false, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	res, err := m.client().Do(req)
+	if err != nil {
+		return 0, "", false, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		// some servers do not support HEAD, download is streamed
+		return -1, "", false, nil
+	}
+	return res.ContentLength, res.Header.Get("ETag"), res.Header.Get("Accept-Ranges") == "bytes" && res.ContentLength > 0, nil
+}
+
+// stream downloads file as whole, partial file is resumed with range
+// request when server supports ranges.
+func (m *Manager) stream(ctx context.Context, rawURL, part string, size int64, ranges bool) error {
+	var offset int64
+	if info, err := os.Stat(part); err == nil && ranges && info.Size() < size {
+		offset = info.Size()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	res, err := m.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer res.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	case http.StatusOK:
+		offset = 0
+	default:
+		return fmt.Errorf("%w: %s: %s", Error, rawURL, res.Status)
+	}
+	if size < 0 && res.ContentLength >= 0 {
+		size = offset + res.ContentLength
+	}
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer f.Close()
+
+	p := m.newProgress(rawURL, size, offset)
+	defer p.finish()
+	if _, err := io.Copy(f, io.TeeReader(res.Body, p)); err != nil {
+		return fmt.Errorf("%w: %s: %s", Error, rawURL, err.Error())
+	}
+	return f.Close()
+}
+
+func (m *Manager) chunked(ctx context.Context, rawURL, part, statePath string, st *state, chunk int64) error {
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer f.Close()
+	if err := f.Truncate(st.Size); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+
+	var done int64
+	for i, ok := range st.Done {
+		if ok {
+			done += min(chunk, st.Size-int64(i)*chunk)
+		}
+	}
+	p := m.newProgress(rawURL, st.Size, done)
+	defer p.finish()
+
+	concurrency := max(m.Concurrency, 1)
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, concurrency)
+		errs []error
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for i, ok := range st.Done {
+		if ok {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			start := int64(i) * chunk
+			end := min(start+chunk, st.Size) - 1
+			if err := m.fetchRange(ctx, rawURL, f, start, end, p); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				cancel()
+				return
+			}
+			mu.Lock()
+			st.Done[i] = true
+			saveState(statePath, st)
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (m *Manager) fetchRange(ctx context.Context, rawURL string, f *os.File, start, end int64, p *progress) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	res, err := m.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("%w: %s: range request failed: %s", Error, rawURL, res.Status)
+	}
+	w := io.NewOffsetWriter(f, start)
+	n, err := io.Copy(w, io.TeeReader(io.LimitReader(res.Body, end-start+1), p))
+	if err != nil {
+		return fmt.Errorf("%w: %s: %s", Error, rawURL, err.Error())
+	}
+	if n != end-start+1 {
+		return fmt.Errorf("%w: %s: short chunk at %d", Error, rawURL, start)
+	}
+	return nil
+}
+
+func (m *Manager) client() *http.Client {
+	if m.Client != nil {
+		return m.Client
+	}
+	return http.DefaultClient
+}
+
+func (m *Manager) log(msg string, attrs ...slog.Attr) {
+	if m.sess != nil {
+		internal.Log(m.sess.Log(), msg, attrs...)
+	}
+}
+
+type progress struct {
+	m     *Manager
+	url   string
+	total int64
+	done  atomic.Int64
+	mu    sync.Mutex
+	last  time.Time
+}
+
+func (m *Manager) newProgress(rawURL string, total, done int64) *progress {
+	p := &progress{m: m, url: rawURL, total: total}
+	p.done.Store(done)
+	return p
+}
+
+// Write counts downloaded bytes, reports are serialized so that
+// Manager.Progress is never called concurrently.
+func (p *progress) Write(b []byte) (int, error) {
+	p.done.Add(int64(len(b)))
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.last) >= p.m.ProgressInterval {
+		p.last = time.Now()
+		p.report()
+	}
+	return len(b), nil
+}
+
+func (p *progress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.report()
+}
+
+func (p *progress) report() {
+	pr := Progress{URL: p.url, Total: p.total, Done: p.done.Load()}
+	if p.m.Progress != nil {
+		p.m.Progress(pr)
+	}
+	if p.m.Events && p.m.sess != nil {
+		payload := new(vars.Map)
+		_ = payload.Store("url", pr.URL)
+		_ = payload.Store("total", pr.Total)
+		_ = payload.Store("done", pr.Done)
+		p.m.sess.Dispatch(ProgressEvent.Create(pr.Done, payload))
+	}
+}
+
+func loadState(path string) state {
+	var st state
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st
+	}
+	_ = json.Unmarshal(data, &st)
+	return st
+}
+
+func saveState(path string, st *state) {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0644)
+}
+
+func fileSum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
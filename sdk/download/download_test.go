@@ -0,0 +1,326 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+)
+
+var errVerify = errors.New("verify")
+
+// testServer serves content and records Range headers of GET requests.
+type testServer struct {
+	content []byte
+	etag    string
+	// noRanges disables range support.
+	noRanges bool
+	// status overrides response status when set.
+	status int
+
+	mu     sync.Mutex
+	ranges []string
+	gets   int
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	if r.Method == http.MethodGet {
+		s.mu.Lock()
+		s.gets++
+		if rng := r.Header.Get("Range"); rng != "" {
+			s.ranges = append(s.ranges, rng)
+		}
+		s.mu.Unlock()
+	}
+	if s.etag != "" {
+		w.Header().Set("ETag", s.etag)
+	}
+	if s.noRanges {
+		r.Header.Del("Range")
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		_, _ = w.Write(s.content)
+		return
+	}
+	http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(s.content))
+}
+
+func (s *testServer) requested() (gets int, ranges []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gets, append([]string(nil), s.ranges...)
+}
+
+func sum(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func TestGet(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10)
+	const chunk = 32
+
+	tests := []struct {
+		name   string
+		server *testServer
+		// prepare is called with destination path before download.
+		prepare  func(t *testing.T, dest, url string)
+		chunk    int64
+		req      Request
+		wantErr  error
+		wantGets int
+		// wantRanges are Range headers of GET requests in any order.
+		wantRanges []string
+	}{
+		{
+			name:     "stream",
+			server:   &testServer{content: content},
+			chunk:    1 << 20,
+			wantGets: 1,
+		},
+		{
+			name:       "chunked",
+			server:     &testServer{content: content, etag: `"v1"`},
+			chunk:      chunk,
+			wantGets:   4,
+			wantRanges: []string{"bytes=0-31", "bytes=32-63", "bytes=64-95", "bytes=96-99"},
+		},
+		{
+			name:     "server without ranges and head",
+			server:   &testServer{content: content, noRanges: true},
+			chunk:    chunk,
+			wantGets: 1,
+		},
+		{
+			name:   "resume stream",
+			server: &testServer{content: content, etag: `"v1"`},
+			chunk:  1 << 20,
+			prepare: func(t *testing.T, dest, url string) {
+				testutils.NoError(t, os.WriteFile(dest+partSuffix, content[:40], 0644))
+				saveState(dest+stateSuffix, &state{URL: url, Size: int64(len(content)), ETag: `"v1"`})
+			},
+			wantGets:   1,
+			wantRanges: []string{"bytes=40-"},
+		},
+		{
+			name:   "resume chunked",
+			server: &testServer{content: content, etag: `"v1"`},
+			chunk:  chunk,
+			prepare: func(t *testing.T, dest, url string) {
+				part := make([]byte, len(content))
+				copy(part, content[:chunk])
+				copy(part[2*chunk:], content[2*chunk:3*chunk])
+				testutils.NoError(t, os.WriteFile(dest+partSuffix, part, 0644))
+				saveState(dest+stateSuffix, &state{
+					URL:  url,
+					Size: int64(len(content)),
+					ETag: `"v1"`,
+					Done: []bool{true, false, true, false},
+				})
+			},
+			wantGets:   2,
+			wantRanges: []string{"bytes=32-63", "bytes=96-99"},
+		},
+		{
+			name:   "partial data of changed file",
+			server: &testServer{content: content, etag: `"v2"`},
+			chunk:  1 << 20,
+			prepare: func(t *testing.T, dest, url string) {
+				testutils.NoError(t, os.WriteFile(dest+partSuffix, []byte("stale data"), 0644))
+				saveState(dest+stateSuffix, &state{URL: url, Size: int64(len(content)), ETag: `"v1"`})
+			},
+			wantGets: 1,
+		},
+		{
+			name:     "checksum",
+			server:   &testServer{content: content},
+			chunk:    1 << 20,
+			req:      Request{Sum: strings.ToUpper(sum(content))},
+			wantGets: 1,
+		},
+		{
+			name:   "cached",
+			server: &testServer{content: content},
+			chunk:  1 << 20,
+			prepare: func(t *testing.T, dest, url string) {
+				testutils.NoError(t, os.WriteFile(dest, content, 0644))
+			},
+			req: Request{Sum: sum(content)},
+		},
+		{
+			name:   "cached with other checksum",
+			server: &testServer{content: content},
+			chunk:  1 << 20,
+			prepare: func(t *testing.T, dest, url string) {
+				testutils.NoError(t, os.WriteFile(dest, []byte("old"), 0644))
+			},
+			req:      Request{Sum: sum(content)},
+			wantGets: 1,
+		},
+		{
+			name:     "checksum mismatch",
+			server:   &testServer{content: content},
+			chunk:    1 << 20,
+			req:      Request{Sum: sum([]byte("other"))},
+			wantErr:  ErrChecksum,
+			wantGets: 1,
+		},
+		{
+			name:       "chunked checksum mismatch",
+			server:     &testServer{content: content, etag: `"v1"`},
+			chunk:      chunk,
+			req:        Request{Sum: sum([]byte("other"))},
+			wantErr:    ErrChecksum,
+			wantGets:   4,
+			wantRanges: []string{"bytes=0-31", "bytes=32-63", "bytes=64-95", "bytes=96-99"},
+		},
+		{
+			name:   "verify",
+			server: &testServer{content: content},
+			chunk:  1 << 20,
+			req: Request{Verify: func(ctx context.Context, path string) error {
+				return errVerify
+			}},
+			wantErr:  errVerify,
+			wantGets: 1,
+		},
+		{
+			name:    "not found",
+			server:  &testServer{status: http.StatusNotFound},
+			chunk:   1 << 20,
+			wantErr: Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.server)
+			defer srv.Close()
+
+			m := &Manager{
+				Client:      srv.Client(),
+				CacheDir:    t.TempDir(),
+				Concurrency: 2,
+				ChunkSize:   tt.chunk,
+			}
+			req := tt.req
+			req.URL = srv.URL + "/file.bin"
+			dest, err := m.CachePath(req.URL)
+			testutils.NoError(t, err)
+			testutils.NoError(t, os.MkdirAll(filepath.Dir(dest), 0750))
+			if tt.prepare != nil {
+				tt.prepare(t, dest, req.URL)
+			}
+
+			got, err := m.Get(context.Background(), req)
+			gets, ranges := tt.server.requested()
+			testutils.Equal(t, tt.wantGets, gets, "GET requests")
+			testutils.Equal(t, len(tt.wantRanges), len(ranges), "range requests")
+			for _, want := range tt.wantRanges {
+				found := false
+				for _, r := range ranges {
+					found = found || r == want
+				}
+				testutils.True(t, found, "range "+want+" must be requested")
+			}
+
+			if tt.wantErr != nil {
+				testutils.ErrorIs(t, err, tt.wantErr)
+				_, serr := os.Stat(dest)
+				testutils.True(t, os.IsNotExist(serr), "destination must not exist")
+				if !errors.Is(err, ErrChecksum) && !errors.Is(err, errVerify) {
+					return
+				}
+				// rejected download must not be resumed
+				for _, suffix := range []string{partSuffix, stateSuffix} {
+					_, serr := os.Stat(dest + suffix)
+					testutils.True(t, os.IsNotExist(serr), suffix+" must be removed")
+				}
+				return
+			}
+			testutils.NoError(t, err)
+			testutils.Equal(t, dest, got)
+			data, err := os.ReadFile(got)
+			testutils.NoError(t, err)
+			testutils.Equal(t, string(content), string(data))
+			for _, suffix := range []string{partSuffix, stateSuffix} {
+				_, serr := os.Stat(dest + suffix)
+				testutils.True(t, os.IsNotExist(serr), suffix+" must be removed")
+			}
+		})
+	}
+}
+
+func TestGetAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	m := &Manager{Client: srv.Client(), CacheDir: t.TempDir()}
+	paths, err := m.GetAll(context.Background(),
+		Request{URL: srv.URL + "/a"},
+		Request{URL: srv.URL + "/missing"},
+		Request{URL: srv.URL + "/b"},
+	)
+	testutils.ErrorIs(t, err, Error)
+	testutils.Equal(t, 3, len(paths))
+	testutils.Equal(t, "", paths[1], "failed download must not have path")
+	for i, want := range map[int]string{0: "/a", 2: "/b"} {
+		data, err := os.ReadFile(paths[i])
+		testutils.NoError(t, err)
+		testutils.Equal(t, want, string(data))
+	}
+}
+
+func TestDest(t *testing.T) {
+	tests := []struct {
+		name     string
+		cacheDir string
+		req      Request
+		want     string
+		wantErr  bool
+	}{
+		{name: "destination", req: Request{URL: "https://example.com/a.tar.gz", Dest: "/tmp/a"}, want: "a"},
+		{name: "cache", cacheDir: "/cache", req: Request{URL: "https://example.com/a.tar.gz"}, want: "a.tar.gz"},
+		{name: "cache without file name", cacheDir: "/cache", req: Request{URL: "https://example.com/"}, want: "download"},
+		{name: "no cache directory", req: Request{URL: "https://example.com/a.tar.gz"}, wantErr: true},
+		{name: "invalid url", cacheDir: "/cache", req: Request{URL: "://"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{CacheDir: tt.cacheDir}
+			got, err := m.dest(tt.req)
+			if tt.wantErr {
+				testutils.ErrorIs(t, err, Error)
+				return
+			}
+			testutils.NoError(t, err)
+			testutils.Equal(t, tt.want, filepath.Base(got))
+		})
+	}
+}
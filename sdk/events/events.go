@@ -91,3 +91,24 @@ type Listener[SESS context.Context] interface {
 	OnEvent(scope, key string, cb ActionWithEvent[SESS])
 	OnAnyEvent(cb ActionWithEvent[SESS])
 }
+
+type broadcast struct {
+	Event
+}
+
+// Broadcast marks ev to be delivered also to other running instances
+// of the application when instance broadcasting is enabled with
+// app.instance.broadcast. Events received from other instances are
+// dispatched as local events and are not broadcast further.
+func Broadcast(ev Event) Event {
+	if ev == nil || IsBroadcast(ev) {
+		return ev
+	}
+	return broadcast{ev}
+}
+
+// IsBroadcast reports whether ev is marked with Broadcast.
+func IsBroadcast(ev Event) bool {
+	_, ok := ev.(broadcast)
+	return ok
+}
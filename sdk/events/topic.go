@@ -0,0 +1,447 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var Error = errors.New("events")
+
+// ErrTopicClosed is returned when publishing or subscribing to closed
+// topic.
+var ErrTopicClosed = fmt.Errorf("%w: topic closed", Error)
+
+// DefaultBuffer is buffer size of subscriptions which do not set one.
+const DefaultBuffer = 64
+
+// Policy tells what happens when subscriber buffer is full.
+type Policy int
+
+const (
+	// DropOldest drops oldest buffered message to make room for new one,
+	// publisher never waits.
+	DropOldest Policy = iota
+	// Block makes publisher wait until subscriber has room or context
+	// given to Publish is done.
+	Block
+	// SpillToDisk writes messages which do not fit in buffer to spill
+	// file and delivers them in order once subscriber catches up,
+	// messages must be JSON encodable.
+	SpillToDisk
+)
+
+func (p Policy) String() string {
+	switch p {
+	case DropOldest:
+		return "drop-oldest"
+	case Block:
+		return "block"
+	case SpillToDisk:
+		return "spill-to-disk"
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+// SubscribeOptions configures subscription.
+type SubscribeOptions struct {
+	// Name identifies subscriber in metrics.
+	Name string
+	// Buffer is number of messages buffered for subscriber,
+	// DefaultBuffer when zero.
+	Buffer int
+	// Policy applied when buffer is full.
+	Policy Policy
+	// SpillDir is directory of spill file used by SpillToDisk policy,
+	// os.TempDir when empty.
+	SpillDir string
+}
+
+// TopicMetrics are delivery metrics of topic.
+type TopicMetrics struct {
+	Name string
+	// Published is number of messages published to topic.
+	Published   uint64
+	Subscribers []SubscriptionMetrics
+}
+
+// SubscriptionMetrics are delivery metrics of subscription.
+type SubscriptionMetrics struct {
+	Name   string
+	Policy Policy
+	// Delivered is number of messages handed to subscriber buffer.
+	Delivered uint64
+	// Dropped is number of messages subscriber did not receive.
+	Dropped uint64
+	// Spilled is number of messages written to spill file.
+	Spilled uint64
+	// Pending is number of messages buffered or spilled which
+	// subscriber has not received yet.
+	Pending int
+}
+
+// Topic is typed message queue delivering each published message to
+// all subscribers. Unlike events which are dispatched through the
+// application event bus, topics are meant for high volume data
+// exchanged between services, each subscriber has own buffer and
+// policy deciding what happens when it falls behind.
+type Topic[T any] struct {
+	name      string
+	mu        sync.RWMutex
+	subs      []*Subscription[T]
+	closed    bool
+	published atomic.Uint64
+}
+
+// NewTopic returns topic with given name, topics are usually declared
+// as package variables shared by publishing and subscribing services.
+func NewTopic[T any](name string) *Topic[T] {
+	return &Topic[T]{name: name}
+}
+
+// Name returns name of the topic.
+func (t *Topic[T]) Name() string {
+	return t.name
+}
+
+// Publish delivers v to all subscribers. Context limits how long
+// publisher waits for subscribers with Block policy, errors of such
+// subscribers and spill errors are returned joined.
+func (t *Topic[T]) Publish(ctx context.Context, v T) error {
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return fmt.Errorf("%w: %s", ErrTopicClosed, t.name)
+	}
+	subs := t.subs
+	t.mu.RUnlock()
+
+	t.published.Add(1)
+	var errs []error
+	for _, s := range subs {
+		if err := s.deliver(ctx, v); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %s: %w", Error, t.name, s.opts.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Subscribe adds subscriber to the topic, it receives messages
+// published after it subscribed.
+func (t *Topic[T]) Subscribe(opts SubscribeOptions) (*Subscription[T], error) {
+	if opts.Buffer <= 0 {
+		opts.Buffer = DefaultBuffer
+	}
+	s := &Subscription[T]{
+		topic: t,
+		opts:  opts,
+		ch:    make(chan T, opts.Buffer),
+		done:  make(chan struct{}),
+	}
+	if opts.Policy == SpillToDisk {
+		s.spill = &spill{wake: make(chan struct{}, 1)}
+		s.wg.Add(1)
+		go s.pump()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		s.close()
+		return nil, fmt.Errorf("%w: %s", ErrTopicClosed, t.name)
+	}
+	// subs is copied on write so that Publish can iterate without lock.
+	t.subs = append(t.subs[:len(t.subs):len(t.subs)], s)
+	return s, nil
+}
+
+// Close unsubscribes all subscribers, channels of subscriptions are
+// closed and messages buffered in them can still be received.
+func (t *Topic[T]) Close() {
+	t.mu.Lock()
+	subs := t.subs
+	t.subs = nil
+	t.closed = true
+	t.mu.Unlock()
+	for _, s := range subs {
+		s.close()
+	}
+}
+
+// Metrics returns delivery metrics of the topic.
+func (t *Topic[T]) Metrics() TopicMetrics {
+	t.mu.RLock()
+	subs := t.subs
+	t.mu.RUnlock()
+	m := TopicMetrics{
+		Name:      t.name,
+		Published: t.published.Load(),
+	}
+	for _, s := range subs {
+		m.Subscribers = append(m.Subscribers, s.Metrics())
+	}
+	return m
+}
+
+func (t *Topic[T]) remove(s *Subscription[T]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	subs := make([]*Subscription[T], 0, len(t.subs))
+	for _, sub := range t.subs {
+		if sub != s {
+			subs = append(subs, sub)
+		}
+	}
+	t.subs = subs
+}
+
+// Subscription receives messages of topic.
+type Subscription[T any] struct {
+	topic *Topic[T]
+	opts  SubscribeOptions
+	ch    chan T
+	done  chan struct{}
+	once  sync.Once
+	// sendmu is held for reading while sending to ch, it is locked
+	// before ch is closed so that nothing is sent to closed channel.
+	sendmu sync.RWMutex
+	// wg tracks spill pump.
+	wg    sync.WaitGroup
+	spill *spill
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	spilled   atomic.Uint64
+}
+
+// C returns channel messages are received from, it is closed when
+// subscription is cancelled or topic is closed.
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// Unsubscribe cancels subscription, pending spilled messages are
+// discarded.
+func (s *Subscription[T]) Unsubscribe() {
+	s.topic.remove(s)
+	s.close()
+}
+
+// Metrics returns delivery metrics of the subscription.
+func (s *Subscription[T]) Metrics() SubscriptionMetrics {
+	m := SubscriptionMetrics{
+		Name:      s.opts.Name,
+		Policy:    s.opts.Policy,
+		Delivered: s.delivered.Load(),
+		Dropped:   s.dropped.Load(),
+		Spilled:   s.spilled.Load(),
+		Pending:   len(s.ch),
+	}
+	if s.spill != nil {
+		s.spill.mu.Lock()
+		m.Pending += s.spill.pending
+		s.spill.mu.Unlock()
+	}
+	return m
+}
+
+func (s *Subscription[T]) close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+		s.sendmu.Lock()
+		close(s.ch)
+		s.sendmu.Unlock()
+		if s.spill != nil {
+			s.spill.remove()
+		}
+	})
+}
+
+func (s *Subscription[T]) deliver(ctx context.Context, v T) error {
+	s.sendmu.RLock()
+	defer s.sendmu.RUnlock()
+	select {
+	case <-s.done:
+		return nil
+	default:
+	}
+
+	switch s.opts.Policy {
+	case Block:
+		select {
+		case s.ch <- v:
+			s.delivered.Add(1)
+		case <-s.done:
+		case <-ctx.Done():
+			s.dropped.Add(1)
+			return ctx.Err()
+		}
+		return nil
+	case SpillToDisk:
+		return s.push(v)
+	}
+
+	for {
+		select {
+		case s.ch <- v:
+			s.delivered.Add(1)
+			return nil
+		default:
+		}
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// spill is spill file of subscription, records are JSON lines written
+// at woff and read from roff.
+type spill struct {
+	mu      sync.Mutex
+	f       *os.File
+	roff    int64
+	woff    int64
+	pending int
+	wake    chan struct{}
+}
+
+// push sends v to subscriber or appends it to spill file, nothing is
+// sent directly while there are spilled messages to keep order.
+func (s *Subscription[T]) push(v T) error {
+	sp := s.spill
+	sp.mu.Lock()
+	if sp.pending == 0 {
+		select {
+		case s.ch <- v:
+			sp.mu.Unlock()
+			s.delivered.Add(1)
+			return nil
+		default:
+		}
+	}
+	err := sp.write(s.opts.SpillDir, s.topic.name, v)
+	sp.mu.Unlock()
+	if err != nil {
+		s.dropped.Add(1)
+		return err
+	}
+	s.spilled.Add(1)
+	select {
+	case sp.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// pump delivers spilled messages as subscriber receives buffered ones.
+func (s *Subscription[T]) pump() {
+	defer s.wg.Done()
+	sp := s.spill
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-sp.wake:
+		}
+		for {
+			line, ok := sp.next()
+			if !ok {
+				break
+			}
+			var v T
+			if err := json.Unmarshal(line, &v); err != nil {
+				s.dropped.Add(1)
+				sp.advance(len(line))
+				continue
+			}
+			s.sendmu.RLock()
+			select {
+			case s.ch <- v:
+				s.delivered.Add(1)
+			case <-s.done:
+				s.sendmu.RUnlock()
+				return
+			}
+			s.sendmu.RUnlock()
+			sp.advance(len(line))
+		}
+	}
+}
+
+func (sp *spill) write(dir, topic string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if sp.f == nil {
+		if dir == "" {
+			dir = os.TempDir()
+		}
+		name := strings.NewReplacer("/", "-", string(os.PathSeparator), "-").Replace(topic)
+		if sp.f, err = os.CreateTemp(dir, name+"-*.spill"); err != nil {
+			return err
+		}
+	}
+	data = append(data, '\n')
+	if _, err := sp.f.WriteAt(data, sp.woff); err != nil {
+		return err
+	}
+	sp.woff += int64(len(data))
+	sp.pending++
+	return nil
+}
+
+// next returns next spilled record including its newline, file is
+// truncated when all records were delivered.
+func (sp *spill) next() ([]byte, bool) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.pending == 0 {
+		if sp.f != nil && sp.woff > 0 {
+			_ = sp.f.Truncate(0)
+			sp.roff, sp.woff = 0, 0
+		}
+		return nil, false
+	}
+	r := bufio.NewReader(io.NewSectionReader(sp.f, sp.roff, sp.woff-sp.roff))
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		// unreadable spill file, discard what is left.
+		sp.pending = 0
+		return nil, false
+	}
+	return line, true
+}
+
+func (sp *spill) advance(n int) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.roff += int64(n)
+	sp.pending--
+}
+
+func (sp *spill) remove() {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.f == nil {
+		return
+	}
+	name := sp.f.Name()
+	_ = sp.f.Close()
+	_ = os.Remove(name)
+	sp.f = nil
+}
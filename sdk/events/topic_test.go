@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package events_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/sdk/events"
+)
+
+type sample struct {
+	Seq int `json:"seq"`
+}
+
+func receive(t *testing.T, sub *events.Subscription[sample], n int) []int {
+	t.Helper()
+	var got []int
+	for len(got) < n {
+		select {
+		case v, ok := <-sub.C():
+			if !ok {
+				return got
+			}
+			got = append(got, v.Seq)
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of %d messages", len(got), n)
+		}
+	}
+	return got
+}
+
+func TestTopicDropOldest(t *testing.T) {
+	topic := events.NewTopic[sample]("samples")
+	sub, err := topic.Subscribe(events.SubscribeOptions{Name: "slow", Buffer: 2})
+	testutils.NoError(t, err)
+
+	for i := 1; i <= 5; i++ {
+		testutils.NoError(t, topic.Publish(context.Background(), sample{Seq: i}))
+	}
+	testutils.EqualAny(t, []int{4, 5}, receive(t, sub, 2))
+
+	m := topic.Metrics()
+	testutils.Equal(t, uint64(5), m.Published)
+	testutils.Equal(t, 1, len(m.Subscribers))
+	testutils.Equal(t, uint64(5), m.Subscribers[0].Delivered)
+	testutils.Equal(t, uint64(3), m.Subscribers[0].Dropped)
+	testutils.Equal(t, 0, m.Subscribers[0].Pending)
+
+	sub.Unsubscribe()
+	_, ok := <-sub.C()
+	testutils.False(t, ok, "channel must be closed")
+	testutils.Equal(t, 0, len(topic.Metrics().Subscribers))
+}
+
+func TestTopicBlock(t *testing.T) {
+	topic := events.NewTopic[sample]("samples")
+	sub, err := topic.Subscribe(events.SubscribeOptions{Buffer: 1, Policy: events.Block})
+	testutils.NoError(t, err)
+
+	testutils.NoError(t, topic.Publish(context.Background(), sample{Seq: 1}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = topic.Publish(ctx, sample{Seq: 2})
+	testutils.ErrorIs(t, err, context.DeadlineExceeded)
+	testutils.ErrorIs(t, err, events.Error)
+
+	go func() {
+		_ = topic.Publish(context.Background(), sample{Seq: 3})
+	}()
+	testutils.EqualAny(t, []int{1, 3}, receive(t, sub, 2))
+	testutils.Equal(t, uint64(1), sub.Metrics().Dropped)
+
+	topic.Close()
+	testutils.ErrorIs(t, topic.Publish(context.Background(), sample{}), events.ErrTopicClosed)
+	_, err = topic.Subscribe(events.SubscribeOptions{})
+	testutils.ErrorIs(t, err, events.ErrTopicClosed)
+}
+
+func TestTopicSpillToDisk(t *testing.T) {
+	dir := t.TempDir()
+	topic := events.NewTopic[sample]("samples")
+	sub, err := topic.Subscribe(events.SubscribeOptions{Buffer: 2, Policy: events.SpillToDisk, SpillDir: dir})
+	testutils.NoError(t, err)
+
+	for i := 1; i <= 10; i++ {
+		testutils.NoError(t, topic.Publish(context.Background(), sample{Seq: i}))
+	}
+	m := sub.Metrics()
+	testutils.Equal(t, uint64(8), m.Spilled)
+	testutils.Equal(t, 10, m.Pending)
+
+	// order is kept while subscriber catches up
+	testutils.EqualAny(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, receive(t, sub, 10))
+	testutils.NoError(t, topic.Publish(context.Background(), sample{Seq: 11}))
+	testutils.EqualAny(t, []int{11}, receive(t, sub, 1))
+
+	// metrics are final once spill pump has stopped
+	sub.Unsubscribe()
+	m = sub.Metrics()
+	testutils.Equal(t, uint64(11), m.Delivered)
+	testutils.Equal(t, uint64(0), m.Dropped)
+	testutils.Equal(t, 0, m.Pending)
+	entries, err := os.ReadDir(dir)
+	testutils.NoError(t, err)
+	testutils.Equal(t, 0, len(entries), "spill file must be removed")
+
+	// values which can not be spilled are reported
+	funcs := events.NewTopic[func()]("funcs")
+	_, err = funcs.Subscribe(events.SubscribeOptions{Buffer: 1, Policy: events.SpillToDisk, SpillDir: dir})
+	testutils.NoError(t, err)
+	testutils.NoError(t, funcs.Publish(context.Background(), func() {}))
+	err = funcs.Publish(context.Background(), func() {})
+	testutils.True(t, err != nil && errors.Is(err, events.Error), "spill error must be returned")
+	funcs.Close()
+}
@@ -0,0 +1,218 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package filesync synchronizes directory trees rsync style. Files are
+// compared by SHA-256 checksum and only changed files are transferred.
+// Trees can be local directories or directories on remote hosts
+// reached with ssh.
+package filesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/happy-sdk/happy/sdk/services/watcher"
+)
+
+var Error = errors.New("filesync")
+
+// Entry is regular file of tree.
+type Entry struct {
+	// Path is slash separated path relative to tree root.
+	Path string
+	Size int64
+	// Sum is hex encoded SHA-256 checksum of file content.
+	Sum  string
+	Exec bool
+}
+
+// Tree is directory tree files are synchronized from or to.
+type Tree interface {
+	// String returns tree location for messages.
+	String() string
+	// List returns regular files of tree keyed by path.
+	List(ctx context.Context) (map[string]Entry, error)
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
+	// Write creates or replaces file at path with content of r,
+	// parent directories are created as needed.
+	Write(ctx context.Context, path string, exec bool, r io.Reader) error
+	Remove(ctx context.Context, path string) error
+}
+
+// Options control which files are synchronized.
+type Options struct {
+	// Include patterns, all files are included when empty. Patterns
+	// are slash separated globs where ** matches any directories.
+	Include []string
+	// Exclude patterns are applied after Include.
+	Exclude []string
+	// Delete removes files from destination which do not exist in source.
+	Delete bool
+	// DryRun only computes plan without changing destination.
+	DryRun bool
+}
+
+func (o Options) match(path string) bool {
+	if len(o.Include) > 0 && !matchAny(o.Include, path) {
+		return false
+	}
+	return !matchAny(o.Exclude, path)
+}
+
+// Op is operation of plan.
+type Op uint8
+
+const (
+	Add Op = iota + 1
+	Update
+	Delete
+	// Chmod changes only executable bit of file.
+	Chmod
+)
+
+func (op Op) String() string {
+	switch op {
+	case Add:
+		return "+"
+	case Update:
+		return "~"
+	case Delete:
+		return "-"
+	case Chmod:
+		return "x"
+	}
+	return "?"
+}
+
+// Change is single planned change of destination.
+type Change struct {
+	Op   Op
+	Path string
+	Size int64
+	Exec bool
+}
+
+// Plan is list of changes needed to make destination match source.
+type Plan []Change
+
+// Bytes returns number of bytes plan transfers.
+func (p Plan) Bytes() int64 {
+	var n int64
+	for _, c := range p {
+		if c.Op == Add || c.Op == Update {
+			n += c.Size
+		}
+	}
+	return n
+}
+
+// String returns plan as diff like listing, one change per line
+// prefixed with +, ~, - or x. Paths with control characters are quoted.
+func (p Plan) String() string {
+	var b strings.Builder
+	for _, c := range p {
+		if strings.ContainsAny(c.Path, "\n\r\t") {
+			fmt.Fprintf(&b, "%s %q\n", c.Op, c.Path)
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s\n", c.Op, c.Path)
+	}
+	return b.String()
+}
+
+// Diff returns plan which makes dst match src.
+func Diff(ctx context.Context, src, dst Tree, opts Options) (Plan, error) {
+	srcFiles, err := src.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dstFiles, err := dst.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan Plan
+	for path, s := range srcFiles {
+		if !opts.match(path) {
+			continue
+		}
+		d, ok := dstFiles[path]
+		switch {
+		case !ok:
+			plan = append(plan, Change{Op: Add, Path: path, Size: s.Size, Exec: s.Exec})
+		case d.Sum != s.Sum:
+			plan = append(plan, Change{Op: Update, Path: path, Size: s.Size, Exec: s.Exec})
+		case d.Exec != s.Exec:
+			plan = append(plan, Change{Op: Chmod, Path: path, Exec: s.Exec})
+		}
+	}
+	if opts.Delete {
+		for path := range dstFiles {
+			if _, ok := srcFiles[path]; !ok && opts.match(path) {
+				plan = append(plan, Change{Op: Delete, Path: path})
+			}
+		}
+	}
+	sort.Slice(plan, func(i, j int) bool { return plan[i].Path < plan[j].Path })
+	return plan, nil
+}
+
+// Apply applies plan computed by Diff, chmod of unchanged content is
+// applied by rewriting the file.
+func Apply(ctx context.Context, src, dst Tree, plan Plan) error {
+	for _, c := range plan {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if c.Op == Delete {
+			if err := dst.Remove(ctx, c.Path); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := copyFile(ctx, src, dst, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync makes dst match src and returns applied plan, with
+// Options.DryRun plan is returned without applying it.
+func Sync(ctx context.Context, src, dst Tree, opts Options) (Plan, error) {
+	plan, err := Diff(ctx, src, dst, opts)
+	if err != nil || opts.DryRun {
+		return plan, err
+	}
+	return plan, Apply(ctx, src, dst, plan)
+}
+
+func copyFile(ctx context.Context, src, dst Tree, c Change) error {
+	r, err := src.Open(ctx, c.Path)
+	if err != nil {
+		return err
+	}
+	err = dst.Write(ctx, c.Path, c.Exec, r)
+	if cerr := r.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func matchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if watcher.Match(pattern, path) {
+			return true
+		}
+		// directory pattern matches everything below it
+		if !strings.ContainsAny(pattern, "*?[") && strings.HasPrefix(path, strings.TrimSuffix(pattern, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package filesync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Local is tree in local directory.
+type Local struct {
+	Dir string
+}
+
+func (l *Local) String() string {
+	return l.Dir
+}
+
+func (l *Local) List(ctx context.Context) (map[string]Entry, error) {
+	files := make(map[string]Entry)
+	if _, err := os.Stat(l.Dir); os.IsNotExist(err) {
+		return files, nil
+	}
+	err := filepath.WalkDir(l.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(l.Dir, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sum, err := fileSum(p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		files[rel] = Entry{Path: rel, Size: info.Size(), Sum: sum, Exec: info.Mode()&0111 != 0}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return files, nil
+}
+
+func (l *Local) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	p, err := l.path(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return f, nil
+}
+
+// Write writes file atomically through temporary file in same directory.
+func (l *Local) Write(ctx context.Context, path string, exec bool, r io.Reader) error {
+	dest, err := l.path(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".filesync-*")
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	mode := os.FileMode(0644)
+	if exec {
+		mode = 0755
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return nil
+}
+
+func (l *Local) Remove(ctx context.Context, path string) error {
+	p, err := l.path(path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return nil
+}
+
+// path returns local path of slash separated path, paths escaping
+// tree root are rejected.
+func (l *Local) path(path string) (string, error) {
+	p := filepath.FromSlash(path)
+	if !filepath.IsLocal(p) {
+		return "", fmt.Errorf("%w: path %q is outside of %s", Error, path, l.Dir)
+	}
+	return filepath.Join(l.Dir, p), nil
+}
+
+func fileSum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package filesync
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/happy-sdk/happy/pkg/diff"
+)
+
+// previewLimit is max size of file content diffed by Preview.
+const previewLimit = 1 << 20
+
+// Preview returns line diffs of text files plan adds, updates or
+// deletes. Binary files and files larger than 1 MiB are skipped, they
+// are still listed by Plan.String.
+func Preview(ctx context.Context, src, dst Tree, plan Plan) ([]*diff.File, error) {
+	var files []*diff.File
+	for _, c := range plan {
+		if c.Op == Chmod || c.Size > previewLimit {
+			continue
+		}
+		var (
+			old, new []byte
+			text     = true
+			err      error
+		)
+		if c.Op != Add {
+			if old, text, err = readText(ctx, dst, c.Path); err != nil {
+				return nil, err
+			}
+		}
+		if text && c.Op != Delete {
+			if new, text, err = readText(ctx, src, c.Path); err != nil {
+				return nil, err
+			}
+		}
+		if !text {
+			continue
+		}
+		oldName, newName := "a/"+c.Path, "b/"+c.Path
+		switch c.Op {
+		case Add:
+			oldName = "/dev/null"
+		case Delete:
+			newName = "/dev/null"
+		}
+		if f := diff.Text(oldName, newName, string(old), string(new)); f.Changed() {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// readText reports false when file is binary or too large to preview.
+func readText(ctx context.Context, t Tree, path string) ([]byte, bool, error) {
+	r, err := t.Open(ctx, path)
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, previewLimit+1))
+	if cerr := r.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) > previewLimit || bytes.IndexByte(data, 0) >= 0 {
+		return nil, false, nil
+	}
+	return data, true, nil
+}
@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package filesync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// SSH is tree in directory on remote host. Commands are run with ssh
+// client found in PATH and remote host needs POSIX shell with find and
+// sha256sum or shasum.
+type SSH struct {
+	// Host is ssh destination e.g. user@example.com or ssh config alias.
+	Host string
+	Dir  string
+	Port int
+	// Identity is path of private key file.
+	Identity string
+	// Options are additional ssh -o options e.g. StrictHostKeyChecking=yes.
+	Options []string
+}
+
+func (s *SSH) String() string {
+	return s.Host + ":" + s.Dir
+}
+
+// listScript prints sum, size, executable bit and path of every regular
+// file, records are NUL terminated so any file name is safe.
+const listScript = `cd -- %s 2>/dev/null || exit 0
+find . -type f -exec sh -c 'for f; do
+s=$(($(wc -c < "$f")))
+h=$(sha256sum < "$f" 2>/dev/null || shasum -a 256 < "$f")
+x=0; [ -x "$f" ] && x=1
+printf "%%s %%s %%s %%s\0" "${h%%%% *}" "$s" "$x" "${f#./}"
+done' sh {} +`
+
+func (s *SSH) List(ctx context.Context) (map[string]Entry, error) {
+	var stdout bytes.Buffer
+	if err := s.run(ctx, fmt.Sprintf(listScript, shellQuote(s.Dir)), nil, &stdout); err != nil {
+		return nil, err
+	}
+	files := make(map[string]Entry)
+	for _, rec := range strings.Split(stdout.String(), "\x00") {
+		if rec == "" {
+			continue
+		}
+		fields := strings.SplitN(rec, " ", 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("%w: %s: unexpected listing %q", Error, s, rec)
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: unexpected size %q", Error, s, fields[1])
+		}
+		files[fields[3]] = Entry{Path: fields[3], Size: size, Sum: fields[0], Exec: fields[2] == "1"}
+	}
+	return files, nil
+}
+
+func (s *SSH) Open(ctx context.Context, p string) (io.ReadCloser, error) {
+	remote, err := s.path(p)
+	if err != nil {
+		return nil, err
+	}
+	cmd := s.command(ctx, "cat -- "+shellQuote(remote))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%w: ssh: %s", Error, err.Error())
+	}
+	return &sshReader{ReadCloser: out, cmd: cmd, stderr: &stderr}, nil
+}
+
+// Write uploads file to temporary path and renames it in place.
+func (s *SSH) Write(ctx context.Context, p string, exec bool, r io.Reader) error {
+	remote, err := s.path(p)
+	if err != nil {
+		return err
+	}
+	mode := "644"
+	if exec {
+		mode = "755"
+	}
+	dest := shellQuote(remote)
+	tmp := shellQuote(path.Join(path.Dir(remote), ".filesync-"+path.Base(remote)))
+	script := fmt.Sprintf("mkdir -p -- %s && cat > %s && chmod %s %s && mv -f -- %s %s",
+		shellQuote(path.Dir(remote)), tmp, mode, tmp, tmp, dest)
+	return s.run(ctx, script, r, nil)
+}
+
+func (s *SSH) Remove(ctx context.Context, p string) error {
+	remote, err := s.path(p)
+	if err != nil {
+		return err
+	}
+	return s.run(ctx, "rm -f -- "+shellQuote(remote), nil, nil)
+}
+
+func (s *SSH) path(p string) (string, error) {
+	clean := path.Clean(p)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%w: path %q is outside of %s", Error, p, s)
+	}
+	return path.Join(s.Dir, clean), nil
+}
+
+func (s *SSH) command(ctx context.Context, script string) *exec.Cmd {
+	args := []string{"-o", "BatchMode=yes"}
+	if s.Port > 0 {
+		args = append(args, "-p", strconv.Itoa(s.Port))
+	}
+	if s.Identity != "" {
+		args = append(args, "-i", s.Identity)
+	}
+	for _, opt := range s.Options {
+		args = append(args, "-o", opt)
+	}
+	args = append(args, "--", s.Host, script)
+	return exec.CommandContext(ctx, "ssh", args...)
+}
+
+func (s *SSH) run(ctx context.Context, script string, stdin io.Reader, stdout io.Writer) error {
+	cmd := s.command(ctx, script)
+	var stderr bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: ssh %s: %s: %s", Error, s.Host, err.Error(), strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+type sshReader struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+}
+
+func (r *sshReader) Close() error {
+	_ = r.ReadCloser.Close()
+	if err := r.cmd.Wait(); err != nil {
+		return fmt.Errorf("%w: ssh: %s: %s", Error, err.Error(), strings.TrimSpace(r.stderr.String()))
+	}
+	return nil
+}
+
+// ParseTree returns tree for location, host:dir and user@host:dir
+// locations are SSH trees, other locations are local directories.
+func ParseTree(location string) Tree {
+	host, dir, ok := strings.Cut(location, ":")
+	// drive letters and paths containing colon after separator are local
+	if !ok || len(host) <= 1 || strings.ContainsAny(host, `/\`) {
+		return &Local{Dir: location}
+	}
+	if dir == "" {
+		dir = "."
+	}
+	return &SSH{Host: host, Dir: dir}
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,273 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package filesync
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+)
+
+// fakeSSH is ssh client which runs remote script with local shell,
+// host "unreachable" fails like ssh does when connection fails.
+const fakeSSH = `#!/bin/sh
+while [ "$1" != "--" ]; do shift; done
+shift
+host=$1
+shift
+if [ "$host" = "unreachable" ]; then
+	echo "ssh: connect to host unreachable port 22: Connection refused" >&2
+	exit 255
+fi
+exec sh -c "$1"
+`
+
+// withFakeSSH puts fake ssh client first in PATH.
+func withFakeSSH(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ssh client requires POSIX shell")
+	}
+	bin := t.TempDir()
+	testutils.NoError(t, os.WriteFile(filepath.Join(bin, "ssh"), []byte(fakeSSH), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		testutils.NoError(t, os.MkdirAll(filepath.Dir(p), 0750))
+		mode := os.FileMode(0644)
+		if strings.HasSuffix(name, ".sh") {
+			mode = 0755
+		}
+		testutils.NoError(t, os.WriteFile(p, []byte(content), mode))
+	}
+}
+
+func TestSSHSync(t *testing.T) {
+	withFakeSSH(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		src  map[string]string
+		dst  map[string]string
+		opts Options
+		want string
+	}{
+		{
+			name: "empty destination",
+			src: map[string]string{
+				"a.txt":          "a",
+				"dir/b.txt":      "b",
+				"run.sh":         "#!/bin/sh",
+				"with space.txt": "space",
+				"it's.txt":       "quote",
+			},
+			want: "+ a.txt\n+ dir/b.txt\n+ it's.txt\n+ run.sh\n+ with space.txt\n",
+		},
+		{
+			name: "missing destination directory",
+			src:  map[string]string{"a.txt": "a"},
+			want: "+ a.txt\n",
+		},
+		{
+			name: "update and keep",
+			src:  map[string]string{"a.txt": "new", "b.txt": "b"},
+			dst:  map[string]string{"a.txt": "old", "b.txt": "b", "c.txt": "c"},
+			want: "~ a.txt\n",
+		},
+		{
+			name: "delete",
+			src:  map[string]string{"a.txt": "a"},
+			dst:  map[string]string{"a.txt": "a", "dir/c.txt": "c"},
+			opts: Options{Delete: true},
+			want: "- dir/c.txt\n",
+		},
+		{
+			name: "exclude",
+			src:  map[string]string{"a.txt": "a", "tmp/b.txt": "b"},
+			opts: Options{Exclude: []string{"tmp"}},
+			want: "+ a.txt\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := t.TempDir()
+			srcDir := filepath.Join(base, "src")
+			dstDir := filepath.Join(base, "dst")
+			writeFiles(t, srcDir, tt.src)
+			writeFiles(t, dstDir, tt.dst)
+
+			src := &Local{Dir: srcDir}
+			dst := &SSH{Host: "example", Dir: dstDir}
+			plan, err := Sync(ctx, src, dst, tt.opts)
+			testutils.NoError(t, err)
+			testutils.Equal(t, tt.want, plan.String())
+
+			// destination matches source after sync
+			plan, err = Diff(ctx, src, dst, tt.opts)
+			testutils.NoError(t, err)
+			testutils.Equal(t, "", plan.String(), "plan after sync")
+
+			// and back from remote to empty local directory
+			back := &Local{Dir: filepath.Join(base, "back")}
+			_, err = Sync(ctx, dst, back, Options{})
+			testutils.NoError(t, err)
+			plan, err = Diff(ctx, dst, back, Options{})
+			testutils.NoError(t, err)
+			testutils.Equal(t, "", plan.String(), "plan after sync back")
+		})
+	}
+}
+
+func TestSSHList(t *testing.T) {
+	withFakeSSH(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "hello", "bin/run.sh": "#!/bin/sh"})
+
+	remote, err := (&SSH{Host: "example", Dir: dir}).List(context.Background())
+	testutils.NoError(t, err)
+	local, err := (&Local{Dir: dir}).List(context.Background())
+	testutils.NoError(t, err)
+
+	testutils.Equal(t, len(local), len(remote))
+	for path, want := range local {
+		testutils.Equal(t, want, remote[path], path)
+	}
+	testutils.True(t, remote["bin/run.sh"].Exec, "run.sh must be executable")
+}
+
+func TestSSHErrors(t *testing.T) {
+	withFakeSSH(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		fn   func(s *SSH) error
+	}{
+		{
+			name: "list unreachable",
+			fn: func(s *SSH) error {
+				s.Host = "unreachable"
+				_, err := s.List(ctx)
+				return err
+			},
+		},
+		{
+			name: "write unreachable",
+			fn: func(s *SSH) error {
+				s.Host = "unreachable"
+				return s.Write(ctx, "a.txt", false, strings.NewReader("a"))
+			},
+		},
+		{
+			name: "open missing file",
+			fn: func(s *SSH) error {
+				r, err := s.Open(ctx, "missing.txt")
+				if err != nil {
+					return err
+				}
+				_, _ = io.ReadAll(r)
+				return r.Close()
+			},
+		},
+		{
+			name: "open outside",
+			fn: func(s *SSH) error {
+				_, err := s.Open(ctx, "../a.txt")
+				return err
+			},
+		},
+		{
+			name: "write outside",
+			fn: func(s *SSH) error {
+				return s.Write(ctx, "dir/../../a.txt", false, strings.NewReader("a"))
+			},
+		},
+		{
+			name: "remove absolute",
+			fn: func(s *SSH) error {
+				return s.Remove(ctx, "/etc/passwd")
+			},
+		},
+		{
+			name: "canceled",
+			fn: func(s *SSH) error {
+				ctx, cancel := context.WithCancel(ctx)
+				cancel()
+				_, err := s.List(ctx)
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fn(&SSH{Host: "example", Dir: dir})
+			testutils.ErrorIs(t, err, Error)
+		})
+	}
+	_, err := os.Stat(filepath.Join(filepath.Dir(dir), "a.txt"))
+	testutils.True(t, os.IsNotExist(err), "file outside of tree must not be written")
+}
+
+func TestSSHCommand(t *testing.T) {
+	s := &SSH{
+		Host:     "user@example.com",
+		Dir:      "/srv",
+		Port:     2222,
+		Identity: "/keys/id",
+		Options:  []string{"StrictHostKeyChecking=yes"},
+	}
+	cmd := s.command(context.Background(), "true")
+	testutils.Equal(t,
+		"ssh -o BatchMode=yes -p 2222 -i /keys/id -o StrictHostKeyChecking=yes -- user@example.com true",
+		strings.Join(append([]string{"ssh"}, cmd.Args[1:]...), " "))
+}
+
+func TestParseTree(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+		ssh      bool
+	}{
+		{"dir", "dir", false},
+		{"/srv/app", "/srv/app", false},
+		{"host:/srv/app", "host:/srv/app", true},
+		{"user@host:app", "user@host:app", true},
+		{"host:", "host:.", true},
+		{`C:\app`, `C:\app`, false},
+		{"./dir:name", "./dir:name", false},
+	}
+	for _, tt := range tests {
+		tree := ParseTree(tt.location)
+		_, ok := tree.(*SSH)
+		testutils.Equal(t, tt.ssh, ok, tt.location)
+		testutils.Equal(t, tt.want, tree.String(), tt.location)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"dir", "'dir'"},
+		{"with space", "'with space'"},
+		{"it's", `'it'\''s'`},
+		{"$(rm -rf /)", "'$(rm -rf /)'"},
+	}
+	for _, tt := range tests {
+		testutils.Equal(t, tt.want, shellQuote(tt.in), tt.in)
+	}
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package instance
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/vars"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/events"
+	"github.com/happy-sdk/happy/sdk/internal"
+)
+
+// broadcastTimeout limits time spent delivering event to single instance.
+const broadcastTimeout = time.Second
+
+// message is broadcast event on the wire, one JSON object per line.
+type message struct {
+	From    string         `json:"from"`
+	Scope   string         `json:"scope"`
+	Key     string         `json:"key"`
+	Value   string         `json:"value,omitempty"`
+	Payload map[string]any `json:"payload,omitempty"`
+}
+
+// EnableBroadcast starts listening for events broadcast by other
+// running instances of the application and delivers events dispatched
+// with events.Broadcast to them. Instances find each other by unix
+// sockets next to their pid files.
+func (inst *Instance) EnableBroadcast() error {
+	if inst.listener != nil {
+		return nil
+	}
+	inst.socket = strings.TrimSuffix(inst.pidfile, ".pid") + ".sock"
+	_ = os.Remove(inst.socket)
+	ln, err := net.Listen("unix", inst.socket)
+	if err != nil {
+		return fmt.Errorf("%w: failed to listen for broadcast events: %s", Error, err.Error())
+	}
+	inst.listener = ln
+	internal.Log(inst.sess.Log(), "listening for broadcast events", slog.String("socket", inst.socket))
+
+	go inst.accept(ln)
+	session.AttachBroadcaster(inst.sess, inst.broadcast)
+	return nil
+}
+
+func (inst *Instance) accept(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				inst.sess.Log().Warn("broadcast listener failed", slog.String("err", err.Error()))
+			}
+			return
+		}
+		go inst.receive(conn)
+	}
+}
+
+func (inst *Instance) receive(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(broadcastTimeout))
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			inst.sess.Log().Warn("invalid broadcast message", slog.String("err", err.Error()))
+			return
+		}
+		if msg.Scope == "" || msg.Key == "" || msg.From == inst.id.String() {
+			continue
+		}
+		var payload *vars.Map
+		if len(msg.Payload) > 0 {
+			payload = new(vars.Map)
+			for k, v := range msg.Payload {
+				if err := payload.Store(k, v); err != nil {
+					inst.sess.Log().Warn("invalid broadcast payload", slog.String("key", k), slog.String("err", err.Error()))
+				}
+			}
+		}
+		internal.Log(inst.sess.Log(), "received broadcast event",
+			slog.String("from", msg.From),
+			slog.String("scope", msg.Scope),
+			slog.String("key", msg.Key))
+		inst.sess.Dispatch(events.New(msg.Scope, msg.Key).Create(msg.Value, payload))
+	}
+}
+
+// broadcast delivers ev to all other instances listening for broadcast
+// events, sockets left behind by crashed instances are removed.
+func (inst *Instance) broadcast(ev events.Event) error {
+	msg := message{
+		From:  inst.id.String(),
+		Scope: ev.Scope(),
+		Key:   ev.Key(),
+		Value: ev.String(),
+	}
+	if pl := ev.Payload(); pl != nil && pl.Len() > 0 {
+		msg.Payload = make(map[string]any, pl.Len())
+		pl.Range(func(v vars.Variable) bool {
+			msg.Payload[v.Name()] = v.Any()
+			return true
+		})
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	sockets, err := filepath.Glob(filepath.Join(filepath.Dir(inst.pidfile), "instance-*.sock"))
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, socket := range sockets {
+		if socket == inst.socket {
+			continue
+		}
+		conn, err := net.DialTimeout("unix", socket, broadcastTimeout)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) {
+				internal.Log(inst.sess.Log(), "removing stale broadcast socket", slog.String("socket", socket))
+				_ = os.Remove(socket)
+				continue
+			}
+			errs = append(errs, err)
+			continue
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(broadcastTimeout))
+		if _, err := conn.Write(data); err != nil {
+			errs = append(errs, err)
+		}
+		_ = conn.Close()
+	}
+	return errors.Join(errs...)
+}
+
+func (inst *Instance) closeBroadcast() error {
+	if inst.listener == nil {
+		return nil
+	}
+	err := inst.listener.Close()
+	inst.listener = nil
+	if rerr := os.Remove(inst.socket); rerr != nil && !errors.Is(rerr, os.ErrNotExist) {
+		err = errors.Join(err, rerr)
+	}
+	return err
+}
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"path/filepath"
 	"time"
@@ -22,6 +23,9 @@ import (
 type Settings struct {
 	// How many instances of the applications can be booted at the same time.
 	Max settings.Uint `key:"max" default:"1" desc:"Maximum number of instances of the application that can be booted at the same time"`
+	// Broadcast delivers events dispatched with events.Broadcast to other
+	// running instances of the application and receives theirs.
+	Broadcast settings.Bool `key:"broadcast" default:"false" mutation:"once" desc:"Exchange broadcast events with other running instances of the application"`
 }
 
 func (s Settings) Blueprint() (*settings.Blueprint, error) {
@@ -37,6 +41,10 @@ type Instance struct {
 	id      ID
 	sess    *session.Context
 	pidfile string
+	unlock  func() error
+
+	socket   string
+	listener net.Listener
 }
 
 var Error = errors.New("instance error")
@@ -66,19 +74,16 @@ func New(sess *session.Context) (*Instance, error) {
 		return nil, fmt.Errorf("%w: pids directory not found: %s", Error, pidsdir)
 	}
 
-	pidfiles, err := os.ReadDir(pidsdir)
-	if err != nil {
-		return nil, err
-	}
-
 	inst := &Instance{
 		id:   ID(sess.Opts().Get("app.instance.id").String()),
 		sess: sess,
 	}
 
-	if len(pidfiles) >= sess.Settings().Get("app.instance.max").Value().Int() {
-		return nil, fmt.Errorf("%w: max instances reached (%s)", Error, sess.Settings().Get("app.instance.max").String())
+	unlock, err := lock(sess, pidsdir, sess.Settings().Get("app.instance.max").Value().Int())
+	if err != nil {
+		return nil, err
 	}
+	inst.unlock = unlock
 
 	inst.pidfile = filepath.Join(
 		pidsdir,
@@ -87,6 +92,7 @@ func New(sess *session.Context) (*Instance, error) {
 	internal.Log(sess.Log(), "create pid lock file", slog.String("file", inst.pidfile))
 
 	if err := os.WriteFile(inst.pidfile, []byte(inst.sess.Opts().Get("app.pid").String()), 0644); err != nil {
+		_ = inst.unlock()
 		return nil, fmt.Errorf("%w: failed to write intance PID file: %s", Error, err.Error())
 	}
 
@@ -95,6 +101,15 @@ func New(sess *session.Context) (*Instance, error) {
 
 func (inst *Instance) Dispose() error {
 	internal.Log(inst.sess.Log(), "disposing instance", slog.String("id", inst.id.String()))
+	if err := inst.closeBroadcast(); err != nil {
+		inst.sess.Log().Warn("failed to close broadcast listener", slog.String("err", err.Error()))
+	}
+	if inst.unlock != nil {
+		if err := inst.unlock(); err != nil {
+			return fmt.Errorf("%w: failed to release instance lock: %s", Error, err.Error())
+		}
+		inst.unlock = nil
+	}
 	// delete the pidfile
 	if _, err := os.Stat(inst.pidfile); err == nil {
 		if err := os.Remove(inst.pidfile); err != nil {
@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+//go:build !windows
+
+package instance
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+// lock counts pid files of running instances.
+func lock(sess *session.Context, pidsdir string, max int) (func() error, error) {
+	pidfiles, err := filepath.Glob(filepath.Join(pidsdir, "instance-*.pid"))
+	if err != nil {
+		return nil, err
+	}
+	if len(pidfiles) >= max {
+		return nil, fmt.Errorf("%w: max instances reached (%d)", Error, max)
+	}
+	return func() error { return nil }, nil
+}
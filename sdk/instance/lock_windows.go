@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package instance
+
+import (
+	"fmt"
+	"log/slog"
+	"syscall"
+	"unsafe"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/internal"
+)
+
+var (
+	kernel32        = syscall.NewLazyDLL("kernel32.dll")
+	procCreateMutex = kernel32.NewProc("CreateMutexW")
+)
+
+const errorAlreadyExists = syscall.Errno(183)
+
+// lock acquires one of max named mutexes. Unlike pid files named mutexes
+// are released by the system when process crashes.
+func lock(sess *session.Context, pidsdir string, max int) (func() error, error) {
+	slug := sess.Get("app.slug").String()
+	for i := 0; i < max; i++ {
+		name, err := syscall.UTF16PtrFromString(fmt.Sprintf("Local\\happy-%s-instance-%d", slug, i))
+		if err != nil {
+			return nil, err
+		}
+		h, _, err := procCreateMutex.Call(0, 0, uintptr(unsafe.Pointer(name)))
+		if h == 0 {
+			return nil, fmt.Errorf("%w: failed to create instance mutex: %s", Error, err.Error())
+		}
+		if err == errorAlreadyExists {
+			_ = syscall.CloseHandle(syscall.Handle(h))
+			continue
+		}
+		internal.Log(sess.Log(), "acquired instance mutex", slog.Int("slot", i))
+		return func() error {
+			return syscall.CloseHandle(syscall.Handle(h))
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: max instances reached (%d)", Error, max)
+}
@@ -9,8 +9,10 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/happy-sdk/happy/sdk/app/session"
+	sdkchangelog "github.com/happy-sdk/happy/sdk/changelog"
 )
 
 func ParseGitLog(sess *session.Context, log string) (*Changelog, error) {
@@ -152,6 +154,28 @@ func (c *Changelog) HasPatchUpdate() bool {
 	return false
 }
 
+// Release returns changes as release of version in shared changelog
+// model, so that it can be rendered in common formats.
+func (c *Changelog) Release(version string, date time.Time) sdkchangelog.Release {
+	r := sdkchangelog.Release{Version: version, Date: date}
+	for _, e := range c.breaking {
+		r.Add(sdkchangelog.SectionBreaking, e.entry())
+	}
+	for _, e := range c.entries {
+		section := sdkchangelog.SectionChanged
+		switch e.Typ.Typ {
+		case "feat":
+			section = sdkchangelog.SectionAdded
+		case "fix":
+			section = sdkchangelog.SectionFixed
+		case "revert":
+			section = sdkchangelog.SectionRemoved
+		}
+		r.Add(section, e.entry())
+	}
+	return r
+}
+
 var breakingChangeType = EntryType{
 	Typ:  "BREAKING CHANGE",
 	Kind: EntryKindMajor,
@@ -185,6 +209,15 @@ type Entry struct {
 	Typ       EntryType
 }
 
+func (e Entry) entry() sdkchangelog.Entry {
+	return sdkchangelog.Entry{
+		Text:   e.Subject,
+		Scope:  e.Typ.Scope,
+		Hash:   e.ShortHash,
+		Author: e.Author,
+	}
+}
+
 type EntryKind int
 
 const (
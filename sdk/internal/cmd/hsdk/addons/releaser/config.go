@@ -19,6 +19,7 @@ import (
 	"github.com/happy-sdk/happy/pkg/vars"
 	"github.com/happy-sdk/happy/sdk/app/session"
 	"github.com/happy-sdk/happy/sdk/cli"
+	"github.com/happy-sdk/happy/sdk/project"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
@@ -45,41 +46,33 @@ func newConfiguration(sess *session.Context, path string, allowDirty bool) error
 		}
 
 		addCmd := exec.Command("git", "add", "-A")
-		addCmd.Dir = sess.Get("releaser.wd").String()
+		addCmd.Dir = sess.Get("addon.releaser.wd").String()
 		if err := cli.Run(sess, addCmd); err != nil {
 			return err
 		}
 		// commitCmd := exec.Command("git", "commit", "--amend", "--no-edit")
-		// commitCmd.Dir = sess.Get("releaser.wd").String()
+		// commitCmd.Dir = sess.Get("addon.releaser.wd").String()
 		// if err := cli.Run(sess, commitCmd); err != nil {
 		// 	return err
 		// }
 		commitCmd := exec.Command("git", "commit", "-sm", "wip: prepare release")
-		commitCmd.Dir = sess.Get("releaser.wd").String()
+		commitCmd.Dir = sess.Get("addon.releaser.wd").String()
 		if err := cli.Run(sess, commitCmd); err != nil {
 			return err
 		}
 	}
 
-	totalmodules := 0
-	if err := filepath.Walk(sess.Get("releaser.wd").String(), func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			return nil
-		}
-		goModPath := filepath.Join(path, "go.mod")
-		if _, err := os.Stat(goModPath); err != nil {
-			return nil
-		}
-		totalmodules++
-		return nil
-	}); err != nil {
+	proj, err := project.Detect(sess.Get("addon.releaser.wd").String())
+	if err != nil {
 		return err
 	}
+	modules, err := proj.Modules()
+	if err != nil {
+		return err
+	}
+	totalmodules := len(modules)
 
-	dotenvp := filepath.Join(sess.Get("releaser.wd").String(), ".env")
+	dotenvp := filepath.Join(sess.Get("addon.releaser.wd").String(), ".env")
 	dotenvb, err := os.ReadFile(dotenvp)
 	if err == nil {
 		sess.Log().Debug("loading .env file", slog.String("path", dotenvp))
@@ -97,16 +90,16 @@ func newConfiguration(sess *session.Context, path string, allowDirty bool) error
 	}
 
 	var opts map[string]string = map[string]string{
-		"releaser.git.branch":       gitinfo.branch,
-		"releaser.git.remote.url":   gitinfo.remoteURL,
-		"releaser.git.remote.name":  gitinfo.remoteName,
-		"releaser.git.dirty":        gitinfo.dirty,
-		"releaser.git.committer":    gitinfo.committer,
-		"releaser.git.email":        gitinfo.email,
-		"releaser.go.modules.count": fmt.Sprint(totalmodules),
-		"releaser.go.monorepo":      fmt.Sprintf("%t", totalmodules > 1),
-		"releaser.github.token":     os.Getenv("GITHUB_TOKEN"),
-		"releaser.git.allow.dirty":  fmt.Sprintf("%t", allowDirty),
+		"addon.releaser.git.branch":       gitinfo.branch,
+		"addon.releaser.git.remote.url":   gitinfo.remoteURL,
+		"addon.releaser.git.remote.name":  gitinfo.remoteName,
+		"addon.releaser.git.dirty":        gitinfo.dirty,
+		"addon.releaser.git.committer":    gitinfo.committer,
+		"addon.releaser.git.email":        gitinfo.email,
+		"addon.releaser.go.modules.count": fmt.Sprint(totalmodules),
+		"addon.releaser.go.monorepo":      fmt.Sprintf("%t", totalmodules > 1),
+		"addon.releaser.github.token":     os.Getenv("GITHUB_TOKEN"),
+		"addon.releaser.git.allow.dirty":  fmt.Sprintf("%t", allowDirty),
 	}
 	for key, value := range opts {
 		if err := sess.Opts().Set(key, value); err != nil {
@@ -119,34 +112,11 @@ func newConfiguration(sess *session.Context, path string, allowDirty bool) error
 
 // resolveProjectWD resolves the working directory of the project.
 func resolveProjectWD(sess *session.Context, path string) error {
-	currentPath, err := filepath.Abs(path)
-	if err != nil {
-		return err
+	proj, err := project.Detect(path)
+	if err != nil || proj.VCS != "git" {
+		return errors.New("git repository not found in any parent directory")
 	}
-
-	// Loop until a Git repository is found or the root directory is reached
-	for {
-		// Check if the current path is a Git repository
-		if isGitRepo(currentPath) {
-			return sess.Opts().Set("releaser.wd", currentPath)
-		}
-
-		parent := filepath.Dir(currentPath)
-		// Check if we've reached the root directory
-		if parent == currentPath {
-			break
-		}
-		currentPath = parent
-	}
-
-	return errors.New("git repository not found in any parent directory")
-}
-
-// isGitRepo checks if the given directory is a Git repository.
-func isGitRepo(path string) bool {
-	gitDir := filepath.Join(path, ".git")
-	_, err := os.Stat(gitDir)
-	return err == nil || !os.IsNotExist(err)
+	return sess.Opts().Set("addon.releaser.wd", proj.VCSRoot)
 }
 
 type gitinfo struct {
@@ -163,7 +133,7 @@ func getGitInfo(sess *session.Context) (*gitinfo, error) {
 
 	// Get current branch
 	branchCmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	branchCmd.Dir = sess.Get("releaser.wd").String()
+	branchCmd.Dir = sess.Get("addon.releaser.wd").String()
 	branch, err := cli.ExecRaw(sess, branchCmd)
 	if err != nil {
 		return nil, err
@@ -172,7 +142,7 @@ func getGitInfo(sess *session.Context) (*gitinfo, error) {
 
 	// Get remote name
 	remoteCmd := exec.Command("git", "rev-parse", "--abbrev-ref", "@{u}")
-	remoteCmd.Dir = sess.Get("releaser.wd").String()
+	remoteCmd.Dir = sess.Get("addon.releaser.wd").String()
 	remote, err := cli.ExecRaw(sess, remoteCmd)
 	if err != nil {
 		return nil, err
@@ -184,7 +154,7 @@ func getGitInfo(sess *session.Context) (*gitinfo, error) {
 
 	// Get origin URL
 	remoteURLCmd := exec.Command("git", "config", "--get", "remote."+info.remoteName+".url")
-	remoteURLCmd.Dir = sess.Get("releaser.wd").String()
+	remoteURLCmd.Dir = sess.Get("addon.releaser.wd").String()
 	remoteURL, err := cli.ExecRaw(sess, remoteURLCmd)
 	if err != nil {
 		return nil, err
@@ -193,7 +163,7 @@ func getGitInfo(sess *session.Context) (*gitinfo, error) {
 
 	// Check for uncommitted changes
 	statusCmd := exec.Command("git", "status", "--porcelain")
-	statusCmd.Dir = sess.Get("releaser.wd").String()
+	statusCmd.Dir = sess.Get("addon.releaser.wd").String()
 	status, err := cli.ExecRaw(sess, statusCmd)
 	if err != nil {
 		return nil, err
@@ -206,7 +176,7 @@ func getGitInfo(sess *session.Context) (*gitinfo, error) {
 
 	// Get committer name and email
 	committerCmd := exec.Command("git", "config", "user.name")
-	committerCmd.Dir = sess.Get("releaser.wd").String()
+	committerCmd.Dir = sess.Get("addon.releaser.wd").String()
 	committer, err := cli.ExecRaw(sess, committerCmd)
 	if err != nil {
 		return nil, err
@@ -214,7 +184,7 @@ func getGitInfo(sess *session.Context) (*gitinfo, error) {
 	info.committer = strings.TrimSpace(string(committer))
 
 	emailCmd := exec.Command("git", "config", "user.email")
-	emailCmd.Dir = sess.Get("releaser.wd").String()
+	emailCmd.Dir = sess.Get("addon.releaser.wd").String()
 	email, err := cli.ExecRaw(sess, emailCmd)
 	if err != nil {
 		return nil, err
@@ -291,7 +261,7 @@ func (m configTable) View() string {
 }
 
 func getConfirmConfigModel(sess *session.Context) (configTable, error) {
-	releaserOptions := sess.Opts().WithPrefix("releaser.")
+	releaserOptions := sess.Opts().WithPrefix("addon.releaser.")
 	// sort keys
 	var (
 		longestKey         int = 10
@@ -302,18 +272,18 @@ func getConfirmConfigModel(sess *session.Context) (configTable, error) {
 	var options []DescribedOption
 
 	releaserOptions.Range(func(v vars.Variable) bool {
-		if len("releaser."+v.Name()) > longestKey {
-			longestKey = len("releaser." + v.Name())
+		if len("addon.releaser."+v.Name()) > longestKey {
+			longestKey = len("addon.releaser." + v.Name())
 		}
 		if v.Len() > longestValue {
 			longestValue = v.Len()
 		}
-		desc := sess.Describe("releaser." + v.Name())
+		desc := sess.Describe("addon.releaser." + v.Name())
 		if len(desc) > longestDescription {
 			longestDescription = len(desc)
 		}
 		options = append(options, DescribedOption{
-			Name:        "releaser." + v.Name(),
+			Name:        "addon.releaser." + v.Name(),
 			Description: desc,
 			Value:       v.String(),
 		})
@@ -335,7 +305,7 @@ func getConfirmConfigModel(sess *session.Context) (configTable, error) {
 
 	for _, option := range options {
 		var value string
-		if option.Name == "releaser.github.token" {
+		if option.Name == "addon.releaser.github.token" {
 			value = "********"
 		} else {
 			value = option.Value
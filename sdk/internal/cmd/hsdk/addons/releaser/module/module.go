@@ -12,12 +12,12 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/happy-sdk/happy/pkg/version"
 	"github.com/happy-sdk/happy/sdk/app/session"
 	"github.com/happy-sdk/happy/sdk/cli"
 	"github.com/happy-sdk/happy/sdk/internal/cmd/hsdk/addons/releaser/changelog"
@@ -133,7 +133,7 @@ func (p *Package) getChangelog(sess *session.Context) error {
 	}
 	lastTagQuery = append(lastTagQuery, []string{"--pretty=format::COMMIT_START:%nSHORT:%h%nLONG:%H%nAUTHOR:%an%nMESSAGE:%B:COMMIT_END:", "--", localpath}...)
 	logcmd := exec.Command("git", lastTagQuery...)
-	logcmd.Dir = sess.Get("releaser.wd").String()
+	logcmd.Dir = sess.Get("addon.releaser.wd").String()
 	logout, err := cli.Exec(sess, logcmd)
 	if err != nil {
 		return err
@@ -180,6 +180,10 @@ func (p *Package) SetDep(dep string, version string) error {
 				if err := p.Modfile.AddRequire(require.Mod.Path, version); err != nil {
 					return err
 				}
+				// local replace is used until dependency is released
+				if err := p.Modfile.DropReplace(require.Mod.Path, ""); err != nil {
+					return err
+				}
 				p.NeedsRelease = true
 				p.UpdateDeps = true
 				if p.NextRelease == "" || p.LastRelease == p.NextRelease {
@@ -415,40 +419,21 @@ func GetConfirmReleasablesView(sess *session.Context, pkgs []*Package, queue []s
 }
 
 func bumpMajor(prefix, ver string) (string, error) {
-	clean := strings.TrimPrefix(ver, prefix+"v")
-	parts := strings.Split(clean, ".")
-	if len(parts) != 3 {
-		return "", fmt.Errorf("invalid version: %s", ver)
-	}
-	major, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return "", err
-	}
-	return fmt.Sprintf("%s%s", prefix, fmt.Sprintf("v%d.0.0", major+1)), nil
+	return bump(prefix, ver, version.BumpMajor)
 }
 
 func bumpMinor(prefix, ver string) (string, error) {
-	clean := strings.TrimPrefix(ver, prefix+"v")
-	parts := strings.Split(clean, ".")
-	if len(parts) != 3 {
-		return "", fmt.Errorf("invalid version: %s", ver)
-	}
-	minor, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return "", err
-	}
-	return fmt.Sprintf("%s%s", prefix, fmt.Sprintf("v%s.%d.0", parts[0], minor+1)), nil
+	return bump(prefix, ver, version.BumpMinor)
 }
 
 func bumpPatch(prefix, ver string) (string, error) {
-	clean := strings.TrimPrefix(ver, prefix+"v")
-	parts := strings.Split(clean, ".")
-	if len(parts) != 3 {
-		return "", fmt.Errorf("invalid version: %s", ver)
-	}
-	patch, err := strconv.Atoi(parts[2])
+	return bump(prefix, ver, version.BumpPatch)
+}
+
+func bump(prefix, ver string, b version.Bump) (string, error) {
+	next, err := version.Version(strings.TrimPrefix(ver, prefix)).Next(b)
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("%s%s", prefix, fmt.Sprintf("v%s.%s.%d", parts[0], parts[1], patch+1)), nil
+	return prefix + next.String(), nil
 }
@@ -39,15 +39,15 @@ func (p *Package) Release(sess *session.Context) error {
 	}
 	localpath := strings.TrimSuffix(p.TagPrefix, "/")
 
-	if err := git.AddAndCommit(sess, sess.Get("releaser.wd").String(), "dep", localpath, "update go.mod deps"); err != nil {
+	if err := git.AddAndCommit(sess, sess.Get("addon.releaser.wd").String(), "dep", localpath, "update go.mod deps"); err != nil {
 		return err
 	}
 
-	origin := sess.Get("releaser.git.remote.name").String()
-	branch := sess.Get("releaser.git.branch").String()
+	origin := sess.Get("addon.releaser.git.remote.name").String()
+	branch := sess.Get("addon.releaser.git.branch").String()
 
 	gitpush := exec.Command("git", "push", origin, branch)
-	gitpush.Dir = sess.Get("releaser.wd").String()
+	gitpush.Dir = sess.Get("addon.releaser.wd").String()
 	if err := cli.Run(sess, gitpush); err != nil {
 		return err
 	}
@@ -58,13 +58,13 @@ func (p *Package) Release(sess *session.Context) error {
 	}
 
 	gitag := exec.Command("git", "tag", "-sm", fmt.Sprintf("%q", p.NextRelease), p.NextRelease)
-	gitag.Dir = sess.Get("releaser.wd").String()
+	gitag.Dir = sess.Get("addon.releaser.wd").String()
 	if err := cli.Run(sess, gitag); err != nil {
 		return err
 	}
 
 	gitpushtag := exec.Command("git", "push", origin, p.NextRelease)
-	gitpushtag.Dir = sess.Get("releaser.wd").String()
+	gitpushtag.Dir = sess.Get("addon.releaser.wd").String()
 	if err := cli.Run(sess, gitpushtag); err != nil {
 		return err
 	}
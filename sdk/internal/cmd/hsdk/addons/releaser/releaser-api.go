@@ -8,15 +8,16 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/happy-sdk/happy/sdk/app/session"
 	"github.com/happy-sdk/happy/sdk/custom"
 	"github.com/happy-sdk/happy/sdk/internal/cmd/hsdk/addons/releaser/module"
+	"github.com/happy-sdk/happy/sdk/project"
 	"golang.org/x/mod/semver"
 )
 
@@ -39,7 +40,7 @@ func (r *releaser) Initialize(sess *session.Context, path string, allowDirty boo
 	r.mu.Lock()
 	r.sess = sess
 	r.mu.Unlock()
-	sess.Log().Ok("releaser initialized", slog.String("wd", sess.Get("releaser.wd").String()))
+	sess.Log().Ok("releaser initialized", slog.String("wd", sess.Get("addon.releaser.wd").String()))
 	return nil
 }
 
@@ -81,10 +82,10 @@ func (r *releaser) confirmConfig(next string) error {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	if err := r.sess.Opts().Set("releaser.next", next); err != nil {
+	if err := r.sess.Opts().Set("addon.releaser.next", next); err != nil {
 		return err
 	}
-	if r.sess.Get("releaser.go.modules.count").Int() == 0 {
+	if r.sess.Get("addon.releaser.go.modules.count").Int() == 0 {
 		return fmt.Errorf("no modules to release")
 	}
 	m, err := getConfirmConfigModel(r.sess)
@@ -113,35 +114,30 @@ func (r *releaser) loadModules() error {
 	defer r.mu.Unlock()
 	r.sess.Log().Info("loading modules")
 
+	proj, err := project.Detect(r.sess.Get("addon.releaser.wd").String())
+	if err != nil {
+		return err
+	}
+	modules, err := proj.Modules()
+	if err != nil {
+		return err
+	}
 	var pkgs []*module.Package
-	if err := filepath.Walk(r.sess.Get("releaser.wd").String(), func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			return nil
-		}
-		goModPath := filepath.Join(path, "go.mod")
-		if _, err := os.Stat(goModPath); err != nil {
-			return nil
-		}
-		pkg, err := module.Load(goModPath)
+	for _, mod := range modules {
+		pkg, err := module.Load(filepath.Join(mod.Dir, "go.mod"))
 		if err != nil {
 			return err
 		}
 		pkgs = append(pkgs, pkg)
-		return nil
-	}); err != nil {
-		return err
 	}
 
 	if len(pkgs) == 0 {
-		return fmt.Errorf("no modules found in %s", r.sess.Get("releaser.wd").String())
+		return fmt.Errorf("no modules found in %s", r.sess.Get("addon.releaser.wd").String())
 	}
 
 	for _, pkg := range pkgs {
 		r.sess.Log().Info("loading release info for", slog.String("pkg", pkg.Modfile.Module.Mod.Path))
-		tagPrefix := strings.TrimPrefix(pkg.Dir+"/", r.sess.Get("releaser.wd").String()+"/")
+		tagPrefix := strings.TrimPrefix(pkg.Dir+"/", r.sess.Get("addon.releaser.wd").String()+"/")
 		pkg.TagPrefix = tagPrefix
 
 		if err := pkg.LoadReleaseInfo(r.sess); err != nil {
@@ -243,7 +239,7 @@ func (r *releaser) printChangelog() error {
 			clp.Changes = append(clp.Changes, change)
 		}
 
-		if pkg.Dir == r.sess.Get("releaser.wd").String() {
+		if pkg.Dir == r.sess.Get("addon.releaser.wd").String() {
 			cl.Root = clp
 		} else {
 			cl.Subpkgs = append(cl.Subpkgs, clp)
@@ -302,20 +298,9 @@ func (r *releaser) printChangelog() error {
 	fmt.Println("")
 
 	for _, scl := range cl.Subpkgs {
-		fmt.Printf("### %s\n\n`%s@%s`\n", scl.pkg.NextRelease, scl.pkg.Import, scl.pkg.NextRelease)
-
-		for i, breaking := range scl.Breaking {
-			if i == 0 {
-				fmt.Println("**Breaking Changes**")
-			}
-			fmt.Println(breaking)
-		}
-		for i, change := range scl.Changes {
-			if i == 0 {
-				fmt.Println("**Changes**")
-			}
-			fmt.Println(change)
-		}
+		fmt.Printf("### %s\n\n`%s@%s`\n\n", scl.pkg.NextRelease, scl.pkg.Import, scl.pkg.NextRelease)
+		release := scl.pkg.Changelog.Release(scl.pkg.NextRelease, time.Now())
+		fmt.Println(release.Markdown())
 	}
 
 	fmt.Println("")
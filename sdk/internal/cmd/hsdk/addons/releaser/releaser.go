@@ -115,7 +115,7 @@ func (r *releaser) createReleaseCommand() *command.Command {
 	cmd.AfterAlways(func(sess *session.Context, err error) error {
 		optstbl := textfmt.Table{}
 
-		sess.Opts().WithPrefix("releaser.").Range(func(v vars.Variable) bool {
+		sess.Opts().WithPrefix("addon.releaser.").Range(func(v vars.Variable) bool {
 			optstbl.AddRow(v.Name(), v.Value().String())
 			return true
 		})
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package crypt holds key derivation shared by packages encrypting
+// data at rest.
+package crypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+const (
+	// KeySize is size of derived AES-256 key.
+	KeySize = 32
+	// SaltSize is size of random salt passed to Key.
+	SaltSize = 16
+	// Rounds is PBKDF2 iteration count.
+	Rounds = 600000
+)
+
+// Key derives key from secret with PBKDF2-HMAC-SHA256 (RFC 8018).
+func Key(secret, salt []byte) []byte {
+	prf := hmac.New(sha256.New, secret)
+	var key []byte
+	var block [4]byte
+	for i := uint32(1); len(key) < KeySize; i++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(block[:], i)
+		prf.Write(block[:])
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for n := 1; n < Rounds; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:KeySize]
+}
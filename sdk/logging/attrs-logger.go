@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// AttrsLogger is a Logger which adds attributes to every record
+// and delegates it to the parent logger. Level is shared with parent.
+type AttrsLogger struct {
+	parent Logger
+	attrs  []slog.Attr
+}
+
+// WithAttrs returns logger which adds attrs to all records logged with it.
+func WithAttrs(parent Logger, attrs ...slog.Attr) *AttrsLogger {
+	if al, ok := parent.(*AttrsLogger); ok {
+		return &AttrsLogger{
+			parent: al.parent,
+			attrs:  append(append([]slog.Attr{}, al.attrs...), attrs...),
+		}
+	}
+	return &AttrsLogger{parent: parent, attrs: attrs}
+}
+
+func (l *AttrsLogger) Debug(msg string, attrs ...slog.Attr) {
+	l.LogDepth(1, LevelDebug, msg, attrs...)
+}
+
+func (l *AttrsLogger) Info(msg string, attrs ...slog.Attr) {
+	l.LogDepth(1, LevelInfo, msg, attrs...)
+}
+
+func (l *AttrsLogger) Ok(msg string, attrs ...slog.Attr) {
+	l.LogDepth(1, LevelOk, msg, attrs...)
+}
+
+func (l *AttrsLogger) Notice(msg string, attrs ...slog.Attr) {
+	l.LogDepth(1, LevelNotice, msg, attrs...)
+}
+
+func (l *AttrsLogger) NotImplemented(msg string, attrs ...slog.Attr) {
+	l.LogDepth(1, LevelNotImplemented, msg, attrs...)
+}
+
+func (l *AttrsLogger) Warn(msg string, attrs ...slog.Attr) {
+	l.LogDepth(1, LevelWarn, msg, attrs...)
+}
+
+func (l *AttrsLogger) Deprecated(msg string, attrs ...slog.Attr) {
+	l.LogDepth(1, LevelDeprecated, msg, attrs...)
+}
+
+func (l *AttrsLogger) Error(msg string, attrs ...slog.Attr) {
+	l.LogDepth(1, LevelError, msg, attrs...)
+}
+
+func (l *AttrsLogger) BUG(msg string, attrs ...slog.Attr) {
+	l.LogDepth(1, LevelBUG, msg, attrs...)
+}
+
+func (l *AttrsLogger) Println(line string, attrs ...slog.Attr) {
+	line = strings.TrimRight(line, "\n")
+	if !testing.Testing() {
+		line += "\n"
+	}
+	l.LogDepth(1, LevelAlways, line, attrs...)
+}
+
+func (l *AttrsLogger) Printf(format string, v ...any) {
+	if !l.parent.Enabled(LevelAlways) {
+		return
+	}
+	l.LogDepth(1, LevelAlways, fmt.Sprintf(format, v...))
+}
+
+func (l *AttrsLogger) HTTP(status int, method, path string, attrs ...slog.Attr) {
+	l.parent.HTTP(status, method, path, l.with(attrs)...)
+}
+
+func (l *AttrsLogger) Handle(r slog.Record) error {
+	r = r.Clone()
+	r.AddAttrs(l.attrs...)
+	return l.parent.Handle(r)
+}
+
+func (l *AttrsLogger) Enabled(lvl Level) bool { return l.parent.Enabled(lvl) }
+
+func (l *AttrsLogger) Level() Level { return l.parent.Level() }
+
+func (l *AttrsLogger) SetLevel(lvl Level) { l.parent.SetLevel(lvl) }
+
+func (l *AttrsLogger) LogDepth(depth int, lvl Level, msg string, attrs ...slog.Attr) {
+	// attrs are merged only for records which are logged
+	if !l.parent.Enabled(lvl) {
+		return
+	}
+	l.parent.LogDepth(depth+1, lvl, msg, l.with(attrs)...)
+}
+
+func (l *AttrsLogger) Logger() *slog.Logger {
+	args := make([]any, len(l.attrs))
+	for i, attr := range l.attrs {
+		args[i] = attr
+	}
+	return l.parent.Logger().With(args...)
+}
+
+func (l *AttrsLogger) ConsumeQueue(queue *QueueLogger) error {
+	return l.parent.ConsumeQueue(queue)
+}
+
+func (l *AttrsLogger) with(attrs []slog.Attr) []slog.Attr {
+	if len(attrs) == 0 {
+		return l.attrs
+	}
+	return append(append(make([]slog.Attr, 0, len(l.attrs)+len(attrs)), l.attrs...), attrs...)
+}
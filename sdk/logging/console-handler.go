@@ -13,9 +13,12 @@ import (
 	"os"
 	"runtime"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/happy-sdk/happy/pkg/cli/ansicolor"
+	"github.com/happy-sdk/happy/sdk/datetime"
 )
 
 type consoleTheme struct {
@@ -35,13 +38,18 @@ type consoleTheme struct {
 }
 
 type ConsoleOptions struct {
-	Level           Level
-	Theme           ansicolor.Theme
-	ReplaceAttr     func(groups []string, a slog.Attr) slog.Attr
-	AddSource       bool
-	TimeLocation    *time.Location
+	Level        Level
+	Theme        ansicolor.Theme
+	ReplaceAttr  func(groups []string, a slog.Attr) slog.Attr
+	AddSource    bool
+	TimeLocation *time.Location
+	// Clock used for record timestamps, datetime.SystemClock when nil.
+	Clock           datetime.Clock
 	TimestampFormat string
 	NoTimestamp     bool
+	// JSON writes records as JSON lines to stdout, suitable for
+	// log collectors e.g. in Kubernetes.
+	JSON bool
 }
 
 func ConsoleDefaultOptions() ConsoleOptions {
@@ -65,6 +73,7 @@ func Console(opts ConsoleOptions) *DefaultLogger {
 		lvl:   new(slog.LevelVar),
 		ctx:   context.Background(),
 		tsloc: tsloc,
+		clock: opts.Clock,
 	}
 	l.lvl.Set(slog.Level(opts.Level))
 
@@ -108,7 +117,9 @@ func Console(opts ConsoleOptions) *DefaultLogger {
 		l:     log.New(os.Stderr, "", 0),
 		tsfmt: tsfmt,
 		nots:  opts.NoTimestamp,
+		json:  opts.JSON,
 	}
+	h.precompute()
 
 	l.log = slog.New(h)
 	return l
@@ -121,10 +132,51 @@ type ConsoleHandler struct {
 	l      *log.Logger
 	tsfmt  string
 	nots   bool
+	json   bool
+
+	// mu serializes writes of records built in pooled buffers.
+	mu sync.Mutex
+	// levels are styled and padded level labels.
+	levels map[Level]string
+	// ansi escape sequences starting styles used by Handle.
+	attrsAnsi, mutedAnsi, sysdebugAnsi, debugAnsi, lightAnsi string
+}
+
+const ansiReset = "\033[0m"
+
+// consoleBufPool holds buffers records are formatted into.
+var consoleBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 512)
+		return &b
+	},
+}
+
+// precompute resolves level labels and style escape sequences once,
+// so that they are not formatted for every record.
+func (h *ConsoleHandler) precompute() {
+	h.levels = make(map[Level]string, len(lvlval))
+	for lvl := range lvlval {
+		h.levels[lvl] = h.levelStr(lvl)
+	}
+	prefix := func(s ansicolor.Style) string {
+		return strings.TrimSuffix(s.String(""), ansiReset)
+	}
+	h.attrsAnsi = prefix(h.styles.attrs)
+	h.mutedAnsi = prefix(h.styles.muted)
+	h.sysdebugAnsi = prefix(h.styles.sysdebug)
+	h.debugAnsi = prefix(h.styles.debug)
+	h.lightAnsi = prefix(h.styles.light)
 }
 
 func (h *ConsoleHandler) getLevelStr(lvl slog.Level) string {
-	l := Level(lvl)
+	if str, ok := h.levels[Level(lvl)]; ok {
+		return str
+	}
+	return h.levelStr(Level(lvl))
+}
+
+func (h *ConsoleHandler) levelStr(l Level) string {
 	if l == LevelQuiet {
 		return ""
 	}
@@ -160,13 +212,43 @@ func (h *ConsoleHandler) getLevelStr(lvl slog.Level) string {
 }
 
 func (h *ConsoleHandler) Handle(ctx context.Context, r slog.Record) error {
-	lvlstr := h.getLevelStr(r.Level)
+	if h.json {
+		return h.Handler.Handle(ctx, r)
+	}
 	lvl := Level(r.Level)
 
-	var (
-		msg,
-		payload string
-	)
+	bufp := consoleBufPool.Get().(*[]byte)
+	buf := (*bufp)[:0]
+	defer func() {
+		// do not keep buffers grown by exceptionally large records
+		if cap(buf) <= 64<<10 {
+			*bufp = buf
+			consoleBufPool.Put(bufp)
+		}
+	}()
+
+	if lvl != LevelAlways {
+		buf = append(buf, h.getLevelStr(r.Level)...)
+		buf = append(buf, ' ')
+		if !h.nots {
+			buf = append(buf, h.mutedAnsi...)
+			buf = r.Time.AppendFormat(buf, h.tsfmt)
+			buf = append(buf, ansiReset...)
+		}
+		buf = append(buf, ' ')
+	}
+
+	switch {
+	case lvl < LevelDebug:
+		buf = append(buf, h.sysdebugAnsi...)
+	case lvl == LevelDebug:
+		buf = append(buf, h.debugAnsi...)
+	default:
+		buf = append(buf, h.lightAnsi...)
+	}
+	buf = append(buf, r.Message...)
+	buf = append(buf, ansiReset...)
+	buf = append(buf, ' ')
 
 	if r.NumAttrs() > 0 {
 		fields := make(map[string]any, r.NumAttrs())
@@ -179,39 +261,32 @@ func (h *ConsoleHandler) Handle(ctx context.Context, r slog.Record) error {
 			return err
 		}
 		if lvl >= LevelDebug {
-			payload = h.styles.attrs.String(string(b))
+			buf = append(buf, h.attrsAnsi...)
+			buf = append(buf, b...)
+			buf = append(buf, ansiReset...)
 		} else {
-			payload = string(b)
+			buf = append(buf, b...)
 		}
 	}
 
-	var timeStr string
-	if !h.nots {
-		timeStr = h.styles.muted.String(r.Time.Format(h.tsfmt))
-	}
-
-	if lvl < LevelDebug {
-		msg = h.styles.sysdebug.String(r.Message)
-	} else if lvl == LevelDebug {
-		msg = h.styles.debug.String(r.Message)
-	} else {
-		msg = h.styles.light.String(r.Message)
-	}
-
 	if h.src && r.PC != 0 {
 		fs := runtime.CallersFrames([]uintptr{r.PC})
 		f, _ := fs.Next()
 		if f.File != "" {
-			payload += " " + h.styles.muted.String(f.File+":"+strconv.Itoa(f.Line))
+			buf = append(buf, ' ')
+			buf = append(buf, h.mutedAnsi...)
+			buf = append(buf, f.File...)
+			buf = append(buf, ':')
+			buf = strconv.AppendInt(buf, int64(f.Line), 10)
+			buf = append(buf, ansiReset...)
 		}
 	}
-	if lvl == LevelAlways {
-		h.l.Println(msg, payload)
-	} else {
-		h.l.Println(lvlstr, timeStr, msg, payload)
-	}
+	buf = append(buf, '\n')
 
-	return nil
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.l.Writer().Write(buf)
+	return err
 }
 
 func (h *ConsoleHandler) http(status int, method, p string, attrs ...slog.Attr) {
@@ -257,5 +332,7 @@ func (h *ConsoleHandler) http(status int, method, p string, attrs ...slog.Attr)
 
 	timeStr := h.styles.muted.String(time.Now().Format(h.tsfmt))
 
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	h.l.Println(state, timeStr, p, payload)
 }
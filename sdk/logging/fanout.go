@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// FanOut returns handler which passes records to all handlers enabled
+// for record level, errors of handlers are returned joined.
+func FanOut(handlers ...slog.Handler) slog.Handler {
+	return &fanOut{handlers: handlers}
+}
+
+type fanOut struct {
+	handlers []slog.Handler
+}
+
+func (h *fanOut) Enabled(ctx context.Context, lvl slog.Level) bool {
+	for _, hh := range h.handlers {
+		if hh.Enabled(ctx, lvl) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *fanOut) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, hh := range h.handlers {
+		if !hh.Enabled(ctx, r.Level) {
+			continue
+		}
+		// handlers may retain record, each gets own copy of attrs.
+		if err := hh.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *fanOut) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, hh := range h.handlers {
+		handlers[i] = hh.WithAttrs(attrs)
+	}
+	return &fanOut{handlers: handlers}
+}
+
+func (h *fanOut) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, hh := range h.handlers {
+		handlers[i] = hh.WithGroup(name)
+	}
+	return &fanOut{handlers: handlers}
+}
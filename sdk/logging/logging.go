@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/datetime"
 )
 
 type Settings struct {
@@ -25,6 +26,7 @@ type Settings struct {
 	TimestampFormat settings.String `key:"timeestamp_format,config" default:"15:04:05.000" mutation:"once" desc:"Timestamp format for log messages"`
 	NoTimestamp     settings.Bool   `key:"no_timestamp,config" default:"false" mutation:"once" desc:"Do not show timestamps"`
 	NoSlogDefault   settings.Bool   `key:"no_slog_default" default:"false" mutation:"once" desc:"Do not set the default slog logger"`
+	Format          settings.String `key:"format,config" default:"auto" mutation:"once" desc:"Log format text, json or auto which uses json in Kubernetes"`
 }
 
 func (s Settings) Blueprint() (*settings.Blueprint, error) {
@@ -168,6 +170,7 @@ type Logger interface {
 
 type DefaultLogger struct {
 	tsloc *time.Location
+	clock datetime.Clock
 	lvl   *slog.LevelVar
 	log   *slog.Logger
 	ctx   context.Context
@@ -221,6 +224,37 @@ func NewDefault(lvl Level) *DefaultLogger {
 	return l
 }
 
+// NewFromHandler returns logger which writes records with level lvl
+// or higher to handler h. Handler level is ignored, so that logger can
+// log with lower level than logger handler h was created for.
+func NewFromHandler(h slog.Handler, lvl Level) *DefaultLogger {
+	l := &DefaultLogger{
+		lvl:   new(slog.LevelVar),
+		ctx:   context.Background(),
+		tsloc: time.Local,
+	}
+	l.lvl.Set(slog.Level(lvl))
+	l.log = slog.New(&levelHandler{Handler: h, lvl: l.lvl})
+	return l
+}
+
+type levelHandler struct {
+	slog.Handler
+	lvl *slog.LevelVar
+}
+
+func (h *levelHandler) Enabled(_ context.Context, lvl slog.Level) bool {
+	return lvl >= h.lvl.Level()
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), lvl: h.lvl}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), lvl: h.lvl}
+}
+
 func (l *DefaultLogger) Debug(msg string, attrs ...slog.Attr) {
 	l.logDepth(lvlDebug, msg, attrs...)
 }
@@ -266,36 +300,46 @@ func (l *DefaultLogger) Println(line string, attrs ...slog.Attr) {
 }
 
 func (l *DefaultLogger) Printf(format string, v ...any) {
+	if !l.enabled(lvlAlways) {
+		return
+	}
 	l.logDepth(lvlAlways, fmt.Sprintf(format, v...))
 }
 
 func (l *DefaultLogger) HTTP(status int, method, path string, attrs ...slog.Attr) {
 	switch status {
 	case 100, 200:
-		if l.log.Enabled(l.ctx, lvlInfo) {
+		if l.enabled(lvlInfo) {
 			l.http(status, method, path, attrs...)
 		}
 	case 300:
-		if l.log.Enabled(l.ctx, lvlWarn) {
+		if l.enabled(lvlWarn) {
 			l.http(status, method, path, attrs...)
 		}
 	case 400:
-		if l.log.Enabled(l.ctx, lvlError) {
+		if l.enabled(lvlError) {
 			l.http(status, method, path, attrs...)
 		}
 	case 500:
-		if l.log.Enabled(l.ctx, lvlError) {
+		if l.enabled(lvlError) {
 			l.http(status, method, path, attrs...)
 		}
 	default:
-		if l.log.Enabled(l.ctx, lvlBUG) {
+		if l.enabled(lvlBUG) {
 			attrs = append(attrs, slog.String("err", "invalid status code"))
 			l.http(status, method, path, attrs...)
 		}
 	}
 }
 
-func (l *DefaultLogger) Enabled(lvl Level) bool { return l.log.Enabled(l.ctx, slog.Level(lvl)) }
+func (l *DefaultLogger) Enabled(lvl Level) bool { return l.enabled(slog.Level(lvl)) }
+
+// enabled reports whether records with lvl are logged. Level is read
+// atomically from level var shared with the handler, which avoids
+// calling handler for disabled records on hot paths e.g. ticks.
+func (l *DefaultLogger) enabled(lvl slog.Level) bool {
+	return lvl >= l.lvl.Level()
+}
 
 func (l *DefaultLogger) Level() Level { return Level(l.lvl.Level()) }
 
@@ -307,7 +351,7 @@ func (l *DefaultLogger) SetLevel(lvl Level) {
 // The depth is the number of stack frames to ascend when logging the message.
 // It is useful only when AddSource is enabled.
 func (l *DefaultLogger) LogDepth(depth int, lvl Level, msg string, attrs ...slog.Attr) {
-	if !l.log.Enabled(l.ctx, slog.Level(lvl)) {
+	if !l.enabled(slog.Level(lvl)) {
 		return
 	}
 	var pcs [1]uintptr
@@ -318,7 +362,7 @@ func (l *DefaultLogger) LogDepth(depth int, lvl Level, msg string, attrs ...slog
 }
 
 func (l *DefaultLogger) Handle(r slog.Record) error {
-	if !l.log.Enabled(l.ctx, r.Level) {
+	if !l.enabled(r.Level) {
 		return nil
 	}
 	return l.log.Handler().Handle(l.ctx, r)
@@ -353,7 +397,7 @@ func (l *DefaultLogger) http(status int, method, path string, attrs ...slog.Attr
 }
 
 func (l *DefaultLogger) logDepth(lvl slog.Level, msg string, attrs ...slog.Attr) {
-	if !l.log.Enabled(l.ctx, lvl) {
+	if !l.enabled(lvl) {
 		return
 	}
 	var pcs [1]uintptr
@@ -367,5 +411,8 @@ func (l *DefaultLogger) ts() time.Time {
 	if l.tsloc == nil {
 		panic("logging: time location is nil")
 	}
+	if l.clock != nil {
+		return l.clock.Now().In(l.tsloc)
+	}
 	return time.Now().In(l.tsloc)
 }
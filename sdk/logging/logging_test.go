@@ -3,3 +3,67 @@
 // Copyright © 2022 The Happy Authors
 
 package logging
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"log/slog"
+	"testing"
+)
+
+func discardConsole(lvl Level) *DefaultLogger {
+	opts := ConsoleDefaultOptions()
+	opts.Level = lvl
+	l := Console(opts)
+	l.log.Handler().(*ConsoleHandler).l = log.New(io.Discard, "", 0)
+	return l
+}
+
+func TestConsoleHandlerFormat(t *testing.T) {
+	opts := ConsoleDefaultOptions()
+	opts.AddSource = false
+	opts.NoTimestamp = true
+	l := Console(opts)
+	h := l.log.Handler().(*ConsoleHandler)
+	out := new(bytes.Buffer)
+	h.l = log.New(out, "", 0)
+
+	l.Info("hello", slog.String("k", "v"))
+	l.Println("line")
+	want := h.styles.info.String(" info       ") + "  " + h.styles.light.String("hello") + " " +
+		h.styles.attrs.String(`{"k":"v"}`) + "\n" +
+		h.styles.light.String("line") + " \n"
+	if got := out.String(); got != want {
+		t.Errorf("output\n%q\nwant\n%q", got, want)
+	}
+
+	l.Debug("hidden")
+	if out.Len() != len(want) {
+		t.Errorf("disabled level logged: %q", out.String()[len(want):])
+	}
+}
+
+func BenchmarkLoggerDisabled(b *testing.B) {
+	var l Logger = discardConsole(LevelInfo)
+	b.ReportAllocs()
+	for range b.N {
+		l.LogDepth(0, levelHappy, "tick", slog.Int("n", 1))
+	}
+}
+
+func BenchmarkAttrsLoggerDisabled(b *testing.B) {
+	var l Logger = WithAttrs(discardConsole(LevelInfo), slog.String("service", "bench"))
+	b.ReportAllocs()
+	for range b.N {
+		l.LogDepth(0, levelHappy, "tick", slog.Int("n", 1))
+	}
+}
+
+func BenchmarkConsoleEnabled(b *testing.B) {
+	var l Logger = discardConsole(levelHappy)
+	b.ReportAllocs()
+	for range b.N {
+		l.LogDepth(0, levelHappy, "tick", slog.Int("n", 1))
+	}
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package listener provides network listeners which survive graceful
+// restart of the application. Listeners opened with Listen are handed
+// over to the restarted process, which reuses them instead of binding
+// the address again, so no connections are refused during upgrade.
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// EnvKey is environment variable holding inherited listeners in form
+// "network:address=fd;network:address=fd".
+const EnvKey = "HAPPY_LISTEN_FDS"
+
+var Error = errors.New("listener")
+
+var (
+	mu        sync.Mutex
+	inherited map[string]uintptr
+	active    = make(map[string]net.Listener)
+	loaded    bool
+)
+
+// Listen announces on the local network address. When the process was
+// started by graceful restart and parent process passed listener for the
+// same network and address, inherited listener is returned instead.
+func Listen(network, address string) (net.Listener, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	key := network + ":" + address
+	if _, ok := active[key]; ok {
+		return nil, fmt.Errorf("%w: %s already registered", Error, key)
+	}
+
+	loadInherited()
+	if fd, ok := inherited[key]; ok {
+		delete(inherited, key)
+		f := os.NewFile(fd, key)
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w: inherit %s: %s", Error, key, err)
+		}
+		active[key] = ln
+		return &tracked{Listener: ln, key: key}, nil
+	}
+
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	active[key] = ln
+	return &tracked{Listener: ln, key: key}, nil
+}
+
+// Inherited reports whether process has inherited listeners from
+// parent process which are not claimed yet by Listen.
+func Inherited() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	loadInherited()
+	return len(inherited) > 0
+}
+
+// Files returns duplicated file descriptors of active listeners keyed by
+// "network:address". Caller is responsible for closing returned files.
+func Files() (map[string]*os.File, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	files := make(map[string]*os.File, len(active))
+	for key, ln := range active {
+		fl, ok := ln.(interface{ File() (*os.File, error) })
+		if !ok {
+			continue
+		}
+		f, err := fl.File()
+		if err != nil {
+			for _, f := range files {
+				_ = f.Close()
+			}
+			return nil, fmt.Errorf("%w: %s: %s", Error, key, err)
+		}
+		files[key] = f
+	}
+	return files, nil
+}
+
+// Env returns EnvKey environment variable entry for files which are
+// available in child process under given descriptors.
+func Env(fds map[string]uintptr) string {
+	entries := make([]string, 0, len(fds))
+	for key, fd := range fds {
+		entries = append(entries, key+"="+strconv.FormatUint(uint64(fd), 10))
+	}
+	return EnvKey + "=" + strings.Join(entries, ";")
+}
+
+func loadInherited() {
+	if loaded {
+		return
+	}
+	loaded = true
+	inherited = make(map[string]uintptr)
+	val, ok := os.LookupEnv(EnvKey)
+	if !ok {
+		return
+	}
+	// Inherited descriptors must not leak to processes spawned by the application.
+	_ = os.Unsetenv(EnvKey)
+	for _, entry := range strings.Split(val, ";") {
+		key, fdstr, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		fd, err := strconv.ParseUint(fdstr, 10, 64)
+		if err != nil {
+			continue
+		}
+		inherited[key] = uintptr(fd)
+	}
+}
+
+type tracked struct {
+	net.Listener
+	key  string
+	once sync.Once
+}
+
+func (t *tracked) Close() error {
+	t.once.Do(func() {
+		mu.Lock()
+		delete(active, t.key)
+		mu.Unlock()
+	})
+	return t.Listener.Close()
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package notify sends desktop notifications so that long running
+// commands can alert the user when they complete. Notifications are
+// delivered with notify-send or DBus on Linux and BSD, osascript on
+// macOS and toast notifications on Windows.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+var (
+	Error = errors.New("notify")
+	// ErrUnsupported is returned when no notification backend is
+	// available on the system.
+	ErrUnsupported = fmt.Errorf("%w: desktop notifications are not supported", Error)
+)
+
+type Settings struct {
+	Disabled settings.Bool     `key:"disabled,save" default:"false" mutation:"mutable" desc:"Disable desktop notifications"`
+	After    settings.Duration `key:"after,save" default:"0s" mutation:"mutable" desc:"Notify when command takes longer than given duration also without --notify flag, 0 disables"`
+	Timeout  settings.Duration `key:"timeout" default:"5s" mutation:"once" desc:"Timeout of notification delivery"`
+}
+
+func (s Settings) Blueprint() (*settings.Blueprint, error) {
+	b, err := settings.New(s)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Urgency of notification, backends without urgency support ignore it.
+type Urgency int
+
+const (
+	UrgencyNormal Urgency = iota
+	UrgencyLow
+	UrgencyCritical
+)
+
+func (u Urgency) String() string {
+	switch u {
+	case UrgencyLow:
+		return "low"
+	case UrgencyCritical:
+		return "critical"
+	}
+	return "normal"
+}
+
+type Notification struct {
+	Title   string
+	Message string
+	Urgency Urgency
+	// Icon is icon name or path, used only by notify-send and DBus.
+	Icon string
+}
+
+// Enabled reports whether notifications are not disabled with
+// app.notify.disabled.
+func Enabled(sess *session.Context) bool {
+	return !sess.Get("app.notify.disabled").Bool()
+}
+
+// Send sends desktop notification when notifications are enabled,
+// empty title defaults to application name.
+func Send(sess *session.Context, n Notification) error {
+	if !Enabled(sess) {
+		return nil
+	}
+	if n.Title == "" {
+		n.Title = sess.Get("app.name").String()
+	}
+	timeout := sess.Get("app.notify.timeout").Duration()
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(sess, timeout)
+	defer cancel()
+	return Desktop(ctx, n)
+}
+
+// Desktop sends notification with platform backend regardless of
+// application settings.
+func Desktop(ctx context.Context, n Notification) error {
+	if n.Title == "" && n.Message == "" {
+		return fmt.Errorf("%w: empty notification", Error)
+	}
+	if err := send(ctx, n); err != nil {
+		if errors.Is(err, ErrUnsupported) {
+			return err
+		}
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return nil
+}
+
+// Completed sends notification about completed command, failed
+// commands are sent with critical urgency.
+func Completed(sess *session.Context, cmd string, took time.Duration, err error) error {
+	n := Notification{
+		Message: fmt.Sprintf("%s completed in %s", cmd, took.Round(time.Millisecond)),
+	}
+	if err != nil {
+		n.Message = fmt.Sprintf("%s failed after %s: %s", cmd, took.Round(time.Millisecond), err.Error())
+		n.Urgency = UrgencyCritical
+	}
+	return Send(sess, n)
+}
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+//go:build darwin
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// send displays notification through UserNotifications with osascript.
+func send(ctx context.Context, n Notification) error {
+	path, err := exec.LookPath("osascript")
+	if err != nil {
+		return ErrUnsupported
+	}
+	script := fmt.Sprintf("display notification %s with title %s", quote(n.Message), quote(n.Title))
+	if n.Urgency == UrgencyCritical {
+		script += ` sound name "Basso"`
+	}
+	out, err := exec.CommandContext(ctx, path, "-e", script).CombinedOutput()
+	if err != nil && len(out) > 0 {
+		return fmt.Errorf("%s: %s", err.Error(), out)
+	}
+	return err
+}
+
+// quote returns s as AppleScript string literal.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
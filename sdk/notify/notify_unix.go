@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+//go:build !darwin && !windows
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// send uses notify-send when available and falls back to calling
+// org.freedesktop.Notifications over DBus with gdbus.
+func send(ctx context.Context, n Notification) error {
+	if path, err := exec.LookPath("notify-send"); err == nil {
+		args := []string{"--urgency", n.Urgency.String()}
+		if n.Icon != "" {
+			args = append(args, "--icon", n.Icon)
+		}
+		args = append(args, "--", n.Title, n.Message)
+		return run(exec.CommandContext(ctx, path, args...))
+	}
+	if path, err := exec.LookPath("gdbus"); err == nil {
+		return run(exec.CommandContext(ctx, path, "call", "--session",
+			"--dest", "org.freedesktop.Notifications",
+			"--object-path", "/org/freedesktop/Notifications",
+			"--method", "org.freedesktop.Notifications.Notify",
+			"", "0", n.Icon, n.Title, n.Message, "[]",
+			fmt.Sprintf("{'urgency': <byte %d>}", dbusUrgency(n.Urgency)), "-1",
+		))
+	}
+	return ErrUnsupported
+}
+
+func dbusUrgency(u Urgency) int {
+	switch u {
+	case UrgencyLow:
+		return 0
+	case UrgencyCritical:
+		return 2
+	}
+	return 1
+}
+
+func run(cmd *exec.Cmd) error {
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("%s: %s", err.Error(), out)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package paths provides overrides for application directories. Defaults
+// follow platform conventions which may be unsuitable in containers, snaps
+// or CI, there directories can be set with settings or environment
+// variables <SLUG>_CONFIG_DIR, <SLUG>_CACHE_DIR, <SLUG>_DATA_DIR,
+// <SLUG>_STATE_DIR and <SLUG>_TMP_DIR.
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+var Error = errors.New("paths")
+
+type Settings struct {
+	ConfigDir settings.String `key:"config_dir" default:"" mutation:"once" desc:"Override application configuration directory"`
+	CacheDir  settings.String `key:"cache_dir" default:"" mutation:"once" desc:"Override application cache directory"`
+	DataDir   settings.String `key:"data_dir" default:"" mutation:"once" desc:"Override application data directory"`
+	StateDir  settings.String `key:"state_dir" default:"" mutation:"once" desc:"Override application state directory"`
+	TmpDir    settings.String `key:"tmp_dir" default:"" mutation:"once" desc:"Override base directory of runtime tmp directories"`
+}
+
+func (s Settings) Blueprint() (*settings.Blueprint, error) {
+	return settings.New(s)
+}
+
+// Dirs which can be overridden, names match settings keys without _dir suffix.
+var Dirs = []string{"config", "cache", "data", "state", "tmp"}
+
+// EnvKey returns environment variable name overriding directory
+// e.g. EnvKey("my-app", "config") returns MY_APP_CONFIG_DIR.
+func EnvKey(slug, dir string) string {
+	key := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, slug)
+	return key + "_" + strings.ToUpper(dir) + "_DIR"
+}
+
+// Override returns directory override from environment or setting value,
+// environment takes precedence. Empty string is returned when directory
+// is not overridden.
+func Override(slug, dir, setting string) (string, error) {
+	p := os.Getenv(EnvKey(slug, dir))
+	if p == "" {
+		p = setting
+	}
+	if p == "" {
+		return "", nil
+	}
+	return Validate(p)
+}
+
+// Validate expands leading ~ to user home directory and verifies that
+// path is absolute and is not an existing file.
+func Validate(p string) (string, error) {
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, `~\`) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		p = filepath.Join(home, p[1:])
+	}
+	p = filepath.Clean(p)
+	if !filepath.IsAbs(p) {
+		return "", fmt.Errorf("%w: directory must be absolute path: %s", Error, p)
+	}
+	if info, err := os.Stat(p); err == nil && !info.IsDir() {
+		return "", fmt.Errorf("%w: not a directory: %s", Error, p)
+	}
+	return p, nil
+}
+
+// Ensure returns application directory by name e.g. "data" and creates
+// it when it does not exist yet. Data and state directories are created
+// on demand only when application uses them.
+func Ensure(sess *session.Context, dir string) (string, error) {
+	p := sess.Get("app.fs.path." + dir).String()
+	if p == "" {
+		return "", fmt.Errorf("%w: unknown directory %s", Error, dir)
+	}
+	if err := os.MkdirAll(p, 0700); err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return p, nil
+}
+
+// Overridden reports whether application directory by name e.g. "cache"
+// is overridden with setting or environment variable. Overridden
+// directories are chosen by user and must not be removed by application.
+func Overridden(sess *session.Context, dir string) bool {
+	key := "app.fs." + dir + "_dir"
+	if !sess.Has(key) {
+		return false
+	}
+	p, err := Override(sess.Get("app.slug").String(), dir, sess.Get(key).String())
+	return err == nil && p != ""
+}
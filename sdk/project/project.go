@@ -0,0 +1,263 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package project detects project context of working directory, such as
+// project root, version control, Go modules and project local settings
+// and tasks, so that tools do not have to reimplement path walking.
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	Error = errors.New("project")
+	// ErrNotFound is returned when no project root is found.
+	ErrNotFound = fmt.Errorf("%w: not found", Error)
+)
+
+// TasksPrefix is prefix of project local settings keys defining tasks,
+// e.g. tasks.test = "go test ./..." in project config file.
+const TasksPrefix = "tasks."
+
+// Module is Go module within the project.
+type Module struct {
+	// Path is module path declared in go.mod.
+	Path string
+	// Dir is absolute directory of module.
+	Dir string
+	// Rel is directory of module relative to project root, "." for root.
+	Rel string
+}
+
+// Project is project context detected from working directory.
+type Project struct {
+	// Root is absolute project root directory.
+	Root string
+	// VCS is version control system of project, "git" or empty.
+	VCS string
+	// VCSRoot is root of version control repository, it may be parent
+	// of Root.
+	VCSRoot string
+	// Workspace reports whether project root has go.work file.
+	Workspace bool
+	// ConfigFile is path of project local config file if any.
+	ConfigFile string
+	// Settings are project local settings from ConfigFile.
+	Settings map[string]string
+	// Tasks are project local tasks from ConfigFile keyed by name.
+	Tasks map[string]string
+
+	modsOnce sync.Once
+	mods     []Module
+	modsErr  error
+}
+
+// Detect detects project which dir belongs to. Nearest directory with
+// go.work is preferred as project root, then git repository root and
+// then nearest directory with go.mod.
+func Detect(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+
+	var gowork, git, gomod string
+	for cur := dir; ; {
+		if gowork == "" && exists(filepath.Join(cur, "go.work")) {
+			gowork = cur
+		}
+		if git == "" && exists(filepath.Join(cur, ".git")) {
+			git = cur
+		}
+		if gomod == "" && exists(filepath.Join(cur, "go.mod")) {
+			gomod = cur
+		}
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			break
+		}
+		cur = parent
+	}
+
+	p := &Project{
+		Settings: make(map[string]string),
+		Tasks:    make(map[string]string),
+	}
+	switch {
+	case gowork != "":
+		p.Root, p.Workspace = gowork, true
+	case git != "":
+		p.Root = git
+	case gomod != "":
+		p.Root = gomod
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, dir)
+	}
+	if git != "" && strings.HasPrefix(p.Root+string(filepath.Separator), git+string(filepath.Separator)) {
+		p.VCS, p.VCSRoot = "git", git
+	}
+	return p, nil
+}
+
+// Modules returns Go modules of the project sorted by Rel. Modules are
+// looked up on first call from go.work or by walking project tree.
+func (p *Project) Modules() ([]Module, error) {
+	p.modsOnce.Do(func() {
+		if p.Workspace {
+			p.modsErr = p.loadWorkspaceModules()
+		} else {
+			p.modsErr = p.walkModules()
+		}
+		sort.Slice(p.mods, func(i, j int) bool {
+			return p.mods[i].Rel < p.mods[j].Rel
+		})
+	})
+	return p.mods, p.modsErr
+}
+
+// Load sets project local settings read from config file, keys with
+// TasksPrefix are loaded as tasks.
+func (p *Project) Load(file string, values map[string]string) {
+	p.ConfigFile = file
+	for key, val := range values {
+		if name, ok := strings.CutPrefix(key, TasksPrefix); ok {
+			p.Tasks[name] = val
+			continue
+		}
+		p.Settings[key] = val
+	}
+}
+
+// Module returns module with module path, it reports false when
+// project has no such module.
+func (p *Project) Module(path string) (Module, bool) {
+	mods, _ := p.Modules()
+	for _, m := range mods {
+		if m.Path == path {
+			return m, true
+		}
+	}
+	return Module{}, false
+}
+
+// Rel returns path relative to project root.
+func (p *Project) Rel(path string) (string, error) {
+	return filepath.Rel(p.Root, path)
+}
+
+func (p *Project) addModule(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	rel, err := filepath.Rel(p.Root, dir)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	p.mods = append(p.mods, Module{
+		Path: modulePath(data),
+		Dir:  dir,
+		Rel:  filepath.ToSlash(rel),
+	})
+	return nil
+}
+
+func (p *Project) walkModules() error {
+	return filepath.WalkDir(p.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != p.Root && skipDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		if !exists(filepath.Join(path, "go.mod")) {
+			return nil
+		}
+		return p.addModule(path)
+	})
+}
+
+func (p *Project) loadWorkspaceModules() error {
+	data, err := os.ReadFile(filepath.Join(p.Root, "go.work"))
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	for _, use := range workspaceUses(data) {
+		dir := filepath.Join(p.Root, filepath.FromSlash(use))
+		if !exists(filepath.Join(dir, "go.mod")) {
+			continue
+		}
+		if err := p.addModule(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipDir reports whether directory is not searched for modules.
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+		name == "testdata" || name == "vendor" || name == "node_modules"
+}
+
+// modulePath returns module path declared in go.mod data.
+func modulePath(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			return strings.Trim(strings.TrimSpace(stripComment(rest)), `"`)
+		}
+	}
+	return ""
+}
+
+// workspaceUses returns directories of use directives in go.work data.
+func workspaceUses(data []byte) []string {
+	var (
+		uses  []string
+		block bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		switch {
+		case line == "":
+		case block && line == ")":
+			block = false
+		case block:
+			uses = append(uses, strings.Trim(line, `"`))
+		case line == "use (":
+			block = true
+		case strings.HasPrefix(line, "use "):
+			uses = append(uses, strings.Trim(strings.TrimSpace(line[4:]), `"`))
+		}
+	}
+	return uses
+}
+
+func stripComment(line string) string {
+	if i := strings.Index(line, "//"); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,308 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package ratelimit provides token bucket and sliding window limiters
+// which use session clock and are released when session is destroyed.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/datetime"
+)
+
+var (
+	Error       = errors.New("ratelimit")
+	ErrClosed   = fmt.Errorf("%w: session closed", Error)
+	ErrExceeded = fmt.Errorf("%w: wait exceeds deadline", Error)
+)
+
+// Limiter limits rate of events.
+type Limiter interface {
+	// Allow reports whether event may happen now and consumes it if so.
+	Allow() bool
+	// Reserve consumes event and returns duration to wait before it may happen.
+	Reserve() time.Duration
+	// Wait blocks until event may happen, ctx is done or session is destroyed.
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is Limiter which allows events at rate limit per interval
+// with bursts of up to burst events.
+type TokenBucket struct {
+	mu     sync.Mutex
+	sess   *session.Context
+	clock  datetime.Clock
+	every  time.Duration
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns TokenBucket which refills limit tokens per
+// interval and holds at most burst tokens. Bucket starts full.
+func NewTokenBucket(sess *session.Context, limit int, interval time.Duration, burst int) *TokenBucket {
+	if limit < 1 {
+		limit = 1
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	clock := sess.Time().Clock()
+	return &TokenBucket{
+		sess:   sess,
+		clock:  clock,
+		every:  interval / time.Duration(limit),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+func (tb *TokenBucket) Allow() bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refill()
+	if tb.tokens < 1 {
+		return false
+	}
+	tb.tokens--
+	return true
+}
+
+func (tb *TokenBucket) Reserve() time.Duration {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refill()
+	tb.tokens--
+	if tb.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-tb.tokens * float64(tb.every))
+}
+
+func (tb *TokenBucket) Wait(ctx context.Context) error {
+	return wait(ctx, tb.sess, tb.clock, tb.Reserve)
+}
+
+// Tokens returns number of currently available tokens.
+func (tb *TokenBucket) Tokens() float64 {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refill()
+	return tb.tokens
+}
+
+func (tb *TokenBucket) refill() {
+	now := tb.clock.Now()
+	elapsed := now.Sub(tb.last)
+	tb.last = now
+	if elapsed <= 0 || tb.every <= 0 {
+		return
+	}
+	tb.tokens = min(tb.burst, tb.tokens+float64(elapsed)/float64(tb.every))
+}
+
+// SlidingWindow is Limiter which allows at most limit events within
+// any window long period.
+type SlidingWindow struct {
+	mu     sync.Mutex
+	sess   *session.Context
+	clock  datetime.Clock
+	limit  int
+	window time.Duration
+	events []time.Time
+}
+
+// NewSlidingWindow returns SlidingWindow allowing limit events per window.
+func NewSlidingWindow(sess *session.Context, limit int, window time.Duration) *SlidingWindow {
+	if limit < 1 {
+		limit = 1
+	}
+	return &SlidingWindow{
+		sess:   sess,
+		clock:  sess.Time().Clock(),
+		limit:  limit,
+		window: window,
+	}
+}
+
+func (sw *SlidingWindow) Allow() bool {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	now := sw.clock.Now()
+	sw.expire(now)
+	if len(sw.events) >= sw.limit {
+		return false
+	}
+	sw.events = append(sw.events, now)
+	return true
+}
+
+func (sw *SlidingWindow) Reserve() time.Duration {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	now := sw.clock.Now()
+	sw.expire(now)
+	at := now
+	if len(sw.events) >= sw.limit {
+		// event may happen when the event limit positions back leaves the window
+		at = sw.events[len(sw.events)-sw.limit].Add(sw.window)
+	}
+	sw.events = append(sw.events, at)
+	return at.Sub(now)
+}
+
+func (sw *SlidingWindow) Wait(ctx context.Context) error {
+	return wait(ctx, sw.sess, sw.clock, sw.Reserve)
+}
+
+func (sw *SlidingWindow) expire(now time.Time) {
+	cutoff := now.Add(-sw.window)
+	i := 0
+	for i < len(sw.events) && !sw.events[i].After(cutoff) {
+		i++
+	}
+	sw.events = sw.events[i:]
+}
+
+func wait(ctx context.Context, sess *session.Context, clock datetime.Clock, reserve func() time.Duration) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case <-sess.Done():
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+	d := reserve()
+	if d <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && clock.Now().Add(d).After(deadline) {
+		return ErrExceeded
+	}
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-sess.Done():
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Keyed holds separate limiter per key, e.g. per host or per user.
+// Limiters idle longer than idle are removed by background goroutine
+// which exits when session is destroyed.
+type Keyed struct {
+	mu       sync.Mutex
+	clock    datetime.Clock
+	idle     time.Duration
+	factory  func() Limiter
+	limiters map[string]*keyedLimiter
+}
+
+type keyedLimiter struct {
+	Limiter
+	used time.Time
+}
+
+// NewKeyed returns Keyed which creates limiters with factory.
+func NewKeyed(sess *session.Context, idle time.Duration, factory func() Limiter) *Keyed {
+	k := &Keyed{
+		clock:    sess.Time().Clock(),
+		idle:     idle,
+		factory:  factory,
+		limiters: make(map[string]*keyedLimiter),
+	}
+	if idle > 0 {
+		go k.janitor(sess)
+	}
+	return k
+}
+
+// Get returns limiter for key creating it when needed.
+func (k *Keyed) Get(key string) Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l, ok := k.limiters[key]
+	if !ok {
+		l = &keyedLimiter{Limiter: k.factory()}
+		k.limiters[key] = l
+	}
+	l.used = k.clock.Now()
+	return l.Limiter
+}
+
+// Len returns number of active limiters.
+func (k *Keyed) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.limiters)
+}
+
+func (k *Keyed) janitor(sess *session.Context) {
+	ticker := k.clock.NewTicker(k.idle)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sess.Done():
+			k.mu.Lock()
+			k.limiters = make(map[string]*keyedLimiter)
+			k.mu.Unlock()
+			return
+		case now := <-ticker.C():
+			k.mu.Lock()
+			for key, l := range k.limiters {
+				if now.Sub(l.used) >= k.idle {
+					delete(k.limiters, key)
+				}
+			}
+			k.mu.Unlock()
+		}
+	}
+}
+
+// Transport returns http.RoundTripper which waits for limiter before
+// each request. http.DefaultTransport is used when base is nil.
+func Transport(l Limiter, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{limiter: l, base: base}
+}
+
+// Client returns copy of client which requests are rate limited by l.
+// http.DefaultClient is used when client is nil.
+func Client(l Limiter, client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := *client
+	c.Transport = Transport(l, client.Transport)
+	return &c
+}
+
+type transport struct {
+	limiter Limiter
+	base    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
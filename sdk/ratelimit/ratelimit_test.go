@@ -0,0 +1,211 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy"
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/engine"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/ratelimit"
+)
+
+// run calls fn within session of application driven by fake clock.
+func run(t *testing.T, fn func(sess *session.Context)) {
+	t.Helper()
+	main := app.New(happy.Settings{
+		Slug: "happy-ratelimit-test",
+		Engine: engine.Settings{
+			Deterministic: true,
+		},
+	})
+	main.WithLogger(logging.NewTestLogger(logging.LevelError))
+	main.Do(func(sess *session.Context, args action.Args) error {
+		fn(sess)
+		return nil
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+}
+
+func TestLimiters(t *testing.T) {
+	type step struct {
+		advance time.Duration
+		allow   bool
+	}
+	tests := []struct {
+		name  string
+		new   func(sess *session.Context) ratelimit.Limiter
+		steps []step
+	}{
+		{
+			name: "token bucket",
+			new: func(sess *session.Context) ratelimit.Limiter {
+				return ratelimit.NewTokenBucket(sess, 2, time.Second, 2)
+			},
+			steps: []step{
+				{0, true},
+				{0, true},
+				{0, false},
+				{250 * time.Millisecond, false},
+				{250 * time.Millisecond, true},
+				{0, false},
+				{5 * time.Second, true},
+				{0, true},
+				{0, false},
+			},
+		},
+		{
+			name: "sliding window",
+			new: func(sess *session.Context) ratelimit.Limiter {
+				return ratelimit.NewSlidingWindow(sess, 2, time.Second)
+			},
+			steps: []step{
+				{0, true},
+				{500 * time.Millisecond, true},
+				{0, false},
+				{500 * time.Millisecond, true},
+				{0, false},
+				{500 * time.Millisecond, true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run(t, func(sess *session.Context) {
+				l := tt.new(sess)
+				for i, s := range tt.steps {
+					sess.Time().Fake().Advance(s.advance)
+					testutils.Equal(t, s.allow, l.Allow(), "step", i)
+				}
+			})
+		})
+	}
+}
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func(sess *session.Context) ratelimit.Limiter
+		want []time.Duration
+	}{
+		{
+			name: "token bucket",
+			new: func(sess *session.Context) ratelimit.Limiter {
+				return ratelimit.NewTokenBucket(sess, 4, time.Second, 1)
+			},
+			want: []time.Duration{0, 250 * time.Millisecond, 500 * time.Millisecond},
+		},
+		{
+			name: "sliding window",
+			new: func(sess *session.Context) ratelimit.Limiter {
+				return ratelimit.NewSlidingWindow(sess, 1, time.Second)
+			},
+			want: []time.Duration{0, time.Second, 2 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run(t, func(sess *session.Context) {
+				l := tt.new(sess)
+				for i, want := range tt.want {
+					testutils.Equal(t, want, l.Reserve(), "reservation", i)
+				}
+			})
+		})
+	}
+}
+
+func TestWaitErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  func(sess *session.Context) (context.Context, context.CancelFunc)
+		want error
+	}{
+		{
+			name: "deadline exceeded by wait",
+			ctx: func(sess *session.Context) (context.Context, context.CancelFunc) {
+				return context.WithDeadline(context.Background(), sess.Time().Now().Add(100*time.Millisecond))
+			},
+			want: ratelimit.ErrExceeded,
+		},
+		{
+			name: "canceled",
+			ctx: func(sess *session.Context) (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			want: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run(t, func(sess *session.Context) {
+				l := ratelimit.NewTokenBucket(sess, 1, time.Second, 1)
+				testutils.True(t, l.Allow(), "first event")
+				ctx, cancel := tt.ctx(sess)
+				defer cancel()
+				err := l.Wait(ctx)
+				testutils.True(t, errors.Is(err, tt.want), "got", err)
+			})
+		})
+	}
+}
+
+func TestWaitFakeClock(t *testing.T) {
+	run(t, func(sess *session.Context) {
+		l := ratelimit.NewSlidingWindow(sess, 1, time.Second)
+		testutils.NoError(t, l.Wait(context.Background()))
+
+		done := make(chan error, 1)
+		go func() { done <- l.Wait(context.Background()) }()
+		select {
+		case err := <-done:
+			t.Errorf("wait returned before clock advanced: %v", err)
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+		// waiter may not have created its timer yet, keep advancing
+		for i := 0; i < 20; i++ {
+			sess.Time().Fake().Advance(time.Second)
+			select {
+			case err := <-done:
+				testutils.NoError(t, err)
+				return
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+		t.Error("wait did not return after clock advanced")
+	})
+}
+
+func TestKeyed(t *testing.T) {
+	run(t, func(sess *session.Context) {
+		k := ratelimit.NewKeyed(sess, 0, func() ratelimit.Limiter {
+			return ratelimit.NewTokenBucket(sess, 1, time.Second, 1)
+		})
+		tests := []struct {
+			key   string
+			allow bool
+		}{
+			{"a", true},
+			{"a", false},
+			{"b", true},
+			{"b", false},
+		}
+		for _, tt := range tests {
+			testutils.Equal(t, tt.allow, k.Get(tt.key).Allow(), tt.key)
+		}
+		testutils.Equal(t, 2, k.Len(), "limiters")
+	})
+}
@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+)
+
+const (
+	FormatSPDX      = "spdx"
+	FormatCycloneDX = "cyclonedx"
+	FormatLicenses  = "licenses"
+)
+
+// Encode encodes SBOM in format, FormatLicenses produces plain text
+// license report.
+func (s *SBOM) Encode(format string) ([]byte, error) {
+	switch format {
+	case FormatSPDX:
+		return s.SPDX()
+	case FormatCycloneDX:
+		return s.CycloneDX()
+	case FormatLicenses:
+		return []byte(s.LicenseReport()), nil
+	}
+	return nil, fmt.Errorf("%w: unknown format %q, expected %s, %s or %s",
+		Error, format, FormatSPDX, FormatCycloneDX, FormatLicenses)
+}
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// SPDX returns SBOM as SPDX 2.3 JSON document.
+func (s *SBOM) SPDX() ([]byte, error) {
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              s.Name,
+		DocumentNamespace: "https://spdx.org/spdxdocs/" + s.Name + "-" + s.digest(),
+		CreationInfo: spdxCreationInfo{
+			Created:  s.Created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: happy-sdk-sbom"},
+		},
+	}
+	var root string
+	for i, mod := range s.Modules {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i)
+		doc.Packages = append(doc.Packages, spdxPackage{
+			SPDXID:           id,
+			Name:             mod.Path,
+			VersionInfo:      mod.Version,
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: mod.License,
+			LicenseDeclared:  mod.License,
+			CopyrightText:    "NOASSERTION",
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  mod.purl(),
+			}},
+		})
+		if mod.Main {
+			root = id
+			doc.Relationships = append(doc.Relationships, spdxRelationship{
+				SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: id,
+			})
+		} else if root != "" {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{
+				SPDXElementID: root, RelationshipType: "DEPENDS_ON", RelatedSPDXElement: id,
+			})
+		}
+	}
+	return marshal(doc)
+}
+
+type cdxDocument struct {
+	BOMFormat    string         `json:"bomFormat"`
+	SpecVersion  string         `json:"specVersion"`
+	SerialNumber string         `json:"serialNumber"`
+	Version      int            `json:"version"`
+	Metadata     cdxMetadata    `json:"metadata"`
+	Components   []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp"`
+	Component *cdxComponent `json:"component,omitempty"`
+}
+
+type cdxComponent struct {
+	Type     string       `json:"type"`
+	BOMRef   string       `json:"bom-ref"`
+	Name     string       `json:"name"`
+	Version  string       `json:"version,omitempty"`
+	PURL     string       `json:"purl"`
+	Licenses []cdxLicense `json:"licenses,omitempty"`
+}
+
+type cdxLicense struct {
+	License cdxLicenseID `json:"license"`
+}
+
+type cdxLicenseID struct {
+	ID string `json:"id"`
+}
+
+// CycloneDX returns SBOM as CycloneDX 1.5 JSON document.
+func (s *SBOM) CycloneDX() ([]byte, error) {
+	digest := s.digest()
+	doc := cdxDocument{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		// urn:uuid formatted from digest keeps serial number stable
+		// for same module graph.
+		SerialNumber: fmt.Sprintf("urn:uuid:%s-%s-%s-%s-%s",
+			digest[0:8], digest[8:12], digest[12:16], digest[16:20], digest[20:32]),
+		Version:  1,
+		Metadata: cdxMetadata{Timestamp: s.Created.UTC().Format(time.RFC3339)},
+	}
+	for _, mod := range s.Modules {
+		c := cdxComponent{
+			Type:    "library",
+			BOMRef:  mod.purl(),
+			Name:    mod.Path,
+			Version: mod.Version,
+			PURL:    mod.purl(),
+		}
+		if mod.License != LicenseUnknown {
+			c.Licenses = []cdxLicense{{License: cdxLicenseID{ID: mod.License}}}
+		}
+		if mod.Main {
+			c.Type = "application"
+			doc.Metadata.Component = &c
+			continue
+		}
+		doc.Components = append(doc.Components, c)
+	}
+	return marshal(doc)
+}
+
+// LicenseReport returns license summary table.
+func (s *SBOM) LicenseReport() string {
+	licenses := s.Licenses()
+	ids := make([]string, 0, len(licenses))
+	for id := range licenses {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	table := textfmt.Table{
+		Title:      "Licenses of " + s.Name,
+		WithHeader: true,
+	}
+	table.AddRow("LICENSE", "MODULES", "MODULE")
+	for _, id := range ids {
+		for i, mod := range licenses[id] {
+			if i == 0 {
+				table.AddRow(id, fmt.Sprint(len(licenses[id])), mod.Path+"@"+mod.Version)
+				continue
+			}
+			table.AddRow("", "", mod.Path+"@"+mod.Version)
+		}
+	}
+	return table.String()
+}
+
+func (m Module) purl() string {
+	purl := "pkg:golang/" + m.Path
+	if m.Version != "" && m.Version != "(devel)" {
+		purl += "@" + m.Version
+	}
+	return purl
+}
+
+// digest returns hex encoded digest of module graph.
+func (s *SBOM) digest() string {
+	h := sha256.New()
+	h.Write([]byte(s.Name))
+	for _, mod := range s.Modules {
+		h.Write([]byte(strings.Join([]string{mod.Path, mod.Version, mod.Sum}, " ")))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func marshal(doc any) ([]byte, error) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return data, nil
+}
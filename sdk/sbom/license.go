@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// licenseFiles are candidate license file names in order of preference.
+var licenseFiles = []string{
+	"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "LICENCE.md",
+	"COPYING", "COPYING.md", "COPYING.txt", "LICENSE-MIT", "LICENSE-APACHE",
+}
+
+var spdxIdentifier = regexp.MustCompile(`SPDX-License-Identifier:\s*([A-Za-z0-9.+\-]+)`)
+
+// licenseMatchers identify licenses by distinctive phrases, more
+// specific licenses are listed before licenses they contain.
+var licenseMatchers = []struct {
+	id      string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"gnu affero general public license"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"CC0-1.0", []string{"cc0 1.0 universal"}},
+}
+
+// DetectLicense detects license of module in dir, it returns SPDX
+// license identifier and path of license file or LicenseUnknown.
+func DetectLicense(dir string) (id, file string) {
+	for _, name := range licenseFiles {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if id := ClassifyLicense(string(data)); id != LicenseUnknown {
+			return id, path
+		}
+	}
+	return LicenseUnknown, ""
+}
+
+// ClassifyLicense returns SPDX license identifier of license text or
+// LicenseUnknown.
+func ClassifyLicense(text string) string {
+	if m := spdxIdentifier.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, m := range licenseMatchers {
+		matched := true
+		for _, phrase := range m.phrases {
+			if !strings.Contains(text, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return m.id
+		}
+	}
+	return LicenseUnknown
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package sbom
+
+import (
+	"os"
+
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+)
+
+// Command returns sbom command which prints SBOM or license report of
+// binary, application binary itself when no binary is given.
+func Command() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "sbom",
+		Category:         "Release",
+		Description:      "Generate SBOM and license report of binary",
+		Usage:            "[binary]",
+		MaxArgs:          1,
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.AddInfo("Module graph is read from build info embedded in the binary and licenses are detected from module cache.")
+
+	cmd.WithFlags(
+		varflag.StringFunc("format", FormatSPDX, "output format: spdx, cyclonedx or licenses"),
+		varflag.StringFunc("output", "", "write output to file instead of stdout"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		binary := args.Arg(0).String()
+		if args.Argn() == 0 {
+			exe, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			binary = exe
+		}
+		s, err := FromBinary(binary)
+		if err != nil {
+			return err
+		}
+		var root string
+		if proj := sess.Project(); proj != nil {
+			root = proj.Root
+		}
+		s.DetectLicenses(root)
+
+		data, err := s.Encode(args.Flag("format").String())
+		if err != nil {
+			return err
+		}
+		if output := args.Flag("output").String(); output != "" {
+			return os.WriteFile(output, data, 0644)
+		}
+		sess.Log().Println(string(data))
+		return nil
+	})
+	return cmd
+}
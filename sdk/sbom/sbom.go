@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package sbom generates software bill of materials in SPDX and
+// CycloneDX formats and license summary from module graph embedded in
+// Go binaries.
+package sbom
+
+import (
+	"debug/buildinfo"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var Error = errors.New("sbom")
+
+// LicenseUnknown is license of module which license was not detected.
+const LicenseUnknown = "NOASSERTION"
+
+// Module is single module of binary module graph.
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+	// Main is true for main module of binary.
+	Main bool `json:"main,omitempty"`
+	// License is SPDX license identifier or LicenseUnknown.
+	License string `json:"license"`
+	// LicenseFile is path of license file license was detected from.
+	LicenseFile string `json:"license_file,omitempty"`
+}
+
+// SBOM is bill of materials of single binary.
+type SBOM struct {
+	Name      string    `json:"name"`
+	GoVersion string    `json:"go_version"`
+	Created   time.Time `json:"created"`
+	// Modules holds main module first, followed by dependencies
+	// sorted by path.
+	Modules []Module `json:"modules"`
+}
+
+// FromBinary reads module graph of Go binary at path.
+func FromBinary(path string) (*SBOM, error) {
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return FromBuildInfo(filepath.Base(path), info), nil
+}
+
+// FromBuildInfo returns SBOM of binary name from its build info.
+func FromBuildInfo(name string, info *debug.BuildInfo) *SBOM {
+	s := &SBOM{
+		Name:      name,
+		GoVersion: info.GoVersion,
+		Created:   time.Now().UTC(),
+	}
+	if info.Main.Path != "" {
+		s.Modules = append(s.Modules, module(&info.Main, true))
+	}
+	var deps []Module
+	for _, dep := range info.Deps {
+		deps = append(deps, module(dep, false))
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Path < deps[j].Path })
+	s.Modules = append(s.Modules, deps...)
+	return s
+}
+
+// DetectLicenses detects licenses of modules from module cache, main
+// module license is detected from root when root is not empty.
+func (s *SBOM) DetectLicenses(root string) {
+	modcache := modCache()
+	for i := range s.Modules {
+		mod := &s.Modules[i]
+		var dir string
+		switch {
+		case mod.Main && root != "":
+			dir = root
+		case mod.Main, modcache == "", mod.Version == "" || mod.Version == "(devel)":
+			continue
+		default:
+			dir = filepath.Join(modcache, escapePath(mod.Path)+"@"+escapePath(mod.Version))
+		}
+		mod.License, mod.LicenseFile = DetectLicense(dir)
+	}
+}
+
+// Licenses returns modules grouped by license.
+func (s *SBOM) Licenses() map[string][]Module {
+	licenses := make(map[string][]Module)
+	for _, mod := range s.Modules {
+		licenses[mod.License] = append(licenses[mod.License], mod)
+	}
+	return licenses
+}
+
+func module(m *debug.Module, main bool) Module {
+	if m.Replace != nil {
+		m = m.Replace
+	}
+	return Module{
+		Path:    m.Path,
+		Version: m.Version,
+		Sum:     m.Sum,
+		Main:    main,
+		License: LicenseUnknown,
+	}
+}
+
+func modCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	out, err := exec.Command("go", "env", "GOMODCACHE").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// escapePath escapes module path or version the way module cache does,
+// upper case letters are replaced with ! followed by lower case letter.
+func escapePath(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Write writes SBOM of binary in formats next to binary as
+// <binary>.<format>.json, or <binary>.licenses.txt for license report,
+// and returns paths of written files. Main module license is detected
+// from root when root is not empty.
+func Write(binary, root string, formats ...string) ([]string, error) {
+	s, err := FromBinary(binary)
+	if err != nil {
+		return nil, err
+	}
+	s.DetectLicenses(root)
+	var files []string
+	for _, format := range formats {
+		data, err := s.Encode(format)
+		if err != nil {
+			return files, err
+		}
+		path := binary + "." + format + ".json"
+		if format == FormatLicenses {
+			path = binary + "." + format + ".txt"
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return files, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package services
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+var (
+	ErrServiceNotFound   = fmt.Errorf("%w: service not found", Error)
+	ErrServiceNotRunning = fmt.Errorf("%w: service not running", Error)
+	ErrServiceAPI        = fmt.Errorf("%w: service api", Error)
+)
+
+// API returns API object provided by service with Service.ProvideAPI.
+// Service is looked up by name, slug or address and it must be running
+// e.g. loaded with Require or required by command.
+//
+//	store, err := services.API[*Store](sess, "store")
+func API[T any](sess *session.Context, name string) (T, error) {
+	var zero T
+	info, err := lookup(sess, name)
+	if err != nil {
+		return zero, err
+	}
+	if !info.Running() {
+		return zero, fmt.Errorf("%w: %s", ErrServiceNotRunning, info.Addr())
+	}
+	raw := info.API()
+	if raw == nil {
+		return zero, fmt.Errorf("%w: %s does not provide api", ErrServiceAPI, info.Addr())
+	}
+	api, ok := raw.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s provides %T, not %s", ErrServiceAPI, info.Addr(), raw, reflect.TypeFor[T]())
+	}
+	return api, nil
+}
+
+// MustAPI is like API but panics on error, use it only for services
+// guaranteed to be running e.g. required by command.
+func MustAPI[T any](sess *session.Context, name string) T {
+	api, err := API[T](sess, name)
+	if err != nil {
+		panic(err)
+	}
+	return api
+}
+
+func lookup(sess *session.Context, name string) (*service.Info, error) {
+	if info, err := sess.ServiceInfo(name); err == nil {
+		return info, nil
+	}
+	for _, info := range sess.Services() {
+		if info.Name() == name || path.Base(info.Addr().String()) == name {
+			return info, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrServiceNotFound, name)
+}
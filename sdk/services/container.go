@@ -12,10 +12,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/happy-sdk/happy/pkg/options"
 	"github.com/happy-sdk/happy/pkg/vars"
+	"github.com/happy-sdk/happy/sdk/action"
 	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/datetime"
 	"github.com/happy-sdk/happy/sdk/events"
 	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/logging"
 	"github.com/happy-sdk/happy/sdk/networking/address"
 	"github.com/happy-sdk/happy/sdk/services/service"
 )
@@ -27,7 +31,18 @@ type Container struct {
 	cancel  context.CancelCauseFunc
 	ctx     context.Context
 	cron    *serviceCron
+	clock   datetime.Clock
 	retries int
+	// budget is deadline of tick and tock invocations.
+	budget time.Duration
+
+	// replicas are child sessions of service instances
+	// when service runs more than one instance.
+	replicas []*session.Context
+
+	// sess is scoped session with own logger when service
+	// has logging overrides.
+	sess *session.Context
 }
 
 func NewContainer(sess *session.Context, addr *address.Address, svc *Service) (*Container, error) {
@@ -41,6 +56,7 @@ func NewContainer(sess *session.Context, addr *address.Address, svc *Service) (*
 		info: service.NewInfo(svc.Name(), addr),
 		svc:  svc,
 	}
+	service.SetInstances(container.info, int(svc.settings.Instances))
 
 	if err := session.AttachServiceInfo(sess, container.Info()); err != nil {
 		return nil, err
@@ -60,22 +76,37 @@ func (c *Container) Settings() service.Config {
 	return c.svc.settings
 }
 
+// SetClock sets clock used by service cron jobs,
+// it must be called before service is registered.
+func (c *Container) SetClock(clock datetime.Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clock
+}
+
 func (c *Container) Register(sess *session.Context) error {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	initerrs := errors.Join(c.svc.errs...)
 	if initerrs != nil {
-		return fmt.Errorf("%w(%s): service failed to initialize: %w", Error, c.info.Name(), initerrs)
+		err := fmt.Errorf("%w(%s): service failed to initialize: %w", Error, c.info.Name(), initerrs)
+		service.AddError(c.info, err)
+		return err
+	}
+	if err := c.setupLogging(sess); err != nil {
+		service.AddError(c.info, err)
+		return err
 	}
+	sess = c.scoped(sess)
 	if c.svc.registerAction != nil {
-		if err := c.svc.registerAction(sess); err != nil {
+		if err := action.Try(func() error { return c.svc.registerAction(sess) }); err != nil {
 			service.AddError(c.info, err)
 			return err
 		}
 	}
 
 	if c.svc.cronsetup != nil {
-		c.cron = newCron(sess)
+		c.cron = newCron(sess, c.clock)
 		c.svc.cronsetup(c.cron)
 	}
 	sess.Log().Debug("service registered",
@@ -121,10 +152,19 @@ func (c *Container) Start(ectx context.Context, sess *session.Context) (err erro
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	sess = c.scoped(sess)
 
 	c.retries++
-	if c.svc.startAction != nil {
-		if err := c.svc.startAction(sess); err != nil {
+	if c.info.Instances() > 1 {
+		if err := c.startReplicas(sess); err != nil {
+			service.AddError(c.info, err)
+			logPanicStack(sess, err)
+			return err
+		}
+	} else if c.svc.startAction != nil {
+		if err := action.Try(func() error { return c.svc.startAction(sess) }); err != nil {
+			service.AddError(c.info, err)
+			logPanicStack(sess, err)
 			return err
 		}
 	}
@@ -136,10 +176,12 @@ func (c *Container) Start(ectx context.Context, sess *session.Context) (err erro
 	}
 
 	c.ctx, c.cancel = context.WithCancelCause(ectx) // with engine context
+	c.budget = sess.Get("app.engine.throttle_ticks").Duration()
 
 	payload := new(vars.Map)
 
 	if err == nil {
+		service.SetAPI(c.info, c.svc.api)
 		service.MarkStarted(c.info)
 	} else {
 		service.AddError(c.info, err)
@@ -152,6 +194,7 @@ func (c *Container) Start(ectx context.Context, sess *session.Context) (err erro
 		"addr":       c.info.Addr(),
 		"running":    c.info.Running(),
 		"started.at": c.info.StartedAt(),
+		"instances":  c.info.Instances(),
 	}
 	for k, v := range kv {
 		if err := payload.Store(k, v); err != nil {
@@ -165,11 +208,13 @@ func (c *Container) Start(ectx context.Context, sess *session.Context) (err erro
 }
 
 func (c *Container) Stop(sess *session.Context, e error) (err error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sess = c.scoped(sess)
 
 	if e != nil {
 		sess.Log().Error(e.Error(), slog.String("service", c.info.Addr().String()))
+		logPanicStack(sess, e)
 	}
 	if c.cron != nil {
 		internal.Log(sess.Log(), "stopping cron scheduler, waiting jobs to finish", slog.String("service", c.info.Addr().String()))
@@ -179,10 +224,13 @@ func (c *Container) Stop(sess *session.Context, e error) (err error) {
 	}
 
 	c.cancel(e)
-	if c.svc.stopAction != nil {
-		err = c.svc.stopAction(sess, e)
+	if len(c.replicas) > 0 {
+		err = c.stopReplicas(e)
+	} else if c.svc.stopAction != nil {
+		err = action.Try(func() error { return c.svc.stopAction(sess, e) })
 	}
 
+	service.SetAPI(c.info, nil)
 	service.MarkStopped(c.info)
 
 	payload := new(vars.Map)
@@ -228,7 +276,15 @@ func (c *Container) Tick(sess *session.Context, ts time.Time, delta time.Duratio
 	if c.svc.tickAction == nil {
 		return nil
 	}
-	return c.svc.tickAction(sess, ts, delta)
+	sess = c.scoped(sess)
+	ctx, cancel := c.tickContext(sess)
+	defer cancel()
+	if len(c.replicas) > 0 {
+		return c.eachReplica(func(rsess *session.Context) error {
+			return c.svc.tickAction(ctx, rsess, ts, delta)
+		})
+	}
+	return action.Try(func() error { return c.svc.tickAction(ctx, sess, ts, delta) })
 }
 
 func (c *Container) Tock(sess *session.Context, delta time.Duration, tps int) error {
@@ -237,7 +293,18 @@ func (c *Container) Tock(sess *session.Context, delta time.Duration, tps int) er
 		c.mu.RUnlock()
 		return nil
 	}
-	if err := c.svc.tockAction(sess, delta, tps); err != nil {
+	sess = c.scoped(sess)
+	ctx, cancel := c.tickContext(sess)
+	defer cancel()
+	var err error
+	if len(c.replicas) > 0 {
+		err = c.eachReplica(func(rsess *session.Context) error {
+			return c.svc.tockAction(ctx, rsess, delta, tps)
+		})
+	} else {
+		err = action.Try(func() error { return c.svc.tockAction(ctx, sess, delta, tps) })
+	}
+	if err != nil {
 		c.mu.RUnlock()
 		return err
 	}
@@ -252,19 +319,34 @@ func (c *Container) Tock(sess *session.Context, delta time.Duration, tps int) er
 	return nil
 }
 
+// tickContext returns context of tick or tock invocation, it is
+// cancelled when service stops or invocation exceeds tick budget.
+func (c *Container) tickContext(sess *session.Context) (context.Context, context.CancelFunc) {
+	parent := context.Context(sess)
+	if c.ctx != nil {
+		parent = c.ctx
+	}
+	if c.budget > 0 {
+		return context.WithTimeout(parent, c.budget)
+	}
+	return context.WithCancel(parent)
+}
+
 func (c *Container) HandleEvent(sess *session.Context, ev events.Event) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	if c.svc.listeners == nil {
 		return
 	}
+	sess = c.scoped(sess)
 	lid := ev.Scope() + "." + ev.Key()
 	for sk, listeners := range c.svc.listeners {
 		for _, listener := range listeners {
 			if sk == "any" || sk == lid {
-				if err := listener(sess, ev); err != nil {
+				if err := action.Try(func() error { return listener(sess, ev) }); err != nil {
 					service.AddError(c.info, err)
 					sess.Log().Error(Error.Error(), slog.String("service", c.info.Addr().String()), slog.String("err", err.Error()))
+					logPanicStack(sess, err)
 				}
 			}
 		}
@@ -284,6 +366,132 @@ func (c *Container) Listeners() []string {
 	return listeners
 }
 
+// setupLogging creates scoped session with own logger when service
+// has logging overrides or custom log handler.
+func (c *Container) setupLogging(sess *session.Context) error {
+	cnf := c.svc.settings.Logging
+	if !cnf.Overrides() && c.svc.logHandler == nil {
+		return nil
+	}
+	lvl := sess.Log().Level()
+	if cnf.Level != "" {
+		l, err := logging.LevelFromString(cnf.Level.String())
+		if err != nil {
+			return fmt.Errorf("%w(%s): logging: %s", Error, c.info.Name(), err.Error())
+		}
+		lvl = l
+	}
+
+	var (
+		logger logging.Logger
+		file   *logging.RotatingFile
+	)
+	switch {
+	case c.svc.logHandler != nil:
+		logger = logging.NewFromHandler(c.svc.logHandler, lvl)
+	case cnf.File != "":
+		f, err := logging.NewRotatingFile(cnf.File.String(), int64(cnf.MaxSize), int(cnf.MaxBackups))
+		if err != nil {
+			return fmt.Errorf("%w(%s): logging: %s", Error, c.info.Name(), err.Error())
+		}
+		file = f
+		logger = logging.New(file, lvl)
+	default:
+		logger = logging.NewFromHandler(sess.Log().Logger().Handler(), lvl)
+	}
+
+	scoped, err := session.Scoped(sess, c.svc.Slug(), logging.WithAttrs(logger, slog.String("service", c.info.Addr().String())))
+	if err != nil {
+		if file != nil {
+			_ = file.Close()
+		}
+		return err
+	}
+	if file != nil {
+		go func() {
+			<-scoped.Done()
+			_ = file.Close()
+		}()
+	}
+	c.sess = scoped
+	return nil
+}
+
+// scoped returns service scoped session when service has own logger.
+func (c *Container) scoped(sess *session.Context) *session.Context {
+	if c.sess != nil {
+		return c.sess
+	}
+	return sess
+}
+
+// startReplicas creates child session for each service instance
+// and calls start action with it.
+func (c *Container) startReplicas(sess *session.Context) error {
+	n := c.info.Instances()
+	replicas := make([]*session.Context, 0, n)
+	for i := 0; i < n; i++ {
+		rsess, err := sess.Child(
+			fmt.Sprintf("%s-%d", c.svc.Slug(), i),
+			options.NewArg("service.instance.id", i),
+		)
+		if err != nil {
+			for _, r := range replicas {
+				r.Destroy(nil)
+			}
+			return err
+		}
+		replicas = append(replicas, rsess)
+	}
+	c.replicas = replicas
+	if c.svc.startAction == nil {
+		return nil
+	}
+	if err := c.eachReplica(c.svc.startAction); err != nil {
+		for _, r := range c.replicas {
+			r.Destroy(nil)
+		}
+		c.replicas = nil
+		return err
+	}
+	return nil
+}
+
+// stopReplicas calls stop action of all instances in parallel
+// and destroys their sessions.
+func (c *Container) stopReplicas(e error) error {
+	var err error
+	if c.svc.stopAction != nil {
+		err = c.eachReplica(func(rsess *session.Context) error {
+			return c.svc.stopAction(rsess, e)
+		})
+	}
+	for _, r := range c.replicas {
+		r.Destroy(nil)
+	}
+	c.replicas = nil
+	return err
+}
+
+// eachReplica calls fn for each service instance in parallel.
+func (c *Container) eachReplica(fn action.Action) error {
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(c.replicas))
+	)
+	for i, rsess := range c.replicas {
+		wg.Add(1)
+		go func(i int, rsess *session.Context) {
+			defer wg.Done()
+			if err := action.Try(func() error { return fn(rsess) }); err != nil {
+				errs[i] = fmt.Errorf("instance %d: %w", i, err)
+			}
+		}(i, rsess)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
 func (c *Container) Cancel(err error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package services_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy"
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/engine"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+func TestTickContext(t *testing.T) {
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"happy-tick-context-test"}
+
+	main := app.New(happy.Settings{
+		Slug: "happy-tick-context-test",
+		Engine: engine.Settings{
+			ThrottleTicks: settings.Duration(10 * time.Millisecond),
+		},
+	})
+	main.WithLogger(logging.NewTestLogger(logging.LevelError))
+
+	ticked := make(chan context.Context, 1)
+	svc := services.New(service.Config{Name: "tick-context"})
+	svc.TickContext(func(ctx context.Context, sess *session.Context, ts time.Time, delta time.Duration) error {
+		select {
+		case ticked <- ctx:
+		default:
+		}
+		return nil
+	})
+	main.WithServices(svc)
+
+	var (
+		ctx         context.Context
+		hasDeadline bool
+	)
+	main.Do(func(sess *session.Context, args action.Args) error {
+		loader, err := services.Require(sess, "tick-context")
+		if err != nil {
+			return err
+		}
+		select {
+		case ctx = <-ticked:
+		case <-time.After(5 * time.Second):
+			return errors.New("tick not observed")
+		}
+		_, hasDeadline = ctx.Deadline()
+		return loader.Stop()
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+	testutils.True(t, hasDeadline, "tick context must have deadline")
+	testutils.Error(t, ctx.Err(), "tick context must be done after tick returned")
+}
+
+func TestStartPanic(t *testing.T) {
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"happy-start-panic-test"}
+
+	main := app.New(happy.Settings{Slug: "happy-start-panic-test"})
+	main.WithLogger(logging.NewTestLogger(logging.LevelQuiet))
+
+	svc := services.New(service.Config{Name: "start-panic"})
+	svc.OnStart(func(sess *session.Context) error {
+		panic("start")
+	})
+	main.WithServices(svc)
+
+	var (
+		loadErr error
+		svcErrs []error
+	)
+	main.Do(func(sess *session.Context, args action.Args) error {
+		_, loadErr = services.Require(sess, "start-panic")
+		for _, info := range sess.Services() {
+			if info.Name() != "start-panic" {
+				continue
+			}
+			for _, err := range info.Errs() {
+				svcErrs = append(svcErrs, err)
+			}
+		}
+		return nil
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()), "panic in service hook must not crash application")
+	testutils.Error(t, loadErr)
+	if !testutils.Equal(t, 1, len(svcErrs), "service errors") {
+		return
+	}
+	var perr *action.PanicError
+	if testutils.True(t, errors.As(svcErrs[0], &perr), "service error must be *action.PanicError") {
+		testutils.EqualAny(t, "start", perr.Value)
+		testutils.True(t, len(perr.Stack) > 0, "stack must be recorded")
+	}
+}
@@ -0,0 +1,313 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+// Process is API of service created with Exec, it reports state of
+// supervised process.
+type Process struct {
+	mu       sync.Mutex
+	cnf      service.Process
+	name     string
+	args     []string
+	cmd      *exec.Cmd
+	exited   chan struct{}
+	stopping bool
+	restarts int
+	err      error
+	stop     chan struct{}
+}
+
+// Exec returns service which supervises external process cmd. Process
+// is started when service starts and terminated with SIGTERM, followed
+// by SIGKILL after Process.StopTimeout, when service stops. Output of
+// the process is written to service log line by line, stdout at info
+// and stderr at warn level. Process exiting while service is running
+// is restarted according to Process.Restart policy. Process API is
+// available with API[*services.Process] while service is running.
+func Exec(cnf service.Config, cmd ...string) *Service {
+	svc := New(cnf)
+	if len(cmd) == 0 || cmd[0] == "" {
+		svc.errs = append(svc.errs, fmt.Errorf("%w: exec service %s: command is empty", Error, cnf.Name))
+		return svc
+	}
+	switch cnf.Process.Restart.String() {
+	case "", service.RestartNever, service.RestartOnFailure, service.RestartAlways:
+	default:
+		svc.errs = append(svc.errs, fmt.Errorf("%w: exec service %s: invalid restart policy %q", Error, cnf.Name, cnf.Process.Restart))
+		return svc
+	}
+	proc := &Process{
+		cnf:  cnf.Process,
+		name: cmd[0],
+		args: cmd[1:],
+	}
+	if proc.cnf.LogPrefix == "" {
+		proc.cnf.LogPrefix = svc.settings.Slug
+	}
+	if proc.cnf.RestartDelay == 0 {
+		proc.cnf.RestartDelay = settings.Duration(time.Second)
+	}
+	if proc.cnf.MaxRestarts == 0 {
+		proc.cnf.MaxRestarts = 5
+	}
+	if proc.cnf.StopTimeout == 0 {
+		proc.cnf.StopTimeout = settings.Duration(10 * time.Second)
+	}
+	svc.ProvideAPI(proc)
+
+	svc.OnStart(func(sess *session.Context) error {
+		proc.mu.Lock()
+		defer proc.mu.Unlock()
+		proc.stopping = false
+		proc.restarts = 0
+		proc.err = nil
+		proc.stop = make(chan struct{})
+		if err := proc.start(sess); err != nil {
+			return err
+		}
+		go proc.supervise(sess, proc.stop)
+		return nil
+	})
+
+	svc.OnStop(func(sess *session.Context, e error) error {
+		return proc.terminate(sess)
+	})
+	return svc
+}
+
+// Pid returns process id of running process, 0 when process is not running.
+func (p *Process) Pid() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd == nil || p.cmd.Process == nil || p.exited == nil {
+		return 0
+	}
+	select {
+	case <-p.exited:
+		return 0
+	default:
+		return p.cmd.Process.Pid
+	}
+}
+
+// Restarts returns how many times process was restarted since service started.
+func (p *Process) Restarts() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.restarts
+}
+
+// Err returns error of last process exit, nil when process exited
+// successfully or it is still running.
+func (p *Process) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// start starts the process, caller must hold p.mu.
+func (p *Process) start(sess *session.Context) error {
+	cmd := exec.Command(p.name, p.args...)
+	cmd.Dir = p.cnf.Dir.String()
+	if cmd.Dir == "" {
+		cmd.Dir = sess.Get("app.fs.path.wd").String()
+	}
+	env, err := p.environ(sess)
+	if err != nil {
+		return err
+	}
+	cmd.Env = env
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %s", Error, p.name, err.Error())
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %s", Error, p.name, err.Error())
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("%w: %s: %s", Error, p.name, err.Error())
+	}
+
+	var pipes sync.WaitGroup
+	pipes.Add(2)
+	go p.pipe(&pipes, sess.Log(), logging.LevelInfo, stdout)
+	go p.pipe(&pipes, sess.Log(), logging.LevelWarn, stderr)
+
+	exited := make(chan struct{})
+	go func() {
+		// output must be read before Wait closes pipes.
+		pipes.Wait()
+		err := cmd.Wait()
+		p.mu.Lock()
+		p.err = err
+		p.mu.Unlock()
+		close(exited)
+	}()
+	p.cmd, p.exited = cmd, exited
+	internal.Log(sess.Log(), "process started",
+		slog.String("cmd", p.name),
+		slog.Int("pid", cmd.Process.Pid))
+	return nil
+}
+
+// environ returns environment of the process.
+func (p *Process) environ(sess *session.Context) ([]string, error) {
+	env := append(os.Environ(), p.cnf.Env...)
+	for _, entry := range p.cnf.EnvFrom {
+		name, key, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || key == "" {
+			return nil, fmt.Errorf("%w: %s: invalid env from entry %q", Error, p.name, entry)
+		}
+		if !sess.Has(key) {
+			return nil, fmt.Errorf("%w: %s: env %s: no such setting %s", Error, p.name, name, key)
+		}
+		env = append(env, name+"="+sess.Get(key).String())
+	}
+	return env, nil
+}
+
+// pipe writes lines read from r to log.
+func (p *Process) pipe(wg *sync.WaitGroup, log logging.Logger, lvl logging.Level, r io.Reader) {
+	defer wg.Done()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		log.LogDepth(0, lvl, p.cnf.LogPrefix.String()+": "+scanner.Text())
+	}
+}
+
+// supervise restarts process when it exits until stop is closed.
+func (p *Process) supervise(sess *session.Context, stop <-chan struct{}) {
+	for {
+		p.mu.Lock()
+		exited := p.exited
+		p.mu.Unlock()
+
+		select {
+		case <-stop:
+			return
+		case <-exited:
+		}
+
+		p.mu.Lock()
+		if p.stopping {
+			p.mu.Unlock()
+			return
+		}
+		err := p.err
+		restart := p.shouldRestart(err)
+		p.mu.Unlock()
+
+		attrs := []slog.Attr{slog.String("cmd", p.name)}
+		if err != nil {
+			attrs = append(attrs, slog.String("err", err.Error()))
+		}
+		if !restart {
+			sess.Log().Warn("process exited", attrs...)
+			return
+		}
+		sess.Log().Warn("process exited, restarting", attrs...)
+
+		timer := sess.Time().Clock().NewTimer(time.Duration(p.cnf.RestartDelay))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		p.mu.Lock()
+		if p.stopping {
+			p.mu.Unlock()
+			return
+		}
+		p.restarts++
+		if err := p.start(sess); err != nil {
+			p.err = err
+			p.mu.Unlock()
+			sess.Log().Error("failed to restart process", slog.String("cmd", p.name), slog.String("err", err.Error()))
+			return
+		}
+		p.mu.Unlock()
+	}
+}
+
+// shouldRestart reports whether process which exited with err is
+// restarted, caller must hold p.mu.
+func (p *Process) shouldRestart(err error) bool {
+	if max := int(p.cnf.MaxRestarts); max >= 0 && p.restarts >= max {
+		return false
+	}
+	switch p.cnf.Restart.String() {
+	case service.RestartAlways:
+		return true
+	case service.RestartNever:
+		return false
+	default:
+		return err != nil
+	}
+}
+
+// terminate stops supervising and terminates the process gracefully.
+func (p *Process) terminate(sess *session.Context) error {
+	p.mu.Lock()
+	p.stopping = true
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+	cmd, exited := p.cmd, p.exited
+	p.mu.Unlock()
+	if cmd == nil || exited == nil {
+		return nil
+	}
+	select {
+	case <-exited:
+		return nil
+	default:
+	}
+
+	timeout := time.Duration(p.cnf.StopTimeout)
+	// SIGTERM is not supported on Windows, process is killed right away.
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		timeout = 0
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-exited:
+		return nil
+	case <-timer.C:
+	}
+	sess.Log().Warn("process did not exit on time, killing",
+		slog.String("cmd", p.name),
+		slog.Duration("timeout", timeout))
+	if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("%w: %s: %s", Error, p.name, err.Error())
+	}
+	<-exited
+	return nil
+}
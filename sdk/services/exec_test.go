@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package services_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy"
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+// runExec runs application with exec service and calls do with
+// process API while service is running.
+func runExec(t *testing.T, cnf service.Config, script string, do func(sess *session.Context, proc *services.Process) error) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("exec tests require sh")
+	}
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"happy-exec-test"}
+
+	main := app.New(happy.Settings{Slug: "happy-exec-test"})
+	main.WithLogger(logging.NewTestLogger(logging.LevelError))
+	main.WithServices(services.Exec(cnf, "sh", "-c", script))
+	main.Do(func(sess *session.Context, args action.Args) error {
+		loader, err := services.Require(sess, cnf.Name.String())
+		if err != nil {
+			return err
+		}
+		proc, err := services.API[*services.Process](sess, cnf.Name.String())
+		if err != nil {
+			return err
+		}
+		if err := do(sess, proc); err != nil {
+			return err
+		}
+		return loader.Stop()
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+}
+
+func TestExecStop(t *testing.T) {
+	var pid int
+	runExec(t, service.Config{
+		Name:    "exec-stop",
+		Process: service.Process{StopTimeout: settings.Duration(5 * time.Second)},
+	}, "trap 'exit 0' TERM; while :; do sleep 0.05; done", func(sess *session.Context, proc *services.Process) error {
+		pid = proc.Pid()
+		if pid == 0 {
+			return errors.New("process must be running")
+		}
+		return nil
+	})
+	testutils.True(t, pid > 0, "process must be started")
+}
+
+func TestExecRestart(t *testing.T) {
+	runExec(t, service.Config{
+		Name: "exec-restart",
+		Process: service.Process{
+			Restart:      service.RestartOnFailure,
+			RestartDelay: settings.Duration(10 * time.Millisecond),
+			MaxRestarts:  2,
+		},
+	}, "exit 3", func(sess *session.Context, proc *services.Process) error {
+		deadline := time.Now().Add(5 * time.Second)
+		for proc.Restarts() < 2 || proc.Pid() != 0 {
+			if time.Now().After(deadline) {
+				return errors.New("process must be restarted twice")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if proc.Err() == nil {
+			return errors.New("exit error must be reported")
+		}
+		return nil
+	})
+}
+
+func TestExecInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cnf  service.Config
+		cmd  []string
+	}{
+		{name: "empty command", cnf: service.Config{Name: "exec"}},
+		{name: "invalid restart", cnf: service.Config{Name: "exec", Process: service.Process{Restart: "sometimes"}}, cmd: []string{"true"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(args []string) { os.Args = args }(os.Args)
+			os.Args = []string{"happy-exec-test"}
+			main := app.New(happy.Settings{Slug: "happy-exec-test"})
+			main.WithLogger(logging.NewTestLogger(logging.LevelQuiet))
+			main.WithServices(services.Exec(tt.cnf, tt.cmd...))
+			main.Do(func(sess *session.Context, args action.Args) error {
+				_, err := services.Require(sess, tt.cnf.Name.String())
+				return err
+			})
+			testutils.Error(t, main.RunCtx(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,365 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package logforward provides passive log forwarding service. Other
+// services route their structured logs to it with Route or Handler and
+// records are written to rotated JSON log file per source by background
+// writers, so that disk IO does not slow down services which log on
+// hot paths. Each source has bounded queue, records which do not fit
+// are dropped and counted.
+package logforward
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+var Error = errors.New("logforward")
+
+type Config struct {
+	// Name of the forwarder service.
+	Name string
+	// Dir is directory <source>.log files are written to, defaults to
+	// logs directory in application state directory.
+	Dir string
+	// QueueSize is number of records queued per source, defaults to 1024.
+	QueueSize int
+	// MaxSize is size in bytes after log file is rotated, defaults
+	// to 10MB.
+	MaxSize int64
+	// MaxBackups is number of rotated files kept per source, defaults to 3.
+	MaxBackups int
+}
+
+// Stats are forwarding statistics of source.
+type Stats struct {
+	Source string
+	// Written is number of records written to log file.
+	Written uint64
+	// Dropped is number of records dropped because queue was full or
+	// forwarder was stopped.
+	Dropped uint64
+	// Failed is number of records which could not be written.
+	Failed uint64
+	// Queued is number of records waiting to be written.
+	Queued int
+}
+
+// Forwarder writes records of sources to their log files.
+type Forwarder struct {
+	mu      sync.Mutex
+	cnf     Config
+	sources map[string]*source
+	running bool
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// New returns new Forwarder.
+func New(cnf Config) *Forwarder {
+	if cnf.Name == "" {
+		cnf.Name = "log-forwarder"
+	}
+	if cnf.QueueSize <= 0 {
+		cnf.QueueSize = 1024
+	}
+	if cnf.MaxSize <= 0 {
+		cnf.MaxSize = 10 << 20
+	}
+	if cnf.MaxBackups <= 0 {
+		cnf.MaxBackups = 3
+	}
+	return &Forwarder{
+		cnf:     cnf,
+		sources: make(map[string]*source),
+	}
+}
+
+// Route forwards logs of svc to source named by service slug, records
+// are also passed to handlers in also e.g. to keep them in console.
+// It must be called before svc is registered.
+func (f *Forwarder) Route(svc *services.Service, also ...slog.Handler) {
+	h := f.Handler(svc.Slug())
+	if len(also) > 0 {
+		h = logging.FanOut(append([]slog.Handler{h}, also...)...)
+	}
+	svc.WithLogHandler(h)
+}
+
+// Handler returns handler queueing records of source, records are
+// queued before forwarder service is started and written once it is
+// running.
+func (f *Forwarder) Handler(name string) slog.Handler {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	src, ok := f.sources[name]
+	if !ok {
+		src = newSource(name, f.cnf.QueueSize)
+		f.sources[name] = src
+		if f.running {
+			f.startSource(src)
+		}
+		if f.stopped {
+			src.close()
+		}
+	}
+	return &handler{src: src, h: src.json}
+}
+
+// Stats returns statistics of sources sorted by source name.
+func (f *Forwarder) Stats() []Stats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats := make([]Stats, 0, len(f.sources))
+	for _, src := range f.sources {
+		src.mu.RLock()
+		queued := len(src.queue)
+		src.mu.RUnlock()
+		stats = append(stats, Stats{
+			Source:  src.name,
+			Written: src.written.Load(),
+			Dropped: src.dropped.Load(),
+			Failed:  src.failed.Load(),
+			Queued:  queued,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Source < stats[j].Source })
+	return stats
+}
+
+// AsService returns service which writes forwarded records while it
+// is running, queued records are written before it stops. Forwarder
+// is provided as service API.
+func (f *Forwarder) AsService() *services.Service {
+	svc := services.New(service.Config{
+		Name:        settings.String(f.cnf.Name),
+		Description: "Writes forwarded service logs to files",
+	})
+	svc.ProvideAPI(f)
+
+	svc.OnStart(func(sess *session.Context) error {
+		dir := f.cnf.Dir
+		if dir == "" {
+			state := sess.Get("app.fs.path.state").String()
+			if state == "" {
+				return fmt.Errorf("%w: log directory is not configured", Error)
+			}
+			dir = filepath.Join(state, "logs")
+		}
+		f.start(dir)
+		internal.Log(sess.Log(), "forwarding logs", slog.String("dir", dir))
+		return nil
+	})
+
+	svc.OnStop(func(sess *session.Context, e error) error {
+		f.stop()
+		for _, st := range f.Stats() {
+			if st.Dropped > 0 || st.Failed > 0 {
+				sess.Log().Warn("log records lost",
+					slog.String("source", st.Source),
+					slog.Uint64("dropped", st.Dropped),
+					slog.Uint64("failed", st.Failed))
+			}
+		}
+		return nil
+	})
+	return svc
+}
+
+// start starts writers of sources writing to files in dir.
+func (f *Forwarder) start(dir string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cnf.Dir = dir
+	f.running = true
+	f.stopped = false
+	for _, src := range f.sources {
+		src.open(f.cnf.QueueSize)
+		f.startSource(src)
+	}
+}
+
+// stop closes queues of sources and waits until writers have written
+// queued records.
+func (f *Forwarder) stop() {
+	f.mu.Lock()
+	f.running = false
+	f.stopped = true
+	for _, src := range f.sources {
+		src.close()
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+}
+
+// startSource starts writer of src, caller must hold f.mu.
+func (f *Forwarder) startSource(src *source) {
+	path := filepath.Join(f.cnf.Dir, src.name+".log")
+	src.mu.RLock()
+	queue := src.queue
+	src.mu.RUnlock()
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		file, err := logging.NewRotatingFile(path, f.cnf.MaxSize, f.cnf.MaxBackups)
+		if err != nil {
+			src.out.fail(err)
+		} else {
+			src.out.set(file)
+			defer file.Close()
+		}
+		src.run(queue)
+	}()
+}
+
+type record struct {
+	h slog.Handler
+	r slog.Record
+}
+
+type source struct {
+	name  string
+	out   *output
+	json  slog.Handler
+	mu    sync.RWMutex
+	queue chan record
+	// closed sources drop records, queue is closed once.
+	closed bool
+
+	written atomic.Uint64
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+}
+
+func newSource(name string, size int) *source {
+	out := &output{}
+	return &source{
+		name:  name,
+		out:   out,
+		json:  slog.NewJSONHandler(out, &slog.HandlerOptions{ReplaceAttr: replaceLevel}),
+		queue: make(chan record, size),
+	}
+}
+
+func (src *source) enqueue(h slog.Handler, r slog.Record) {
+	src.mu.RLock()
+	defer src.mu.RUnlock()
+	if src.closed {
+		src.dropped.Add(1)
+		return
+	}
+	select {
+	case src.queue <- record{h: h, r: r.Clone()}:
+	default:
+		src.dropped.Add(1)
+	}
+}
+
+// open makes closed source of stopped forwarder accept records again.
+func (src *source) open(size int) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	if src.closed {
+		src.closed = false
+		src.queue = make(chan record, size)
+	}
+}
+
+func (src *source) close() {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	if !src.closed {
+		src.closed = true
+		close(src.queue)
+	}
+}
+
+// run writes queued records until queue is closed and drained.
+func (src *source) run(queue <-chan record) {
+	for rec := range queue {
+		if err := rec.h.Handle(context.Background(), rec.r); err != nil {
+			src.failed.Add(1)
+			continue
+		}
+		src.written.Add(1)
+	}
+}
+
+// output is writer of source log file, file is opened by writer
+// when forwarder starts.
+type output struct {
+	mu   sync.Mutex
+	file *logging.RotatingFile
+	err  error
+}
+
+func (o *output) set(file *logging.RotatingFile) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.file, o.err = file, nil
+}
+
+func (o *output) fail(err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.file, o.err = nil, err
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.file == nil {
+		if o.err != nil {
+			return 0, o.err
+		}
+		return 0, fmt.Errorf("%w: log file is not open", Error)
+	}
+	return o.file.Write(p)
+}
+
+// handler queues records of source, records are formatted by h when
+// they are written so that attrs and groups of derived handlers apply.
+type handler struct {
+	src *source
+	h   slog.Handler
+}
+
+func (h *handler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *handler) Handle(_ context.Context, r slog.Record) error {
+	h.src.enqueue(h.h, r)
+	return nil
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &handler{src: h.src, h: h.h.WithAttrs(attrs)}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{src: h.src, h: h.h.WithGroup(name)}
+}
+
+func replaceLevel(_ []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey {
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			a.Value = slog.StringValue(logging.Level(level).String())
+		}
+	}
+	return a
+}
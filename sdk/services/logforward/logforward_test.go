@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package logforward
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/sdk/logging"
+)
+
+func readLog(t *testing.T, path string) []map[string]any {
+	t.Helper()
+	f, err := os.Open(path)
+	if !testutils.NoError(t, err) {
+		t.FailNow()
+	}
+	defer f.Close()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := make(map[string]any)
+		testutils.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestForwarder(t *testing.T) {
+	dir := t.TempDir()
+	f := New(Config{QueueSize: 4})
+
+	// records logged before start are queued up to queue size
+	log := slog.New(f.Handler("worker")).With(slog.String("service", "worker"))
+	for i := 0; i < 6; i++ {
+		log.Info("job", slog.Int("n", i))
+	}
+	f.start(dir)
+	api := slog.New(f.Handler("api"))
+	api.WithGroup("req").Log(context.Background(), slog.Level(logging.LevelOk), "served", slog.String("path", "/"))
+	f.stop()
+
+	lines := readLog(t, filepath.Join(dir, "worker.log"))
+	testutils.Equal(t, 4, len(lines))
+	testutils.EqualAny(t, "worker", lines[0]["service"])
+	testutils.EqualAny(t, float64(3), lines[3]["n"])
+
+	lines = readLog(t, filepath.Join(dir, "api.log"))
+	testutils.Equal(t, 1, len(lines))
+	testutils.EqualAny(t, logging.LevelOk.String(), lines[0]["level"])
+	req, _ := lines[0]["req"].(map[string]any)
+	testutils.EqualAny(t, "/", req["path"])
+
+	stats := f.Stats()
+	testutils.Equal(t, 2, len(stats))
+	testutils.Equal(t, Stats{Source: "api", Written: 1}, stats[0])
+	testutils.Equal(t, Stats{Source: "worker", Written: 4, Dropped: 2}, stats[1])
+
+	// stopped forwarder drops records until it is started again
+	log.Info("late")
+	testutils.Equal(t, uint64(3), f.Stats()[1].Dropped)
+	f.start(dir)
+	log.Info("again")
+	f.stop()
+	testutils.Equal(t, 5, len(readLog(t, filepath.Join(dir, "worker.log"))))
+}
+
+func TestFanOut(t *testing.T) {
+	dir := t.TempDir()
+	f := New(Config{})
+	f.start(dir)
+	console := logging.NewTestLogger(logging.LevelWarn)
+	log := slog.New(logging.FanOut(f.Handler("svc"), console.Logger().Handler()))
+	log.Info("info")
+	log.Warn("warn")
+	f.stop()
+	testutils.Equal(t, 2, len(readLog(t, filepath.Join(dir, "svc.log"))))
+	out := console.Output()
+	testutils.False(t, strings.Contains(out, `"msg":"info"`), "console level must apply")
+	testutils.True(t, strings.Contains(out, `"msg":"warn"`))
+}
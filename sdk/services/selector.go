@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+// selector selects registered services, syntax is pattern[?query]
+// where pattern is glob matched against service name, slug and address
+// and query is & separated list of markers:
+//
+//	optional  selector may match nothing and matched services may fail to load
+//	all       empty pattern selects all registered services e.g. "?all"
+type selector struct {
+	raw      string
+	pattern  string
+	optional bool
+	all      bool
+}
+
+func parseSelector(raw string) (selector, error) {
+	sel := selector{raw: raw}
+	pattern, query, _ := strings.Cut(raw, "?")
+	sel.pattern = pattern
+	if query != "" {
+		for _, marker := range strings.Split(query, "&") {
+			switch marker {
+			case "optional":
+				sel.optional = true
+			case "required":
+				sel.optional = false
+			case "all":
+				sel.all = true
+			default:
+				return sel, fmt.Errorf("%w: unknown marker %q in service selector %q", Error, marker, raw)
+			}
+		}
+	}
+	if sel.pattern == "" {
+		if !sel.all {
+			return sel, fmt.Errorf("%w: empty service selector %q", Error, raw)
+		}
+		sel.pattern = "*"
+	}
+	if _, err := path.Match(sel.pattern, ""); err != nil {
+		return sel, fmt.Errorf("%w: invalid service pattern %q", Error, sel.pattern)
+	}
+	return sel, nil
+}
+
+func (sel selector) match(info *service.Info) bool {
+	if sel.all && sel.pattern == "*" {
+		return true
+	}
+	addr := info.Addr().String()
+	if sel.pattern == addr {
+		return true
+	}
+	for _, name := range []string{info.Name(), path.Base(addr)} {
+		if ok, _ := path.Match(sel.pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadStatus is outcome of loading single service.
+type LoadStatus string
+
+const (
+	LoadPending  LoadStatus = "pending"
+	LoadStarted  LoadStatus = "started"
+	LoadRunning  LoadStatus = "already running"
+	LoadFailed   LoadStatus = "failed"
+	LoadTimedOut LoadStatus = "timed out"
+)
+
+// LoadResult is load outcome of single service.
+type LoadResult struct {
+	Service string `json:"service"`
+	// Selector is first selector which matched the service.
+	Selector string     `json:"selector"`
+	Optional bool       `json:"optional"`
+	Status   LoadStatus `json:"status"`
+	Err      string     `json:"error,omitempty"`
+}
+
+// LoadReport is report of service loader.
+type LoadReport struct {
+	Results []LoadResult `json:"results"`
+	// Unmatched are optional selectors which did not match any service.
+	Unmatched []string      `json:"unmatched,omitempty"`
+	Took      time.Duration `json:"took"`
+}
+
+// Failed returns results of services which did not load.
+func (r LoadReport) Failed() []LoadResult {
+	var failed []LoadResult
+	for _, res := range r.Results {
+		if res.Status == LoadFailed || res.Status == LoadTimedOut {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+func (r LoadReport) String() string {
+	table := textfmt.Table{
+		Title:      fmt.Sprintf("Services loaded in %s", r.Took),
+		WithHeader: true,
+	}
+	table.AddRow("SERVICE", "SELECTOR", "REQUIRED", "STATUS", "ERROR")
+	for _, res := range r.Results {
+		table.AddRow(res.Service, res.Selector, fmt.Sprint(!res.Optional), string(res.Status), res.Err)
+	}
+	for _, sel := range r.Unmatched {
+		table.AddRow("-", sel, "false", "no match", "")
+	}
+	return table.String()
+}
+
+// Require loads services matching selectors and waits until they are
+// running, e.g. Require(sess, "db-*", "cache?optional", "?all&optional").
+// Returned loader holds load report and can stop started services.
+func Require(sess *session.Context, selectors ...string) (*ServiceLoader, error) {
+	return RequireContext(sess, sess, selectors...)
+}
+
+// RequireContext is like Require, but loading is canceled when ctx is
+// done. Services started before cancellation are stopped.
+func RequireContext(ctx context.Context, sess *session.Context, selectors ...string) (*ServiceLoader, error) {
+	loader := LazyLoader(sess, selectors...)
+	loader.ctx = ctx
+	<-loader.Load()
+	if err := loader.Err(); err != nil {
+		if ctx.Err() != nil {
+			if serr := loader.Stop(); serr != nil {
+				err = errors.Join(err, serr)
+			}
+		}
+		return loader, err
+	}
+	return loader, nil
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package services_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy"
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+func TestRequireSelectors(t *testing.T) {
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"happy-selectors-test"}
+
+	type result struct {
+		status   services.LoadStatus
+		optional bool
+		err      string
+	}
+	tests := []struct {
+		name      string
+		selectors []string
+		wantErr   string
+		want      map[string]result
+		unmatched []string
+	}{
+		{
+			name:      "optional failures",
+			selectors: []string{"db-*", "cache?optional", "slow?optional", "missing?optional"},
+			want: map[string]result{
+				"db-main": {status: services.LoadStarted},
+				"cache":   {status: services.LoadFailed, optional: true, err: "cache unavailable"},
+				"slow":    {status: services.LoadTimedOut, optional: true, err: "service did not load on time"},
+			},
+			unmatched: []string{"missing?optional"},
+		},
+		{
+			// service is optional only when all selectors matching it are optional.
+			name:      "required and optional",
+			selectors: []string{"db-*?optional", "db-main"},
+			want: map[string]result{
+				"db-main": {status: services.LoadRunning},
+			},
+		},
+		{
+			name:      "required failure",
+			selectors: []string{"queue"},
+			wantErr:   "queue unavailable",
+			want: map[string]result{
+				"queue": {status: services.LoadFailed, err: "queue unavailable"},
+			},
+		},
+		{
+			name:      "required unmatched",
+			selectors: []string{"missing"},
+			wantErr:   `no services match "missing"`,
+		},
+		{
+			name:      "unknown marker",
+			selectors: []string{"db-*?sometimes"},
+			wantErr:   `unknown marker "sometimes"`,
+		},
+	}
+
+	main := app.New(happy.Settings{
+		Slug: "happy-selectors-test",
+		Services: services.Settings{
+			LoaderTimeout: settings.Duration(500 * time.Millisecond),
+		},
+	})
+	main.WithLogger(logging.NewTestLogger(logging.LevelQuiet))
+
+	release := make(chan struct{})
+	failing := func(name string) *services.Service {
+		svc := services.New(service.Config{Name: settings.String(name)})
+		svc.OnStart(func(sess *session.Context) error {
+			return errors.New(name + " unavailable")
+		})
+		return svc
+	}
+	slow := services.New(service.Config{Name: "slow"})
+	slow.OnStart(func(sess *session.Context) error {
+		<-release
+		return nil
+	})
+	main.WithServices(
+		services.New(service.Config{Name: "db-main"}),
+		failing("cache"),
+		failing("queue"),
+		slow,
+	)
+
+	var (
+		reports = make([]services.LoadReport, len(tests))
+		errs    = make([]error, len(tests))
+	)
+	main.Do(func(sess *session.Context, args action.Args) error {
+		defer close(release)
+		for i, tt := range tests {
+			var loader *services.ServiceLoader
+			loader, errs[i] = services.Require(sess, tt.selectors...)
+			reports[i] = loader.Report()
+		}
+		return nil
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr == "" {
+				testutils.NoError(t, errs[i])
+			} else if testutils.Error(t, errs[i]) {
+				testutils.True(t, strings.Contains(errs[i].Error(), tt.wantErr), "error %q must contain %q", errs[i].Error(), tt.wantErr)
+			}
+
+			report := reports[i]
+			testutils.Equal(t, len(tt.want), len(report.Results), "results")
+			var failed int
+			for _, res := range report.Results {
+				name := path.Base(res.Service)
+				want, ok := tt.want[name]
+				if !testutils.True(t, ok, "unexpected result for %s", name) {
+					continue
+				}
+				testutils.Equal(t, want.status, res.Status, name)
+				testutils.Equal(t, want.optional, res.Optional, name+" optional")
+				testutils.True(t, strings.Contains(res.Err, want.err), "%s error %q must contain %q", name, res.Err, want.err)
+				if want.status == services.LoadFailed || want.status == services.LoadTimedOut {
+					failed++
+				}
+			}
+			testutils.Equal(t, failed, len(report.Failed()), "failed results")
+			testutils.Equal(t, strings.Join(tt.unmatched, ","), strings.Join(report.Unmatched, ","), "unmatched")
+		})
+	}
+}
@@ -5,6 +5,8 @@
 package services
 
 import (
+	"log/slog"
+
 	"github.com/happy-sdk/happy/sdk/action"
 	"github.com/happy-sdk/happy/sdk/app/session"
 	"github.com/happy-sdk/happy/sdk/events"
@@ -16,16 +18,21 @@ type Service struct {
 	registerAction action.Action
 	startAction    action.Action
 	stopAction     action.WithPrevErr
-	tickAction     action.Tick
-	tockAction     action.Tock
+	tickAction     action.TickContext
+	tockAction     action.TockContext
 	listeners      map[string][]events.ActionWithEvent[*session.Context]
 
-	cronsetup func(schedule CronScheduler)
-	errs      []error
+	cronsetup  func(schedule CronScheduler)
+	logHandler slog.Handler
+	api        any
+	errs       []error
 }
 
 type CronScheduler interface {
 	Job(name, expr string, cb action.Action)
+	// JobContext schedules job receiving context which is cancelled
+	// when service stops and when job is due to run again.
+	JobContext(name, expr string, cb action.WithContext)
 }
 
 // New cretes new draft service which you can compose
@@ -43,6 +50,19 @@ func New(s service.Config) *Service {
 	return svc
 }
 
+// WithLogHandler sets handler service logs are written to,
+// it takes precedence over service.Config.Logging.File.
+func (s *Service) WithLogHandler(h slog.Handler) {
+	s.logHandler = h
+}
+
+// ProvideAPI sets API object other parts of application can use while
+// the service is running, see API. It can be called when composing the
+// service or from OnRegister and OnStart actions.
+func (s *Service) ProvideAPI(api any) {
+	s.api = api
+}
+
 func (s *Service) Name() string {
 	return s.settings.Name.String()
 }
@@ -74,13 +94,24 @@ func (s *Service) OnStop(action action.WithPrevErr) {
 }
 
 // OnTick when set will be called every application tick when service is in running state.
-func (s *Service) Tick(action action.Tick) {
-	s.tickAction = action
+func (s *Service) Tick(a action.Tick) {
+	s.tickAction = action.ContextTick(a)
 }
 
 // OnTock is called after every tick.
-func (s *Service) Tock(action action.Tock) {
-	s.tockAction = action
+func (s *Service) Tock(a action.Tock) {
+	s.tockAction = action.ContextTock(a)
+}
+
+// TickContext is Tick receiving context which is cancelled when
+// service stops and when tick exceeds app.engine.throttle_ticks.
+func (s *Service) TickContext(a action.TickContext) {
+	s.tickAction = a
+}
+
+// TockContext is Tock receiving context, see TickContext.
+func (s *Service) TockContext(a action.TockContext) {
+	s.tockAction = a
 }
 
 // OnEvent is called when a specific event is received.
@@ -19,15 +19,50 @@ type Info struct {
 	errs      map[time.Time]error
 	startedAt time.Time
 	stoppedAt time.Time
+	instances int
+	api       any
 }
 
 func NewInfo(name string, addr *address.Address) *Info {
 	return &Info{
-		name: name,
-		addr: addr,
+		name:      name,
+		addr:      addr,
+		instances: 1,
 	}
 }
 
+// Instances returns number of parallel service instances.
+func (s *Info) Instances() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.instances
+}
+
+// SetInstances sets number of parallel service instances.
+func SetInstances(s *Info, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n < 1 {
+		n = 1
+	}
+	s.instances = n
+}
+
+// API returns API object provided by the service, nil when service
+// does not provide API or has not been started.
+func (s *Info) API() any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.api
+}
+
+// SetAPI sets API object provided by the service.
+func SetAPI(s *Info, api any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.api = api
+}
+
 func (s *Info) Valid() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
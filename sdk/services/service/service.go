@@ -25,6 +25,71 @@ type Config struct {
 	RetryOnError settings.Bool     `key:",init" default:"false" desc:"Retry the service in case of an error."`
 	MaxRetries   settings.Int      `key:",init" default:"3" desc:"Maximum number of retries on error."`
 	RetryBackoff settings.Duration `key:",init" default:"5s" desc:"Duration to wait before each retry."`
+	// Instances is number of parallel service instances, each instance
+	// gets own child session with service.instance.id option set to
+	// its index. Useful for worker-style services e.g. queue consumers.
+	Instances settings.Int `key:",init" default:"1" desc:"Number of parallel service instances."`
+	// Logging overrides application logging for the service.
+	Logging Logging `key:"logging"`
+	// Process configures external process of service created with services.Exec.
+	Process Process `key:"process"`
+}
+
+// Logging overrides level and destination of service logs, so that
+// noisy background services can write to own files while the main
+// console stays clean. Custom slog.Handler can be set with
+// services.Service.WithLogHandler.
+type Logging struct {
+	// Level of service logger, application level is used when empty.
+	Level settings.String `key:",init" desc:"Service log level, application log level is used when empty."`
+	// File service logs are written to instead of application log.
+	File       settings.String `key:",init" desc:"Path of file service logs are written to."`
+	MaxSize    settings.Int    `key:",init" default:"10485760" desc:"Size in bytes after log file is rotated."`
+	MaxBackups settings.Int    `key:",init" default:"3" desc:"Number of rotated log files to keep."`
+}
+
+func (l Logging) Blueprint() (*settings.Blueprint, error) {
+	return settings.New(l)
+}
+
+// Overrides reports whether any logging override is set.
+func (l Logging) Overrides() bool {
+	return l.Level != "" || l.File != ""
+}
+
+// Restart policies of Process.
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+// Process configures external process supervised by service created
+// with services.Exec.
+type Process struct {
+	// Dir is working directory of the process, application working
+	// directory is used when empty.
+	Dir settings.String `key:",init" desc:"Working directory of the process."`
+	// Env entries "KEY=value" are added to environment of the process.
+	Env settings.StringSlice `key:",init" desc:"Environment variables of the process."`
+	// EnvFrom entries "KEY=setting.key" set variable KEY to value of
+	// session setting or option setting.key when process starts.
+	EnvFrom settings.StringSlice `key:",init" desc:"Environment variables of the process set from settings."`
+	// Restart policy applied when process exits while service is running,
+	// one of never, on-failure or always.
+	Restart      settings.String   `key:",init" default:"on-failure" desc:"Restart policy of the process: never, on-failure or always."`
+	RestartDelay settings.Duration `key:",init" default:"1s" desc:"Duration to wait before process is restarted."`
+	// MaxRestarts limits restarts, negative value allows unlimited restarts.
+	MaxRestarts settings.Int `key:",init" default:"5" desc:"Maximum number of process restarts, negative for unlimited."`
+	// StopTimeout is time process has to exit after SIGTERM before it is killed.
+	StopTimeout settings.Duration `key:",init" default:"10s" desc:"Duration to wait for process to exit before it is killed."`
+	// LogPrefix prefixes process output lines written to service log,
+	// service slug is used when empty.
+	LogPrefix settings.String `key:",init" desc:"Prefix of process output lines in service log."`
+}
+
+func (p Process) Blueprint() (*settings.Blueprint, error) {
+	return settings.New(p)
 }
 
 func (s *Config) Blueprint() (*settings.Blueprint, error) {
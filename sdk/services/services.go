@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/happy-sdk/happy/pkg/scheduling/cron"
@@ -16,8 +17,10 @@ import (
 	"github.com/happy-sdk/happy/pkg/vars"
 	"github.com/happy-sdk/happy/sdk/action"
 	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/datetime"
 	"github.com/happy-sdk/happy/sdk/events"
 	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/logging"
 	"github.com/happy-sdk/happy/sdk/networking/address"
 	"github.com/happy-sdk/happy/sdk/services/service"
 )
@@ -32,6 +35,7 @@ var (
 type Settings struct {
 	LoaderTimeout  settings.Duration `key:"loader_timeout,save" default:"30s" mutation:"once" desc:"Service loader timeout"`
 	RunCronOnStart settings.Bool     `key:"cron_on_service_start,save" default:"false" mutation:"once" desc:"Run cron jobs on service start"`
+	KeepRequired   settings.Bool     `key:"keep_required,save" default:"false" mutation:"once" desc:"Keep services required by command running after command Do action"`
 }
 
 func (s Settings) Blueprint() (*settings.Blueprint, error) {
@@ -54,12 +58,17 @@ type Info interface {
 }
 
 type ServiceLoader struct {
-	loading  bool
-	loaderCh chan struct{}
-	errs     []error
-	sess     *session.Context
-	hostaddr *address.Address
-	svcs     []*address.Address
+	loading   bool
+	loaderCh  chan struct{}
+	errs      []error
+	sess      *session.Context
+	ctx       context.Context
+	hostaddr  *address.Address
+	svcs      []*address.Address
+	selectors []selector
+	started   []*service.Info
+	results   map[string]*LoadResult
+	report    LoadReport
 }
 
 // NewServiceLoader creates new service loader which can be used to load services.
@@ -67,6 +76,7 @@ func NewLoader(sess *session.Context, svcs ...string) *ServiceLoader {
 	loader := &ServiceLoader{
 		sess:     sess,
 		loaderCh: make(chan struct{}),
+		results:  make(map[string]*LoadResult),
 	}
 	hostaddr, err := address.Parse(sess.Get("app.address").String())
 	if err != nil {
@@ -84,19 +94,98 @@ func NewLoader(sess *session.Context, svcs ...string) *ServiceLoader {
 		if err != nil {
 			loader.addErr(err)
 		} else {
-			loader.svcs = append(loader.svcs, svcaddr)
+			loader.add(svcaddr, addr, false)
 		}
 	}
 
 	return loader
 }
 
+// LazyLoader creates service loader which loads services matching any
+// of given selectors. Selector is glob pattern matched against service
+// name, slug or address e.g. "db-*", optionally followed by query
+// markers: "cache?optional" may match nothing and its services may fail
+// to load, "?all" selects all registered services. Selectors are
+// resolved against registered services when Load is called.
+func LazyLoader(sess *session.Context, selectors ...string) *ServiceLoader {
+	loader := NewLoader(sess)
+	for _, raw := range selectors {
+		sel, err := parseSelector(raw)
+		if err != nil {
+			loader.addErr(err)
+			continue
+		}
+		loader.selectors = append(loader.selectors, sel)
+	}
+	return loader
+}
+
+// Report returns load report, it is complete after Load finished.
+func (sl *ServiceLoader) Report() LoadReport {
+	return sl.report
+}
+
+// add adds service to load, service is optional only when all
+// selectors which matched it are optional.
+func (sl *ServiceLoader) add(addr *address.Address, selector string, optional bool) {
+	key := addr.String()
+	if res, ok := sl.results[key]; ok {
+		res.Optional = res.Optional && optional
+		return
+	}
+	sl.svcs = append(sl.svcs, addr)
+	sl.results[key] = &LoadResult{
+		Service:  key,
+		Selector: selector,
+		Optional: optional,
+		Status:   LoadPending,
+	}
+}
+
+// resolveSelectors adds services matching loader selectors.
+func (sl *ServiceLoader) resolveSelectors() error {
+	for _, sel := range sl.selectors {
+		var matched bool
+		for _, info := range sl.sess.Services() {
+			if !sel.match(info) {
+				continue
+			}
+			matched = true
+			sl.add(info.Addr(), sel.raw, sel.optional)
+		}
+		if matched {
+			continue
+		}
+		if !sel.optional {
+			return fmt.Errorf("%w: no services match %q", Error, sel.raw)
+		}
+		sl.report.Unmatched = append(sl.report.Unmatched, sel.raw)
+	}
+	return nil
+}
+
+// finish records load report.
+func (sl *ServiceLoader) finish(started time.Time) {
+	sl.report.Took = time.Since(started)
+	sl.report.Results = sl.report.Results[:0]
+	for _, addr := range sl.svcs {
+		if res, ok := sl.results[addr.String()]; ok {
+			sl.report.Results = append(sl.report.Results, *res)
+		}
+	}
+}
+
 func (sl *ServiceLoader) Load() <-chan struct{} {
 	if sl.loading {
 		return sl.loaderCh
 	}
 	sl.loading = true
+	startedAt := time.Now()
+	if err := sl.resolveSelectors(); err != nil {
+		sl.addErr(err)
+	}
 	if len(sl.errs) > 0 {
+		sl.finish(startedAt)
 		sl.cancel(fmt.Errorf(
 			"%w: loader initializeton failed",
 			Error,
@@ -119,12 +208,19 @@ func (sl *ServiceLoader) Load() <-chan struct{} {
 
 	for _, svcaddr := range sl.svcs {
 		svcaddrstr := svcaddr.String()
+		res := sl.results[svcaddrstr]
 		info, err := sl.sess.ServiceInfo(svcaddrstr)
 		if err != nil {
+			res.Status, res.Err = LoadFailed, err.Error()
+			if res.Optional {
+				continue
+			}
+			sl.finish(startedAt)
 			sl.cancel(err)
 			return sl.loaderCh
 		}
 		if _, ok := queue[svcaddrstr]; ok {
+			sl.finish(startedAt)
 			sl.cancel(fmt.Errorf(
 				"%w: duplicated service request %s",
 				Error,
@@ -133,62 +229,88 @@ func (sl *ServiceLoader) Load() <-chan struct{} {
 			return sl.loaderCh
 		}
 		if info.Running() {
-			sl.sess.Log().NotImplemented(
-				"requested service is already running",
-				slog.String("service", svcaddrstr),
-			)
+			internal.Log(sl.sess.Log(), "requested service is already running", slog.String("service", svcaddrstr))
+			res.Status = LoadRunning
 			continue
 		}
 		internal.Log(sl.sess.Log(), "requesting service", slog.String("service", svcaddrstr))
 		queue[svcaddrstr] = info
 		require = append(require, svcaddrstr)
+		sl.started = append(sl.started, info)
 	}
 
 	sl.sess.Dispatch(startEvent(require...))
 
-	ctx, cancel := context.WithTimeout(sl.sess, timeout)
+	parent := context.Context(sl.sess)
+	if sl.ctx != nil {
+		parent = sl.ctx
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
 
 	go func() {
 		defer cancel()
 		ltick := time.NewTicker(time.Millisecond * 100)
 		defer ltick.Stop()
-		qlen := len(queue)
 
 	loader:
 		for {
 			select {
 			case <-ctx.Done():
 				sl.sess.Log().Warn("loader context done")
-				for _, status := range queue {
-					if !status.Running() {
-						sl.addErr(fmt.Errorf("service did not load on time %s", status.Addr().String()))
+				var failed bool
+				for addr, status := range queue {
+					if status.Running() {
+						sl.results[addr].Status = LoadStarted
+						continue
+					}
+					res := sl.results[addr]
+					if errors.Is(ctx.Err(), context.Canceled) {
+						res.Status, res.Err = LoadFailed, "service loading canceled"
+					} else {
+						res.Status, res.Err = LoadTimedOut, "service did not load on time"
 					}
+					if !res.Optional {
+						failed = true
+						sl.addErr(fmt.Errorf("%s %s", res.Err, addr))
+					}
+				}
+				sl.finish(startedAt)
+				if failed {
+					sl.cancel(ctx.Err())
+					return
 				}
-				sl.cancel(ctx.Err())
+				sl.done()
 				return
 			case <-ltick.C:
-				var loaded int
-				for _, status := range queue {
+				for addr, status := range queue {
+					res := sl.results[addr]
 					if errs := status.Errs(); errs != nil {
+						var msgs []error
 						for _, err := range errs {
-							sl.addErr(err)
+							msgs = append(msgs, err)
 						}
-						var addr string
-						if status.Addr() != nil {
-							addr = status.Addr().String()
+						res.Status, res.Err = LoadFailed, errors.Join(msgs...).Error()
+						delete(queue, addr)
+						if res.Optional {
+							sl.sess.Log().Warn("optional service failed to load", slog.String("service", addr), slog.String("err", res.Err))
+							continue
 						}
+						sl.errs = append(sl.errs, msgs...)
+						sl.finish(startedAt)
 						sl.cancel(fmt.Errorf("%w: service loader failed to load required services %s, %s", Error, addr, errors.Join(sl.errs...)))
 						return
 					}
 					if status.Running() {
-						loaded++
+						res.Status = LoadStarted
+						delete(queue, addr)
 					}
 				}
-				if loaded == qlen {
+				if len(queue) == 0 {
 					break loader
 				}
 			}
 		}
+		sl.finish(startedAt)
 		sl.done()
 	}()
 
@@ -196,6 +318,14 @@ func (sl *ServiceLoader) Load() <-chan struct{} {
 }
 
 func startEvent(svcs ...string) events.Event {
+	return StartEvent.Create(fmt.Sprintf("requested services (%d)", len(svcs)), servicesPayload(svcs))
+}
+
+func stopEvent(svcs ...string) events.Event {
+	return StopEvent.Create(fmt.Sprintf("stop services (%d)", len(svcs)), servicesPayload(svcs))
+}
+
+func servicesPayload(svcs []string) *vars.Map {
 	payload := new(vars.Map)
 	var errs []error
 	for i, url := range svcs {
@@ -206,8 +336,72 @@ func startEvent(svcs ...string) events.Event {
 	if len(errs) > 0 {
 		_ = payload.Store("err", errors.Join(errs...).Error())
 	}
+	return payload
+}
+
+// Stop stops services started by the loader and waits
+// until they are stopped or loader timeout is reached.
+func (sl *ServiceLoader) Stop() error {
+	if sl.loading {
+		return fmt.Errorf("%w: can not stop services while loading", Error)
+	}
+	if len(sl.started) == 0 {
+		return nil
+	}
+	var stop []string
+	for _, info := range sl.started {
+		if info.Running() {
+			stop = append(stop, info.Addr().String())
+		}
+	}
+	sl.started = nil
+	return stopAndWait(sl.sess, stop)
+}
+
+// Stop stops running services looked up by name, slug or address and
+// waits until they are stopped or loader timeout is reached. Services
+// which are not running are skipped.
+func Stop(sess *session.Context, names ...string) error {
+	var stop []string
+	for _, name := range names {
+		info, err := lookup(sess, name)
+		if err != nil {
+			return err
+		}
+		if info.Running() {
+			stop = append(stop, info.Addr().String())
+		}
+	}
+	return stopAndWait(sess, stop)
+}
 
-	return StartEvent.Create(fmt.Sprintf("requested services (%d)", len(svcs)), payload)
+func stopAndWait(sess *session.Context, stop []string) error {
+	if len(stop) == 0 {
+		return nil
+	}
+	sess.Dispatch(stopEvent(stop...))
+
+	timeout := sess.Get("app.services.loader_timeout").Duration()
+	ctx, cancel := context.WithTimeout(sess, timeout)
+	defer cancel()
+	ltick := time.NewTicker(time.Millisecond * 100)
+	defer ltick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: services did not stop on time", Error)
+		case <-ltick.C:
+			running := false
+			for _, addr := range stop {
+				if info, err := sess.ServiceInfo(addr); err == nil && info.Running() {
+					running = true
+				}
+			}
+			if !running {
+				return nil
+			}
+		}
+	}
 }
 
 func (sl *ServiceLoader) Err() error {
@@ -241,43 +435,100 @@ func (sl *ServiceLoader) addErr(err error) {
 type serviceCron struct {
 	sess     *session.Context
 	lib      *cron.Cron
+	parser   cron.Parser
+	clock    datetime.Clock
 	jobIDs   []cron.EntryID
 	jobInfos map[cron.EntryID]cronInfo
+
+	// ctx is parent of job contexts, it is cancelled when cron stops.
+	mu     sync.RWMutex
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 type cronInfo struct {
 	Name string
 	Expr string
 }
 
-func newCron(sess *session.Context) *serviceCron {
+func newCron(sess *session.Context, clock datetime.Clock) *serviceCron {
 	c := &serviceCron{
 		jobInfos: make(map[cron.EntryID]cronInfo),
+		parser: cron.NewParser(
+			cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+		),
+		clock: clock,
 	}
 	c.sess = sess
-	c.lib = cron.New(cron.WithParser(cron.NewParser(
-		cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
-	)))
+	c.ctx, c.cancel = context.WithCancel(sess)
+	opts := []cron.Option{
+		cron.WithParser(c.parser),
+	}
+	if clock != nil {
+		opts = append(opts, cron.WithClock(cronClock{clock}))
+	} else {
+		c.clock = sess.Time()
+	}
+	if lc, ok := clock.(*datetime.LocalClock); ok {
+		opts = append(opts, cron.WithLocation(lc.Location()))
+	}
+	c.lib = cron.New(opts...)
 	return c
 }
 
+// cronClock adapts datetime.Clock to cron.Clock.
+type cronClock struct {
+	datetime.Clock
+}
+
+func (c cronClock) NewTimer(d time.Duration) cron.Timer {
+	return c.Clock.NewTimer(d)
+}
+
 func (cs *serviceCron) Job(name, expr string, cb action.Action) {
-	id, err := cs.lib.AddFunc(expr, func() {
-		if err := cb(cs.sess); err != nil {
-			cs.sess.Log().Error(fmt.Sprintf("%s:%s:%s", Error, cron.Error, err))
-		}
-	})
-	cs.jobIDs = append(cs.jobIDs, id)
-	cs.jobInfos[id] = cronInfo{name, expr}
+	cs.JobContext(name, expr, action.ContextAction(cb))
+}
+
+func (cs *serviceCron) JobContext(name, expr string, cb action.WithContext) {
+	sched, err := cs.parser.Parse(expr)
 	if err != nil {
 		cs.sess.Log().Error(fmt.Sprintf(
 			"%s:%s: failed to add job",
 			Error,
-			cron.Error), slog.Int("id", int(id)), slog.String("name", name), slog.String("expr", expr), slog.String("err", err.Error()))
+			cron.Error), slog.String("name", name), slog.String("expr", expr), slog.String("err", err.Error()))
 		return
 	}
+	id := cs.lib.Schedule(sched, cron.FuncJob(func() {
+		ctx, cancel := cs.jobContext(sched)
+		defer cancel()
+		if err := action.Try(func() error { return cb(ctx, cs.sess) }); err != nil {
+			cs.sess.Log().Error(fmt.Sprintf("%s:%s:%s", Error, cron.Error, err), slog.String("job", name))
+			logPanicStack(cs.sess, err)
+		}
+	}))
+	cs.jobIDs = append(cs.jobIDs, id)
+	cs.jobInfos[id] = cronInfo{name, expr}
+}
+
+// jobContext returns context of job run, job should complete before
+// it is due to run again.
+func (cs *serviceCron) jobContext(sched cron.Schedule) (context.Context, context.CancelFunc) {
+	cs.mu.RLock()
+	parent := cs.ctx
+	cs.mu.RUnlock()
+	now := cs.clock.Now()
+	if next := sched.Next(now); !next.IsZero() {
+		return context.WithTimeout(parent, next.Sub(now))
+	}
+	return context.WithCancel(parent)
 }
 
 func (cs *serviceCron) Start() error {
+	cs.mu.Lock()
+	if cs.ctx.Err() != nil {
+		// restarted service
+		cs.ctx, cs.cancel = context.WithCancel(cs.sess)
+	}
+	cs.mu.Unlock()
 	if cs.sess.Get("app.services.cron_on_service_start").Bool() {
 		for _, id := range cs.jobIDs {
 			info, ok := cs.jobInfos[id]
@@ -297,7 +548,19 @@ func (cs *serviceCron) Start() error {
 }
 
 func (cs *serviceCron) Stop() error {
+	cs.mu.RLock()
+	cs.cancel()
+	cs.mu.RUnlock()
 	ctx := cs.lib.Stop()
 	<-ctx.Done()
 	return nil
 }
+
+// logPanicStack logs stack trace when err is recovered panic.
+func logPanicStack(sess *session.Context, err error) {
+	var perr *action.PanicError
+	if errors.As(err, &perr) {
+		sess.Log().LogDepth(2, logging.LevelBUG, perr.Error())
+		sess.Log().LogDepth(2, logging.LevelAlways, string(perr.Stack))
+	}
+}
@@ -0,0 +1,376 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package watcher provides file watcher service. Files matching declared
+// glob patterns are polled and debounced changes are delivered to
+// callbacks and optionally dispatched as ChangedEvent.
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/vars"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/datetime"
+	"github.com/happy-sdk/happy/sdk/events"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+var (
+	Error = errors.New("watcher")
+	// ChangedEvent is dispatched with number of changes as value and
+	// change.<n>.path and change.<n>.op payload when Config.Events is true.
+	ChangedEvent = events.New("watcher", "changed")
+)
+
+// Op is type of file change.
+type Op uint8
+
+const (
+	Create Op = iota + 1
+	Write
+	Remove
+)
+
+func (op Op) String() string {
+	switch op {
+	case Create:
+		return "create"
+	case Write:
+		return "write"
+	case Remove:
+		return "remove"
+	}
+	return "unknown"
+}
+
+// Change is single file change.
+type Change struct {
+	Path string
+	Op   Op
+}
+
+type Config struct {
+	// Name of the watcher service.
+	Name string
+	// Root directory watched, defaults to current working directory.
+	Root string
+	// Patterns are slash separated glob patterns relative to Root,
+	// ** matches any number of directories.
+	Patterns []string
+	// Ignore patterns, matching files and directories are skipped.
+	Ignore []string
+	// Interval between polls, defaults to 500ms.
+	Interval time.Duration
+	// Debounce is time without changes before changes are delivered,
+	// defaults to 200ms.
+	Debounce time.Duration
+	// Events enables dispatching of ChangedEvent.
+	Events bool
+}
+
+// Callback is called with debounced changes.
+type Callback func(sess *session.Context, changes []Change) error
+
+// Watcher watches files matching patterns.
+type Watcher struct {
+	mu        sync.Mutex
+	cnf       Config
+	callbacks []Callback
+	files     map[string]fileState
+	pending   map[string]Op
+	changedAt time.Time
+	stop      chan struct{}
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// New returns new Watcher.
+func New(cnf Config) *Watcher {
+	if cnf.Name == "" {
+		cnf.Name = "file-watcher"
+	}
+	if cnf.Interval <= 0 {
+		cnf.Interval = 500 * time.Millisecond
+	}
+	if cnf.Debounce <= 0 {
+		cnf.Debounce = 200 * time.Millisecond
+	}
+	return &Watcher{
+		cnf:     cnf,
+		pending: make(map[string]Op),
+	}
+}
+
+// OnChange adds callback called with debounced changes.
+func (w *Watcher) OnChange(cb Callback) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callbacks = append(w.callbacks, cb)
+}
+
+// AsService returns service which polls files while it is running.
+func (w *Watcher) AsService() *services.Service {
+	svc := services.New(service.Config{
+		Name:        settings.String(w.cnf.Name),
+		Description: "Watches files for changes",
+	})
+
+	svc.OnStart(func(sess *session.Context) error {
+		if w.cnf.Root == "" {
+			w.cnf.Root = sess.Get("app.fs.path.wd").String()
+		}
+		if len(w.cnf.Patterns) == 0 {
+			return fmt.Errorf("%w: %s has no patterns", Error, w.cnf.Name)
+		}
+		files, err := w.scan()
+		if err != nil {
+			return err
+		}
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.files = files
+		internal.Log(sess.Log(), "watching files",
+			slog.String("root", w.cnf.Root),
+			slog.Int("files", len(files)))
+		w.stop = make(chan struct{})
+		go w.run(sess, sess.Time().Clock(), w.stop)
+		return nil
+	})
+
+	svc.OnStop(func(sess *session.Context, e error) error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.stop != nil {
+			close(w.stop)
+			w.stop = nil
+		}
+		return nil
+	})
+	return svc
+}
+
+func (w *Watcher) run(sess *session.Context, clock datetime.Clock, stop <-chan struct{}) {
+	ticker := clock.NewTicker(w.cnf.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-sess.Done():
+			return
+		case now := <-ticker.C():
+			if err := w.poll(now); err != nil {
+				internal.Log(sess.Log(), "watcher poll failed", slog.String("err", err.Error()))
+				continue
+			}
+			if changes := w.debounced(now); len(changes) > 0 {
+				w.deliver(sess, changes)
+			}
+		}
+	}
+}
+
+// Poll scans files once and records changes, it is useful with
+// Flush in tests and tools which drive watcher manually.
+func (w *Watcher) Poll(now time.Time) error {
+	return w.poll(now)
+}
+
+// Reset scans files and uses result as baseline for following polls,
+// pending changes are discarded.
+func (w *Watcher) Reset() error {
+	files, err := w.scan()
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files = files
+	w.pending = make(map[string]Op)
+	return nil
+}
+
+// Debounced returns pending changes when no new changes were
+// recorded within debounce duration before now.
+func (w *Watcher) Debounced(now time.Time) []Change {
+	return w.debounced(now)
+}
+
+// Flush returns pending changes regardless of debounce.
+func (w *Watcher) Flush() []Change {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.takePending()
+}
+
+func (w *Watcher) poll(now time.Time) error {
+	files, err := w.scan()
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	changed := false
+	for p, st := range files {
+		prev, ok := w.files[p]
+		switch {
+		case !ok:
+			w.pending[p] = Create
+			changed = true
+		case !prev.modTime.Equal(st.modTime) || prev.size != st.size:
+			if w.pending[p] != Create {
+				w.pending[p] = Write
+			}
+			changed = true
+		}
+	}
+	for p := range w.files {
+		if _, ok := files[p]; !ok {
+			if w.pending[p] == Create {
+				delete(w.pending, p)
+			} else {
+				w.pending[p] = Remove
+			}
+			changed = true
+		}
+	}
+	w.files = files
+	if changed {
+		w.changedAt = now
+	}
+	return nil
+}
+
+func (w *Watcher) debounced(now time.Time) []Change {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) == 0 || now.Sub(w.changedAt) < w.cnf.Debounce {
+		return nil
+	}
+	return w.takePending()
+}
+
+func (w *Watcher) takePending() []Change {
+	changes := make([]Change, 0, len(w.pending))
+	for p, op := range w.pending {
+		changes = append(changes, Change{Path: p, Op: op})
+	}
+	w.pending = make(map[string]Op)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func (w *Watcher) deliver(sess *session.Context, changes []Change) {
+	w.mu.Lock()
+	callbacks := w.callbacks
+	w.mu.Unlock()
+
+	internal.Log(sess.Log(), "files changed", slog.Int("changes", len(changes)))
+	for _, cb := range callbacks {
+		if err := cb(sess, changes); err != nil {
+			sess.Log().Error("watcher callback failed", slog.String("watcher", w.cnf.Name), slog.String("err", err.Error()))
+		}
+	}
+
+	if !w.cnf.Events {
+		return
+	}
+	payload := new(vars.Map)
+	for i, c := range changes {
+		_ = payload.Store(fmt.Sprintf("change.%d.path", i), c.Path)
+		_ = payload.Store(fmt.Sprintf("change.%d.op", i), c.Op.String())
+	}
+	sess.Dispatch(ChangedEvent.Create(len(changes), payload))
+}
+
+func (w *Watcher) scan() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.WalkDir(w.cnf.Root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == w.cnf.Root {
+				return err
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(w.cnf.Root, p)
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if matchAny(w.cnf.Ignore, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !matchAny(w.cnf.Patterns, rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[p] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return files, nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether slash separated name matches glob pattern.
+// In addition to path.Match syntax ** matches zero or more directories.
+func Match(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
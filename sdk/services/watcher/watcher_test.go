@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/app/main.go", true},
+		{"cmd/**", "cmd/app/main.go", true},
+		{"docs/**/*.md", "docs/README.md", true},
+		{"docs/**/*.md", "docs/a/b/c.md", true},
+		{"docs/**/*.md", "src/a.md", false},
+		{".git", ".git", true},
+	}
+	for _, tt := range tests {
+		testutils.Equal(t, tt.want, Match(tt.pattern, tt.name), tt.pattern+" "+tt.name)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	root := t.TempDir()
+	w := New(Config{
+		Root:     root,
+		Patterns: []string{"**/*.txt"},
+		Ignore:   []string{"skip"},
+	})
+	files, err := w.scan()
+	testutils.NoError(t, err)
+	w.files = files
+
+	testutils.NoError(t, os.MkdirAll(filepath.Join(root, "skip"), 0700))
+	testutils.NoError(t, os.WriteFile(filepath.Join(root, "skip", "a.txt"), []byte("a"), 0600))
+	testutils.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0600))
+	testutils.NoError(t, os.WriteFile(filepath.Join(root, "a.md"), []byte("a"), 0600))
+
+	now := time.Now()
+	testutils.NoError(t, w.Poll(now))
+	testutils.Equal(t, 0, len(w.debounced(now)), "changes are debounced")
+
+	changes := w.debounced(now.Add(time.Second))
+	testutils.Equal(t, 1, len(changes), "changes after debounce")
+	testutils.Equal(t, Create, changes[0].Op, "create op")
+
+	testutils.NoError(t, os.Remove(filepath.Join(root, "a.txt")))
+	testutils.NoError(t, w.Poll(now))
+	changes = w.Flush()
+	testutils.Equal(t, 1, len(changes), "remove change")
+	testutils.Equal(t, Remove, changes[0].Op, "remove op")
+}
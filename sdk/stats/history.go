@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package stats
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/metrics"
+	"strings"
+	"time"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+var Error = errors.New("stats")
+
+const cpuMetric = "/cpu/classes/total:cpu-seconds"
+
+// Snapshot is point in time sample of runtime statistics
+// persisted to stats history.
+type Snapshot struct {
+	Time time.Time `json:"time"`
+	// CPU is process CPU usage in percent of all available CPUs
+	// since previous snapshot.
+	CPU        float64 `json:"cpu"`
+	Memory     uint64  `json:"memory"`
+	Goroutines int     `json:"goroutines"`
+	// Services are per service counters keyed by service address.
+	Services map[string]ServiceCounters `json:"services,omitempty"`
+	// Series are summaries of profiler series since previous snapshot
+	// keyed by series name.
+	Series map[string]Summary `json:"series,omitempty"`
+}
+
+type ServiceCounters struct {
+	Running   bool `json:"running"`
+	Instances int  `json:"instances"`
+	Errors    int  `json:"errors"`
+}
+
+// Snapshot samples current runtime statistics and counters
+// of services attached to session.
+func (r *Profiler) Snapshot(sess *session.Context) Snapshot {
+	r.mu.Lock()
+	now := r.clock.Now().In(r.tsloc)
+	since := r.snapshotAt
+	r.snapshotAt = now
+	samples := []metrics.Sample{{Name: cpuMetric}}
+	metrics.Read(samples)
+	var cpu float64
+	if samples[0].Value.Kind() == metrics.KindFloat64 {
+		cpuSeconds := samples[0].Value.Float64()
+		if !r.cpu.at.IsZero() {
+			if wall := now.Sub(r.cpu.at).Seconds(); wall > 0 {
+				cpu = (cpuSeconds - r.cpu.seconds) / wall / float64(runtime.NumCPU()) * 100
+			}
+		}
+		r.cpu.seconds, r.cpu.at = cpuSeconds, now
+	}
+	r.mu.Unlock()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	snap := Snapshot{
+		Time:       now,
+		CPU:        math.Max(0, math.Round(cpu*100)/100),
+		Memory:     mem.HeapAlloc,
+		Goroutines: runtime.NumGoroutine(),
+	}
+	for _, info := range sess.Services() {
+		if snap.Services == nil {
+			snap.Services = make(map[string]ServiceCounters)
+		}
+		snap.Services[info.Addr().String()] = ServiceCounters{
+			Running:   info.Running(),
+			Instances: info.Instances(),
+			Errors:    len(info.Errs()),
+		}
+	}
+	for _, s := range r.AllSeries() {
+		sum := s.Summary(since)
+		if sum.Count == 0 {
+			continue
+		}
+		if snap.Series == nil {
+			snap.Series = make(map[string]Summary)
+		}
+		snap.Series[s.Name()] = sum
+	}
+	return snap
+}
+
+// HistoryFile returns path of stats history file in application state directory.
+func HistoryFile(sess *session.Context) string {
+	return filepath.Join(sess.Get("app.fs.path.state").String(), "stats", "history.jsonl")
+}
+
+// AppendHistory appends snapshot to history file at path. History is
+// kept as ring buffer, when it holds more than size snapshots oldest
+// ones are dropped.
+func AppendHistory(path string, size int, snap Snapshot) error {
+	if size <= 0 {
+		return fmt.Errorf("%w: history size must be positive", Error)
+	}
+	history, err := ReadHistory(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	history = append(history, snap)
+	if len(history) > size {
+		history = history[len(history)-size:]
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, s := range history {
+		if err := enc.Encode(s); err != nil {
+			return fmt.Errorf("%w: %s", Error, err.Error())
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return nil
+}
+
+// ReadHistory reads snapshots from history file at path, oldest first.
+func ReadHistory(path string) ([]Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var history []Snapshot
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var s Snapshot
+		if err := json.Unmarshal(line, &s); err != nil {
+			// skip partially written or corrupted snapshot
+			continue
+		}
+		history = append(history, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return history, nil
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as single line of block characters
+// scaled between min and max of values.
+func Sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(sparks)-1))
+		}
+		b.WriteRune(sparks[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package stats
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultSeriesSize is number of samples kept by series created with
+// size zero or less, e.g. 5 minutes of samples pushed every second.
+const DefaultSeriesSize = 300
+
+// Sample is value of series at point in time.
+type Sample struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Summary summarizes samples of series.
+type Summary struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+	Last  float64 `json:"last"`
+}
+
+// Series is time-series ring buffer of fixed size, when it is full
+// pushing sample overwrites the oldest one. It is meant for Tick
+// handlers sampling values such as frame rate or queue depth which
+// commands and TUI render as sparklines.
+type Series struct {
+	mu      sync.RWMutex
+	name    string
+	samples []Sample
+	// head is index next sample is written to.
+	head int
+	n    int
+}
+
+// NewSeries returns series holding up to size samples.
+func NewSeries(name string, size int) *Series {
+	if size <= 0 {
+		size = DefaultSeriesSize
+	}
+	return &Series{
+		name:    name,
+		samples: make([]Sample, size),
+	}
+}
+
+// Name returns name of the series.
+func (s *Series) Name() string {
+	return s.name
+}
+
+// Cap returns maximum number of samples series holds.
+func (s *Series) Cap() int {
+	return len(s.samples)
+}
+
+// Len returns number of samples in series.
+func (s *Series) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.n
+}
+
+// Push adds sample with value v taken at ts.
+func (s *Series) Push(ts time.Time, v float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[s.head] = Sample{Time: ts, Value: v}
+	s.head = (s.head + 1) % len(s.samples)
+	if s.n < len(s.samples) {
+		s.n++
+	}
+}
+
+// Last returns the most recent sample.
+func (s *Series) Last() (Sample, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.n == 0 {
+		return Sample{}, false
+	}
+	return s.samples[(s.head-1+len(s.samples))%len(s.samples)], true
+}
+
+// Samples returns all samples oldest first.
+func (s *Series) Samples() []Sample {
+	return s.Since(time.Time{})
+}
+
+// Since returns samples taken at or after t oldest first, e.g.
+// Since(now.Add(-10*time.Second)) returns samples of last 10 seconds.
+func (s *Series) Since(t time.Time) []Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Sample, 0, s.n)
+	start := (s.head - s.n + len(s.samples)) % len(s.samples)
+	for i := 0; i < s.n; i++ {
+		sample := s.samples[(start+i)%len(s.samples)]
+		if !sample.Time.Before(t) {
+			out = append(out, sample)
+		}
+	}
+	return out
+}
+
+// Values returns values of samples taken at or after t oldest first,
+// suitable for Sparkline.
+func (s *Series) Values(t time.Time) []float64 {
+	samples := s.Since(t)
+	values := make([]float64, len(samples))
+	for i, sample := range samples {
+		values[i] = sample.Value
+	}
+	return values
+}
+
+// Summary summarizes samples taken at or after t.
+func (s *Series) Summary(t time.Time) Summary {
+	values := s.Values(t)
+	if len(values) == 0 {
+		return Summary{}
+	}
+	sum := Summary{
+		Count: len(values),
+		Min:   math.Inf(1),
+		Max:   math.Inf(-1),
+		Last:  values[len(values)-1],
+	}
+	var total float64
+	for _, v := range values {
+		sum.Min, sum.Max = math.Min(sum.Min, v), math.Max(sum.Max, v)
+		total += v
+	}
+	sum.Mean = total / float64(len(values))
+	return sum
+}
+
+// Series returns series of profiler with given name, it is created
+// with size when it does not exist. Values of series are included in
+// stats state and history snapshots.
+func (r *Profiler) Series(name string, size int) *Series {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.series[name]; ok {
+		return s
+	}
+	if r.series == nil {
+		r.series = make(map[string]*Series)
+	}
+	s := NewSeries(name, size)
+	r.series[name] = s
+	return s
+}
+
+// AllSeries returns series of profiler sorted by name.
+func (r *Profiler) AllSeries() []*Series {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*Series, 0, len(r.series))
+	for _, s := range r.series {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	return list
+}
@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+)
+
+func TestSeries(t *testing.T) {
+	s := NewSeries("queue.depth", 4)
+	_, ok := s.Last()
+	testutils.False(t, ok)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		s.Push(start.Add(time.Duration(i)*time.Second), float64(i))
+	}
+	// oldest samples are overwritten
+	testutils.Equal(t, 4, s.Len())
+	testutils.EqualAny(t, []float64{2, 3, 4, 5}, s.Values(time.Time{}))
+	testutils.EqualAny(t, []float64{4, 5}, s.Values(start.Add(4*time.Second)))
+	last, ok := s.Last()
+	testutils.True(t, ok)
+	testutils.Equal(t, 5.0, last.Value)
+
+	testutils.Equal(t, Summary{Count: 4, Min: 2, Max: 5, Mean: 3.5, Last: 5}, s.Summary(time.Time{}))
+	testutils.Equal(t, Summary{}, s.Summary(start.Add(time.Minute)))
+
+	prof := New("test")
+	testutils.True(t, prof.Series("fps", 0) == prof.Series("fps", 10), "series must be reused")
+	testutils.Equal(t, DefaultSeriesSize, prof.Series("fps", 0).Cap())
+	prof.Series("fps", 0).Push(start, 60)
+	prof.Update()
+	testutils.Equal(t, "60", prof.Get("series.fps").String())
+}
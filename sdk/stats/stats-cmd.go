@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/strings/humanize"
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/pkg/vars/varflag"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+)
+
+// Command returns stats command for inspecting persisted stats history.
+func Command() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "stats",
+		Category:         "Diagnostics",
+		Description:      "Inspect runtime statistics history",
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.AddInfo("Snapshots are recorded while app.stats.enabled is true, every app.stats.history_interval.")
+
+	cmd.WithSubCommands(
+		topCommand(),
+		historyCommand(),
+	)
+	return cmd
+}
+
+func topCommand() *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "top",
+		Description: "Show latest statistics with trend over recent snapshots",
+	})
+	cmd.WithFlags(
+		varflag.UintFunc("samples", 60, "number of recent snapshots trend is rendered from"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		history, err := loadHistory(sess, int(args.Flag("samples").Var().Uint()))
+		if err != nil {
+			return err
+		}
+		last := history[len(history)-1]
+
+		var cpu, mem, goroutines []float64
+		for _, s := range history {
+			cpu = append(cpu, s.CPU)
+			mem = append(mem, float64(s.Memory))
+			goroutines = append(goroutines, float64(s.Goroutines))
+		}
+		table := textfmt.Table{
+			Title:      fmt.Sprintf("Stats @ %s", last.Time.Format(time.RFC3339)),
+			WithHeader: true,
+		}
+		table.AddRow("METRIC", "CURRENT", "MIN", "MAX", "TREND")
+		lo, hi := bounds(cpu)
+		table.AddRow("cpu", percent(last.CPU), percent(lo), percent(hi), Sparkline(cpu))
+		lo, hi = bounds(mem)
+		table.AddRow("memory", humanize.IBytes(last.Memory), humanize.IBytes(uint64(lo)), humanize.IBytes(uint64(hi)), Sparkline(mem))
+		lo, hi = bounds(goroutines)
+		table.AddRow("goroutines", strconv.Itoa(last.Goroutines), fmt.Sprint(lo), fmt.Sprint(hi), Sparkline(goroutines))
+
+		names := make([]string, 0, len(last.Series))
+		for name := range last.Series {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) > 0 {
+			table.AddDivider()
+		}
+		for _, name := range names {
+			var values []float64
+			for _, s := range history {
+				if sum, ok := s.Series[name]; ok {
+					values = append(values, sum.Mean)
+				}
+			}
+			lo, hi = bounds(values)
+			table.AddRow(name, number(last.Series[name].Last), number(lo), number(hi), Sparkline(values))
+		}
+
+		addrs := make([]string, 0, len(last.Services))
+		for addr := range last.Services {
+			addrs = append(addrs, addr)
+		}
+		sort.Strings(addrs)
+		if len(addrs) > 0 {
+			table.AddDivider()
+		}
+		for _, addr := range addrs {
+			var errs []float64
+			for _, s := range history {
+				errs = append(errs, float64(s.Services[addr].Errors))
+			}
+			svc := last.Services[addr]
+			state := "stopped"
+			if svc.Running {
+				state = fmt.Sprintf("running x%d", svc.Instances)
+			}
+			lo, hi = bounds(errs)
+			table.AddRow(addr+" errors", strconv.Itoa(svc.Errors), fmt.Sprint(lo), fmt.Sprint(hi), Sparkline(errs)+" "+state)
+		}
+		sess.Log().Println(table.String())
+		return nil
+	})
+	return cmd
+}
+
+func historyCommand() *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "history",
+		Description: "List persisted stats snapshots",
+	})
+	cmd.WithFlags(
+		varflag.UintFunc("limit", 20, "number of most recent snapshots to list"),
+	)
+
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		history, err := loadHistory(sess, int(args.Flag("limit").Var().Uint()))
+		if err != nil {
+			return err
+		}
+		table := textfmt.Table{
+			Title:      "Stats history",
+			WithHeader: true,
+		}
+		table.AddRow("TIME", "CPU", "MEMORY", "GOROUTINES", "SERVICES", "ERRORS")
+		for _, s := range history {
+			var running, errs int
+			for _, svc := range s.Services {
+				if svc.Running {
+					running++
+				}
+				errs += svc.Errors
+			}
+			table.AddRow(
+				s.Time.Format(time.DateTime),
+				percent(s.CPU),
+				humanize.IBytes(s.Memory),
+				strconv.Itoa(s.Goroutines),
+				fmt.Sprintf("%d/%d", running, len(s.Services)),
+				strconv.Itoa(errs),
+			)
+		}
+		sess.Log().Println(table.String())
+		return nil
+	})
+	return cmd
+}
+
+// loadHistory returns up to n most recent snapshots.
+func loadHistory(sess *session.Context, n int) ([]Snapshot, error) {
+	history, err := ReadHistory(HistoryFile(sess))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("%w: no stats history recorded, enable app.stats.enabled", Error)
+	}
+	if n > 0 && len(history) > n {
+		history = history[len(history)-n:]
+	}
+	return history, nil
+}
+
+func bounds(values []float64) (lo, hi float64) {
+	for i, v := range values {
+		if i == 0 || v < lo {
+			lo = v
+		}
+		if i == 0 || v > hi {
+			hi = v
+		}
+	}
+	return lo, hi
+}
+
+func number(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func percent(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64) + "%"
+}
@@ -17,13 +17,16 @@ import (
 	"github.com/happy-sdk/happy/pkg/strings/textfmt"
 	"github.com/happy-sdk/happy/pkg/vars"
 	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/datetime"
 	"github.com/happy-sdk/happy/sdk/internal/fsutils"
 	"github.com/happy-sdk/happy/sdk/services"
 	"github.com/happy-sdk/happy/sdk/services/service"
 )
 
 type Settings struct {
-	Enabled settings.Bool `key:"enabled,save" default:"false" mutation:"once"  desc:"Enable runtime statistics"`
+	Enabled         settings.Bool     `key:"enabled,save" default:"false" mutation:"once"  desc:"Enable runtime statistics"`
+	HistoryInterval settings.Duration `key:"history_interval,save" default:"1m" mutation:"once" desc:"Interval of stats snapshots persisted to history, 0 disables history"`
+	HistorySize     settings.Int      `key:"history_size,save" default:"1440" mutation:"once" desc:"Number of stats snapshots kept in history"`
 }
 
 func (s Settings) Blueprint() (*settings.Blueprint, error) {
@@ -41,12 +44,20 @@ type Profiler struct {
 	db          *vars.Map
 	lastUpdated time.Time
 	tsloc       *time.Location
+	clock       datetime.Clock
+	series      map[string]*Series
+	// snapshotAt is time of previous snapshot.
+	snapshotAt time.Time
 
 	goroutines struct {
 		current int
 		min     int
 		max     int
 	}
+	cpu struct {
+		seconds float64
+		at      time.Time
+	}
 }
 
 func New(title string) *Profiler {
@@ -54,6 +65,7 @@ func New(title string) *Profiler {
 		title: title,
 		db:    new(vars.Map),
 		tsloc: time.UTC,
+		clock: datetime.SystemClock(),
 	}
 }
 
@@ -121,7 +133,13 @@ func (r *Profiler) Update() {
 	_ = r.db.Store("mem.gc.next", humanize.IBytes(mem.NextGC))
 	_ = r.db.Store("mem.gc.num", mem.NumGC)
 	_ = r.db.Store("mem.gc.cpu_fraction", mem.GCCPUFraction)
-	r.lastUpdated = time.Now().In(r.tsloc)
+
+	for name, s := range r.series {
+		if last, ok := s.Last(); ok {
+			_ = r.db.Store("series."+name, last.Value)
+		}
+	}
+	r.lastUpdated = r.clock.Now().In(r.tsloc)
 }
 
 func (r *Profiler) SetTimeLocation(loc *time.Location) {
@@ -130,6 +148,16 @@ func (r *Profiler) SetTimeLocation(loc *time.Location) {
 	r.tsloc = loc
 }
 
+// SetClock sets clock used for update timestamps.
+func (r *Profiler) SetClock(clock datetime.Clock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clock = clock
+	if lc, ok := clock.(*datetime.LocalClock); ok {
+		r.tsloc = lc.Location()
+	}
+}
+
 type State struct {
 	title string
 	time  time.Time
@@ -180,10 +208,18 @@ func (s State) String() string {
 	return tbl.String()
 }
 
-func AsService(prof *Profiler) *services.Service {
+// ServiceName is name of runtime stats service.
+const ServiceName = "app-runtime-stats"
+
+// AsService returns runtime stats service, when historyInterval is
+// greater than zero snapshots are persisted to stats history. Profiler
+// is provided as service API, so that e.g. Tick handlers can record
+// series with services.API[*stats.Profiler](sess, stats.ServiceName).
+func AsService(prof *Profiler, historyInterval time.Duration) *services.Service {
 	svc := services.New(service.Config{
-		Name: "app-runtime-stats",
+		Name: ServiceName,
 	})
+	svc.ProvideAPI(prof)
 
 	svc.Cron(func(schedule services.CronScheduler) {
 		schedule.Job("stats:update-uptime", "@every 5s", func(sess *session.Context) error {
@@ -195,7 +231,7 @@ func AsService(prof *Profiler) *services.Service {
 				if err != nil {
 					return err
 				}
-				uptime := time.Since(staprofed)
+				uptime := sess.Time().Since(staprofed)
 				if err := prof.Set("app.uptime", uptime.String()); err != nil {
 					return err
 				}
@@ -228,6 +264,17 @@ func AsService(prof *Profiler) *services.Service {
 
 			return nil
 		})
+
+		if historyInterval <= 0 {
+			return
+		}
+		schedule.Job("stats:history", "@every "+historyInterval.String(), func(sess *session.Context) error {
+			size := sess.Get("app.stats.history_size").Int()
+			if size <= 0 {
+				return nil
+			}
+			return AppendHistory(HistoryFile(sess), size, prof.Snapshot(sess))
+		})
 	})
 	return svc
 }
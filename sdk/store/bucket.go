@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Bucket provides typed access to values of named bucket, values
+// are stored as JSON documents.
+type Bucket[T any] struct {
+	name string
+}
+
+// NewBucket returns typed bucket with given name, bucket is created
+// when first value is put to it.
+func NewBucket[T any](name string) Bucket[T] {
+	return Bucket[T]{name: name}
+}
+
+// Name returns name of the bucket.
+func (b Bucket[T]) Name() string {
+	return b.name
+}
+
+// Get returns value stored under key, ErrNotFound when there is none.
+func (b Bucket[T]) Get(tx *Tx, key string) (T, error) {
+	var v T
+	data, ok := tx.get(b.name, key)
+	if !ok {
+		return v, fmt.Errorf("%w: %s/%s", ErrNotFound, b.name, key)
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("%w: %s/%s: %s", Error, b.name, key, err.Error())
+	}
+	return v, nil
+}
+
+// Has reports whether value is stored under key.
+func (b Bucket[T]) Has(tx *Tx, key string) bool {
+	_, ok := tx.get(b.name, key)
+	return ok
+}
+
+// Put stores v under key.
+func (b Bucket[T]) Put(tx *Tx, key string, v T) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("%w: %s/%s: %s", Error, b.name, key, err.Error())
+	}
+	return tx.put(b.name, key, data)
+}
+
+// Delete deletes value stored under key, it is not an error when
+// there is none.
+func (b Bucket[T]) Delete(tx *Tx, key string) error {
+	return tx.delete(b.name, key)
+}
+
+// Keys returns sorted keys of the bucket.
+func (b Bucket[T]) Keys(tx *Tx) []string {
+	return tx.keys(b.name)
+}
+
+// Len returns number of values in the bucket.
+func (b Bucket[T]) Len(tx *Tx) int {
+	return len(tx.state.Buckets[b.name])
+}
+
+// Range calls fn for each value in key order until fn returns false.
+func (b Bucket[T]) Range(tx *Tx, fn func(key string, v T) bool) error {
+	for _, key := range tx.keys(b.name) {
+		v, err := b.Get(tx, key)
+		if err != nil {
+			return err
+		}
+		if !fn(key, v) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Find returns values matching match in key order.
+func (b Bucket[T]) Find(tx *Tx, match func(key string, v T) bool) ([]T, error) {
+	var found []T
+	err := b.Range(tx, func(key string, v T) bool {
+		if match(key, v) {
+			found = append(found, v)
+		}
+		return true
+	})
+	return found, err
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package store
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Migration changes store schema to Version.
+type Migration struct {
+	// Version is schema version after migration, it must be positive
+	// and unique.
+	Version int
+	Name    string
+	Up      func(tx *Tx) error
+}
+
+// Migrate applies migrations with version newer than store version in
+// version order, each in own transaction which also records the new
+// version. It returns number of applied migrations, store is left at
+// version of last successful migration on error.
+func (s *Store) Migrate(migrations ...Migration) (applied int, err error) {
+	migrations = append([]Migration(nil), migrations...)
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i, m := range migrations {
+		if m.Version <= 0 || m.Up == nil {
+			return 0, fmt.Errorf("%w: migration %d %s: version must be positive and Up set", Error, m.Version, m.Name)
+		}
+		if i > 0 && migrations[i-1].Version == m.Version {
+			return 0, fmt.Errorf("%w: duplicated migration version %d", Error, m.Version)
+		}
+	}
+
+	for _, m := range migrations {
+		var migrated bool
+		err := s.Update(func(tx *Tx) error {
+			// version is checked within transaction since other process
+			// may have migrated the store meanwhile.
+			if tx.state.Version >= m.Version {
+				return nil
+			}
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			tx.state.Version = m.Version
+			tx.changed = true
+			migrated = true
+			return nil
+		})
+		if err != nil {
+			return applied, fmt.Errorf("%w: migration %d %s: %w", Error, m.Version, m.Name, err)
+		}
+		if migrated {
+			applied++
+		}
+	}
+	return applied, nil
+}
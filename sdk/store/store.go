@@ -0,0 +1,330 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package store provides embedded key/value and document store which
+// keeps durable structured application state in a single file.
+// Values are kept in named buckets as JSON documents, typed access is
+// provided by Bucket. Changes are made in transactions which are
+// committed atomically, so that readers and other processes never see
+// partially written state.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	Error       = errors.New("store")
+	ErrNotFound = fmt.Errorf("%w: not found", Error)
+	ErrClosed   = fmt.Errorf("%w: closed", Error)
+	ErrReadOnly = fmt.Errorf("%w: read-only transaction", Error)
+)
+
+// Options configures Store.
+type Options struct {
+	// Lock is called before write transaction starts and returned
+	// release func after it is committed or rolled back. It guards
+	// store file against concurrent writes of other processes, e.g.
+	// session Lock. Store is guarded only within the process when nil.
+	Lock func() (release func() error, err error)
+}
+
+// Store is embedded state store backed by a file.
+type Store struct {
+	mu     sync.Mutex
+	path   string
+	lock   func() (func() error, error)
+	state  *state
+	mod    time.Time
+	size   int64
+	closed bool
+}
+
+type state struct {
+	// Version is schema version set by migrations.
+	Version int                                   `json:"version"`
+	Buckets map[string]map[string]json.RawMessage `json:"buckets"`
+}
+
+// Open opens store in file at path, file is created on first commit.
+func Open(path string, opts Options) (*Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%w: path is required", Error)
+	}
+	s := &Store{
+		path:  path,
+		lock:  opts.Lock,
+		state: &state{Buckets: make(map[string]map[string]json.RawMessage)},
+	}
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Path returns path of the store file.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Version returns schema version of the store set by Migrate.
+func (s *Store) Version() (int, error) {
+	var version int
+	err := s.View(func(tx *Tx) error {
+		version = tx.state.Version
+		return nil
+	})
+	return version, err
+}
+
+// View calls fn within read-only transaction.
+func (s *Store) View(fn func(tx *Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	return fn(&Tx{state: s.state})
+}
+
+// Update calls fn within read-write transaction, changes are committed
+// when fn returns nil and discarded otherwise.
+func (s *Store) Update(fn func(tx *Tx) error) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if s.lock != nil {
+		release, err := s.lock()
+		if err != nil {
+			return fmt.Errorf("%w: %w", Error, err)
+		}
+		defer func() {
+			if rerr := release(); rerr != nil && err == nil {
+				err = fmt.Errorf("%w: %w", Error, rerr)
+			}
+		}()
+	}
+	// other process may have committed before lock was acquired.
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	// buckets are shared with committed state until they are modified.
+	tx := &Tx{
+		state: &state{
+			Version: s.state.Version,
+			Buckets: make(map[string]map[string]json.RawMessage, len(s.state.Buckets)),
+		},
+		writable: true,
+		copied:   make(map[string]bool),
+	}
+	for name, bucket := range s.state.Buckets {
+		tx.state.Buckets[name] = bucket
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if !tx.changed {
+		return nil
+	}
+	if err := s.write(tx.state); err != nil {
+		return err
+	}
+	s.state = tx.state
+	return nil
+}
+
+// Close closes the store, transactions return ErrClosed afterwards.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// refresh loads the store file when it was changed since last load,
+// caller must hold s.mu.
+func (s *Store) refresh() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if info.ModTime().Equal(s.mod) && info.Size() == s.size {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	st := &state{}
+	if err := json.Unmarshal(data, st); err != nil {
+		return fmt.Errorf("%w: %s is corrupted: %s", Error, s.path, err.Error())
+	}
+	if st.Buckets == nil {
+		st.Buckets = make(map[string]map[string]json.RawMessage)
+	}
+	s.state, s.mod, s.size = st, info.ModTime(), info.Size()
+	return nil
+}
+
+// write writes st to the store file atomically, caller must hold s.mu.
+func (s *Store) write(st *state) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+"-*")
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.mod, s.size = info.ModTime(), info.Size()
+	}
+	return nil
+}
+
+// Tx is store transaction, it must not be used after function it was
+// passed to returns.
+type Tx struct {
+	state    *state
+	writable bool
+	changed  bool
+	// copied are buckets copied on first write in this transaction.
+	copied map[string]bool
+}
+
+// Writable reports whether transaction can make changes.
+func (tx *Tx) Writable() bool {
+	return tx.writable
+}
+
+// Version returns schema version of the store.
+func (tx *Tx) Version() int {
+	return tx.state.Version
+}
+
+// SetVersion sets schema version of the store, it is meant for
+// restoring store from backup, use Migrate to change schema.
+func (tx *Tx) SetVersion(version int) error {
+	if !tx.writable {
+		return ErrReadOnly
+	}
+	if version < 0 {
+		return fmt.Errorf("%w: invalid version %d", Error, version)
+	}
+	tx.state.Version = version
+	tx.changed = true
+	return nil
+}
+
+// Buckets returns sorted names of buckets.
+func (tx *Tx) Buckets() []string {
+	names := make([]string, 0, len(tx.state.Buckets))
+	for name := range tx.state.Buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DeleteBucket deletes bucket with all its values.
+func (tx *Tx) DeleteBucket(name string) error {
+	if !tx.writable {
+		return ErrReadOnly
+	}
+	if _, ok := tx.state.Buckets[name]; !ok {
+		return nil
+	}
+	delete(tx.state.Buckets, name)
+	delete(tx.copied, name)
+	tx.changed = true
+	return nil
+}
+
+func (tx *Tx) get(bucket, key string) (json.RawMessage, bool) {
+	data, ok := tx.state.Buckets[bucket][key]
+	return data, ok
+}
+
+func (tx *Tx) keys(bucket string) []string {
+	keys := make([]string, 0, len(tx.state.Buckets[bucket]))
+	for key := range tx.state.Buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (tx *Tx) put(bucket, key string, data json.RawMessage) error {
+	if !tx.writable {
+		return ErrReadOnly
+	}
+	if bucket == "" || key == "" {
+		return fmt.Errorf("%w: bucket and key are required", Error)
+	}
+	tx.bucket(bucket)[key] = data
+	tx.changed = true
+	return nil
+}
+
+func (tx *Tx) delete(bucket, key string) error {
+	if !tx.writable {
+		return ErrReadOnly
+	}
+	if _, ok := tx.state.Buckets[bucket][key]; !ok {
+		return nil
+	}
+	delete(tx.bucket(bucket), key)
+	tx.changed = true
+	return nil
+}
+
+// bucket returns bucket which can be modified in this transaction,
+// committed bucket is copied so that rollback leaves it intact.
+func (tx *Tx) bucket(name string) map[string]json.RawMessage {
+	if tx.copied[name] {
+		return tx.state.Buckets[name]
+	}
+	current := tx.state.Buckets[name]
+	bucket := make(map[string]json.RawMessage, len(current)+1)
+	for k, v := range current {
+		bucket[k] = v
+	}
+	tx.state.Buckets[name] = bucket
+	tx.copied[name] = true
+	return bucket
+}
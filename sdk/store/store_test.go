@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package store_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/sdk/store"
+)
+
+type task struct {
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+}
+
+var tasks = store.NewBucket[task]("tasks")
+
+func open(t *testing.T, path string) *store.Store {
+	t.Helper()
+	s, err := store.Open(path, store.Options{})
+	if !testutils.NoError(t, err) {
+		t.FailNow()
+	}
+	return s
+}
+
+func TestStoreUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	s := open(t, path)
+	testutils.NoError(t, s.Update(func(tx *store.Tx) error {
+		if err := tasks.Put(tx, "a", task{Title: "first"}); err != nil {
+			return err
+		}
+		return tasks.Put(tx, "b", task{Title: "second", Done: true})
+	}))
+
+	// reopened store must see committed values
+	s = open(t, path)
+	testutils.NoError(t, s.View(func(tx *store.Tx) error {
+		testutils.EqualAny(t, []string{"a", "b"}, tasks.Keys(tx))
+		v, err := tasks.Get(tx, "b")
+		testutils.NoError(t, err)
+		testutils.Equal(t, task{Title: "second", Done: true}, v)
+
+		done, err := tasks.Find(tx, func(key string, v task) bool { return v.Done })
+		testutils.NoError(t, err)
+		testutils.Equal(t, 1, len(done))
+
+		_, err = tasks.Get(tx, "missing")
+		testutils.ErrorIs(t, err, store.ErrNotFound)
+		testutils.ErrorIs(t, tasks.Put(tx, "c", task{}), store.ErrReadOnly)
+		return nil
+	}))
+}
+
+func TestStoreRollback(t *testing.T) {
+	s := open(t, filepath.Join(t.TempDir(), "store.json"))
+	testutils.NoError(t, s.Update(func(tx *store.Tx) error {
+		return tasks.Put(tx, "a", task{Title: "kept"})
+	}))
+
+	errAbort := errors.New("abort")
+	err := s.Update(func(tx *store.Tx) error {
+		if err := tasks.Put(tx, "a", task{Title: "changed"}); err != nil {
+			return err
+		}
+		if err := tasks.Put(tx, "b", task{Title: "added"}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	testutils.ErrorIs(t, err, errAbort)
+
+	testutils.NoError(t, s.View(func(tx *store.Tx) error {
+		v, err := tasks.Get(tx, "a")
+		testutils.NoError(t, err)
+		testutils.Equal(t, "kept", v.Title)
+		testutils.False(t, tasks.Has(tx, "b"), "rolled back value must not be stored")
+		return nil
+	}))
+}
+
+func TestStoreMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	var calls []int
+	migrations := []store.Migration{
+		{Version: 2, Name: "mark done", Up: func(tx *store.Tx) error {
+			calls = append(calls, 2)
+			return tasks.Range(tx, func(key string, v task) bool {
+				v.Done = true
+				return tasks.Put(tx, key, v) == nil
+			})
+		}},
+		{Version: 1, Name: "seed", Up: func(tx *store.Tx) error {
+			calls = append(calls, 1)
+			return tasks.Put(tx, "a", task{Title: "seeded"})
+		}},
+	}
+
+	s := open(t, path)
+	applied, err := s.Migrate(migrations...)
+	testutils.NoError(t, err)
+	testutils.Equal(t, 2, applied)
+	testutils.EqualAny(t, []int{1, 2}, calls)
+
+	s = open(t, path)
+	applied, err = s.Migrate(migrations...)
+	testutils.NoError(t, err)
+	testutils.Equal(t, 0, applied, "migrations must be applied once")
+	version, err := s.Version()
+	testutils.NoError(t, err)
+	testutils.Equal(t, 2, version)
+
+	tests := []struct {
+		name string
+		m    []store.Migration
+	}{
+		{"invalid version", []store.Migration{{Version: 0, Up: func(*store.Tx) error { return nil }}}},
+		{"missing up", []store.Migration{{Version: 3}}},
+		{"duplicated", []store.Migration{
+			{Version: 3, Up: func(*store.Tx) error { return nil }},
+			{Version: 3, Up: func(*store.Tx) error { return nil }},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Migrate(tt.m...)
+			testutils.ErrorIs(t, err, store.Error)
+		})
+	}
+}
+
+func TestStoreClosed(t *testing.T) {
+	s := open(t, filepath.Join(t.TempDir(), "store.json"))
+	testutils.NoError(t, s.Close())
+	testutils.ErrorIs(t, s.View(func(tx *store.Tx) error { return nil }), store.ErrClosed)
+	testutils.ErrorIs(t, s.Update(func(tx *store.Tx) error { return nil }), store.ErrClosed)
+}
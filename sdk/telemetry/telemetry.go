@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package telemetry provides explicit opt-in anonymous usage telemetry.
+// Events recorded with sess.Telemetry().Event are buffered in application
+// cache directory and uploaded in batches to configured endpoint by
+// the telemetry service. Telemetry is off by default, users can opt in
+// with the telemetry command.
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/strings/textfmt"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/cli/command"
+	"github.com/happy-sdk/happy/sdk/config"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+var Error = errors.New("telemetry")
+
+// ServiceName is the name of the telemetry upload service.
+const ServiceName = "app-telemetry"
+
+type Settings struct {
+	Enabled       settings.Bool     `key:"enabled,save" default:"false" mutation:"mutable" desc:"User has opted in to anonymous usage telemetry"`
+	Endpoint      settings.String   `key:"endpoint" default:"" mutation:"once" desc:"Endpoint where batches of telemetry events are posted as JSON array"`
+	BatchSize     settings.Uint     `key:"batch_size" default:"100" mutation:"once" desc:"Maximum number of events uploaded in single request"`
+	FlushInterval settings.Duration `key:"flush_interval" default:"1h" mutation:"once" desc:"Interval between uploads of queued events"`
+	Timeout       settings.Duration `key:"timeout" default:"10s" mutation:"once" desc:"Upload request timeout"`
+}
+
+func (s Settings) Blueprint() (*settings.Blueprint, error) {
+	b, err := settings.New(s)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Enabled reports whether user has opted in and upload endpoint
+// is configured for the application.
+func Enabled(sess *session.Context) bool {
+	return sess.Telemetry().Enabled() && sess.Get("app.telemetry.endpoint").String() != ""
+}
+
+// AsService returns service which uploads queued events every interval
+// and when application stops.
+func AsService(interval time.Duration) *services.Service {
+	svc := services.New(service.Config{
+		Name: ServiceName,
+	})
+
+	var mu sync.Mutex
+	flush := func(sess *session.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := Flush(sess); err != nil {
+			internal.Log(sess.Log(), "telemetry upload failed", slog.String("err", err.Error()))
+		}
+		return nil
+	}
+
+	svc.OnStop(func(sess *session.Context, e error) error {
+		return flush(sess)
+	})
+
+	svc.Cron(func(schedule services.CronScheduler) {
+		schedule.Job("telemetry:flush", fmt.Sprintf("@every %s", interval), flush)
+	})
+	return svc
+}
+
+// Flush uploads queued events in batches. Uploaded events are removed
+// from the queue, events which failed to upload stay queued.
+func Flush(sess *session.Context) error {
+	if !Enabled(sess) {
+		return nil
+	}
+	t := sess.Telemetry()
+	t.Lock()
+	defer t.Unlock()
+
+	events, err := readQueue(t.QueuePath())
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	size := max(int(sess.Get("app.telemetry.batch_size").Uint()), 1)
+	sent := 0
+	for sent < len(events) {
+		end := min(sent+size, len(events))
+		if err := upload(sess, events[sent:end]); err != nil {
+			if werr := writeQueue(t.QueuePath(), events[sent:]); werr != nil {
+				return errors.Join(err, werr)
+			}
+			return err
+		}
+		sent = end
+	}
+	internal.Log(sess.Log(), "telemetry uploaded", slog.Int("events", sent))
+	return writeQueue(t.QueuePath(), nil)
+}
+
+func upload(sess *session.Context, batch []json.RawMessage) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	ctx, cancel := context.WithTimeout(sess, sess.Get("app.telemetry.timeout").Duration())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sess.Get("app.telemetry.endpoint").String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s", sess.Get("app.slug").String(), sess.Get("app.version").String()))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%w: endpoint responded with %s", Error, res.Status)
+	}
+	return nil
+}
+
+func readQueue(p string) ([]json.RawMessage, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer f.Close()
+	var events []json.RawMessage
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || !json.Valid(line) {
+			continue
+		}
+		events = append(events, json.RawMessage(bytes.Clone(line)))
+	}
+	return events, scanner.Err()
+}
+
+func writeQueue(p string, events []json.RawMessage) error {
+	if len(events) == 0 {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %s", Error, err.Error())
+		}
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, ev := range events {
+		buf.Write(ev)
+		buf.WriteByte('\n')
+	}
+	return os.WriteFile(p, buf.Bytes(), 0600)
+}
+
+// Command returns telemetry command with on, off and status subcommands.
+func Command() *command.Command {
+	cmd := command.New(command.Config{
+		Name:             "telemetry",
+		Category:         "Configuration",
+		Description:      "Manage anonymous usage telemetry",
+		Immediate:        true,
+		SkipSharedBefore: true,
+	})
+
+	cmd.AddInfo("Telemetry is off by default. When enabled, anonymous usage events such as executed command names and error types are collected. No arguments, paths or personal data are collected.")
+
+	cmd.WithSubCommands(
+		toggleCommand("on", "Opt in to anonymous usage telemetry", true),
+		toggleCommand("off", "Opt out of usage telemetry and remove queued events", false),
+		statusCommand(),
+	)
+	return cmd
+}
+
+func toggleCommand(name, desc string, enable bool) *command.Command {
+	cmd := command.New(command.Config{
+		Name:        settings.String(name),
+		Description: settings.String(desc),
+	})
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		if err := config.SaveSetting(sess, "app.telemetry.enabled", fmt.Sprint(enable)); err != nil {
+			return err
+		}
+		if err := sess.Settings().Set("app.telemetry.enabled", enable); err != nil {
+			return err
+		}
+		if enable {
+			sess.Log().Ok("telemetry enabled, thank you")
+			return nil
+		}
+		if err := writeQueue(sess.Telemetry().QueuePath(), nil); err != nil {
+			return err
+		}
+		sess.Log().Ok("telemetry disabled")
+		return nil
+	})
+	return cmd
+}
+
+func statusCommand() *command.Command {
+	cmd := command.New(command.Config{
+		Name:        "status",
+		Description: "Show telemetry status",
+	})
+	cmd.Do(func(sess *session.Context, args action.Args) error {
+		events, err := readQueue(sess.Telemetry().QueuePath())
+		if err != nil {
+			return err
+		}
+		endpoint := sess.Get("app.telemetry.endpoint").String()
+		if endpoint == "" {
+			endpoint = "-"
+		}
+		table := textfmt.Table{Title: "Telemetry"}
+		table.AddRow("enabled", fmt.Sprint(sess.Telemetry().Enabled()))
+		table.AddRow("endpoint", endpoint)
+		table.AddRow("queued events", fmt.Sprint(len(events)))
+		table.AddRow("queue", sess.Telemetry().QueuePath())
+		sess.Log().Println(table.String())
+		return nil
+	})
+	return cmd
+}
@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+)
+
+func TestQueue(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "queue.jsonl")
+
+	events, err := readQueue(p)
+	testutils.NoError(t, err)
+	testutils.Equal(t, 0, len(events), "missing queue")
+
+	data := []byte("{\"name\":\"a\"}\n\ninvalid\n{\"name\":\"b\"}\n")
+	testutils.NoError(t, os.WriteFile(p, data, 0600))
+	events, err = readQueue(p)
+	testutils.NoError(t, err)
+	testutils.Equal(t, 2, len(events), "invalid lines are skipped")
+
+	testutils.NoError(t, writeQueue(p, events[1:]))
+	events, err = readQueue(p)
+	testutils.NoError(t, err)
+	testutils.Equal(t, 1, len(events), "rewritten queue")
+	testutils.Equal(t, `{"name":"b"}`, string(events[0]), "remaining event")
+
+	testutils.NoError(t, writeQueue(p, nil))
+	_, err = os.Stat(p)
+	testutils.Equal(t, true, os.IsNotExist(err), "empty queue is removed")
+}
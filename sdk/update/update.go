@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package update provides lightweight background check for newer
+// application versions. It is not a self-updater, it only notifies
+// user and exposes the result through session options
+// app.update.available and app.update.latest.
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/happy-sdk/happy/pkg/options"
+	"github.com/happy-sdk/happy/pkg/settings"
+	"github.com/happy-sdk/happy/pkg/version"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/internal"
+	"github.com/happy-sdk/happy/sdk/services"
+	"github.com/happy-sdk/happy/sdk/services/service"
+)
+
+var Error = errors.New("update")
+
+const (
+	// ServiceName is the name of the update check service.
+	ServiceName = "app-update-check"
+	cacheFile   = "update-check.json"
+	// noticeInterval is minimum interval between update notices.
+	noticeInterval = 24 * time.Hour
+)
+
+type Settings struct {
+	Disabled      settings.Bool     `key:"disabled,save" default:"false" mutation:"mutable" desc:"Disable application update checks"`
+	URL           settings.String   `key:"url" default:"" mutation:"once" desc:"Version endpoint returning latest version as plain text or json {\"version\": \"v1.2.3\", \"url\": \"...\"}"`
+	CheckInterval settings.Duration `key:"check_interval,save" default:"24h" mutation:"mutable" desc:"Minimum interval between update checks"`
+	Timeout       settings.Duration `key:"timeout" default:"10s" mutation:"once" desc:"Version endpoint request timeout"`
+}
+
+func (s Settings) Blueprint() (*settings.Blueprint, error) {
+	b, err := settings.New(s)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Enabled reports whether update check is not disabled and
+// version endpoint is configured for the application.
+func Enabled(sess *session.Context) bool {
+	return !sess.Get("app.update.disabled").Bool() && sess.Get("app.update.url").String() != ""
+}
+
+// Result is the cached result of the last update check.
+type Result struct {
+	Current    string    `json:"current"`
+	Latest     string    `json:"latest"`
+	URL        string    `json:"url,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+	NotifiedAt time.Time `json:"notified_at,omitempty"`
+}
+
+// Available reports whether latest version is newer than current.
+func (r Result) Available() bool {
+	if !version.IsValid(r.Current) || !version.IsValid(r.Latest) {
+		return false
+	}
+	return version.Compare(r.Latest, r.Current) > 0
+}
+
+// AsService returns service which checks for updates on start and
+// then hourly, contacting version endpoint at most once per check interval.
+func AsService() *services.Service {
+	svc := services.New(service.Config{
+		Name: ServiceName,
+	})
+
+	var mu sync.Mutex
+	check := func(sess *session.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return Check(sess, false)
+	}
+
+	svc.OnStart(func(sess *session.Context) error {
+		go func() {
+			if err := check(sess); err != nil {
+				internal.Log(sess.Log(), "update check failed", slog.String("err", err.Error()))
+			}
+		}()
+		return nil
+	})
+
+	svc.Cron(func(schedule services.CronScheduler) {
+		schedule.Job("update:check", "@every 1h", check)
+	})
+	return svc
+}
+
+// Check checks for updates and updates app.update.* options. Cached result is used
+// unless it is older than check interval or force is true.
+func Check(sess *session.Context, force bool) error {
+	if !Enabled(sess) {
+		return nil
+	}
+	res, err := loadCache(sess)
+	current := sess.Get("app.version").String()
+	if err != nil || force || res.Current != current ||
+		sess.Time().Since(res.CheckedAt) >= sess.Get("app.update.check_interval").Duration() {
+		latest, url, err := fetch(sess)
+		if err != nil {
+			return err
+		}
+		res.Latest = latest
+		res.URL = url
+		res.CheckedAt = sess.Time().Now()
+	}
+	res.Current = current
+
+	if err := sess.Opts().Set("app.update.latest", res.Latest); err != nil {
+		return err
+	}
+	if err := sess.Opts().Set("app.update.available", res.Available()); err != nil {
+		return err
+	}
+
+	if res.Available() && sess.Time().Since(res.NotifiedAt) >= noticeInterval {
+		attrs := []slog.Attr{
+			slog.String("current", res.Current),
+			slog.String("latest", res.Latest),
+		}
+		if res.URL != "" {
+			attrs = append(attrs, slog.String("url", res.URL))
+		}
+		sess.Log().Notice("new version available", attrs...)
+		res.NotifiedAt = sess.Time().Now()
+	}
+	return saveCache(sess, res)
+}
+
+func fetch(sess *session.Context) (version, url string, err error) {
+	endpoint := sess.Get("app.update.url").String()
+	internal.Log(sess.Log(), "checking for updates", slog.String("url", endpoint))
+
+	ctx, cancel := context.WithTimeout(sess, options.GetAsOr(sess, "app.update.timeout", 10*time.Second))
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	req.Header.Set("Accept", "application/json, text/plain")
+	info := sess.BuildInfo()
+	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s (%s/%s; %s)", sess.Get("app.slug").String(), info.Version, info.OS, info.Arch, info.GoVersion))
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("%w: version endpoint responded with %s", Error, res.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, 64<<10))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return parse(body)
+}
+
+func parse(body []byte) (ver, url string, err error) {
+	body = []byte(strings.TrimSpace(string(body)))
+	if len(body) > 0 && body[0] == '{' {
+		var data struct {
+			Version string `json:"version"`
+			URL     string `json:"url"`
+		}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return "", "", fmt.Errorf("%w: invalid version response: %s", Error, err.Error())
+		}
+		ver, url = data.Version, data.URL
+	} else {
+		ver = string(body)
+	}
+	if !version.IsValid(ver) {
+		return "", "", fmt.Errorf("%w: invalid version %q", Error, ver)
+	}
+	return ver, url, nil
+}
+
+func cachePath(sess *session.Context) (string, error) {
+	dir, err := options.GetAs[string](sess, "app.fs.path.cache")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return filepath.Join(dir, cacheFile), nil
+}
+
+func loadCache(sess *session.Context) (Result, error) {
+	var res Result
+	file, err := cachePath(sess)
+	if err != nil {
+		return res, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return res, err
+	}
+	err = json.Unmarshal(data, &res)
+	return res, err
+}
+
+func saveCache(sess *session.Context, res Result) error {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	file, err := cachePath(sess)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package update_test
+
+import (
+	"testing"
+
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/sdk/update"
+)
+
+func TestResultAvailable(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            bool
+	}{
+		{"v1.0.0", "v1.0.1", true},
+		{"1.0.0", "1.1.0", true},
+		{"v1.1.0", "v1.0.9", false},
+		{"v1.0.0", "v1.0.0", false},
+		{"v1.0.0-devel", "v1.0.0", true},
+		{"v1.0.0", "", false},
+		{"invalid", "v1.0.0", false},
+	}
+	for _, tt := range tests {
+		res := update.Result{Current: tt.current, Latest: tt.latest}
+		testutils.Equal(t, tt.want, res.Available(), tt.current+" -> "+tt.latest)
+	}
+}
@@ -0,0 +1,372 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+// Package vcr provides HTTP transport which records interactions to
+// cassette files and replays them, so that commands calling external
+// APIs can be tested offline. Interactions are recorded to cache
+// directory of the application with Path, tests replay them with
+// ForTest.
+//
+//	cassette, err := vcr.Load(vcr.Path(sess, "github"), vcr.ModeAuto)
+//	client := vcr.Client(cassette, nil)
+//	...
+//	err = cassette.Save()
+package vcr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/happy-sdk/happy/sdk/app/session"
+)
+
+var (
+	Error          = errors.New("vcr")
+	ErrNotRecorded = fmt.Errorf("%w: interaction not recorded", Error)
+)
+
+// Mode controls whether cassette replays or records interactions.
+type Mode int
+
+const (
+	// ModeReplay replays recorded interactions, requests which were
+	// not recorded fail with ErrNotRecorded.
+	ModeReplay Mode = iota
+	// ModeRecord sends all requests and records them, interactions
+	// recorded before are replaced.
+	ModeRecord
+	// ModeAuto replays recorded interactions and records others.
+	ModeAuto
+)
+
+// ParseMode parses mode name replay, record or auto.
+func ParseMode(s string) (Mode, error) {
+	switch s {
+	case "", "replay":
+		return ModeReplay, nil
+	case "record":
+		return ModeRecord, nil
+	case "auto":
+		return ModeAuto, nil
+	}
+	return ModeReplay, fmt.Errorf("%w: unknown mode %q", Error, s)
+}
+
+// redacted are headers which are not recorded by default.
+var redacted = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Interaction is recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Response is recorded response.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Body is recorded body, it is written as string when it is valid
+// UTF-8 and base64 encoded otherwise.
+type Body []byte
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = Body(s)
+		return nil
+	}
+	var enc map[string]string
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return err
+	}
+	raw, err := base64.StdEncoding.DecodeString(enc["base64"])
+	if err != nil {
+		return err
+	}
+	*b = raw
+	return nil
+}
+
+// Cassette is set of interactions stored in file.
+type Cassette struct {
+	mu           sync.Mutex
+	path         string
+	mode         Mode
+	interactions []Interaction
+	used         []bool
+	changed      bool
+
+	// Match reports whether recorded request matches req with body,
+	// method and URL have to be equal by default.
+	Match func(req *http.Request, body []byte, rec Request) bool
+	// Redact are names of headers which are not recorded in addition
+	// to Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+	Redact []string
+}
+
+// Load returns cassette stored in file at path, cassette is empty when
+// file does not exist or mode is ModeRecord.
+func Load(path string, mode Mode) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode}
+	if mode == ModeRecord {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := json.Unmarshal(data, &c.interactions); err != nil {
+		return nil, fmt.Errorf("%w: corrupted cassette %s: %s", Error, path, err.Error())
+	}
+	c.used = make([]bool, len(c.interactions))
+	return c, nil
+}
+
+// Path returns path of cassette name in cache directory of the
+// application.
+func Path(sess *session.Context, name string) string {
+	return filepath.Join(sess.Get("app.fs.path.cache").String(), "vcr", name+".json")
+}
+
+// Interactions returns recorded interactions.
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Interaction(nil), c.interactions...)
+}
+
+// Save writes cassette to its file when interactions were recorded.
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.changed {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	c.changed = false
+	return nil
+}
+
+// Transport returns transport which replays interactions of c and
+// records requests sent with base according to mode of c.
+// http.DefaultTransport is used when base is nil.
+func Transport(c *Cassette, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{cassette: c, base: base}
+}
+
+// Client returns copy of client which requests are recorded and
+// replayed by c. http.DefaultClient is used when client is nil.
+func Client(c *Cassette, client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	cl := *client
+	cl.Transport = Transport(c, client.Transport)
+	return &cl
+}
+
+type transport struct {
+	cassette *Cassette
+	base     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", Error, err.Error())
+		}
+	}
+
+	c := t.cassette
+	if c.mode != ModeRecord {
+		if rec, ok := c.find(req, body); ok {
+			return rec.Response.response(req), nil
+		}
+		if c.mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, req.URL.String())
+		}
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+	res, err := t.base.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", Error, err.Error())
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	c.record(Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: c.redact(req.Header),
+			Body:   body,
+		},
+		Response: Response{
+			Status: res.StatusCode,
+			Header: c.redact(res.Header),
+			Body:   resBody,
+		},
+	})
+	return res, nil
+}
+
+// find returns first unused interaction matching req, when all
+// matching interactions were used last of them is returned again.
+func (c *Cassette) find(req *http.Request, body []byte) (Interaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	match := c.Match
+	if match == nil {
+		match = func(req *http.Request, _ []byte, rec Request) bool {
+			return req.Method == rec.Method && req.URL.String() == rec.URL
+		}
+	}
+	last := -1
+	for i, rec := range c.interactions {
+		if !match(req, body, rec.Request) {
+			continue
+		}
+		if !c.used[i] {
+			c.used[i] = true
+			return rec, true
+		}
+		last = i
+	}
+	if last >= 0 {
+		return c.interactions[last], true
+	}
+	return Interaction{}, false
+}
+
+func (c *Cassette) record(i Interaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interactions = append(c.interactions, i)
+	c.used = append(c.used, true)
+	c.changed = true
+}
+
+// redact returns copy of h without redacted headers.
+func (c *Cassette) redact(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range redacted {
+		out.Del(name)
+	}
+	for _, name := range c.Redact {
+		out.Del(name)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// response returns recorded response to req.
+func (r Response) response(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(r.Status) + " " + http.StatusText(r.Status),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// TB is subset of testing.TB used by ForTest.
+type TB interface {
+	Helper()
+	Cleanup(func())
+	Fatalf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+// ForTest returns cassette stored in file at path for test t. Mode is
+// read from HAPPY_VCR environment variable and defaults to replay, so
+// that tests run offline, set it to record or auto to record missing
+// interactions. Recorded interactions are saved when test completes.
+func ForTest(t TB, path string) *Cassette {
+	t.Helper()
+	mode, err := ParseMode(strings.TrimSpace(os.Getenv("HAPPY_VCR")))
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	c, err := Load(path, mode)
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	t.Cleanup(func() {
+		if err := c.Save(); err != nil {
+			t.Errorf("%s", err.Error())
+		}
+	})
+	return c
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright © 2024 The Happy Authors
+
+package vcr_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/happy-sdk/happy"
+	"github.com/happy-sdk/happy/pkg/devel/testutils"
+	"github.com/happy-sdk/happy/sdk/action"
+	"github.com/happy-sdk/happy/sdk/app"
+	"github.com/happy-sdk/happy/sdk/app/session"
+	"github.com/happy-sdk/happy/sdk/logging"
+	"github.com/happy-sdk/happy/sdk/vcr"
+)
+
+func get(t *testing.T, client *http.Client, method, url, body string) (int, string, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	testutils.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	testutils.NoError(t, err)
+	return res.StatusCode, string(data), nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Call", "1")
+		if r.URL.Path == "/binary" {
+			_, _ = w.Write([]byte{0xff, 0x00, 0xfe})
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + " " + string(body)))
+	}))
+	path := filepath.Join(t.TempDir(), "vcr", "cassette.json")
+
+	rec, err := vcr.Load(path, vcr.ModeAuto)
+	testutils.NoError(t, err)
+	client := vcr.Client(rec, nil)
+	status, body, err := get(t, client, http.MethodPost, srv.URL+"/a", "hello")
+	testutils.NoError(t, err)
+	testutils.Equal(t, http.StatusCreated, status)
+	testutils.Equal(t, "POST /a hello", body)
+	_, body, err = get(t, client, http.MethodGet, srv.URL+"/binary", "")
+	testutils.NoError(t, err)
+	testutils.Equal(t, "\xff\x00\xfe", body)
+	testutils.NoError(t, rec.Save())
+	testutils.Equal(t, 2, calls)
+
+	data, err := os.ReadFile(path)
+	testutils.NoError(t, err)
+	testutils.False(t, strings.Contains(string(data), "secret"), "credentials must not be recorded")
+	srv.Close()
+
+	replay, err := vcr.Load(path, vcr.ModeReplay)
+	testutils.NoError(t, err)
+	testutils.Equal(t, 2, len(replay.Interactions()))
+	client = vcr.Client(replay, nil)
+	for range 2 {
+		status, body, err = get(t, client, http.MethodPost, srv.URL+"/a", "hello")
+		testutils.NoError(t, err)
+		testutils.Equal(t, http.StatusCreated, status)
+		testutils.Equal(t, "POST /a hello", body)
+	}
+	_, body, err = get(t, client, http.MethodGet, srv.URL+"/binary", "")
+	testutils.NoError(t, err)
+	testutils.Equal(t, "\xff\x00\xfe", body)
+	_, _, err = get(t, client, http.MethodGet, srv.URL+"/b", "")
+	testutils.ErrorIs(t, err, vcr.ErrNotRecorded)
+	testutils.Equal(t, 2, calls)
+}
+
+func TestForTest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	t.Setenv("HAPPY_VCR", "record")
+	t.Run("record", func(t *testing.T) {
+		client := vcr.Client(vcr.ForTest(t, path), nil)
+		_, body, err := get(t, client, http.MethodGet, srv.URL, "")
+		testutils.NoError(t, err)
+		testutils.Equal(t, "ok", body)
+	})
+	t.Setenv("HAPPY_VCR", "")
+	t.Run("replay", func(t *testing.T) {
+		c := vcr.ForTest(t, path)
+		testutils.Equal(t, 1, len(c.Interactions()))
+	})
+}
+
+func TestPath(t *testing.T) {
+	main := app.New(happy.Settings{Slug: "happy-vcr-test"})
+	main.WithLogger(logging.NewTestLogger(logging.LevelError))
+	main.Do(func(sess *session.Context, args action.Args) error {
+		testutils.Equal(t,
+			filepath.Join(sess.Get("app.fs.path.cache").String(), "vcr", "api.json"),
+			vcr.Path(sess, "api"))
+		return nil
+	})
+	testutils.NoError(t, main.RunCtx(context.Background()))
+}